@@ -0,0 +1,193 @@
+package animation
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+)
+
+type ChannelT int
+
+const (
+	UNKNOWN_CHANNEL ChannelT = iota
+	TRANSLATION
+	ROTATION
+	SCALE
+)
+
+func (c ChannelT) String() string {
+	switch c {
+	case TRANSLATION:
+		return "translation"
+	case ROTATION:
+		return "rotation"
+	case SCALE:
+		return "scale"
+	}
+	return "unknown"
+}
+
+func StringToChannelT(s string) ChannelT {
+	switch strings.ToLower(s) {
+	case "translation", "translate":
+		return TRANSLATION
+	case "rotation", "rotate":
+		return ROTATION
+	case "scale":
+		return SCALE
+	}
+	return UNKNOWN_CHANNEL
+}
+
+type Interpolation int
+
+const (
+	LINEAR Interpolation = iota
+	STEP
+)
+
+func StringToInterpolation(s string) Interpolation {
+	switch strings.ToLower(s) {
+	case "step":
+		return STEP
+	}
+	return LINEAR
+}
+
+// Channel is a keyframed track of one joint transform. Values hold 3 floats
+// per key for translation and scale, 4 (w, x, y, z) for rotation.
+type Channel struct {
+	Joint  int
+	Kind   ChannelT
+	Interp Interpolation
+	Times  []float32
+	Values []float32
+}
+
+func NewChannel(joint int, kind ChannelT, interp Interpolation, times, values []float32) *Channel {
+	return &Channel{joint, kind, interp, times, values}
+}
+
+func (c *Channel) width() int {
+	if c.Kind == ROTATION {
+		return 4
+	}
+	return 3
+}
+
+func (c *Channel) key(i int) []float32 {
+	w := c.width()
+	return c.Values[i*w : i*w+w]
+}
+
+func (c *Channel) valid() bool {
+	return len(c.Times) > 0 && len(c.Values) >= len(c.Times)*c.width()
+}
+
+// Sample writes the channel value at time t into out.
+func (c *Channel) Sample(t float32, out []float32) {
+	if !c.valid() {
+		return
+	}
+	n := len(c.Times)
+	switch {
+	case t <= c.Times[0]:
+		copy(out, c.key(0))
+		return
+	case t >= c.Times[n-1]:
+		copy(out, c.key(n-1))
+		return
+	}
+
+	b := sort.Search(n, func(i int) bool { return c.Times[i] > t })
+	a := b - 1
+	if c.Interp == STEP {
+		copy(out, c.key(a))
+		return
+	}
+
+	f := (t - c.Times[a]) / (c.Times[b] - c.Times[a])
+	ka, kb := c.key(a), c.key(b)
+	if c.Kind == ROTATION {
+		q := math.QuatUnp(ka...)
+		q.Slerp(math.QuatUnp(kb...), f)
+		copy(out, q.Raw())
+		return
+	}
+	for i := range ka {
+		out[i] = ka[i] + (kb[i]-ka[i])*f
+	}
+}
+
+// Event is a named marker at a time in a clip.
+type Event struct {
+	Time float32
+	Name string
+}
+
+// Clip is a set of channels animating the joints of a skeleton.
+type Clip struct {
+	Name     string
+	Duration float32
+	Channels []*Channel
+	Events   []Event
+}
+
+func NewClip(name string) *Clip {
+	return &Clip{
+		Name:     name,
+		Channels: make([]*Channel, 0),
+		Events:   make([]Event, 0),
+	}
+}
+
+// AddChannel adds channels, extending the clip duration to the last key.
+func (c *Clip) AddChannel(chs ...*Channel) {
+	for _, ch := range chs {
+		c.Channels = append(c.Channels, ch)
+		if n := len(ch.Times); n > 0 && ch.Times[n-1] > c.Duration {
+			c.Duration = ch.Times[n-1]
+		}
+	}
+}
+
+func (c *Clip) AddEvent(t float32, name string) {
+	c.Events = append(c.Events, Event{t, name})
+	sort.SliceStable(c.Events, func(i, j int) bool {
+		return c.Events[i].Time < c.Events[j].Time
+	})
+}
+
+// Apply samples every channel at time t into the pose.
+func (c *Clip) Apply(t float32, p *Pose) {
+	for _, ch := range c.Channels {
+		if ch.Joint < 0 || ch.Joint >= p.Len() {
+			continue
+		}
+		switch ch.Kind {
+		case TRANSLATION:
+			ch.Sample(t, p.T[ch.Joint*3:ch.Joint*3+3])
+		case ROTATION:
+			ch.Sample(t, p.R[ch.Joint*4:ch.Joint*4+4])
+		case SCALE:
+			ch.Sample(t, p.S[ch.Joint*3:ch.Joint*3+3])
+		}
+	}
+}
+
+// events returns the events in (from, to], to being less than from when the
+// range wraps around the end of the clip.
+func (c *Clip) events(from, to float32) []Event {
+	ret := make([]Event, 0)
+	if from > to {
+		ret = append(ret, c.events(from, c.Duration)...)
+		from = -1
+	}
+	for _, e := range c.Events {
+		if e.Time > from && e.Time <= to {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
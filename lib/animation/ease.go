@@ -0,0 +1,172 @@
+package animation
+
+import (
+	"math"
+	"strings"
+)
+
+// Ease maps linear progress in [0, 1] to eased progress.
+type Ease func(float32) float32
+
+func Linear(t float32) float32 {
+	return t
+}
+
+func QuadIn(t float32) float32 {
+	return t * t
+}
+
+func QuadOut(t float32) float32 {
+	return t * (2 - t)
+}
+
+func QuadInOut(t float32) float32 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return -1 + (4-2*t)*t
+}
+
+func CubicIn(t float32) float32 {
+	return t * t * t
+}
+
+func CubicOut(t float32) float32 {
+	t = t - 1
+	return t*t*t + 1
+}
+
+func CubicInOut(t float32) float32 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	t = 2*t - 2
+	return 0.5*t*t*t + 1
+}
+
+func SineIn(t float32) float32 {
+	return 1 - float32(math.Cos(float64(t)*math.Pi/2))
+}
+
+func SineOut(t float32) float32 {
+	return float32(math.Sin(float64(t) * math.Pi / 2))
+}
+
+func SineInOut(t float32) float32 {
+	return -0.5 * (float32(math.Cos(math.Pi*float64(t))) - 1)
+}
+
+func ExpoIn(t float32) float32 {
+	if t == 0 {
+		return 0
+	}
+	return float32(math.Pow(2, 10*float64(t-1)))
+}
+
+func ExpoOut(t float32) float32 {
+	if t == 1 {
+		return 1
+	}
+	return 1 - float32(math.Pow(2, -10*float64(t)))
+}
+
+func ExpoInOut(t float32) float32 {
+	if t < 0.5 {
+		return ExpoIn(2*t) / 2
+	}
+	return 0.5 + ExpoOut(2*t-1)/2
+}
+
+const backOvershoot = 1.70158
+
+func BackIn(t float32) float32 {
+	return t * t * ((backOvershoot+1)*t - backOvershoot)
+}
+
+func BackOut(t float32) float32 {
+	return 1 - BackIn(1-t)
+}
+
+func BackInOut(t float32) float32 {
+	if t < 0.5 {
+		return BackIn(2*t) / 2
+	}
+	return 0.5 + BackOut(2*t-1)/2
+}
+
+func ElasticIn(t float32) float32 {
+	return 1 - ElasticOut(1-t)
+}
+
+func ElasticOut(t float32) float32 {
+	if t == 0 || t == 1 {
+		return t
+	}
+	return float32(math.Pow(2, -10*float64(t))*math.Sin((float64(t)-0.075)*(2*math.Pi)/0.3)) + 1
+}
+
+func ElasticInOut(t float32) float32 {
+	if t < 0.5 {
+		return ElasticIn(2*t) / 2
+	}
+	return 0.5 + ElasticOut(2*t-1)/2
+}
+
+func BounceOut(t float32) float32 {
+	switch {
+	case t < 1/2.75:
+		return 7.5625 * t * t
+	case t < 2/2.75:
+		t -= 1.5 / 2.75
+		return 7.5625*t*t + 0.75
+	case t < 2.5/2.75:
+		t -= 2.25 / 2.75
+		return 7.5625*t*t + 0.9375
+	}
+	t -= 2.625 / 2.75
+	return 7.5625*t*t + 0.984375
+}
+
+func BounceIn(t float32) float32 {
+	return 1 - BounceOut(1-t)
+}
+
+func BounceInOut(t float32) float32 {
+	if t < 0.5 {
+		return BounceIn(2*t) / 2
+	}
+	return 0.5 + BounceOut(2*t-1)/2
+}
+
+var Eases = map[string]Ease{
+	"linear":         Linear,
+	"quad_in":        QuadIn,
+	"quad_out":       QuadOut,
+	"quad_in_out":    QuadInOut,
+	"cubic_in":       CubicIn,
+	"cubic_out":      CubicOut,
+	"cubic_in_out":   CubicInOut,
+	"sine_in":        SineIn,
+	"sine_out":       SineOut,
+	"sine_in_out":    SineInOut,
+	"expo_in":        ExpoIn,
+	"expo_out":       ExpoOut,
+	"expo_in_out":    ExpoInOut,
+	"back_in":        BackIn,
+	"back_out":       BackOut,
+	"back_in_out":    BackInOut,
+	"elastic_in":     ElasticIn,
+	"elastic_out":    ElasticOut,
+	"elastic_in_out": ElasticInOut,
+	"bounce_in":      BounceIn,
+	"bounce_out":     BounceOut,
+	"bounce_in_out":  BounceInOut,
+}
+
+// StringToEase returns the named easing curve, linear for unknown names.
+func StringToEase(s string) Ease {
+	if e, ok := Eases[strings.ToLower(s)]; ok {
+		return e
+	}
+	return Linear
+}
@@ -0,0 +1,598 @@
+package animation
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+// floats reads a list of numbers from a table, or the raw values of a math
+// userdata.
+func floats(L *l.LState, v l.LValue) []float32 {
+	switch vv := v.(type) {
+	case l.LNumber:
+		return []float32{float32(vv)}
+	case *l.LTable:
+		ret := make([]float32, 0, vv.Len())
+		for i := 1; i <= vv.Len(); i++ {
+			ret = append(ret, float32(l.LVAsNumber(vv.RawGetInt(i))))
+		}
+		return ret
+	case *l.LUserData:
+		switch m := vv.Value.(type) {
+		case math.Vector:
+			return m.Raw()
+		case math.Quaternion:
+			return m.Raw()
+		case math.Color:
+			return m.Raw()
+		}
+	case *l.LNilType:
+		return nil
+	}
+	L.RaiseError("%v is not a list of numbers", v)
+	return nil
+}
+
+const lSkeletonClass = "SKELETON"
+
+// shv.skeleton{{name = "hip", translate = {0, 1, 0}}, {name = "knee", parent = "hip"}}
+func lSkeleton(L *l.LState) int {
+	t := L.CheckTable(1)
+	s := NewSkeleton()
+	for i := 1; i <= t.Len(); i++ {
+		jt, ok := t.RawGetInt(i).(*l.LTable)
+		if !ok {
+			L.ArgError(1, "joint tables expected")
+			return 0
+		}
+		name := jt.RawGetString("name").String()
+		parent := -1
+		if p := jt.RawGetString("parent"); p != l.LNil {
+			if parent = s.Index(p.String()); parent < 0 {
+				L.RaiseError("joint %s parent %s does not exist", name, p.String())
+				return 0
+			}
+		}
+		_, err := s.AddJoint(
+			name,
+			parent,
+			floats(L, jt.RawGetString("translate")),
+			floats(L, jt.RawGetString("rotate")),
+			floats(L, jt.RawGetString("scale")),
+		)
+		if err != nil {
+			L.RaiseError(err.Error())
+			return 0
+		}
+	}
+	s.Bind()
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = s }, lSkeletonClass)
+	return 1
+}
+
+func checkSkeleton(L *l.LState, pos int) *Skeleton {
+	ud := L.CheckUserData(pos)
+	if s, ok := ud.Value.(*Skeleton); ok {
+		return s
+	}
+	L.ArgError(pos, "skeleton expected")
+	return nil
+}
+
+type skeletonMemberFunc func(*l.LState, *Skeleton) int
+
+func skeletonMember(fn skeletonMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if s := checkSkeleton(L, 1); s != nil {
+			return fn(L, s)
+		}
+		return 0
+	}
+}
+
+func getSkeletonCount(L *l.LState, s *Skeleton) int {
+	L.Push(l.LNumber(s.Len()))
+	return 1
+}
+
+func skeletonIndex(L *l.LState, s *Skeleton) int {
+	L.Push(l.LNumber(s.Index(L.CheckString(2))))
+	return 1
+}
+
+var skeletonTable = &lua.Table{
+	lSkeletonClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+	},
+	map[string]l.LGFunction{
+		"count": lua.NewProperty(skeletonMember(getSkeletonCount), nil),
+	},
+	map[string]l.LGFunction{
+		"index": skeletonMember(skeletonIndex),
+	},
+}
+
+const lClipClass = "CLIP"
+
+// shv.clip(skeleton, {name = "walk", channels = {{joint = "hip", kind = "rotation",
+// times = {0, 1}, values = {1, 0, 0, 0, 0, 0, 1, 0}}}, events = {{time = 0.5, name = "step"}}})
+func lClip(L *l.LState) int {
+	s := checkSkeleton(L, 1)
+	t := L.CheckTable(2)
+	c := NewClip(t.RawGetString("name").String())
+
+	if chs, ok := t.RawGetString("channels").(*l.LTable); ok {
+		for i := 1; i <= chs.Len(); i++ {
+			ct, ok := chs.RawGetInt(i).(*l.LTable)
+			if !ok {
+				L.ArgError(2, "channel tables expected")
+				return 0
+			}
+			joint := ct.RawGetString("joint").String()
+			ji := s.Index(joint)
+			if ji < 0 {
+				L.RaiseError("clip %s joint %s does not exist", c.Name, joint)
+				return 0
+			}
+			kind := StringToChannelT(ct.RawGetString("kind").String())
+			if kind == UNKNOWN_CHANNEL {
+				L.RaiseError("clip %s channel kind must be translation, rotation or scale", c.Name)
+				return 0
+			}
+			c.AddChannel(NewChannel(
+				ji,
+				kind,
+				StringToInterpolation(ct.RawGetString("interpolation").String()),
+				floats(L, ct.RawGetString("times")),
+				floats(L, ct.RawGetString("values")),
+			))
+		}
+	}
+
+	if evs, ok := t.RawGetString("events").(*l.LTable); ok {
+		for i := 1; i <= evs.Len(); i++ {
+			if et, ok := evs.RawGetInt(i).(*l.LTable); ok {
+				c.AddEvent(float32(l.LVAsNumber(et.RawGetString("time"))), et.RawGetString("name").String())
+			}
+		}
+	}
+
+	if d := t.RawGetString("duration"); d != l.LNil {
+		c.Duration = float32(l.LVAsNumber(d))
+	}
+
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = c }, lClipClass)
+	return 1
+}
+
+func checkClip(L *l.LState, pos int) *Clip {
+	ud := L.CheckUserData(pos)
+	if c, ok := ud.Value.(*Clip); ok {
+		return c
+	}
+	L.ArgError(pos, "clip expected")
+	return nil
+}
+
+type clipMemberFunc func(*l.LState, *Clip) int
+
+func clipMember(fn clipMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if c := checkClip(L, 1); c != nil {
+			return fn(L, c)
+		}
+		return 0
+	}
+}
+
+func getClipName(L *l.LState, c *Clip) int {
+	L.Push(l.LString(c.Name))
+	return 1
+}
+
+func getClipDuration(L *l.LState, c *Clip) int {
+	L.Push(l.LNumber(c.Duration))
+	return 1
+}
+
+func clipEvent(L *l.LState, c *Clip) int {
+	c.AddEvent(float32(L.CheckNumber(2)), L.CheckString(3))
+	return 0
+}
+
+var clipTable = &lua.Table{
+	lClipClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+	},
+	map[string]l.LGFunction{
+		"name":     lua.NewProperty(clipMember(getClipName), nil),
+		"duration": lua.NewProperty(clipMember(getClipDuration), nil),
+	},
+	map[string]l.LGFunction{
+		"event": clipMember(clipEvent),
+	},
+}
+
+const lMixerClass = "MIXER"
+
+func lMixer(L *l.LState) int {
+	m := NewMixer(checkSkeleton(L, 1))
+	CurrentAnimationSystem.Add(m)
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = m }, lMixerClass)
+	return 1
+}
+
+func checkMixer(L *l.LState, pos int) *Mixer {
+	ud := L.CheckUserData(pos)
+	if m, ok := ud.Value.(*Mixer); ok {
+		return m
+	}
+	L.ArgError(pos, "mixer expected")
+	return nil
+}
+
+type mixerMemberFunc func(*l.LState, *Mixer) int
+
+func mixerMember(fn mixerMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if m := checkMixer(L, 1); m != nil {
+			return fn(L, m)
+		}
+		return 0
+	}
+}
+
+// mixer:play(clip[, {fade = 0.3, weight = 1, speed = 1, loop = true, layer = 0, additive = false}])
+func mixerPlay(L *l.LState, m *Mixer) int {
+	c := checkClip(L, 2)
+	a := m.Prepare(c)
+	var fade float32
+	weight := float32(1)
+	if opts, ok := L.Get(3).(*l.LTable); ok {
+		opts.ForEach(func(k, v l.LValue) {
+			switch k.String() {
+			case "fade":
+				fade = float32(l.LVAsNumber(v))
+			case "weight":
+				weight = float32(l.LVAsNumber(v))
+			case "speed":
+				a.Speed = float32(l.LVAsNumber(v))
+			case "loop":
+				a.Loop = l.LVAsBool(v)
+			case "layer":
+				a.Layer = int(l.LVAsNumber(v))
+			case "additive":
+				a.Additive = l.LVAsBool(v)
+			}
+		})
+	}
+	m.Play(c, fade)
+	a.Fade(weight, fade)
+	return 0
+}
+
+func mixerStop(L *l.LState, m *Mixer) int {
+	c := checkClip(L, 2)
+	m.Stop(c, float32(L.OptNumber(3, 0)))
+	return 0
+}
+
+func mixerAction(L *l.LState, m *Mixer) *Action {
+	c := checkClip(L, 2)
+	if a := m.Action(c); a != nil {
+		return a
+	}
+	return m.Prepare(c)
+}
+
+// mixer:weight(clip[, weight[, fade]])
+func mixerWeight(L *l.LState, m *Mixer) int {
+	a := mixerAction(L, m)
+	if L.GetTop() >= 3 {
+		a.Fade(float32(L.CheckNumber(3)), float32(L.OptNumber(4, 0)))
+		return 0
+	}
+	L.Push(l.LNumber(a.Weight()))
+	return 1
+}
+
+func mixerSpeed(L *l.LState, m *Mixer) int {
+	a := mixerAction(L, m)
+	if L.GetTop() >= 3 {
+		a.Speed = float32(L.CheckNumber(3))
+		return 0
+	}
+	L.Push(l.LNumber(a.Speed))
+	return 1
+}
+
+func mixerTime(L *l.LState, m *Mixer) int {
+	a := mixerAction(L, m)
+	if L.GetTop() >= 3 {
+		a.SetTime(float32(L.CheckNumber(3)))
+		return 0
+	}
+	L.Push(l.LNumber(a.Time()))
+	return 1
+}
+
+func mixerPlaying(L *l.LState, m *Mixer) int {
+	c := checkClip(L, 2)
+	a := m.Action(c)
+	L.Push(l.LBool(a != nil && a.Playing()))
+	return 1
+}
+
+// mixer:on(function(clip_name, event_name) end)
+func mixerOn(L *l.LState, m *Mixer) int {
+	fn := L.CheckFunction(2)
+	m.OnEvent(func(a *Action, e Event) error {
+		return L.CallByParam(l.P{
+			Fn:      fn,
+			NRet:    0,
+			Protect: true,
+		}, l.LString(a.Clip().Name), l.LString(e.Name))
+	})
+	return 0
+}
+
+func mixerRemove(L *l.LState, m *Mixer) int {
+	CurrentAnimationSystem.Remove(m.ID())
+	return 0
+}
+
+var mixerTable = &lua.Table{
+	lMixerClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+	},
+	map[string]l.LGFunction{},
+	map[string]l.LGFunction{
+		"play":    mixerMember(mixerPlay),
+		"stop":    mixerMember(mixerStop),
+		"weight":  mixerMember(mixerWeight),
+		"speed":   mixerMember(mixerSpeed),
+		"time":    mixerMember(mixerTime),
+		"playing": mixerMember(mixerPlaying),
+		"on":      mixerMember(mixerOn),
+		"remove":  mixerMember(mixerRemove),
+	},
+}
+
+const lTweenClass = "TWEEN"
+
+// tweenOptions applies an options table to a tweener, returning the duration
+// and easing given for a property tween.
+func tweenOptions(L *l.LState, w *Tweener, opts *l.LTable) (float32, Ease) {
+	duration := float32(1)
+	ease := Ease(Linear)
+	if opts == nil {
+		return duration, ease
+	}
+	opts.ForEach(func(k, v l.LValue) {
+		switch k.String() {
+		case "duration":
+			duration = float32(l.LVAsNumber(v))
+		case "ease":
+			ease = StringToEase(v.String())
+		case "delay":
+			w.Delay = float32(l.LVAsNumber(v))
+		case "speed":
+			w.Speed = float32(l.LVAsNumber(v))
+		case "loop":
+			switch lv := v.(type) {
+			case l.LBool:
+				if lv {
+					w.Loops = -1
+				}
+			default:
+				w.Loops = int(l.LVAsNumber(v))
+			}
+		case "pingpong":
+			w.PingPong = l.LVAsBool(v)
+		case "on_complete":
+			if fn, ok := v.(*l.LFunction); ok {
+				w.OnComplete(tweenCallback(L, fn))
+			}
+		}
+	})
+	return duration, ease
+}
+
+func tweenCallback(L *l.LState, fn *l.LFunction) func() error {
+	return func() error {
+		return L.CallByParam(l.P{
+			Fn:      fn,
+			NRet:    0,
+			Protect: true,
+		})
+	}
+}
+
+// shv.tween(node, "translate", {0, 1, 0}, {duration = 2, ease = "quad_in_out",
+// delay = 0, loop = 2 | true, pingpong = false, from = {0, 0, 0}, on_complete = fn})
+func lTween(L *l.LState) int {
+	ud := L.CheckUserData(1)
+	name := L.CheckString(2)
+	to := floats(L, L.CheckAny(3))
+	opts := L.OptTable(4, nil)
+
+	t, ok := ud.Value.(Tweenable)
+	if !ok {
+		L.ArgError(1, "tweenable expected")
+		return 0
+	}
+	p := t.TweenProperty(name)
+	if p == nil {
+		L.RaiseError(UnknownPropertyError(name).Error())
+		return 0
+	}
+
+	w := NewTweener(nil)
+	duration, ease := tweenOptions(L, w, opts)
+	tw := To(p, to, duration, ease)
+	if opts != nil {
+		if from := opts.RawGetString("from"); from != l.LNil {
+			tw.From(floats(L, from))
+		}
+	}
+	w.t = tw
+	return pushTweener(L, w)
+}
+
+func tweenList(L *l.LState, pos int) []Tween {
+	t := L.CheckTable(pos)
+	ret := make([]Tween, 0, t.Len())
+	for i := 1; i <= t.Len(); i++ {
+		ud, ok := t.RawGetInt(i).(*l.LUserData)
+		if !ok {
+			L.ArgError(pos, "list of tweens expected")
+			return nil
+		}
+		w, ok := ud.Value.(*Tweener)
+		if !ok {
+			L.ArgError(pos, "list of tweens expected")
+			return nil
+		}
+		ct, err := w.Composed()
+		if err != nil {
+			L.ArgError(pos, err.Error())
+			return nil
+		}
+		ret = append(ret, ct)
+	}
+	return ret
+}
+
+// shv.sequence({a, b}[, opts]) and shv.parallel({a, b}[, opts]); tweens
+// looping forever cannot be composed, loop the composition instead
+func composeTweens(fn func(...Tween) Tween) l.LGFunction {
+	return func(L *l.LState) int {
+		ts := tweenList(L, 1)
+		w := NewTweener(fn(ts...))
+		tweenOptions(L, w, L.OptTable(2, nil))
+		return pushTweener(L, w)
+	}
+}
+
+// shv.wait(seconds)
+func lWait(L *l.LState) int {
+	return pushTweener(L, NewTweener(Wait(float32(L.CheckNumber(1)))))
+}
+
+func pushTweener(L *l.LState, w *Tweener) int {
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = w }, lTweenClass)
+	return 1
+}
+
+func checkTweener(L *l.LState, pos int) *Tweener {
+	ud := L.CheckUserData(pos)
+	if w, ok := ud.Value.(*Tweener); ok {
+		return w
+	}
+	L.ArgError(pos, "tween expected")
+	return nil
+}
+
+type tweenerMemberFunc func(*l.LState, *Tweener) int
+
+func tweenerMember(fn tweenerMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if w := checkTweener(L, 1); w != nil {
+			return fn(L, w)
+		}
+		return 0
+	}
+}
+
+func tweenerPlay(L *l.LState, w *Tweener) int {
+	w.Play()
+	CurrentAnimationSystem.AddTweener(w)
+	L.Push(L.Get(1))
+	return 1
+}
+
+func tweenerPause(L *l.LState, w *Tweener) int {
+	w.Pause()
+	return 0
+}
+
+func tweenerStop(L *l.LState, w *Tweener) int {
+	w.Stop()
+	return 0
+}
+
+func tweenerOnComplete(L *l.LState, w *Tweener) int {
+	w.OnComplete(tweenCallback(L, L.CheckFunction(2)))
+	return 0
+}
+
+func getTweenerPlaying(L *l.LState, w *Tweener) int {
+	L.Push(l.LBool(w.Playing()))
+	return 1
+}
+
+func getTweenerDone(L *l.LState, w *Tweener) int {
+	L.Push(l.LBool(w.Done()))
+	return 1
+}
+
+func getTweenerDuration(L *l.LState, w *Tweener) int {
+	L.Push(l.LNumber(w.Tween().Duration()))
+	return 1
+}
+
+var tweenTable = &lua.Table{
+	lTweenClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+	},
+	map[string]l.LGFunction{
+		"playing":  lua.NewProperty(tweenerMember(getTweenerPlaying), nil),
+		"done":     lua.NewProperty(tweenerMember(getTweenerDone), nil),
+		"duration": lua.NewProperty(tweenerMember(getTweenerDuration), nil),
+	},
+	map[string]l.LGFunction{
+		"play":        tweenerMember(tweenerPlay),
+		"pause":       tweenerMember(tweenerPause),
+		"stop":        tweenerMember(tweenerStop),
+		"on_complete": tweenerMember(tweenerOnComplete),
+	},
+}
+
+func CheckMixer(L *l.LState, pos int) *Mixer {
+	return checkMixer(L, pos)
+}
+
+func PushMixer(L *l.LState, m *Mixer) int {
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = m }, lMixerClass)
+	return 1
+}
+
+func RegisterWith() lua.RegisterWith {
+	return func(m lua.Module) error {
+		m.AddLGFunc("skeleton", lSkeleton)
+		m.AddLGFunc("clip", lClip)
+		m.AddLGFunc("mixer", lMixer)
+		m.AddLGFunc("tween", lTween)
+		m.AddLGFunc("sequence", composeTweens(Sequence))
+		m.AddLGFunc("parallel", composeTweens(Parallel))
+		m.AddLGFunc("wait", lWait)
+		rmtfn := func(L *l.LState, M lua.Module) {
+			M.Register(L, skeletonTable)
+			M.Register(L, clipTable)
+			M.Register(L, mixerTable)
+			M.Register(L, tweenTable)
+		}
+		m.AddMT(rmtfn)
+		return nil
+	}
+}
@@ -0,0 +1,287 @@
+package animation
+
+import (
+	"sort"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/ecs"
+)
+
+// Action is the playback state of a clip in a mixer.
+type Action struct {
+	clip     *Clip
+	time     float32
+	started  bool
+	Speed    float32
+	Loop     bool
+	Layer    int
+	Additive bool
+	weight   float32
+	target   float32
+	rate     float32
+	playing  bool
+	stopping bool
+}
+
+func newAction(c *Clip) *Action {
+	return &Action{
+		clip:  c,
+		Speed: 1,
+		Loop:  true,
+	}
+}
+
+func (a *Action) Clip() *Clip {
+	return a.clip
+}
+
+func (a *Action) Time() float32 {
+	return a.time
+}
+
+func (a *Action) SetTime(t float32) {
+	a.time = t
+}
+
+func (a *Action) Weight() float32 {
+	return a.weight
+}
+
+// SetWeight sets the weight immediately, cancelling any fade.
+func (a *Action) SetWeight(w float32) {
+	a.weight = w
+	a.target = w
+	a.rate = 0
+}
+
+// Fade moves the weight to w over duration seconds.
+func (a *Action) Fade(w, duration float32) {
+	a.target = w
+	if duration <= 0 {
+		a.weight = w
+		a.rate = 0
+		return
+	}
+	a.rate = (w - a.weight) / duration
+}
+
+func (a *Action) Playing() bool {
+	return a.playing && !a.stopping
+}
+
+func (a *Action) advance(dt float32) []Event {
+	if !a.playing {
+		return nil
+	}
+	from := a.time
+	if !a.started {
+		from = -1
+		a.started = true
+	}
+	a.time += dt * a.Speed
+
+	d := a.clip.Duration
+	if d > 0 {
+		switch {
+		case a.Loop:
+			for a.time > d {
+				a.time -= d
+			}
+			for a.time < 0 {
+				a.time += d
+			}
+		case a.time > d:
+			a.time = d
+		case a.time < 0:
+			a.time = 0
+		}
+	}
+
+	if a.rate != 0 {
+		a.weight += a.rate * dt
+		if (a.rate > 0 && a.weight >= a.target) || (a.rate < 0 && a.weight <= a.target) {
+			a.weight = a.target
+			a.rate = 0
+		}
+	}
+
+	if from == a.time {
+		return nil
+	}
+	return a.clip.events(from, a.time)
+}
+
+func (a *Action) done() bool {
+	return a.stopping && a.weight <= 0
+}
+
+// EventFunc is called for each clip event an action passes during update.
+type EventFunc func(*Action, Event) error
+
+// Mixer plays and blends clips over a skeleton. Layer 0 actions cross fade
+// into each other, higher layers are blended on top in order, additive
+// actions apply their difference from the rest pose.
+type Mixer struct {
+	ecs.Entity
+	s        *Skeleton
+	actions  []*Action
+	rest     *Pose
+	pose     *Pose
+	scratch  *Pose
+	matrices []float32
+	on       []EventFunc
+}
+
+func NewMixer(s *Skeleton) *Mixer {
+	m := &Mixer{
+		Entity:  ecs.NewEntity(),
+		s:       s,
+		actions: make([]*Action, 0),
+		rest:    s.RestPose(),
+		pose:    s.RestPose(),
+		scratch: NewPose(s.Len()),
+		on:      make([]EventFunc, 0),
+	}
+	m.matrices = s.Matrices(m.pose, nil)
+	return m
+}
+
+func (m *Mixer) Skeleton() *Skeleton {
+	return m.s
+}
+
+// Action returns the action for the clip, nil if it is not in the mixer.
+func (m *Mixer) Action(c *Clip) *Action {
+	for _, a := range m.actions {
+		if a.clip == c {
+			return a
+		}
+	}
+	return nil
+}
+
+func (m *Mixer) Actions() []*Action {
+	return m.actions
+}
+
+// Prepare returns the action for the clip, adding it stopped if it is not in
+// the mixer, so it can be set up before playing.
+func (m *Mixer) Prepare(c *Clip) *Action {
+	a := m.Action(c)
+	if a == nil {
+		a = newAction(c)
+		m.actions = append(m.actions, a)
+	}
+	return a
+}
+
+// Play starts the clip, fading it in over fade seconds. Starting a base layer
+// clip fades out the other base layer clips over the same time.
+func (m *Mixer) Play(c *Clip, fade float32) *Action {
+	a := m.Prepare(c)
+	a.playing = true
+	a.stopping = false
+	a.Fade(1, fade)
+	if !a.Additive && a.Layer == 0 {
+		m.crossFade(a, fade)
+	}
+	return a
+}
+
+func (m *Mixer) crossFade(to *Action, fade float32) {
+	for _, a := range m.actions {
+		if a != to && a.playing && !a.Additive && a.Layer == 0 {
+			a.stopping = true
+			a.Fade(0, fade)
+		}
+	}
+}
+
+// Stop fades the clip out over fade seconds, removing it when silent.
+func (m *Mixer) Stop(c *Clip, fade float32) {
+	if a := m.Action(c); a != nil {
+		a.stopping = true
+		a.Fade(0, fade)
+	}
+}
+
+func (m *Mixer) StopAll() {
+	m.actions = m.actions[:0]
+}
+
+func (m *Mixer) OnEvent(fn ...EventFunc) {
+	m.on = append(m.on, fn...)
+}
+
+func (m *Mixer) Pose() *Pose {
+	return m.pose
+}
+
+// Matrices returns the skinning matrices of the current pose, 16 floats per
+// joint.
+func (m *Mixer) Matrices() []float32 {
+	return m.matrices
+}
+
+func (m *Mixer) sample(a *Action) *Pose {
+	m.scratch.Copy(m.rest)
+	a.clip.Apply(a.time, m.scratch)
+	return m.scratch
+}
+
+// Update advances every action by dt seconds, fires passed events and
+// recalculates the pose.
+func (m *Mixer) Update(dt float32) error {
+	var err error
+	live := m.actions[:0]
+	for _, a := range m.actions {
+		for _, e := range a.advance(dt) {
+			for _, fn := range m.on {
+				if ferr := fn(a, e); ferr != nil && err == nil {
+					err = ferr
+				}
+			}
+		}
+		if !a.done() {
+			live = append(live, a)
+		}
+	}
+	m.actions = live
+
+	sort.SliceStable(m.actions, func(i, j int) bool {
+		return m.actions[i].Layer < m.actions[j].Layer
+	})
+
+	m.pose.Copy(m.rest)
+	base := make([]*Action, 0)
+	var total float32
+	for _, a := range m.actions {
+		if a.Layer == 0 && !a.Additive && a.weight > 0 {
+			base = append(base, a)
+			total += a.weight
+		}
+	}
+	// normalized blend of the base layer, the rest pose taking up any
+	// remaining weight
+	var acc float32
+	if total < 1 {
+		acc = 1 - total
+	}
+	for _, a := range base {
+		acc += a.weight
+		m.pose.Blend(m.sample(a), a.weight/acc)
+	}
+
+	for _, a := range m.actions {
+		if a.weight <= 0 || (a.Layer == 0 && !a.Additive) {
+			continue
+		}
+		if a.Additive {
+			m.pose.Additive(m.sample(a), m.rest, a.weight)
+		} else {
+			m.pose.Blend(m.sample(a), a.weight)
+		}
+	}
+
+	m.matrices = m.s.Matrices(m.pose, m.matrices)
+	return err
+}
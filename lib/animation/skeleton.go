@@ -0,0 +1,187 @@
+package animation
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// Joint is a bone of a skeleton, with its rest transform relative to its
+// parent.
+type Joint struct {
+	Name        string
+	Parent      int
+	Translation [3]float32
+	Rotation    [4]float32
+	Scale       [3]float32
+	InverseBind math.Matrice
+}
+
+// Skeleton is a list of joints, parents always preceding their children.
+type Skeleton struct {
+	Joints []*Joint
+}
+
+func NewSkeleton() *Skeleton {
+	return &Skeleton{
+		make([]*Joint, 0),
+	}
+}
+
+var JointParentError = xrror.Xrror("joint %s parent %d must be added before it").Out
+
+// AddJoint adds a joint with a rest transform, parent -1 for a root. Any of
+// the transform slices may be nil for identity.
+func (s *Skeleton) AddJoint(name string, parent int, t, r, sc []float32) (*Joint, error) {
+	if parent >= len(s.Joints) {
+		return nil, JointParentError(name, parent)
+	}
+	j := &Joint{
+		Name:     name,
+		Parent:   parent,
+		Rotation: [4]float32{1, 0, 0, 0},
+		Scale:    [3]float32{1, 1, 1},
+	}
+	copy(j.Translation[:], t)
+	copy(j.Rotation[:], r)
+	copy(j.Scale[:], sc)
+	s.Joints = append(s.Joints, j)
+	return j, nil
+}
+
+func (s *Skeleton) Len() int {
+	return len(s.Joints)
+}
+
+// Index returns the index of the named joint, or -1.
+func (s *Skeleton) Index(name string) int {
+	for i, j := range s.Joints {
+		if j.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// RestPose returns a pose holding the rest transform of every joint.
+func (s *Skeleton) RestPose() *Pose {
+	p := NewPose(s.Len())
+	for i, j := range s.Joints {
+		copy(p.T[i*3:], j.Translation[:])
+		copy(p.R[i*4:], j.Rotation[:])
+		copy(p.S[i*3:], j.Scale[:])
+	}
+	return p
+}
+
+func (s *Skeleton) world(p *Pose) []math.Matrice {
+	world := make([]math.Matrice, s.Len())
+	for i, j := range s.Joints {
+		local := math.Mat4().Compose(p.T[i*3:i*3+3], p.R[i*4:i*4+4], p.S[i*3:i*3+3])
+		if j.Parent >= 0 {
+			world[i] = math.MultiplyMatrices(world[j.Parent], local)
+		} else {
+			world[i] = local
+		}
+	}
+	return world
+}
+
+// Bind sets the inverse bind matrix of every joint from the rest pose.
+func (s *Skeleton) Bind() {
+	for i, w := range s.world(s.RestPose()) {
+		inv := w.Inverse()
+		if inv == nil {
+			inv = math.IdentityMatrix(math.MAT4)
+		}
+		s.Joints[i].InverseBind = inv
+	}
+}
+
+// Matrices writes the skinning matrix of every joint for the pose into out,
+// 16 floats per joint, growing out as needed.
+func (s *Skeleton) Matrices(p *Pose, out []float32) []float32 {
+	n := s.Len() * 16
+	if cap(out) < n {
+		out = make([]float32, n)
+	}
+	out = out[:n]
+	for i, w := range s.world(p) {
+		m := w
+		if ib := s.Joints[i].InverseBind; ib != nil {
+			m = math.MultiplyMatrices(w, ib)
+		}
+		copy(out[i*16:], m.Raw())
+	}
+	return out
+}
+
+// Pose is the local translation, rotation(w, x, y, z) and scale of every joint
+// of a skeleton.
+type Pose struct {
+	T, R, S []float32
+}
+
+func NewPose(joints int) *Pose {
+	p := &Pose{
+		make([]float32, joints*3),
+		make([]float32, joints*4),
+		make([]float32, joints*3),
+	}
+	for i := 0; i < joints; i++ {
+		p.R[i*4] = 1
+		p.S[i*3], p.S[i*3+1], p.S[i*3+2] = 1, 1, 1
+	}
+	return p
+}
+
+func (p *Pose) Len() int {
+	return len(p.T) / 3
+}
+
+func (p *Pose) Copy(o *Pose) {
+	copy(p.T, o.T)
+	copy(p.R, o.R)
+	copy(p.S, o.S)
+}
+
+func lerp(a, b []float32, w float32) {
+	for i := range a {
+		a[i] = a[i] + (b[i]-a[i])*w
+	}
+}
+
+// Blend moves the pose towards o by w.
+func (p *Pose) Blend(o *Pose, w float32) {
+	if w <= 0 {
+		return
+	}
+	lerp(p.T, o.T, w)
+	lerp(p.S, o.S, w)
+	for i := 0; i < p.Len(); i++ {
+		q := math.QuatUnp(p.R[i*4 : i*4+4]...)
+		q.Slerp(math.QuatUnp(o.R[i*4:i*4+4]...), w)
+		copy(p.R[i*4:], q.Raw())
+	}
+}
+
+// Additive applies the difference between o and ref on top of the pose,
+// scaled by w.
+func (p *Pose) Additive(o, ref *Pose, w float32) {
+	if w <= 0 {
+		return
+	}
+	for i := range p.T {
+		p.T[i] += (o.T[i] - ref.T[i]) * w
+	}
+	for i := range p.S {
+		if ref.S[i] != 0 {
+			p.S[i] *= 1 + (o.S[i]/ref.S[i]-1)*w
+		}
+	}
+	for i := 0; i < p.Len(); i++ {
+		delta := math.QuatUnp(ref.R[i*4 : i*4+4]...).Inverse().Mul(math.QuatUnp(o.R[i*4 : i*4+4]...))
+		d := math.Quat(1, 0, 0, 0).Slerp(delta, w)
+		r := math.QuatUnp(p.R[i*4 : i*4+4]...).Mul(d).Normalize()
+		copy(p.R[i*4:], r.Raw())
+	}
+}
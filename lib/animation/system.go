@@ -0,0 +1,100 @@
+package animation
+
+var CurrentAnimationSystem *animationSystem
+
+type animationSystem struct {
+	mixers   []*Mixer
+	tweeners []*Tweener
+}
+
+func (a *animationSystem) Priority() int {
+	return 6
+}
+
+// Update advances every tweener and mixer by the frame delta, in
+// nanoseconds. Tweeners no longer playing are dropped.
+func (a *animationSystem) Update(d int64) error {
+	dt := float32(d) / 1e9
+	var err error
+	// callbacks may play other tweeners, adding to the list
+	for _, w := range append([]*Tweener(nil), a.tweeners...) {
+		if werr := w.Update(dt); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	playing := make([]*Tweener, 0, len(a.tweeners))
+	for _, w := range a.tweeners {
+		if w.Playing() {
+			playing = append(playing, w)
+		}
+	}
+	a.tweeners = playing
+	for _, m := range a.mixers {
+		if merr := m.Update(dt); merr != nil && err == nil {
+			err = merr
+		}
+	}
+	return err
+}
+
+// Count is the number of mixers and of tweeners playing.
+func (a *animationSystem) Count() (int, int) {
+	return len(a.mixers), len(a.tweeners)
+}
+
+func (a *animationSystem) Add(ms ...*Mixer) {
+	for _, m := range ms {
+		if !a.has(m) {
+			a.mixers = append(a.mixers, m)
+		}
+	}
+}
+
+func (a *animationSystem) has(m *Mixer) bool {
+	for _, h := range a.mixers {
+		if h == m {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTweener adds tweeners to be updated while they play.
+func (a *animationSystem) AddTweener(ws ...*Tweener) {
+	for _, w := range ws {
+		if !a.hasTweener(w) {
+			a.tweeners = append(a.tweeners, w)
+		}
+	}
+}
+
+func (a *animationSystem) hasTweener(w *Tweener) bool {
+	for _, h := range a.tweeners {
+		if h == w {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *animationSystem) Remove(id uint64) {
+	for idx, m := range a.mixers {
+		if m.ID() == id {
+			a.mixers = append(a.mixers[:idx], a.mixers[idx+1:]...)
+			return
+		}
+	}
+	for idx, w := range a.tweeners {
+		if w.ID() == id {
+			a.tweeners = append(a.tweeners[:idx], a.tweeners[idx+1:]...)
+			return
+		}
+	}
+}
+
+func init() {
+	CurrentAnimationSystem = &animationSystem{
+		make([]*Mixer, 0),
+		make([]*Tweener, 0),
+	}
+}
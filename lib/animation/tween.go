@@ -0,0 +1,390 @@
+package animation
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/ecs"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// Property is an animatable value read and written as floats. Rotation
+// properties hold a quaternion(w, x, y, z) and are slerped.
+type Property interface {
+	Get() []float32
+	Set(...float32)
+	Rotation() bool
+}
+
+type property struct {
+	get      func() []float32
+	set      func(...float32)
+	rotation bool
+}
+
+func NewProperty(get func() []float32, set func(...float32), rotation bool) Property {
+	return &property{get, set, rotation}
+}
+
+func (p *property) Get() []float32 {
+	return p.get()
+}
+
+func (p *property) Set(v ...float32) {
+	p.set(v...)
+}
+
+func (p *property) Rotation() bool {
+	return p.rotation
+}
+
+// Tweenable is anything exposing named properties to tween, nil for an
+// unknown name.
+type Tweenable interface {
+	TweenProperty(string) Property
+}
+
+var (
+	UnknownPropertyError = xrror.Xrror("%s is not a tweenable property").Out
+	ComposeLoopError     = xrror.Xrror("a tween looping forever has no end to compose")
+)
+
+// Tween is a seekable animation of known duration in seconds. Start is
+// called once before seeking, when the tween is first reached.
+type Tween interface {
+	Duration() float32
+	Start()
+	Seek(float32)
+}
+
+type tween struct {
+	p         Property
+	from, to  []float32
+	fixedFrom bool
+	duration  float32
+	ease      Ease
+	cur       []float32
+}
+
+// To tweens the property from its value at start to the given value.
+func To(p Property, to []float32, duration float32, e Ease) *tween {
+	if e == nil {
+		e = Linear
+	}
+	return &tween{
+		p:        p,
+		to:       to,
+		duration: duration,
+		ease:     e,
+	}
+}
+
+// From sets a fixed start value instead of the value at start.
+func (t *tween) From(v []float32) *tween {
+	t.from = v
+	t.fixedFrom = v != nil
+	return t
+}
+
+func (t *tween) Duration() float32 {
+	return t.duration
+}
+
+func (t *tween) Start() {
+	if !t.fixedFrom {
+		t.from = append(t.from[:0], t.p.Get()...)
+	}
+	n := len(t.from)
+	if len(t.to) < n {
+		n = len(t.to)
+	}
+	t.cur = make([]float32, n)
+}
+
+func (t *tween) Seek(at float32) {
+	f := float32(1)
+	if t.duration > 0 {
+		f = clamp(at/t.duration, 0, 1)
+	}
+	e := t.ease(f)
+	if t.p.Rotation() && len(t.cur) == 4 {
+		q := math.QuatUnp(t.from[:4]...)
+		q.Slerp(math.QuatUnp(t.to[:4]...), e)
+		copy(t.cur, q.Raw())
+	} else {
+		for i := range t.cur {
+			t.cur[i] = t.from[i] + (t.to[i]-t.from[i])*e
+		}
+	}
+	t.p.Set(t.cur...)
+}
+
+func clamp(v, lo, hi float32) float32 {
+	switch {
+	case v < lo:
+		return lo
+	case v > hi:
+		return hi
+	}
+	return v
+}
+
+type wait struct {
+	d float32
+}
+
+// Wait is an empty tween lasting d seconds, for spacing sequences.
+func Wait(d float32) Tween {
+	return &wait{d}
+}
+
+func (w *wait) Duration() float32 { return w.d }
+func (w *wait) Start()            {}
+func (w *wait) Seek(float32)      {}
+
+type sequence struct {
+	ts      []Tween
+	started []bool
+	cur     int
+}
+
+// Sequence plays tweens one after another, each starting from where the
+// previous left its property.
+func Sequence(ts ...Tween) Tween {
+	return &sequence{ts, make([]bool, len(ts)), 0}
+}
+
+func (s *sequence) Duration() float32 {
+	var d float32
+	for _, t := range s.ts {
+		d += t.Duration()
+	}
+	return d
+}
+
+func (s *sequence) Start() {
+	for i := range s.started {
+		s.started[i] = false
+	}
+	s.cur = 0
+	if len(s.ts) > 0 {
+		s.ts[0].Start()
+		s.started[0] = true
+	}
+}
+
+func (s *sequence) Seek(at float32) {
+	if len(s.ts) == 0 {
+		return
+	}
+	var off float32
+	k := 0
+	for k < len(s.ts)-1 && at > off+s.ts[k].Duration() {
+		off += s.ts[k].Duration()
+		k++
+	}
+	for s.cur < k {
+		s.ts[s.cur].Seek(s.ts[s.cur].Duration())
+		s.cur++
+		if !s.started[s.cur] {
+			s.ts[s.cur].Start()
+			s.started[s.cur] = true
+		}
+	}
+	for s.cur > k {
+		s.ts[s.cur].Seek(0)
+		s.cur--
+	}
+	s.ts[k].Seek(at - off)
+}
+
+type parallel struct {
+	ts []Tween
+}
+
+// Parallel plays tweens together, lasting as long as the longest.
+func Parallel(ts ...Tween) Tween {
+	return &parallel{ts}
+}
+
+func (p *parallel) Duration() float32 {
+	var d float32
+	for _, t := range p.ts {
+		if td := t.Duration(); td > d {
+			d = td
+		}
+	}
+	return d
+}
+
+func (p *parallel) Start() {
+	for _, t := range p.ts {
+		t.Start()
+	}
+}
+
+func (p *parallel) Seek(at float32) {
+	for _, t := range p.ts {
+		if d := t.Duration(); at > d {
+			t.Seek(d)
+			continue
+		}
+		t.Seek(at)
+	}
+}
+
+type repeat struct {
+	t        Tween
+	loops    int
+	pingpong bool
+}
+
+// Repeat plays a tween loops more times, reversing every other time with
+// pingpong.
+func Repeat(t Tween, loops int, pingpong bool) Tween {
+	if loops < 0 {
+		loops = 0
+	}
+	return &repeat{t, loops, pingpong}
+}
+
+func (r *repeat) Duration() float32 {
+	return r.t.Duration() * float32(r.loops+1)
+}
+
+func (r *repeat) Start() {
+	r.t.Start()
+}
+
+func (r *repeat) Seek(at float32) {
+	local, _ := cycle(at, r.t.Duration(), r.loops, r.pingpong)
+	r.t.Seek(local)
+}
+
+// cycle maps time t onto a tween of duration d repeated loops more times,
+// forever for loops < 0, and reports when the repetitions are over.
+func cycle(t, d float32, loops int, pingpong bool) (float32, bool) {
+	if d <= 0 {
+		return 0, true
+	}
+	n := int(t / d)
+	local := t - float32(n)*d
+	done := false
+	if loops >= 0 && n > loops {
+		n, local, done = loops, d, true
+	}
+	if pingpong && n%2 == 1 {
+		local = d - local
+	}
+	return local, done
+}
+
+// Tweener plays a tween over time, with a start delay, looping, ping pong and
+// completion callbacks.
+type Tweener struct {
+	ecs.Entity
+	t        Tween
+	Delay    float32
+	Loops    int
+	PingPong bool
+	Speed    float32
+	elapsed  float32
+	started  bool
+	playing  bool
+	done     bool
+	complete []func() error
+}
+
+func NewTweener(t Tween) *Tweener {
+	return &Tweener{
+		Entity:   ecs.NewEntity(),
+		t:        t,
+		Speed:    1,
+		complete: make([]func() error, 0),
+	}
+}
+
+func (w *Tweener) Tween() Tween {
+	return w.t
+}
+
+// Composed returns the tween with its delay and loops applied, for use
+// inside sequences and parallel groups, an error when it loops forever.
+func (w *Tweener) Composed() (Tween, error) {
+	t := w.t
+	switch {
+	case w.Loops < 0:
+		return nil, ComposeLoopError
+	case w.Loops > 0:
+		t = Repeat(t, w.Loops, w.PingPong)
+	}
+	if w.Delay > 0 {
+		t = Sequence(Wait(w.Delay), t)
+	}
+	return t, nil
+}
+
+// Play starts or resumes the tweener, restarting it when done.
+func (w *Tweener) Play() {
+	if w.done {
+		w.reset()
+	}
+	w.playing = true
+}
+
+func (w *Tweener) Pause() {
+	w.playing = false
+}
+
+// Stop halts the tweener where it is and rewinds it, without completing.
+func (w *Tweener) Stop() {
+	w.playing = false
+	w.reset()
+}
+
+func (w *Tweener) reset() {
+	w.elapsed = 0
+	w.started = false
+	w.done = false
+}
+
+func (w *Tweener) Playing() bool {
+	return w.playing
+}
+
+func (w *Tweener) Done() bool {
+	return w.done
+}
+
+func (w *Tweener) OnComplete(fn ...func() error) {
+	w.complete = append(w.complete, fn...)
+}
+
+// Update advances the tweener by dt seconds, calling the completion callbacks
+// when the last repetition ends.
+func (w *Tweener) Update(dt float32) error {
+	if !w.playing || w.done {
+		return nil
+	}
+	w.elapsed += dt * w.Speed
+	at := w.elapsed - w.Delay
+	if at < 0 {
+		return nil
+	}
+	if !w.started {
+		w.t.Start()
+		w.started = true
+	}
+	local, done := cycle(at, w.t.Duration(), w.Loops, w.PingPong)
+	w.t.Seek(local)
+	if !done {
+		return nil
+	}
+	w.done = true
+	w.playing = false
+	var err error
+	for _, fn := range w.complete {
+		if ferr := fn(); ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
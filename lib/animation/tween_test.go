@@ -0,0 +1,32 @@
+package animation
+
+import "testing"
+
+func TestComposed(t *testing.T) {
+	var v float32
+	p := NewProperty(func() []float32 { return []float32{v} }, func(f ...float32) { v = f[0] }, false)
+	cases := []struct {
+		loops    int
+		delay    float32
+		duration float32
+		err      bool
+	}{
+		{0, 0, 2, false},
+		{2, 0, 6, false},
+		{1, 0.5, 4.5, false},
+		{-1, 0, 0, true},
+		{-1, 1, 0, true},
+	}
+	for _, c := range cases {
+		w := NewTweener(To(p, []float32{1}, 2, nil))
+		w.Loops, w.Delay = c.loops, c.delay
+		ct, err := w.Composed()
+		if (err != nil) != c.err {
+			t.Errorf("loops %d: error %v", c.loops, err)
+			continue
+		}
+		if err == nil && ct.Duration() != c.duration {
+			t.Errorf("loops %d delay %v: duration %v, want %v", c.loops, c.delay, ct.Duration(), c.duration)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package asset
+
+import (
+	"strings"
+	"time"
+)
+
+// State is where an asset is in loading.
+type State int
+
+const (
+	PENDING State = iota
+	READY
+	FAILED
+	UNLOADED
+)
+
+var stateNames = []string{"pending", "ready", "failed", "unloaded"}
+
+func (s State) String() string {
+	if s >= 0 && int(s) < len(stateNames) {
+		return stateNames[s]
+	}
+	return "UNKNOWN_STATE"
+}
+
+func StringToState(s string) (State, bool) {
+	s = strings.ToLower(s)
+	for i, n := range stateNames {
+		if n == s {
+			return State(i), true
+		}
+	}
+	return PENDING, false
+}
+
+// Asset is something loaded from a file, shared by everything holding a
+// reference to it and unloaded some time after the last is released.
+type Asset struct {
+	ID     string
+	Path   string
+	Type   *Type
+	value  interface{}
+	size   int64
+	refs   int
+	state  State
+	err    error
+	unused time.Time
+	done   []func(*Asset)
+}
+
+// Value is what was loaded, nil until ready.
+func (a *Asset) Value() interface{} {
+	return a.value
+}
+
+func (a *Asset) State() State {
+	return a.state
+}
+
+func (a *Asset) Ready() bool {
+	return a.state == READY
+}
+
+// Err is why the asset failed to load.
+func (a *Asset) Err() error {
+	return a.err
+}
+
+// Refs is the number of references held to the asset.
+func (a *Asset) Refs() int {
+	return a.refs
+}
+
+// Size is about how many bytes the loaded asset takes.
+func (a *Asset) Size() int64 {
+	return a.size
+}
+
+// Then calls fn once the asset is ready or failed, at once if it already
+// is.
+func (a *Asset) Then(fn func(*Asset)) {
+	if a.state == PENDING {
+		a.done = append(a.done, fn)
+		return
+	}
+	fn(a)
+}
+
+// settle ends loading with a value or an error, calling what waited.
+func (a *Asset) settle(v interface{}, size int64, err error) {
+	if err != nil {
+		a.state, a.err = FAILED, err
+	} else {
+		a.state, a.value, a.size = READY, v, size
+	}
+	done := a.done
+	a.done = nil
+	for _, fn := range done {
+		fn(a)
+	}
+}
@@ -0,0 +1,268 @@
+package asset
+
+import (
+	"time"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/audio"
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/text"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+const lAssetClass = "ASSET"
+
+func PushAsset(L *l.LState, a *Asset) int {
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = a }, lAssetClass)
+	return 1
+}
+
+func CheckAsset(L *l.LState, pos int) *Asset {
+	ud := L.CheckUserData(pos)
+	if a, ok := ud.Value.(*Asset); ok {
+		return a
+	}
+	L.ArgError(pos, "asset expected")
+	return nil
+}
+
+// then calls a Lua function with an asset once it is ready or failed.
+func then(L *l.LState, a *Asset, fn *l.LFunction) {
+	a.Then(func(a *Asset) {
+		PushAsset(L, a)
+		ud := L.Get(-1)
+		L.Pop(1)
+		if err := L.CallByParam(l.P{
+			Fn:      fn,
+			NRet:    0,
+			Protect: true,
+		}, ud); err != nil {
+			CurrentManager.fail(err)
+		}
+	})
+}
+
+// shv.assets.load("textures/crate.png", "texture") loads an asset by path or
+// id now, the type taken from the extension when not given.
+func lLoad(L *l.LState) int {
+	a, err := CurrentManager.LoadAs(L.CheckString(1), L.OptString(2, ""))
+	if err != nil {
+		L.RaiseError(err.Error())
+		return 0
+	}
+	return PushAsset(L, a)
+}
+
+// shv.assets.load_async("music/theme.ogg", function(a) ... end) starts
+// loading an asset in the background, returning it pending, the function
+// called on the frame it is ready or failed. A type may be given before the
+// function.
+func lLoadAsync(L *l.LState) int {
+	typ, fnPos := "", 2
+	if s, ok := L.Get(2).(l.LString); ok {
+		typ, fnPos = string(s), 3
+	}
+	a, err := CurrentManager.LoadAsyncAs(L.CheckString(1), typ)
+	if err != nil {
+		L.RaiseError(err.Error())
+		return 0
+	}
+	if fn, ok := L.Get(fnPos).(*l.LFunction); ok {
+		then(L, a, fn)
+	}
+	return PushAsset(L, a)
+}
+
+// shv.assets.define("crate", "textures/crate.png", "texture")
+func lDefine(L *l.LState) int {
+	if err := CurrentManager.Define(L.CheckString(1), L.CheckString(2), L.OptString(3, "")); err != nil {
+		L.RaiseError(err.Error())
+	}
+	return 0
+}
+
+func lGet(L *l.LState) int {
+	if a, ok := CurrentManager.Get(L.CheckString(1)); ok {
+		return PushAsset(L, a)
+	}
+	L.Push(l.LNil)
+	return 1
+}
+
+// shv.assets.collect(seconds) unloads assets unused for a time, 0 when not
+// given, returning how many.
+func lCollect(L *l.LState) int {
+	d := time.Duration(float64(L.OptNumber(1, 0)) * float64(time.Second))
+	L.Push(l.LNumber(CurrentManager.Collect(d)))
+	return 1
+}
+
+// shv.assets.keep(seconds) sets how long unused assets stay loaded,
+// returning it.
+func lKeep(L *l.LState) int {
+	if L.GetTop() > 0 {
+		CurrentManager.SetKeep(time.Duration(float64(L.CheckNumber(1)) * float64(time.Second)))
+	}
+	L.Push(l.LNumber(CurrentManager.Keep().Seconds()))
+	return 1
+}
+
+// shv.assets.report() is a table of live, pending, bytes and assets, each
+// an id, path, type, state, refs and size.
+func lReport(L *l.LState) int {
+	r := CurrentManager.Report()
+	t := L.NewTable()
+	t.RawSetString("live", l.LNumber(r.Live))
+	t.RawSetString("pending", l.LNumber(r.Pending))
+	t.RawSetString("bytes", l.LNumber(r.Bytes))
+	as := L.NewTable()
+	for _, i := range r.Assets {
+		it := L.NewTable()
+		it.RawSetString("id", l.LString(i.ID))
+		it.RawSetString("path", l.LString(i.Path))
+		it.RawSetString("type", l.LString(i.Type))
+		it.RawSetString("state", l.LString(i.State.String()))
+		it.RawSetString("refs", l.LNumber(i.Refs))
+		it.RawSetString("size", l.LNumber(i.Size))
+		as.Append(it)
+	}
+	t.RawSetString("assets", as)
+	L.Push(t)
+	return 1
+}
+
+var assetFuncs = map[string]l.LGFunction{
+	"load":       lLoad,
+	"load_async": lLoadAsync,
+	"define":     lDefine,
+	"get":        lGet,
+	"collect":    lCollect,
+	"keep":       lKeep,
+	"report":     lReport,
+}
+
+type assetMemberFunc func(*l.LState, *Asset) int
+
+func assetMember(fn assetMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if a := CheckAsset(L, 1); a != nil {
+			return fn(L, a)
+		}
+		return 0
+	}
+}
+
+func assetProperty(get assetMemberFunc) l.LGFunction {
+	return lua.NewProperty(assetMember(get), nil)
+}
+
+func getAssetID(L *l.LState, a *Asset) int {
+	L.Push(l.LString(a.ID))
+	return 1
+}
+
+func getAssetPath(L *l.LState, a *Asset) int {
+	L.Push(l.LString(a.Path))
+	return 1
+}
+
+func getAssetType(L *l.LState, a *Asset) int {
+	L.Push(l.LString(a.Type.Name))
+	return 1
+}
+
+func getAssetState(L *l.LState, a *Asset) int {
+	L.Push(l.LString(a.State().String()))
+	return 1
+}
+
+func getAssetRefs(L *l.LState, a *Asset) int {
+	L.Push(l.LNumber(a.Refs()))
+	return 1
+}
+
+func getAssetSize(L *l.LState, a *Asset) int {
+	L.Push(l.LNumber(a.Size()))
+	return 1
+}
+
+func getAssetError(L *l.LState, a *Asset) int {
+	if err := a.Err(); err != nil {
+		L.Push(l.LString(err.Error()))
+		return 1
+	}
+	L.Push(l.LNil)
+	return 1
+}
+
+func assetReady(L *l.LState, a *Asset) int {
+	L.Push(l.LBool(a.Ready()))
+	return 1
+}
+
+// asset:value() is what was loaded: a font, a sound, the bytes of data as a
+// string, or anything else as userdata for other functions to take; nil
+// until ready.
+func assetValue(L *l.LState, a *Asset) int {
+	switch v := a.Value().(type) {
+	case nil:
+		L.Push(l.LNil)
+	case *text.Font:
+		return text.PushFont(L, v)
+	case *audio.Buffer:
+		return audio.PushSound(L, v)
+	case []byte:
+		L.Push(l.LString(v))
+	default:
+		ud := L.NewUserData()
+		ud.Value = v
+		L.Push(ud)
+	}
+	return 1
+}
+
+// asset:then(function(a) ... end) calls the function once the asset is
+// ready or failed, at once if it already is.
+func assetThen(L *l.LState, a *Asset) int {
+	then(L, a, L.CheckFunction(2))
+	return 0
+}
+
+func assetRelease(L *l.LState, a *Asset) int {
+	CurrentManager.Release(a)
+	return 0
+}
+
+var assetTable = &lua.Table{
+	lAssetClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+	},
+	map[string]l.LGFunction{
+		"id":    assetProperty(getAssetID),
+		"path":  assetProperty(getAssetPath),
+		"type":  assetProperty(getAssetType),
+		"state": assetProperty(getAssetState),
+		"refs":  assetProperty(getAssetRefs),
+		"size":  assetProperty(getAssetSize),
+		"error": assetProperty(getAssetError),
+	},
+	map[string]l.LGFunction{
+		"ready":   assetMember(assetReady),
+		"value":   assetMember(assetValue),
+		"then":    assetMember(assetThen),
+		"release": assetMember(assetRelease),
+	},
+}
+
+func RegisterWith() lua.RegisterWith {
+	return func(m lua.Module) error {
+		rmtfn := func(L *l.LState, M lua.Module) {
+			M.Register(L, assetTable)
+			lua.SetSub(L, M, "assets", assetFuncs)
+		}
+		m.AddMT(rmtfn)
+		return nil
+	}
+}
@@ -0,0 +1,407 @@
+package asset
+
+import (
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Laughs-In-Flowers/log"
+	"github.com/Laughs-In-Flowers/shiva/lib/tread"
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+var (
+	UnknownTypeError = xrror.Xrror("no asset type %s").Out
+	UnloadedError    = xrror.Xrror("asset %s was unloaded").Out
+)
+
+// job is an asset to decode in the background, again when reloading.
+type job struct {
+	a      *Asset
+	path   string
+	t      *Type
+	reload bool
+}
+
+// Manager loads assets by path or id through vfs.CurrentFS, once each for
+// everything asking for them. Files are read and decoded on background
+// goroutines, then finished on the main thread through tread. Assets no
+// longer referenced are kept for a while, in case they are asked for again,
+// then unloaded; those that failed are not kept, so asking again retries.
+// Assets of types that reload are reloaded in place when their files
+// change, while the watcher is enabled. Every method is for the main
+// thread.
+type Manager struct {
+	assets  map[string]*Asset
+	ids     map[string]string
+	types   map[string]*Type
+	byExt   map[string]*Type
+	keep    time.Duration
+	workers int
+	jobs    chan *job
+	post    func(func())
+	watcher *vfs.Watcher
+	watched map[string]func()
+	err     error
+}
+
+// NewManager returns a manager decoding with a number of goroutines.
+func NewManager(workers int) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+	m := &Manager{
+		assets:  make(map[string]*Asset),
+		ids:     make(map[string]string),
+		types:   make(map[string]*Type),
+		byExt:   make(map[string]*Type),
+		keep:    30 * time.Second,
+		workers: workers,
+		post:    tread.Post,
+		watcher: vfs.CurrentWatcher,
+		watched: make(map[string]func()),
+	}
+	m.Register(Texture, HDR, Model, Font, BitmapFont, Sound, Data)
+	return m
+}
+
+// Register adds types of asset, replacing any of the same name or for the
+// same extensions.
+func (m *Manager) Register(ts ...*Type) {
+	for _, t := range ts {
+		m.types[t.Name] = t
+		for _, e := range t.Extensions {
+			m.byExt[strings.ToLower(e)] = t
+		}
+	}
+}
+
+func (m *Manager) Type(name string) (*Type, bool) {
+	t, ok := m.types[name]
+	return t, ok
+}
+
+// Keep is how long an asset no longer referenced stays loaded.
+func (m *Manager) Keep() time.Duration {
+	return m.keep
+}
+
+func (m *Manager) SetKeep(d time.Duration) {
+	m.keep = d
+}
+
+// Define names an asset by an id, loaded from a path as a type, "" for the
+// type of its extension.
+func (m *Manager) Define(id, path, typ string) error {
+	if _, err := m.typeOf(path, typ); err != nil {
+		return err
+	}
+	m.ids[id] = typ + ":" + vfs.Clean(path)
+	return nil
+}
+
+func (m *Manager) typeOf(path, typ string) (*Type, error) {
+	if typ == "" {
+		if t, ok := m.byExt[strings.ToLower(filepath.Ext(path))]; ok {
+			return t, nil
+		}
+		return Data, nil
+	}
+	if t, ok := m.types[typ]; ok {
+		return t, nil
+	}
+	return nil, UnknownTypeError(typ)
+}
+
+// resolve is the path and type a name stands for, an id or a path.
+func (m *Manager) resolve(name, typ string) (string, *Type, error) {
+	if def, ok := m.ids[name]; ok {
+		spl := strings.SplitN(def, ":", 2)
+		t, err := m.typeOf(spl[1], spl[0])
+		return spl[1], t, err
+	}
+	path := vfs.Clean(name)
+	t, err := m.typeOf(path, typ)
+	return path, t, err
+}
+
+// acquire is the asset for a name, taking a reference to it, and whether
+// it is new and needs loading.
+func (m *Manager) acquire(name, typ string) (*Asset, bool, error) {
+	path, t, err := m.resolve(name, typ)
+	if err != nil {
+		return nil, false, err
+	}
+	key := t.Name + ":" + path
+	if a, ok := m.assets[key]; ok {
+		a.refs++
+		return a, false, nil
+	}
+	a := &Asset{ID: name, Path: path, Type: t, refs: 1}
+	m.assets[key] = a
+	return a, true, nil
+}
+
+// Load loads an asset by id or path now, or returns it if already loaded,
+// with a reference taken that Release gives back. None is taken on failure.
+func (m *Manager) Load(name string) (*Asset, error) {
+	return m.LoadAs(name, "")
+}
+
+// LoadAs is Load as a type, "" for that of the extension.
+func (m *Manager) LoadAs(name, typ string) (*Asset, error) {
+	a, _, err := m.acquire(name, typ)
+	if err != nil {
+		return nil, err
+	}
+	if a.state == PENDING {
+		m.settle(a, m.decode(a.Path, a.Type))
+	}
+	if a.err != nil {
+		m.Release(a)
+		return nil, a.err
+	}
+	return a, nil
+}
+
+// LoadAsync starts loading an asset in the background, returning it at once,
+// pending until the frame it is finished on. A reference is taken that
+// Release gives back.
+func (m *Manager) LoadAsync(name string) (*Asset, error) {
+	return m.LoadAsyncAs(name, "")
+}
+
+// LoadAsyncAs is LoadAsync as a type, "" for that of the extension.
+func (m *Manager) LoadAsyncAs(name, typ string) (*Asset, error) {
+	a, isNew, err := m.acquire(name, typ)
+	if err != nil {
+		return nil, err
+	}
+	if isNew {
+		m.queue(&job{a, a.Path, a.Type, false})
+	}
+	return a, nil
+}
+
+func (m *Manager) queue(j *job) {
+	if m.jobs == nil {
+		m.jobs = make(chan *job, 256)
+		for i := 0; i < m.workers; i++ {
+			go m.work()
+		}
+	}
+	select {
+	case m.jobs <- j:
+	default:
+		go func() { m.jobs <- j }()
+	}
+}
+
+// decoded is what a worker made of a file.
+type decoded struct {
+	v   interface{}
+	err error
+}
+
+func (m *Manager) decode(path string, t *Type) decoded {
+	b, err := vfs.ReadFile(path)
+	if err != nil {
+		return decoded{nil, err}
+	}
+	v, err := t.Decode(path, b)
+	return decoded{v, err}
+}
+
+func (m *Manager) finish(a *Asset, d decoded) (interface{}, int64, error) {
+	if d.err != nil {
+		return nil, 0, d.err
+	}
+	return a.Type.Finish(d.v)
+}
+
+// settle finishes an asset, watching its file when its type reloads, or
+// forgets it when it failed so the next load tries again.
+func (m *Manager) settle(a *Asset, d decoded) {
+	a.settle(m.finish(a, d))
+	if a.state == FAILED {
+		if key := a.Type.Name + ":" + a.Path; m.assets[key] == a {
+			delete(m.assets, key)
+		}
+		return
+	}
+	if _, ok := m.watched[a.Path]; !ok && a.state == READY && a.Type.Reload != nil {
+		m.watched[a.Path] = m.watcher.Watch(a.Path, m.changed)
+	}
+}
+
+func (m *Manager) work() {
+	for j := range m.jobs {
+		j := j
+		d := m.decode(j.path, j.t)
+		if j.reload {
+			m.post(func() { m.reload(j.a, d) })
+			continue
+		}
+		m.post(func() {
+			// loaded meanwhile with Load
+			if j.a.state != PENDING {
+				return
+			}
+			m.settle(j.a, d)
+		})
+	}
+}
+
+// changed decodes again in the background every loaded asset of a changed
+// file that reloads.
+func (m *Manager) changed(path string) {
+	for _, a := range m.assets {
+		if a.Path == path && a.state == READY && a.Type.Reload != nil {
+			m.queue(&job{a, a.Path, a.Type, true})
+		}
+	}
+}
+
+// reload replaces the value of an asset in place with what was decoded
+// again, keeping what it was when that fails.
+func (m *Manager) reload(a *Asset, d decoded) {
+	if a.state != READY {
+		return
+	}
+	err := d.err
+	if err == nil {
+		var size int64
+		if size, err = a.Type.Reload(a.value, d.v); err == nil {
+			a.size = size
+			return
+		}
+	}
+	log.Printf("reloading %s %s: %s", a.Type.Name, a.Path, err)
+}
+
+// Get is an asset already asked for by id or path, without taking a
+// reference.
+func (m *Manager) Get(name string) (*Asset, bool) {
+	path, t, err := m.resolve(name, "")
+	if err != nil {
+		return nil, false
+	}
+	a, ok := m.assets[t.Name+":"+path]
+	return a, ok
+}
+
+// Release gives back a reference to an asset, unloaded once none are left
+// and it has stayed unused for Keep.
+func (m *Manager) Release(a *Asset) {
+	if a.refs <= 0 {
+		return
+	}
+	a.refs--
+	if a.refs == 0 {
+		a.unused = time.Now()
+	}
+}
+
+// Collect unloads every loaded asset unused for at least a time, returning
+// how many.
+func (m *Manager) Collect(unused time.Duration) int {
+	n := 0
+	now := time.Now()
+	for k, a := range m.assets {
+		if a.refs > 0 || a.state == PENDING || now.Sub(a.unused) < unused {
+			continue
+		}
+		if a.state == READY && a.Type.Free != nil {
+			a.Type.Free(a.value)
+		}
+		a.state, a.value, a.size = UNLOADED, nil, 0
+		a.err = UnloadedError(a.ID)
+		delete(m.assets, k)
+		n++
+	}
+	if n > 0 {
+		m.unwatch()
+	}
+	return n
+}
+
+// unwatch stops watching the files of assets no longer loaded.
+func (m *Manager) unwatch() {
+	live := make(map[string]bool)
+	for _, a := range m.assets {
+		live[a.Path] = true
+	}
+	for p, stop := range m.watched {
+		if !live[p] {
+			delete(m.watched, p)
+			stop()
+		}
+	}
+}
+
+// Info is what Report tells of an asset.
+type Info struct {
+	ID    string
+	Path  string
+	Type  string
+	State State
+	Refs  int
+	Size  int64
+}
+
+// Report is the assets loaded or loading, largest first, with their count
+// and bytes in all.
+type Report struct {
+	Live    int
+	Pending int
+	Bytes   int64
+	Assets  []Info
+}
+
+func (m *Manager) Report() Report {
+	r := Report{Assets: make([]Info, 0, len(m.assets))}
+	for _, a := range m.assets {
+		switch a.state {
+		case PENDING:
+			r.Pending++
+		case READY:
+			r.Live++
+			r.Bytes += a.size
+		}
+		r.Assets = append(r.Assets, Info{a.ID, a.Path, a.Type.Name, a.state, a.refs, a.size})
+	}
+	sort.Slice(r.Assets, func(i, j int) bool {
+		if r.Assets[i].Size != r.Assets[j].Size {
+			return r.Assets[i].Size > r.Assets[j].Size
+		}
+		return r.Assets[i].Path < r.Assets[j].Path
+	})
+	return r
+}
+
+// Priority, Update and Remove make the manager a system, unloading assets
+// unused for Keep each frame and returning the first error of a callback.
+func (m *Manager) Priority() int {
+	return 3
+}
+
+func (m *Manager) Update(int64) error {
+	m.Collect(m.keep)
+	err := m.err
+	m.err = nil
+	return err
+}
+
+// fail keeps the first error of a callback since the last update.
+func (m *Manager) fail(err error) {
+	if m.err == nil {
+		m.err = err
+	}
+}
+
+func (m *Manager) Remove(uint64) {}
+
+var CurrentManager *Manager = NewManager(runtime.NumCPU())
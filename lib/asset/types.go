@@ -0,0 +1,182 @@
+package asset
+
+import (
+	"bytes"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/audio"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/geometry"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/texture"
+	"github.com/Laughs-In-Flowers/shiva/lib/text"
+)
+
+// DecodeFunc turns the bytes of a file into what Finish takes, on a
+// background goroutine, touching neither the GPU nor Lua.
+type DecodeFunc func(path string, data []byte) (interface{}, error)
+
+// FinishFunc makes what was decoded into the value of an asset, on the main
+// thread, with about how many bytes it takes.
+type FinishFunc func(decoded interface{}) (interface{}, int64, error)
+
+// FreeFunc unloads the value of an asset, on the main thread.
+type FreeFunc func(value interface{})
+
+// ReloadFunc replaces what the value of an asset holds with what was
+// decoded again from its changed file, in place, on the main thread, with
+// about how many bytes it now takes.
+type ReloadFunc func(value, decoded interface{}) (int64, error)
+
+// Type is a kind of asset: how it is decoded, finished, freed and reloaded,
+// and the file extensions loaded as it when no type is given. Types without
+// Reload are not reloaded when their files change.
+type Type struct {
+	Name       string
+	Extensions []string
+	Decode     DecodeFunc
+	Finish     FinishFunc
+	Free       FreeFunc
+	Reload     ReloadFunc
+}
+
+type rgba struct {
+	pix  []byte
+	w, h int
+}
+
+// Texture is png and jpeg files as *texture.Data, RGBA, reloaded into the
+// same texture.
+var Texture = &Type{
+	Name:       "texture",
+	Extensions: []string{".png", ".jpg", ".jpeg"},
+	Decode: func(path string, data []byte) (interface{}, error) {
+		pix, w, h, err := texture.DecodeRGBA(bytes.NewReader(data))
+		return &rgba{pix, w, h}, err
+	},
+	Finish: func(d interface{}) (interface{}, int64, error) {
+		i := d.(*rgba)
+		t := texture.NewData(graphics.RGBA)
+		t.Set(i.w, i.h, i.pix)
+		return t, int64(len(i.pix)), nil
+	},
+	Free: func(v interface{}) {
+		v.(*texture.Data).Close()
+	},
+	Reload: func(v, d interface{}) (int64, error) {
+		i := d.(*rgba)
+		v.(*texture.Data).Set(i.w, i.h, i.pix)
+		return int64(len(i.pix)), nil
+	},
+}
+
+type rgbf struct {
+	pix  []float32
+	w, h int
+}
+
+// HDR is radiance hdr files as *texture.Float, RGB, reloaded into the same
+// texture.
+var HDR = &Type{
+	Name:       "hdr",
+	Extensions: []string{".hdr"},
+	Decode: func(path string, data []byte) (interface{}, error) {
+		pix, w, h, err := texture.DecodeHDR(bytes.NewReader(data))
+		return &rgbf{pix, w, h}, err
+	},
+	Finish: func(d interface{}) (interface{}, int64, error) {
+		i := d.(*rgbf)
+		t := texture.NewFloat()
+		t.Set(i.w, i.h, i.pix)
+		return t, int64(len(i.pix) * 4), nil
+	},
+	Free: func(v interface{}) {
+		v.(*texture.Float).Close()
+	},
+	Reload: func(v, d interface{}) (int64, error) {
+		i := d.(*rgbf)
+		v.(*texture.Float).Set(i.w, i.h, i.pix)
+		return int64(len(i.pix) * 4), nil
+	},
+}
+
+// Model is Wavefront obj files as geometry.Geometry, reloaded into the same
+// geometry and buffers.
+var Model = &Type{
+	Name:       "model",
+	Extensions: []string{".obj"},
+	Decode: func(path string, data []byte) (interface{}, error) {
+		return geometry.DecodeOBJ(bytes.NewReader(data))
+	},
+	Finish: func(d interface{}) (interface{}, int64, error) {
+		o := d.(*geometry.OBJ)
+		return o.Geometry(), o.Size(), nil
+	},
+	Free: func(v interface{}) {
+		v.(geometry.Geometry).Close()
+	},
+	Reload: func(v, d interface{}) (int64, error) {
+		o := d.(*geometry.OBJ)
+		o.Apply(v.(geometry.Geometry))
+		return o.Size(), nil
+	},
+}
+
+type fontData struct {
+	f    *text.Font
+	size int
+}
+
+func fontType(name string, mode text.Mode, exts ...string) *Type {
+	return &Type{
+		Name:       name,
+		Extensions: exts,
+		Decode: func(path string, data []byte) (interface{}, error) {
+			f, err := text.Parse(data, mode)
+			if err != nil {
+				return nil, text.FontError(path, err)
+			}
+			return &fontData{f, len(data)}, nil
+		},
+		Finish: func(d interface{}) (interface{}, int64, error) {
+			fd := d.(*fontData)
+			return fd.f, int64(fd.size), nil
+		},
+		Free: func(v interface{}) {
+			v.(*text.Font).Atlas().Texture().Close()
+		},
+	}
+}
+
+// Font is ttf and otf files as *text.Font in SDF mode, BitmapFont the same
+// in bitmap mode.
+var (
+	Font       = fontType("font", text.SDF, ".ttf", ".otf")
+	BitmapFont = fontType("bitmap_font", text.BITMAP)
+)
+
+// Sound is wav, ogg and mp3 files fully decoded as *audio.Buffer.
+var Sound = &Type{
+	Name:       "sound",
+	Extensions: []string{".wav", ".wave", ".ogg", ".oga", ".mp3"},
+	Decode: func(path string, data []byte) (interface{}, error) {
+		s, err := audio.Decode(path, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return audio.ReadAll(s)
+	},
+	Finish: func(d interface{}) (interface{}, int64, error) {
+		b := d.(*audio.Buffer)
+		return b, int64(len(b.Data) * 4), nil
+	},
+}
+
+// Data is any other file as its bytes.
+var Data = &Type{
+	Name: "data",
+	Decode: func(path string, data []byte) (interface{}, error) {
+		return data, nil
+	},
+	Finish: func(d interface{}) (interface{}, int64, error) {
+		return d, int64(len(d.([]byte))), nil
+	},
+}
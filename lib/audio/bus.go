@@ -0,0 +1,204 @@
+package audio
+
+import (
+	glm "math"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// The buses every mixer starts with, all feeding master.
+const (
+	MasterBus = "master"
+	MusicBus  = "music"
+	SfxBus    = "sfx"
+	VoiceBus  = "voice"
+)
+
+var (
+	UnknownBusError = xrror.Xrror("%s is not an audio bus").Out
+	BusCycleError   = xrror.Xrror("bus %s cannot feed %s, which feeds it").Out
+	DuckCycleError  = xrror.Xrror("bus %s cannot be ducked by %s, which it feeds").Out
+)
+
+// duck lowers a bus by Amount dB while its key bus is louder than
+// Threshold dB, the sidechain.
+type duck struct {
+	key               *Bus
+	amount, threshold float32
+	attack, release   float32
+	env, gain         float32
+}
+
+// Bus sums voices and other buses, runs the sum through its effects and
+// feeds it, at its volume, to its parent.
+type Bus struct {
+	name    string
+	m       *Mixer
+	parent  *Bus
+	volume  float32
+	mute    bool
+	effects []Effect
+	duck    *duck
+	buf     []float32
+	removed bool
+}
+
+func newBus(m *Mixer, name string, parent *Bus) *Bus {
+	return &Bus{
+		name:    name,
+		m:       m,
+		parent:  parent,
+		volume:  1,
+		effects: make([]Effect, 0),
+	}
+}
+
+func (b *Bus) Name() string {
+	return b.name
+}
+
+func (b *Bus) Parent() *Bus {
+	return b.parent
+}
+
+// SetParent routes the bus into another, refusing cycles. Master has no
+// parent.
+func (b *Bus) SetParent(p *Bus) error {
+	if b == b.m.master {
+		return nil
+	}
+	if p == nil {
+		p = b.m.master
+	}
+	for a := p; a != nil; a = a.parent {
+		if a == b {
+			return BusCycleError(b.name, p.name)
+		}
+	}
+	b.parent = p
+	b.m.reorder()
+	return nil
+}
+
+func (b *Bus) Volume() float32 {
+	return b.volume
+}
+
+func (b *Bus) SetVolume(v float32) {
+	if v < 0 {
+		v = 0
+	}
+	b.volume = v
+}
+
+func (b *Bus) Mute() bool {
+	return b.mute
+}
+
+func (b *Bus) SetMute(m bool) {
+	b.mute = m
+}
+
+func (b *Bus) Effects() []Effect {
+	return b.effects
+}
+
+func (b *Bus) AddEffect(es ...Effect) {
+	b.effects = append(b.effects, es...)
+}
+
+func (b *Bus) ClearEffects() {
+	b.effects = b.effects[:0]
+}
+
+// Duck lowers the bus by amount dB while key is above threshold dB, moving
+// over attack and release seconds. A nil key stops ducking.
+func (b *Bus) Duck(key *Bus, amount, threshold, attack, release float32) error {
+	for a := b; key != nil && a != nil; a = a.parent {
+		if a == key {
+			return DuckCycleError(b.name, key.name)
+		}
+	}
+	if key == nil {
+		b.duck = nil
+	} else {
+		b.duck = &duck{
+			key:       key,
+			amount:    dbToGain(-float32(glm.Abs(float64(amount)))),
+			threshold: dbToGain(threshold),
+			attack:    attack,
+			release:   release,
+			gain:      1,
+		}
+	}
+	b.m.reorder()
+	return nil
+}
+
+// Ducked is the gain ducking currently applies, 1 when not ducked.
+func (b *Bus) Ducked() float32 {
+	if b.duck == nil {
+		return 1
+	}
+	return b.duck.gain
+}
+
+func (b *Bus) clear(n int) {
+	if cap(b.buf) < n {
+		b.buf = make([]float32, n)
+	}
+	b.buf = b.buf[:n]
+	for i := range b.buf {
+		b.buf[i] = 0
+	}
+}
+
+// seconds the key level detector takes to fall
+const duckDetect = 0.05
+
+func (b *Bus) applyDuck(rate int) {
+	d := b.duck
+	key := d.key.buf
+	att, rel := smoothing(d.attack, rate), smoothing(d.release, rate)
+	fall := smoothing(duckDetect, rate)
+	for i := 0; i+1 < len(b.buf) && i+1 < len(key); i += 2 {
+		peak := float32(glm.Max(glm.Abs(float64(key[i])), glm.Abs(float64(key[i+1]))))
+		d.env = peak + fall*(d.env-peak)
+		if peak > d.env {
+			d.env = peak
+		}
+		target := float32(1)
+		if d.env > d.threshold {
+			target = d.amount
+		}
+		k := rel
+		if target < d.gain {
+			k = att
+		}
+		d.gain = target + k*(d.gain-target)
+		b.buf[i] *= d.gain
+		b.buf[i+1] *= d.gain
+	}
+}
+
+// process runs the effects, ducking and volume, then feeds the parent.
+func (b *Bus) process(rate int) {
+	for _, e := range b.effects {
+		e.Apply(b.buf)
+	}
+	if b.duck != nil {
+		b.applyDuck(rate)
+	}
+	v := b.volume
+	if b.mute {
+		v = 0
+	}
+	for i := range b.buf {
+		b.buf[i] *= v
+	}
+	if b.parent != nil {
+		for i := range b.buf {
+			b.parent.buf[i] += b.buf[i]
+		}
+	}
+}
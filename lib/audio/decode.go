@@ -0,0 +1,49 @@
+package audio
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+var UnknownAudioFormatError = xrror.Xrror("%s is not a known audio format").Out
+
+// Open opens a file as a streaming source, decoded by extension: .wav, .ogg
+// or .mp3.
+func Open(path string) (Source, error) {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Decode(path, f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Decode decodes a stream by the extension of its name.
+func Decode(name string, r io.ReadSeeker) (Source, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".wav", ".wave":
+		return DecodeWAV(r)
+	case ".ogg", ".oga":
+		return DecodeOgg(r)
+	case ".mp3":
+		return DecodeMP3(r)
+	}
+	return nil, UnknownAudioFormatError(name)
+}
+
+// Load fully decodes a file into a buffer.
+func Load(path string) (*Buffer, error) {
+	s, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return ReadAll(s)
+}
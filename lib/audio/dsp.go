@@ -0,0 +1,288 @@
+package audio
+
+import (
+	glm "math"
+	"strings"
+)
+
+// Effect processes interleaved stereo samples in place.
+type Effect interface {
+	Apply([]float32)
+	Reset()
+}
+
+type EffectT int
+
+const (
+	UNKNOWN_EFFECT EffectT = iota
+	LOWPASS
+	HIGHPASS
+	DELAY
+	REVERB
+	COMPRESSOR
+	LIMITER
+)
+
+func (e EffectT) String() string {
+	switch e {
+	case LOWPASS:
+		return "lowpass"
+	case HIGHPASS:
+		return "highpass"
+	case DELAY:
+		return "delay"
+	case REVERB:
+		return "reverb"
+	case COMPRESSOR:
+		return "compressor"
+	case LIMITER:
+		return "limiter"
+	}
+	return "UNKNOWN_EFFECT"
+}
+
+func StringToEffectT(s string) EffectT {
+	switch strings.ToLower(s) {
+	case "lowpass":
+		return LOWPASS
+	case "highpass":
+		return HIGHPASS
+	case "delay":
+		return DELAY
+	case "reverb":
+		return REVERB
+	case "compressor":
+		return COMPRESSOR
+	case "limiter":
+		return LIMITER
+	}
+	return UNKNOWN_EFFECT
+}
+
+func dbToGain(db float32) float32 {
+	return float32(glm.Pow(10, float64(db)/20))
+}
+
+func gainToDb(g float32) float32 {
+	if g <= 1e-9 {
+		return -180
+	}
+	return 20 * float32(glm.Log10(float64(g)))
+}
+
+// smoothing is the one pole coefficient reaching about 63% of a change in
+// t seconds.
+func smoothing(t float32, rate int) float32 {
+	if t <= 0 {
+		return 0
+	}
+	return float32(glm.Exp(-1 / (float64(t) * float64(rate))))
+}
+
+// Biquad is a second order low or high pass filter.
+type Biquad struct {
+	kind           EffectT
+	rate           int
+	Freq, Q        float32
+	b0, b1, b2     float32
+	a1, a2         float32
+	x1, x2, y1, y2 [2]float32
+	freq, q        float32
+}
+
+func NewLowPass(rate int, freq, q float32) *Biquad {
+	return newBiquad(LOWPASS, rate, freq, q)
+}
+
+func NewHighPass(rate int, freq, q float32) *Biquad {
+	return newBiquad(HIGHPASS, rate, freq, q)
+}
+
+func newBiquad(kind EffectT, rate int, freq, q float32) *Biquad {
+	if q <= 0 {
+		q = 0.7071
+	}
+	b := &Biquad{kind: kind, rate: rate, Freq: freq, Q: q}
+	b.tune()
+	return b
+}
+
+func (b *Biquad) tune() {
+	if b.freq == b.Freq && b.q == b.Q {
+		return
+	}
+	b.freq, b.q = b.Freq, b.Q
+	f := clamp(b.Freq, 10, float32(b.rate)*0.49)
+	q := b.Q
+	if q <= 0 {
+		q = 0.7071
+	}
+	w0 := 2 * glm.Pi * float64(f) / float64(b.rate)
+	cos := float32(glm.Cos(w0))
+	alpha := float32(glm.Sin(w0)) / (2 * q)
+	a0 := 1 + alpha
+	switch b.kind {
+	case HIGHPASS:
+		b.b0 = (1 + cos) / 2 / a0
+		b.b1 = -(1 + cos) / a0
+	default:
+		b.b0 = (1 - cos) / 2 / a0
+		b.b1 = (1 - cos) / a0
+	}
+	b.b2 = b.b0
+	b.a1 = -2 * cos / a0
+	b.a2 = (1 - alpha) / a0
+}
+
+func (b *Biquad) Apply(buf []float32) {
+	b.tune()
+	for i := 0; i+1 < len(buf); i += 2 {
+		for c := 0; c < 2; c++ {
+			x := buf[i+c]
+			y := b.b0*x + b.b1*b.x1[c] + b.b2*b.x2[c] - b.a1*b.y1[c] - b.a2*b.y2[c]
+			b.x2[c], b.x1[c] = b.x1[c], x
+			b.y2[c], b.y1[c] = b.y1[c], y
+			buf[i+c] = y
+		}
+	}
+}
+
+func (b *Biquad) Reset() {
+	b.x1, b.x2, b.y1, b.y2 = [2]float32{}, [2]float32{}, [2]float32{}, [2]float32{}
+}
+
+// MaxDelay is the longest delay, in seconds.
+const MaxDelay = 4
+
+// Delay is an echo of Time seconds, fed back by Feedback and mixed in by Mix.
+type Delay struct {
+	Time, Feedback, Mix float32
+	rate                int
+	buf                 []float32
+	i                   int
+}
+
+func NewDelay(rate int, time, feedback, mix float32) *Delay {
+	return &Delay{
+		Time:     time,
+		Feedback: feedback,
+		Mix:      mix,
+		rate:     rate,
+		buf:      make([]float32, rate*MaxDelay*2),
+	}
+}
+
+func (d *Delay) Apply(buf []float32) {
+	frames := len(d.buf) / 2
+	n := int(clamp(d.Time, 0, MaxDelay) * float32(d.rate))
+	if n <= 0 {
+		return
+	}
+	if n > frames {
+		n = frames
+	}
+	fb := clamp(d.Feedback, 0, 0.99)
+	for i := 0; i+1 < len(buf); i += 2 {
+		r := d.i - n
+		if r < 0 {
+			r += frames
+		}
+		for c := 0; c < 2; c++ {
+			x := buf[i+c]
+			y := d.buf[r*2+c]
+			d.buf[d.i*2+c] = x + y*fb
+			buf[i+c] = x*(1-d.Mix) + y*d.Mix
+		}
+		if d.i++; d.i == frames {
+			d.i = 0
+		}
+	}
+}
+
+func (d *Delay) Reset() {
+	for i := range d.buf {
+		d.buf[i] = 0
+	}
+	d.i = 0
+}
+
+// Compressor reduces the level above Threshold dB by Ratio, following the
+// signal over Attack and Release seconds, with Makeup dB of gain after.
+type Compressor struct {
+	Threshold, Ratio float32
+	Attack, Release  float32
+	Makeup           float32
+	rate             int
+	env              float32
+}
+
+func NewCompressor(rate int, threshold, ratio float32) *Compressor {
+	return &Compressor{
+		Threshold: threshold,
+		Ratio:     ratio,
+		Attack:    0.005,
+		Release:   0.1,
+		rate:      rate,
+	}
+}
+
+func (c *Compressor) Apply(buf []float32) {
+	att, rel := smoothing(c.Attack, c.rate), smoothing(c.Release, c.rate)
+	ratio := c.Ratio
+	if ratio < 1 {
+		ratio = 1
+	}
+	makeup := dbToGain(c.Makeup)
+	for i := 0; i+1 < len(buf); i += 2 {
+		peak := float32(glm.Max(glm.Abs(float64(buf[i])), glm.Abs(float64(buf[i+1]))))
+		k := rel
+		if peak > c.env {
+			k = att
+		}
+		c.env = peak + k*(c.env-peak)
+		g := makeup
+		if over := gainToDb(c.env) - c.Threshold; over > 0 {
+			g *= dbToGain(-over * (1 - 1/ratio))
+		}
+		buf[i] *= g
+		buf[i+1] *= g
+	}
+}
+
+func (c *Compressor) Reset() {
+	c.env = 0
+}
+
+// Limiter holds peaks under Ceiling dB, recovering over Release seconds.
+type Limiter struct {
+	Ceiling, Release float32
+	rate             int
+	gain             float32
+}
+
+func NewLimiter(rate int, ceiling float32) *Limiter {
+	return &Limiter{Ceiling: ceiling, Release: 0.05, rate: rate, gain: 1}
+}
+
+func (l *Limiter) Apply(buf []float32) {
+	ceiling := dbToGain(l.Ceiling)
+	rel := smoothing(l.Release, l.rate)
+	for i := 0; i+1 < len(buf); i += 2 {
+		peak := float32(glm.Max(glm.Abs(float64(buf[i])), glm.Abs(float64(buf[i+1]))))
+		target := float32(1)
+		if peak > ceiling {
+			target = ceiling / peak
+		}
+		if target < l.gain {
+			l.gain = target
+		} else {
+			l.gain = target + rel*(l.gain-target)
+		}
+		buf[i] = clamp(buf[i]*l.gain, -ceiling, ceiling)
+		buf[i+1] = clamp(buf[i+1]*l.gain, -ceiling, ceiling)
+	}
+}
+
+func (l *Limiter) Reset() {
+	l.gain = 1
+}
@@ -0,0 +1,126 @@
+package audio
+
+import (
+	glm "math"
+	"testing"
+)
+
+func peak(s []float32) float32 {
+	var p float32
+	for _, v := range s {
+		if v < 0 {
+			v = -v
+		}
+		if v > p {
+			p = v
+		}
+	}
+	return p
+}
+
+// response renders a unit sine of freq through an effect on the sfx bus,
+// returning the peak of its last tenth of a second.
+func response(t *testing.T, e Effect, freq, amp float32) float32 {
+	m := testMixer(t)
+	m.Bus(SfxBus).AddEffect(e)
+	playOn(m, tone(freq, amp, 1), SfxBus)
+	c := &captureSink{}
+	if err := m.Render(0.5, c); err != nil {
+		t.Fatal(err)
+	}
+	return peak(c.left(DefaultRate / 10))
+}
+
+func TestFilterResponse(t *testing.T) {
+	cases := []struct {
+		name     string
+		e        func() Effect
+		freq     float32
+		min, max float32
+	}{
+		{"lowpass pass", func() Effect { return NewLowPass(DefaultRate, 1000, 0) }, 100, 0.98, 1.01},
+		{"lowpass cutoff", func() Effect { return NewLowPass(DefaultRate, 1000, 0) }, 1000, 0.69, 0.72},
+		{"lowpass stop", func() Effect { return NewLowPass(DefaultRate, 1000, 0) }, 10000, 0, 0.011},
+		{"highpass pass", func() Effect { return NewHighPass(DefaultRate, 1000, 0) }, 10000, 0.98, 1.01},
+		{"highpass cutoff", func() Effect { return NewHighPass(DefaultRate, 1000, 0) }, 1000, 0.69, 0.72},
+		{"highpass stop", func() Effect { return NewHighPass(DefaultRate, 1000, 0) }, 100, 0, 0.011},
+		{"resonant peak", func() Effect { return NewLowPass(DefaultRate, 1000, 4) }, 1000, 3.9, 4.1},
+	}
+	for _, c := range cases {
+		// quiet enough that the resonant peak is not clamped by the mixer
+		got := response(t, c.e(), c.freq, 0.2) / 0.2
+		if got < c.min || got > c.max {
+			t.Errorf("%s: gain %v, want %v to %v", c.name, got, c.min, c.max)
+		}
+	}
+}
+
+func TestLimiterResponse(t *testing.T) {
+	ceiling := dbToGain(-6)
+	if p := response(t, NewLimiter(DefaultRate, -6), 440, 0.9); p > ceiling+1e-5 || p < ceiling*0.95 {
+		t.Errorf("limited peak %v, want at most %v", p, ceiling)
+	}
+	if p := response(t, NewLimiter(DefaultRate, -6), 440, 0.25); glm.Abs(float64(p-0.25)) > 1e-3 {
+		t.Errorf("quiet peak %v, want 0.25 untouched", p)
+	}
+
+	// the gain falls at once on a peak and recovers over the release
+	l := NewLimiter(DefaultRate, -6)
+	buf := []float32{1, 1, 0.1, 0.1}
+	l.Apply(buf)
+	if glm.Abs(float64(buf[0]-ceiling)) > 1e-5 || buf[2] >= 0.1 {
+		t.Errorf("limited %v", buf)
+	}
+	for i := 0; i < DefaultRate; i++ {
+		l.Apply(buf[2:])
+		buf[2], buf[3] = 0.1, 0.1
+	}
+	if l.gain < 0.999 {
+		t.Errorf("gain %v after a second of release", l.gain)
+	}
+}
+
+func TestCompressorResponse(t *testing.T) {
+	cases := []struct {
+		amp, want float32
+	}{
+		// 12 dB over at 4:1 is cut by 9 dB
+		{1, dbToGain(-9)},
+		{0.5, 0.5 * dbToGain(-6*0.75)},
+		{0.2, 0.2},
+	}
+	for _, c := range cases {
+		m := testMixer(t)
+		m.Bus(SfxBus).AddEffect(NewCompressor(DefaultRate, -12, 4))
+		playOn(m, level(c.amp, 1), SfxBus)
+		s := &captureSink{}
+		if err := m.Render(0.5, s); err != nil {
+			t.Fatal(err)
+		}
+		if got := s.left(1)[0]; glm.Abs(float64(got-c.want)) > 1e-3 {
+			t.Errorf("compressed %v to %v, want %v", c.amp, got, c.want)
+		}
+	}
+}
+
+func TestDelayResponse(t *testing.T) {
+	d := NewDelay(DefaultRate, 0.01, 0.5, 0.5)
+	n := DefaultRate / 100
+	buf := make([]float32, n*3*2)
+	buf[0], buf[1] = 1, 1
+	d.Apply(buf)
+	want := map[int]float32{0: 0.5, n: 0.5, 2 * n: 0.25}
+	for i := 0; i < len(buf); i += 2 {
+		if w := want[i/2]; glm.Abs(float64(buf[i]-w)) > 1e-6 || buf[i] != buf[i+1] {
+			t.Fatalf("frame %d: %v %v, want %v", i/2, buf[i], buf[i+1], w)
+		}
+	}
+}
+
+func TestEffectTString(t *testing.T) {
+	for e := LOWPASS; e <= LIMITER; e++ {
+		if StringToEffectT(e.String()) != e {
+			t.Errorf("%v round trip", e)
+		}
+	}
+}
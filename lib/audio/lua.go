@@ -0,0 +1,432 @@
+package audio
+
+import (
+	glm "math"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+const (
+	lSoundClass = "SOUND"
+	lVoiceClass = "VOICE"
+	lZoneClass  = "ZONE"
+)
+
+// voiceOptions applies {gain = 1, pan = 0, pitch = 1, loop = false,
+// bus = "sfx"}.
+func voiceOptions(L *l.LState, v *Voice, opts *l.LTable) {
+	if opts == nil {
+		return
+	}
+	if n, ok := opts.RawGetString("gain").(l.LNumber); ok {
+		v.SetGain(float32(n))
+	}
+	if n, ok := opts.RawGetString("pan").(l.LNumber); ok {
+		v.SetPan(float32(n))
+	}
+	if n, ok := opts.RawGetString("pitch").(l.LNumber); ok {
+		v.SetPitch(float32(n))
+	}
+	if b := opts.RawGetString("loop"); b != l.LNil {
+		v.SetLoop(l.LVAsBool(b))
+	}
+	if b := opts.RawGetString("bus"); b != l.LNil {
+		v.SetBus(busFrom(L, b))
+	}
+}
+
+func VoiceOptions(L *l.LState, v *Voice, opts *l.LTable) {
+	voiceOptions(L, v, opts)
+}
+
+// shv.sound("hit.wav"), fully decoded for playing any number of times at once
+func lSound(L *l.LState) int {
+	path := L.CheckString(1)
+	b, err := Load(path)
+	if err != nil {
+		L.RaiseError("error loading sound %s: %s", path, err)
+		return 0
+	}
+	return PushSound(L, b)
+}
+
+func PushSound(L *l.LState, b *Buffer) int {
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = b }, lSoundClass)
+	return 1
+}
+
+// shv.music("theme.ogg", {loop = true}), streamed from the file as it plays
+func lMusic(L *l.LState) int {
+	path := L.CheckString(1)
+	s, err := Open(path)
+	if err != nil {
+		L.RaiseError("error opening music %s: %s", path, err)
+		return 0
+	}
+	v := CurrentAudioSystem.Voice(s)
+	v.SetBus(CurrentAudioSystem.Bus(MusicBus))
+	voiceOptions(L, v, L.OptTable(2, nil))
+	return pushVoice(L, v)
+}
+
+func checkSound(L *l.LState, pos int) *Buffer {
+	ud := L.CheckUserData(pos)
+	if b, ok := ud.Value.(*Buffer); ok {
+		return b
+	}
+	L.ArgError(pos, "sound expected")
+	return nil
+}
+
+type soundMemberFunc func(*l.LState, *Buffer) int
+
+func soundMember(fn soundMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if b := checkSound(L, 1); b != nil {
+			return fn(L, b)
+		}
+		return 0
+	}
+}
+
+// sound:play({gain = 0.5}) plays the sound on a new voice, returning it
+func soundPlay(L *l.LState, b *Buffer) int {
+	v := CurrentAudioSystem.Voice(b.Source())
+	voiceOptions(L, v, L.OptTable(2, nil))
+	v.Play()
+	return pushVoice(L, v)
+}
+
+func getSoundDuration(L *l.LState, b *Buffer) int {
+	L.Push(l.LNumber(b.Duration()))
+	return 1
+}
+
+var soundTable = &lua.Table{
+	lSoundClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+	},
+	map[string]l.LGFunction{
+		"duration": lua.NewProperty(soundMember(getSoundDuration), nil),
+	},
+	map[string]l.LGFunction{
+		"play": soundMember(soundPlay),
+	},
+}
+
+func PushVoice(L *l.LState, v *Voice) int {
+	return pushVoice(L, v)
+}
+
+func pushVoice(L *l.LState, v *Voice) int {
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = v }, lVoiceClass)
+	return 1
+}
+
+func checkVoice(L *l.LState, pos int) *Voice {
+	ud := L.CheckUserData(pos)
+	if v, ok := ud.Value.(*Voice); ok {
+		return v
+	}
+	L.ArgError(pos, "voice expected")
+	return nil
+}
+
+type voiceMemberFunc func(*l.LState, *Voice) int
+
+func voiceMember(fn voiceMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if v := checkVoice(L, 1); v != nil {
+			return fn(L, v)
+		}
+		return 0
+	}
+}
+
+func voiceProperty(get, set voiceMemberFunc) l.LGFunction {
+	var lset l.LGFunction
+	if set != nil {
+		lset = voiceMember(set)
+	}
+	return lua.NewProperty(voiceMember(get), lset)
+}
+
+func voicePlay(L *l.LState, v *Voice) int {
+	v.Play()
+	L.Push(L.Get(1))
+	return 1
+}
+
+func voicePause(L *l.LState, v *Voice) int {
+	v.Pause()
+	return 0
+}
+
+func voiceStop(L *l.LState, v *Voice) int {
+	v.Stop()
+	return 0
+}
+
+func voiceClose(L *l.LState, v *Voice) int {
+	if err := v.Close(); err != nil {
+		L.RaiseError(err.Error())
+	}
+	return 0
+}
+
+func getVoicePlaying(L *l.LState, v *Voice) int {
+	L.Push(l.LBool(v.Playing()))
+	return 1
+}
+
+func getVoiceGain(L *l.LState, v *Voice) int {
+	L.Push(l.LNumber(v.Gain()))
+	return 1
+}
+
+func setVoiceGain(L *l.LState, v *Voice) int {
+	v.SetGain(float32(L.CheckNumber(3)))
+	return 0
+}
+
+func getVoicePan(L *l.LState, v *Voice) int {
+	L.Push(l.LNumber(v.Pan()))
+	return 1
+}
+
+func setVoicePan(L *l.LState, v *Voice) int {
+	v.SetPan(float32(L.CheckNumber(3)))
+	return 0
+}
+
+func getVoicePitch(L *l.LState, v *Voice) int {
+	L.Push(l.LNumber(v.Pitch()))
+	return 1
+}
+
+func setVoicePitch(L *l.LState, v *Voice) int {
+	v.SetPitch(float32(L.CheckNumber(3)))
+	return 0
+}
+
+func getVoiceBus(L *l.LState, v *Voice) int {
+	return pushBus(L, v.Bus())
+}
+
+func setVoiceBus(L *l.LState, v *Voice) int {
+	v.SetBus(busFrom(L, L.Get(3)))
+	return 0
+}
+
+func getVoiceLoop(L *l.LState, v *Voice) int {
+	L.Push(l.LBool(v.Loop()))
+	return 1
+}
+
+func setVoiceLoop(L *l.LState, v *Voice) int {
+	v.SetLoop(L.CheckBool(3))
+	return 0
+}
+
+var voiceTable = &lua.Table{
+	lVoiceClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+	},
+	map[string]l.LGFunction{
+		"playing": voiceProperty(getVoicePlaying, nil),
+		"gain":    voiceProperty(getVoiceGain, setVoiceGain),
+		"pan":     voiceProperty(getVoicePan, setVoicePan),
+		"pitch":   voiceProperty(getVoicePitch, setVoicePitch),
+		"loop":    voiceProperty(getVoiceLoop, setVoiceLoop),
+		"bus":     voiceProperty(getVoiceBus, setVoiceBus),
+	},
+	map[string]l.LGFunction{
+		"play":  voiceMember(voicePlay),
+		"pause": voiceMember(voicePause),
+		"stop":  voiceMember(voiceStop),
+		"close": voiceMember(voiceClose),
+	},
+}
+
+var SpatialError = xrror.Xrror("spatial %s: %v is not valid").Out
+
+func luaVec3(key string, v l.LValue) ([3]float32, error) {
+	var ret [3]float32
+	t, ok := v.(*l.LTable)
+	if !ok || t.Len() < 3 {
+		return ret, SpatialError(key, v)
+	}
+	for i := range ret {
+		ret[i] = float32(l.LVAsNumber(t.RawGetInt(i + 1)))
+	}
+	return ret, nil
+}
+
+func radians(deg l.LValue) float32 {
+	return float32(l.LVAsNumber(deg)) * glm.Pi / 180
+}
+
+// SpatialFrom configures a spatial voice from a lua table, cone angles in
+// degrees.
+//
+// {rolloff = "inverse" | "linear" | "exponential", ref = 1, max = 100,
+// factor = 1, cone = {inner = 60, outer = 120, gain = 0.2}, doppler = 1,
+// reverb = 0.3, lowpass = 8000}
+func SpatialFrom(s *Spatial, t *l.LTable) error {
+	var err error
+	t.ForEach(func(k, v l.LValue) {
+		if err != nil {
+			return
+		}
+		key := k.String()
+		switch key {
+		case "rolloff":
+			r := StringToRolloffT(v.String())
+			if r == UNKNOWN_ROLLOFF {
+				err = SpatialError(key, v)
+				return
+			}
+			s.Rolloff = r
+		case "ref":
+			s.RefDistance = float32(l.LVAsNumber(v))
+		case "max":
+			s.MaxDistance = float32(l.LVAsNumber(v))
+		case "factor":
+			s.RolloffFactor = float32(l.LVAsNumber(v))
+		case "cone":
+			ct, ok := v.(*l.LTable)
+			if !ok {
+				err = SpatialError(key, v)
+				return
+			}
+			s.ConeInner = radians(ct.RawGetString("inner"))
+			s.ConeOuter = radians(ct.RawGetString("outer"))
+			s.ConeOuterGain = float32(l.LVAsNumber(ct.RawGetString("gain")))
+		case "doppler":
+			s.Doppler = float32(l.LVAsNumber(v))
+		case "reverb":
+			s.Reverb = float32(l.LVAsNumber(v))
+		case "lowpass":
+			s.LowPass = float32(l.LVAsNumber(v))
+		}
+	})
+	return err
+}
+
+// shv.audio_zone{center = {0, 0, 0}, radius = 10, reverb = 0.5, lowpass = 4000}
+func lZone(L *l.LState) int {
+	t := L.CheckTable(1)
+	z := &Zone{
+		Radius:  float32(l.LVAsNumber(t.RawGetString("radius"))),
+		Reverb:  float32(l.LVAsNumber(t.RawGetString("reverb"))),
+		LowPass: float32(l.LVAsNumber(t.RawGetString("lowpass"))),
+	}
+	if c := t.RawGetString("center"); c != l.LNil {
+		var err error
+		if z.Center, err = luaVec3("center", c); err != nil {
+			L.RaiseError(err.Error())
+			return 0
+		}
+	}
+	CurrentAudioSystem.AddZone(z)
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = z }, lZoneClass)
+	return 1
+}
+
+func checkZone(L *l.LState, pos int) *Zone {
+	ud := L.CheckUserData(pos)
+	if z, ok := ud.Value.(*Zone); ok {
+		return z
+	}
+	L.ArgError(pos, "zone expected")
+	return nil
+}
+
+type zoneMemberFunc func(*l.LState, *Zone) int
+
+func zoneMember(fn zoneMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if z := checkZone(L, 1); z != nil {
+			return fn(L, z)
+		}
+		return 0
+	}
+}
+
+func zoneNumber(field func(*Zone) *float32) l.LGFunction {
+	return lua.NewProperty(
+		zoneMember(func(L *l.LState, z *Zone) int {
+			L.Push(l.LNumber(*field(z)))
+			return 1
+		}),
+		zoneMember(func(L *l.LState, z *Zone) int {
+			*field(z) = float32(L.CheckNumber(3))
+			return 0
+		}),
+	)
+}
+
+func zoneRemove(L *l.LState, z *Zone) int {
+	CurrentAudioSystem.RemoveZone(z)
+	return 0
+}
+
+var zoneTable = &lua.Table{
+	lZoneClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+	},
+	map[string]l.LGFunction{
+		"radius":  zoneNumber(func(z *Zone) *float32 { return &z.Radius }),
+		"reverb":  zoneNumber(func(z *Zone) *float32 { return &z.Reverb }),
+		"lowpass": zoneNumber(func(z *Zone) *float32 { return &z.LowPass }),
+	},
+	map[string]l.LGFunction{
+		"remove": zoneMember(zoneRemove),
+	},
+}
+
+// shv.reverb{room = 0.5, damp = 0.5, wet = 1} sets the reverb zones and
+// spatial voices send to
+func lReverb(L *l.LState) int {
+	t := L.CheckTable(1)
+	r := CurrentAudioSystem.Reverb()
+	if n, ok := t.RawGetString("room").(l.LNumber); ok {
+		r.Room = float32(n)
+	}
+	if n, ok := t.RawGetString("damp").(l.LNumber); ok {
+		r.Damp = float32(n)
+	}
+	if n, ok := t.RawGetString("wet").(l.LNumber); ok {
+		r.Wet = float32(n)
+	}
+	return 0
+}
+
+func RegisterWith() lua.RegisterWith {
+	return func(m lua.Module) error {
+		m.AddLGFunc("sound", lSound)
+		m.AddLGFunc("music", lMusic)
+		m.AddLGFunc("audio_zone", lZone)
+		m.AddLGFunc("reverb", lReverb)
+		m.AddLGFunc("bus", lBus)
+		m.AddLGFunc("audio_render", lAudioRender)
+		rmtfn := func(L *l.LState, M lua.Module) {
+			M.Register(L, soundTable)
+			M.Register(L, voiceTable)
+			M.Register(L, zoneTable)
+			M.Register(L, busTable)
+			M.Register(L, effectTable)
+		}
+		m.AddMT(rmtfn)
+		return nil
+	}
+}
@@ -0,0 +1,384 @@
+package audio
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+const (
+	lBusClass    = "BUS"
+	lEffectClass = "EFFECT"
+)
+
+var EffectError = xrror.Xrror("effect %s: %v is not valid").Out
+
+func number(t *l.LTable, key string, to *float32) {
+	if n, ok := t.RawGetString(key).(l.LNumber); ok {
+		*to = float32(n)
+	}
+}
+
+// configureEffect sets effect parameters from a table, leaving those not
+// given.
+func configureEffect(e Effect, t *l.LTable) {
+	switch ee := e.(type) {
+	case *Biquad:
+		number(t, "freq", &ee.Freq)
+		number(t, "q", &ee.Q)
+	case *Delay:
+		number(t, "time", &ee.Time)
+		number(t, "feedback", &ee.Feedback)
+		number(t, "mix", &ee.Mix)
+	case *Reverb:
+		number(t, "room", &ee.Room)
+		number(t, "damp", &ee.Damp)
+		number(t, "wet", &ee.Wet)
+	case *Compressor:
+		number(t, "threshold", &ee.Threshold)
+		number(t, "ratio", &ee.Ratio)
+		number(t, "attack", &ee.Attack)
+		number(t, "release", &ee.Release)
+		number(t, "makeup", &ee.Makeup)
+	case *Limiter:
+		number(t, "ceiling", &ee.Ceiling)
+		number(t, "release", &ee.Release)
+	}
+}
+
+// EffectFrom builds an effect from a lua table, dB levels and seconds
+// throughout.
+//
+// {kind = "lowpass" | "highpass", freq = 1000, q = 0.707}
+// {kind = "delay", time = 0.25, feedback = 0.4, mix = 0.3}
+// {kind = "reverb", room = 0.5, damp = 0.5, wet = 1}
+// {kind = "compressor", threshold = -18, ratio = 4, attack = 0.005, release = 0.1, makeup = 0}
+// {kind = "limiter", ceiling = -1, release = 0.05}
+func EffectFrom(rate int, t *l.LTable) (Effect, error) {
+	var e Effect
+	kind := t.RawGetString("kind")
+	switch StringToEffectT(kind.String()) {
+	case LOWPASS:
+		e = NewLowPass(rate, 1000, 0)
+	case HIGHPASS:
+		e = NewHighPass(rate, 1000, 0)
+	case DELAY:
+		e = NewDelay(rate, 0.25, 0.4, 0.3)
+	case REVERB:
+		e = NewReverb(rate)
+	case COMPRESSOR:
+		e = NewCompressor(rate, -18, 4)
+	case LIMITER:
+		e = NewLimiter(rate, -1)
+	default:
+		return nil, EffectError("kind", kind)
+	}
+	configureEffect(e, t)
+	return e, nil
+}
+
+func effectKind(e Effect) EffectT {
+	switch ee := e.(type) {
+	case *Biquad:
+		return ee.kind
+	case *Delay:
+		return DELAY
+	case *Reverb:
+		return REVERB
+	case *Compressor:
+		return COMPRESSOR
+	case *Limiter:
+		return LIMITER
+	}
+	return UNKNOWN_EFFECT
+}
+
+func pushEffect(L *l.LState, e Effect) int {
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = e }, lEffectClass)
+	return 1
+}
+
+func checkEffect(L *l.LState, pos int) Effect {
+	ud := L.CheckUserData(pos)
+	if e, ok := ud.Value.(Effect); ok {
+		return e
+	}
+	L.ArgError(pos, "effect expected")
+	return nil
+}
+
+type effectMemberFunc func(*l.LState, Effect) int
+
+func effectMember(fn effectMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if e := checkEffect(L, 1); e != nil {
+			return fn(L, e)
+		}
+		return 0
+	}
+}
+
+func effectSet(L *l.LState, e Effect) int {
+	configureEffect(e, L.CheckTable(2))
+	return 0
+}
+
+func effectReset(L *l.LState, e Effect) int {
+	e.Reset()
+	return 0
+}
+
+func getEffectKind(L *l.LState, e Effect) int {
+	L.Push(l.LString(effectKind(e).String()))
+	return 1
+}
+
+var effectTable = &lua.Table{
+	lEffectClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+	},
+	map[string]l.LGFunction{
+		"kind": lua.NewProperty(effectMember(getEffectKind), nil),
+	},
+	map[string]l.LGFunction{
+		"set":   effectMember(effectSet),
+		"reset": effectMember(effectReset),
+	},
+}
+
+// busFrom resolves a bus userdata or name.
+func busFrom(L *l.LState, v l.LValue) *Bus {
+	switch vv := v.(type) {
+	case *l.LUserData:
+		if b, ok := vv.Value.(*Bus); ok {
+			return b
+		}
+	case l.LString:
+		if b := CurrentAudioSystem.Bus(string(vv)); b != nil {
+			return b
+		}
+	}
+	L.RaiseError(UnknownBusError(v.String()).Error())
+	return nil
+}
+
+// duckFrom applies {key = "voice", amount = 12, threshold = -30,
+// attack = 0.01, release = 0.3}, or stops ducking for false.
+func duckFrom(L *l.LState, b *Bus, v l.LValue) {
+	t, ok := v.(*l.LTable)
+	if !ok {
+		if !l.LVAsBool(v) {
+			b.Duck(nil, 0, 0, 0, 0)
+		}
+		return
+	}
+	amount, threshold := float32(12), float32(-30)
+	attack, release := float32(0.01), float32(0.3)
+	number(t, "amount", &amount)
+	number(t, "threshold", &threshold)
+	number(t, "attack", &attack)
+	number(t, "release", &release)
+	key := busFrom(L, t.RawGetString("key"))
+	if err := b.Duck(key, amount, threshold, attack, release); err != nil {
+		L.RaiseError(err.Error())
+	}
+}
+
+func configureBus(L *l.LState, b *Bus, t *l.LTable) {
+	if p := t.RawGetString("parent"); p != l.LNil {
+		if err := b.SetParent(busFrom(L, p)); err != nil {
+			L.RaiseError(err.Error())
+			return
+		}
+	}
+	if n, ok := t.RawGetString("volume").(l.LNumber); ok {
+		b.SetVolume(float32(n))
+	}
+	if m := t.RawGetString("mute"); m != l.LNil {
+		b.SetMute(l.LVAsBool(m))
+	}
+	if et, ok := t.RawGetString("effects").(*l.LTable); ok {
+		b.ClearEffects()
+		for i := 1; i <= et.Len(); i++ {
+			ct, ok := et.RawGetInt(i).(*l.LTable)
+			if !ok {
+				L.RaiseError(EffectError("table", et.RawGetInt(i)).Error())
+				return
+			}
+			e, err := EffectFrom(b.m.f.SampleRate, ct)
+			if err != nil {
+				L.RaiseError(err.Error())
+				return
+			}
+			b.AddEffect(e)
+		}
+	}
+	if d := t.RawGetString("duck"); d != l.LNil {
+		duckFrom(L, b, d)
+	}
+}
+
+// shv.bus("music", {parent = "master", volume = 0.8, mute = false,
+// effects = {{kind = "lowpass", freq = 8000}}, duck = {key = "voice", amount = 12}})
+// returns the named bus, adding it if needed and configuring it when given
+// a table.
+func lBus(L *l.LState) int {
+	name := L.CheckString(1)
+	b := CurrentAudioSystem.AddBus(name, nil)
+	if t, ok := L.Get(2).(*l.LTable); ok {
+		configureBus(L, b, t)
+	}
+	return pushBus(L, b)
+}
+
+// shv.audio_render("out.wav", 10) mixes seconds of audio straight to a wav
+// file, independent of the frame clock.
+func lAudioRender(L *l.LState) int {
+	path := L.CheckString(1)
+	seconds := float32(L.CheckNumber(2))
+	if err := CurrentAudioSystem.Render(seconds, &wavSink{path: path}); err != nil {
+		L.RaiseError("error rendering audio to %s: %s", path, err)
+	}
+	return 0
+}
+
+func pushBus(L *l.LState, b *Bus) int {
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = b }, lBusClass)
+	return 1
+}
+
+func checkBus(L *l.LState, pos int) *Bus {
+	ud := L.CheckUserData(pos)
+	if b, ok := ud.Value.(*Bus); ok {
+		return b
+	}
+	L.ArgError(pos, "bus expected")
+	return nil
+}
+
+type busMemberFunc func(*l.LState, *Bus) int
+
+func busMember(fn busMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if b := checkBus(L, 1); b != nil {
+			return fn(L, b)
+		}
+		return 0
+	}
+}
+
+func busProperty(get, set busMemberFunc) l.LGFunction {
+	var lset l.LGFunction
+	if set != nil {
+		lset = busMember(set)
+	}
+	return lua.NewProperty(busMember(get), lset)
+}
+
+func busAddEffect(L *l.LState, b *Bus) int {
+	e, err := EffectFrom(b.m.f.SampleRate, L.CheckTable(2))
+	if err != nil {
+		L.RaiseError(err.Error())
+		return 0
+	}
+	b.AddEffect(e)
+	return pushEffect(L, e)
+}
+
+func busClearEffects(L *l.LState, b *Bus) int {
+	b.ClearEffects()
+	return 0
+}
+
+func busEffects(L *l.LState, b *Bus) int {
+	t := L.NewTable()
+	for _, e := range b.Effects() {
+		pushEffect(L, e)
+		t.Append(L.Get(-1))
+		L.Pop(1)
+	}
+	L.Push(t)
+	return 1
+}
+
+func busDuck(L *l.LState, b *Bus) int {
+	duckFrom(L, b, L.Get(2))
+	return 0
+}
+
+func busRemove(L *l.LState, b *Bus) int {
+	if err := b.m.RemoveBus(b.Name()); err != nil {
+		L.RaiseError(err.Error())
+	}
+	return 0
+}
+
+func getBusName(L *l.LState, b *Bus) int {
+	L.Push(l.LString(b.Name()))
+	return 1
+}
+
+func getBusVolume(L *l.LState, b *Bus) int {
+	L.Push(l.LNumber(b.Volume()))
+	return 1
+}
+
+func setBusVolume(L *l.LState, b *Bus) int {
+	b.SetVolume(float32(L.CheckNumber(3)))
+	return 0
+}
+
+func getBusMute(L *l.LState, b *Bus) int {
+	L.Push(l.LBool(b.Mute()))
+	return 1
+}
+
+func setBusMute(L *l.LState, b *Bus) int {
+	b.SetMute(L.CheckBool(3))
+	return 0
+}
+
+func getBusParent(L *l.LState, b *Bus) int {
+	if p := b.Parent(); p != nil {
+		return pushBus(L, p)
+	}
+	L.Push(l.LNil)
+	return 1
+}
+
+func setBusParent(L *l.LState, b *Bus) int {
+	if err := b.SetParent(busFrom(L, L.Get(3))); err != nil {
+		L.RaiseError(err.Error())
+	}
+	return 0
+}
+
+func getBusDucked(L *l.LState, b *Bus) int {
+	L.Push(l.LNumber(b.Ducked()))
+	return 1
+}
+
+var busTable = &lua.Table{
+	lBusClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+	},
+	map[string]l.LGFunction{
+		"name":   busProperty(getBusName, nil),
+		"volume": busProperty(getBusVolume, setBusVolume),
+		"mute":   busProperty(getBusMute, setBusMute),
+		"parent": busProperty(getBusParent, setBusParent),
+		"ducked": busProperty(getBusDucked, nil),
+	},
+	map[string]l.LGFunction{
+		"add_effect":    busMember(busAddEffect),
+		"clear_effects": busMember(busClearEffects),
+		"effects":       busMember(busEffects),
+		"duck":          busMember(busDuck),
+		"remove":        busMember(busRemove),
+	},
+}
@@ -0,0 +1,335 @@
+package audio
+
+var CurrentAudioSystem *Mixer
+
+// DefaultRate is the mixer output sample rate.
+const DefaultRate = 44100
+
+// Mixer sums playing voices into interleaved stereo written to a sink, as
+// much audio each update as time has passed.
+type Mixer struct {
+	f      Format
+	sink   Sink
+	voices []*Voice
+	Gain   float32
+	acc    float64
+	buf    []float32
+	send   []float32
+	l      *Listener
+	zones  []*Zone
+	reverb *Reverb
+	master *Bus
+	buses  []*Bus
+	order  []*Bus
+}
+
+func NewMixer(rate int, s Sink) (*Mixer, error) {
+	if rate <= 0 {
+		rate = DefaultRate
+	}
+	m := &Mixer{
+		f:      Format{rate, 2},
+		voices: make([]*Voice, 0),
+		Gain:   1,
+		buf:    make([]float32, 0, rate/10*2),
+		send:   make([]float32, 0, rate/10*2),
+		l:      newListener(),
+		zones:  make([]*Zone, 0),
+		reverb: NewReverb(rate),
+		buses:  make([]*Bus, 0),
+	}
+	m.master = newBus(m, MasterBus, nil)
+	m.buses = append(m.buses, m.master)
+	for _, name := range []string{MusicBus, SfxBus, VoiceBus} {
+		m.AddBus(name, m.master)
+	}
+	m.reorder()
+	return m, m.SetSink(s)
+}
+
+func (m *Mixer) Format() Format {
+	return m.f
+}
+
+// SetSink closes the current sink and opens another, nil for the null sink.
+func (m *Mixer) SetSink(s Sink) error {
+	if s == nil {
+		s = &nullSink{}
+	}
+	if m.sink != nil {
+		if err := m.sink.Close(); err != nil {
+			return err
+		}
+	}
+	m.sink = s
+	return s.Open(m.f)
+}
+
+// Voice returns a new stopped voice playing src through the sfx bus.
+func (m *Mixer) Voice(src Source) *Voice {
+	v := newVoice(m, src)
+	v.bus = m.Bus(SfxBus)
+	return v
+}
+
+func (m *Mixer) Master() *Bus {
+	return m.master
+}
+
+// Bus is the named bus, nil if there is none.
+func (m *Mixer) Bus(name string) *Bus {
+	for _, b := range m.buses {
+		if b.name == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// AddBus returns the named bus, adding it fed into parent, master for nil,
+// if it does not exist.
+func (m *Mixer) AddBus(name string, parent *Bus) *Bus {
+	if b := m.Bus(name); b != nil {
+		return b
+	}
+	if parent == nil {
+		parent = m.master
+	}
+	b := newBus(m, name, parent)
+	m.buses = append(m.buses, b)
+	m.reorder()
+	return b
+}
+
+// RemoveBus removes a bus other than master, rerouting what fed it to its
+// parent and dropping the ducking it keyed.
+func (m *Mixer) RemoveBus(name string) error {
+	b := m.Bus(name)
+	switch {
+	case b == nil:
+		return UnknownBusError(name)
+	case b == m.master:
+		return nil
+	}
+	for idx, h := range m.buses {
+		if h == b {
+			m.buses = append(m.buses[:idx], m.buses[idx+1:]...)
+			break
+		}
+	}
+	for _, h := range m.buses {
+		if h.parent == b {
+			h.parent = b.parent
+		}
+		if h.duck != nil && h.duck.key == b {
+			h.duck = nil
+		}
+	}
+	for _, v := range m.voices {
+		if v.bus == b {
+			v.bus = b.parent
+		}
+	}
+	b.removed = true
+	m.reorder()
+	return nil
+}
+
+// Buses is every bus, master first.
+func (m *Mixer) Buses() []*Bus {
+	return m.buses
+}
+
+// reorder sorts the buses so each is processed after the buses feeding it
+// and the bus keying its ducking.
+func (m *Mixer) reorder() {
+	order := make([]*Bus, 0, len(m.buses))
+	seen := make(map[*Bus]bool)
+	var visit func(*Bus)
+	visit = func(b *Bus) {
+		if seen[b] {
+			return
+		}
+		seen[b] = true
+		if b.duck != nil {
+			visit(b.duck.key)
+		}
+		for _, h := range m.buses {
+			if h.parent == b {
+				visit(h)
+			}
+		}
+		order = append(order, b)
+	}
+	for _, b := range m.buses {
+		visit(b)
+	}
+	m.order = order
+}
+
+// Play plays a buffer on a new voice.
+func (m *Mixer) Play(b *Buffer) *Voice {
+	v := m.Voice(b.Source())
+	v.Play()
+	return v
+}
+
+func (m *Mixer) add(v *Voice) {
+	for _, h := range m.voices {
+		if h == v {
+			return
+		}
+	}
+	m.voices = append(m.voices, v)
+}
+
+// Listener is where spatial voices are heard from.
+func (m *Mixer) Listener() *Listener {
+	return m.l
+}
+
+// Reverb is the reverb spatial voices send to.
+func (m *Mixer) Reverb() *Reverb {
+	return m.reverb
+}
+
+func (m *Mixer) AddZone(zs ...*Zone) {
+	m.zones = append(m.zones, zs...)
+}
+
+func (m *Mixer) RemoveZone(z *Zone) {
+	for idx, h := range m.zones {
+		if h == z {
+			m.zones = append(m.zones[:idx], m.zones[idx+1:]...)
+			return
+		}
+	}
+}
+
+// Voices is the number of voices playing.
+func (m *Mixer) Voices() int {
+	return len(m.voices)
+}
+
+// Mix fills interleaved stereo out with the playing voices, dropping those
+// no longer playing.
+func (m *Mixer) Mix(out []float32) {
+	for _, b := range m.buses {
+		b.clear(len(out))
+	}
+	if cap(m.send) < len(out) {
+		m.send = make([]float32, len(out))
+	}
+	m.send = m.send[:len(out)]
+	for i := range m.send {
+		m.send[i] = 0
+	}
+	playing := m.voices[:0]
+	for _, v := range m.voices {
+		if v.bus == nil || v.bus.removed {
+			v.bus = m.master
+		}
+		if v.mix(v.bus.buf, m.send, m.f.SampleRate, m.l, m.zones) {
+			playing = append(playing, v)
+		}
+	}
+	for i := len(playing); i < len(m.voices); i++ {
+		m.voices[i] = nil
+	}
+	m.voices = playing
+	m.reverb.Process(m.send, m.master.buf)
+	for _, b := range m.order {
+		b.process(m.f.SampleRate)
+	}
+	for i := range out {
+		out[i] = clamp(m.master.buf[i]*m.Gain, -1, 1)
+	}
+}
+
+func (m *Mixer) Priority() int {
+	return 4
+}
+
+// Update mixes the frame delta, in nanoseconds, worth of audio to the sink.
+// Long stalls are capped at a quarter second rather than caught up.
+func (m *Mixer) Update(d int64) error {
+	m.acc += float64(d) * float64(m.f.SampleRate) / 1e9
+	frames := int(m.acc)
+	m.acc -= float64(frames)
+	m.step(float32(d) / 1e9)
+	if max := m.f.SampleRate / 4; frames > max {
+		frames = max
+	}
+	return m.write(frames, m.sink)
+}
+
+func (m *Mixer) step(dt float32) {
+	m.l.step(dt)
+	for _, v := range m.voices {
+		if v.spatial != nil {
+			v.spatial.step(dt)
+		}
+	}
+}
+
+func (m *Mixer) write(frames int, s Sink) error {
+	for frames > 0 {
+		n := frames
+		if c := cap(m.buf) / 2; n > c {
+			n = c
+		}
+		m.buf = m.buf[:n*2]
+		m.Mix(m.buf)
+		if err := s.Write(m.buf); err != nil {
+			return err
+		}
+		frames -= n
+	}
+	return nil
+}
+
+// Render mixes seconds of audio straight to a sink as fast as it can,
+// independent of frame timing, so the same graph and voices always render
+// the same samples. The sink is opened and closed around the render.
+func (m *Mixer) Render(seconds float32, s Sink) error {
+	if err := s.Open(m.f); err != nil {
+		return err
+	}
+	frames := int(seconds * float32(m.f.SampleRate))
+	block := cap(m.buf) / 2
+	for frames > 0 {
+		n := block
+		if n > frames {
+			n = frames
+		}
+		m.step(float32(n) / float32(m.f.SampleRate))
+		if err := m.write(n, s); err != nil {
+			s.Close()
+			return err
+		}
+		frames -= n
+	}
+	return s.Close()
+}
+
+func (m *Mixer) Remove(id uint64) {
+	for idx, v := range m.voices {
+		if v.ID() == id {
+			m.voices = append(m.voices[:idx], m.voices[idx+1:]...)
+			return
+		}
+	}
+}
+
+// Close stops every voice and closes the sink.
+func (m *Mixer) Close() error {
+	for _, v := range m.voices {
+		v.playing = false
+	}
+	m.voices = m.voices[:0]
+	return m.sink.Close()
+}
+
+func init() {
+	CurrentAudioSystem, _ = NewMixer(DefaultRate, nil)
+}
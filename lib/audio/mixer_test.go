@@ -0,0 +1,243 @@
+package audio
+
+import (
+	"bytes"
+	glm "math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// captureSink keeps every sample written to it.
+type captureSink struct {
+	f       Format
+	samples []float32
+	closed  bool
+}
+
+func (c *captureSink) Open(f Format) error {
+	c.f, c.samples, c.closed = f, c.samples[:0], false
+	return nil
+}
+
+func (c *captureSink) Write(s []float32) error {
+	c.samples = append(c.samples, s...)
+	return nil
+}
+
+func (c *captureSink) Close() error {
+	c.closed = true
+	return nil
+}
+
+// left is the left channel of the last frames written.
+func (c *captureSink) left(frames int) []float32 {
+	out := make([]float32, 0, frames)
+	for i := len(c.samples) - frames*2; i < len(c.samples); i += 2 {
+		out = append(out, c.samples[i])
+	}
+	return out
+}
+
+// tone is seconds of a mono sine at the default rate.
+func tone(freq, amp, seconds float32) *Buffer {
+	n := int(seconds * DefaultRate)
+	b := &Buffer{Format{DefaultRate, 1}, make([]float32, n)}
+	for i := range b.Data {
+		b.Data[i] = amp * float32(glm.Sin(2*glm.Pi*float64(freq)*float64(i)/DefaultRate))
+	}
+	return b
+}
+
+// level is seconds of a mono constant at the default rate.
+func level(amp, seconds float32) *Buffer {
+	n := int(seconds * DefaultRate)
+	b := &Buffer{Format{DefaultRate, 1}, make([]float32, n)}
+	for i := range b.Data {
+		b.Data[i] = amp
+	}
+	return b
+}
+
+func testMixer(t *testing.T) *Mixer {
+	m, err := NewMixer(DefaultRate, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func playOn(m *Mixer, b *Buffer, bus string) *Voice {
+	v := m.Voice(b.Source())
+	v.SetBus(m.Bus(bus))
+	v.Play()
+	return v
+}
+
+// graph plays music, effects and dialogue through filtered, delayed,
+// compressed and ducked buses into a limited master.
+func graph(t *testing.T) *Mixer {
+	m := testMixer(t)
+	music, sfx, voice := m.Bus(MusicBus), m.Bus(SfxBus), m.Bus(VoiceBus)
+	music.AddEffect(NewLowPass(DefaultRate, 2000, 1), NewCompressor(DefaultRate, -12, 4))
+	sfx.AddEffect(NewHighPass(DefaultRate, 500, 0), NewDelay(DefaultRate, 0.05, 0.4, 0.3))
+	if err := music.Duck(voice, 9, -30, 0.01, 0.2); err != nil {
+		t.Fatal(err)
+	}
+	m.Master().AddEffect(NewLimiter(DefaultRate, -1))
+	playOn(m, tone(220, 0.6, 1), MusicBus).SetLoop(true)
+	s := playOn(m, tone(3000, 0.5, 0.1), SfxBus)
+	s.SetPan(-0.5)
+	s.SetPitch(1.5)
+	playOn(m, tone(440, 0.4, 0.3), VoiceBus)
+	return m
+}
+
+func TestRenderDeterministic(t *testing.T) {
+	a, b := &captureSink{}, &captureSink{}
+	if err := graph(t).Render(0.5, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := graph(t).Render(0.5, b); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.samples) != DefaultRate || !a.closed || a.f != (Format{DefaultRate, 2}) {
+		t.Fatalf("rendered %d samples of %v, closed %v", len(a.samples), a.f, a.closed)
+	}
+	if len(b.samples) != len(a.samples) {
+		t.Fatalf("renders of %d and %d samples", len(a.samples), len(b.samples))
+	}
+	var loud bool
+	for i := range a.samples {
+		if a.samples[i] != b.samples[i] {
+			t.Fatalf("sample %d: %v then %v", i, a.samples[i], b.samples[i])
+		}
+		loud = loud || a.samples[i] != 0
+	}
+	if !loud {
+		t.Fatal("rendered silence")
+	}
+}
+
+func TestRenderWAV(t *testing.T) {
+	dir := t.TempDir()
+	var files [2][]byte
+	for i := range files {
+		path := filepath.Join(dir, "render.wav")
+		s, err := NewSink("wav", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := graph(t).Render(0.25, s); err != nil {
+			t.Fatal(err)
+		}
+		if files[i], err = os.ReadFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(files[0], files[1]) {
+		t.Fatal("wav renders differ")
+	}
+	c := &captureSink{}
+	if err := graph(t).Render(0.25, c); err != nil {
+		t.Fatal(err)
+	}
+	src, err := DecodeWAV(bytes.NewReader(files[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := ReadAll(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Format != (Format{DefaultRate, 2}) || len(w.Data) != len(c.samples) {
+		t.Fatalf("wav of %d samples at %v, want %d", len(w.Data), w.Format, len(c.samples))
+	}
+	for i := range w.Data {
+		if d := w.Data[i] - c.samples[i]; d > 1.0/16384 || d < -1.0/16384 {
+			t.Fatalf("sample %d: wav %v, mix %v", i, w.Data[i], c.samples[i])
+		}
+	}
+}
+
+func TestRenderDucking(t *testing.T) {
+	m := testMixer(t)
+	music, voice := m.Bus(MusicBus), m.Bus(VoiceBus)
+	if err := music.Duck(voice, 12, -20, 0.005, 0.05); err != nil {
+		t.Fatal(err)
+	}
+	playOn(m, level(0.5, 1), MusicBus).SetLoop(true)
+	playOn(m, level(0.25, 0.2), VoiceBus)
+
+	c := &captureSink{}
+	if err := m.Render(0.15, c); err != nil {
+		t.Fatal(err)
+	}
+	ducked := dbToGain(-12)
+	if g := music.Ducked(); glm.Abs(float64(g-ducked)) > 1e-3 {
+		t.Errorf("ducked gain %v, want %v", g, ducked)
+	}
+	want := 0.5*ducked + 0.25
+	for _, s := range c.left(100) {
+		if glm.Abs(float64(s-want)) > 1e-3 {
+			t.Fatalf("ducked mix %v, want %v", s, want)
+		}
+	}
+
+	// the voice ends at 0.2 seconds and the music recovers
+	if err := m.Render(0.6, c); err != nil {
+		t.Fatal(err)
+	}
+	if g := music.Ducked(); glm.Abs(float64(g-1)) > 1e-3 {
+		t.Errorf("released gain %v, want 1", g)
+	}
+	for _, s := range c.left(100) {
+		if glm.Abs(float64(s-0.5)) > 1e-3 {
+			t.Fatalf("released mix %v, want 0.5", s)
+		}
+	}
+
+	if err := music.Duck(nil, 0, 0, 0, 0); err != nil || music.Ducked() != 1 {
+		t.Errorf("unducking %v, gain %v", err, music.Ducked())
+	}
+	if err := music.Duck(m.Master(), 6, -20, 0, 0); err == nil {
+		t.Error("bus ducked by the master it feeds")
+	}
+}
+
+func TestRenderBusRouting(t *testing.T) {
+	m := testMixer(t)
+	fx := m.AddBus("fx", m.Bus(SfxBus))
+	fx.SetVolume(0.5)
+	m.Bus(SfxBus).SetVolume(0.5)
+	playOn(m, level(0.8, 1), "fx")
+	muted := playOn(m, level(0.8, 1), MusicBus)
+	m.Bus(MusicBus).SetMute(true)
+
+	c := &captureSink{}
+	if err := m.Render(0.1, c); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range c.left(100) {
+		if glm.Abs(float64(s-0.2)) > 1e-5 {
+			t.Fatalf("routed mix %v, want 0.2", s)
+		}
+	}
+	if err := m.Bus(SfxBus).SetParent(fx); err == nil {
+		t.Error("bus fed by its child")
+	}
+	if err := m.RemoveBus("fx"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Render(0.1, c); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range c.left(100) {
+		if glm.Abs(float64(s-0.4)) > 1e-5 {
+			t.Fatalf("rerouted mix %v, want 0.4", s)
+		}
+	}
+	if muted.Bus() != m.Bus(MusicBus) || !muted.Playing() {
+		t.Error("muted voice stopped")
+	}
+}
@@ -0,0 +1,64 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// mp3 decodes to 16 bit little endian stereo
+const mp3FrameBytes = 4
+
+type mp3Source struct {
+	r   io.ReadSeeker
+	d   *mp3.Decoder
+	f   Format
+	raw []byte
+}
+
+// DecodeMP3 streams mp3 data from r.
+func DecodeMP3(r io.ReadSeeker) (Source, error) {
+	d, err := mp3.NewDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	return &mp3Source{r: r, d: d, f: Format{d.SampleRate(), 2}}, nil
+}
+
+func (s *mp3Source) Format() Format {
+	return s.f
+}
+
+func (s *mp3Source) Read(buf []float32) (int, error) {
+	n := len(buf) - len(buf)%2
+	if cap(s.raw) < n*2 {
+		s.raw = make([]byte, n*2)
+	}
+	raw := s.raw[:n*2]
+	read, err := io.ReadFull(s.d, raw)
+	n = read / 2
+	for i := 0; i < n; i++ {
+		buf[i] = float32(int16(binary.LittleEndian.Uint16(raw[i*2:]))) / 32768
+	}
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (s *mp3Source) Seek(frame int) error {
+	_, err := s.d.Seek(int64(frame*mp3FrameBytes), io.SeekStart)
+	return err
+}
+
+func (s *mp3Source) Frames() int {
+	if n := s.d.Length(); n > 0 {
+		return int(n / mp3FrameBytes)
+	}
+	return -1
+}
+
+func (s *mp3Source) Close() error {
+	return closeReader(s.r)
+}
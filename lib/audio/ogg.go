@@ -0,0 +1,45 @@
+package audio
+
+import (
+	"io"
+
+	"github.com/jfreymuth/oggvorbis"
+)
+
+type oggSource struct {
+	r io.ReadSeeker
+	d *oggvorbis.Reader
+	f Format
+}
+
+// DecodeOgg streams ogg vorbis data from r.
+func DecodeOgg(r io.ReadSeeker) (Source, error) {
+	d, err := oggvorbis.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &oggSource{r, d, Format{d.SampleRate(), d.Channels()}}, nil
+}
+
+func (s *oggSource) Format() Format {
+	return s.f
+}
+
+func (s *oggSource) Read(buf []float32) (int, error) {
+	return s.d.Read(buf)
+}
+
+func (s *oggSource) Seek(frame int) error {
+	return s.d.SetPosition(int64(frame))
+}
+
+func (s *oggSource) Frames() int {
+	if n := s.d.Length(); n > 0 {
+		return int(n)
+	}
+	return -1
+}
+
+func (s *oggSource) Close() error {
+	return closeReader(s.r)
+}
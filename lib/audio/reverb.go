@@ -0,0 +1,136 @@
+package audio
+
+// comb and allpass delay lengths in frames at 44100 Hz, the right channel
+// spread a little apart to decorrelate it from the left
+var (
+	combTuning    = []int{1116, 1188, 1277, 1356}
+	allpassTuning = []int{556, 441}
+)
+
+const reverbSpread = 23
+
+type comb struct {
+	buf      []float32
+	i        int
+	store    float32
+	feedback float32
+	damp     float32
+}
+
+func (c *comb) process(x float32) float32 {
+	y := c.buf[c.i]
+	c.store = y*(1-c.damp) + c.store*c.damp
+	c.buf[c.i] = x + c.store*c.feedback
+	if c.i++; c.i == len(c.buf) {
+		c.i = 0
+	}
+	return y
+}
+
+type allpass struct {
+	buf []float32
+	i   int
+}
+
+func (a *allpass) process(x float32) float32 {
+	b := a.buf[a.i]
+	a.buf[a.i] = x + b*0.5
+	if a.i++; a.i == len(a.buf) {
+		a.i = 0
+	}
+	return b - x
+}
+
+type reverbChannel struct {
+	combs     []*comb
+	allpasses []*allpass
+}
+
+func newReverbChannel(rate, spread int) *reverbChannel {
+	frames := func(n int) int {
+		if f := (n + spread) * rate / 44100; f > 0 {
+			return f
+		}
+		return 1
+	}
+	c := &reverbChannel{}
+	for _, n := range combTuning {
+		c.combs = append(c.combs, &comb{buf: make([]float32, frames(n))})
+	}
+	for _, n := range allpassTuning {
+		c.allpasses = append(c.allpasses, &allpass{buf: make([]float32, frames(n))})
+	}
+	return c
+}
+
+func (c *reverbChannel) process(x float32) float32 {
+	var y float32
+	for _, cb := range c.combs {
+		y += cb.process(x)
+	}
+	for _, ap := range c.allpasses {
+		y = ap.process(y)
+	}
+	return y
+}
+
+// Reverb is a small Schroeder reverb of parallel combs into series allpasses.
+// Room sizes from 0 to 1 lengthen the tail, Damp darkens it.
+type Reverb struct {
+	Room, Damp, Wet float32
+	l, r            *reverbChannel
+	dry             []float32
+}
+
+func NewReverb(rate int) *Reverb {
+	return &Reverb{
+		Room: 0.5,
+		Damp: 0.5,
+		Wet:  1,
+		l:    newReverbChannel(rate, 0),
+		r:    newReverbChannel(rate, reverbSpread),
+	}
+}
+
+func (r *Reverb) tune() {
+	feedback := 0.7 + clamp(r.Room, 0, 1)*0.28
+	damp := clamp(r.Damp, 0, 1) * 0.4
+	for _, c := range [][]*comb{r.l.combs, r.r.combs} {
+		for _, cb := range c {
+			cb.feedback, cb.damp = feedback, damp
+		}
+	}
+}
+
+// Process adds the reverb of interleaved stereo in to out.
+func (r *Reverb) Process(in, out []float32) {
+	r.tune()
+	wet := r.Wet * 0.25
+	for i := 0; i+1 < len(in) && i+1 < len(out); i += 2 {
+		x := (in[i] + in[i+1]) * 0.5
+		out[i] += r.l.process(x) * wet
+		out[i+1] += r.r.process(x) * wet
+	}
+}
+
+// Apply adds the reverb of buf to itself, as an effect.
+func (r *Reverb) Apply(buf []float32) {
+	r.dry = append(r.dry[:0], buf...)
+	r.Process(r.dry, buf)
+}
+
+func (r *Reverb) Reset() {
+	for _, c := range []*reverbChannel{r.l, r.r} {
+		for _, cb := range c.combs {
+			for i := range cb.buf {
+				cb.buf[i] = 0
+			}
+			cb.store = 0
+		}
+		for _, ap := range c.allpasses {
+			for i := range ap.buf {
+				ap.buf[i] = 0
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package audio
+
+import (
+	"os"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// Sink is where the mixer writes interleaved float32 samples.
+type Sink interface {
+	Open(Format) error
+	Write([]float32) error
+	Close() error
+}
+
+type SinkT int
+
+const (
+	UNKNOWN_SINK SinkT = iota
+	NULL
+	WAV
+	DEVICE
+)
+
+func (s SinkT) String() string {
+	switch s {
+	case NULL:
+		return "null"
+	case WAV:
+		return "wav"
+	case DEVICE:
+		return "device"
+	}
+	return "UNKNOWN_SINK"
+}
+
+func StringToSinkT(s string) SinkT {
+	switch strings.ToLower(s) {
+	case "null":
+		return NULL
+	case "wav":
+		return WAV
+	case "device":
+		return DEVICE
+	}
+	return UNKNOWN_SINK
+}
+
+var DefaultSink = DEVICE
+
+// NewSinkFunc builds a sink from an implementation specific argument, e.g.
+// a file path.
+type NewSinkFunc func(string) (Sink, error)
+
+type sr struct {
+	has map[SinkT]NewSinkFunc
+}
+
+func (s *sr) add(name string, fn NewSinkFunc) {
+	s.has[StringToSinkT(name)] = fn
+}
+
+func (s *sr) get(name, arg string) (Sink, error) {
+	if fn, exists := s.has[StringToSinkT(name)]; exists {
+		return fn(arg)
+	}
+	return nil, UnknownSinkError(name)
+}
+
+var SinkRegistry *sr
+
+func Register(name string, fn NewSinkFunc) {
+	SinkRegistry.add(name, fn)
+}
+
+var UnknownSinkError = xrror.Xrror("%s is not a known audio sink").Out
+
+func NewSink(name, arg string) (Sink, error) {
+	return SinkRegistry.get(name, arg)
+}
+
+// nullSink discards everything, for headless runs.
+type nullSink struct{}
+
+func (n *nullSink) Open(Format) error     { return nil }
+func (n *nullSink) Write([]float32) error { return nil }
+func (n *nullSink) Close() error          { return nil }
+
+// wavSink records the mix to a 16 bit pcm wav file, patching the header
+// sizes on close.
+type wavSink struct {
+	path string
+	f    Format
+	file *os.File
+	size uint32
+	raw  []byte
+}
+
+func (w *wavSink) Open(f Format) error {
+	file, err := os.Create(w.path)
+	if err != nil {
+		return err
+	}
+	w.f, w.file, w.size = f, file, 0
+	_, err = file.Write(wavHeader(f, 0))
+	return err
+}
+
+func (w *wavSink) Write(samples []float32) error {
+	if w.file == nil {
+		return nil
+	}
+	w.raw = PCM16(samples, w.raw)
+	n, err := w.file.Write(w.raw)
+	w.size += uint32(n)
+	return err
+}
+
+func (w *wavSink) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	file := w.file
+	w.file = nil
+	if _, err := file.WriteAt(wavHeader(w.f, w.size), 0); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func init() {
+	SinkRegistry = &sr{make(map[SinkT]NewSinkFunc)}
+	Register("null", func(string) (Sink, error) { return &nullSink{}, nil })
+	Register("wav", func(path string) (Sink, error) {
+		if path == "" {
+			path = "shiva.wav"
+		}
+		return &wavSink{path: path}, nil
+	})
+}
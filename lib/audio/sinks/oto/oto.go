@@ -0,0 +1,73 @@
+package oto
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/audio"
+
+	"github.com/hajimehoshi/oto"
+)
+
+// writes queued ahead of the device before the mix is dropped
+const queued = 8
+
+// sink plays the mix on the default audio device. Writes are queued to a
+// goroutine so a slow device never blocks the frame.
+type sink struct {
+	ctx    *oto.Context
+	player *oto.Player
+	q      chan []byte
+	done   chan struct{}
+}
+
+func New(string) (audio.Sink, error) {
+	return &sink{}, nil
+}
+
+func (s *sink) Open(f audio.Format) error {
+	// about a tenth of a second of 16 bit samples
+	ctx, err := oto.NewContext(f.SampleRate, f.Channels, 2, f.SampleRate/10*f.Channels*2)
+	if err != nil {
+		return err
+	}
+	s.ctx = ctx
+	s.player = ctx.NewPlayer()
+	s.q = make(chan []byte, queued)
+	s.done = make(chan struct{})
+	go s.play()
+	return nil
+}
+
+func (s *sink) play() {
+	defer close(s.done)
+	for b := range s.q {
+		if _, err := s.player.Write(b); err != nil {
+			return
+		}
+	}
+}
+
+func (s *sink) Write(samples []float32) error {
+	if s.q == nil {
+		return nil
+	}
+	b := audio.PCM16(samples, nil)
+	select {
+	case s.q <- b:
+	default:
+	}
+	return nil
+}
+
+func (s *sink) Close() error {
+	if s.q == nil {
+		return nil
+	}
+	close(s.q)
+	<-s.done
+	s.q = nil
+	s.player.Close()
+	return s.ctx.Close()
+}
+
+func init() {
+	audio.Register("device", New)
+}
@@ -0,0 +1,5 @@
+package sinks
+
+import (
+	_ "github.com/Laughs-In-Flowers/shiva/lib/audio/sinks/oto"
+)
@@ -0,0 +1,105 @@
+package audio
+
+import (
+	"io"
+)
+
+// Format is the sample rate and channel count of audio data.
+type Format struct {
+	SampleRate int
+	Channels   int
+}
+
+// Source is decoded audio read as interleaved float32 samples in [-1, 1].
+type Source interface {
+	Format() Format
+	// Read fills buf with interleaved samples, returning io.EOF at the end.
+	Read([]float32) (int, error)
+	// Seek moves to a frame, a frame being one sample per channel.
+	Seek(int) error
+	// Frames is the length in frames, -1 when unknown.
+	Frames() int
+	Close() error
+}
+
+// Buffer is fully decoded audio, shared by any number of voices.
+type Buffer struct {
+	Format
+	Data []float32
+}
+
+func (b *Buffer) Frames() int {
+	if b.Channels == 0 {
+		return 0
+	}
+	return len(b.Data) / b.Channels
+}
+
+// Duration is the length in seconds.
+func (b *Buffer) Duration() float32 {
+	if b.SampleRate == 0 {
+		return 0
+	}
+	return float32(b.Frames()) / float32(b.SampleRate)
+}
+
+// Source returns a new reader over the buffer.
+func (b *Buffer) Source() Source {
+	return &bufferSource{b, 0}
+}
+
+type bufferSource struct {
+	b   *Buffer
+	pos int
+}
+
+func (s *bufferSource) Format() Format {
+	return s.b.Format
+}
+
+func (s *bufferSource) Read(buf []float32) (int, error) {
+	if s.pos >= len(s.b.Data) {
+		return 0, io.EOF
+	}
+	n := copy(buf, s.b.Data[s.pos:])
+	s.pos += n
+	return n, nil
+}
+
+func (s *bufferSource) Seek(frame int) error {
+	s.pos = frame * s.b.Channels
+	if s.pos > len(s.b.Data) {
+		s.pos = len(s.b.Data)
+	}
+	return nil
+}
+
+func (s *bufferSource) Frames() int {
+	return s.b.Frames()
+}
+
+func (s *bufferSource) Close() error {
+	return nil
+}
+
+// ReadAll decodes the rest of a source into a buffer and closes it.
+func ReadAll(s Source) (*Buffer, error) {
+	defer s.Close()
+	f := s.Format()
+	size := 4096
+	if n := s.Frames(); n > 0 {
+		size = n * f.Channels
+	}
+	b := &Buffer{f, make([]float32, 0, size)}
+	chunk := make([]float32, 4096*f.Channels)
+	for {
+		n, err := s.Read(chunk)
+		b.Data = append(b.Data, chunk[:n]...)
+		if err == io.EOF {
+			return b, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
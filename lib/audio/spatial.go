@@ -0,0 +1,287 @@
+package audio
+
+import (
+	glm "math"
+	"strings"
+)
+
+type RolloffT int
+
+const (
+	UNKNOWN_ROLLOFF RolloffT = iota
+	LINEAR
+	INVERSE
+	EXPONENTIAL
+)
+
+func (r RolloffT) String() string {
+	switch r {
+	case LINEAR:
+		return "linear"
+	case INVERSE:
+		return "inverse"
+	case EXPONENTIAL:
+		return "exponential"
+	}
+	return "UNKNOWN_ROLLOFF"
+}
+
+func StringToRolloffT(s string) RolloffT {
+	switch strings.ToLower(s) {
+	case "linear":
+		return LINEAR
+	case "inverse":
+		return INVERSE
+	case "exponential":
+		return EXPONENTIAL
+	}
+	return UNKNOWN_ROLLOFF
+}
+
+// SpeedOfSound in world units per second, for doppler shift.
+var SpeedOfSound float32 = 343.3
+
+type vec3 [3]float32
+
+func (a vec3) sub(b vec3) vec3 {
+	return vec3{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func (a vec3) scale(s float32) vec3 {
+	return vec3{a[0] * s, a[1] * s, a[2] * s}
+}
+
+func (a vec3) dot(b vec3) float32 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func (a vec3) length() float32 {
+	return float32(glm.Sqrt(float64(a.dot(a))))
+}
+
+// mover tracks a position and the velocity it implies between updates.
+type mover struct {
+	pos, prev, vel vec3
+	placed, moved  bool
+}
+
+func (m *mover) place(p [3]float32) {
+	if !m.placed {
+		m.prev = p
+		m.placed = true
+	}
+	m.pos = p
+	m.moved = true
+}
+
+func (m *mover) step(dt float32) {
+	if !m.moved || dt <= 0 {
+		return
+	}
+	m.vel = m.pos.sub(m.prev).scale(1 / dt)
+	m.prev = m.pos
+	m.moved = false
+}
+
+// Listener is where spatial voices are heard from, usually following the
+// active camera.
+type Listener struct {
+	mover
+	right, up, forward vec3
+}
+
+func newListener() *Listener {
+	return &Listener{
+		right:   vec3{1, 0, 0},
+		up:      vec3{0, 1, 0},
+		forward: vec3{0, 0, -1},
+	}
+}
+
+// SetView places the listener with a column major view matrix, as the
+// camera it follows renders with.
+func (l *Listener) SetView(m []float32) {
+	if len(m) < 16 {
+		return
+	}
+	var p [3]float32
+	for i := 0; i < 3; i++ {
+		p[i] = -(m[i*4]*m[12] + m[i*4+1]*m[13] + m[i*4+2]*m[14])
+	}
+	l.right = vec3{m[0], m[4], m[8]}
+	l.up = vec3{m[1], m[5], m[9]}
+	l.forward = vec3{-m[2], -m[6], -m[10]}
+	l.place(p)
+}
+
+// Set places the listener at a position, facing forward.
+func (l *Listener) Set(pos, forward, up [3]float32) {
+	f, u := vec3(forward), vec3(up)
+	if n := f.length(); n > 0 {
+		f = f.scale(1 / n)
+	}
+	r := vec3{
+		f[1]*u[2] - f[2]*u[1],
+		f[2]*u[0] - f[0]*u[2],
+		f[0]*u[1] - f[1]*u[0],
+	}
+	if n := r.length(); n > 0 {
+		r = r.scale(1 / n)
+	}
+	l.right, l.up, l.forward = r, u, f
+	l.place(pos)
+}
+
+func (l *Listener) Position() [3]float32 {
+	return l.pos
+}
+
+// Spatial places a voice in the world, attenuating, panning and doppler
+// shifting it relative to the listener. Cone angles are full angles in
+// radians, a direction of zero being omnidirectional.
+type Spatial struct {
+	mover
+	Direction     [3]float32
+	Rolloff       RolloffT
+	RefDistance   float32
+	MaxDistance   float32
+	RolloffFactor float32
+	ConeInner     float32
+	ConeOuter     float32
+	ConeOuterGain float32
+	Doppler       float32
+	Reverb        float32
+	LowPass       float32
+}
+
+func NewSpatial() *Spatial {
+	return &Spatial{
+		Rolloff:       INVERSE,
+		RefDistance:   1,
+		MaxDistance:   100,
+		RolloffFactor: 1,
+		ConeInner:     2 * glm.Pi,
+		ConeOuter:     2 * glm.Pi,
+		ConeOuterGain: 0,
+		Doppler:       1,
+	}
+}
+
+func (s *Spatial) SetPosition(p [3]float32) {
+	s.place(p)
+}
+
+func (s *Spatial) Position() [3]float32 {
+	return s.pos
+}
+
+func (s *Spatial) attenuation(d float32) float32 {
+	ref, max := s.RefDistance, s.MaxDistance
+	if ref <= 0 {
+		ref = 1
+	}
+	if max < ref {
+		max = ref
+	}
+	d = clamp(d, ref, max)
+	rf := s.RolloffFactor
+	switch s.Rolloff {
+	case LINEAR:
+		if max == ref {
+			return 1
+		}
+		return clamp(1-rf*(d-ref)/(max-ref), 0, 1)
+	case EXPONENTIAL:
+		return float32(glm.Pow(float64(d/ref), float64(-rf)))
+	}
+	return ref / (ref + rf*(d-ref))
+}
+
+// cone is the gain towards a unit vector from the source to the listener.
+func (s *Spatial) cone(toListener vec3) float32 {
+	dir := vec3(s.Direction)
+	n := dir.length()
+	if n == 0 || s.ConeInner >= 2*glm.Pi {
+		return 1
+	}
+	cos := clamp(dir.dot(toListener)/n, -1, 1)
+	angle := 2 * float32(glm.Acos(float64(cos)))
+	inner, outer := s.ConeInner, s.ConeOuter
+	if outer < inner {
+		outer = inner
+	}
+	switch {
+	case angle <= inner:
+		return 1
+	case angle >= outer:
+		return s.ConeOuterGain
+	}
+	t := (angle - inner) / (outer - inner)
+	return 1 + (s.ConeOuterGain-1)*t
+}
+
+// doppler is the pitch factor for the source and listener velocities.
+func (s *Spatial) doppler(l *Listener, toListener vec3) float32 {
+	if s.Doppler <= 0 {
+		return 1
+	}
+	limit := SpeedOfSound / s.Doppler
+	vl := clamp(l.vel.dot(toListener), -limit, limit)
+	vs := clamp(s.vel.dot(toListener), -limit, limit)
+	den := SpeedOfSound - s.Doppler*vs
+	if den <= 0 {
+		return 2
+	}
+	return clamp((SpeedOfSound-s.Doppler*vl)/den, 0.5, 2)
+}
+
+// mixing is what a spatial voice sounds like this update.
+type mixing struct {
+	gain, pan, pitch float32
+	send, lowpass    float32
+}
+
+func (s *Spatial) params(l *Listener, zs []*Zone) mixing {
+	m := mixing{gain: 1, pitch: 1, send: s.Reverb, lowpass: s.LowPass}
+	sl := l.pos.sub(s.pos)
+	d := sl.length()
+	if d > 0 {
+		toListener := sl.scale(1 / d)
+		m.gain = s.attenuation(d) * s.cone(toListener)
+		m.pitch = s.doppler(l, toListener)
+		// from the listener, the source lies opposite
+		m.pan = clamp(-toListener.dot(l.right), -1, 1)
+	}
+	if z := zoneAt(zs, s.pos); z != nil {
+		if z.Reverb > m.send {
+			m.send = z.Reverb
+		}
+		if z.LowPass > 0 && (m.lowpass <= 0 || z.LowPass < m.lowpass) {
+			m.lowpass = z.LowPass
+		}
+	}
+	return m
+}
+
+// Zone is a sphere of the world whose sources send to the reverb and are
+// low passed, a LowPass of 0 Hz leaving them unfiltered.
+type Zone struct {
+	Center  [3]float32
+	Radius  float32
+	Reverb  float32
+	LowPass float32
+}
+
+// zoneAt is the smallest zone holding p.
+func zoneAt(zs []*Zone, p vec3) *Zone {
+	var ret *Zone
+	for _, z := range zs {
+		if p.sub(z.Center).length() > z.Radius {
+			continue
+		}
+		if ret == nil || z.Radius < ret.Radius {
+			ret = z
+		}
+	}
+	return ret
+}
@@ -0,0 +1,273 @@
+package audio
+
+import (
+	"io"
+	glm "math"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/ecs"
+)
+
+// frames of source data a voice decodes ahead
+const voiceFrames = 1024
+
+// Voice plays a source through a mixer, resampled to the mixer rate, with its
+// own gain, pan, pitch and looping.
+type Voice struct {
+	ecs.Entity
+	m       *Mixer
+	src     Source
+	f       Format
+	gain    float32
+	pan     float32
+	pitch   float32
+	loop    bool
+	spatial *Spatial
+	bus     *Bus
+	lp      [2]float32
+	playing bool
+	ended   bool
+	in      []float32
+	n       int
+	pos     float64
+	eof     bool
+	read    int
+	err     error
+}
+
+func newVoice(m *Mixer, src Source) *Voice {
+	f := src.Format()
+	if f.Channels <= 0 {
+		f.Channels = 1
+	}
+	return &Voice{
+		Entity: ecs.NewEntity(),
+		m:      m,
+		src:    src,
+		f:      f,
+		gain:   1,
+		pitch:  1,
+		in:     make([]float32, voiceFrames*f.Channels),
+	}
+}
+
+// Play starts or resumes the voice, rewinding it when it had ended.
+func (v *Voice) Play() {
+	if v.ended {
+		v.rewind()
+	}
+	v.playing = true
+	v.m.add(v)
+}
+
+func (v *Voice) Pause() {
+	v.playing = false
+}
+
+// Stop halts and rewinds the voice.
+func (v *Voice) Stop() {
+	v.playing = false
+	v.rewind()
+}
+
+func (v *Voice) rewind() {
+	v.err = v.src.Seek(0)
+	v.n, v.pos, v.read = 0, 0, 0
+	v.eof, v.ended = false, false
+}
+
+func (v *Voice) Playing() bool {
+	return v.playing
+}
+
+// Err is the last error reading the source, which ends the voice.
+func (v *Voice) Err() error {
+	return v.err
+}
+
+func (v *Voice) Gain() float32 {
+	return v.gain
+}
+
+func (v *Voice) SetGain(g float32) {
+	if g < 0 {
+		g = 0
+	}
+	v.gain = g
+}
+
+// Pan is the stereo balance, -1 full left to 1 full right.
+func (v *Voice) Pan() float32 {
+	return v.pan
+}
+
+func (v *Voice) SetPan(p float32) {
+	v.pan = clamp(p, -1, 1)
+}
+
+// Pitch scales the playback rate, and so the pitch.
+func (v *Voice) Pitch() float32 {
+	return v.pitch
+}
+
+func (v *Voice) SetPitch(p float32) {
+	if p <= 0 {
+		p = 0.01
+	}
+	v.pitch = p
+}
+
+func (v *Voice) Loop() bool {
+	return v.loop
+}
+
+func (v *Voice) SetLoop(b bool) {
+	v.loop = b
+}
+
+// Bus is the bus the voice plays into.
+func (v *Voice) Bus() *Bus {
+	return v.bus
+}
+
+func (v *Voice) SetBus(b *Bus) {
+	if b == nil {
+		b = v.m.master
+	}
+	v.bus = b
+}
+
+// Spatial is the placement of the voice in the world, nil when it plays
+// unplaced.
+func (v *Voice) Spatial() *Spatial {
+	return v.spatial
+}
+
+func (v *Voice) SetSpatial(s *Spatial) {
+	v.spatial = s
+}
+
+func (v *Voice) Source() Source {
+	return v.src
+}
+
+// Close stops the voice and closes its source.
+func (v *Voice) Close() error {
+	v.Stop()
+	v.m.Remove(v.ID())
+	return v.src.Close()
+}
+
+func clamp(v, lo, hi float32) float32 {
+	switch {
+	case v < lo:
+		return lo
+	case v > hi:
+		return hi
+	}
+	return v
+}
+
+// fill drops consumed frames and decodes more, looping as needed. At the end
+// of a non looping source a silent frame is added to interpolate towards.
+func (v *Voice) fill() bool {
+	if v.eof {
+		return false
+	}
+	ch := v.f.Channels
+	drop := int(v.pos)
+	if drop > v.n {
+		drop = v.n
+	}
+	copy(v.in, v.in[drop*ch:v.n*ch])
+	v.n -= drop
+	v.pos -= float64(drop)
+
+	for v.n < voiceFrames {
+		k, err := v.src.Read(v.in[v.n*ch:])
+		k /= ch
+		v.n += k
+		v.read += k
+		switch {
+		case err == io.EOF:
+			// an empty source would loop forever
+			if v.loop && v.read > 0 {
+				if v.err = v.src.Seek(0); v.err == nil {
+					v.read = 0
+					continue
+				}
+			}
+			v.eof = true
+		case err != nil:
+			v.err = err
+			v.eof = true
+		case k == 0:
+			return true
+		}
+		if v.eof {
+			if v.n < voiceFrames {
+				for i := v.n * ch; i < (v.n+1)*ch; i++ {
+					v.in[i] = 0
+				}
+				v.n++
+			}
+			return true
+		}
+	}
+	return true
+}
+
+func (v *Voice) frame(i int) (float32, float32) {
+	i *= v.f.Channels
+	if v.f.Channels == 1 {
+		return v.in[i], v.in[i]
+	}
+	return v.in[i], v.in[i+1]
+}
+
+// mix adds the voice to interleaved stereo out at the given rate, and to
+// the reverb send, returning false once the voice is no longer playing.
+func (v *Voice) mix(out, send []float32, rate int, l *Listener, zs []*Zone) bool {
+	if !v.playing {
+		return false
+	}
+	m := mixing{gain: 1, pitch: 1}
+	if v.spatial != nil {
+		m = v.spatial.params(l, zs)
+	}
+	step := float64(v.f.SampleRate) / float64(rate) * float64(v.pitch*m.pitch)
+	pan := clamp(v.pan+m.pan, -1, 1)
+	gl := v.gain * m.gain * clamp(1-pan, 0, 1)
+	gr := v.gain * m.gain * clamp(1+pan, 0, 1)
+	var a float32
+	if m.lowpass > 0 {
+		a = 1 - float32(glm.Exp(-2*glm.Pi*float64(m.lowpass)/float64(rate)))
+	}
+	for i := 0; i+1 < len(out); i += 2 {
+		for int(v.pos)+1 >= v.n {
+			if !v.fill() || int(v.pos)+1 >= v.n {
+				v.playing = false
+				v.ended = true
+				return false
+			}
+		}
+		idx := int(v.pos)
+		t := float32(v.pos - float64(idx))
+		l0, r0 := v.frame(idx)
+		l1, r1 := v.frame(idx + 1)
+		sl := (l0 + (l1-l0)*t) * gl
+		sr := (r0 + (r1-r0)*t) * gr
+		if a > 0 {
+			v.lp[0] += a * (sl - v.lp[0])
+			v.lp[1] += a * (sr - v.lp[1])
+			sl, sr = v.lp[0], v.lp[1]
+		}
+		out[i] += sl
+		out[i+1] += sr
+		if m.send > 0 {
+			send[i] += sl * m.send
+			send[i+1] += sr * m.send
+		}
+		v.pos += step
+	}
+	return true
+}
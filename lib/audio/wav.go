@@ -0,0 +1,199 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	glm "math"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+var InvalidWAVError = xrror.Xrror("invalid wav: %s").Out
+
+const (
+	wavPCM        = 1
+	wavFloat      = 3
+	wavExtensible = 0xFFFE
+)
+
+type wavSource struct {
+	r          io.ReadSeeker
+	f          Format
+	encoding   int
+	bytes      int
+	start, end int64
+	pos        int64
+	raw        []byte
+}
+
+func closeReader(r interface{}) error {
+	if c, ok := r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// DecodeWAV reads 8, 16, 24 or 32 bit integer and 32 bit float wav data,
+// streaming samples from r.
+func DecodeWAV(r io.ReadSeeker) (Source, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, err
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, InvalidWAVError("not a RIFF WAVE file")
+	}
+
+	s := &wavSource{r: r}
+	var offset int64 = 12
+	haveFmt := false
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, InvalidWAVError("no data chunk")
+		}
+		offset += 8
+		size := int64(binary.LittleEndian.Uint32(hdr[4:8]))
+		switch string(hdr[0:4]) {
+		case "fmt ":
+			if size < 16 {
+				return nil, InvalidWAVError("short fmt chunk")
+			}
+			b := make([]byte, size)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return nil, err
+			}
+			s.encoding = int(binary.LittleEndian.Uint16(b[0:2]))
+			s.f.Channels = int(binary.LittleEndian.Uint16(b[2:4]))
+			s.f.SampleRate = int(binary.LittleEndian.Uint32(b[4:8]))
+			s.bytes = int(binary.LittleEndian.Uint16(b[14:16])) / 8
+			if s.encoding == wavExtensible && size >= 26 {
+				s.encoding = int(binary.LittleEndian.Uint16(b[24:26]))
+			}
+			haveFmt = true
+		case "data":
+			if !haveFmt {
+				return nil, InvalidWAVError("data before fmt chunk")
+			}
+			s.start, s.end, s.pos = offset, offset+size, offset
+			return s, s.validate()
+		default:
+			if _, err := r.Seek(size+size%2, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+		offset += size + size%2
+	}
+}
+
+func (s *wavSource) validate() error {
+	switch {
+	case s.f.Channels <= 0 || s.f.SampleRate <= 0:
+		return InvalidWAVError("bad format")
+	case s.encoding == wavPCM && s.bytes >= 1 && s.bytes <= 4:
+	case s.encoding == wavFloat && s.bytes == 4:
+	default:
+		return InvalidWAVError("unsupported encoding")
+	}
+	return nil
+}
+
+func (s *wavSource) Format() Format {
+	return s.f
+}
+
+func (s *wavSource) sample(b []byte) float32 {
+	switch {
+	case s.encoding == wavFloat:
+		return glm.Float32frombits(binary.LittleEndian.Uint32(b))
+	case s.bytes == 1:
+		return (float32(b[0]) - 128) / 128
+	case s.bytes == 2:
+		return float32(int16(binary.LittleEndian.Uint16(b))) / 32768
+	case s.bytes == 3:
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float32(v) / 8388608
+	}
+	return float32(int32(binary.LittleEndian.Uint32(b))) / 2147483648
+}
+
+func (s *wavSource) Read(buf []float32) (int, error) {
+	left := int((s.end - s.pos) / int64(s.bytes))
+	if left <= 0 {
+		return 0, io.EOF
+	}
+	n := len(buf)
+	if n > left {
+		n = left
+	}
+	if cap(s.raw) < n*s.bytes {
+		s.raw = make([]byte, n*s.bytes)
+	}
+	raw := s.raw[:n*s.bytes]
+	read, err := io.ReadFull(s.r, raw)
+	n = read / s.bytes
+	s.pos += int64(n * s.bytes)
+	for i := 0; i < n; i++ {
+		buf[i] = s.sample(raw[i*s.bytes:])
+	}
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (s *wavSource) Seek(frame int) error {
+	pos := s.start + int64(frame*s.f.Channels*s.bytes)
+	if pos > s.end {
+		pos = s.end
+	}
+	if _, err := s.r.Seek(pos, io.SeekStart); err != nil {
+		return err
+	}
+	s.pos = pos
+	return nil
+}
+
+func (s *wavSource) Frames() int {
+	return int((s.end - s.start) / int64(s.f.Channels*s.bytes))
+}
+
+func (s *wavSource) Close() error {
+	return closeReader(s.r)
+}
+
+// wavHeader is the 44 byte header of 16 bit pcm wav data of size bytes.
+func wavHeader(f Format, size uint32) []byte {
+	h := make([]byte, 44)
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], 36+size)
+	copy(h[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], wavPCM)
+	binary.LittleEndian.PutUint16(h[22:], uint16(f.Channels))
+	binary.LittleEndian.PutUint32(h[24:], uint32(f.SampleRate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(f.SampleRate*f.Channels*2))
+	binary.LittleEndian.PutUint16(h[32:], uint16(f.Channels*2))
+	binary.LittleEndian.PutUint16(h[34:], 16)
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], size)
+	return h
+}
+
+// PCM16 converts samples to little endian 16 bit pcm, clamping to [-1, 1].
+func PCM16(samples []float32, out []byte) []byte {
+	if cap(out) < len(samples)*2 {
+		out = make([]byte, len(samples)*2)
+	}
+	out = out[:len(samples)*2]
+	for i, v := range samples {
+		switch {
+		case v > 1:
+			v = 1
+		case v < -1:
+			v = -1
+		}
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(int16(v*32767)))
+	}
+	return out
+}
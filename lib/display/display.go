@@ -112,6 +112,12 @@ func New(p graphics.Provider, renders string) (*Display, error) {
 	nativeWindow.MakeContextCurrent()
 	glfw.SwapInterval(1)
 
+	dis = &Display{
+		nativeWindow,
+		DefaultDisplayData(),
+		scene,
+	}
+
 	nativeWindow.SetPosCallback(dis.posCallback)
 	nativeWindow.SetSizeCallback(dis.sizeCallback)
 	nativeWindow.SetFramebufferSizeCallback(dis.framebufferSizeCallback)
@@ -120,11 +126,6 @@ func New(p graphics.Provider, renders string) (*Display, error) {
 	nativeWindow.SetFocusCallback(dis.focusCallback)
 	nativeWindow.SetIconifyCallback(dis.iconifyCallback)
 
-	dis = &Display{
-		nativeWindow,
-		DefaultDisplayData(),
-		scene,
-	}
 	currentDisplay = dis
 	return dis, err
 }
@@ -135,6 +136,7 @@ func (d *Display) Priority() int {
 
 func (d *Display) Update(int64) error {
 	d.Render()
+	d.Overlay()
 	d.SwapBuffers()
 	return nil
 }
@@ -151,7 +153,7 @@ func (d *Display) sizeCallback(w *glfw.Window, width int, height int) {
 }
 
 func (d *Display) framebufferSizeCallback(w *glfw.Window, width int, height int) {
-	//spew.Dump("framebuffersizeCallback")
+	d.Scene.Resize(width, height)
 }
 
 func (d *Display) closeCallback(w *glfw.Window) {
@@ -1,20 +1,32 @@
 package engine
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"time"
 
 	"github.com/Laughs-In-Flowers/log"
+	"github.com/Laughs-In-Flowers/shiva/lib/animation"
+	"github.com/Laughs-In-Flowers/shiva/lib/asset"
+	"github.com/Laughs-In-Flowers/shiva/lib/audio"
 	"github.com/Laughs-In-Flowers/shiva/lib/display"
 	"github.com/Laughs-In-Flowers/shiva/lib/ecs"
 	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/shader"
+	"github.com/Laughs-In-Flowers/shiva/lib/gui"
 	"github.com/Laughs-In-Flowers/shiva/lib/input"
 	"github.com/Laughs-In-Flowers/shiva/lib/lua"
 	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/particle"
 	"github.com/Laughs-In-Flowers/shiva/lib/render"
 	"github.com/Laughs-In-Flowers/shiva/lib/scene"
+	"github.com/Laughs-In-Flowers/shiva/lib/text"
+	"github.com/Laughs-In-Flowers/shiva/lib/tread"
+	"github.com/Laughs-In-Flowers/shiva/lib/ui"
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
 	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
 	"github.com/go-gl/glfw/v3.2/glfw"
 )
@@ -33,7 +45,9 @@ type Engine struct {
 	kill    bool
 	debug   bool
 	FPS     int64
+	step    int64
 	gp      graphics.Provider
+	as      audio.Sink
 	rs      string
 	l       *lua.Lua
 	w       *ecs.World
@@ -66,8 +80,12 @@ RESTART:
 		case e.kill:
 			goto QUIT
 		default:
-			now := time.Now().Unix()
-			world.Update(now)
+			tread.Pump()
+			if e.step > 0 {
+				world.Update(fixed(e.step))
+			} else {
+				world.Update(delta())
+			}
 			fps(e)
 		}
 	}
@@ -92,7 +110,11 @@ func (e *Engine) Close() {
 	} else {
 		e.Print("closing....")
 	}
+	if err := input.CurrentInputSystem.Close(); err != nil {
+		e.Print(err)
+	}
 	display.Close()
+	audio.CurrentAudioSystem.Close()
 	e.Print("done")
 	os.Exit(0)
 }
@@ -217,9 +239,13 @@ var builtIns = []Config{
 	config{5000, eGraphics},
 	config{5001, eRenders},
 	config{6000, eDisplay},
+	config{6500, eAudio},
 	config{7000, eInput},
+	config{7001, eReplay},
+	config{7002, eGUI},
 	config{8001, eLua},
 	config{8002, eCheckLoadLuaModule},
+	config{8003, eVFS},
 	config{9000, eWorld},
 }
 
@@ -290,6 +316,16 @@ func SetGraphics(s string) Config {
 		})
 }
 
+// SetShaderCache keeps binaries of shader programs in a directory between
+// runs, loaded rather than compiled again where the graphics driver allows.
+func SetShaderCache(dir string) Config {
+	return NewConfig(50,
+		func(e *Engine) error {
+			shader.CurrentCache.SetDir(dir)
+			return nil
+		})
+}
+
 func eRenders(e *Engine) error {
 	if e.rs == "" {
 		e.rs = render.DefaultRenderer.String()
@@ -328,6 +364,133 @@ func eInput(e *Engine) error {
 	return nil
 }
 
+func eGUI(e *Engine) error {
+	ui.CurrentUI.Attach()
+	currentUISystem = ui.CurrentUI
+	gui.CurrentGUI.SetStats(e.stats)
+	gui.CurrentGUI.Attach()
+	currentGUISystem = gui.CurrentGUI
+	return nil
+}
+
+// stats are the figures shown in the built in GUI panel.
+func (e *Engine) stats() []gui.Stat {
+	mixers, tweeners := animation.CurrentAnimationSystem.Count()
+	ar := asset.CurrentManager.Report()
+	sc := shader.CurrentCache.Stats()
+	ret := []gui.Stat{
+		{"fps", fmt.Sprint(e.FPS)},
+		{"emitters", fmt.Sprint(particle.CurrentParticleSystem.Count())},
+		{"mixers", fmt.Sprint(mixers)},
+		{"tweeners", fmt.Sprint(tweeners)},
+		{"voices", fmt.Sprint(audio.CurrentAudioSystem.Voices())},
+		{"assets", fmt.Sprintf("%d (%d pending)", ar.Live, ar.Pending)},
+		{"asset memory", fmt.Sprintf("%.1f MB", float64(ar.Bytes)/(1<<20))},
+		{"shader cache", fmt.Sprintf("%d hits, %d misses", sc.Hits, sc.Misses)},
+		{"shader programs", fmt.Sprintf("%d compiled, %d loaded", sc.Compiled, sc.Loaded)},
+	}
+	if s := scene.Current(); s != nil {
+		rs := s.Stats()
+		ret = append(ret,
+			gui.Stat{"nodes", fmt.Sprint(s.Count())},
+			gui.Stat{"draws", fmt.Sprint(rs.Draws)},
+			gui.Stat{"vertices", fmt.Sprint(rs.Vertices)},
+		)
+	}
+	return ret
+}
+
+// DefaultRecordStep is the fixed step recordings run at when none is set.
+const DefaultRecordStep = int64(time.Second / 60)
+
+// SetFixedStep runs every frame as the same step of time, paced to real
+// time, rather than the time each frame took.
+func SetFixedStep(d time.Duration) Config {
+	return NewConfig(50,
+		func(e *Engine) error {
+			e.step = int64(d)
+			return nil
+		})
+}
+
+var (
+	recordFile string
+	replayFile string
+)
+
+// SetRecord records input to a file, at a fixed step, for replaying.
+func SetRecord(file string) Config {
+	return NewConfig(50,
+		func(e *Engine) error {
+			recordFile = file
+			return nil
+		})
+}
+
+// SetReplay replays input recorded to a file at the step it was recorded
+// with, failing on the first frame the scene diverges from the recording and
+// quitting at its end.
+func SetReplay(file string) Config {
+	return NewConfig(50,
+		func(e *Engine) error {
+			replayFile = file
+			return nil
+		})
+}
+
+var RecordReplayError = xrror.Xrror("cannot record and replay input at once")
+
+func eReplay(e *Engine) error {
+	switch {
+	case recordFile != "" && replayFile != "":
+		return RecordReplayError
+	case recordFile != "":
+		if e.step <= 0 {
+			e.step = DefaultRecordStep
+		}
+		e.Printf("recording input to %s", recordFile)
+		return input.CurrentInputSystem.Record(recordFile, e.step, scene.Checksum)
+	case replayFile != "":
+		step, err := input.CurrentInputSystem.Replay(replayFile, scene.Checksum, e.Kill)
+		if err != nil {
+			return err
+		}
+		e.step = step
+		e.Printf("replaying input from %s", replayFile)
+	}
+	return nil
+}
+
+func eAudio(e *Engine) error {
+	if e.as == nil {
+		s, err := audio.NewSink(audio.DefaultSink.String(), "")
+		if err == nil {
+			err = audio.CurrentAudioSystem.SetSink(s)
+		}
+		if err != nil {
+			e.Printf("no audio device, audio disabled: %s", err)
+			return audio.CurrentAudioSystem.SetSink(nil)
+		}
+		e.as = s
+		return nil
+	}
+	return audio.CurrentAudioSystem.SetSink(e.as)
+}
+
+// SetAudio sets the audio sink by name, "device", "null" or "wav", with an
+// implementation argument such as the wav file path.
+func SetAudio(name, arg string) Config {
+	return NewConfig(50,
+		func(e *Engine) error {
+			s, err := audio.NewSink(name, arg)
+			if err != nil {
+				return err
+			}
+			e.as = s
+			return nil
+		})
+}
+
 var (
 	luaDir  string = workingDir
 	luaFile string = "main"
@@ -365,6 +528,30 @@ func eLua(e *Engine) error {
 	slfn := scene.RegisterWith()
 	slfn(shv)
 
+	alfn := animation.RegisterWith()
+	alfn(shv)
+
+	aulfn := audio.RegisterWith()
+	aulfn(shv)
+
+	tlfn := text.RegisterWith()
+	tlfn(shv)
+
+	glfn := gui.RegisterWith()
+	glfn(shv)
+
+	uilfn := ui.RegisterWith()
+	uilfn(shv)
+
+	aslfn := asset.RegisterWith()
+	aslfn(shv)
+
+	shlfn := shader.RegisterWith()
+	shlfn(shv)
+
+	mlfn := material.RegisterWith()
+	mlfn(shv)
+
 	L, err := lua.New(
 		e.debug,
 		lua.SetPath("_SHIVA_PATH", luaDir),
@@ -378,6 +565,47 @@ func eLua(e *Engine) error {
 	return nil
 }
 
+var packFiles []string
+
+// SetPacks mounts packs written by shiva pack over the directory of the lua
+// file, read before it, the last given read first.
+func SetPacks(files ...string) Config {
+	return NewConfig(50,
+		func(e *Engine) error {
+			packFiles = append(packFiles, files...)
+			return nil
+		})
+}
+
+// SetWatch reloads textures and models loaded as assets and shader templates
+// in place when their files change, polling at an interval.
+func SetWatch(interval time.Duration) Config {
+	return NewConfig(50,
+		func(e *Engine) error {
+			vfs.CurrentWatcher.SetInterval(interval)
+			vfs.CurrentWatcher.SetEnabled(true)
+			return nil
+		})
+}
+
+// eVFS mounts what assets are read from: packs, then the directory of the
+// lua file, then the working directory, then what is embedded.
+func eVFS(e *Engine) error {
+	for i, f := range packFiles {
+		p, err := vfs.OpenPack(f)
+		if err != nil {
+			return err
+		}
+		vfs.Mount("", p, 10+i)
+	}
+	vfs.Mount("", vfs.Dir(luaDir), 0)
+	if workingDir != luaDir {
+		vfs.Mount("", vfs.Dir(workingDir), -1)
+	}
+	vfs.Mount("", vfs.Embedded("embedded", lua.ResourceFS), -10)
+	return nil
+}
+
 var NoLoadLuaFuncError = xrror.Xrror("load lua function has not been specified.")
 
 func eCheckLoadLuaModule(e *Engine) error {
@@ -388,10 +616,21 @@ func eCheckLoadLuaModule(e *Engine) error {
 }
 
 var (
-	currentDisplaySystem ecs.System
-	currentInputSystem   ecs.System
+	currentDisplaySystem   ecs.System
+	currentInputSystem     ecs.System
+	currentAnimationSystem ecs.System = animation.CurrentAnimationSystem
+	currentParticleSystem  ecs.System = particle.CurrentParticleSystem
+	currentAudioSystem     ecs.System = audio.CurrentAudioSystem
+	currentAssetSystem     ecs.System = asset.CurrentManager
+	currentWatchSystem     ecs.System = vfs.CurrentWatcher
+	currentUISystem        ecs.System
+	currentGUISystem       ecs.System
 )
 
+// eWorld adds the systems of the engine, updated each frame highest priority
+// first, no two sharing one: input (9) is read before the GUI (8) and UI (7)
+// built from it, then animation (6), particles (5) and audio (4) play on,
+// assets (3) and watched files (2) load, and the display (1) renders last.
 func eWorld(e *Engine) error {
 	hefn := func(err error) {
 		e.hefn(e, err)
@@ -400,6 +639,13 @@ func eWorld(e *Engine) error {
 	world.Add(
 		currentDisplaySystem,
 		currentInputSystem,
+		currentAnimationSystem,
+		currentParticleSystem,
+		currentAudioSystem,
+		currentAssetSystem,
+		currentWatchSystem,
+		currentUISystem,
+		currentGUISystem,
 	)
 	e.w = world
 	return nil
@@ -22,3 +22,36 @@ func fps(e *Engine) {
 		frameCount = 0
 	}
 }
+
+var frameLast time.Time
+
+// delta returns the nanoseconds elapsed since the previous frame.
+func delta() int64 {
+	now := time.Now()
+	if frameLast.IsZero() {
+		frameLast = now
+	}
+	d := now.Sub(frameLast)
+	frameLast = now
+	return int64(d)
+}
+
+// fixed waits out the rest of a fixed step since the previous frame and
+// returns the step, so every frame advances the same time whatever its
+// real duration.
+func fixed(step int64) int64 {
+	if frameLast.IsZero() {
+		frameLast = time.Now()
+	}
+	next := frameLast.Add(time.Duration(step))
+	if wait := time.Until(next); wait > 0 {
+		time.Sleep(wait)
+	}
+	if now := time.Now(); now.Sub(next) > time.Duration(step)*4 {
+		// too far behind to catch up, carry on from now
+		frameLast = now
+	} else {
+		frameLast = next
+	}
+	return step
+}
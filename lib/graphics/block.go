@@ -0,0 +1,170 @@
+package graphics
+
+// Binding points of the uniform blocks programs share.
+const (
+	FrameBinding uint32 = iota
+	LightsBinding
+	MaterialBinding
+	EnvironmentBinding
+)
+
+type block struct {
+	binding uint32
+	layout  *Std140
+}
+
+var blocks = map[string]block{
+	"Frame":       {FrameBinding, FrameLayout()},
+	"Lights":      {LightsBinding, LightsLayout()},
+	"Material":    {MaterialBinding, MaterialLayout()},
+	"Environment": {EnvironmentBinding, EnvironmentLayout()},
+}
+
+// BindBlock sets the binding point programs bind a uniform block of a name
+// to once linked, and the layout the block is expected to have.
+func BindBlock(name string, binding uint32, l *Std140) {
+	blocks[name] = block{binding, l}
+}
+
+// BlockBinding is the binding point and layout of a uniform block of a
+// name, false if it has none.
+func BlockBinding(name string) (uint32, *Std140, bool) {
+	b, ok := blocks[name]
+	return b.binding, b.layout, ok
+}
+
+// LightsMax is how many lights of each kind the Lights block holds, the
+// most a program lights with.
+const LightsMax = 8
+
+// FrameLayout is the layout of the Frame block, what is the same for every
+// draw of a frame: the camera and the time.
+func FrameLayout() *Std140 {
+	return NewStd140().
+		Must("ViewMatrix", FLOAT_MAT4, 1).
+		Must("ProjectionMatrix", FLOAT_MAT4, 1).
+		Must("CameraPosition", FLOAT_VEC4, 1).
+		Must("Time", FLOAT_VEC4, 1)
+}
+
+// LightsLayout is the layout of the Lights block, the lights of each kind
+// as arrays of vec4 the shaders take them apart with macros.
+func LightsLayout() *Std140 {
+	return NewStd140().
+		Must("AmbientLight", FLOAT_VEC4, LightsMax).
+		Must("DirLight", FLOAT_VEC4, 2*LightsMax).
+		Must("PointLight", FLOAT_VEC4, 3*LightsMax).
+		Must("SpotLight", FLOAT_VEC4, 5*LightsMax)
+}
+
+// MaterialLayout is the layout of the Material block, the colors of a
+// material with its shininess, opacity, point size and point rotation.
+func MaterialLayout() *Std140 {
+	return NewStd140().
+		Must("MatAmbient", FLOAT_VEC4, 1).
+		Must("MatDiffuse", FLOAT_VEC4, 1).
+		Must("MatSpecular", FLOAT_VEC4, 1).
+		Must("MatEmissive", FLOAT_VEC4, 1).
+		Must("MatParams", FLOAT_VEC4, 1)
+}
+
+// EnvironmentLayout is the layout of the Environment block, what a scene
+// has around what it draws: ambient light, fog and sky.
+func EnvironmentLayout() *Std140 {
+	return NewStd140().
+		Must("EnvAmbient", FLOAT_VEC4, 1).
+		Must("FogColor", FLOAT_VEC4, 1).
+		Must("FogParams", FLOAT_VEC4, 1).
+		Must("FogHeight", FLOAT_VEC4, 1).
+		Must("SkyTop", FLOAT_VEC4, 1).
+		Must("SkyHorizon", FLOAT_VEC4, 1).
+		Must("SkyBottom", FLOAT_VEC4, 1).
+		Must("SkyParams", FLOAT_VEC4, 1)
+}
+
+// UniformBlock is the buffer of a uniform block, laid out std140, uploaded
+// as it changes and bound to its binding point when provided.
+type UniformBlock struct {
+	p       Provider
+	handle  Buffer
+	name    string
+	binding uint32
+	layout  *Std140
+	data    []byte
+	update  bool
+}
+
+func NewUniformBlock(name string, binding uint32, l *Std140) *UniformBlock {
+	return &UniformBlock{
+		name:    name,
+		binding: binding,
+		layout:  l,
+		data:    make([]byte, l.Size()),
+		update:  true,
+	}
+}
+
+// NewBoundBlock is a uniform block of a name with the binding point and
+// layout it is bound with, nil when it is not.
+func NewBoundBlock(name string) *UniformBlock {
+	b, ok := blocks[name]
+	if !ok {
+		return nil
+	}
+	return NewUniformBlock(name, b.binding, b.layout)
+}
+
+func (u *UniformBlock) Name() string {
+	return u.name
+}
+
+func (u *UniformBlock) Binding() uint32 {
+	return u.binding
+}
+
+func (u *UniformBlock) Layout() *Std140 {
+	return u.layout
+}
+
+func (u *UniformBlock) Bytes() []byte {
+	return u.data
+}
+
+// Set writes floats as element idx of a member, uploaded when next
+// provided if they changed it.
+func (u *UniformBlock) Set(name string, idx int, v ...float32) error {
+	changed, err := u.layout.Put(u.data, name, idx, v...)
+	if changed {
+		u.update = true
+	}
+	return err
+}
+
+// Changed is whether the block has changed since it was last provided.
+func (u *UniformBlock) Changed() bool {
+	return u.update
+}
+
+func (u *UniformBlock) Provide(p Provider) {
+	if u.p == nil {
+		u.handle = p.GenBuffer()
+		p.BindBuffer(UNIFORM_BUFFER, u.handle)
+		p.BufferData(UNIFORM_BUFFER, len(u.data), p.Ptr(u.data), DYNAMIC_DRAW)
+		u.p = p
+		u.update = false
+	}
+	if u.update {
+		p.BindBuffer(UNIFORM_BUFFER, u.handle)
+		p.BufferSubData(UNIFORM_BUFFER, 0, len(u.data), p.Ptr(u.data))
+		u.update = false
+	}
+	p.BindBufferBase(UNIFORM_BUFFER, u.binding, u.handle)
+}
+
+func (u *UniformBlock) Close() {
+	if p := u.p; p != nil {
+		p.DeleteBuffer(u.handle)
+	}
+	u.p = nil
+	u.update = true
+}
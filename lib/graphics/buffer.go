@@ -12,12 +12,13 @@ type BuffAttrib struct {
 }
 
 type Buff struct {
-	p      Provider
-	handle Buffer
-	usage  Enum
-	update bool
-	buffer math.AF32
-	a      []BuffAttrib
+	p       Provider
+	handle  Buffer
+	usage   Enum
+	update  bool
+	buffer  math.AF32
+	a       []BuffAttrib
+	divisor uint32
 }
 
 func NewBuff() *Buff {
@@ -60,6 +61,13 @@ func (b *Buff) SetUsage(u Enum) {
 	b.usage = u
 }
 
+// SetDivisor makes the buffer attributes advance once every d instances
+// instead of every vertex, for instanced drawing.
+func (b *Buff) SetDivisor(d uint32) *Buff {
+	b.divisor = d
+	return b
+}
+
 func (b *Buff) Buffer() *math.AF32 {
 	return &b.buffer
 }
@@ -96,6 +104,9 @@ func (b *Buff) Provide(p Provider) {
 			}
 			p.EnableVertexAttribArray(uint32(loc))
 			p.VertexAttribPointer(uint32(loc), attrib.Size, FLOAT, false, int32(stride), p.Ptr(&offset))
+			if b.divisor > 0 {
+				p.VertexAttribDivisor(uint32(loc), b.divisor)
+			}
 			items += uint32(attrib.Size)
 			offset = uint32(elsize) * items
 		}
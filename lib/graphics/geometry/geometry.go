@@ -9,7 +9,6 @@ type Geometer interface {
 	graphics.Initializer
 	graphics.Closer
 	graphics.Providable
-	graphics.RefCounter
 }
 
 type Geometry interface {
@@ -17,17 +16,18 @@ type Geometry interface {
 	Grouper
 	Indicer
 	VBOer
+	Bounder
 }
 
 type geometry struct {
 	p             graphics.Provider
-	refcount      int
 	vbos          []*graphics.Buff
 	groups        []Group
 	indices       math.AU32
 	handleVAO     uint32
 	handleIndices graphics.Buffer
 	updateIndices bool
+	bounds        *bounds
 }
 
 func New() *geometry {
@@ -38,19 +38,15 @@ func New() *geometry {
 
 func (g *geometry) Initialize() {
 	g.p = nil
-	g.refcount = 1
 	g.vbos = make([]*graphics.Buff, 0)
 	g.groups = make([]Group, 0)
 	g.handleVAO = 0
 	g.handleIndices = 0
 	g.updateIndices = true
+	g.bounds = nil
 }
 
 func (g *geometry) Close() {
-	if g.refcount > 1 {
-		g.refcount--
-		return
-	}
 	if g.p != nil {
 		g.p.DeleteVertexArray(g.handleVAO)
 		g.p.DeleteBuffer(g.handleIndices)
@@ -87,14 +83,6 @@ func (g *geometry) Provide(p graphics.Provider) {
 	}
 }
 
-func (g *geometry) Increment() {
-	g.refcount++
-}
-
-func (g *geometry) Decrement() {
-	g.refcount--
-}
-
 type Group struct {
 	Start  int
 	Count  int
@@ -142,8 +130,8 @@ func (g *geometry) Indices() math.AU32 {
 
 func (g *geometry) SetIndices(i math.AU32) {
 	g.indices = i
-	//g.boundingBoxValid = false
-	//g.boundingSphereValid = false
+	g.updateIndices = true
+	g.bounds = nil
 }
 
 type VBOer interface {
@@ -163,6 +151,39 @@ func (g *geometry) VBO(attrib string) *graphics.Buff {
 
 func (g *geometry) AddVBO(vbo *graphics.Buff) {
 	g.vbos = append(g.vbos, vbo)
+	g.bounds = nil
+}
+
+// Attribute returns the values of the named attribute, unpacked from any vbo
+// it is interleaved in, and the number of components per vertex.
+func (g *geometry) Attribute(name string) (math.AF32, int) {
+	for _, vbo := range g.vbos {
+		var offset, size, stride int
+		found := false
+		for i := 0; i < vbo.AttribCount(); i++ {
+			a := vbo.AttribAt(i)
+			if a.Name == name {
+				size = int(a.Size)
+				found = true
+			}
+			if !found {
+				offset += int(a.Size)
+			}
+			stride += int(a.Size)
+		}
+		if !found || stride == 0 {
+			continue
+		}
+		buf := *vbo.Buffer()
+		count := len(buf) / stride
+		ret := math.NewAF32(0, count*size)
+		for v := 0; v < count; v++ {
+			start := v*stride + offset
+			ret.Append(buf[start : start+size]...)
+		}
+		return ret, size
+	}
+	return nil, 0
 }
 
 func (g *geometry) VBOItems() int {
@@ -176,10 +197,59 @@ func (g *geometry) VBOItems() int {
 	return vbo.Buffer().Bytes() / vbo.Stride()
 }
 
-//func (g *Geometry) BoundingBox() math32.Box3 {
-//	return nil
-//}
+type Bounder interface {
+	Attribute(string) (math.AF32, int)
+	BoundingBox() (math.Vector, math.Vector)
+	BoundingSphere() (math.Vector, float32)
+}
 
-//func (g *Geometry) BoundingSphere() math32.Sphere {
-//	return nil
-//}
+type bounds struct {
+	min, max, center math.Vector
+	radius           float32
+}
+
+func (g *geometry) calculateBounds() *bounds {
+	if g.bounds != nil {
+		return g.bounds
+	}
+	b := &bounds{
+		min:    math.Vec3(0, 0, 0),
+		max:    math.Vec3(0, 0, 0),
+		center: math.Vec3(0, 0, 0),
+	}
+	pos, size := g.Attribute("VertexPosition")
+	if size >= 3 && len(pos) >= size {
+		b.min.Update(pos[0], pos[1], pos[2])
+		b.max.Update(pos[0], pos[1], pos[2])
+		for i := 0; i+2 < len(pos); i += size {
+			for c := 0; c < 3; c++ {
+				v := pos[i+c]
+				b.min.Set(c, math.Min(b.min.Get(c), v))
+				b.max.Set(c, math.Max(b.max.Get(c), v))
+			}
+		}
+		for c := 0; c < 3; c++ {
+			b.center.Set(c, (b.min.Get(c)+b.max.Get(c))/2)
+		}
+		for i := 0; i+2 < len(pos); i += size {
+			d := math.Vec3(pos[i], pos[i+1], pos[i+2]).Sub(b.center).Len()
+			b.radius = math.Max(b.radius, d)
+		}
+	}
+	g.bounds = b
+	return b
+}
+
+// BoundingBox returns the minimum and maximum corners of the axis aligned box
+// containing every vertex position.
+func (g *geometry) BoundingBox() (math.Vector, math.Vector) {
+	b := g.calculateBounds()
+	return b.min, b.max
+}
+
+// BoundingSphere returns the center and radius of a sphere containing every
+// vertex position.
+func (g *geometry) BoundingSphere() (math.Vector, float32) {
+	b := g.calculateBounds()
+	return b.center, b.radius
+}
@@ -0,0 +1,202 @@
+package geometry
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+var (
+	OBJError         = xrror.Xrror("obj line %d: %s").Out
+	OBJNumbersError  = xrror.Xrror("%d numbers expected").Out
+	OBJPositionError = xrror.Xrror("%s has no position").Out
+	OBJIndexError    = xrror.Xrror("%s refers past what was read").Out
+)
+
+// OBJ is the triangles of a Wavefront obj file, decoded without touching
+// the gpu: positions, normals, texture coordinates and indices, each vertex
+// once. Faces of more than 3 vertices are fanned into triangles, normals
+// are smoothed from the faces when the file has none, and objects, groups
+// and materials are ignored.
+type OBJ struct {
+	Positions math.AF32
+	Normals   math.AF32
+	Texcoords math.AF32
+	Indices   math.AU32
+}
+
+// DecodeOBJ reads an obj file.
+func DecodeOBJ(r io.Reader) (*OBJ, error) {
+	var v, vt, vn []float32
+	o := &OBJ{
+		Positions: math.NewAF32(0, 0),
+		Normals:   math.NewAF32(0, 0),
+		Texcoords: math.NewAF32(0, 0),
+		Indices:   math.NewAU32(0, 0),
+	}
+	seen := make(map[[3]int]uint32)
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		fs := strings.Fields(s.Text())
+		if len(fs) == 0 || strings.HasPrefix(fs[0], "#") {
+			continue
+		}
+		var err error
+		switch fs[0] {
+		case "v":
+			v, err = objFloats(v, fs[1:], 3)
+		case "vt":
+			vt, err = objFloats(vt, fs[1:], 2)
+		case "vn":
+			vn, err = objFloats(vn, fs[1:], 3)
+		case "f":
+			if len(fs) < 4 {
+				return nil, OBJError(line, "a face needs 3 vertices")
+			}
+			face := make([]uint32, 0, len(fs)-1)
+			for _, f := range fs[1:] {
+				k, err := objVertex(f, len(v)/3, len(vt)/2, len(vn)/3)
+				if err != nil {
+					return nil, OBJError(line, err)
+				}
+				idx, ok := seen[k]
+				if !ok {
+					idx = uint32(o.Positions.Len() / 3)
+					seen[k] = idx
+					o.Positions.Append(v[k[0]*3 : k[0]*3+3]...)
+					if k[1] >= 0 {
+						o.Texcoords.Append(vt[k[1]*2 : k[1]*2+2]...)
+					} else {
+						o.Texcoords.Append(0, 0)
+					}
+					if k[2] >= 0 {
+						o.Normals.Append(vn[k[2]*3 : k[2]*3+3]...)
+					} else {
+						o.Normals.Append(0, 0, 0)
+					}
+				}
+				face = append(face, idx)
+			}
+			for i := 2; i < len(face); i++ {
+				o.Indices.Append(face[0], face[i-1], face[i])
+			}
+		}
+		if err != nil {
+			return nil, OBJError(line, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(vn) == 0 {
+		o.smooth()
+	}
+	return o, nil
+}
+
+func objFloats(to []float32, fs []string, n int) ([]float32, error) {
+	if len(fs) < n {
+		return to, OBJNumbersError(n)
+	}
+	for _, f := range fs[:n] {
+		x, err := strconv.ParseFloat(f, 32)
+		if err != nil {
+			return to, err
+		}
+		to = append(to, float32(x))
+	}
+	return to, nil
+}
+
+// objVertex is the position, texture coordinate and normal of a face vertex,
+// v, v/vt, v//vn or v/vt/vn, 0 based and -1 when not given; negative
+// indices count back from the last read.
+func objVertex(f string, nv, nvt, nvn int) ([3]int, error) {
+	k := [3]int{-1, -1, -1}
+	counts := [3]int{nv, nvt, nvn}
+	for i, s := range strings.SplitN(f, "/", 3) {
+		if s == "" {
+			if i == 0 {
+				return k, OBJPositionError(f)
+			}
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return k, err
+		}
+		if n < 0 {
+			n += counts[i]
+		} else {
+			n--
+		}
+		if n < 0 || n >= counts[i] {
+			return k, OBJIndexError(f)
+		}
+		k[i] = n
+	}
+	return k, nil
+}
+
+// smooth sets each normal to the normalized sum of those of the triangles
+// using its vertex.
+func (o *OBJ) smooth() {
+	p := o.Positions
+	for i := 0; i+2 < len(o.Indices); i += 3 {
+		a, b, c := o.Indices[i]*3, o.Indices[i+1]*3, o.Indices[i+2]*3
+		va := math.Vec3(p[a], p[a+1], p[a+2])
+		e1 := math.Vec3(p[b], p[b+1], p[b+2]).Sub(va)
+		e2 := math.Vec3(p[c], p[c+1], p[c+2]).Sub(va)
+		n := e1.Cross(e2)
+		for _, at := range []uint32{a, b, c} {
+			for j := uint32(0); j < 3; j++ {
+				o.Normals[at+j] += n.Get(int(j))
+			}
+		}
+	}
+	for i := 0; i+2 < len(o.Normals); i += 3 {
+		n := math.Vec3(o.Normals[i], o.Normals[i+1], o.Normals[i+2])
+		if n.Len() > 0 {
+			o.Normals.Set(i, n.Normalize().Raw()...)
+		}
+	}
+}
+
+// Size is about how many bytes the model takes.
+func (o *OBJ) Size() int64 {
+	return int64(o.Positions.Bytes() + o.Normals.Bytes() + o.Texcoords.Bytes() + o.Indices.Bytes())
+}
+
+// Apply sets the vertices and indices of a geometry to those of the model
+// in place, replacing what the buffers it already has for them hold, so
+// whatever draws it draws the model from the next frame.
+func (o *OBJ) Apply(g Geometry) {
+	for _, a := range []struct {
+		name string
+		size int32
+		data math.AF32
+	}{
+		{"VertexPosition", 3, o.Positions},
+		{"VertexNormal", 3, o.Normals},
+		{"VertexTexcoord", 2, o.Texcoords},
+	} {
+		if vbo := g.VBO(a.name); vbo != nil {
+			vbo.SetBuffer(a.data)
+			continue
+		}
+		g.AddVBO(graphics.NewBuff().AddAttrib(a.name, a.size).SetBuffer(a.data))
+	}
+	g.SetIndices(o.Indices)
+}
+
+// Geometry is a new geometry of the model.
+func (o *OBJ) Geometry() Geometry {
+	g := New()
+	o.Apply(g)
+	return g
+}
@@ -0,0 +1,96 @@
+package geometry
+
+import (
+	"strings"
+	"testing"
+)
+
+const quadOBJ = `# a quad
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+f 1/1/1 2/2/1 3/3/1 4/4/1
+`
+
+func TestDecodeOBJ(t *testing.T) {
+	o, err := DecodeOBJ(strings.NewReader(quadOBJ))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := o.Positions.Len() / 3; n != 4 {
+		t.Errorf("%d vertices, want 4", n)
+	}
+	want := []uint32{0, 1, 2, 0, 2, 3}
+	if len(o.Indices) != len(want) {
+		t.Fatalf("indices %v, want %v", o.Indices, want)
+	}
+	for i := range want {
+		if o.Indices[i] != want[i] {
+			t.Fatalf("indices %v, want %v", o.Indices, want)
+		}
+	}
+	if o.Texcoords[4] != 1 || o.Texcoords[5] != 1 {
+		t.Errorf("texcoord of the third vertex %v, want 1 1", o.Texcoords[4:6])
+	}
+}
+
+func TestDecodeOBJSharedAndSmoothed(t *testing.T) {
+	o, err := DecodeOBJ(strings.NewReader("v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\nf -3 -2 -1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := o.Positions.Len() / 3; n != 3 {
+		t.Errorf("%d vertices, want the 3 shared", n)
+	}
+	for i := 0; i < 3; i++ {
+		if n := o.Normals[i*3 : i*3+3]; n[0] != 0 || n[1] != 0 || n[2] != 1 {
+			t.Errorf("normal %d %v, want 0 0 1", i, n)
+		}
+	}
+}
+
+func TestDecodeOBJErrors(t *testing.T) {
+	for _, s := range []string{
+		"v 0 0\n",
+		"v 0 0 0\nv 1 0 0\nf 1 2\n",
+		"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 4\n",
+		"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/2 2 3\n",
+		"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 x\n",
+	} {
+		if _, err := DecodeOBJ(strings.NewReader(s)); err == nil {
+			t.Errorf("%q decoded, want an error", s)
+		}
+	}
+}
+
+func TestOBJApplyInPlace(t *testing.T) {
+	o, err := DecodeOBJ(strings.NewReader(quadOBJ))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := o.Geometry()
+	vbo := g.VBO("VertexPosition")
+	tri, err := DecodeOBJ(strings.NewReader("v 0 0 0\nv 2 0 0\nv 0 2 0\nf 1 2 3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tri.Apply(g)
+	if g.VBO("VertexPosition") != vbo {
+		t.Error("positions moved to a new buffer")
+	}
+	if n := g.VBOItems(); n != 3 {
+		t.Errorf("%d vertices after reload, want 3", n)
+	}
+	if len(g.Indices()) != 3 {
+		t.Errorf("%d indices after reload, want 3", len(g.Indices()))
+	}
+	if _, max := g.BoundingBox(); max.Get(0) != 2 {
+		t.Errorf("bounds not recomputed, max %v", max.Raw())
+	}
+}
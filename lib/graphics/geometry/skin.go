@@ -0,0 +1,13 @@
+package geometry
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+)
+
+// Skin adds the joint indices and weights of up to 4 joints per vertex to the
+// geometry, as VertexJoints and VertexWeights attributes.
+func Skin(g Geometry, joints, weights math.AF32) {
+	g.AddVBO(graphics.NewBuff().AddAttrib("VertexJoints", 4).SetBuffer(joints))
+	g.AddVBO(graphics.NewBuff().AddAttrib("VertexWeights", 4).SetBuffer(weights))
+}
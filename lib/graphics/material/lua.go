@@ -0,0 +1,233 @@
+package material
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+// Floats reads numbers from a number, a boolean, a table of numbers or the
+// raw values of a math userdata, false for anything else.
+func Floats(v l.LValue) ([]float32, bool) {
+	switch vv := v.(type) {
+	case l.LNumber:
+		return []float32{float32(vv)}, true
+	case l.LBool:
+		if vv {
+			return []float32{1}, true
+		}
+		return []float32{0}, true
+	case *l.LTable:
+		ret := make([]float32, 0, vv.Len())
+		for i := 1; i <= vv.Len(); i++ {
+			n, ok := vv.RawGetInt(i).(l.LNumber)
+			if !ok {
+				return nil, false
+			}
+			ret = append(ret, float32(n))
+		}
+		return ret, true
+	case *l.LUserData:
+		switch m := vv.Value.(type) {
+		case math.Vector:
+			return m.Raw(), true
+		case math.Color:
+			return m.Raw(), true
+		case math.Matrice:
+			return m.Raw(), true
+		}
+	}
+	return nil, false
+}
+
+func pushFloats(L *l.LState, v []float32) {
+	if len(v) == 1 {
+		L.Push(l.LNumber(v[0]))
+		return
+	}
+	t := L.CreateTable(len(v), 0)
+	for _, f := range v {
+		t.Append(l.LNumber(f))
+	}
+	L.Push(t)
+}
+
+const (
+	lMaterialClass = "MATERIAL"
+	lUniformsClass = "MATERIALUNIFORMS"
+)
+
+func PushMaterial(L *l.LState, m Material) int {
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = m }, lMaterialClass)
+	return 1
+}
+
+func CheckMaterial(L *l.LState, pos int) Material {
+	ud := L.CheckUserData(pos)
+	if m, ok := ud.Value.(Material); ok {
+		return m
+	}
+	L.ArgError(pos, "material expected")
+	return nil
+}
+
+// shv.material("water") is a material drawn with a shader, built in or
+// registered with shv.shaders.register, standard when none is given.
+func lMaterial(L *l.LState) int {
+	m := New()
+	m.SetShader(L.OptString(1, "standard"))
+	return PushMaterial(L, m)
+}
+
+type materialMemberFunc func(*l.LState, Material) int
+
+func materialMember(fn materialMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if m := CheckMaterial(L, 1); m != nil {
+			return fn(L, m)
+		}
+		return 0
+	}
+}
+
+func materialProperty(get, set materialMemberFunc) l.LGFunction {
+	var s l.LGFunction
+	if set != nil {
+		s = materialMember(set)
+	}
+	return lua.NewProperty(materialMember(get), s)
+}
+
+func getShader(L *l.LState, m Material) int {
+	L.Push(l.LString(m.Shader()))
+	return 1
+}
+
+func setShader(L *l.LState, m Material) int {
+	m.SetShader(L.CheckString(3))
+	return 0
+}
+
+func getOpacity(L *l.LState, m Material) int {
+	L.Push(l.LNumber(m.Opacity()))
+	return 1
+}
+
+func setOpacity(L *l.LState, m Material) int {
+	m.SetOpacity(float32(L.CheckNumber(3)))
+	return 0
+}
+
+func getShininess(L *l.LState, m Material) int {
+	L.Push(l.LNumber(m.Shininess()))
+	return 1
+}
+
+func setShininess(L *l.LState, m Material) int {
+	m.SetShininess(float32(L.CheckNumber(3)))
+	return 0
+}
+
+func setWireframe(L *l.LState, m Material) int {
+	m.SetWireframe(L.CheckBool(3))
+	return 0
+}
+
+// mat.uniforms is the params of the shader of the material, read and set by
+// name: mat.uniforms.time = t.
+func getUniforms(L *l.LState, m Material) int {
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = m }, lUniformsClass)
+	return 1
+}
+
+// mat:color("diffuse", {1, 0.5, 0, 1}) sets a color of the material,
+// ambient, diffuse, specular or emissive.
+func materialColor(L *l.LState, m Material) int {
+	c := StringToColorT(L.CheckString(2))
+	if c == UNKNOWN_COLOR {
+		L.ArgError(2, "ambient, diffuse, specular or emissive expected")
+		return 0
+	}
+	v, ok := Floats(L.Get(3))
+	if !ok || len(v) < 3 {
+		L.ArgError(3, "color expected")
+		return 0
+	}
+	if len(v) < 4 {
+		v = append(v, 1)
+	}
+	m.SetColor(c, math.NewColor(v[0], v[1], v[2], v[3]))
+	return 0
+}
+
+var materialTable = &lua.Table{
+	lMaterialClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+		lua.DefaultIdx("__newindex"),
+	},
+	map[string]l.LGFunction{
+		"shader":    materialProperty(getShader, setShader),
+		"opacity":   materialProperty(getOpacity, setOpacity),
+		"shininess": materialProperty(getShininess, setShininess),
+		"wireframe": lua.NewProperty(nil, materialMember(setWireframe)),
+		"uniforms":  materialProperty(getUniforms, nil),
+	},
+	map[string]l.LGFunction{
+		"color": materialMember(materialColor),
+	},
+}
+
+func uniformsIndex(t *lua.Table, _ string) l.LGFunction {
+	return func(L *l.LState) int {
+		m := CheckMaterial(L, 1)
+		name := L.CheckString(2)
+		v, ok := m.Param(name)
+		if !ok {
+			L.RaiseError("%s", UnknownParamError(m.Shader(), name))
+			return 0
+		}
+		pushFloats(L, v)
+		return 1
+	}
+}
+
+func uniformsNewIndex(t *lua.Table, _ string) l.LGFunction {
+	return func(L *l.LState) int {
+		m := CheckMaterial(L, 1)
+		name := L.CheckString(2)
+		v, ok := Floats(L.Get(3))
+		if !ok {
+			L.ArgError(3, "numbers expected")
+			return 0
+		}
+		if err := m.SetParam(name, v...); err != nil {
+			L.RaiseError("%s", err)
+		}
+		return 0
+	}
+}
+
+var uniformsTable = &lua.Table{
+	lUniformsClass,
+	nil,
+	[]*lua.LMetaFunc{
+		{"__index", uniformsIndex},
+		{"__newindex", uniformsNewIndex},
+	},
+	nil, nil,
+}
+
+func RegisterWith() lua.RegisterWith {
+	return func(m lua.Module) error {
+		m.AddLGFunc("material", lMaterial)
+		rmtfn := func(L *l.LState, M lua.Module) {
+			M.Register(L, materialTable)
+			M.Register(L, uniformsTable)
+		}
+		m.AddMT(rmtfn)
+		return nil
+	}
+}
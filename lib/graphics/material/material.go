@@ -5,6 +5,7 @@ import (
 
 	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
 	"github.com/Laughs-In-Flowers/shiva/lib/graphics/texture"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
 )
 
 type Material interface {
@@ -17,6 +18,8 @@ type Material interface {
 	Framer
 	Polygoner
 	Texturer
+	Colorer
+	Parameterizer
 }
 
 type UseLights int
@@ -50,26 +53,31 @@ const (
 )
 
 type material struct {
-	refcount         int               // Current number of references
-	useShader        string            // Shader name
-	independent      bool              // shader does not depend on the number of lights in the scene and/or number of textures in the material.
-	uselights        UseLights         // Use lights bit mask
-	sideVisible      Side              // sides visible
-	wireframe        bool              // show as wirefrme
-	depthMask        bool              // Enable writing into the depth buffer
-	depthTest        bool              // Enable depth buffer test
-	depthFunc        graphics.Enum     // Active depth test function
-	blending         Blending          // blending mode
-	blendRGB         graphics.Enum     // separate blend equation for RGB
-	blendAlpha       graphics.Enum     // separate blend equation for Alpha
-	blendSrcRGB      graphics.Enum     // separate blend func source RGB
-	blendDstRGB      graphics.Enum     // separate blend func dest RGB
-	blendSrcAlpha    graphics.Enum     // separate blend func source Alpha
-	blendDstAlpha    graphics.Enum     // separate blend func dest Alpha
-	lineWidth        float32           // line width for lines and mesh wireframe
-	polyOffsetFactor float32           // polygon offset factor
-	polyOffsetUnits  float32           // polygon offset units
-	textures         []texture.Texture // List of textures
+	useShader        string                  // Shader name
+	independent      bool                    // shader does not depend on the number of lights in the scene and/or number of textures in the material.
+	uselights        UseLights               // Use lights bit mask
+	sideVisible      Side                    // sides visible
+	wireframe        bool                    // show as wirefrme
+	depthMask        bool                    // Enable writing into the depth buffer
+	depthTest        bool                    // Enable depth buffer test
+	depthFunc        graphics.Enum           // Active depth test function
+	blending         Blending                // blending mode
+	blendRGB         graphics.Enum           // separate blend equation for RGB
+	blendAlpha       graphics.Enum           // separate blend equation for Alpha
+	blendSrcRGB      graphics.Enum           // separate blend func source RGB
+	blendDstRGB      graphics.Enum           // separate blend func dest RGB
+	blendSrcAlpha    graphics.Enum           // separate blend func source Alpha
+	blendDstAlpha    graphics.Enum           // separate blend func dest Alpha
+	lineWidth        float32                 // line width for lines and mesh wireframe
+	polyOffsetFactor float32                 // polygon offset factor
+	polyOffsetUnits  float32                 // polygon offset units
+	textures         []texture.Texture       // List of textures
+	colors           [4]math.Color           // ambient, diffuse, specular and emissive colors
+	shininess        float32                 // specular shininess factor
+	opacity          float32                 // opacity of the material
+	block            *graphics.UniformBlock  // Material uniform block
+	params           map[string][]float32    // values of params of the shader
+	uniforms         map[string]typedUniform // uniforms params are transferred by
 }
 
 func New() *material {
@@ -85,17 +93,21 @@ func Basic() Material {
 	return b
 }
 
+func Skinned() Material {
+	s := New()
+	s.SetShader("skinned")
+	return s
+}
+
 type Materializer interface {
 	graphics.Initializer
 	graphics.Closer
 	graphics.Providable
-	graphics.RefCounter
 	//graphics.Renderable
 	Shaderer
 }
 
 func (m *material) Initialize() {
-	m.refcount = 1
 	m.uselights = ULAll
 	m.sideVisible = SIFront
 	m.wireframe = false
@@ -107,16 +119,26 @@ func (m *material) Initialize() {
 	m.polyOffsetFactor = 0
 	m.polyOffsetUnits = 0
 	m.textures = make([]texture.Texture, 0)
+	m.colors = [4]math.Color{
+		math.NewColor(1, 1, 1, 1),
+		math.NewColor(1, 1, 1, 1),
+		math.NewColor(0.5, 0.5, 0.5, 1),
+		math.NewColor(0, 0, 0, 1),
+	}
+	m.shininess = 30
+	m.opacity = 1
+	m.params = make(map[string][]float32)
+	m.uniforms = make(map[string]typedUniform)
+	if m.block == nil {
+		m.block = graphics.NewBoundBlock("Material")
+	}
 }
 
 func (m *material) Close() {
-	if m.refcount > 1 {
-		m.refcount--
-		return
-	}
 	for i := 0; i < len(m.textures); i++ {
 		m.textures[i].Close()
 	}
+	m.block.Close()
 	m.Initialize()
 }
 
@@ -189,14 +211,10 @@ func (m *material) Provide(p graphics.Provider) {
 	for idx, tex := range m.textures {
 		tex.Render(p, idx)
 	}
-}
 
-func (m *material) Increment() {
-	m.refcount++
-}
-
-func (m *material) Decrement() {
-	m.refcount--
+	m.setBlock()
+	m.block.Provide(p)
+	m.provideParams(p)
 }
 
 type Shaderer interface {
@@ -341,3 +359,92 @@ func (m *material) HasTexture(t texture.Texture) bool {
 func (m *material) TextureCount() int {
 	return len(m.textures)
 }
+
+type ColorT int
+
+const (
+	UNKNOWN_COLOR ColorT = iota
+	AMBIENT
+	DIFFUSE
+	SPECULAR
+	EMISSIVE
+)
+
+func (c ColorT) String() string {
+	switch c {
+	case AMBIENT:
+		return "ambient"
+	case DIFFUSE:
+		return "diffuse"
+	case SPECULAR:
+		return "specular"
+	case EMISSIVE:
+		return "emissive"
+	}
+	return "unknown"
+}
+
+func StringToColorT(s string) ColorT {
+	switch s {
+	case "ambient":
+		return AMBIENT
+	case "diffuse", "color":
+		return DIFFUSE
+	case "specular":
+		return SPECULAR
+	case "emissive":
+		return EMISSIVE
+	}
+	return UNKNOWN_COLOR
+}
+
+type Colorer interface {
+	Color(ColorT) math.Color
+	SetColor(ColorT, math.Color)
+	Shininess() float32
+	SetShininess(float32)
+	Opacity() float32
+	SetOpacity(float32)
+}
+
+// Color returns the color of the kind, nil for an unknown kind.
+func (m *material) Color(c ColorT) math.Color {
+	if c == UNKNOWN_COLOR {
+		return nil
+	}
+	return m.colors[c-AMBIENT]
+}
+
+func (m *material) SetColor(c ColorT, v math.Color) {
+	if c == UNKNOWN_COLOR || v == nil {
+		return
+	}
+	m.colors[c-AMBIENT] = v
+}
+
+func (m *material) Shininess() float32 {
+	return m.shininess
+}
+
+func (m *material) SetShininess(v float32) {
+	m.shininess = v
+}
+
+func (m *material) Opacity() float32 {
+	return m.opacity
+}
+
+func (m *material) SetOpacity(v float32) {
+	m.opacity = v
+}
+
+var blockColors = [4]string{"MatAmbient", "MatDiffuse", "MatSpecular", "MatEmissive"}
+
+// setBlock lays the material out in its Material block, uploaded when
+// provided only if that changed it.
+func (m *material) setBlock() {
+	for i, c := range m.colors {
+		m.block.Set(blockColors[i], 0, c.R(), c.G(), c.B(), c.A())
+	}
+	m.block.Set("MatParams", 0, m.shininess, m.opacity, 1, 0)
+}
@@ -0,0 +1,111 @@
+package material
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// Param is a uniform a shader declares for the materials drawn with it, of
+// a type and a default each material has until it sets its own.
+type Param struct {
+	Name    string
+	Type    graphics.Enum
+	Default []float32
+}
+
+var (
+	UnknownParamError = xrror.Xrror("shader %s declares no parameter %s").Out
+	ParamSizeError    = xrror.Xrror("parameter %s takes %d values, not %d").Out
+	ParamTypeError    = xrror.Xrror("parameter %s is of unsupported type 0x%X").Out
+)
+
+// NewParam is a param of a type, its default zero when none is given.
+func NewParam(name string, t graphics.Enum, def ...float32) (*Param, error) {
+	n := graphics.UniformTypeSize(t)
+	if n == 0 {
+		return nil, ParamTypeError(name, uint32(t))
+	}
+	if def == nil {
+		def = make([]float32, n)
+	}
+	if len(def) != n {
+		return nil, ParamSizeError(name, n, len(def))
+	}
+	return &Param{name, t, def}, nil
+}
+
+var declared = make(map[string][]*Param)
+
+// DeclareParams sets the params a shader declares, replacing those it did.
+func DeclareParams(shader string, ps ...*Param) {
+	declared[shader] = ps
+}
+
+// DeclaredParams are the params a shader declares.
+func DeclaredParams(shader string) []*Param {
+	return declared[shader]
+}
+
+func declaredParam(shader, name string) (*Param, bool) {
+	for _, p := range declared[shader] {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+type Parameterizer interface {
+	Param(string) ([]float32, bool)
+	SetParam(string, ...float32) error
+	Params() []*Param
+}
+
+// Param is the value of a param of the shader of the material, its default
+// until set.
+func (m *material) Param(name string) ([]float32, bool) {
+	p, ok := declaredParam(m.useShader, name)
+	if !ok {
+		return nil, false
+	}
+	if v, ok := m.params[name]; ok && len(v) == len(p.Default) {
+		return v, true
+	}
+	return p.Default, true
+}
+
+func (m *material) SetParam(name string, v ...float32) error {
+	p, ok := declaredParam(m.useShader, name)
+	if !ok {
+		return UnknownParamError(m.useShader, name)
+	}
+	if len(v) != len(p.Default) {
+		return ParamSizeError(name, len(p.Default), len(v))
+	}
+	m.params[name] = append([]float32(nil), v...)
+	return nil
+}
+
+// Params are those the shader of the material declares.
+func (m *material) Params() []*Param {
+	return DeclaredParams(m.useShader)
+}
+
+// provideParams transfers the params of the shader of the material.
+func (m *material) provideParams(p graphics.Provider) {
+	for _, d := range DeclaredParams(m.useShader) {
+		u, ok := m.uniforms[d.Name]
+		if !ok || u.t != d.Type {
+			u = typedUniform{graphics.TypedUniform(d.Name, d.Type), d.Type}
+			m.uniforms[d.Name] = u
+		}
+		v, _ := m.Param(d.Name)
+		u.Update(v...)
+		u.Transfer(p)
+	}
+}
+
+type typedUniform struct {
+	graphics.Uniform
+	t graphics.Enum
+}
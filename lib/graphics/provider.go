@@ -100,6 +100,9 @@ type Provider interface {
 	// BindBuffer binds a buffer to the graphics level target specified by enum
 	BindBuffer(Enum, Buffer)
 
+	// BindBufferBase binds a buffer to an indexed binding point of a target
+	BindBufferBase(Enum, uint32, Buffer)
+
 	// BindFragDataLocation binds a user-defined varying out variable
 	// to a fragment shader color number
 	BindFragDataLocation(Program, uint32, string)
@@ -135,6 +138,9 @@ type Provider interface {
 	// BufferData creates a new data store for the bound buffer object.
 	BufferData(Enum, int, unsafe.Pointer, Enum)
 
+	// BufferSubData updates a range, from an offset, of the data store of the bound buffer object.
+	BufferSubData(Enum, int, int, unsafe.Pointer)
+
 	// CheckFramebufferStatus checks the completeness status of a framebuffer
 	CheckFramebufferStatus(Enum) Enum
 
@@ -192,6 +198,9 @@ type Provider interface {
 	// DepthMask enables or disables writing into the depth buffer
 	DepthMask(bool)
 
+	// DetachShader detaches a shader object from a program object
+	DetachShader(Program, Shader)
+
 	// Disable disables various graphics level capabilities
 	Disable(Enum)
 
@@ -204,6 +213,12 @@ type Provider interface {
 	// DrawArrays renders primitives from array data
 	DrawArrays(Enum, int32, int32)
 
+	// DrawElementsInstanced renders multiple instances of primitives from array data
+	DrawElementsInstanced(Enum, int32, Enum, unsafe.Pointer, int32)
+
+	// DrawArraysInstanced renders multiple instances of primitives from array data
+	DrawArraysInstanced(Enum, int32, int32, int32)
+
 	// Enable enables various graphics level capabilities.
 	Enable(Enum)
 
@@ -238,6 +253,21 @@ type Provider interface {
 	// GenVertexArray creates a graphics level VAO
 	GenVertexArray() uint32
 
+	// GetActiveAttrib returns the name, size and type of an active attribute of a program
+	GetActiveAttrib(Program, uint32) (string, int32, Enum)
+
+	// GetActiveUniform returns the name, size and type of an active uniform of a program
+	GetActiveUniform(Program, uint32) (string, int32, Enum)
+
+	// GetActiveUniformBlockName returns the name of an active uniform block of a program
+	GetActiveUniformBlockName(Program, uint32) string
+
+	// GetActiveUniformBlockiv returns a parameter of an active uniform block of a program
+	GetActiveUniformBlockiv(Program, uint32, Enum, *int32)
+
+	// GetActiveUniformsiv returns a parameter of each of a set of active uniforms of a program
+	GetActiveUniformsiv(Program, []uint32, Enum, []int32)
+
 	// GetAttribLocation returns the location of a attribute variable
 	GetAttribLocation(Program, string) int32
 
@@ -247,6 +277,13 @@ type Provider interface {
 	// GetError returns the next error
 	GetError() uint32
 
+	// GetIntegerv returns the value of a graphics level parameter
+	GetIntegerv(Enum, *int32)
+
+	// GetProgramBinary returns the binary of a linked program object with its format,
+	// nil when there is none
+	GetProgramBinary(Program) ([]byte, Enum)
+
 	// GetProgramInfoLog returns the information log for a program object
 	GetProgramInfoLog(Program) string
 
@@ -259,6 +296,10 @@ type Provider interface {
 	// GetShaderiv returns a parameter from the shader object
 	GetShaderiv(Shader, Enum, *int32)
 
+	// GetUniformBlockIndex returns the index of a uniform block of a program, INVALID_INDEX
+	// when there is none
+	GetUniformBlockIndex(Program, string) uint32
+
 	// GetUniformLocation returns the location of a uniform variable
 	GetUniformLocation(Program, string) int32
 
@@ -277,6 +318,12 @@ type Provider interface {
 	// PolygonOffset sets the scale and units used to calculate depth values
 	PolygonOffset(float32, float32)
 
+	// ProgramBinary loads a program object from a binary of a format
+	ProgramBinary(Program, Enum, []byte)
+
+	// ProgramParameteri sets a parameter of a program object
+	ProgramParameteri(Program, Enum, int32)
+
 	// Ptr takes a slice or a pointer and returns a graphics level compatbile address
 	Ptr(interface{}) unsafe.Pointer
 
@@ -288,6 +335,9 @@ type Provider interface {
 	// ReadBuffer specifies the color buffer source for pixels
 	ReadBuffer(Enum)
 
+	// ReadPixels reads a block of pixels from the bound read framebuffer
+	ReadPixels(int32, int32, int32, int32, Enum, Enum, unsafe.Pointer)
+
 	// RenderbufferStorage establishes the format and dimensions of a renderbuffer
 	RenderbufferStorage(Enum, Enum, int32, int32)
 
@@ -334,6 +384,9 @@ type Provider interface {
 	// Uniform1fv specifies the value of a uniform variable for the current program object
 	Uniform1fv(int32, []float32)
 
+	// Uniform2f specifies the value of a uniform variable for the current program object
+	Uniform2f(int32, float32, float32)
+
 	// Uniform3f specifies the value of a uniform variable for the current program object
 	Uniform3f(int32, float32, float32, float32)
 
@@ -341,7 +394,7 @@ type Provider interface {
 	Uniform3fv(int32, []float32)
 
 	// UniformMatrix3fv specifies the value of a uniform variable for the current program object
-	//UniformMatrix3fv(int32, int32, bool, []float32)
+	UniformMatrix3fv(int32, int32, bool, []float32)
 
 	// Uniform4f specifies the value of a uniform variable for the current program object
 	Uniform4f(int32, float32, float32, float32, float32)
@@ -352,6 +405,9 @@ type Provider interface {
 	// UniformMatrix4fv specifies the value of a uniform variable for the current program object
 	UniformMatrix4fv(int32, int32, bool, []float32)
 
+	// UniformBlockBinding assigns a binding point to a uniform block of a program
+	UniformBlockBinding(Program, uint32, uint32)
+
 	// UseProgram installs a program object as part of the current rendering state
 	UseProgram(Program)
 
@@ -366,6 +422,10 @@ type Provider interface {
 	// Only integer types are accepted by this function.
 	VertexAttribIPointer(uint32, int32, Enum, int32, unsafe.Pointer)
 
+	// VertexAttribDivisor sets the number of instances drawn before a
+	// vertex attribute advances, 0 advancing per vertex.
+	VertexAttribDivisor(uint32, uint32)
+
 	// Viewport sets the viewport, an affine transformation that
 	// normalizes device coordinates to window coordinates.
 	Viewport(int32, int32, int32, int32)
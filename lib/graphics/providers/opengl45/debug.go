@@ -56,6 +56,11 @@ func (g *OGL45DEBUG) BindBuffer(target graphics.Enum, b graphics.Buffer) {
 	g.run(func() { gl.BindBuffer(uint32(target), uint32(b)) })
 }
 
+// BindBufferBase binds a buffer to an indexed binding point of a target
+func (g *OGL45DEBUG) BindBufferBase(target graphics.Enum, index uint32, b graphics.Buffer) {
+	g.run(func() { gl.BindBufferBase(uint32(target), index, uint32(b)) })
+}
+
 // BindFragDataLocation binds a user-defined varying out variable
 // to a fragment shader color number
 func (g *OGL45DEBUG) BindFragDataLocation(p graphics.Program, color uint32, name string) {
@@ -123,6 +128,11 @@ func (g *OGL45DEBUG) BufferData(target graphics.Enum, size int, data unsafe.Poin
 	g.run(func() { gl.BufferData(uint32(target), size, data, uint32(usage)) })
 }
 
+// BufferSubData updates a range, from an offset, of the data store of the bound buffer object.
+func (g *OGL45DEBUG) BufferSubData(target graphics.Enum, offset, size int, data unsafe.Pointer) {
+	g.run(func() { gl.BufferSubData(uint32(target), offset, size, data) })
+}
+
 // CheckFramebufferStatus checks the completeness status of a framebuffer
 func (g *OGL45DEBUG) CheckFramebufferStatus(target graphics.Enum) graphics.Enum {
 	ret := graphics.Enum(gl.CheckFramebufferStatus(uint32(target)))
@@ -229,6 +239,11 @@ func (g *OGL45DEBUG) DepthMask(flag bool) {
 	g.run(func() { gl.DepthMask(flag) })
 }
 
+// DetachShader detaches a shader object from a program object
+func (g *OGL45DEBUG) DetachShader(p graphics.Program, s graphics.Shader) {
+	g.run(func() { gl.DetachShader(uint32(p), uint32(s)) })
+}
+
 // Disable disables various GL capabilities.
 func (g *OGL45DEBUG) Disable(e graphics.Enum) {
 	g.run(func() { gl.Disable(uint32(e)) })
@@ -250,6 +265,16 @@ func (g *OGL45DEBUG) DrawArrays(mode graphics.Enum, first int32, count int32) {
 	g.run(func() { gl.DrawArrays(uint32(mode), first, count) })
 }
 
+// DrawElementsInstanced renders multiple instances of primitives from array data
+func (g *OGL45DEBUG) DrawElementsInstanced(mode graphics.Enum, count int32, ty graphics.Enum, indices unsafe.Pointer, instances int32) {
+	g.run(func() { gl.DrawElementsInstanced(uint32(mode), count, uint32(ty), indices, instances) })
+}
+
+// DrawArraysInstanced renders multiple instances of primitives from array data
+func (g *OGL45DEBUG) DrawArraysInstanced(mode graphics.Enum, first int32, count int32, instances int32) {
+	g.run(func() { gl.DrawArraysInstanced(uint32(mode), first, count, instances) })
+}
+
 // Enable enables various GL capabilities.
 func (g *OGL45DEBUG) Enable(e graphics.Enum) {
 	g.run(func() { gl.Enable(uint32(e)) })
@@ -325,6 +350,48 @@ func (g *OGL45DEBUG) GenVertexArray() uint32 {
 	return a
 }
 
+// GetActiveAttrib returns the name, size and type of an active attribute of a program
+func (g *OGL45DEBUG) GetActiveAttrib(p graphics.Program, index uint32) (string, int32, graphics.Enum) {
+	var n, size int32
+	var xtype uint32
+	g.GetProgramiv(p, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &n)
+	b := make([]byte, n+1)
+	g.run(func() { gl.GetActiveAttrib(uint32(p), index, n+1, nil, &size, &xtype, &b[0]) })
+	return gl.GoStr(&b[0]), size, graphics.Enum(xtype)
+}
+
+// GetActiveUniform returns the name, size and type of an active uniform of a program
+func (g *OGL45DEBUG) GetActiveUniform(p graphics.Program, index uint32) (string, int32, graphics.Enum) {
+	var n, size int32
+	var xtype uint32
+	g.GetProgramiv(p, gl.ACTIVE_UNIFORM_MAX_LENGTH, &n)
+	b := make([]byte, n+1)
+	g.run(func() { gl.GetActiveUniform(uint32(p), index, n+1, nil, &size, &xtype, &b[0]) })
+	return gl.GoStr(&b[0]), size, graphics.Enum(xtype)
+}
+
+// GetActiveUniformBlockName returns the name of an active uniform block of a program
+func (g *OGL45DEBUG) GetActiveUniformBlockName(p graphics.Program, index uint32) string {
+	var n int32
+	g.GetActiveUniformBlockiv(p, index, gl.UNIFORM_BLOCK_NAME_LENGTH, &n)
+	b := make([]byte, n+1)
+	g.run(func() { gl.GetActiveUniformBlockName(uint32(p), index, n+1, nil, &b[0]) })
+	return gl.GoStr(&b[0])
+}
+
+// GetActiveUniformBlockiv returns a parameter of an active uniform block of a program
+func (g *OGL45DEBUG) GetActiveUniformBlockiv(p graphics.Program, index uint32, pname graphics.Enum, params *int32) {
+	g.run(func() { gl.GetActiveUniformBlockiv(uint32(p), index, uint32(pname), params) })
+}
+
+// GetActiveUniformsiv returns a parameter of each of a set of active uniforms of a program
+func (g *OGL45DEBUG) GetActiveUniformsiv(p graphics.Program, indices []uint32, pname graphics.Enum, params []int32) {
+	if len(indices) == 0 {
+		return
+	}
+	g.run(func() { gl.GetActiveUniformsiv(uint32(p), int32(len(indices)), &indices[0], uint32(pname), &params[0]) })
+}
+
 // GetAttribLocation returns the location of a attribute variable
 func (g *OGL45DEBUG) GetAttribLocation(p graphics.Program, name string) int32 {
 	glName := name + "\x00"
@@ -346,6 +413,25 @@ func (g *OGL45DEBUG) GetError() uint32 {
 	return gl.GetError()
 }
 
+// GetIntegerv returns the value of a GL parameter
+func (g *OGL45DEBUG) GetIntegerv(pname graphics.Enum, data *int32) {
+	g.run(func() { gl.GetIntegerv(uint32(pname), data) })
+}
+
+// GetProgramBinary returns the binary of a linked program with its format, nil
+// when there is none
+func (g *OGL45DEBUG) GetProgramBinary(p graphics.Program) ([]byte, graphics.Enum) {
+	var n int32
+	g.GetProgramiv(p, gl.PROGRAM_BINARY_LENGTH, &n)
+	if n <= 0 {
+		return nil, 0
+	}
+	b := make([]byte, n)
+	var format uint32
+	g.run(func() { gl.GetProgramBinary(uint32(p), n, &n, &format, gl.Ptr(b)) })
+	return b[:n], graphics.Enum(format)
+}
+
 // GetProgramInfoLog returns the information log for a program object
 func (g *OGL45DEBUG) GetProgramInfoLog(p graphics.Program) string {
 	var logLength int32
@@ -380,6 +466,15 @@ func (g *OGL45DEBUG) GetShaderiv(s graphics.Shader, pname graphics.Enum, params
 	g.run(func() { gl.GetShaderiv(uint32(s), uint32(pname), params) })
 }
 
+// GetUniformBlockIndex returns the index of a uniform block of a program, INVALID_INDEX
+// when there is none
+func (g *OGL45DEBUG) GetUniformBlockIndex(p graphics.Program, name string) uint32 {
+	glName := name + "\x00"
+	ret := gl.GetUniformBlockIndex(uint32(p), gl.Str(glName))
+	checkGLErr()
+	return ret
+}
+
 // GetUniformLocation returns the location of a uniform variable
 func (g *OGL45DEBUG) GetUniformLocation(p graphics.Program, name string) int32 {
 	glName := name + "\x00"
@@ -414,6 +509,16 @@ func (g *OGL45DEBUG) PolygonOffset(factor float32, units float32) {
 	g.run(func() { gl.PolygonOffset(factor, units) })
 }
 
+// ProgramBinary loads a program from a binary of a format
+func (g *OGL45DEBUG) ProgramBinary(p graphics.Program, format graphics.Enum, b []byte) {
+	g.run(func() { gl.ProgramBinary(uint32(p), uint32(format), gl.Ptr(b), int32(len(b))) })
+}
+
+// ProgramParameteri sets a parameter of a program
+func (g *OGL45DEBUG) ProgramParameteri(p graphics.Program, pname graphics.Enum, value int32) {
+	g.run(func() { gl.ProgramParameteri(uint32(p), uint32(pname), value) })
+}
+
 // Ptr takes a slice or a pointer and returns an OpenGL compatbile address
 func (g *OGL45DEBUG) Ptr(data interface{}) unsafe.Pointer {
 	return gl.Ptr(data)
@@ -431,6 +536,11 @@ func (g *OGL45DEBUG) ReadBuffer(src graphics.Enum) {
 	g.run(func() { gl.ReadBuffer(uint32(src)) })
 }
 
+// ReadPixels reads a block of pixels from the bound read framebuffer
+func (g *OGL45DEBUG) ReadPixels(x, y, width, height int32, format, ty graphics.Enum, pixels unsafe.Pointer) {
+	g.run(func() { gl.ReadPixels(x, y, width, height, uint32(format), uint32(ty), pixels) })
+}
+
 // RenderbufferStorage establishes the format and dimensions of a renderbuffer
 func (g *OGL45DEBUG) RenderbufferStorage(target graphics.Enum, internalformat graphics.Enum, width int32, height int32) {
 	g.run(func() { gl.RenderbufferStorage(uint32(target), uint32(internalformat), width, height) })
@@ -525,6 +635,11 @@ func (g *OGL45DEBUG) Uniform1fv(location int32, values []float32) {
 	g.run(func() { gl.Uniform1fv(location, int32(len(values)), &values[0]) })
 }
 
+// Uniform2f specifies the value of a uniform variable for the current program object
+func (g *OGL45DEBUG) Uniform2f(location int32, v0, v1 float32) {
+	g.run(func() { gl.Uniform2f(location, v0, v1) })
+}
+
 // Uniform3f specifies the value of a uniform variable for the current program object
 func (g *OGL45DEBUG) Uniform3f(location int32, v0, v1, v2 float32) {
 	g.run(func() { gl.Uniform3f(location, v0, v1, v2) })
@@ -545,11 +660,21 @@ func (g *OGL45DEBUG) Uniform4fv(location int32, values []float32) {
 	g.run(func() { gl.Uniform4fv(location, int32(len(values)), &values[0]) })
 }
 
+// UniformMatrix3fv specifies the value of a uniform variable for the current program object
+func (g *OGL45DEBUG) UniformMatrix3fv(location, count int32, transpose bool, value []float32) {
+	g.run(func() { gl.UniformMatrix3fv(location, count, transpose, &value[0]) })
+}
+
 // UniformMatrix4fv specifies the value of a uniform variable for the current program object
 func (g *OGL45DEBUG) UniformMatrix4fv(location, count int32, transpose bool, value []float32) {
 	g.run(func() { gl.UniformMatrix4fv(location, count, transpose, &value[0]) })
 }
 
+// UniformBlockBinding assigns a binding point to a uniform block of a program
+func (g *OGL45DEBUG) UniformBlockBinding(p graphics.Program, index, binding uint32) {
+	g.run(func() { gl.UniformBlockBinding(uint32(p), index, binding) })
+}
+
 // UseProgram installs a program object as part of the current rendering state
 func (g *OGL45DEBUG) UseProgram(p graphics.Program) {
 	g.currentProgram = p
@@ -571,6 +696,12 @@ func (g *OGL45DEBUG) VertexAttribIPointer(dst uint32, size int32, ty graphics.En
 	g.run(func() { gl.VertexAttribIPointer(dst, size, uint32(ty), stride, ptr) })
 }
 
+// VertexAttribDivisor sets the number of instances drawn before a
+// vertex attribute advances, 0 advancing per vertex.
+func (g *OGL45DEBUG) VertexAttribDivisor(index, divisor uint32) {
+	g.run(func() { gl.VertexAttribDivisor(index, divisor) })
+}
+
 // Viewport sets the viewport, an affine transformation that
 // normalizes device coordinates to window coordinates.
 func (g *OGL45DEBUG) Viewport(x, y, width, height int32) {
@@ -42,6 +42,11 @@ func (g *OGL45) BindBuffer(target graphics.Enum, b graphics.Buffer) {
 	gl.BindBuffer(uint32(target), uint32(b))
 }
 
+// BindBufferBase binds a buffer to an indexed binding point of a target
+func (g *OGL45) BindBufferBase(target graphics.Enum, index uint32, b graphics.Buffer) {
+	gl.BindBufferBase(uint32(target), index, uint32(b))
+}
+
 // BindFragDataLocation binds a user-defined varying out variable
 // to a fragment shader color number
 func (g *OGL45) BindFragDataLocation(p graphics.Program, color uint32, name string) {
@@ -99,6 +104,11 @@ func (g *OGL45) BufferData(target graphics.Enum, size int, data unsafe.Pointer,
 	gl.BufferData(uint32(target), size, data, uint32(usage))
 }
 
+// BufferSubData updates a range, from an offset, of the data store of the bound buffer object.
+func (g *OGL45) BufferSubData(target graphics.Enum, offset, size int, data unsafe.Pointer) {
+	gl.BufferSubData(uint32(target), offset, size, data)
+}
+
 // CheckFramebufferStatus checks the completeness status of a framebuffer
 func (g *OGL45) CheckFramebufferStatus(target graphics.Enum) graphics.Enum {
 	return graphics.Enum(gl.CheckFramebufferStatus(uint32(target)))
@@ -199,6 +209,11 @@ func (g *OGL45) DepthMask(flag bool) {
 	gl.DepthMask(flag)
 }
 
+// DetachShader detaches a shader object from a program object
+func (g *OGL45) DetachShader(p graphics.Program, s graphics.Shader) {
+	gl.DetachShader(uint32(p), uint32(s))
+}
+
 // Disable disables various GL capabilities.
 func (g *OGL45) Disable(e graphics.Enum) {
 	gl.Disable(uint32(e))
@@ -220,6 +235,16 @@ func (g *OGL45) DrawArrays(mode graphics.Enum, first int32, count int32) {
 	gl.DrawArrays(uint32(mode), first, count)
 }
 
+// DrawElementsInstanced renders multiple instances of primitives from array data
+func (g *OGL45) DrawElementsInstanced(mode graphics.Enum, count int32, ty graphics.Enum, indices unsafe.Pointer, instances int32) {
+	gl.DrawElementsInstanced(uint32(mode), count, uint32(ty), indices, instances)
+}
+
+// DrawArraysInstanced renders multiple instances of primitives from array data
+func (g *OGL45) DrawArraysInstanced(mode graphics.Enum, first int32, count int32, instances int32) {
+	gl.DrawArraysInstanced(uint32(mode), first, count, instances)
+}
+
 // DrawArrays renders primitives from array data
 
 // Enable enables various GL capabilities.
@@ -288,6 +313,48 @@ func (g *OGL45) GenVertexArray() uint32 {
 	return a
 }
 
+// GetActiveAttrib returns the name, size and type of an active attribute of a program
+func (g *OGL45) GetActiveAttrib(p graphics.Program, index uint32) (string, int32, graphics.Enum) {
+	var n, size int32
+	var xtype uint32
+	g.GetProgramiv(p, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &n)
+	b := make([]byte, n+1)
+	gl.GetActiveAttrib(uint32(p), index, n+1, nil, &size, &xtype, &b[0])
+	return gl.GoStr(&b[0]), size, graphics.Enum(xtype)
+}
+
+// GetActiveUniform returns the name, size and type of an active uniform of a program
+func (g *OGL45) GetActiveUniform(p graphics.Program, index uint32) (string, int32, graphics.Enum) {
+	var n, size int32
+	var xtype uint32
+	g.GetProgramiv(p, gl.ACTIVE_UNIFORM_MAX_LENGTH, &n)
+	b := make([]byte, n+1)
+	gl.GetActiveUniform(uint32(p), index, n+1, nil, &size, &xtype, &b[0])
+	return gl.GoStr(&b[0]), size, graphics.Enum(xtype)
+}
+
+// GetActiveUniformBlockName returns the name of an active uniform block of a program
+func (g *OGL45) GetActiveUniformBlockName(p graphics.Program, index uint32) string {
+	var n int32
+	g.GetActiveUniformBlockiv(p, index, gl.UNIFORM_BLOCK_NAME_LENGTH, &n)
+	b := make([]byte, n+1)
+	gl.GetActiveUniformBlockName(uint32(p), index, n+1, nil, &b[0])
+	return gl.GoStr(&b[0])
+}
+
+// GetActiveUniformBlockiv returns a parameter of an active uniform block of a program
+func (g *OGL45) GetActiveUniformBlockiv(p graphics.Program, index uint32, pname graphics.Enum, params *int32) {
+	gl.GetActiveUniformBlockiv(uint32(p), index, uint32(pname), params)
+}
+
+// GetActiveUniformsiv returns a parameter of each of a set of active uniforms of a program
+func (g *OGL45) GetActiveUniformsiv(p graphics.Program, indices []uint32, pname graphics.Enum, params []int32) {
+	if len(indices) == 0 {
+		return
+	}
+	gl.GetActiveUniformsiv(uint32(p), int32(len(indices)), &indices[0], uint32(pname), &params[0])
+}
+
 // GetAttribLocation returns the location of a attribute variable
 func (g *OGL45) GetAttribLocation(p graphics.Program, name string) int32 {
 	glName := name + "\x00"
@@ -305,6 +372,25 @@ func (g *OGL45) GetError() uint32 {
 	return gl.GetError()
 }
 
+// GetIntegerv returns the value of a GL parameter
+func (g *OGL45) GetIntegerv(pname graphics.Enum, data *int32) {
+	gl.GetIntegerv(uint32(pname), data)
+}
+
+// GetProgramBinary returns the binary of a linked program with its format, nil
+// when there is none
+func (g *OGL45) GetProgramBinary(p graphics.Program) ([]byte, graphics.Enum) {
+	var n int32
+	g.GetProgramiv(p, gl.PROGRAM_BINARY_LENGTH, &n)
+	if n <= 0 {
+		return nil, 0
+	}
+	b := make([]byte, n)
+	var format uint32
+	gl.GetProgramBinary(uint32(p), n, &n, &format, gl.Ptr(b))
+	return b[:n], graphics.Enum(format)
+}
+
 // GetProgramInfoLog returns the information log for a program object
 func (g *OGL45) GetProgramInfoLog(p graphics.Program) string {
 	var logLength int32
@@ -339,6 +425,13 @@ func (g *OGL45) GetShaderiv(s graphics.Shader, pname graphics.Enum, params *int3
 	gl.GetShaderiv(uint32(s), uint32(pname), params)
 }
 
+// GetUniformBlockIndex returns the index of a uniform block of a program, INVALID_INDEX
+// when there is none
+func (g *OGL45) GetUniformBlockIndex(p graphics.Program, name string) uint32 {
+	glName := name + "\x00"
+	return gl.GetUniformBlockIndex(uint32(p), gl.Str(glName))
+}
+
 // GetUniformLocation returns the location of a uniform variable
 func (g *OGL45) GetUniformLocation(p graphics.Program, name string) int32 {
 	glName := name + "\x00"
@@ -369,6 +462,16 @@ func (g *OGL45) PolygonOffset(factor float32, units float32) {
 	gl.PolygonOffset(factor, units)
 }
 
+// ProgramBinary loads a program from a binary of a format
+func (g *OGL45) ProgramBinary(p graphics.Program, format graphics.Enum, b []byte) {
+	gl.ProgramBinary(uint32(p), uint32(format), gl.Ptr(b), int32(len(b)))
+}
+
+// ProgramParameteri sets a parameter of a program
+func (g *OGL45) ProgramParameteri(p graphics.Program, pname graphics.Enum, value int32) {
+	gl.ProgramParameteri(uint32(p), uint32(pname), value)
+}
+
 // Ptr takes a slice or a pointer and returns an OpenGL compatbile address
 func (g *OGL45) Ptr(data interface{}) unsafe.Pointer {
 	return gl.Ptr(data)
@@ -386,6 +489,11 @@ func (g *OGL45) ReadBuffer(src graphics.Enum) {
 	gl.ReadBuffer(uint32(src))
 }
 
+// ReadPixels reads a block of pixels from the bound read framebuffer
+func (g *OGL45) ReadPixels(x, y, width, height int32, format, ty graphics.Enum, pixels unsafe.Pointer) {
+	gl.ReadPixels(x, y, width, height, uint32(format), uint32(ty), pixels)
+}
+
 // RenderbufferStorage establishes the format and dimensions of a renderbuffer
 func (g *OGL45) RenderbufferStorage(target graphics.Enum, internalformat graphics.Enum, width int32, height int32) {
 	gl.RenderbufferStorage(uint32(target), uint32(internalformat), width, height)
@@ -464,6 +572,11 @@ func (g *OGL45) Uniform1fv(location int32, values []float32) {
 	gl.Uniform1fv(location, int32(len(values)), &values[0])
 }
 
+// Uniform2f specifies the value of a uniform variable for the current program object
+func (g *OGL45) Uniform2f(location int32, v0, v1 float32) {
+	gl.Uniform2f(location, v0, v1)
+}
+
 // Uniform3f specifies the value of a uniform variable for the current program object
 func (g *OGL45) Uniform3f(location int32, v0, v1, v2 float32) {
 	gl.Uniform3f(location, v0, v1, v2)
@@ -471,7 +584,7 @@ func (g *OGL45) Uniform3f(location int32, v0, v1, v2 float32) {
 
 // Uniform3fv specifies the value of a uniform variable for the current program object
 func (g *OGL45) Uniform3fv(location int32, values []float32) {
-	gl.Uniform3fv(location, int32(len(values)), &values[0])
+	gl.Uniform3fv(location, int32(len(values)/3), &values[0])
 }
 
 // Uniform4f specifies the value of a uniform variable for the current program object
@@ -481,7 +594,12 @@ func (g *OGL45) Uniform4f(location int32, v0, v1, v2, v3 float32) {
 
 // Uniform4fv specifies the value of a uniform variable for the current program object
 func (g *OGL45) Uniform4fv(location int32, values []float32) {
-	gl.Uniform4fv(location, int32(len(values)), &values[0])
+	gl.Uniform4fv(location, int32(len(values)/4), &values[0])
+}
+
+// UniformMatrix3fv specifies the value of a uniform variable for the current program object
+func (g *OGL45) UniformMatrix3fv(location, count int32, transpose bool, value []float32) {
+	gl.UniformMatrix3fv(location, count, transpose, &value[0])
 }
 
 // UniformMatrix4fv specifies the value of a uniform variable for the current program object
@@ -489,6 +607,11 @@ func (g *OGL45) UniformMatrix4fv(location, count int32, transpose bool, value []
 	gl.UniformMatrix4fv(location, count, transpose, &value[0])
 }
 
+// UniformBlockBinding assigns a binding point to a uniform block of a program
+func (g *OGL45) UniformBlockBinding(p graphics.Program, index, binding uint32) {
+	gl.UniformBlockBinding(uint32(p), index, binding)
+}
+
 // UseProgram installs a program object as part of the current rendering state
 func (g *OGL45) UseProgram(p graphics.Program) {
 	g.currentProgram = p
@@ -510,6 +633,12 @@ func (g *OGL45) VertexAttribIPointer(dst uint32, size int32, ty graphics.Enum, s
 	gl.VertexAttribIPointer(dst, size, uint32(ty), stride, ptr)
 }
 
+// VertexAttribDivisor sets the number of instances drawn before a
+// vertex attribute advances, 0 advancing per vertex.
+func (g *OGL45) VertexAttribDivisor(index, divisor uint32) {
+	gl.VertexAttribDivisor(index, divisor)
+}
+
 // Viewport sets the viewport, an affine transformation that
 // normalizes device coordinates to window coordinates.
 func (g *OGL45) Viewport(x, y, width, height int32) {
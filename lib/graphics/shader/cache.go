@@ -0,0 +1,161 @@
+package shader
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+)
+
+// CacheStats count how shaderers came by their programs.
+type CacheStats struct {
+	Hits, Misses int // programs set that were built or not
+	Compiled     int // programs compiled from their templates
+	Loaded       int // programs loaded from binaries on disk
+	Stored       int // binaries written to disk
+	Failed       int // programs failing to build
+}
+
+// Cache is what shaderers share about their programs: profiles to build
+// before they are first drawn with, the directory binaries of programs are
+// kept in between runs, and the counts of it all.
+type Cache struct {
+	dir      string
+	declared []*Profile
+	stats    CacheStats
+}
+
+// NewCache returns a cache keeping binaries in a directory, "" for none.
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// SetDir sets the directory binaries are kept in, "" for none.
+func (c *Cache) SetDir(dir string) {
+	c.dir = dir
+}
+
+// Declare adds profiles for shaderers to build the next time they set a
+// program. Their progs are those of the shaderers of the same tag.
+func (c *Cache) Declare(prs ...*Profile) {
+	c.declared = append(c.declared, prs...)
+}
+
+func (c *Cache) Declared() []*Profile {
+	return c.declared
+}
+
+func (c *Cache) Stats() CacheStats {
+	return c.stats
+}
+
+// CurrentCache is the cache of the default shaderer.
+var CurrentCache *Cache = NewCache("")
+
+// Key is a stable key of a profile, leaving out the counts of lights and
+// textures of independent profiles.
+func (p *Profile) Key() string {
+	k := fmt.Sprintf("%s|%s|%s|%s|%s", p.Tag, p.Version, p.F, p.G, p.V)
+	if p.Independent {
+		return k + "|independent"
+	}
+	return fmt.Sprintf(
+		"%s|%d|%d|%d|%d|%d|%d",
+		k,
+		p.AmbientLightsMax,
+		p.DirectionalLightsMax,
+		p.PointLightsMax,
+		p.SpotLightsMax,
+		p.MaterialTexturesMax,
+		p.JointsMax,
+	)
+}
+
+// A binary is kept in a file named by a hash of the provider, the profile
+// and the sources it was built from:
+//
+//	magic  [8]byte "SHVPRGM" and the version
+//	format uint32
+//	crc    uint32 of the binary
+//	binary
+//
+// little endian.
+const binaryVersion = 1
+
+var binaryMagic = [8]byte{'S', 'H', 'V', 'P', 'R', 'G', 'M', binaryVersion}
+
+func binaryName(p graphics.Provider, pr *Profile, srcs []stageSource) string {
+	tag, major, minor := p.Version()
+	h := sha1.New()
+	fmt.Fprintf(h, "%s %d.%d\x00%s", tag, major, minor, pr.Key())
+	for _, s := range srcs {
+		fmt.Fprintf(h, "\x00%s\x00%s", s.kind, s.Code)
+	}
+	return hex.EncodeToString(h.Sum(nil)) + ".bin"
+}
+
+// binaries is whether a provider can give and take program binaries.
+func binaries(p graphics.Provider) bool {
+	var n int32
+	p.GetIntegerv(graphics.NUM_PROGRAM_BINARY_FORMATS, &n)
+	return n > 0
+}
+
+// loadBinary is a program from a binary kept at a path, false if there is
+// none or the provider no longer takes it.
+func loadBinary(p graphics.Provider, pr *Profile, path string) (*Program, bool) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil || len(b) < 16 || !bytes.Equal(b[:8], binaryMagic[:]) {
+		return nil, false
+	}
+	format := graphics.Enum(binary.LittleEndian.Uint32(b[8:]))
+	crc := binary.LittleEndian.Uint32(b[12:])
+	data := b[16:]
+	if crc32.ChecksumIEEE(data) != crc {
+		return nil, false
+	}
+	h := p.CreateProgram()
+	p.ProgramBinary(h, format, data)
+	var status int32
+	p.GetProgramiv(h, graphics.LINK_STATUS, &status)
+	if status == graphics.FALSE {
+		p.DeleteProgram(h)
+		return nil, false
+	}
+	prgm := &Program{Profile: pr, handle: h, binary: data, format: format}
+	prgm.reflected(p)
+	return prgm, true
+}
+
+// storeBinary keeps the binary of a program at a path, false if the
+// provider gave none.
+func storeBinary(p graphics.Provider, prgm *Program, path string) (bool, error) {
+	data, format := p.GetProgramBinary(prgm.handle)
+	if len(data) == 0 {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	b := new(bytes.Buffer)
+	b.Write(binaryMagic[:])
+	binary.Write(b, binary.LittleEndian, uint32(format))
+	binary.Write(b, binary.LittleEndian, crc32.ChecksumIEEE(data))
+	b.Write(data)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b.Bytes(), 0644); err != nil {
+		return false, err
+	}
+	return true, os.Rename(tmp, path)
+}
@@ -0,0 +1,275 @@
+package shader
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// Validator checks a shader of a kind, "vertex", "geometry" or "fragment",
+// without a GPU.
+type Validator interface {
+	Validate(kind string, s *Source) error
+}
+
+var (
+	NoStageError = xrror.Xrror("no glslang stage for %s shaders").Out
+	SyntaxError  = xrror.Xrror("%s: %s").Out
+)
+
+type glslang struct {
+	path string
+}
+
+// Glslang is a validator running glslangValidator, the reference GLSL
+// compiler, found at a path or by name on PATH.
+func Glslang(path string) (Validator, error) {
+	p, err := exec.LookPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return &glslang{p}, nil
+}
+
+var glslangStages = map[string]string{
+	"vertex":   "vert",
+	"geometry": "geom",
+	"fragment": "frag",
+}
+
+func (g *glslang) Validate(kind string, s *Source) error {
+	st, ok := glslangStages[kind]
+	if !ok {
+		return NoStageError(kind)
+	}
+	cmd := exec.Command(g.path, "--stdin", "-S", st)
+	cmd.Stdin = strings.NewReader(s.Code)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if len(out) == 0 {
+			return err
+		}
+		return errors.New(strings.TrimSpace(s.MapLog(string(out))))
+	}
+	return nil
+}
+
+// Syntax is a validator of the structure of GLSL, for when no compiler is
+// at hand: the version directive first, comments closed, brackets matched,
+// preprocessor conditionals closed, nothing of a template left unrendered
+// and a main function.
+var Syntax Validator = syntax{}
+
+type syntax struct{}
+
+var (
+	reMain       = regexp.MustCompile(`\bvoid\s+main\s*\(`)
+	reDirective  = regexp.MustCompile(`^#\s*([a-z]*)`)
+	reVersion    = regexp.MustCompile(`^#\s*version\s+\d+(\s+(core|compatibility|es))?\s*$`)
+	closeBracket = map[byte]byte{')': '(', ']': '[', '}': '{'}
+	directives   = map[string]bool{
+		"define": true, "undef": true, "if": true, "ifdef": true, "ifndef": true,
+		"else": true, "elif": true, "endif": true, "error": true, "pragma": true,
+		"extension": true, "version": true, "line": true, "": true,
+	}
+)
+
+type bracket struct {
+	c    byte
+	line int
+}
+
+func (syntax) Validate(kind string, s *Source) error {
+	at := func(n int) string {
+		if l, ok := s.Where(n); ok {
+			return l.String()
+		}
+		return fmt.Sprintf("line %d", n)
+	}
+	var brackets []bracket
+	var conds []int
+	version, main := false, false
+	comment := 0
+	for i, ln := range strings.Split(s.Code, "\n") {
+		n := i + 1
+		code := new(strings.Builder)
+		for j := 0; j < len(ln); j++ {
+			switch {
+			case comment > 0:
+				if strings.HasPrefix(ln[j:], "*/") {
+					comment = 0
+					j++
+				}
+			case strings.HasPrefix(ln[j:], "//"):
+				j = len(ln)
+			case strings.HasPrefix(ln[j:], "/*"):
+				comment = n
+				j++
+			default:
+				code.WriteByte(ln[j])
+			}
+		}
+		c := strings.TrimSpace(code.String())
+		if c == "" {
+			continue
+		}
+		if strings.Contains(c, "{{") || strings.Contains(c, "}}") || strings.Contains(c, "<no value>") {
+			return SyntaxError(at(n), "template left unrendered")
+		}
+		if d := reDirective.FindStringSubmatch(c); d != nil {
+			switch {
+			case !directives[d[1]]:
+				return SyntaxError(at(n), "unknown directive #"+d[1])
+			case d[1] == "version":
+				if version {
+					return SyntaxError(at(n), "#version after other code")
+				}
+				if !reVersion.MatchString(c) {
+					return SyntaxError(at(n), "malformed #version")
+				}
+			case !version:
+				return SyntaxError(at(n), "#version is not first")
+			case d[1] == "if" || d[1] == "ifdef" || d[1] == "ifndef":
+				conds = append(conds, n)
+			case d[1] == "else" || d[1] == "elif":
+				if len(conds) == 0 {
+					return SyntaxError(at(n), "#"+d[1]+" without #if")
+				}
+			case d[1] == "endif":
+				if len(conds) == 0 {
+					return SyntaxError(at(n), "#endif without #if")
+				}
+				conds = conds[:len(conds)-1]
+			}
+			version = true
+			continue
+		}
+		if !version {
+			return SyntaxError(at(n), "#version is not first")
+		}
+		if reMain.MatchString(c) {
+			main = true
+		}
+		for j := 0; j < len(c); j++ {
+			switch b := c[j]; b {
+			case '(', '[', '{':
+				brackets = append(brackets, bracket{b, n})
+			case ')', ']', '}':
+				if len(brackets) == 0 {
+					return SyntaxError(at(n), "unmatched "+string(b))
+				}
+				if o := brackets[len(brackets)-1]; o.c != closeBracket[b] {
+					return SyntaxError(at(o.line), "unclosed "+string(o.c)+" before "+string(b))
+				}
+				brackets = brackets[:len(brackets)-1]
+			}
+		}
+	}
+	switch {
+	case comment > 0:
+		return SyntaxError(at(comment), "comment not closed")
+	case len(brackets) > 0:
+		b := brackets[len(brackets)-1]
+		return SyntaxError(at(b.line), "unclosed "+string(b.c))
+	case len(conds) > 0:
+		return SyntaxError(at(conds[len(conds)-1]), "#if without #endif")
+	case !version:
+		return SyntaxError(at(1), "no #version")
+	case !main:
+		return SyntaxError(at(1), "no main function")
+	}
+	return nil
+}
+
+// checkTextures are the texture counts programs are checked with.
+var checkTextures = []int{0, 1, 4}
+
+// Permutations are the profiles a prog is checked with: each kind of light
+// with none or one, with none, one or several textures.
+func Permutations(p *Prog) []*Profile {
+	joints := 0
+	if p.Tag == "skinned" {
+		joints = MaxJoints
+	}
+	var ret []*Profile
+	for lights := 0; lights < 16; lights++ {
+		use := material.ULNone
+		if lights != 0 {
+			use = material.ULAll
+		}
+		for _, t := range checkTextures {
+			ret = append(ret, &Profile{
+				Prog:                 p,
+				UseLights:            use,
+				AmbientLightsMax:     lights & 1,
+				DirectionalLightsMax: lights >> 1 & 1,
+				PointLightsMax:       lights >> 2 & 1,
+				SpotLightsMax:        lights >> 3 & 1,
+				MaterialTexturesMax:  t,
+				JointsMax:            joints,
+			})
+		}
+	}
+	return ret
+}
+
+func (p *Profile) String() string {
+	return fmt.Sprintf(
+		"%s (lights %d/%d/%d/%d, textures %d)",
+		p.Tag,
+		p.AmbientLightsMax,
+		p.DirectionalLightsMax,
+		p.PointLightsMax,
+		p.SpotLightsMax,
+		p.MaterialTexturesMax,
+	)
+}
+
+// Problem is a shader of a profile failing to render or validate.
+type Problem struct {
+	Profile   *Profile
+	Kind, Tag string
+	Err       error
+}
+
+func (p *Problem) Error() string {
+	return fmt.Sprintf("%s %s shader %s: %s", p.Profile, p.Kind, p.Tag, p.Err)
+}
+
+// Check renders each shader of every permutation of the progs of the
+// shaderer, validating each distinct source once, returning the problems
+// found, each once, and how many sources were validated.
+func (s *shaderer) Check(v Validator) ([]*Problem, int) {
+	var problems []*Problem
+	validated := make(map[string]error)
+	reported := make(map[string]bool)
+	progs := append(append([]*Prog{}, s.prog...), custom...)
+	for _, prog := range progs {
+		for _, pr := range Permutations(prog) {
+			for _, st := range pr.stages() {
+				src, err := s.Source(st.tag, pr)
+				if err == nil {
+					key := st.kind + "\x00" + src.Code
+					var done bool
+					if err, done = validated[key]; !done {
+						err = v.Validate(st.kind, src)
+						validated[key] = err
+					}
+				}
+				if err == nil {
+					continue
+				}
+				if key := st.kind + st.tag + err.Error(); !reported[key] {
+					reported[key] = true
+					problems = append(problems, &Problem{pr, st.kind, st.tag, err})
+				}
+			}
+		}
+	}
+	return problems, len(validated)
+}
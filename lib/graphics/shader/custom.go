@@ -0,0 +1,111 @@
+package shader
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// Custom is a shader registered at run time, each stage the name of a
+// template or the source of one, a template itself, with the params its
+// materials set.
+type Custom struct {
+	Tag, Version               string
+	Vertex, Geometry, Fragment string
+	Params                     []*material.Param
+}
+
+var (
+	NoTagError        = xrror.Xrror("custom shader without a tag").Out
+	MissingStageError = xrror.Xrror("custom shader %s has no %s stage").Out
+)
+
+// scripted holds the templates of custom shaders, found before the files
+// and templates built in.
+var scripted = MapLoader()
+
+// custom are the progs of custom shaders, found by every shaderer.
+var custom []*Prog
+
+// isTemplateName is whether a stage of a custom shader names a template
+// rather than being the source of one: a single line, no more than a name.
+func isTemplateName(s string) bool {
+	s = strings.TrimSpace(s)
+	return s != "" && !strings.ContainsAny(s, "\n{};#( ")
+}
+
+var reVersionLine = regexp.MustCompile(`(?m)^[ \t]*#[ \t]*version[^\n]*\n?`)
+
+// prologue puts the version directive at the top of the source of a stage
+// without one, with the params declared after it.
+func prologue(src string, ps []*material.Param) string {
+	decl := new(strings.Builder)
+	for _, p := range ps {
+		fmt.Fprintf(decl, "uniform %s %s;\n", graphics.UniformTypeString(p.Type), p.Name)
+	}
+	if at := reVersionLine.FindStringIndex(src); at != nil {
+		v := src[at[0]:at[1]]
+		if !strings.HasSuffix(v, "\n") {
+			v += "\n"
+		}
+		return src[:at[0]] + v + decl.String() + src[at[1]:]
+	}
+	return "#version {{ .Version }}\n" + decl.String() + src
+}
+
+// Register adds a custom shader, or replaces one of the same tag, rebuilding
+// the programs of it on their next use. Materials use it by its tag as any
+// shader, lit as their profiles have them.
+func Register(c *Custom) error {
+	if c.Tag == "" {
+		return NoTagError()
+	}
+	if strings.TrimSpace(c.Vertex) == "" {
+		return MissingStageError(c.Tag, "vertex")
+	}
+	if strings.TrimSpace(c.Fragment) == "" {
+		return MissingStageError(c.Tag, "fragment")
+	}
+	version := c.Version
+	if version == "" {
+		version = defaultVersion
+	}
+	stage := func(src, ext string) string {
+		if src == "" || isTemplateName(src) {
+			return strings.TrimSpace(src)
+		}
+		name := c.Tag + ext
+		scripted.TemplateMap[name] = prologue(src, c.Params)
+		return name
+	}
+	p := &Prog{
+		c.Tag,
+		version,
+		stage(c.Fragment, ".frag"),
+		stage(c.Geometry, ".geom"),
+		stage(c.Vertex, ".vert"),
+	}
+	material.DeclareParams(c.Tag, c.Params...)
+	for _, o := range custom {
+		if o.Tag == c.Tag {
+			*o = *p
+			changed(c.Tag)
+			return nil
+		}
+	}
+	custom = append(custom, p)
+	return nil
+}
+
+// Customs are the tags of the custom shaders registered.
+func Customs() []string {
+	ret := make([]string, 0, len(custom))
+	for _, p := range custom {
+		ret = append(ret, p.Tag)
+	}
+	return ret
+}
@@ -1,14 +1,31 @@
 package shader
 
 var defaultTemplates = map[string]string{
-	"cattributes": cattributes,
-	"clights":     clights,
-	"cmaterials":  cmaterials,
-	"cphong":      cphong,
-	"vbasic":      vbasic,
-	"fbasic":      fbasic,
-	"vstandard":   vstandard,
-	"fstandard":   fstandard,
+	"cattributes":  cattributes,
+	"cframe":       cframe,
+	"clights":      clights,
+	"cmaterials":   cmaterials,
+	"cenvironment": cenvironment,
+	"cfog":         cfog,
+	"cphong":       cphong,
+	"vbasic":       vbasic,
+	"fbasic":       fbasic,
+	"vstandard":    vstandard,
+	"fstandard":    fstandard,
+	"cskinning":    cskinning,
+	"vskinned":     vskinned,
+	"vpick":        vpick,
+	"fpick":        fpick,
+	"vparticle":    vparticle,
+	"fparticle":    fparticle,
+	"vtext":        vtext,
+	"ftext":        ftext,
+	"ftextsdf":     ftextsdf,
+	"fimage":       fimage,
+	"vsky":         vsky,
+	"fsky":         fsky,
+	"vterrain":     vterrain,
+	"fterrain":     fterrain,
 }
 
 const cattributes = `{{ define "cattributes" }}// Vertex attributes
@@ -18,37 +35,60 @@ layout(location = 2) in vec3  VertexColor;
 layout(location = 3) in vec2  VertexTexcoord;
 layout(location = 4) in float VertexDistance;
 layout(location = 5) in vec4  VertexTexoffsets;
+layout(location = 6) in vec4  VertexJoints;
+layout(location = 7) in vec4  VertexWeights;
+// Per instance particle attributes
+layout(location = 8) in vec4  ParticlePosition;
+layout(location = 9) in vec4  ParticleColor;
+layout(location = 10) in vec2 ParticleFrame;
+// Per vertex glyph color
+layout(location = 11) in vec4 GlyphColor;
+{{ end }}
+`
+
+const cframe = `{{ define "cframe" }}
+// Frame uniform block, as graphics.FrameLayout, the same for every draw of a frame
+layout(std140) uniform Frame {
+    mat4 ViewMatrix;
+    mat4 ProjectionMatrix;
+    vec4 CameraPosition;
+    vec4 Time;
+};
 {{ end }}
 `
 
 const clights = `{{ define "clights" }}
+{{ if or .AmbientLightsMax .DirectionalLightsMax .PointLightsMax .SpotLightsMax }}
+// Lights uniform block, as graphics.LightsLayout, lights of each kind taking
+// as many vec4 each
+layout(std140) uniform Lights {
+    vec4 AmbientLight[{{ lightsMax }}];
+    vec4 DirLight[2*{{ lightsMax }}];
+    vec4 PointLight[3*{{ lightsMax }}];
+    vec4 SpotLight[5*{{ lightsMax }}];
+};
+{{ end }}
 {{ if .AmbientLightsMax }}
-// Ambient lights uniforms
-uniform vec3 AmbientLightColor[{{.AmbientLightsMax}}];
+// Macros to access elements inside the AmbientLight array
+#define AmbientLightColor(a)		AmbientLight[a].xyz
 {{ end }}
-{{ if .DirLightsMax }}
-// Directional lights uniform array. Each directional light uses 2 elements
-uniform vec3  DirLight[2*{{.DirLightsMax}}];
-// Macros to access elements inside the DirectionalLight uniform array
-#define DirLightColor(a)		DirLight[2*a]
-#define DirLightPosition(a)		DirLight[2*a+1]
+{{ if .DirectionalLightsMax }}
+// Macros to access elements inside the DirLight array
+#define DirLightColor(a)		DirLight[2*a].xyz
+#define DirLightPosition(a)		DirLight[2*a+1].xyz
 {{ end }}
 {{ if .PointLightsMax }}
-// Point lights uniform array. Each point light uses 3 elements
-uniform vec3  PointLight[3*{{.PointLightsMax}}];
-// Macros to access elements inside the PointLight uniform array
-#define PointLightColor(a)			PointLight[3*a]
-#define PointLightPosition(a)		PointLight[3*a+1]
+// Macros to access elements inside the PointLight array
+#define PointLightColor(a)			PointLight[3*a].xyz
+#define PointLightPosition(a)		PointLight[3*a+1].xyz
 #define PointLightLinearDecay(a)	PointLight[3*a+2].x
 #define PointLightQuadraticDecay(a)	PointLight[3*a+2].y
 {{ end }}
 {{ if .SpotLightsMax }}
-// Spot lights uniforms. Each spot light uses 5 elements
-uniform vec3  SpotLight[5*{{.SpotLightsMax}}];
-// Macros to access elements inside the PointLight uniform array
-#define SpotLightColor(a)			SpotLight[5*a]
-#define SpotLightPosition(a)		SpotLight[5*a+1]
-#define SpotLightDirection(a)		SpotLight[5*a+2]
+// Macros to access elements inside the SpotLight array
+#define SpotLightColor(a)			SpotLight[5*a].xyz
+#define SpotLightPosition(a)		SpotLight[5*a+1].xyz
+#define SpotLightDirection(a)		SpotLight[5*a+2].xyz
 #define SpotLightAngularDecay(a)	SpotLight[5*a+3].x
 #define SpotLightCutoffAngle(a)		SpotLight[5*a+3].y
 #define SpotLightLinearDecay(a)		SpotLight[5*a+3].z
@@ -58,17 +98,23 @@ uniform vec3  SpotLight[5*{{.SpotLightsMax}}];
 `
 
 const cmaterials = `{{ define "cmaterials" }}
-// Material uniforms
-uniform vec3	Material[6];
-// Macros to access elements inside the Material uniform array
-#define MatAmbientColor		Material[0]
-#define MatDiffuseColor		Material[1]
-#define MatSpecularColor	Material[2]
-#define MatEmissiveColor	Material[3]
-#define MatShininess		Material[4].x
-#define MatOpacity			Material[4].y
-#define MatPointSize		Material[4].z
-#define MatPointRotationZ	Material[5].x
+// Material uniform block, as graphics.MaterialLayout
+layout(std140) uniform Material {
+    vec4 MatAmbient;
+    vec4 MatDiffuse;
+    vec4 MatSpecular;
+    vec4 MatEmissive;
+    vec4 MatParams;
+};
+// Macros to access elements inside the Material block
+#define MatAmbientColor		MatAmbient.xyz
+#define MatDiffuseColor		MatDiffuse.xyz
+#define MatSpecularColor	MatSpecular.xyz
+#define MatEmissiveColor	MatEmissive.xyz
+#define MatShininess		MatParams.x
+#define MatOpacity			MatParams.y
+#define MatPointSize		MatParams.z
+#define MatPointRotationZ	MatParams.w
 {{if .MaterialTexturesMax}}
 // Textures uniforms
 uniform sampler2D	MatTexture[{{.MaterialTexturesMax}}];
@@ -82,6 +128,73 @@ uniform mat3		MatTexinfo[{{.MaterialTexturesMax}}];
 {{ end }}
 `
 
+const cenvironment = `{{ define "cenvironment" }}
+// Environment uniform block, as graphics.EnvironmentLayout, what the scene
+// has around what it draws
+layout(std140) uniform Environment {
+    vec4 EnvAmbient;
+    vec4 FogColor;
+    vec4 FogParams;
+    vec4 FogHeight;
+    vec4 SkyTop;
+    vec4 SkyHorizon;
+    vec4 SkyBottom;
+    vec4 SkyParams;
+};
+// Macros to access elements inside the Environment block
+#define EnvAmbientColor		EnvAmbient.xyz
+#define FogMode				int(FogColor.w)
+#define FogDensity			FogParams.x
+#define FogStart			FogParams.y
+#define FogEnd				FogParams.z
+#define FogBase				FogHeight.x
+#define FogFalloff			FogHeight.y
+#define SkyKind				int(SkyParams.x)
+#define SkyExposure			SkyParams.y
+#define SkyRotation			SkyParams.z
+#define SkyHorizonSharpness	SkyParams.w
+{{ end }}
+`
+
+const cfog = `{{ define "cfog" }}
+/***
+ fog of the environment, none, linear, exponential, squared exponential or
+ thinning with height above FogBase
+ Parameters:
+    color:      input color of a fragment
+    position:   input fragment position in camera coordinates
+ Uniforms:
+    Frame block
+    Environment block
+*/
+vec3 applyFog(vec3 color, vec3 position) {
+    int mode = FogMode;
+    if (mode == 0) {
+        return color;
+    }
+    float dist = length(position);
+    float amount;
+    if (mode == 1) {
+        amount = clamp((dist - FogStart) / max(FogEnd - FogStart, 1e-5), 0.0, 1.0);
+    } else if (mode == 2) {
+        amount = 1.0 - exp(-FogDensity * dist);
+    } else if (mode == 3) {
+        float d = FogDensity * dist;
+        amount = 1.0 - exp(-d * d);
+    } else {
+        // Fog integrated along the ray from the camera through density
+        // falling off exponentially with height.
+        vec3 dir = transpose(mat3(ViewMatrix)) * (position / max(dist, 1e-5));
+        float falloff = max(FogFalloff, 1e-5);
+        float t = dir.y * falloff * dist;
+        float k = abs(t) > 1e-4 ? (1.0 - exp(-t)) / t : 1.0;
+        amount = 1.0 - exp(-FogDensity * exp(-falloff * (CameraPosition.y - FogBase)) * dist * k);
+    }
+    return mix(color, FogColor.xyz, amount);
+}
+{{ end }}
+`
+
 const cphong = `{{ define "cphong" }}
 /***
  phong lighting model
@@ -94,13 +207,8 @@ const cphong = `{{ define "cphong" }}
     ambdiff:    output ambient+diffuse color
     spec:       output specular color
  Uniforms:
-    AmbientLightColor[]
-    DiffuseLightColor[]
-    DiffuseLightPosition[]
-    PointLightColor[]
-    PointLightPosition[];
-    PointLightLinearDecay[];
-    PointLightQuadraticDecay[];
+    Lights block
+    Environment block
     MatSpecularColor
     MatShininess
 */
@@ -108,10 +216,11 @@ void phongModel(vec4 position, vec3 normal, vec3 camDir, vec3 matAmbient, vec3 m
     vec3 ambientTotal  = vec3(0.0);
     vec3 diffuseTotal  = vec3(0.0);
     vec3 specularTotal = vec3(0.0);
+    ambientTotal += EnvAmbientColor * matAmbient;
     {{ range loop .AmbientLightsMax }}
-        ambientTotal += AmbientLightColor[{{.}}] * matAmbient;
+        ambientTotal += AmbientLightColor({{.}}) * matAmbient;
     {{ end }}
-    {{ range loop .DirLightsMax }}
+    {{ range loop .DirectionalLightsMax }}
     {
         // Diffuse reflection
         // DirLightPosition is the direction of the current light
@@ -211,6 +320,7 @@ const vstandard = `
 {{ include "cattributes" }}
 {{ include "cmaterials" }}
 {{ include "clights" }}
+{{ include "cenvironment" }}
 #version {{.Version}}
 {{ template "cattributes" .}}
 // Model uniforms
@@ -219,6 +329,7 @@ uniform mat3 NormalMatrix;
 uniform mat4 MVP;
 {{ template "clights" . }}
 {{ template "cmaterials" . }}
+{{ template "cenvironment" . }}
 {{ template "cphong" . }}
 // Outputs for the fragment shader.
 out vec3 ColorFrontAmbdiff;
@@ -226,6 +337,7 @@ out vec3 ColorFrontSpec;
 out vec3 ColorBackAmbdiff;
 out vec3 ColorBackSpec;
 out vec2 FragTexcoord;
+out vec3 FragPosition;
 void main() {
     // Transform this vertex normal to camera coordinates.
     vec3 normal = normalize(NormalMatrix * VertexNormal);
@@ -239,27 +351,35 @@ void main() {
     phongModel(position,  normal, camDir, MatAmbientColor, MatDiffuseColor, ColorFrontAmbdiff, ColorFrontSpec);
     phongModel(position, -normal, camDir, MatAmbientColor, MatDiffuseColor, ColorBackAmbdiff, ColorBackSpec);
     vec2 texcoord = VertexTexcoord;
-    {{if .MatTexturesMax }}
+    {{if .MaterialTexturesMax }}
     // Flips texture coordinate Y if requested.
     if (MatTexFlipY(0)) {
         texcoord.y = 1 - texcoord.y;
     }
     {{ end }}
     FragTexcoord = texcoord;
+    FragPosition = position.xyz;
     gl_Position = MVP * vec4(VertexPosition, 1.0);
 }
 `
 
 const fstandard = `
 {{ include "cmaterials" }}
+{{ include "cframe" }}
+{{ include "cenvironment" }}
+{{ include "cfog" }}
 #version {{.Version}}
 {{ template "cmaterials" .}}
+{{ template "cframe" . }}
+{{ template "cenvironment" . }}
+{{ template "cfog" . }}
 // Inputs from Vertex shader
 in vec3 ColorFrontAmbdiff;
 in vec3 ColorFrontSpec;
 in vec3 ColorBackAmbdiff;
 in vec3 ColorBackSpec;
 in vec2 FragTexcoord;
+in vec3 FragPosition;
 // Output
 out vec4 FragColor;
 void main() {
@@ -267,7 +387,7 @@ void main() {
     // Combine all texture colors and opacity
     // Use Go templates to unroll the loop because non-const
     // array indexes are not allowed until GLSL 4.00.
-    {{ range loop .MatTexturesMax }}
+    {{ range loop .MaterialTexturesMax }}
     if (MatTexVisible({{.}})) {
         vec4 texcolor = texture(MatTexture[{{.}}], FragTexcoord * MatTexRepeat({{.}}) + MatTexOffset({{.}}));
         if ({{.}} == 0) {
@@ -287,5 +407,342 @@ void main() {
         colorSpec = vec4(ColorBackSpec, 0);
     }
     FragColor = min(colorAmbDiff * texCombined + colorSpec, vec4(1));
+    FragColor.rgb = applyFog(FragColor.rgb, FragPosition);
+}
+`
+
+const vpick = `
+{{ include "cattributes" }}
+#version {{ .Version }}
+{{ template "cattributes" . }}
+uniform mat4 MVP;
+void main() {
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+`
+
+const fpick = `
+#version {{ .Version }}
+uniform vec4 PickID;
+out vec4 FragColor;
+void main() {
+    FragColor = PickID;
+}
+`
+
+const cskinning = `{{ define "cskinning" }}
+// Skinning uniforms, one matrix per joint
+uniform mat4 JointMatrix[{{.JointsMax}}];
+mat4 skinMatrix() {
+    return VertexWeights.x * JointMatrix[int(VertexJoints.x)] +
+        VertexWeights.y * JointMatrix[int(VertexJoints.y)] +
+        VertexWeights.z * JointMatrix[int(VertexJoints.z)] +
+        VertexWeights.w * JointMatrix[int(VertexJoints.w)];
+}
+{{ end }}
+`
+
+const vskinned = `
+{{ include "cattributes" }}
+{{ include "cmaterials" }}
+{{ include "clights" }}
+{{ include "cenvironment" }}
+{{ include "cskinning" }}
+#version {{.Version}}
+{{ template "cattributes" .}}
+{{ template "cskinning" . }}
+// Model uniforms
+uniform mat4 ModelViewMatrix;
+uniform mat3 NormalMatrix;
+uniform mat4 MVP;
+{{ template "clights" . }}
+{{ template "cmaterials" . }}
+{{ template "cenvironment" . }}
+{{ template "cphong" . }}
+// Outputs for the fragment shader.
+out vec3 ColorFrontAmbdiff;
+out vec3 ColorFrontSpec;
+out vec3 ColorBackAmbdiff;
+out vec3 ColorBackSpec;
+out vec2 FragTexcoord;
+out vec3 FragPosition;
+void main() {
+    // Linear blend skinning of the bind pose position and normal.
+    mat4 skin = skinMatrix();
+    vec4 skinnedPosition = skin * vec4(VertexPosition, 1.0);
+    vec3 skinnedNormal = mat3(skin) * VertexNormal;
+    // Transform this vertex normal to camera coordinates.
+    vec3 normal = normalize(NormalMatrix * skinnedNormal);
+    // Calculate this vertex position in camera coordinates
+    vec4 position = ModelViewMatrix * skinnedPosition;
+    // Calculate the direction vector from the vertex to the camera
+    // The camera is at 0,0,0
+    vec3 camDir = normalize(-position.xyz);
+    // Calculates the vertex Ambient+Diffuse and Specular colors using the Phong model
+    // for the front and back
+    phongModel(position,  normal, camDir, MatAmbientColor, MatDiffuseColor, ColorFrontAmbdiff, ColorFrontSpec);
+    phongModel(position, -normal, camDir, MatAmbientColor, MatDiffuseColor, ColorBackAmbdiff, ColorBackSpec);
+    vec2 texcoord = VertexTexcoord;
+    {{if .MaterialTexturesMax }}
+    // Flips texture coordinate Y if requested.
+    if (MatTexFlipY(0)) {
+        texcoord.y = 1 - texcoord.y;
+    }
+    {{ end }}
+    FragTexcoord = texcoord;
+    FragPosition = position.xyz;
+    gl_Position = MVP * skinnedPosition;
+}
+`
+
+const vparticle = `
+{{ include "cattributes" }}
+{{ include "cframe" }}
+#version {{ .Version }}
+{{ template "cattributes" . }}
+{{ template "cframe" . }}
+// Texture atlas columns and rows
+uniform vec2 ParticleAtlas;
+out vec4 Color;
+out vec2 Texcoord;
+out vec2 Corner;
+void main() {
+    // billboard the quad corner in view space, rotated and scaled
+    float c = cos(ParticleFrame.y);
+    float s = sin(ParticleFrame.y);
+    vec2 corner = mat2(c, s, -s, c) * VertexPosition.xy * ParticlePosition.w;
+    vec4 center = ViewMatrix * vec4(ParticlePosition.xyz, 1.0);
+    gl_Position = ProjectionMatrix * (center + vec4(corner, 0.0, 0.0));
+    Color = ParticleColor;
+    Corner = VertexPosition.xy;
+    vec2 grid = max(ParticleAtlas, vec2(1.0));
+    float frame = floor(ParticleFrame.x);
+    vec2 cell = vec2(mod(frame, grid.x), grid.y - 1.0 - floor(frame / grid.x));
+    Texcoord = (cell + VertexTexcoord) / grid;
+}
+`
+
+const fparticle = `
+#version {{ .Version }}
+{{if .MaterialTexturesMax}}
+uniform sampler2D MatTexture[{{.MaterialTexturesMax}}];
+{{end}}
+in vec4 Color;
+in vec2 Texcoord;
+in vec2 Corner;
+out vec4 FragColor;
+void main() {
+{{if .MaterialTexturesMax}}
+    FragColor = Color * texture(MatTexture[0], Texcoord);
+{{else}}
+    // soft round point without a texture
+    float d = length(Corner) * 2.0;
+    FragColor = vec4(Color.rgb, Color.a * (1.0 - smoothstep(0.5, 1.0, d)));
+{{end}}
+}
+`
+
+const vtext = `
+{{ include "cattributes" }}
+#version {{ .Version }}
+{{ template "cattributes" . }}
+// Model uniforms
+uniform mat4 ModelViewMatrix;
+uniform mat4 ProjectionMatrix;
+out vec4 Color;
+out vec2 Texcoord;
+void main() {
+    Color = GlyphColor;
+    Texcoord = VertexTexcoord;
+    gl_Position = ProjectionMatrix * ModelViewMatrix * vec4(VertexPosition, 1.0);
+}
+`
+
+const ftext = `
+#version {{ .Version }}
+{{if .MaterialTexturesMax}}
+uniform sampler2D MatTexture[{{.MaterialTexturesMax}}];
+{{end}}
+in vec4 Color;
+in vec2 Texcoord;
+out vec4 FragColor;
+void main() {
+{{if .MaterialTexturesMax}}
+    // glyph coverage in the red channel of the atlas
+    FragColor = vec4(Color.rgb, Color.a * texture(MatTexture[0], Texcoord).r);
+{{else}}
+    FragColor = Color;
+{{end}}
+}
+`
+
+const ftextsdf = `
+#version {{ .Version }}
+{{if .MaterialTexturesMax}}
+uniform sampler2D MatTexture[{{.MaterialTexturesMax}}];
+{{end}}
+in vec4 Color;
+in vec2 Texcoord;
+out vec4 FragColor;
+void main() {
+{{if .MaterialTexturesMax}}
+    // distance to the outline at 0.5, smoothed over about a screen pixel
+    float d = texture(MatTexture[0], Texcoord).r;
+    float w = max(fwidth(d), 0.0001) * 0.75;
+    float a = smoothstep(0.5 - w, 0.5 + w, d);
+    if (a <= 0.0) {
+        discard;
+    }
+    FragColor = vec4(Color.rgb, Color.a * a);
+{{else}}
+    FragColor = Color;
+{{end}}
+}
+`
+
+const fimage = `
+#version {{ .Version }}
+{{if .MaterialTexturesMax}}
+uniform sampler2D MatTexture[{{.MaterialTexturesMax}}];
+{{end}}
+in vec4 Color;
+in vec2 Texcoord;
+out vec4 FragColor;
+void main() {
+{{if .MaterialTexturesMax}}
+    // texels tinted by the vertex color
+    FragColor = Color * texture(MatTexture[0], Texcoord);
+{{else}}
+    FragColor = Color;
+{{end}}
+}
+`
+
+const vsky = `
+{{ include "cframe" }}
+#version {{ .Version }}
+{{ template "cframe" . }}
+// Direction of the sky in world coordinates through a fragment
+out vec3 SkyDirection;
+void main() {
+    // A triangle covering the screen, at the far plane.
+    vec2 position = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2) * 2.0 - 1.0;
+    vec4 view = inverse(ProjectionMatrix) * vec4(position, 1.0, 1.0);
+    SkyDirection = transpose(mat3(ViewMatrix)) * (view.xyz / view.w);
+    gl_Position = vec4(position, 1.0, 1.0);
+}
+`
+
+const fsky = `
+{{ include "cenvironment" }}
+#version {{ .Version }}
+{{ template "cenvironment" . }}
+// Sky textures, the one of the kind of sky sampled
+uniform samplerCube SkyCube;
+uniform sampler2D   SkyEquirect;
+in vec3 SkyDirection;
+out vec4 FragColor;
+const float PI = 3.14159265358979;
+void main() {
+    vec3 dir = normalize(SkyDirection);
+    // Turns the sky about the up axis.
+    float c = cos(SkyRotation);
+    float s = sin(SkyRotation);
+    dir = vec3(c * dir.x - s * dir.z, dir.y, s * dir.x + c * dir.z);
+    vec3 color;
+    int kind = SkyKind;
+    if (kind == 2) {
+        color = texture(SkyCube, dir).rgb;
+    } else if (kind == 3) {
+        vec2 uv = vec2(atan(dir.z, dir.x) / (2.0 * PI) + 0.5, acos(clamp(dir.y, -1.0, 1.0)) / PI);
+        color = texture(SkyEquirect, uv).rgb;
+    } else {
+        float h = pow(1.0 - abs(dir.y), max(SkyHorizonSharpness, 1e-3));
+        vec3 pole = dir.y >= 0.0 ? SkyTop.xyz : SkyBottom.xyz;
+        color = mix(pole, SkyHorizon.xyz, h);
+    }
+    if (SkyExposure > 0.0) {
+        color = vec3(1.0) - exp(-color * SkyExposure);
+    }
+    FragColor = vec4(color, 1.0);
+}
+`
+
+const vterrain = `
+{{ include "cattributes" }}
+{{ include "cmaterials" }}
+{{ include "clights" }}
+{{ include "cenvironment" }}
+#version {{ .Version }}
+{{ template "cattributes" . }}
+// Model uniforms
+uniform mat4 ModelViewMatrix;
+uniform mat3 NormalMatrix;
+uniform mat4 MVP;
+{{ template "clights" . }}
+{{ template "cmaterials" . }}
+{{ template "cenvironment" . }}
+{{ template "cphong" . }}
+// Outputs for the fragment shader, the light reaching a vertex the layers
+// are colored with.
+out vec3 ColorAmbdiff;
+out vec3 ColorSpec;
+out vec2 FragTexcoord;
+out vec3 FragPosition;
+void main() {
+    vec3 normal = normalize(NormalMatrix * VertexNormal);
+    vec4 position = ModelViewMatrix * vec4(VertexPosition, 1.0);
+    vec3 camDir = normalize(-position.xyz);
+    phongModel(position, normal, camDir, vec3(1.0), vec3(1.0), ColorAmbdiff, ColorSpec);
+    FragTexcoord = VertexTexcoord;
+    FragPosition = position.xyz;
+    gl_Position = MVP * vec4(VertexPosition, 1.0);
+}
+`
+
+const fterrain = `
+{{ include "cmaterials" }}
+{{ include "cframe" }}
+{{ include "cenvironment" }}
+{{ include "cfog" }}
+#version {{ .Version }}
+{{ template "cmaterials" . }}
+{{ template "cframe" . }}
+{{ template "cenvironment" . }}
+{{ template "cfog" . }}
+// Terrain uniforms: the splat map weighing each layer by a channel, the
+// layers, how often each repeats across the terrain and how many there are
+uniform sampler2D SplatMap;
+uniform sampler2D TerrainLayer[4];
+uniform vec4      TerrainTiling;
+uniform int       TerrainLayers;
+in vec3 ColorAmbdiff;
+in vec3 ColorSpec;
+in vec2 FragTexcoord;
+in vec3 FragPosition;
+out vec4 FragColor;
+void main() {
+    vec3 albedo = MatDiffuseColor;
+    if (TerrainLayers > 0) {
+        vec4 weights = texture(SplatMap, FragTexcoord);
+        vec3 base = texture(TerrainLayer[0], FragTexcoord * TerrainTiling.x).rgb;
+        vec3 sum = weights.r * base;
+        float total = weights.r;
+        if (TerrainLayers > 1) {
+            sum += weights.g * texture(TerrainLayer[1], FragTexcoord * TerrainTiling.y).rgb;
+            total += weights.g;
+        }
+        if (TerrainLayers > 2) {
+            sum += weights.b * texture(TerrainLayer[2], FragTexcoord * TerrainTiling.z).rgb;
+            total += weights.b;
+        }
+        if (TerrainLayers > 3) {
+            sum += weights.a * texture(TerrainLayer[3], FragTexcoord * TerrainTiling.w).rgb;
+            total += weights.a;
+        }
+        albedo = total > 0.0 ? sum / total : base;
+    }
+    vec3 color = min(ColorAmbdiff * albedo + ColorSpec, vec3(1.0));
+    FragColor = vec4(applyFog(color, FragPosition), MatOpacity);
 }
 `
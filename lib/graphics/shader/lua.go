@@ -0,0 +1,136 @@
+package shader
+
+import (
+	"sort"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+// shv.shaders.declare("standard", {textures = 1, directional = 1}) declares
+// a permutation of a program to build before it is first drawn with, of
+// counts of ambient, directional, point and spot lights and of textures, or
+// independent of them.
+func lDeclare(L *l.LState) int {
+	tag := L.CheckString(1)
+	pr := &Profile{Prog: &Prog{Tag: tag}}
+	if t := L.OptTable(2, nil); t != nil {
+		n := func(k string) int {
+			return int(l.LVAsNumber(t.RawGetString(k)))
+		}
+		pr.Independent = l.LVAsBool(t.RawGetString("independent"))
+		pr.AmbientLightsMax = n("ambient")
+		pr.DirectionalLightsMax = n("directional")
+		pr.PointLightsMax = n("point")
+		pr.SpotLightsMax = n("spot")
+		pr.MaterialTexturesMax = n("textures")
+	}
+	if pr.AmbientLightsMax+pr.DirectionalLightsMax+pr.PointLightsMax+pr.SpotLightsMax > 0 {
+		pr.UseLights = material.ULAll
+	}
+	if tag == "skinned" {
+		pr.JointsMax = MaxJoints
+	}
+	CurrentCache.Declare(pr)
+	return 0
+}
+
+// shv.shaders.stats() is a table of hits, misses, compiled, loaded, stored
+// and failed programs.
+func lStats(L *l.LState) int {
+	s := CurrentCache.Stats()
+	t := L.NewTable()
+	t.RawSetString("hits", l.LNumber(s.Hits))
+	t.RawSetString("misses", l.LNumber(s.Misses))
+	t.RawSetString("compiled", l.LNumber(s.Compiled))
+	t.RawSetString("loaded", l.LNumber(s.Loaded))
+	t.RawSetString("stored", l.LNumber(s.Stored))
+	t.RawSetString("failed", l.LNumber(s.Failed))
+	L.Push(t)
+	return 1
+}
+
+// luaParam is a param from a type, "float", "int", "bool", "vec2", "vec3",
+// "vec4" or "mat4", or a table of a type and a default.
+func luaParam(L *l.LState, name string, v l.LValue) *material.Param {
+	var def l.LValue = l.LNil
+	if t, ok := v.(*l.LTable); ok {
+		v, def = t.RawGetInt(1), t.RawGetInt(2)
+	}
+	typ, ok := graphics.StringToUniformType(v.String())
+	if !ok {
+		L.RaiseError("uniform %s is of unknown type %s", name, v.String())
+		return nil
+	}
+	var dv []float32
+	if def != l.LNil {
+		if dv, ok = material.Floats(def); !ok {
+			L.RaiseError("uniform %s default is not numbers", name)
+			return nil
+		}
+	}
+	p, err := material.NewParam(name, typ, dv...)
+	if err != nil {
+		L.RaiseError("%s", err)
+		return nil
+	}
+	return p
+}
+
+// shv.shaders.register("water", {vertex = src, fragment = src, uniforms =
+// {time = "float", tint = {"vec3", {0, 0.4, 1}}}}) registers a custom
+// shader materials are drawn with by its tag. Each stage, vertex, fragment
+// and an optional geometry, is the name of a template or the source of one,
+// which may include the lights and materials built in as those do. Sources
+// get a version directive when they have none, and the uniforms declared
+// after it. An optional version sets the GLSL version.
+func lRegister(L *l.LState) int {
+	tag := L.CheckString(1)
+	t := L.CheckTable(2)
+	str := func(k string) string {
+		if v, ok := t.RawGetString(k).(l.LString); ok {
+			return string(v)
+		}
+		return ""
+	}
+	c := &Custom{
+		Tag:      tag,
+		Version:  str("version"),
+		Vertex:   str("vertex"),
+		Geometry: str("geometry"),
+		Fragment: str("fragment"),
+	}
+	if ut, ok := t.RawGetString("uniforms").(*l.LTable); ok {
+		var names []string
+		ut.ForEach(func(k, _ l.LValue) {
+			names = append(names, k.String())
+		})
+		sort.Strings(names)
+		for _, n := range names {
+			c.Params = append(c.Params, luaParam(L, n, ut.RawGetString(n)))
+		}
+	}
+	if err := Register(c); err != nil {
+		L.RaiseError("%s", err)
+	}
+	return 0
+}
+
+var shaderFuncs = map[string]l.LGFunction{
+	"declare":  lDeclare,
+	"register": lRegister,
+	"stats":    lStats,
+}
+
+func RegisterWith() lua.RegisterWith {
+	return func(m lua.Module) error {
+		rmtfn := func(L *l.LState, M lua.Module) {
+			lua.SetSub(L, M, "shaders", shaderFuncs)
+		}
+		m.AddMT(rmtfn)
+		return nil
+	}
+}
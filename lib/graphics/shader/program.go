@@ -3,6 +3,7 @@ package shader
 import (
 	"fmt"
 
+	"github.com/Laughs-In-Flowers/log"
 	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
 	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
 )
@@ -11,19 +12,56 @@ type Prog struct {
 	Tag, Version, F, G, V string
 }
 
+type stage struct {
+	kind, tag string
+}
+
+// stages are the kinds of shader a prog has, with the templates of each.
+func (p *Prog) stages() []stage {
+	var ret []stage
+	for _, s := range []stage{
+		{"fragment", p.F},
+		{"geometry", p.G},
+		{"vertex", p.V},
+	} {
+		if s.tag != "" {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
 var defaultVersion string = "410 core"
 
+// PickProg writes a flat id color per draw, for gpu picking.
+var PickProg = &Prog{"pick", defaultVersion, "fpick", "", "vpick"}
+
+// SkyProg draws the sky of the environment behind a scene.
+var SkyProg = &Prog{"sky", defaultVersion, "fsky", "", "vsky"}
+
 var defaultProg = []*Prog{
 	{"basic", defaultVersion, "fbasic", "", "vbasic"},
 	{"standard", defaultVersion, "fstandard", "", "vstandard"},
+	{"skinned", defaultVersion, "fstandard", "", "vskinned"},
+	{"particle", defaultVersion, "fparticle", "", "vparticle"},
+	{"text", defaultVersion, "ftext", "", "vtext"},
+	{"textsdf", defaultVersion, "ftextsdf", "", "vtext"},
+	{"image", defaultVersion, "fimage", "", "vtext"},
+	{"terrain", defaultVersion, "fterrain", "", "vterrain"},
+	PickProg,
+	SkyProg,
 }
 
+// MaxJoints is the size of the joint matrix array of skinned programs.
+const MaxJoints = 64
+
 type Profile struct {
 	*Prog
 	Independent bool
 	UseLights   material.UseLights
 	AmbientLightsMax, DirectionalLightsMax,
 	PointLightsMax, SpotLightsMax, MaterialTexturesMax int
+	JointsMax int
 }
 
 func (p *Profile) Equals(o *Profile) bool {
@@ -38,74 +76,189 @@ func (p *Profile) Equals(o *Profile) bool {
 		p.DirectionalLightsMax == o.DirectionalLightsMax &&
 		p.PointLightsMax == o.PointLightsMax &&
 		p.SpotLightsMax == o.SpotLightsMax &&
-		p.MaterialTexturesMax == o.MaterialTexturesMax:
+		p.MaterialTexturesMax == o.MaterialTexturesMax &&
+		p.JointsMax == o.JointsMax:
 		return true
 	}
 	return false
 }
 
+// Program is a linked program of a profile, from shaders, or from a binary
+// when loaded from one.
 type Program struct {
 	*Profile
-	handle graphics.Program
+	handle  graphics.Program
+	shaders []graphics.Shader
+	binary  []byte
+	format  graphics.Enum
+	reflect *Reflection
 }
 
 func NewProgram(p graphics.Provider, pr *Profile, sr Shaderer, s ...graphics.Shader) (*Program, error) {
 	var ss []graphics.Shader = s
 	if len(ss) < 1 {
-		var frag, geo, vert graphics.Shader
 		var err error
-
-		if pr.F != "" {
-			frag, err = NewShader(p, pr, sr, "fragment", pr.F)
-			if err != nil {
-				return nil, err
-			}
-			ss = append(ss, frag)
+		ss, err = buildShaders(p, pr, sr)
+		if err != nil {
+			return nil, err
 		}
+	}
+	handle, err := compileProgram(p, false, ss...)
+	if err != nil {
+		return nil, err
+	}
 
-		if pr.G != "" {
-			geo, err = NewShader(p, pr, sr, "geometry", pr.G)
-			if err != nil {
-				return nil, err
-			}
-			ss = append(ss, geo)
+	prgm := &Program{
+		Profile: pr, handle: handle, shaders: ss,
+	}
+	prgm.reflected(p)
+	return prgm, nil
+}
+
+// stageSource is a stage of a prog rendered.
+type stageSource struct {
+	stage
+	*Source
+}
+
+func renderStages(pr *Profile, sr Shaderer) ([]stageSource, error) {
+	var ret []stageSource
+	for _, k := range pr.stages() {
+		src, err := sr.Source(k.tag, pr)
+		if err != nil {
+			return nil, err
 		}
+		ret = append(ret, stageSource{k, src})
+	}
+	return ret, nil
+}
 
-		if pr.V != "" {
-			vert, err = NewShader(p, pr, sr, "vertex", pr.V)
-			if err != nil {
-				return nil, err
-			}
-			ss = append(ss, vert)
+func compileStages(p graphics.Provider, srcs []stageSource) ([]graphics.Shader, error) {
+	var ss []graphics.Shader
+	for _, s := range srcs {
+		sh, err := buildShader(p, s.kind, s.tag, s.Source)
+		if err != nil {
+			deleteShaders(p, ss)
+			return nil, err
 		}
+		ss = append(ss, sh)
 	}
-	handle, err := compileProgram(p, ss...)
+	return ss, nil
+}
+
+func buildShaders(p graphics.Provider, pr *Profile, sr Shaderer) ([]graphics.Shader, error) {
+	srcs, err := renderStages(pr, sr)
 	if err != nil {
 		return nil, err
 	}
+	return compileStages(p, srcs)
+}
 
-	return &Program{
-		pr, handle,
-	}, nil
+// newProgram compiles and links a program from rendered stages, its binary
+// retrievable when asked for.
+func newProgram(p graphics.Provider, pr *Profile, srcs []stageSource, retrievable bool) (*Program, error) {
+	ss, err := compileStages(p, srcs)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := compileProgram(p, retrievable, ss...)
+	if err != nil {
+		deleteShaders(p, ss)
+		return nil, err
+	}
+	prgm := &Program{Profile: pr, handle: handle, shaders: ss}
+	prgm.reflected(p)
+	return prgm, nil
+}
+
+func deleteShaders(p graphics.Provider, ss []graphics.Shader) {
+	for _, s := range ss {
+		p.DeleteShader(s)
+	}
 }
 
-func compileProgram(p graphics.Provider, shaders ...graphics.Shader) (graphics.Program, error) {
+func compileProgram(p graphics.Provider, retrievable bool, shaders ...graphics.Shader) (graphics.Program, error) {
 	programID := p.CreateProgram()
 
+	if retrievable {
+		p.ProgramParameteri(programID, graphics.PROGRAM_BINARY_RETRIEVABLE_HINT, graphics.TRUE)
+	}
+
 	for _, s := range shaders {
 		p.AttachShader(programID, s)
 	}
 
+	if err := linkProgram(p, programID); err != nil {
+		p.DeleteProgram(programID)
+		return 0, err
+	}
+
+	return programID, nil
+}
+
+func linkProgram(p graphics.Provider, programID graphics.Program) error {
 	p.LinkProgram(programID)
 
 	var status int32
 	p.GetProgramiv(programID, graphics.LINK_STATUS, &status)
 	if status == graphics.FALSE {
 		log := p.GetProgramInfoLog(programID)
-		return 0, fmt.Errorf("failed to link program: %v", log)
+		return fmt.Errorf("failed to link program: %v", log)
 	}
+	return nil
+}
 
-	return programID, nil
+// Rebuild compiles the program again from its templates as they are now,
+// relinking it under the same handle. When that fails the program is
+// relinked from what it was, the error returned.
+func (p *Program) Rebuild(g graphics.Provider, sr Shaderer) error {
+	ss, err := buildShaders(g, p.Profile, sr)
+	if err != nil {
+		return err
+	}
+	if err := p.relink(g, p.shaders, ss); err != nil {
+		if p.shaders == nil && p.binary != nil {
+			p.detach(g, ss)
+			g.ProgramBinary(p.handle, p.format, p.binary)
+		} else {
+			p.relink(g, ss, p.shaders)
+		}
+		deleteShaders(g, ss)
+		p.reflected(g)
+		return err
+	}
+	deleteShaders(g, p.shaders)
+	p.shaders, p.binary = ss, nil
+	p.reflected(g)
+	return nil
+}
+
+// reflected enumerates what the program has active once linked, binding its
+// blocks.
+func (p *Program) reflected(g graphics.Provider) {
+	p.reflect = Reflect(g, p.handle)
+	if err := bindBlocks(g, p.handle, p.reflect); err != nil {
+		log.Printf("%s: %s", p.Profile, err)
+	}
+}
+
+// Reflection is what the program had active when last linked.
+func (p *Program) Reflection() *Reflection {
+	return p.reflect
+}
+
+func (p *Program) detach(g graphics.Provider, ss []graphics.Shader) {
+	for _, s := range ss {
+		g.DetachShader(p.handle, s)
+	}
+}
+
+func (p *Program) relink(g graphics.Provider, from, to []graphics.Shader) error {
+	p.detach(g, from)
+	for _, s := range to {
+		g.AttachShader(p.handle, s)
+	}
+	return linkProgram(g, p.handle)
 }
 
 func (p *Program) Provide(g graphics.Provider) {
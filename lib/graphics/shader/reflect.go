@@ -0,0 +1,192 @@
+package shader
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// Variable is an active uniform or attribute of a linked program. Uniforms
+// of a block have no location but an offset, and a stride when arrays.
+type Variable struct {
+	Name     string
+	Type     graphics.Enum
+	Size     int32
+	Location int32
+	Block    int32
+	Offset   int32
+	Stride   int32
+}
+
+// Block is an active uniform block of a linked program, with the uniforms
+// of it.
+type Block struct {
+	Name     string
+	Index    uint32
+	Binding  uint32
+	Size     int32
+	Uniforms []*Variable
+}
+
+// Reflection is what a linked program has active, as the provider has it.
+type Reflection struct {
+	Uniforms   []*Variable
+	Blocks     []*Block
+	Attributes []*Variable
+}
+
+// variableName is the name of a uniform without the [0] arrays are named
+// with.
+func variableName(n string) string {
+	return strings.TrimSuffix(n, "[0]")
+}
+
+// Reflect enumerates the active uniforms, uniform blocks and attributes of a
+// linked program.
+func Reflect(p graphics.Provider, h graphics.Program) *Reflection {
+	r := &Reflection{}
+	var n int32
+
+	p.GetProgramiv(h, graphics.ACTIVE_UNIFORM_BLOCKS, &n)
+	for i := uint32(0); i < uint32(n); i++ {
+		b := &Block{Name: p.GetActiveUniformBlockName(h, i), Index: i}
+		var v int32
+		p.GetActiveUniformBlockiv(h, i, graphics.UNIFORM_BLOCK_DATA_SIZE, &v)
+		b.Size = v
+		p.GetActiveUniformBlockiv(h, i, graphics.UNIFORM_BLOCK_BINDING, &v)
+		b.Binding = uint32(v)
+		r.Blocks = append(r.Blocks, b)
+	}
+
+	p.GetProgramiv(h, graphics.ACTIVE_UNIFORMS, &n)
+	if n > 0 {
+		idx := make([]uint32, n)
+		for i := range idx {
+			idx[i] = uint32(i)
+		}
+		blocks := make([]int32, n)
+		offsets := make([]int32, n)
+		strides := make([]int32, n)
+		p.GetActiveUniformsiv(h, idx, graphics.UNIFORM_BLOCK_INDEX, blocks)
+		p.GetActiveUniformsiv(h, idx, graphics.UNIFORM_OFFSET, offsets)
+		p.GetActiveUniformsiv(h, idx, graphics.UNIFORM_ARRAY_STRIDE, strides)
+		for i := range idx {
+			name, size, t := p.GetActiveUniform(h, idx[i])
+			u := &Variable{
+				Name:     variableName(name),
+				Type:     t,
+				Size:     size,
+				Location: -1,
+				Block:    blocks[i],
+				Offset:   offsets[i],
+				Stride:   strides[i],
+			}
+			if u.Block < 0 || int(u.Block) >= len(r.Blocks) {
+				u.Block = -1
+				u.Location = p.GetUniformLocation(h, name)
+				r.Uniforms = append(r.Uniforms, u)
+				continue
+			}
+			b := r.Blocks[u.Block]
+			b.Uniforms = append(b.Uniforms, u)
+		}
+	}
+	for _, b := range r.Blocks {
+		sort.Slice(b.Uniforms, func(i, j int) bool {
+			return b.Uniforms[i].Offset < b.Uniforms[j].Offset
+		})
+	}
+
+	p.GetProgramiv(h, graphics.ACTIVE_ATTRIBUTES, &n)
+	for i := uint32(0); i < uint32(n); i++ {
+		name, size, t := p.GetActiveAttrib(h, i)
+		r.Attributes = append(r.Attributes, &Variable{
+			Name:     name,
+			Type:     t,
+			Size:     size,
+			Location: p.GetAttribLocation(h, name),
+			Block:    -1,
+		})
+	}
+	return r
+}
+
+func findVariable(vs []*Variable, name string) (*Variable, bool) {
+	for _, v := range vs {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// Uniform is an active uniform outside of any block.
+func (r *Reflection) Uniform(name string) (*Variable, bool) {
+	return findVariable(r.Uniforms, name)
+}
+
+func (r *Reflection) Attribute(name string) (*Variable, bool) {
+	return findVariable(r.Attributes, name)
+}
+
+func (r *Reflection) Block(name string) (*Block, bool) {
+	for _, b := range r.Blocks {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+func (b *Block) Uniform(name string) (*Variable, bool) {
+	return findVariable(b.Uniforms, name)
+}
+
+var (
+	BlockSizeError   = xrror.Xrror("block %s is %d bytes, laid out as %d").Out
+	BlockMemberError = xrror.Xrror("block %s has no member %s").Out
+	BlockOffsetError = xrror.Xrror("block %s member %s is at %d stride %d, laid out at %d stride %d").Out
+)
+
+// Check is whether a block is laid out as a layout has it, member for
+// member.
+func (b *Block) Check(l *graphics.Std140) error {
+	if int(b.Size) != l.Size() {
+		return BlockSizeError(b.Name, b.Size, l.Size())
+	}
+	for _, m := range l.Members() {
+		u, ok := b.Uniform(m.Name)
+		if !ok {
+			return BlockMemberError(b.Name, m.Name)
+		}
+		stride := int32(0)
+		if m.Count > 1 {
+			stride = int32(m.Stride)
+		}
+		if u.Offset != int32(m.Offset) || u.Stride != stride {
+			return BlockOffsetError(b.Name, m.Name, u.Offset, u.Stride, m.Offset, stride)
+		}
+	}
+	return nil
+}
+
+// bindBlocks binds the blocks of a linked program to the binding points
+// blocks of their names are bound with, checking they are laid out as
+// their layouts have them.
+func bindBlocks(p graphics.Provider, h graphics.Program, r *Reflection) error {
+	var err error
+	for _, b := range r.Blocks {
+		binding, l, ok := graphics.BlockBinding(b.Name)
+		if !ok {
+			continue
+		}
+		p.UniformBlockBinding(h, b.Index, binding)
+		b.Binding = binding
+		if e := b.Check(l); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
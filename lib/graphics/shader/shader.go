@@ -1,15 +1,17 @@
 package shader
 
 import (
-	"bytes"
-	"fmt"
+	"errors"
 	"strings"
 
 	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
 	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
 )
 
-var noShaderError = xrror.Xrror("%s not mappable to a graphics shader type").Out
+var (
+	noShaderError = xrror.Xrror("%s not mappable to a graphics shader type").Out
+	CompileError  = xrror.Xrror("failed to compile %s shader %s:\n%s").Out
+)
 
 func toGLShaderEnum(key string) (graphics.Enum, error) {
 	switch strings.ToLower(key) {
@@ -24,23 +26,14 @@ func toGLShaderEnum(key string) (graphics.Enum, error) {
 }
 
 func NewShader(p graphics.Provider, pr *Profile, sr Shaderer, kind, tag string) (graphics.Shader, error) {
-	raw, err := renderShaderTemplate(sr, pr, tag)
+	src, err := sr.Source(tag, pr)
 	if err != nil {
 		return 0, err
 	}
-	return buildShader(p, kind, raw)
-}
-
-func renderShaderTemplate(sr Shaderer, pr *Profile, tag string) (string, error) {
-	b := new(bytes.Buffer)
-	err := sr.Render(b, tag, pr)
-	if err != nil {
-		return "", err
-	}
-	return b.String(), nil
+	return buildShader(p, kind, tag, src)
 }
 
-func buildShader(p graphics.Provider, kind, source string) (graphics.Shader, error) {
+func buildShader(p graphics.Provider, kind, tag string, src *Source) (graphics.Shader, error) {
 	var sge graphics.Enum
 	var err error
 	sge, err = toGLShaderEnum(kind)
@@ -48,16 +41,16 @@ func buildShader(p graphics.Provider, kind, source string) (graphics.Shader, err
 		return 0, err
 	}
 	var handle graphics.Shader
-	handle, err = compileShader(p, source, sge)
+	handle, err = compileShader(p, src, sge)
 	if err != nil {
-		return 0, err
+		return 0, CompileError(kind, tag, err)
 	}
 	return handle, nil
 }
 
-func compileShader(p graphics.Provider, raw string, shaderType graphics.Enum) (graphics.Shader, error) {
+func compileShader(p graphics.Provider, src *Source, shaderType graphics.Enum) (graphics.Shader, error) {
 	shader := p.CreateShader(shaderType)
-	p.ShaderSource(shader, raw)
+	p.ShaderSource(shader, src.Code)
 
 	p.CompileShader(shader)
 
@@ -65,8 +58,8 @@ func compileShader(p graphics.Provider, raw string, shaderType graphics.Enum) (g
 	p.GetShaderiv(shader, graphics.COMPILE_STATUS, &status)
 	if status == graphics.FALSE {
 		msg := p.GetShaderInfoLog(shader)
-		errmsg := fmt.Errorf("failed to compile %v: %v", raw, msg)
-		return 0, errmsg
+		p.DeleteShader(shader)
+		return 0, errors.New(src.MapLog(msg))
 	}
 	return shader, nil
 }
@@ -1,16 +1,19 @@
 package shader
 
 import (
+	"bytes"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"text/template"
 
+	"github.com/Laughs-In-Flowers/log"
 	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
 	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
 	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
 )
 
@@ -21,36 +24,47 @@ type Shaderer interface {
 	GenerateProfile(material.Material) *Profile
 	SetProgram(graphics.Provider, *Profile) error
 	GenerateProgram(graphics.Provider, *Profile) (graphics.Program, error)
+	Precompile(graphics.Provider) error
+	Reload(graphics.Provider) error
 	//Bind(graphics.Provider, *Bind) error
 }
 
 type shaderer struct {
 	Templater
-	prog []*Prog
-	prgm []*Program
+	prog     []*Prog
+	prgm     []*Program
+	programs map[string]*Program
+	cache    *Cache
+	declared int
+	binaries int
+	seen     int
 }
 
-func NewShaderer() *shaderer {
+func NewShaderer(c *Cache) *shaderer {
 	return &shaderer{
-		NewTemplater(NewLoaderSet(), EmptyFuncSet()),
-		make([]*Prog, 0),
-		make([]*Program, 0),
+		Templater: NewTemplater(NewLoaderSet(), EmptyFuncSet()),
+		prog:      make([]*Prog, 0),
+		prgm:      make([]*Program, 0),
+		programs:  make(map[string]*Program),
+		cache:     c,
+		seen:      changes,
 	}
 }
 
 func DefaultShaderer() *shaderer {
 	return &shaderer{
-		defaultTemplater(),
-		defaultProg,
-		make([]*Program, 0),
+		Templater: defaultTemplater(),
+		prog:      defaultProg,
+		prgm:      make([]*Program, 0),
+		programs:  make(map[string]*Program),
+		cache:     CurrentCache,
+		seen:      changes,
 	}
 }
 
 func (s *shaderer) GetProg(tag string) *Prog {
-	for _, p := range s.prog {
-		if tag == p.Tag {
-			return p
-		}
+	if p, ok := s.findProg(tag); ok {
+		return p
 	}
 	return s.GetProg("basic")
 }
@@ -60,18 +74,18 @@ func (s *shaderer) SetProg(tag, version, fragment, geometry, vertex string) {
 }
 
 func (s *shaderer) SetProgram(p graphics.Provider, pr *Profile) error {
-	var h graphics.Program
-	var err error
-	for _, program := range s.prgm {
-		if program.Equals(pr) {
-			h = program.handle
-			goto USEPROGRAM
-		}
+	if s.seen != changes {
+		s.seen = changes
+		s.Reload(p)
 	}
-	h, err = s.GenerateProgram(p, pr)
-	goto USEPROGRAM
-
-USEPROGRAM:
+	s.Precompile(p)
+	if program, ok := s.programs[pr.Key()]; ok {
+		s.cache.stats.Hits++
+		p.UseProgram(program.handle)
+		return nil
+	}
+	s.cache.stats.Misses++
+	h, err := s.GenerateProgram(p, pr)
 	p.UseProgram(h)
 	return err
 }
@@ -88,6 +102,10 @@ func (s *shaderer) GenerateProfile(m material.Material) *Profile {
 	}
 	use := m.UseLights()
 	matTexCt := m.TextureCount()
+	var joints int
+	if prog.Tag == "skinned" {
+		joints = MaxJoints
+	}
 	return &Profile{
 		prog,
 		independent,
@@ -97,24 +115,129 @@ func (s *shaderer) GenerateProfile(m material.Material) *Profile {
 		point,
 		spot,
 		matTexCt,
+		joints,
 	}
 }
 
+// GenerateProgram is the program of a profile, built if there is none yet.
 func (s *shaderer) GenerateProgram(p graphics.Provider, pr *Profile) (graphics.Program, error) {
-	prgm, err := NewProgram(p, pr, s)
+	key := pr.Key()
+	if prgm, ok := s.programs[key]; ok {
+		return prgm.handle, nil
+	}
+	prgm, err := s.build(p, pr)
 	if err != nil {
+		s.cache.stats.Failed++
 		return 0, err
 	}
+	s.programs[key] = prgm
 	s.prgm = append(s.prgm, prgm)
 	return prgm.handle, nil
 }
 
+// build makes the program of a profile from the binary kept of what its
+// templates render now, or compiles it, keeping its binary.
+func (s *shaderer) build(p graphics.Provider, pr *Profile) (*Program, error) {
+	srcs, err := renderStages(pr, s)
+	if err != nil {
+		return nil, err
+	}
+	var path string
+	if s.cache.dir != "" && s.binariesSupported(p) {
+		path = filepath.Join(s.cache.dir, binaryName(p, pr, srcs))
+		if prgm, ok := loadBinary(p, pr, path); ok {
+			s.cache.stats.Loaded++
+			return prgm, nil
+		}
+	}
+	prgm, err := newProgram(p, pr, srcs, path != "")
+	if err != nil {
+		return nil, err
+	}
+	s.cache.stats.Compiled++
+	if path != "" {
+		stored, err := storeBinary(p, prgm, path)
+		if err != nil {
+			log.Printf("keeping %s shader program binary: %s", pr.Tag, err)
+		}
+		if stored {
+			s.cache.stats.Stored++
+		}
+	}
+	return prgm, nil
+}
+
+func (s *shaderer) binariesSupported(p graphics.Provider) bool {
+	if s.binaries == 0 {
+		s.binaries = -1
+		if binaries(p) {
+			s.binaries = 1
+		}
+	}
+	return s.binaries > 0
+}
+
+// Precompile builds the programs of the profiles declared to the cache
+// since it was last called, logging those failing, returning the first
+// error.
+func (s *shaderer) Precompile(p graphics.Provider) error {
+	var first error
+	declared := s.cache.declared
+	for ; s.declared < len(declared); s.declared++ {
+		d := *declared[s.declared]
+		prog, ok := s.findProg(d.Tag)
+		if !ok {
+			log.Printf("precompiling: no shader program %s", d.Tag)
+			continue
+		}
+		d.Prog = prog
+		if _, err := s.GenerateProgram(p, &d); err != nil {
+			log.Printf("precompiling %s: %s", &d, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+func (s *shaderer) findProg(tag string) (*Prog, bool) {
+	for _, p := range s.prog {
+		if tag == p.Tag {
+			return p, true
+		}
+	}
+	for _, p := range custom {
+		if tag == p.Tag {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Reload rebuilds every program from its templates as they are now, each
+// keeping its handle. Programs failing to build stay as they were, their
+// errors logged, the first returned.
+func (s *shaderer) Reload(p graphics.Provider) error {
+	var first error
+	for _, prgm := range s.prgm {
+		if err := prgm.Rebuild(p, s); err != nil {
+			log.Printf("reloading %s shader program: %s", prgm.Tag, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
 //func (s *shaderer) Bind(p graphics.Provider, b *Bind) error {
 //	return nil
 //}
 
 type Templater interface {
 	Render(io.Writer, string, interface{}) error
+	Source(string, interface{}) (*Source, error)
 	Fetch(string) (*template.Template, error)
 }
 
@@ -132,33 +255,55 @@ func defaultTemplater() *templater {
 }
 
 func (t *templater) Render(w io.Writer, name string, data interface{}) error {
-	tmpl, err := t.assemble(name)
+	s, err := t.Source(name, data)
 	if err != nil {
 		return err
 	}
+	_, err = io.WriteString(w, s.Code)
+	return err
+}
+
+// Source renders a template, mapping each line rendered to the template
+// line it came from.
+func (t *templater) Source(name string, data interface{}) (*Source, error) {
+	tmpl, err := t.assemble(name)
+	if err != nil {
+		return nil, err
+	}
 
 	if tmpl == nil {
-		return NilTemplateError(name)
+		return nil, NilTemplateError(name)
 	}
 
-	return tmpl.Execute(w, data)
+	b := new(bytes.Buffer)
+	if err := tmpl.Execute(b, data); err != nil {
+		return nil, err
+	}
+	return unmark(b.String()), nil
 }
 
+// Fetch is a template assembled, rendering markers of its lines Source
+// takes out.
 func (t *templater) Fetch(name string) (*template.Template, error) {
 	return t.assemble(name)
 }
 
 var (
 	reExtendsTag  *regexp.Regexp = regexp.MustCompile("{{ extends [\"']?([^'\"}']*)[\"']? }}")
-	reIncludeTag  *regexp.Regexp = regexp.MustCompile(`{{ include ["']?([^"]*)["']? }}`)
+	reIncludeTag  *regexp.Regexp = regexp.MustCompile(`{{ (include|import) ["']?([^"'}]*)["']? }}`)
 	reDefineTag   *regexp.Regexp = regexp.MustCompile("{{ ?define \"([^\"]*)\" ?\"?([a-zA-Z0-9]*)?\"? ?}}")
 	reTemplateTag *regexp.Regexp = regexp.MustCompile("{{ ?template \"([^\"]*)\" ?([^ ]*)? ?}}")
 
 	NilTemplateError   = xrror.Xrror("nil template named %s").Out
 	NoTemplateError    = xrror.Xrror("no template named %s").Out
 	EmptyTemplateError = xrror.Xrror("empty template named %s").Out
+	IncludeDepthError  = xrror.Xrror("includes nested too deep at %s").Out
 )
 
+// maxIncludeDepth is how deep includes may nest, deeper most likely a
+// template including itself.
+const maxIncludeDepth = 32
+
 func (t *templater) assemble(name string) (*template.Template, error) {
 	stack := make([]*Node, 0)
 
@@ -171,20 +316,12 @@ func (t *templater) assemble(name string) (*template.Template, error) {
 	blocks := map[string]string{}
 	blockId := 0
 
+	seen := make(map[string]bool)
 	for _, node := range stack {
-		var errInReplace error = nil
-		node.Src = reIncludeTag.ReplaceAllStringFunc(node.Src, func(raw string) string {
-			parsed := reIncludeTag.FindStringSubmatch(raw)
-			templatePath := parsed[1]
-			subTpl, err := t.getTemplate(templatePath)
-			if err != nil {
-				errInReplace = err
-				return "[error]"
-			}
-			return subTpl
-		})
-		if errInReplace != nil {
-			return nil, errInReplace
+		var err error
+		node.Src, err = t.expand(node.Src, seen, 0)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -226,7 +363,7 @@ func (t *templater) assemble(name string) (*template.Template, error) {
 			thisTemplate = rootTemplate.New(node.Name)
 		}
 
-		thisTemplate.Funcs(t.GetFuncs())
+		thisTemplate.Funcs(t.GetFuncs()).Funcs(template.FuncMap{lineFunc: renderMark})
 
 		_, err := thisTemplate.Parse(node.Src)
 		if err != nil {
@@ -237,6 +374,36 @@ func (t *templater) assemble(name string) (*template.Template, error) {
 	return rootTemplate, nil
 }
 
+// expand replaces the include and import tags of a template with the
+// templates they name, themselves expanded. An import is of a template not
+// included or imported yet, so libraries may import what they need.
+func (t *templater) expand(src string, seen map[string]bool, depth int) (string, error) {
+	var errInReplace error
+	src = reIncludeTag.ReplaceAllStringFunc(src, func(raw string) string {
+		if errInReplace != nil {
+			return ""
+		}
+		parsed := reIncludeTag.FindStringSubmatch(raw)
+		kind, templatePath := parsed[1], parsed[2]
+		if kind == "import" && seen[templatePath] {
+			return ""
+		}
+		if depth >= maxIncludeDepth {
+			errInReplace = IncludeDepthError(templatePath)
+			return ""
+		}
+		seen[templatePath] = true
+		subTpl, err := t.getTemplate(templatePath)
+		if err != nil {
+			errInReplace = err
+			return "[error]"
+		}
+		subTpl, errInReplace = t.expand(mark(templatePath, subTpl), seen, depth+1)
+		return subTpl
+	})
+	return src, errInReplace
+}
+
 func (t *templater) getTemplate(name string) (string, error) {
 	for _, l := range t.GetLoaders() {
 		tmpl, err := l.Load(name)
@@ -273,7 +440,7 @@ func (t *templater) add(stack *[]*Node, name string) error {
 
 	node := &Node{
 		Name: name,
-		Src:  tplSrc,
+		Src:  mark(name, tplSrc),
 	}
 
 	*stack = append((*stack), node)
@@ -301,6 +468,9 @@ var defaultFuncs = map[string]interface{}{
 		}
 		return s
 	},
+	"lightsMax": func() int {
+		return graphics.LightsMax
+	},
 }
 
 func defaultFuncSet() *FuncSet {
@@ -328,7 +498,8 @@ func NewLoaderSet() *LoaderSet {
 func defaultLoaderSet() *LoaderSet {
 	ls := NewLoaderSet()
 	ls.AddLoaders(
-		FileLoader(),
+		scripted,
+		FileLoader(ShaderDir),
 		MapLoader(defaultTemplates),
 	)
 	return ls
@@ -373,23 +544,87 @@ func (b *BaseLoader) ValidExtension(ext string) bool {
 
 var PathError = xrror.Xrror("path: %s returned error").Out
 
+// changes counts edits to template files since they were loaded, programs
+// rebuilt by shaderers that have not seen them all.
+var changes int
+
+func changed(string) {
+	changes++
+}
+
+// ShaderDir is where the default file loader finds shader libraries and
+// templates overriding those built in.
+const ShaderDir = "shaders"
+
+// ShaderExtensions are those of template files.
+var ShaderExtensions = []string{".glsl", ".vert", ".frag", ".geom"}
+
+// fileLoader loads templates from files through vfs, by path, or by name in
+// one of its directories, with or without an extension.
 type fileLoader struct {
 	BaseLoader
+	Dirs    []string
+	watched map[string]bool
+}
+
+func FileLoader(dirs ...string) *fileLoader {
+	return &fileLoader{
+		BaseLoader{FileExtensions: ShaderExtensions},
+		dirs,
+		make(map[string]bool),
+	}
 }
 
-func FileLoader() *fileLoader {
-	return &fileLoader{}
+func (f *fileLoader) candidates(name string) []string {
+	var ret []string
+	if f.ValidExtension(filepath.Ext(name)) {
+		ret = append(ret, name)
+		for _, d := range f.Dirs {
+			ret = append(ret, path.Join(d, name))
+		}
+		return ret
+	}
+	for _, d := range f.Dirs {
+		for _, e := range f.FileExtensions {
+			ret = append(ret, path.Join(d, name+e))
+		}
+	}
+	return ret
 }
 
-func (f *fileLoader) Load(path string) (string, error) {
-	if f.ValidExtension(filepath.Ext(path)) {
-		if _, err := os.Stat(path); err == nil {
-			file, err := os.Open(path)
-			r, err := ioutil.ReadAll(file)
+func (f *fileLoader) Load(name string) (string, error) {
+	for _, p := range f.candidates(name) {
+		if vfs.Exists(p) {
+			r, err := vfs.ReadFile(p)
+			if err == nil && !f.watched[p] {
+				f.watched[p] = true
+				vfs.Watch(p, changed)
+			}
 			return string(r), err
 		}
 	}
-	return "", NoTemplateError(path)
+	return "", NoTemplateError(name)
+}
+
+// ListTemplates are the template files in the directories of the loader.
+func (f *fileLoader) ListTemplates() ([]string, error) {
+	names, err := vfs.CurrentFS.Names()
+	if err != nil {
+		return nil, err
+	}
+	var listing []string
+	for _, n := range names {
+		if !f.ValidExtension(path.Ext(n)) {
+			continue
+		}
+		for _, d := range f.Dirs {
+			if strings.HasPrefix(n, d+"/") {
+				listing = append(listing, n)
+				break
+			}
+		}
+	}
+	return listing, nil
 }
 
 type mapLoader struct {
@@ -0,0 +1,133 @@
+package shader
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Line is a line of a template.
+type Line struct {
+	Name string
+	Line int
+}
+
+func (l Line) String() string {
+	if l.Name == "" {
+		return "?"
+	}
+	return fmt.Sprintf("%s:%d", l.Name, l.Line)
+}
+
+// Source is GLSL rendered from templates, with the template line each of
+// its lines came from.
+type Source struct {
+	Code  string
+	Lines []Line
+}
+
+// Where is the template line a line of the source, from 1, came from.
+func (s *Source) Where(n int) (Line, bool) {
+	if n < 1 || n > len(s.Lines) || s.Lines[n-1].Name == "" {
+		return Line{}, false
+	}
+	return s.Lines[n-1], true
+}
+
+// reLogLine matches where a compiler log places a message, as drivers and
+// glslang write it: "0(12)", "0:12(5)" or "ERROR: 0:12:".
+var reLogLine = regexp.MustCompile(`(?m)^((?:ERROR|WARNING): )?\d+(?::(\d+)|\((\d+)\))`)
+
+// MapLog rewrites the source lines a compiler log refers to as the template
+// lines they came from.
+func (s *Source) MapLog(log string) string {
+	return reLogLine.ReplaceAllStringFunc(log, func(raw string) string {
+		m := reLogLine.FindStringSubmatch(raw)
+		n := m[2]
+		if n == "" {
+			n = m[3]
+		}
+		i, _ := strconv.Atoi(n)
+		if l, ok := s.Where(i); ok {
+			return m[1] + l.String()
+		}
+		return raw
+	})
+}
+
+// lineFunc is what marks are rendered with, named so templates are unlikely
+// to use it.
+const lineFunc = "__line"
+
+// mark prefixes each line of a template not within an action with an action
+// rendering a marker of the line, for unmark to map.
+func mark(name, src string) string {
+	b := new(strings.Builder)
+	depth := 0
+	for i, ln := range strings.SplitAfter(src, "\n") {
+		if depth == 0 && ln != "" {
+			fmt.Fprintf(b, "{{ %s %q %d }}", lineFunc, name, i+1)
+		}
+		// the body of a block starts its line too
+		if at := reDefineTag.FindStringIndex(ln); at != nil && depth == 0 {
+			b.WriteString(ln[:at[1]])
+			fmt.Fprintf(b, "{{ %s %q %d }}", lineFunc, name, i+1)
+			ln = ln[at[1]:]
+		}
+		b.WriteString(ln)
+		depth += strings.Count(ln, "{{") - strings.Count(ln, "}}")
+		if depth < 0 {
+			depth = 0
+		}
+	}
+	return b.String()
+}
+
+const marker = '\x00'
+
+func renderMark(name string, line int) string {
+	return fmt.Sprintf("%c%s:%d%c", marker, name, line, marker)
+}
+
+// unmark takes the markers out of rendered templates, each line of what is
+// left from the line of the first marker before anything else on it, or the
+// last before it.
+func unmark(out string) *Source {
+	code := new(strings.Builder)
+	lines := make([]Line, 0)
+	var cur, at Line
+	blank := true
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch {
+		case c == marker:
+			j := strings.IndexByte(out[i+1:], marker)
+			if j < 0 {
+				code.WriteString(out[i:])
+				i = len(out)
+				continue
+			}
+			m := out[i+1 : i+1+j]
+			if k := strings.LastIndexByte(m, ':'); k >= 0 {
+				n, _ := strconv.Atoi(m[k+1:])
+				cur = Line{m[:k], n}
+				if blank {
+					at = cur
+				}
+			}
+			i += j + 1
+		case c == '\n':
+			code.WriteByte(c)
+			lines = append(lines, at)
+			at, blank = cur, true
+		default:
+			if c != ' ' && c != '\t' && c != '\r' {
+				blank = false
+			}
+			code.WriteByte(c)
+		}
+	}
+	lines = append(lines, at)
+	return &Source{code.String(), lines}
+}
@@ -0,0 +1,145 @@
+package graphics
+
+import (
+	"encoding/binary"
+	glm "math"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+var (
+	UnknownMemberError   = xrror.Xrror("no member %s in layout").Out
+	UnsupportedTypeError = xrror.Xrror("unsupported std140 type 0x%X").Out
+	MemberIndexError     = xrror.Xrror("index %d out of range of member %s[%d]").Out
+)
+
+// Member is a member of a std140 layout: a type, FLOAT, INT, FLOAT_VEC2..4,
+// FLOAT_MAT3 or FLOAT_MAT4, an array of Count of it, its Offset and the
+// Stride between elements, in bytes.
+type Member struct {
+	Name   string
+	Type   Enum
+	Count  int
+	Offset int
+	Stride int
+}
+
+// Std140 lays out the members of a uniform block as GLSL std140 does, each
+// member where a shader declaring them in the same order reads them.
+type Std140 struct {
+	members []*Member
+	named   map[string]*Member
+	size    int
+}
+
+func NewStd140() *Std140 {
+	return &Std140{named: make(map[string]*Member)}
+}
+
+// std140 base alignment and size of a type, in bytes, and the number of
+// floats of it.
+func std140(t Enum) (align, size, floats int, err error) {
+	switch t {
+	case FLOAT, INT, BOOL:
+		return 4, 4, 1, nil
+	case FLOAT_VEC2:
+		return 8, 8, 2, nil
+	case FLOAT_VEC3:
+		return 16, 12, 3, nil
+	case FLOAT_VEC4:
+		return 16, 16, 4, nil
+	case FLOAT_MAT3:
+		// three columns, each a vec4
+		return 16, 48, 9, nil
+	case FLOAT_MAT4:
+		return 16, 64, 16, nil
+	}
+	return 0, 0, 0, UnsupportedTypeError(uint32(t))
+}
+
+func roundUp(n, to int) int {
+	return (n + to - 1) / to * to
+}
+
+// Add appends a member, an array of count when count is more than one.
+func (s *Std140) Add(name string, t Enum, count int) error {
+	align, size, _, err := std140(t)
+	if err != nil {
+		return err
+	}
+	if count < 1 {
+		count = 1
+	}
+	stride, end := size, size
+	if count > 1 || t == FLOAT_MAT3 || t == FLOAT_MAT4 {
+		// array elements, and matrix columns, align as vec4, padded out to
+		// it to the end
+		align = 16
+		stride = roundUp(size, 16)
+		end = stride * count
+	}
+	m := &Member{name, t, count, roundUp(s.size, align), stride}
+	s.members = append(s.members, m)
+	s.named[name] = m
+	s.size = m.Offset + end
+	return nil
+}
+
+// Must is Add panicking on an error, for layouts declared with the program.
+func (s *Std140) Must(name string, t Enum, count int) *Std140 {
+	if err := s.Add(name, t, count); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Std140) Member(name string) (*Member, bool) {
+	m, ok := s.named[name]
+	return m, ok
+}
+
+func (s *Std140) Members() []*Member {
+	return s.members
+}
+
+// Size is the size of the block, rounded up to a vec4 as std140 has it.
+func (s *Std140) Size() int {
+	return roundUp(s.size, 16)
+}
+
+// Put writes floats as element idx of a member of a layout, and those
+// after it when there are more, into a buffer of its size, leaving padding
+// as it is, returning whether that changed the buffer. Matrices are column
+// major, a mat3 of nine.
+func (s *Std140) Put(b []byte, name string, idx int, v ...float32) (bool, error) {
+	m, ok := s.named[name]
+	if !ok {
+		return false, UnknownMemberError(name)
+	}
+	_, _, floats, _ := std140(m.Type)
+	n := (len(v) + floats - 1) / floats
+	if idx < 0 || idx+n > m.Count {
+		return false, MemberIndexError(idx, name, m.Count)
+	}
+	changed := false
+	for i, f := range v {
+		off := m.Offset + (idx+i/floats)*m.Stride
+		j := i % floats
+		if m.Type == FLOAT_MAT3 {
+			off += 16*(j/3) + 4*(j%3)
+		} else {
+			off += 4 * j
+		}
+		var bits uint32
+		if m.Type == INT || m.Type == BOOL {
+			bits = uint32(int32(f))
+		} else {
+			bits = glm.Float32bits(f)
+		}
+		if binary.LittleEndian.Uint32(b[off:]) != bits {
+			binary.LittleEndian.PutUint32(b[off:], bits)
+			changed = true
+		}
+	}
+	return changed, nil
+}
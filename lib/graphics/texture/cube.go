@@ -0,0 +1,101 @@
+package texture
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// The faces of a cube map, in the order of their targets.
+const (
+	POSITIVE_X = iota
+	NEGATIVE_X
+	POSITIVE_Y
+	NEGATIVE_Y
+	POSITIVE_Z
+	NEGATIVE_Z
+	CUBE_FACES
+)
+
+var CubeFaceError = xrror.Xrror("%d is not a cube map face").Out
+
+type face struct {
+	width, height int32
+	pix           []byte
+}
+
+// Cube is a cube map of six RGBA faces, top row first each, as a skybox
+// samples by direction. Faces are sent again whenever set.
+type Cube struct {
+	p      graphics.Provider
+	handle graphics.Texture
+	faces  [CUBE_FACES]face
+	update bool
+}
+
+func NewCube() *Cube {
+	c := &Cube{}
+	c.Initialize()
+	return c
+}
+
+// SetFace replaces the texels of a face, uploaded on the next render.
+func (c *Cube) SetFace(f, w, h int, pix []byte) error {
+	if f < 0 || f >= CUBE_FACES {
+		return CubeFaceError(f)
+	}
+	c.faces[f] = face{int32(w), int32(h), pix}
+	c.update = true
+	return nil
+}
+
+// Size is the size of the first face.
+func (c *Cube) Size() (int, int) {
+	return int(c.faces[0].width), int(c.faces[0].height)
+}
+
+func (c *Cube) Initialize() {
+	c.update = true
+}
+
+func (c *Cube) Close() {
+	if c.p != nil {
+		c.p.DeleteTexture(c.handle)
+	}
+	c.p, c.handle = nil, 0
+	c.update = true
+}
+
+func (c *Cube) Render(p graphics.Provider, idx int) {
+	p.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + idx))
+	if c.p == nil {
+		c.handle = p.GenTexture()
+		c.p = p
+		p.BindTexture(graphics.TEXTURE_CUBE_MAP, c.handle)
+		p.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+		p.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR)
+		p.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_WRAP_S, graphics.CLAMP_TO_EDGE)
+		p.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+		p.TexParameteri(graphics.TEXTURE_CUBE_MAP, graphics.TEXTURE_WRAP_R, graphics.CLAMP_TO_EDGE)
+	}
+	p.BindTexture(graphics.TEXTURE_CUBE_MAP, c.handle)
+	if c.update {
+		for i, f := range c.faces {
+			if len(f.pix) == 0 {
+				continue
+			}
+			p.TexImage2D(
+				graphics.Enum(graphics.TEXTURE_CUBE_MAP_POSITIVE_X+i),
+				0,
+				graphics.RGBA8,
+				f.width,
+				f.height,
+				0,
+				graphics.RGBA,
+				graphics.UNSIGNED_BYTE,
+				p.Ptr(f.pix),
+				len(f.pix),
+			)
+		}
+		c.update = false
+	}
+}
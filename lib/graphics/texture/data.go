@@ -0,0 +1,93 @@
+package texture
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+)
+
+// Data is a 2D texture uploaded from bytes in memory, a byte a texel for
+// RED or four for RGBA, sent again whenever it is set. Widths are kept to a
+// multiple of 4 for the default unpack alignment.
+type Data struct {
+	p       graphics.Provider
+	handle  graphics.Texture
+	format  graphics.Enum
+	iformat int32
+	filter  int32
+	wrap    int32
+	width   int32
+	height  int32
+	pix     []byte
+	update  bool
+}
+
+// NewData returns a data texture of format graphics.RED or graphics.RGBA.
+func NewData(format graphics.Enum) *Data {
+	d := &Data{format: format, iformat: graphics.RGBA8, filter: graphics.LINEAR, wrap: graphics.CLAMP_TO_EDGE}
+	if format == graphics.RED {
+		d.iformat = graphics.R8
+	}
+	d.Initialize()
+	return d
+}
+
+// Set replaces the texels, uploaded on the next render.
+func (d *Data) Set(w, h int, pix []byte) {
+	d.width, d.height, d.pix = int32(w), int32(h), pix
+	d.update = true
+}
+
+// SetFilter sets the filter sampling between texels, graphics.LINEAR or
+// graphics.NEAREST, before the texture is first rendered.
+func (d *Data) SetFilter(f graphics.Enum) {
+	d.filter = int32(f)
+}
+
+// SetWrap sets how the texture is sampled beyond its edges,
+// graphics.CLAMP_TO_EDGE or graphics.REPEAT, before it is first rendered.
+func (d *Data) SetWrap(w graphics.Enum) {
+	d.wrap = int32(w)
+}
+
+func (d *Data) Size() (int, int) {
+	return int(d.width), int(d.height)
+}
+
+func (d *Data) Initialize() {
+	d.update = len(d.pix) > 0
+}
+
+func (d *Data) Close() {
+	if d.p != nil {
+		d.p.DeleteTexture(d.handle)
+	}
+	d.p, d.handle = nil, 0
+}
+
+func (d *Data) Render(p graphics.Provider, idx int) {
+	p.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + idx))
+	if d.p == nil {
+		d.handle = p.GenTexture()
+		d.p = p
+		p.BindTexture(graphics.TEXTURE_2D, d.handle)
+		p.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, d.filter)
+		p.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, d.filter)
+		p.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, d.wrap)
+		p.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, d.wrap)
+	}
+	p.BindTexture(graphics.TEXTURE_2D, d.handle)
+	if d.update && len(d.pix) > 0 {
+		p.TexImage2D(
+			graphics.TEXTURE_2D,
+			0,
+			d.iformat,
+			d.width,
+			d.height,
+			0,
+			d.format,
+			graphics.UNSIGNED_BYTE,
+			p.Ptr(d.pix),
+			len(d.pix),
+		)
+		d.update = false
+	}
+}
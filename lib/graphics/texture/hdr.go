@@ -0,0 +1,210 @@
+package texture
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	glm "math"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+var (
+	HDRHeaderError = xrror.Xrror("not a radiance hdr image: %s").Out
+	HDRFormatError = xrror.Xrror("unsupported radiance hdr format %s").Out
+	HDRSizeError   = xrror.Xrror("unsupported radiance hdr resolution %q").Out
+	HDRScanError   = xrror.Xrror("bad radiance hdr scanline %d").Out
+)
+
+// DecodeHDR decodes a radiance rgbe image into RGB float texels, top row
+// first, with its size.
+func DecodeHDR(r io.Reader) ([]float32, int, int, error) {
+	br := bufio.NewReader(r)
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !strings.HasPrefix(line, "#?") {
+		return nil, 0, 0, HDRHeaderError(strings.TrimSpace(line))
+	}
+	for {
+		line, err = br.ReadString('\n')
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if f := strings.TrimPrefix(line, "FORMAT="); f != line && f != "32-bit_rle_rgbe" {
+			return nil, 0, 0, HDRFormatError(f)
+		}
+	}
+	line, err = br.ReadString('\n')
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	var w, h int
+	var flip bool
+	switch {
+	case scanSize(line, "-Y %d +X %d", &h, &w):
+	case scanSize(line, "+Y %d +X %d", &h, &w):
+		flip = true
+	default:
+		return nil, 0, 0, HDRSizeError(strings.TrimSpace(line))
+	}
+
+	ret := make([]float32, w*h*3)
+	scan := make([]byte, w*4)
+	for y := 0; y < h; y++ {
+		if err := readScanline(br, scan, w); err != nil {
+			if err == io.ErrUnexpectedEOF || err == io.EOF {
+				return nil, 0, 0, HDRScanError(y)
+			}
+			return nil, 0, 0, err
+		}
+		row := y
+		if flip {
+			row = h - 1 - y
+		}
+		out := ret[row*w*3 : (row+1)*w*3]
+		for x := 0; x < w; x++ {
+			rgbe := scan[x*4 : x*4+4]
+			if rgbe[3] == 0 {
+				continue
+			}
+			f := float32(glm.Ldexp(1, int(rgbe[3])-(128+8)))
+			out[x*3] = float32(rgbe[0]) * f
+			out[x*3+1] = float32(rgbe[1]) * f
+			out[x*3+2] = float32(rgbe[2]) * f
+		}
+	}
+	return ret, w, h, nil
+}
+
+func scanSize(line, format string, a, b *int) bool {
+	n, err := fmt.Sscanf(line, format, a, b)
+	return err == nil && n == 2 && *a > 0 && *b > 0
+}
+
+// readScanline reads a scanline of rgbe texels into scan, run length
+// encoded a channel at a time or flat, as older files are.
+func readScanline(br *bufio.Reader, scan []byte, w int) error {
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(br, head); err != nil {
+		return err
+	}
+	if w < 8 || w > 0x7fff || head[0] != 2 || head[1] != 2 || head[2]&0x80 != 0 {
+		copy(scan, head)
+		_, err := io.ReadFull(br, scan[4:])
+		return err
+	}
+	if int(head[2])<<8|int(head[3]) != w {
+		return io.ErrUnexpectedEOF
+	}
+	for c := 0; c < 4; c++ {
+		for x := 0; x < w; {
+			n, err := br.ReadByte()
+			if err != nil {
+				return err
+			}
+			if n > 128 {
+				run := int(n) - 128
+				v, err := br.ReadByte()
+				if err != nil {
+					return err
+				}
+				if x+run > w {
+					return io.ErrUnexpectedEOF
+				}
+				for ; run > 0; run-- {
+					scan[x*4+c] = v
+					x++
+				}
+				continue
+			}
+			if n == 0 || x+int(n) > w {
+				return io.ErrUnexpectedEOF
+			}
+			for i := 0; i < int(n); i++ {
+				v, err := br.ReadByte()
+				if err != nil {
+					return err
+				}
+				scan[x*4+c] = v
+				x++
+			}
+		}
+	}
+	return nil
+}
+
+// Float is a 2D texture of RGB float texels, as high dynamic range images
+// decode to, sent again whenever it is set. It repeats across its width, as
+// equirectangular images wrap around.
+type Float struct {
+	p      graphics.Provider
+	handle graphics.Texture
+	width  int32
+	height int32
+	pix    []float32
+	update bool
+}
+
+func NewFloat() *Float {
+	f := &Float{}
+	f.Initialize()
+	return f
+}
+
+// Set replaces the texels, three floats each, uploaded on the next render.
+func (f *Float) Set(w, h int, pix []float32) {
+	f.width, f.height, f.pix = int32(w), int32(h), pix
+	f.update = true
+}
+
+func (f *Float) Size() (int, int) {
+	return int(f.width), int(f.height)
+}
+
+func (f *Float) Initialize() {
+	f.update = len(f.pix) > 0
+}
+
+func (f *Float) Close() {
+	if f.p != nil {
+		f.p.DeleteTexture(f.handle)
+	}
+	f.p, f.handle = nil, 0
+}
+
+func (f *Float) Render(p graphics.Provider, idx int) {
+	p.ActiveTexture(graphics.Texture(graphics.TEXTURE0 + idx))
+	if f.p == nil {
+		f.handle = p.GenTexture()
+		f.p = p
+		p.BindTexture(graphics.TEXTURE_2D, f.handle)
+		p.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+		p.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR)
+		p.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_S, graphics.REPEAT)
+		p.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_WRAP_T, graphics.CLAMP_TO_EDGE)
+	}
+	p.BindTexture(graphics.TEXTURE_2D, f.handle)
+	if f.update && len(f.pix) > 0 {
+		p.TexImage2D(
+			graphics.TEXTURE_2D,
+			0,
+			graphics.RGB16F,
+			f.width,
+			f.height,
+			0,
+			graphics.RGB,
+			graphics.FLOAT,
+			p.Ptr(f.pix),
+			len(f.pix)*4,
+		)
+		f.update = false
+	}
+}
@@ -0,0 +1,22 @@
+package texture
+
+import (
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+// DecodeRGBA decodes a png or jpeg into RGBA texels, top row first, with
+// its size.
+func DecodeRGBA(r io.Reader) ([]byte, int, int, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba.Pix, b.Dx(), b.Dy(), nil
+}
@@ -10,7 +10,6 @@ type Texturer interface {
 	graphics.Initializer
 	graphics.Closer
 	///graphics.Visiblizer
-	graphics.RefCounter
 }
 
 type Texture interface {
@@ -20,7 +19,6 @@ type Texture interface {
 
 type texture2D struct {
 	p            graphics.Provider // Pointer to OpenGL state
-	refCount     int               // Current number of references
 	handle       graphics.Texture  // Texture handle
 	magFilter    uint32            // magnification filter
 	minFilter    uint32            // minification filter
@@ -72,7 +70,6 @@ func (t *texture2D) SetData() error {
 
 func (t *texture2D) Initialize() {
 	t.p = nil
-	t.refCount = 1
 	t.handle = 0
 	t.magFilter = graphics.LINEAR
 	t.minFilter = graphics.LINEAR
@@ -96,14 +93,6 @@ func (t *texture2D) Close() {
 	//
 }
 
-func (t *texture2D) Increment() {
-	t.refCount++
-}
-
-func (t *texture2D) Decrement() {
-	t.refCount--
-}
-
 type Renderer interface {
 	Render(graphics.Provider, int)
 }
@@ -1,6 +1,9 @@
 package graphics
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type Uniform interface {
 	Update(...float32)
@@ -66,25 +69,25 @@ func Uniform1f(key string) Uniform {
 
 func Uniform2f(key string) Uniform {
 	return newUniform(key, 2, func(p Provider, loc int32, v []float32) {
-		//p.Uniform2f(u.Location(p), uni.v0)
+		p.Uniform2f(loc, v[0], v[1])
 	})
 }
 
 func Uniform3f(key string) Uniform {
 	return newUniform(key, 3, func(p Provider, loc int32, v []float32) {
-		//p.Uniform3f(u.Location(p), uni.v0)
+		p.Uniform3f(loc, v[0], v[1], v[2])
 	})
 }
 
 func Uniform4f(key string) Uniform {
 	return newUniform(key, 4, func(p Provider, loc int32, v []float32) {
-		//p.Uniform4f(u.Location(p), uni.v0)
+		p.Uniform4f(loc, v[0], v[1], v[2], v[3])
 	})
 }
 
 func UniformMatrix3fv(key string) Uniform {
 	return newUniform(key, 9, func(p Provider, loc int32, v []float32) {
-		//p.UniformMatrix3fv(u.Location(p), uni.v0)
+		p.UniformMatrix3fv(loc, 1, false, v)
 	})
 }
 
@@ -94,8 +97,88 @@ func UniformMatrix4fv(key string) Uniform {
 	})
 }
 
+// UniformMatrix4fvArray transfers count consecutive 4x4 matrices to a mat4
+// array uniform.
+func UniformMatrix4fvArray(key string, count int) Uniform {
+	return newUniform(key, 16*count, func(p Provider, loc int32, v []float32) {
+		p.UniformMatrix4fv(loc, int32(len(v)/16), false, v)
+	})
+}
+
+// Uniform3fvArray transfers count consecutive vec3 to a vec3 array uniform.
+func Uniform3fvArray(key string, count int) Uniform {
+	return newUniform(key, 3*count, func(p Provider, loc int32, v []float32) {
+		p.Uniform3fv(loc, v)
+	})
+}
+
 func Uniform4fv(key string) Uniform {
 	return newUniform(key, 16, func(p Provider, loc int32, v []float32) {
 		//p.Uniform4f(u.Location(p), uni.v0)
 	})
 }
+
+var uniformTypes = map[string]Enum{
+	"float": FLOAT,
+	"int":   INT,
+	"bool":  BOOL,
+	"vec2":  FLOAT_VEC2,
+	"vec3":  FLOAT_VEC3,
+	"vec4":  FLOAT_VEC4,
+	"mat4":  FLOAT_MAT4,
+}
+
+// StringToUniformType is the type of a GLSL type name typed uniforms may
+// be of, false for any other.
+func StringToUniformType(s string) (Enum, bool) {
+	t, ok := uniformTypes[strings.ToLower(s)]
+	return t, ok
+}
+
+// UniformTypeString is the GLSL name of a type typed uniforms may be of.
+func UniformTypeString(t Enum) string {
+	for k, v := range uniformTypes {
+		if v == t {
+			return k
+		}
+	}
+	return ""
+}
+
+// UniformTypeSize is how many floats a value of a type typed uniforms may
+// be of takes, 0 for any other.
+func UniformTypeSize(t Enum) int {
+	switch t {
+	case FLOAT, INT, BOOL:
+		return 1
+	case FLOAT_VEC2:
+		return 2
+	case FLOAT_VEC3:
+		return 3
+	case FLOAT_VEC4:
+		return 4
+	case FLOAT_MAT4:
+		return 16
+	}
+	return 0
+}
+
+// TypedUniform is a uniform of a type, nil for types typed uniforms may not
+// be of.
+func TypedUniform(key string, t Enum) Uniform {
+	switch t {
+	case FLOAT:
+		return Uniform1f(key)
+	case INT, BOOL:
+		return Uniform1i(key)
+	case FLOAT_VEC2:
+		return Uniform2f(key)
+	case FLOAT_VEC3:
+		return Uniform3f(key)
+	case FLOAT_VEC4:
+		return Uniform4f(key)
+	case FLOAT_MAT4:
+		return UniformMatrix4fv(key)
+	}
+	return nil
+}
@@ -8,11 +8,6 @@ type Closer interface {
 	Close()
 }
 
-type RefCounter interface {
-	Increment()
-	Decrement()
-}
-
 type Moder interface {
 	Mode() Enum
 	SetMode(Enum)
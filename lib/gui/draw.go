@@ -0,0 +1,117 @@
+package gui
+
+// Color is an rgba color, 0 to 1.
+type Color [4]float32
+
+// Rect is a rectangle in window coordinates, y down.
+type Rect struct {
+	X, Y, W, H float32
+}
+
+func (r Rect) Contains(x, y float32) bool {
+	return x >= r.X && y >= r.Y && x < r.X+r.W && y < r.Y+r.H
+}
+
+// Intersect is the part of r inside o, empty if none.
+func (r Rect) Intersect(o Rect) Rect {
+	x0, y0 := max32(r.X, o.X), max32(r.Y, o.Y)
+	x1, y1 := min32(r.X+r.W, o.X+o.W), min32(r.Y+r.H, o.Y+o.H)
+	if x1 <= x0 || y1 <= y0 {
+		return Rect{x0, y0, 0, 0}
+	}
+	return Rect{x0, y0, x1 - x0, y1 - y0}
+}
+
+func (r Rect) Empty() bool {
+	return r.W <= 0 || r.H <= 0
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func clamp32(v, lo, hi float32) float32 {
+	return max32(lo, min32(hi, v))
+}
+
+// vertexSize is position, texcoord and color.
+const vertexSize = 3 + 2 + 4
+
+// drawList is the triangles of a window, every shape an axis aligned quad
+// clipped on the CPU, so the whole GUI draws at once.
+type drawList struct {
+	verts []float32
+	clip  Rect
+	scale float32
+}
+
+func (d *drawList) reset(clip Rect, scale float32) {
+	d.verts = d.verts[:0]
+	d.clip, d.scale = clip, scale
+}
+
+// quad adds a textured rectangle, clipped with its texture coordinates.
+func (d *drawList) quad(r Rect, u0, v0, u1, v1 float32, c Color) {
+	cr := r.Intersect(d.clip)
+	if cr.Empty() {
+		return
+	}
+	if cr != r {
+		du, dv := (u1-u0)/r.W, (v1-v0)/r.H
+		u0, u1 = u0+(cr.X-r.X)*du, u0+(cr.X+cr.W-r.X)*du
+		v0, v1 = v0+(cr.Y-r.Y)*dv, v0+(cr.Y+cr.H-r.Y)*dv
+	}
+	x0, y0, x1, y1 := cr.X, cr.Y, cr.X+cr.W, cr.Y+cr.H
+	d.verts = append(d.verts,
+		x0, y0, 0, u0, v0, c[0], c[1], c[2], c[3],
+		x0, y1, 0, u0, v1, c[0], c[1], c[2], c[3],
+		x1, y1, 0, u1, v1, c[0], c[1], c[2], c[3],
+		x0, y0, 0, u0, v0, c[0], c[1], c[2], c[3],
+		x1, y1, 0, u1, v1, c[0], c[1], c[2], c[3],
+		x1, y0, 0, u1, v0, c[0], c[1], c[2], c[3],
+	)
+}
+
+func (d *drawList) rect(r Rect, c Color) {
+	u, v := WhiteUV()
+	d.quad(r, u, v, u, v, c)
+}
+
+// border draws the outline of a rectangle, a pixel wide.
+func (d *drawList) border(r Rect, c Color) {
+	d.rect(Rect{r.X, r.Y, r.W, 1}, c)
+	d.rect(Rect{r.X, r.Y + r.H - 1, r.W, 1}, c)
+	d.rect(Rect{r.X, r.Y + 1, 1, r.H - 2}, c)
+	d.rect(Rect{r.X + r.W - 1, r.Y + 1, 1, r.H - 2}, c)
+}
+
+// text draws a string from the top left of its first glyph.
+func (d *drawList) text(x, y float32, s string, c Color) {
+	g := GlyphSize * d.scale
+	for _, r := range s {
+		if r != ' ' {
+			u0, v0, u1, v1 := GlyphUV(r)
+			d.quad(Rect{x, y, g, g}, u0, v0, u1, v1, c)
+		}
+		x += g
+	}
+}
+
+// textWidth is the width a string draws at.
+func textWidth(s string, scale float32) float32 {
+	n := 0
+	for range s {
+		n++
+	}
+	return float32(n) * GlyphSize * scale
+}
@@ -0,0 +1,245 @@
+package gui
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/geometry"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
+	"github.com/Laughs-In-Flowers/shiva/lib/input"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/render"
+	"github.com/Laughs-In-Flowers/shiva/lib/scene"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+var CurrentGUI *Context
+
+// FrameFunc builds the widgets of a frame.
+type FrameFunc func(*Context) error
+
+// Context is an immediate mode GUI: windows and widgets are declared again
+// every frame, only positions, sizes and what is open kept between frames.
+// It is a system updating after input, claiming the mouse and keyboard from
+// actions while over a window or editing, and draws over the scene.
+type Context struct {
+	io      *io
+	scale   float32
+	windows map[string]*window
+	order   []*window
+	cur     *window
+	hovered *window
+	active  string
+	focus   string
+	focused bool
+	drag    [2]float32
+	edit    []rune
+	caret   int
+	frames  []FrameFunc
+	panel   bool
+	stats   func() []Stat
+	verts   math.AF32
+	mesh    render.Mesh
+	vbo     *graphics.Buff
+	mv      graphics.Uniform
+	proj    graphics.Uniform
+}
+
+// DefaultScale is the size of a font pixel in window coordinates.
+const DefaultScale = 2
+
+// New returns a GUI reading input from the input subscriptions.
+func New() *Context {
+	c := &Context{
+		io:      newIO(),
+		scale:   DefaultScale,
+		windows: make(map[string]*window),
+		order:   make([]*window, 0),
+		frames:  make([]FrameFunc, 0),
+		verts:   make(math.AF32, 0),
+		mv:      graphics.UniformMatrix4fv("ModelViewMatrix"),
+		proj:    graphics.UniformMatrix4fv("ProjectionMatrix"),
+	}
+	input.KeyInput.Subscribe(c.io)
+	input.CharInput.Subscribe(c.io)
+	input.MouseButtonInput.Subscribe(c.io)
+	input.CursorPositionInput.Subscribe(c.io)
+	input.ScrollInput.Subscribe(c.io)
+	return c
+}
+
+// Attach claims input for the GUI and draws it over the current scene.
+func (c *Context) Attach() {
+	input.CurrentInputSystem.Claim(c.Claim)
+	if s := scene.Current(); s != nil {
+		s.AddOverlay(c)
+	}
+}
+
+func (c *Context) Priority() int {
+	return 8
+}
+
+// Update builds a frame: the built in panel when shown, then every frame
+// function, the first error returned after all have run.
+func (c *Context) Update(d int64) error {
+	c.newFrame()
+	if c.panel {
+		c.statsPanel()
+	}
+	var err error
+	for _, fn := range append([]FrameFunc(nil), c.frames...) {
+		if ferr := fn(c); ferr != nil && err == nil {
+			err = ferr
+		}
+		// a frame function failing between Begin and End leaves it open
+		if c.cur != nil {
+			c.End()
+		}
+	}
+	c.endFrame()
+	return err
+}
+
+func (c *Context) Remove(uint64) {}
+
+// OnFrame adds a function building widgets each frame.
+func (c *Context) OnFrame(fn FrameFunc) {
+	c.frames = append(c.frames, fn)
+}
+
+func (c *Context) Scale() float32 {
+	return c.scale
+}
+
+func (c *Context) SetScale(s float32) {
+	if s > 0 {
+		c.scale = s
+	}
+}
+
+// ShowPanel shows or hides the built in stats panel, also toggled with F3.
+func (c *Context) ShowPanel(show bool) {
+	c.panel = show
+}
+
+func (c *Context) PanelShown() bool {
+	return c.panel
+}
+
+// Claim reports whether the mouse is over a window or held by a widget, and
+// whether a widget is being typed into, from the windows of the last frame.
+func (c *Context) Claim() (bool, bool) {
+	return c.over() != nil || c.active != "", c.focus != ""
+}
+
+// WantsMouse is whether actions are kept from the mouse this frame.
+func (c *Context) WantsMouse() bool {
+	m, _ := c.Claim()
+	return m
+}
+
+// WantsKeyboard is whether actions are kept from the keyboard this frame.
+func (c *Context) WantsKeyboard() bool {
+	_, k := c.Claim()
+	return k
+}
+
+// over is the front window under the mouse.
+func (c *Context) over() *window {
+	x, y := c.io.mouse[0], c.io.mouse[1]
+	for i := len(c.order) - 1; i >= 0; i-- {
+		w := c.order[i]
+		if w.shown && w.frame().Contains(x, y) {
+			return w
+		}
+	}
+	return nil
+}
+
+func (c *Context) newFrame() {
+	c.io.frame()
+	if c.io.key(glfw.KeyF3) {
+		c.panel = !c.panel
+	}
+	c.hovered = c.over()
+	if c.io.pressed[0] && c.hovered != nil {
+		c.raise(c.hovered)
+	}
+	for _, w := range c.order {
+		w.shown = false
+	}
+	c.focused = false
+}
+
+func (c *Context) endFrame() {
+	if !c.io.down[0] {
+		c.active = ""
+	}
+	if !c.focused {
+		c.focus = ""
+	}
+	c.verts = c.verts[:0]
+	for _, w := range c.order {
+		if w.shown {
+			c.verts = append(c.verts, w.d.verts...)
+		}
+	}
+}
+
+// raise brings a window to the front.
+func (c *Context) raise(w *window) {
+	for i, o := range c.order {
+		if o == w {
+			c.order = append(append(c.order[:i], c.order[i+1:]...), w)
+			return
+		}
+	}
+}
+
+// Overlay draws the windows of the last frame, front to back in order, over
+// a window sized width by height in screen coordinates.
+func (c *Context) Overlay(r render.Renderer, width, height int) {
+	if len(c.verts) == 0 {
+		return
+	}
+	if c.mesh == nil {
+		c.mesh = c.newMesh()
+	}
+	c.proj.Update(math.Mat4().Orthographic(0, float32(width), 0, float32(height), -1, 1).Raw()...)
+	c.vbo.SetBuffer(c.verts)
+	for _, m := range c.mesh.Materials() {
+		m.Render(r)
+	}
+}
+
+func (c *Context) newMesh() render.Mesh {
+	c.vbo = graphics.NewBuff().
+		AddAttrib("VertexPosition", 3).
+		AddAttrib("VertexTexcoord", 2).
+		AddAttrib("GlyphColor", 4)
+	c.vbo.SetUsage(graphics.DYNAMIC_DRAW)
+	g := geometry.New()
+	g.AddVBO(c.vbo)
+	c.mv.Update(math.IdentityMatrix(math.MAT4).Raw()...)
+	m := render.NewMesh("gui", g, c.provide, graphics.TRIANGLES)
+	mat := material.New()
+	mat.SetShader("text")
+	mat.SetIndependent(true)
+	mat.SetUseLights(material.ULNone)
+	mat.SetSide(material.SIDouble)
+	mat.SetDepthTest(false)
+	mat.SetDepthMask(false)
+	mat.SetBlending(material.BLNormal)
+	mat.AddTexture(Atlas())
+	m.AddMaterial(mat, 0, 0)
+	return m
+}
+
+func (c *Context) provide(r render.Renderer) {
+	c.mv.Transfer(r)
+	c.proj.Transfer(r)
+}
+
+func init() {
+	CurrentGUI = New()
+}
@@ -0,0 +1,86 @@
+package gui
+
+import (
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// io collects the input events arriving between frames, read by the GUI
+// whatever it then lets actions see.
+type io struct {
+	mouse            [2]float32
+	down             [3]bool
+	taps, lifts      [3]bool
+	pressed          [3]bool
+	released         [3]bool
+	scroll, wheel    float32
+	edits, typed     []edit
+	keys, keyPresses []glfw.Key
+}
+
+// edit is a character typed or a key pressed, kept in the order they came
+// for text input.
+type edit struct {
+	r rune
+	k glfw.Key
+}
+
+func newIO() *io {
+	return &io{
+		edits: make([]edit, 0),
+		keys:  make([]glfw.Key, 0),
+	}
+}
+
+func (o *io) CPEvent(x, y float64) {
+	o.mouse = [2]float32{float32(x), float32(y)}
+}
+
+func (o *io) MBEvent(b glfw.MouseButton, a glfw.Action, m glfw.ModifierKey) {
+	if b < 0 || int(b) >= len(o.down) {
+		return
+	}
+	switch a {
+	case glfw.Press:
+		o.down[b], o.taps[b] = true, true
+	case glfw.Release:
+		o.down[b], o.lifts[b] = false, true
+	}
+}
+
+func (o *io) SEvent(x, y float64) {
+	o.scroll += float32(y)
+}
+
+func (o *io) CIEvent(r rune) {
+	o.edits = append(o.edits, edit{r: r})
+}
+
+func (o *io) KEvent(k glfw.Key, s int, a glfw.Action, m glfw.ModifierKey) {
+	if a == glfw.Press || a == glfw.Repeat {
+		o.keys = append(o.keys, k)
+		o.edits = append(o.edits, edit{k: k})
+	}
+}
+
+// frame moves what arrived since the last frame into this one.
+func (o *io) frame() {
+	for b := range o.down {
+		o.pressed[b] = o.taps[b]
+		o.released[b] = o.lifts[b]
+		o.taps[b], o.lifts[b] = false, false
+	}
+	o.wheel, o.scroll = o.scroll, 0
+	o.typed = append(o.typed[:0], o.edits...)
+	o.edits = o.edits[:0]
+	o.keyPresses = append(o.keyPresses[:0], o.keys...)
+	o.keys = o.keys[:0]
+}
+
+func (o *io) key(k glfw.Key) bool {
+	for _, p := range o.keyPresses {
+		if p == k {
+			return true
+		}
+	}
+	return false
+}
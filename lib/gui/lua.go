@@ -0,0 +1,222 @@
+package gui
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+// shv.gui.on_frame(function() ... end) builds widgets every frame.
+func lOnFrame(L *l.LState) int {
+	fn := L.CheckFunction(1)
+	CurrentGUI.OnFrame(func(*Context) error {
+		return L.CallByParam(l.P{
+			Fn:      fn,
+			NRet:    0,
+			Protect: true,
+		})
+	})
+	return 0
+}
+
+// optRect is the x, y, w and h of an options table, in window coordinates,
+// any not given cascading from the windows already open.
+func optRect(L *l.LState, pos int) Rect {
+	g := CurrentGUI.glyph()
+	n := float32(len(CurrentGUI.order) + 1)
+	r := Rect{n * 2 * g, n * 2 * g, 26 * g, 16 * g}
+	if t, ok := L.Get(pos).(*l.LTable); ok {
+		field := func(k string, v *float32) {
+			if n, ok := t.RawGetString(k).(l.LNumber); ok {
+				*v = float32(n)
+			}
+		}
+		field("x", &r.X)
+		field("y", &r.Y)
+		field("w", &r.W)
+		field("h", &r.H)
+	}
+	return r
+}
+
+// shv.gui.begin("title", {x = 10, y = 10, w = 300, h = 200}) starts a
+// window, returning whether it is open; shv.gui.finish() ends it.
+func lBegin(L *l.LState) int {
+	L.Push(l.LBool(CurrentGUI.Begin(L.CheckString(1), optRect(L, 2))))
+	return 1
+}
+
+func lFinish(L *l.LState) int {
+	CurrentGUI.End()
+	return 0
+}
+
+// shv.gui.window("title", function() ... end, opts) runs the function
+// between begin and finish while the window is open.
+func lWindow(L *l.LState) int {
+	title := L.CheckString(1)
+	fn := L.CheckFunction(2)
+	open := CurrentGUI.Begin(title, optRect(L, 3))
+	var err error
+	if open {
+		err = L.CallByParam(l.P{
+			Fn:      fn,
+			NRet:    0,
+			Protect: true,
+		})
+	}
+	CurrentGUI.End()
+	if err != nil {
+		L.RaiseError(err.Error())
+	}
+	return 0
+}
+
+func lText(L *l.LState) int {
+	CurrentGUI.Text(L.CheckString(1))
+	return 0
+}
+
+func lButton(L *l.LState) int {
+	L.Push(l.LBool(CurrentGUI.Button(L.CheckString(1))))
+	return 1
+}
+
+// shv.gui.checkbox("label", v) is v after any click, and whether it changed.
+func lCheckbox(L *l.LState) int {
+	v, changed := CurrentGUI.Checkbox(L.CheckString(1), L.ToBool(2))
+	L.Push(l.LBool(v))
+	L.Push(l.LBool(changed))
+	return 2
+}
+
+// shv.gui.slider("speed", v, 0, 10) is v after any drag, and whether it
+// changed.
+func lSlider(L *l.LState) int {
+	v, changed := CurrentGUI.Slider(
+		L.CheckString(1),
+		float32(L.CheckNumber(2)),
+		float32(L.CheckNumber(3)),
+		float32(L.CheckNumber(4)),
+	)
+	L.Push(l.LNumber(v))
+	L.Push(l.LBool(changed))
+	return 2
+}
+
+// shv.gui.input("name", s) is s after any typing, and whether it changed.
+func lInput(L *l.LState) int {
+	s, changed := CurrentGUI.InputText(L.CheckString(1), L.OptString(2, ""))
+	L.Push(l.LString(s))
+	L.Push(l.LBool(changed))
+	return 2
+}
+
+// shv.gui.tree("label") is whether the node is open, its widgets ended with
+// shv.gui.tree_pop().
+func lTree(L *l.LState) int {
+	L.Push(l.LBool(CurrentGUI.TreeNode(L.CheckString(1))))
+	return 1
+}
+
+func lTreePop(L *l.LState) int {
+	CurrentGUI.TreePop()
+	return 0
+}
+
+// shv.gui.plot("frame ms", values, {min = 0, max = 33, height = 3})
+func lPlot(L *l.LState) int {
+	lbl := L.CheckString(1)
+	t := L.CheckTable(2)
+	values := make([]float32, 0, t.Len())
+	for i := 1; i <= t.Len(); i++ {
+		if n, ok := t.RawGetInt(i).(l.LNumber); ok {
+			values = append(values, float32(n))
+		}
+	}
+	var lo, hi float32
+	height := 3
+	if o, ok := L.Get(3).(*l.LTable); ok {
+		if n, ok := o.RawGetString("min").(l.LNumber); ok {
+			lo = float32(n)
+		}
+		if n, ok := o.RawGetString("max").(l.LNumber); ok {
+			hi = float32(n)
+		}
+		if n, ok := o.RawGetString("height").(l.LNumber); ok {
+			height = int(n)
+		}
+	}
+	CurrentGUI.Plot(lbl, values, lo, hi, height)
+	return 0
+}
+
+func lSeparator(L *l.LState) int {
+	CurrentGUI.Separator()
+	return 0
+}
+
+func lSameLine(L *l.LState) int {
+	CurrentGUI.SameLine()
+	return 0
+}
+
+// shv.gui.panel(true) shows the built in stats panel, returning whether it
+// is shown.
+func lPanel(L *l.LState) int {
+	if L.GetTop() > 0 {
+		CurrentGUI.ShowPanel(L.ToBool(1))
+	}
+	L.Push(l.LBool(CurrentGUI.PanelShown()))
+	return 1
+}
+
+// shv.gui.scale(3) sets the size of a font pixel, returning it.
+func lScale(L *l.LState) int {
+	if L.GetTop() > 0 {
+		CurrentGUI.SetScale(float32(L.CheckNumber(1)))
+	}
+	L.Push(l.LNumber(CurrentGUI.Scale()))
+	return 1
+}
+
+func lWantsMouse(L *l.LState) int {
+	L.Push(l.LBool(CurrentGUI.WantsMouse()))
+	return 1
+}
+
+func lWantsKeyboard(L *l.LState) int {
+	L.Push(l.LBool(CurrentGUI.WantsKeyboard()))
+	return 1
+}
+
+var guiFuncs = map[string]l.LGFunction{
+	"on_frame":       lOnFrame,
+	"begin":          lBegin,
+	"finish":         lFinish,
+	"window":         lWindow,
+	"text":           lText,
+	"button":         lButton,
+	"checkbox":       lCheckbox,
+	"slider":         lSlider,
+	"input":          lInput,
+	"tree":           lTree,
+	"tree_pop":       lTreePop,
+	"plot":           lPlot,
+	"separator":      lSeparator,
+	"same_line":      lSameLine,
+	"panel":          lPanel,
+	"scale":          lScale,
+	"wants_mouse":    lWantsMouse,
+	"wants_keyboard": lWantsKeyboard,
+}
+
+func RegisterWith() lua.RegisterWith {
+	return func(m lua.Module) error {
+		rmtfn := func(L *l.LState, M lua.Module) {
+			lua.SetSub(L, M, "gui", guiFuncs)
+		}
+		m.AddMT(rmtfn)
+		return nil
+	}
+}
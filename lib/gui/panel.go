@@ -0,0 +1,54 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/scene"
+)
+
+// Stat is a named value shown in the built in panel.
+type Stat struct {
+	Name, Value string
+}
+
+// SetStats sets what supplies the stats shown in the built in panel.
+func (c *Context) SetStats(fn func() []Stat) {
+	c.stats = fn
+}
+
+const panelTitle = "shiva"
+
+// statsPanel is a window of the engine stats and the scene node tree.
+func (c *Context) statsPanel() {
+	g := c.glyph()
+	if c.Begin(panelTitle, Rect{g, g, 30 * g, 24 * g}) {
+		if c.stats != nil {
+			for _, s := range c.stats() {
+				c.Text(fmt.Sprintf("%-10s %s", s.Name, s.Value))
+			}
+		}
+		c.Separator()
+		if s := scene.Current(); s != nil && c.TreeNode("scene") {
+			for _, n := range s.Nodes() {
+				c.nodeTree(n)
+			}
+			c.TreePop()
+		}
+	}
+	c.End()
+}
+
+func (c *Context) nodeTree(n scene.Node) {
+	name := fmt.Sprintf("%s (%s)##%d", n.Tag(), n.LClass(), n.ID())
+	out := n.Out()
+	if len(out) == 0 {
+		c.TextColored(label(name), colorDim)
+		return
+	}
+	if c.TreeNode(name) {
+		for _, o := range out {
+			c.nodeTree(o)
+		}
+		c.TreePop()
+	}
+}
@@ -0,0 +1,499 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+var (
+	colorWindow  = Color{0.08, 0.08, 0.1, 0.88}
+	colorTitle   = Color{0.2, 0.25, 0.4, 0.95}
+	colorBorder  = Color{0.4, 0.4, 0.5, 0.9}
+	colorFrame   = Color{0.2, 0.2, 0.25, 1}
+	colorHot     = Color{0.3, 0.3, 0.4, 1}
+	colorActive  = Color{0.35, 0.45, 0.7, 1}
+	colorFill    = Color{0.3, 0.45, 0.8, 1}
+	colorText    = Color{0.92, 0.92, 0.92, 1}
+	colorDim     = Color{0.6, 0.6, 0.65, 1}
+	colorPlot    = Color{0.9, 0.7, 0.2, 1}
+	colorCaret   = Color{1, 1, 1, 1}
+	colorGrip    = Color{0.4, 0.45, 0.6, 0.9}
+	colorDivider = Color{0.35, 0.35, 0.4, 1}
+)
+
+// window is what a window keeps between frames, and its layout while its
+// widgets are declared.
+type window struct {
+	title     string
+	rect      Rect
+	collapsed bool
+	titleH    float32
+	scroll    float32
+	content   float32
+	shown     bool
+	open      map[string]bool
+	d         drawList
+	x, y      float32
+	rowY      float32
+	rowH      float32
+	lastX     float32
+	indent    float32
+	same      bool
+}
+
+// frame is the part of a window drawn, only its title bar if collapsed.
+func (w *window) frame() Rect {
+	if w.collapsed {
+		return Rect{w.rect.X, w.rect.Y, w.rect.W, w.titleH}
+	}
+	return w.rect
+}
+
+func (c *Context) unit() float32 {
+	return c.scale
+}
+
+func (c *Context) glyph() float32 {
+	return GlyphSize * c.scale
+}
+
+func (c *Context) pad() float32 {
+	return 3 * c.scale
+}
+
+func (c *Context) itemH() float32 {
+	return c.glyph() + 2*c.pad()
+}
+
+func (c *Context) window(title string, r Rect) *window {
+	if w, ok := c.windows[title]; ok {
+		return w
+	}
+	w := &window{
+		title: title,
+		rect:  r,
+		open:  make(map[string]bool),
+	}
+	c.windows[title] = w
+	c.order = append(c.order, w)
+	return w
+}
+
+// label is the text shown for a label, anything from ## on only keeping ids
+// apart.
+func label(s string) string {
+	if i := strings.Index(s, "##"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func (c *Context) id(s string) string {
+	return c.cur.title + "/" + s
+}
+
+// Begin starts a window, placed at r when first seen, and reports whether
+// it is open for widgets. Every Begin is matched with an End.
+func (c *Context) Begin(title string, r Rect) bool {
+	if c.cur != nil {
+		c.End()
+	}
+	w := c.window(title, r)
+	c.cur, w.shown = w, true
+	g, p := c.glyph(), c.pad()
+	th := c.itemH()
+	w.titleH = th
+	mx, my := c.io.mouse[0], c.io.mouse[1]
+	mine := c.hovered == w
+
+	bar := Rect{w.rect.X, w.rect.Y, w.rect.W, th}
+	toggle := Rect{w.rect.X, w.rect.Y, th, th}
+	moveID, sizeID := title+"/#move", title+"/#size"
+	grip := Rect{w.rect.X + w.rect.W - g, w.rect.Y + w.rect.H - g, g, g}
+	if mine && c.io.pressed[0] {
+		switch {
+		case toggle.Contains(mx, my):
+			w.collapsed = !w.collapsed
+		case bar.Contains(mx, my):
+			c.active, c.drag = moveID, [2]float32{mx - w.rect.X, my - w.rect.Y}
+		case !w.collapsed && grip.Contains(mx, my):
+			c.active, c.drag = sizeID, [2]float32{w.rect.X + w.rect.W - mx, w.rect.Y + w.rect.H - my}
+		}
+	}
+	switch {
+	case c.active == moveID && c.io.down[0]:
+		w.rect.X, w.rect.Y = mx-c.drag[0], my-c.drag[1]
+	case c.active == sizeID && c.io.down[0]:
+		w.rect.W = max32(mx+c.drag[0]-w.rect.X, 12*g)
+		w.rect.H = max32(my+c.drag[1]-w.rect.Y, th+2*g)
+	}
+
+	body := Rect{w.rect.X, w.rect.Y + th, w.rect.W, w.rect.H - th}
+	if mine && c.io.wheel != 0 && !w.collapsed {
+		w.scroll -= c.io.wheel * th
+	}
+	w.scroll = clamp32(w.scroll, 0, max32(0, w.content-body.H+2*p))
+
+	w.d.reset(w.frame(), c.scale)
+	if !w.collapsed {
+		w.d.rect(body, colorWindow)
+	}
+	w.d.rect(bar, colorTitle)
+	arrow := "v"
+	if w.collapsed {
+		arrow = ">"
+	}
+	w.d.text(w.rect.X+p, w.rect.Y+p, arrow, colorText)
+	w.d.clip = Rect{w.rect.X, w.rect.Y, w.rect.W - p, th}
+	w.d.text(w.rect.X+th, w.rect.Y+p, title, colorText)
+	w.d.clip = w.frame()
+	w.d.border(w.frame(), colorBorder)
+
+	inner := Rect{body.X + p, body.Y + p, body.W - 2*p, body.H - 2*p}
+	w.d.clip = inner
+	w.x, w.y = inner.X, inner.Y-w.scroll
+	w.rowY, w.rowH, w.lastX, w.indent, w.same = w.y, 0, w.x, 0, false
+	return !w.collapsed
+}
+
+// End finishes the current window.
+func (c *Context) End() {
+	w := c.cur
+	if w == nil {
+		return
+	}
+	p, g := c.pad(), c.glyph()
+	w.content = w.y - (w.rect.Y + w.titleH + p - w.scroll)
+	if !w.collapsed {
+		w.d.clip = w.rect
+		grip := Rect{w.rect.X + w.rect.W - g, w.rect.Y + w.rect.H - g, g, g}
+		for i := float32(0); i < g; i += c.scale {
+			w.d.rect(Rect{grip.X + g - i - c.scale, grip.Y + i, i + c.scale, c.scale}, colorGrip)
+		}
+		w.d.border(w.rect, colorBorder)
+	}
+	c.cur = nil
+}
+
+// width is what is left of the current row.
+func (c *Context) width() float32 {
+	w := c.cur
+	if w.same {
+		return w.d.clip.X + w.d.clip.W - w.lastX - c.pad()
+	}
+	return w.d.clip.X + w.d.clip.W - w.x - w.indent
+}
+
+// item places the next widget, on the current row after SameLine or at the
+// start of the next.
+func (c *Context) item(width, height float32) Rect {
+	w := c.cur
+	var r Rect
+	if w.same {
+		r = Rect{w.lastX + c.pad(), w.rowY, width, height}
+		w.rowH = max32(w.rowH, height)
+		w.same = false
+	} else {
+		r = Rect{w.x + w.indent, w.y, width, height}
+		w.rowY, w.rowH = w.y, height
+	}
+	w.lastX = r.X + r.W
+	w.y = w.rowY + w.rowH + c.unit()*2
+	return r
+}
+
+// behavior is how the mouse treats a widget: over it, held down on it, and
+// released over it having been pressed on it.
+func (c *Context) behavior(id string, r Rect) (hot, held, clicked bool) {
+	w := c.cur
+	hot = c.hovered == w && r.Intersect(w.d.clip).Contains(c.io.mouse[0], c.io.mouse[1])
+	if hot && c.io.pressed[0] && c.active == "" {
+		c.active = id
+	}
+	held = c.active == id && c.io.down[0]
+	clicked = c.active == id && hot && c.io.released[0]
+	return
+}
+
+func (c *Context) frameColor(hot, held bool) Color {
+	switch {
+	case held:
+		return colorActive
+	case hot:
+		return colorHot
+	}
+	return colorFrame
+}
+
+// SameLine places the next widget to the right of the last.
+func (c *Context) SameLine() {
+	if c.cur != nil {
+		c.cur.same = true
+	}
+}
+
+// Text draws lines of text.
+func (c *Context) Text(s string) {
+	c.TextColored(s, colorText)
+}
+
+func (c *Context) TextColored(s string, col Color) {
+	if c.cur == nil {
+		return
+	}
+	p := c.pad()
+	for _, line := range strings.Split(s, "\n") {
+		r := c.item(textWidth(line, c.scale), c.itemH())
+		c.cur.d.text(r.X, r.Y+p, line, col)
+	}
+}
+
+// Button draws a button and reports whether it was clicked.
+func (c *Context) Button(lbl string) bool {
+	if c.cur == nil {
+		return false
+	}
+	p := c.pad()
+	s := label(lbl)
+	r := c.item(textWidth(s, c.scale)+2*p, c.itemH())
+	hot, held, clicked := c.behavior(c.id(lbl), r)
+	c.cur.d.rect(r, c.frameColor(hot, held))
+	c.cur.d.text(r.X+p, r.Y+p, s, colorText)
+	return clicked
+}
+
+// Checkbox draws a box ticked when v, reporting the value after any click
+// and whether it changed.
+func (c *Context) Checkbox(lbl string, v bool) (bool, bool) {
+	if c.cur == nil {
+		return v, false
+	}
+	p, h := c.pad(), c.itemH()
+	s := label(lbl)
+	r := c.item(h+p+textWidth(s, c.scale), h)
+	hot, held, clicked := c.behavior(c.id(lbl), r)
+	box := Rect{r.X, r.Y, h, h}
+	c.cur.d.rect(box, c.frameColor(hot, held))
+	if clicked {
+		v = !v
+	}
+	if v {
+		c.cur.d.rect(Rect{box.X + p, box.Y + p, h - 2*p, h - 2*p}, colorFill)
+	}
+	c.cur.d.text(r.X+h+p, r.Y+p, s, colorText)
+	return v, clicked
+}
+
+// Slider draws v between lo and hi, dragged with the mouse, reporting the
+// value and whether it changed.
+func (c *Context) Slider(lbl string, v, lo, hi float32) (float32, bool) {
+	if c.cur == nil {
+		return v, false
+	}
+	p, h := c.pad(), c.itemH()
+	s := label(lbl)
+	sw := max32(c.width()-textWidth(s, c.scale)-p, 8*c.glyph())
+	r := c.item(sw, h)
+	hot, held, _ := c.behavior(c.id(lbl), r)
+	changed := false
+	if held && hi != lo {
+		t := clamp32((c.io.mouse[0]-r.X)/r.W, 0, 1)
+		if nv := lo + t*(hi-lo); nv != v {
+			v, changed = nv, true
+		}
+	}
+	c.cur.d.rect(r, c.frameColor(hot, held))
+	if hi != lo {
+		t := clamp32((v-lo)/(hi-lo), 0, 1)
+		c.cur.d.rect(Rect{r.X, r.Y, r.W * t, h}, colorFill)
+	}
+	vs := fmt.Sprintf("%.3g", v)
+	c.cur.d.text(r.X+(r.W-textWidth(vs, c.scale))/2, r.Y+p, vs, colorText)
+	c.cur.d.text(r.X+r.W+p, r.Y+p, s, colorText)
+	return v, changed
+}
+
+// InputText draws an editable line of text, clicked to type into it and
+// finished with enter, escape putting back what it was. It reports the text
+// and whether it changed.
+func (c *Context) InputText(lbl, v string) (string, bool) {
+	if c.cur == nil {
+		return v, false
+	}
+	p, h := c.pad(), c.itemH()
+	s := label(lbl)
+	id := c.id(lbl)
+	fw := max32(c.width()-textWidth(s, c.scale)-p, 8*c.glyph())
+	r := c.item(fw, h)
+	hot, _, _ := c.behavior(id, r)
+	if c.io.pressed[0] {
+		switch {
+		case hot && c.focus != id:
+			c.focus, c.edit = id, []rune(v)
+			c.caret = len(c.edit)
+		case !hot && c.focus == id:
+			c.focus = ""
+		}
+	}
+	ret := v
+	if c.focus == id {
+		c.focused = true
+		c.editKeys()
+		switch {
+		case c.io.key(glfw.KeyEscape):
+			c.focus = ""
+		default:
+			ret = string(c.edit)
+			if c.io.key(glfw.KeyEnter) || c.io.key(glfw.KeyKPEnter) {
+				c.focus = ""
+			}
+		}
+	}
+	col := colorFrame
+	if c.focus == id {
+		col = colorActive
+	} else if hot {
+		col = colorHot
+	}
+	c.cur.d.rect(r, col)
+	shown := []rune(ret)
+	caret := len(shown)
+	if c.focus == id {
+		caret = c.caret
+	}
+	// keep the caret in view, scrolling the text left
+	fit := int((r.W - 2*p) / c.glyph())
+	first := 0
+	if caret > fit-1 {
+		first = caret - fit + 1
+	}
+	if first > len(shown) {
+		first = len(shown)
+	}
+	last := first + fit
+	if last > len(shown) {
+		last = len(shown)
+	}
+	c.cur.d.text(r.X+p, r.Y+p, string(shown[first:last]), colorText)
+	if c.focus == id {
+		cx := r.X + p + float32(caret-first)*c.glyph()
+		c.cur.d.rect(Rect{cx, r.Y + p, c.scale, c.glyph()}, colorCaret)
+	}
+	c.cur.d.text(r.X+r.W+p, r.Y+p, s, colorText)
+	return ret, ret != v
+}
+
+// editKeys applies the text typed and editing keys pressed this frame.
+func (c *Context) editKeys() {
+	for _, e := range c.io.typed {
+		if e.r != 0 {
+			c.edit = append(c.edit[:c.caret], append([]rune{e.r}, c.edit[c.caret:]...)...)
+			c.caret++
+			continue
+		}
+		switch e.k {
+		case glfw.KeyBackspace:
+			if c.caret > 0 {
+				c.edit = append(c.edit[:c.caret-1], c.edit[c.caret:]...)
+				c.caret--
+			}
+		case glfw.KeyDelete:
+			if c.caret < len(c.edit) {
+				c.edit = append(c.edit[:c.caret], c.edit[c.caret+1:]...)
+			}
+		case glfw.KeyLeft:
+			if c.caret > 0 {
+				c.caret--
+			}
+		case glfw.KeyRight:
+			if c.caret < len(c.edit) {
+				c.caret++
+			}
+		case glfw.KeyHome:
+			c.caret = 0
+		case glfw.KeyEnd:
+			c.caret = len(c.edit)
+		}
+	}
+}
+
+// TreeNode draws a row opening and closing when clicked, reporting whether
+// it is open. Widgets following an open node are indented until TreePop.
+func (c *Context) TreeNode(lbl string) bool {
+	if c.cur == nil {
+		return false
+	}
+	p := c.pad()
+	s := label(lbl)
+	id := c.id(lbl)
+	r := c.item(max32(c.width(), textWidth(s, c.scale)+c.glyph()+p), c.itemH())
+	hot, held, clicked := c.behavior(id, r)
+	if clicked {
+		c.cur.open[id] = !c.cur.open[id]
+	}
+	open := c.cur.open[id]
+	if hot || held {
+		c.cur.d.rect(r, c.frameColor(hot, held))
+	}
+	arrow := ">"
+	if open {
+		arrow = "v"
+	}
+	c.cur.d.text(r.X, r.Y+p, arrow, colorDim)
+	c.cur.d.text(r.X+c.glyph()+p, r.Y+p, s, colorText)
+	if open {
+		c.cur.indent += c.glyph()
+	}
+	return open
+}
+
+// TreePop ends the widgets of an open TreeNode.
+func (c *Context) TreePop() {
+	if c.cur != nil {
+		c.cur.indent = max32(0, c.cur.indent-c.glyph())
+	}
+}
+
+// Plot draws values as columns from lo to hi, the range of the values if
+// lo and hi are equal, height rows of text tall.
+func (c *Context) Plot(lbl string, values []float32, lo, hi float32, height int) {
+	if c.cur == nil {
+		return
+	}
+	if height < 1 {
+		height = 3
+	}
+	p := c.pad()
+	r := c.item(c.width(), float32(height)*c.itemH())
+	c.cur.d.rect(r, colorFrame)
+	if lo == hi && len(values) > 0 {
+		lo, hi = values[0], values[0]
+		for _, v := range values {
+			lo, hi = min32(lo, v), max32(hi, v)
+		}
+	}
+	if n := len(values); n > 0 && hi != lo {
+		cw := r.W / float32(n)
+		for i, v := range values {
+			t := clamp32((v-lo)/(hi-lo), 0, 1)
+			ch := t * r.H
+			c.cur.d.rect(Rect{r.X + float32(i)*cw, r.Y + r.H - ch, max32(cw, 1), ch}, colorPlot)
+		}
+	}
+	s := label(lbl)
+	if len(values) > 0 {
+		s = fmt.Sprintf("%s %.3g", s, values[len(values)-1])
+	}
+	c.cur.d.text(r.X+p, r.Y+p, s, colorText)
+}
+
+// Separator draws a line across the window.
+func (c *Context) Separator() {
+	if c.cur == nil {
+		return
+	}
+	r := c.item(c.width(), 3*c.scale)
+	c.cur.d.rect(Rect{r.X, r.Y + c.scale, r.W, c.scale}, colorDivider)
+}
@@ -0,0 +1,360 @@
+package input
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// Action is a named input, a button like "jump" or an axis like "move_x",
+// fed by any number of bindings whose values are summed each frame.
+type Action struct {
+	Name        string
+	Bindings    []*Binding
+	value, prev float32
+}
+
+// threshold is the value an action must reach to count as down.
+const threshold = 0.5
+
+func (a *Action) evaluate(d *devices) {
+	a.prev = a.value
+	var v float32
+	analog := false
+	for _, b := range a.Bindings {
+		v += b.value(d)
+		analog = analog || b.Analog()
+	}
+	if !analog {
+		v = clamp(v, -1, 1)
+	}
+	a.value = v
+}
+
+func (a *Action) block() {
+	a.prev, a.value = a.value, 0
+}
+
+func clamp(v, lo, hi float32) float32 {
+	switch {
+	case v < lo:
+		return lo
+	case v > hi:
+		return hi
+	}
+	return v
+}
+
+func (a *Action) Value() float32 {
+	return a.value
+}
+
+// Held reports the action down this frame.
+func (a *Action) Held() bool {
+	return abs(a.value) >= threshold
+}
+
+// Pressed reports the action went down this frame.
+func (a *Action) Pressed() bool {
+	return a.Held() && abs(a.prev) < threshold
+}
+
+// Released reports the action came up this frame.
+func (a *Action) Released() bool {
+	return !a.Held() && abs(a.prev) >= threshold
+}
+
+// Context is a set of actions active together, like gameplay or a menu. A
+// transparent context lets actions of the contexts below it through.
+type Context struct {
+	Name        string
+	Transparent bool
+	actions     map[string]*Action
+}
+
+func newContext(name string) *Context {
+	return &Context{Name: name, actions: make(map[string]*Action)}
+}
+
+// Action is the named action, nil if the context has none.
+func (c *Context) Action(name string) *Action {
+	return c.actions[name]
+}
+
+// Actions is every action name in the context, sorted.
+func (c *Context) Actions() []string {
+	ret := make([]string, 0, len(c.actions))
+	for n := range c.actions {
+		ret = append(ret, n)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// DefaultContext is at the bottom of the stack and cannot be popped.
+const DefaultContext = "default"
+
+var BindingsFileError = xrror.Xrror("bindings file %s: %s").Out
+
+// Actions maps devices to actions through a stack of contexts, evaluated
+// once a frame.
+type Actions struct {
+	d        *devices
+	contexts map[string]*Context
+	stack    []*Context
+	active   map[string]*Action
+}
+
+func newActions(d *devices) *Actions {
+	def := newContext(DefaultContext)
+	return &Actions{
+		d:        d,
+		contexts: map[string]*Context{DefaultContext: def},
+		stack:    []*Context{def},
+		active:   make(map[string]*Action),
+	}
+}
+
+// Context returns the named context, creating it if it does not exist.
+func (as *Actions) Context(name string) *Context {
+	if name == "" {
+		name = DefaultContext
+	}
+	c, ok := as.contexts[name]
+	if !ok {
+		c = newContext(name)
+		as.contexts[name] = c
+	}
+	return c
+}
+
+// Push makes the named context the top of the stack.
+func (as *Actions) Push(name string, transparent bool) *Context {
+	c := as.Context(name)
+	c.Transparent = transparent
+	as.stack = append(as.stack, c)
+	return c
+}
+
+// Pop removes the top context, never the default one, returning it.
+func (as *Actions) Pop() *Context {
+	if len(as.stack) <= 1 {
+		return nil
+	}
+	c := as.stack[len(as.stack)-1]
+	as.stack = as.stack[:len(as.stack)-1]
+	return c
+}
+
+func (as *Actions) Top() *Context {
+	return as.stack[len(as.stack)-1]
+}
+
+// Stack is the context names, bottom first.
+func (as *Actions) Stack() []string {
+	ret := make([]string, 0, len(as.stack))
+	for _, c := range as.stack {
+		ret = append(ret, c.Name)
+	}
+	return ret
+}
+
+// Bind adds bindings to an action of a context, creating the action.
+func (as *Actions) Bind(context, action string, bs ...*Binding) *Action {
+	c := as.Context(context)
+	a, ok := c.actions[action]
+	if !ok {
+		a = &Action{Name: action, Bindings: make([]*Binding, 0)}
+		c.actions[action] = a
+	}
+	a.Bindings = append(a.Bindings, bs...)
+	return a
+}
+
+// Unbind removes an action from a context.
+func (as *Actions) Unbind(context, action string) {
+	delete(as.Context(context).actions, action)
+	delete(as.active, action)
+}
+
+// Action is the action answering to name this frame, the one in the topmost
+// context holding it not hidden by an opaque context above.
+func (as *Actions) Action(name string) *Action {
+	return as.active[name]
+}
+
+func (as *Actions) Value(name string) float32 {
+	if a := as.Action(name); a != nil {
+		return a.Value()
+	}
+	return 0
+}
+
+func (as *Actions) Held(name string) bool {
+	a := as.Action(name)
+	return a != nil && a.Held()
+}
+
+func (as *Actions) Pressed(name string) bool {
+	a := as.Action(name)
+	return a != nil && a.Pressed()
+}
+
+func (as *Actions) Released(name string) bool {
+	a := as.Action(name)
+	return a != nil && a.Released()
+}
+
+// Capture calls fn once with the next input pressed, for rebinding.
+func (as *Actions) Capture(fn func(*Binding) error) {
+	as.d.Capture(fn)
+}
+
+// evaluate reads every action of the contexts reachable down the stack and
+// blocks the rest, so actions of hidden contexts report nothing.
+func (as *Actions) evaluate() {
+	for k := range as.active {
+		delete(as.active, k)
+	}
+	reach := len(as.stack) - 1
+	for ; reach > 0 && as.stack[reach].Transparent; reach-- {
+	}
+	for i := len(as.stack) - 1; i >= reach; i-- {
+		for n, a := range as.stack[i].actions {
+			if _, ok := as.active[n]; !ok {
+				as.active[n] = a
+			}
+		}
+	}
+	for _, c := range as.contexts {
+		for n, a := range c.actions {
+			if as.active[n] == a {
+				a.evaluate(as.d)
+			} else {
+				a.block()
+			}
+		}
+	}
+}
+
+// bindingsFile is the file form of the bindings, each binding either its
+// string form or {"input": "pad:axis:0", "scale": -1, "deadzone": 0.2}.
+type bindingsFile struct {
+	Contexts map[string]*contextFile `json:"contexts"`
+}
+
+type contextFile struct {
+	Transparent bool                          `json:"transparent,omitempty"`
+	Actions     map[string][]*json.RawMessage `json:"actions"`
+}
+
+type bindingFile struct {
+	Input       string   `json:"input"`
+	Scale       *float32 `json:"scale,omitempty"`
+	Sensitivity *float32 `json:"sensitivity,omitempty"`
+	DeadZone    float32  `json:"deadzone,omitempty"`
+}
+
+func decodeBinding(raw json.RawMessage) (*Binding, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return ParseBinding(s)
+	}
+	var bf bindingFile
+	if err := json.Unmarshal(raw, &bf); err != nil {
+		return nil, err
+	}
+	b, err := ParseBinding(bf.Input)
+	if err != nil {
+		return nil, err
+	}
+	if bf.Scale != nil {
+		b.Scale = *bf.Scale
+	}
+	if bf.Sensitivity != nil {
+		b.Scale *= *bf.Sensitivity
+	}
+	b.DeadZone = bf.DeadZone
+	return b, nil
+}
+
+func encodeBinding(b *Binding) (json.RawMessage, error) {
+	if b.Scale == 1 && b.DeadZone == 0 {
+		return json.Marshal(b.String())
+	}
+	scale := b.Scale
+	return json.Marshal(&bindingFile{Input: b.String(), Scale: &scale, DeadZone: b.DeadZone})
+}
+
+// Load reads bindings from a JSON file, replacing those of every context the
+// file names. The stack is left as it is.
+func (as *Actions) Load(path string) error {
+	data, err := vfs.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var f bindingsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return BindingsFileError(path, err)
+	}
+	loaded := make(map[string]*Context)
+	for name, cf := range f.Contexts {
+		c := newContext(name)
+		if cf != nil {
+			c.Transparent = cf.Transparent
+			for an, raws := range cf.Actions {
+				a := &Action{Name: an, Bindings: make([]*Binding, 0, len(raws))}
+				for _, raw := range raws {
+					if raw == nil {
+						continue
+					}
+					b, err := decodeBinding(*raw)
+					if err != nil {
+						return BindingsFileError(path, err)
+					}
+					a.Bindings = append(a.Bindings, b)
+				}
+				c.actions[an] = a
+			}
+		}
+		loaded[name] = c
+	}
+	for name, c := range loaded {
+		if old, ok := as.contexts[name]; ok {
+			old.Transparent = c.Transparent
+			old.actions = c.actions
+		} else {
+			as.contexts[name] = c
+		}
+	}
+	as.active = make(map[string]*Action)
+	return nil
+}
+
+// Save writes every context's bindings to a JSON file Load reads back.
+func (as *Actions) Save(path string) error {
+	f := bindingsFile{Contexts: make(map[string]*contextFile)}
+	for name, c := range as.contexts {
+		cf := &contextFile{Transparent: c.Transparent, Actions: make(map[string][]*json.RawMessage)}
+		for an, a := range c.actions {
+			raws := make([]*json.RawMessage, 0, len(a.Bindings))
+			for _, b := range a.Bindings {
+				raw, err := encodeBinding(b)
+				if err != nil {
+					return err
+				}
+				raws = append(raws, &raw)
+			}
+			cf.Actions[an] = raws
+		}
+		f.Contexts[name] = cf
+	}
+	data, err := json.MarshalIndent(&f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,322 @@
+package input
+
+import (
+	glm "math"
+	"strconv"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+type BindingT int
+
+const (
+	UNKNOWN_BINDING BindingT = iota
+	KEY
+	MOUSE_BUTTON
+	MOUSE_AXIS
+	SCROLL_AXIS
+	PAD_BUTTON
+	PAD_AXIS
+)
+
+var keyNames = map[string]glfw.Key{
+	"space":      glfw.KeySpace,
+	"apostrophe": glfw.KeyApostrophe,
+	"comma":      glfw.KeyComma,
+	"minus":      glfw.KeyMinus,
+	"period":     glfw.KeyPeriod,
+	"slash":      glfw.KeySlash,
+	"semicolon":  glfw.KeySemicolon,
+	"equal":      glfw.KeyEqual,
+	"lbracket":   glfw.KeyLeftBracket,
+	"backslash":  glfw.KeyBackslash,
+	"rbracket":   glfw.KeyRightBracket,
+	"grave":      glfw.KeyGraveAccent,
+	"escape":     glfw.KeyEscape,
+	"enter":      glfw.KeyEnter,
+	"tab":        glfw.KeyTab,
+	"backspace":  glfw.KeyBackspace,
+	"insert":     glfw.KeyInsert,
+	"delete":     glfw.KeyDelete,
+	"right":      glfw.KeyRight,
+	"left":       glfw.KeyLeft,
+	"down":       glfw.KeyDown,
+	"up":         glfw.KeyUp,
+	"pageup":     glfw.KeyPageUp,
+	"pagedown":   glfw.KeyPageDown,
+	"home":       glfw.KeyHome,
+	"end":        glfw.KeyEnd,
+	"capslock":   glfw.KeyCapsLock,
+	"pause":      glfw.KeyPause,
+	"lshift":     glfw.KeyLeftShift,
+	"lctrl":      glfw.KeyLeftControl,
+	"lalt":       glfw.KeyLeftAlt,
+	"lsuper":     glfw.KeyLeftSuper,
+	"rshift":     glfw.KeyRightShift,
+	"rctrl":      glfw.KeyRightControl,
+	"ralt":       glfw.KeyRightAlt,
+	"rsuper":     glfw.KeyRightSuper,
+	"kp.":        glfw.KeyKPDecimal,
+	"kp/":        glfw.KeyKPDivide,
+	"kp*":        glfw.KeyKPMultiply,
+	"kp-":        glfw.KeyKPSubtract,
+	"kp+":        glfw.KeyKPAdd,
+	"kpenter":    glfw.KeyKPEnter,
+}
+
+var modNames = map[string]glfw.ModifierKey{
+	"shift": glfw.ModShift,
+	"ctrl":  glfw.ModControl,
+	"alt":   glfw.ModAlt,
+	"super": glfw.ModSuper,
+}
+
+var mouseNames = map[string]glfw.MouseButton{
+	"left":   glfw.MouseButtonLeft,
+	"right":  glfw.MouseButtonRight,
+	"middle": glfw.MouseButtonMiddle,
+}
+
+func init() {
+	for i := 0; i < 26; i++ {
+		keyNames[string(rune('a'+i))] = glfw.KeyA + glfw.Key(i)
+	}
+	for i := 0; i < 10; i++ {
+		keyNames[strconv.Itoa(i)] = glfw.Key0 + glfw.Key(i)
+		keyNames["kp"+strconv.Itoa(i)] = glfw.KeyKP0 + glfw.Key(i)
+	}
+	for i := 0; i < 12; i++ {
+		keyNames["f"+strconv.Itoa(i+1)] = glfw.KeyF1 + glfw.Key(i)
+	}
+	for i := 4; i <= 8; i++ {
+		mouseNames[strconv.Itoa(i)] = glfw.MouseButton1 + glfw.MouseButton(i-1)
+	}
+}
+
+func keyName(k glfw.Key) string {
+	for n, v := range keyNames {
+		if v == k {
+			return n
+		}
+	}
+	return strconv.Itoa(int(k))
+}
+
+func mouseName(b glfw.MouseButton) string {
+	for n, v := range mouseNames {
+		if v == b {
+			return n
+		}
+	}
+	return strconv.Itoa(int(b) + 1)
+}
+
+// AnyPad binds to whichever gamepad is active.
+const AnyPad = -1
+
+// Binding is one physical input feeding an action: a key with modifiers, a
+// mouse button or axis, or a gamepad button or axis. Scale multiplies the
+// value, negative for the opposite direction of an axis, and DeadZone is
+// cut from analog inputs.
+type Binding struct {
+	Kind     BindingT
+	Code     int
+	Mods     glfw.ModifierKey
+	Pad      int
+	Scale    float32
+	DeadZone float32
+}
+
+var InvalidBindingError = xrror.Xrror("%s is not a valid input binding").Out
+
+// ParseBinding reads a binding from its string form:
+//
+//	key:space, key:ctrl+shift+s, mouse:left, mouse:x, scroll:y,
+//	pad:button:a, pad2:axis:leftx, pad:axis:3
+//
+// A pad with no number is whichever gamepad was used last, pad2 that of
+// player 2.
+func ParseBinding(s string) (*Binding, error) {
+	b := &Binding{Pad: AnyPad, Scale: 1}
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), ":")
+	if len(parts) < 2 {
+		return nil, InvalidBindingError(s)
+	}
+	switch dev, rest := parts[0], parts[1:]; {
+	case dev == "key" && len(rest) == 1:
+		name := rest[0]
+		// modifiers prefix the key, which may itself hold a +
+		for stripped := true; stripped; {
+			stripped = false
+			for m, mod := range modNames {
+				if strings.HasPrefix(name, m+"+") && len(name) > len(m)+1 {
+					name = name[len(m)+1:]
+					b.Mods |= mod
+					stripped = true
+				}
+			}
+		}
+		k, ok := keyNames[name]
+		if !ok {
+			return nil, InvalidBindingError(s)
+		}
+		b.Kind, b.Code = KEY, int(k)
+	case dev == "mouse" && len(rest) == 1:
+		switch rest[0] {
+		case "x":
+			b.Kind, b.Code = MOUSE_AXIS, 0
+		case "y":
+			b.Kind, b.Code = MOUSE_AXIS, 1
+		default:
+			mb, ok := mouseNames[rest[0]]
+			if !ok {
+				return nil, InvalidBindingError(s)
+			}
+			b.Kind, b.Code = MOUSE_BUTTON, int(mb)
+		}
+	case dev == "scroll" && len(rest) == 1:
+		switch rest[0] {
+		case "x":
+			b.Kind, b.Code = SCROLL_AXIS, 0
+		case "y":
+			b.Kind, b.Code = SCROLL_AXIS, 1
+		default:
+			return nil, InvalidBindingError(s)
+		}
+	case strings.HasPrefix(dev, "pad") && len(rest) == 2:
+		if n := strings.TrimPrefix(dev, "pad"); n != "" {
+			pad, err := strconv.Atoi(n)
+			if err != nil || pad < 1 {
+				return nil, InvalidBindingError(s)
+			}
+			b.Pad = pad - 1
+		}
+		code, err := padCode(rest[0], rest[1])
+		if err != nil {
+			return nil, InvalidBindingError(s)
+		}
+		b.Code = code
+		switch {
+		case rest[0] == "button" && code >= 0 && code < int(PadButtons):
+			b.Kind = PAD_BUTTON
+		case rest[0] == "axis" && code >= 0 && code < int(PadAxes):
+			b.Kind = PAD_AXIS
+		default:
+			return nil, InvalidBindingError(s)
+		}
+	default:
+		return nil, InvalidBindingError(s)
+	}
+	return b, nil
+}
+
+// padCode is a gamepad button or axis of the standard layout, by name or
+// index.
+func padCode(kind, name string) (int, error) {
+	switch kind {
+	case "button":
+		if b, ok := padButtonNames[name]; ok {
+			return int(b), nil
+		}
+	case "axis":
+		if a, ok := padAxisNames[name]; ok {
+			return int(a), nil
+		}
+	}
+	return strconv.Atoi(name)
+}
+
+func (b *Binding) String() string {
+	switch b.Kind {
+	case KEY:
+		var mods []string
+		for _, m := range []string{"ctrl", "alt", "shift", "super"} {
+			if b.Mods&modNames[m] != 0 {
+				mods = append(mods, m)
+			}
+		}
+		return "key:" + strings.Join(append(mods, keyName(glfw.Key(b.Code))), "+")
+	case MOUSE_BUTTON:
+		return "mouse:" + mouseName(glfw.MouseButton(b.Code))
+	case MOUSE_AXIS:
+		return "mouse:" + []string{"x", "y"}[b.Code]
+	case SCROLL_AXIS:
+		return "scroll:" + []string{"x", "y"}[b.Code]
+	case PAD_BUTTON, PAD_AXIS:
+		dev := "pad"
+		if b.Pad != AnyPad {
+			dev += strconv.Itoa(b.Pad + 1)
+		}
+		if b.Kind == PAD_AXIS {
+			return dev + ":axis:" + PadAxis(b.Code).String()
+		}
+		return dev + ":button:" + PadButton(b.Code).String()
+	}
+	return "UNKNOWN_BINDING"
+}
+
+// Analog reports whether the binding has continuous values.
+func (b *Binding) Analog() bool {
+	switch b.Kind {
+	case MOUSE_AXIS, SCROLL_AXIS, PAD_AXIS:
+		return true
+	}
+	return false
+}
+
+// value reads the binding from device state, dead zone and scale applied.
+func (b *Binding) value(d *devices) float32 {
+	var v float32
+	switch b.Kind {
+	case KEY:
+		if d.claimKeys {
+			return 0
+		}
+	case MOUSE_BUTTON, MOUSE_AXIS, SCROLL_AXIS:
+		if d.claimMouse {
+			return 0
+		}
+	}
+	switch b.Kind {
+	case KEY:
+		if d.key(glfw.Key(b.Code)) && d.mods()&b.Mods == b.Mods {
+			v = 1
+		}
+	case MOUSE_BUTTON:
+		if d.mouse(glfw.MouseButton(b.Code)) {
+			v = 1
+		}
+	case MOUSE_AXIS:
+		v = d.cursorDelta[b.Code]
+	case SCROLL_AXIS:
+		v = d.scrollDelta[b.Code]
+	case PAD_BUTTON:
+		if d.padButton(b.Pad, b.Code) {
+			v = 1
+		}
+	case PAD_AXIS:
+		v = deadZone(d.padAxis(b.Pad, b.Code), b.DeadZone)
+	}
+	return v * b.Scale
+}
+
+// deadZone cuts |v| under dz to 0, rescaling the rest to keep the full range.
+func deadZone(v, dz float32) float32 {
+	if dz <= 0 {
+		return v
+	}
+	if dz >= 1 {
+		return 0
+	}
+	a := float32(glm.Abs(float64(v)))
+	if a < dz {
+		return 0
+	}
+	s := (a - dz) / (1 - dz)
+	if v < 0 {
+		return -s
+	}
+	return s
+}
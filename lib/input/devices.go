@@ -0,0 +1,204 @@
+package input
+
+import (
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// devices tracks raw key, mouse and gamepad state between frames from the
+// callbacks and polling. Presses shorter than a frame still count as down
+// for the frame they happened in.
+type devices struct {
+	keys        map[glfw.Key]bool
+	keyTaps     map[glfw.Key]bool
+	buttons     map[glfw.MouseButton]bool
+	buttonTaps  map[glfw.MouseButton]bool
+	cursor      [2]float32
+	cursorSeen  bool
+	cursorMoved [2]float32
+	cursorDelta [2]float32
+	scroll      [2]float32
+	scrollDelta [2]float32
+	pads        *Gamepads
+	claimMouse  bool
+	claimKeys   bool
+	capture     []func(*Binding) error
+	err         error
+}
+
+func newDevices() *devices {
+	return &devices{
+		keys:       make(map[glfw.Key]bool),
+		keyTaps:    make(map[glfw.Key]bool),
+		buttons:    make(map[glfw.MouseButton]bool),
+		buttonTaps: make(map[glfw.MouseButton]bool),
+		pads:       NewGamepads(glfwJoysticks{}),
+		capture:    make([]func(*Binding) error, 0),
+	}
+}
+
+func (d *devices) KEvent(k glfw.Key, s int, a glfw.Action, m glfw.ModifierKey) {
+	switch a {
+	case glfw.Press:
+		d.keys[k] = true
+		d.keyTaps[k] = true
+		d.captured(&Binding{Kind: KEY, Code: int(k), Mods: d.mods() &^ modOf(k), Pad: AnyPad, Scale: 1})
+	case glfw.Release:
+		d.keys[k] = false
+	}
+}
+
+func (d *devices) MBEvent(b glfw.MouseButton, a glfw.Action, m glfw.ModifierKey) {
+	switch a {
+	case glfw.Press:
+		d.buttons[b] = true
+		d.buttonTaps[b] = true
+		d.captured(&Binding{Kind: MOUSE_BUTTON, Code: int(b), Pad: AnyPad, Scale: 1})
+	case glfw.Release:
+		d.buttons[b] = false
+	}
+}
+
+func (d *devices) CPEvent(x, y float64) {
+	p := [2]float32{float32(x), float32(y)}
+	if d.cursorSeen {
+		d.cursorMoved[0] += p[0] - d.cursor[0]
+		d.cursorMoved[1] += p[1] - d.cursor[1]
+	}
+	d.cursor, d.cursorSeen = p, true
+}
+
+func (d *devices) SEvent(x, y float64) {
+	d.scroll[0] += float32(x)
+	d.scroll[1] += float32(y)
+}
+
+func modOf(k glfw.Key) glfw.ModifierKey {
+	switch k {
+	case glfw.KeyLeftShift, glfw.KeyRightShift:
+		return glfw.ModShift
+	case glfw.KeyLeftControl, glfw.KeyRightControl:
+		return glfw.ModControl
+	case glfw.KeyLeftAlt, glfw.KeyRightAlt:
+		return glfw.ModAlt
+	case glfw.KeyLeftSuper, glfw.KeyRightSuper:
+		return glfw.ModSuper
+	}
+	return 0
+}
+
+// mods are the modifiers held, from the modifier keys down.
+func (d *devices) mods() glfw.ModifierKey {
+	var m glfw.ModifierKey
+	for k, down := range d.keys {
+		if down {
+			m |= modOf(k)
+		}
+	}
+	return m
+}
+
+func (d *devices) key(k glfw.Key) bool {
+	return d.keys[k] || d.keyTaps[k]
+}
+
+func (d *devices) mouse(b glfw.MouseButton) bool {
+	return d.buttons[b] || d.buttonTaps[b]
+}
+
+// Capture calls fn once with the next key, mouse button or gamepad input
+// pressed, for rebinding. Its error is returned by the next input update.
+func (d *devices) Capture(fn func(*Binding) error) {
+	d.capture = append(d.capture, fn)
+}
+
+func (d *devices) captured(b *Binding) {
+	if len(d.capture) == 0 {
+		return
+	}
+	fns := d.capture
+	d.capture = make([]func(*Binding) error, 0)
+	for _, fn := range fns {
+		if err := fn(b); err != nil && d.err == nil {
+			d.err = err
+		}
+	}
+}
+
+func (d *devices) getPad(i int) *Gamepad {
+	if i == AnyPad {
+		return d.pads.Active()
+	}
+	return d.pads.Player(i + 1)
+}
+
+func (d *devices) padButton(i, b int) bool {
+	if g := d.getPad(i); g != nil {
+		return g.Button(PadButton(b))
+	}
+	return false
+}
+
+func (d *devices) padAxis(i, a int) float32 {
+	if g := d.getPad(i); g != nil {
+		return g.Axis(PadAxis(a))
+	}
+	return 0
+}
+
+// padThreshold is how far an axis must move to count as pressed for capture
+// and to make its gamepad the active one.
+const padThreshold = 0.5
+
+// pollPads reads the gamepads, handing newly pressed buttons and pushed axes
+// to capture. Errors of gamepad event functions are returned like those of
+// capture.
+func (d *devices) pollPads() {
+	if err := d.pads.poll(); err != nil && d.err == nil {
+		d.err = err
+	}
+	if len(d.capture) == 0 {
+		return
+	}
+	for _, g := range d.pads.Connected() {
+		for b := PadButton(0); b < PadButtons; b++ {
+			if g.Pressed(b) {
+				d.captured(&Binding{Kind: PAD_BUTTON, Code: int(b), Pad: AnyPad, Scale: 1})
+			}
+		}
+		for a := PadAxis(0); a < PadAxes; a++ {
+			if v := g.Axis(a); abs(v) > padThreshold && abs(g.prevAxis(a)) <= padThreshold {
+				scale := float32(1)
+				if v < 0 {
+					scale = -1
+				}
+				d.captured(&Binding{Kind: PAD_AXIS, Code: int(a), Pad: AnyPad, Scale: scale})
+			}
+		}
+	}
+}
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// frame latches the deltas gathered since the last frame.
+func (d *devices) frame() {
+	d.cursorDelta, d.cursorMoved = d.cursorMoved, [2]float32{}
+	d.scrollDelta, d.scroll = d.scroll, [2]float32{}
+}
+
+// endFrame clears the taps and returns any capture error.
+func (d *devices) endFrame() error {
+	err := d.err
+	d.err = nil
+	for k := range d.keyTaps {
+		delete(d.keyTaps, k)
+	}
+	for b := range d.buttonTaps {
+		delete(d.buttonTaps, b)
+	}
+	return err
+}
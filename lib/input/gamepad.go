@@ -0,0 +1,366 @@
+package input
+
+// PadButton is a button of the standard gamepad layout.
+type PadButton int
+
+const (
+	PadA PadButton = iota
+	PadB
+	PadX
+	PadY
+	PadBack
+	PadGuide
+	PadStart
+	PadLeftStick
+	PadRightStick
+	PadLeftShoulder
+	PadRightShoulder
+	PadDpadUp
+	PadDpadDown
+	PadDpadLeft
+	PadDpadRight
+	PadButtons
+)
+
+// PadAxis is an axis of the standard gamepad layout. Sticks run -1 to 1,
+// down and right positive; triggers run 0 to 1.
+type PadAxis int
+
+const (
+	PadLeftX PadAxis = iota
+	PadLeftY
+	PadRightX
+	PadRightY
+	PadLeftTrigger
+	PadRightTrigger
+	PadAxes
+)
+
+var padButtonNames = map[string]PadButton{
+	"a":             PadA,
+	"b":             PadB,
+	"x":             PadX,
+	"y":             PadY,
+	"back":          PadBack,
+	"guide":         PadGuide,
+	"start":         PadStart,
+	"leftstick":     PadLeftStick,
+	"rightstick":    PadRightStick,
+	"leftshoulder":  PadLeftShoulder,
+	"rightshoulder": PadRightShoulder,
+	"dpup":          PadDpadUp,
+	"dpdown":        PadDpadDown,
+	"dpleft":        PadDpadLeft,
+	"dpright":       PadDpadRight,
+}
+
+var padAxisNames = map[string]PadAxis{
+	"leftx":        PadLeftX,
+	"lefty":        PadLeftY,
+	"rightx":       PadRightX,
+	"righty":       PadRightY,
+	"lefttrigger":  PadLeftTrigger,
+	"righttrigger": PadRightTrigger,
+}
+
+func (b PadButton) String() string {
+	for n, v := range padButtonNames {
+		if v == b {
+			return n
+		}
+	}
+	return "UNKNOWN_PAD_BUTTON"
+}
+
+func (a PadAxis) String() string {
+	for n, v := range padAxisNames {
+		if v == a {
+			return n
+		}
+	}
+	return "UNKNOWN_PAD_AXIS"
+}
+
+func StringToPadButton(s string) (PadButton, bool) {
+	b, ok := padButtonNames[s]
+	return b, ok
+}
+
+func StringToPadAxis(s string) (PadAxis, bool) {
+	a, ok := padAxisNames[s]
+	return a, ok
+}
+
+type padState struct {
+	buttons [PadButtons]bool
+	axes    [PadAxes]float32
+}
+
+// Gamepad is a joystick read through its mapping into the standard layout,
+// the state of this frame and the last kept for pressed and released.
+type Gamepad struct {
+	player    int
+	joystick  int
+	name      string
+	guid      string
+	mapping   *Mapping
+	cur, prev padState
+}
+
+// Player is the 1 based player number the gamepad is assigned to.
+func (g *Gamepad) Player() int {
+	return g.player + 1
+}
+
+// Joystick is the joystick slot read, -1 when disconnected.
+func (g *Gamepad) Joystick() int {
+	return g.joystick
+}
+
+func (g *Gamepad) Connected() bool {
+	return g.joystick >= 0
+}
+
+func (g *Gamepad) Name() string {
+	return g.name
+}
+
+func (g *Gamepad) GUID() string {
+	return g.guid
+}
+
+// Mapped reports whether the gamepad has a mapping of its own rather than
+// the default one.
+func (g *Gamepad) Mapped() bool {
+	return g.mapping != nil && g.mapping.GUID != "default"
+}
+
+func (g *Gamepad) Button(b PadButton) bool {
+	return b >= 0 && b < PadButtons && g.cur.buttons[b]
+}
+
+func (g *Gamepad) Pressed(b PadButton) bool {
+	return g.Button(b) && !g.prev.buttons[b]
+}
+
+func (g *Gamepad) Released(b PadButton) bool {
+	return b >= 0 && b < PadButtons && !g.cur.buttons[b] && g.prev.buttons[b]
+}
+
+func (g *Gamepad) Axis(a PadAxis) float32 {
+	if a < 0 || a >= PadAxes {
+		return 0
+	}
+	return g.cur.axes[a]
+}
+
+func (g *Gamepad) prevAxis(a PadAxis) float32 {
+	return g.prev.axes[a]
+}
+
+// GamepadEventFunc is called as gamepads connect and disconnect.
+type GamepadEventFunc func(g *Gamepad, connected bool) error
+
+// Gamepads polls joysticks each frame into gamepads, handling hot-plugging
+// and keeping each gamepad in a player slot across reconnections.
+type Gamepads struct {
+	js       Joysticks
+	mappings *Mappings
+	players  []*Gamepad
+	active   int
+	on       []GamepadEventFunc
+}
+
+func NewGamepads(js Joysticks) *Gamepads {
+	return &Gamepads{
+		js:       js,
+		mappings: NewMappings(),
+		players:  make([]*Gamepad, js.Count()),
+		on:       make([]GamepadEventFunc, 0),
+	}
+}
+
+// SetJoysticks swaps the joystick backend, disconnecting every gamepad.
+func (gs *Gamepads) SetJoysticks(js Joysticks) error {
+	var err error
+	for _, g := range gs.Connected() {
+		if derr := gs.disconnect(g); derr != nil && err == nil {
+			err = derr
+		}
+	}
+	gs.js = js
+	if n := js.Count(); n > len(gs.players) {
+		gs.players = append(gs.players, make([]*Gamepad, n-len(gs.players))...)
+	}
+	return err
+}
+
+func (gs *Gamepads) Mappings() *Mappings {
+	return gs.mappings
+}
+
+// OnEvent adds functions called as gamepads connect and disconnect.
+func (gs *Gamepads) OnEvent(fns ...GamepadEventFunc) {
+	gs.on = append(gs.on, fns...)
+}
+
+// Player is the gamepad of a 1 based player, nil if there has been none.
+func (gs *Gamepads) Player(n int) *Gamepad {
+	if n < 1 || n > len(gs.players) {
+		return nil
+	}
+	return gs.players[n-1]
+}
+
+// Active is the gamepad last used, nil if there is none.
+func (gs *Gamepads) Active() *Gamepad {
+	return gs.players[gs.active]
+}
+
+// Connected is every connected gamepad in player order.
+func (gs *Gamepads) Connected() []*Gamepad {
+	ret := make([]*Gamepad, 0)
+	for _, g := range gs.players {
+		if g != nil && g.Connected() {
+			ret = append(ret, g)
+		}
+	}
+	return ret
+}
+
+// Assign moves a 1 based player's gamepad to another player, swapping
+// with whatever gamepad that player had.
+func (gs *Gamepads) Assign(from, to int) {
+	if from < 1 || from > len(gs.players) || to < 1 || to > len(gs.players) {
+		return
+	}
+	f, t := from-1, to-1
+	gs.players[f], gs.players[t] = gs.players[t], gs.players[f]
+	for _, i := range []int{f, t} {
+		if g := gs.players[i]; g != nil {
+			g.player = i
+		}
+	}
+	switch gs.active {
+	case f:
+		gs.active = t
+	case t:
+		gs.active = f
+	}
+}
+
+func (gs *Gamepads) byJoystick(j int) *Gamepad {
+	for _, g := range gs.players {
+		if g != nil && g.joystick == j {
+			return g
+		}
+	}
+	return nil
+}
+
+// slot is the player slot for a newly connected joystick: the one it had if
+// it was connected before, else the first never used, else the first left
+// by a disconnected gamepad.
+func (gs *Gamepads) slot(name, guid string) int {
+	free, gone := -1, -1
+	for i, g := range gs.players {
+		switch {
+		case g == nil:
+			if free < 0 {
+				free = i
+			}
+		case !g.Connected() && g.name == name && g.guid == guid:
+			return i
+		case !g.Connected() && gone < 0:
+			gone = i
+		}
+	}
+	if free >= 0 {
+		return free
+	}
+	return gone
+}
+
+func (gs *Gamepads) connect(j int) error {
+	name, guid := gs.js.Name(j), gs.js.GUID(j)
+	i := gs.slot(name, guid)
+	if i < 0 {
+		return nil
+	}
+	// the slot's gamepad is reused, so those holding it see it return
+	g := gs.players[i]
+	if g == nil {
+		g = &Gamepad{player: i}
+		gs.players[i] = g
+	}
+	g.joystick, g.name, g.guid = j, name, guid
+	g.mapping = gs.mappings.Find(guid, name)
+	return gs.event(g, true)
+}
+
+func (gs *Gamepads) disconnect(g *Gamepad) error {
+	g.joystick = -1
+	g.prev, g.cur = padState{}, padState{}
+	return gs.event(g, false)
+}
+
+func (gs *Gamepads) event(g *Gamepad, connected bool) error {
+	var err error
+	for _, fn := range gs.on {
+		if ferr := fn(g, connected); ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// Remap finds the mappings of connected gamepads again, after mappings are
+// added.
+func (gs *Gamepads) Remap() {
+	for _, g := range gs.Connected() {
+		g.mapping = gs.mappings.Find(g.guid, g.name)
+	}
+}
+
+// poll reads every joystick, connecting and disconnecting gamepads as
+// joysticks come and go, returning the first error of the event functions.
+func (gs *Gamepads) poll() error {
+	var err error
+	for j := 0; j < gs.js.Count(); j++ {
+		g := gs.byJoystick(j)
+		present := gs.js.Present(j)
+		var perr error
+		switch {
+		case present && g == nil:
+			perr = gs.connect(j)
+		case !present && g != nil:
+			perr = gs.disconnect(g)
+		}
+		if perr != nil && err == nil {
+			err = perr
+		}
+	}
+	for _, g := range gs.Connected() {
+		g.prev = g.cur
+		g.mapping.apply(gs.js.Axes(g.joystick), gs.js.Buttons(g.joystick), gs.js.Hats(g.joystick), &g.cur)
+		if g.used() {
+			gs.active = g.player
+		}
+	}
+	return err
+}
+
+// used reports a button pressed or an axis pushed past half way this frame.
+func (g *Gamepad) used() bool {
+	for b := PadButton(0); b < PadButtons; b++ {
+		if g.Pressed(b) {
+			return true
+		}
+	}
+	for a := PadAxis(0); a < PadAxes; a++ {
+		if abs(g.Axis(a)) > padThreshold && abs(g.prevAxis(a)) <= padThreshold {
+			return true
+		}
+	}
+	return false
+}
@@ -1,11 +1,13 @@
 package input
 
 import (
-	"github.com/Laughs-In-Flowers/shiva/lib/lua"
 	"github.com/go-gl/glfw/v3.2/glfw"
 )
 
+var registered *glfw.Window
+
 func Register(w *glfw.Window) {
+	registered = w
 	glfw.SetJoystickCallback(JoystickConnectInput.Callback())
 	w.SetKeyCallback(KeyInput.Callback())
 	w.SetCharCallback(CharInput.Callback())
@@ -17,6 +19,21 @@ func Register(w *glfw.Window) {
 	w.SetDropCallback(DropInput.Callback())
 }
 
+// unregister stops glfw feeding the subscribers.
+func unregister() {
+	glfw.SetJoystickCallback(nil)
+	if w := registered; w != nil {
+		w.SetKeyCallback(nil)
+		w.SetCharCallback(nil)
+		w.SetCharModsCallback(nil)
+		w.SetMouseButtonCallback(nil)
+		w.SetCursorPosCallback(nil)
+		w.SetCursorEnterCallback(nil)
+		w.SetScrollCallback(nil)
+		w.SetDropCallback(nil)
+	}
+}
+
 var JoystickConnectInput *joystickConnectInput
 
 type jcsubscriber interface {
@@ -305,25 +322,81 @@ func (d *dropInput) Subscribe(ss ...dsubscriber) {
 
 var CurrentInputSystem *inputSystem
 
-type inputSystem struct{}
+// CurrentActions maps the devices read by the input system to actions.
+var CurrentActions *Actions
+
+// CurrentGamepads are the gamepads read by the input system.
+var CurrentGamepads *Gamepads
+
+// ClaimFunc reports whether something reading input ahead of gameplay, as
+// a GUI, has the mouse or the keyboard this frame, actions reading neither.
+type ClaimFunc func() (mouse, keyboard bool)
+
+type inputSystem struct {
+	d     *devices
+	a     *Actions
+	frame uint64
+	rec   *Recorder
+	play  *Replay
+	end   func()
+	claim []ClaimFunc
+}
 
 func (is *inputSystem) Priority() int {
-	return 2
+	return 9
 }
 
 func (is *inputSystem) Update(d int64) error {
-	glfw.PollEvents()
-	return nil
+	var err error
+	switch {
+	case is.play != nil:
+		glfw.PollEvents()
+		err = is.play.feed(is.frame)
+	case is.rec != nil:
+		is.rec.begin(is.frame)
+		glfw.PollEvents()
+		is.rec.joysticks(is.d.pads.js)
+	default:
+		glfw.PollEvents()
+	}
+	is.d.pollPads()
+	is.d.frame()
+	is.d.claimMouse, is.d.claimKeys = false, false
+	for _, fn := range is.claim {
+		m, k := fn()
+		is.d.claimMouse = is.d.claimMouse || m
+		is.d.claimKeys = is.d.claimKeys || k
+	}
+	is.a.evaluate()
+	if ferr := is.d.endFrame(); ferr != nil && err == nil {
+		err = ferr
+	}
+	if is.play != nil && is.play.Done() && is.end != nil {
+		is.end()
+		is.end = nil
+	}
+	is.frame++
+	if is.rec != nil {
+		// events arriving between updates are read by the next one
+		is.rec.frame = is.frame
+	}
+	return err
 }
 
-func (is *inputSystem) Remove(uint64) {}
+// Frame is the number of input updates so far, counted from the start of
+// any recording or replay.
+func (is *inputSystem) Frame() uint64 {
+	return is.frame
+}
 
-func RegisterWith() lua.RegisterWith {
-	return func(m lua.Module) error {
-		return nil
-	}
+// Claim adds what decides each frame whether actions see the mouse and
+// keyboard, any one claiming them keeping them from actions.
+func (is *inputSystem) Claim(fn ClaimFunc) {
+	is.claim = append(is.claim, fn)
 }
 
+func (is *inputSystem) Remove(uint64) {}
+
 func init() {
 	JoystickConnectInput = &joystickConnectInput{}
 	KeyInput = &keyInput{}
@@ -338,5 +411,12 @@ func init() {
 	ScrollInput = &scrollInput{}
 	DropInput = &dropInput{}
 
-	CurrentInputSystem = &inputSystem{}
+	d := newDevices()
+	KeyInput.Subscribe(d)
+	MouseButtonInput.Subscribe(d)
+	CursorPositionInput.Subscribe(d)
+	ScrollInput.Subscribe(d)
+	CurrentGamepads = d.pads
+	CurrentActions = newActions(d)
+	CurrentInputSystem = &inputSystem{d: d, a: CurrentActions}
 }
@@ -0,0 +1,147 @@
+package input
+
+import (
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// Joysticks is where raw joystick state is read from each frame.
+type Joysticks interface {
+	Count() int
+	Present(int) bool
+	Name(int) string
+	GUID(int) string
+	Axes(int) []float32
+	Buttons(int) []bool
+	Hats(int) []int
+}
+
+// glfwJoysticks reads joysticks through glfw. glfw 3.2 reports neither
+// GUIDs nor hats, so mappings match by name and hats arrive as axes.
+type glfwJoysticks struct{}
+
+func (g glfwJoysticks) Count() int {
+	return int(glfw.JoystickLast) + 1
+}
+
+func (g glfwJoysticks) Present(j int) bool {
+	return glfw.JoystickPresent(glfw.Joystick(j))
+}
+
+func (g glfwJoysticks) Name(j int) string {
+	return glfw.GetJoystickName(glfw.Joystick(j))
+}
+
+func (g glfwJoysticks) GUID(int) string {
+	return ""
+}
+
+func (g glfwJoysticks) Axes(j int) []float32 {
+	return glfw.GetJoystickAxes(glfw.Joystick(j))
+}
+
+func (g glfwJoysticks) Buttons(j int) []bool {
+	raw := glfw.GetJoystickButtons(glfw.Joystick(j))
+	ret := make([]bool, len(raw))
+	for i, b := range raw {
+		ret[i] = b == byte(glfw.Press)
+	}
+	return ret
+}
+
+func (g glfwJoysticks) Hats(int) []int {
+	return nil
+}
+
+// Hat directions, combined for diagonals.
+const (
+	HatUp    = 1
+	HatRight = 2
+	HatDown  = 4
+	HatLeft  = 8
+)
+
+// FakeJoystick is the state of one joystick of FakeJoysticks.
+type FakeJoystick struct {
+	Name    string
+	GUID    string
+	Axes    []float32
+	Buttons []bool
+	Hats    []int
+}
+
+// FakeJoysticks is a joystick backend set by hand, for tests and for
+// driving gamepads from recorded or scripted input.
+type FakeJoysticks struct {
+	js []*FakeJoystick
+}
+
+func NewFakeJoysticks(count int) *FakeJoysticks {
+	return &FakeJoysticks{js: make([]*FakeJoystick, count)}
+}
+
+// Connect plugs a joystick into slot j, returning it for setting state.
+func (f *FakeJoysticks) Connect(j int, name, guid string, axes, buttons, hats int) *FakeJoystick {
+	fj := &FakeJoystick{
+		Name:    name,
+		GUID:    guid,
+		Axes:    make([]float32, axes),
+		Buttons: make([]bool, buttons),
+		Hats:    make([]int, hats),
+	}
+	f.js[j] = fj
+	return fj
+}
+
+func (f *FakeJoysticks) Disconnect(j int) {
+	f.js[j] = nil
+}
+
+func (f *FakeJoysticks) Joystick(j int) *FakeJoystick {
+	if j < 0 || j >= len(f.js) {
+		return nil
+	}
+	return f.js[j]
+}
+
+func (f *FakeJoysticks) Count() int {
+	return len(f.js)
+}
+
+func (f *FakeJoysticks) Present(j int) bool {
+	return f.Joystick(j) != nil
+}
+
+func (f *FakeJoysticks) Name(j int) string {
+	if fj := f.Joystick(j); fj != nil {
+		return fj.Name
+	}
+	return ""
+}
+
+func (f *FakeJoysticks) GUID(j int) string {
+	if fj := f.Joystick(j); fj != nil {
+		return fj.GUID
+	}
+	return ""
+}
+
+func (f *FakeJoysticks) Axes(j int) []float32 {
+	if fj := f.Joystick(j); fj != nil {
+		return fj.Axes
+	}
+	return nil
+}
+
+func (f *FakeJoysticks) Buttons(j int) []bool {
+	if fj := f.Joystick(j); fj != nil {
+		return fj.Buttons
+	}
+	return nil
+}
+
+func (f *FakeJoysticks) Hats(j int) []int {
+	if fj := f.Joystick(j); fj != nil {
+		return fj.Hats
+	}
+	return nil
+}
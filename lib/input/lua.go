@@ -0,0 +1,216 @@
+package input
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+// bindingFrom reads "key:space" or {"pad:axis:0", scale = -1, deadzone = 0.2,
+// sensitivity = 2}.
+func bindingFrom(L *l.LState, v l.LValue) *Binding {
+	switch t := v.(type) {
+	case l.LString:
+		b, err := ParseBinding(string(t))
+		if err != nil {
+			L.RaiseError(err.Error())
+			return nil
+		}
+		return b
+	case *l.LTable:
+		s, ok := t.RawGetInt(1).(l.LString)
+		if !ok {
+			s, ok = t.RawGetString("input").(l.LString)
+		}
+		if !ok {
+			L.RaiseError("binding table has no input")
+			return nil
+		}
+		b, err := ParseBinding(string(s))
+		if err != nil {
+			L.RaiseError(err.Error())
+			return nil
+		}
+		if n, ok := t.RawGetString("scale").(l.LNumber); ok {
+			b.Scale = float32(n)
+		}
+		if n, ok := t.RawGetString("sensitivity").(l.LNumber); ok {
+			b.Scale *= float32(n)
+		}
+		if n, ok := t.RawGetString("deadzone").(l.LNumber); ok {
+			b.DeadZone = float32(n)
+		}
+		return b
+	}
+	L.RaiseError("binding string or table expected")
+	return nil
+}
+
+// singleBinding tells {"key:a", scale = -1} from a list of bindings.
+func singleBinding(t *l.LTable) bool {
+	for _, k := range []string{"input", "scale", "sensitivity", "deadzone"} {
+		if t.RawGetString(k) != l.LNil {
+			return true
+		}
+	}
+	return false
+}
+
+func contextOpt(L *l.LState, pos int) string {
+	if t := L.OptTable(pos, nil); t != nil {
+		if s, ok := t.RawGetString("context").(l.LString); ok {
+			return string(s)
+		}
+	}
+	return DefaultContext
+}
+
+// shv.bind("move_x", {"key:d", {"key:a", scale = -1}, {"pad:axis:0", deadzone = 0.2}}, {context = "gameplay"})
+func lBind(L *l.LState) int {
+	name := L.CheckString(1)
+	bs := make([]*Binding, 0)
+	switch v := L.CheckAny(2).(type) {
+	case l.LString:
+		bs = append(bs, bindingFrom(L, v))
+	case *l.LTable:
+		if singleBinding(v) {
+			bs = append(bs, bindingFrom(L, v))
+		} else {
+			for i := 1; i <= v.Len(); i++ {
+				bs = append(bs, bindingFrom(L, v.RawGetInt(i)))
+			}
+		}
+	default:
+		L.ArgError(2, "binding or list of bindings expected")
+		return 0
+	}
+	CurrentActions.Bind(contextOpt(L, 3), name, bs...)
+	return 0
+}
+
+// shv.unbind("jump", {context = "gameplay"})
+func lUnbind(L *l.LState) int {
+	CurrentActions.Unbind(contextOpt(L, 2), L.CheckString(1))
+	return 0
+}
+
+func lPressed(L *l.LState) int {
+	L.Push(l.LBool(CurrentActions.Pressed(L.CheckString(1))))
+	return 1
+}
+
+func lHeld(L *l.LState) int {
+	L.Push(l.LBool(CurrentActions.Held(L.CheckString(1))))
+	return 1
+}
+
+func lReleased(L *l.LState) int {
+	L.Push(l.LBool(CurrentActions.Released(L.CheckString(1))))
+	return 1
+}
+
+func lValue(L *l.LState) int {
+	L.Push(l.LNumber(CurrentActions.Value(L.CheckString(1))))
+	return 1
+}
+
+// shv.bindings("jump", {context = "gameplay"}) lists the binding strings
+func lBindings(L *l.LState) int {
+	t := L.NewTable()
+	if a := CurrentActions.Context(contextOpt(L, 2)).Action(L.CheckString(1)); a != nil {
+		for _, b := range a.Bindings {
+			t.Append(l.LString(b.String()))
+		}
+	}
+	L.Push(t)
+	return 1
+}
+
+// shv.push_context("menu", {transparent = false})
+func lPushContext(L *l.LState) int {
+	name := L.CheckString(1)
+	transparent := false
+	if t := L.OptTable(2, nil); t != nil {
+		transparent = l.LVAsBool(t.RawGetString("transparent"))
+	}
+	CurrentActions.Push(name, transparent)
+	return 0
+}
+
+func lPopContext(L *l.LState) int {
+	if c := CurrentActions.Pop(); c != nil {
+		L.Push(l.LString(c.Name))
+		return 1
+	}
+	return 0
+}
+
+// shv.context() is the top context name and the whole stack, bottom first
+func lContext(L *l.LState) int {
+	L.Push(l.LString(CurrentActions.Top().Name))
+	t := L.NewTable()
+	for _, n := range CurrentActions.Stack() {
+		t.Append(l.LString(n))
+	}
+	L.Push(t)
+	return 2
+}
+
+func lLoadBindings(L *l.LState) int {
+	path := L.CheckString(1)
+	if err := CurrentActions.Load(path); err != nil {
+		L.RaiseError("error loading bindings %s: %s", path, err)
+	}
+	return 0
+}
+
+func lSaveBindings(L *l.LState) int {
+	path := L.CheckString(1)
+	if err := CurrentActions.Save(path); err != nil {
+		L.RaiseError("error saving bindings %s: %s", path, err)
+	}
+	return 0
+}
+
+// shv.capture(function(binding) shv.bind("jump", binding) end) hands the
+// next input pressed, as its binding string, to the function
+func lCapture(L *l.LState) int {
+	fn := L.CheckFunction(1)
+	CurrentActions.Capture(func(b *Binding) error {
+		return L.CallByParam(l.P{
+			Fn:      fn,
+			NRet:    0,
+			Protect: true,
+		}, l.LString(b.String()))
+	})
+	return 0
+}
+
+func RegisterWith() lua.RegisterWith {
+	return func(m lua.Module) error {
+		m.AddLGFunc("bind", lBind)
+		m.AddLGFunc("unbind", lUnbind)
+		m.AddLGFunc("bindings", lBindings)
+		m.AddLGFunc("pressed", lPressed)
+		m.AddLGFunc("held", lHeld)
+		m.AddLGFunc("released", lReleased)
+		m.AddLGFunc("value", lValue)
+		m.AddLGFunc("push_context", lPushContext)
+		m.AddLGFunc("pop_context", lPopContext)
+		m.AddLGFunc("context", lContext)
+		m.AddLGFunc("load_bindings", lLoadBindings)
+		m.AddLGFunc("save_bindings", lSaveBindings)
+		m.AddLGFunc("capture", lCapture)
+		m.AddLGFunc("gamepad", lGamepad)
+		m.AddLGFunc("gamepads", lGamepads)
+		m.AddLGFunc("on_gamepad", lOnGamepad)
+		m.AddLGFunc("gamepad_mappings", lGamepadMappings)
+		m.AddLGFunc("gamepad_mapping", lGamepadMapping)
+		m.AddLGFunc("assign_gamepad", lAssignGamepad)
+		rmtfn := func(L *l.LState, M lua.Module) {
+			M.Register(L, gamepadTable)
+		}
+		m.AddMT(rmtfn)
+		return nil
+	}
+}
@@ -0,0 +1,190 @@
+package input
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+const lGamepadClass = "GAMEPAD"
+
+func pushGamepad(L *l.LState, g *Gamepad) int {
+	if g == nil {
+		L.Push(l.LNil)
+		return 1
+	}
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = g }, lGamepadClass)
+	return 1
+}
+
+// shv.gamepad(1) is player 1's gamepad, shv.gamepad() the one used last, nil
+// if there is none
+func lGamepad(L *l.LState) int {
+	if L.GetTop() == 0 {
+		return pushGamepad(L, CurrentGamepads.Active())
+	}
+	return pushGamepad(L, CurrentGamepads.Player(L.CheckInt(1)))
+}
+
+// shv.gamepads() lists the connected gamepads in player order
+func lGamepads(L *l.LState) int {
+	t := L.NewTable()
+	for _, g := range CurrentGamepads.Connected() {
+		pushGamepad(L, g)
+		t.Append(L.Get(-1))
+		L.Pop(1)
+	}
+	L.Push(t)
+	return 1
+}
+
+// shv.on_gamepad(function(gamepad, connected) end)
+func lOnGamepad(L *l.LState) int {
+	fn := L.CheckFunction(1)
+	CurrentGamepads.OnEvent(func(g *Gamepad, connected bool) error {
+		pushGamepad(L, g)
+		ud := L.Get(-1)
+		L.Pop(1)
+		return L.CallByParam(l.P{
+			Fn:      fn,
+			NRet:    0,
+			Protect: true,
+		}, ud, l.LBool(connected))
+	})
+	return 0
+}
+
+// shv.gamepad_mappings("gamecontrollerdb.txt")
+func lGamepadMappings(L *l.LState) int {
+	path := L.CheckString(1)
+	if err := CurrentGamepads.Mappings().Load(path); err != nil {
+		L.RaiseError("error loading gamepad mappings %s: %s", path, err)
+		return 0
+	}
+	CurrentGamepads.Remap()
+	return 0
+}
+
+// shv.gamepad_mapping("GUID,name,a:b0,...") adds a single mapping line
+func lGamepadMapping(L *l.LState) int {
+	if err := CurrentGamepads.Mappings().Add(L.CheckString(1)); err != nil {
+		L.RaiseError(err.Error())
+		return 0
+	}
+	CurrentGamepads.Remap()
+	return 0
+}
+
+// shv.assign_gamepad(from, to) swaps the gamepads of two players
+func lAssignGamepad(L *l.LState) int {
+	CurrentGamepads.Assign(L.CheckInt(1), L.CheckInt(2))
+	return 0
+}
+
+func checkGamepad(L *l.LState, pos int) *Gamepad {
+	ud := L.CheckUserData(pos)
+	if g, ok := ud.Value.(*Gamepad); ok {
+		return g
+	}
+	L.ArgError(pos, "gamepad expected")
+	return nil
+}
+
+type gamepadMemberFunc func(*l.LState, *Gamepad) int
+
+func gamepadMember(fn gamepadMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if g := checkGamepad(L, 1); g != nil {
+			return fn(L, g)
+		}
+		return 0
+	}
+}
+
+func gamepadProperty(get gamepadMemberFunc) l.LGFunction {
+	return lua.NewProperty(gamepadMember(get), nil)
+}
+
+func checkPadButton(L *l.LState, pos int) PadButton {
+	b, ok := StringToPadButton(L.CheckString(pos))
+	if !ok {
+		L.ArgError(pos, "gamepad button expected")
+	}
+	return b
+}
+
+func checkPadAxis(L *l.LState, pos int) PadAxis {
+	a, ok := StringToPadAxis(L.CheckString(pos))
+	if !ok {
+		L.ArgError(pos, "gamepad axis expected")
+	}
+	return a
+}
+
+// gamepad:button("a")
+func gamepadButton(L *l.LState, g *Gamepad) int {
+	L.Push(l.LBool(g.Button(checkPadButton(L, 2))))
+	return 1
+}
+
+func gamepadPressed(L *l.LState, g *Gamepad) int {
+	L.Push(l.LBool(g.Pressed(checkPadButton(L, 2))))
+	return 1
+}
+
+func gamepadReleased(L *l.LState, g *Gamepad) int {
+	L.Push(l.LBool(g.Released(checkPadButton(L, 2))))
+	return 1
+}
+
+// gamepad:axis("leftx")
+func gamepadAxis(L *l.LState, g *Gamepad) int {
+	L.Push(l.LNumber(g.Axis(checkPadAxis(L, 2))))
+	return 1
+}
+
+func getGamepadPlayer(L *l.LState, g *Gamepad) int {
+	L.Push(l.LNumber(g.Player()))
+	return 1
+}
+
+func getGamepadName(L *l.LState, g *Gamepad) int {
+	L.Push(l.LString(g.Name()))
+	return 1
+}
+
+func getGamepadGUID(L *l.LState, g *Gamepad) int {
+	L.Push(l.LString(g.GUID()))
+	return 1
+}
+
+func getGamepadConnected(L *l.LState, g *Gamepad) int {
+	L.Push(l.LBool(g.Connected()))
+	return 1
+}
+
+func getGamepadMapped(L *l.LState, g *Gamepad) int {
+	L.Push(l.LBool(g.Mapped()))
+	return 1
+}
+
+var gamepadTable = &lua.Table{
+	lGamepadClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+	},
+	map[string]l.LGFunction{
+		"player":    gamepadProperty(getGamepadPlayer),
+		"name":      gamepadProperty(getGamepadName),
+		"guid":      gamepadProperty(getGamepadGUID),
+		"connected": gamepadProperty(getGamepadConnected),
+		"mapped":    gamepadProperty(getGamepadMapped),
+	},
+	map[string]l.LGFunction{
+		"button":   gamepadMember(gamepadButton),
+		"pressed":  gamepadMember(gamepadPressed),
+		"released": gamepadMember(gamepadReleased),
+		"axis":     gamepadMember(gamepadAxis),
+	},
+}
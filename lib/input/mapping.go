@@ -0,0 +1,262 @@
+package input
+
+import (
+	"bufio"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+type sourceT int
+
+const (
+	srcButton sourceT = iota
+	srcAxis
+	srcHat
+)
+
+// element maps one joystick input to one gamepad button or axis. Half is
+// +1 or -1 for the half of an axis used or fed, 0 for all of it.
+type element struct {
+	button   bool
+	target   int
+	half     int
+	src      sourceT
+	index    int
+	hatMask  int
+	srcHalf  int
+	inverted bool
+}
+
+// Mapping turns a joystick's raw inputs into the gamepad layout, read from
+// an SDL GameControllerDB line:
+//
+//	GUID,name,a:b0,b:b1,leftx:a0,lefty:a1,dpup:h0.1,lefttrigger:+a2,platform:Linux,
+type Mapping struct {
+	GUID     string
+	Name     string
+	Platform string
+	elements []element
+}
+
+var (
+	InvalidMappingError = xrror.Xrror("invalid gamepad mapping %s: %s").Out
+)
+
+// ParseMapping reads one GameControllerDB line.
+func ParseMapping(line string) (*Mapping, error) {
+	fields := strings.Split(strings.TrimSpace(line), ",")
+	if len(fields) < 2 {
+		return nil, InvalidMappingError(line, "too few fields")
+	}
+	m := &Mapping{GUID: fields[0], Name: fields[1], elements: make([]element, 0)}
+	for _, f := range fields[2:] {
+		if f == "" {
+			continue
+		}
+		kv := strings.SplitN(f, ":", 2)
+		if len(kv) != 2 {
+			return nil, InvalidMappingError(line, f)
+		}
+		if kv[0] == "platform" {
+			m.Platform = kv[1]
+			continue
+		}
+		e, ok, err := parseElement(kv[0], kv[1])
+		if err != nil {
+			return nil, InvalidMappingError(line, err)
+		}
+		if ok {
+			m.elements = append(m.elements, e)
+		}
+	}
+	return m, nil
+}
+
+// parseElement reads target:source, skipping targets outside the layout.
+func parseElement(target, source string) (element, bool, error) {
+	var e element
+	switch {
+	case strings.HasPrefix(target, "+"):
+		e.half, target = 1, target[1:]
+	case strings.HasPrefix(target, "-"):
+		e.half, target = -1, target[1:]
+	}
+	if b, ok := padButtonNames[target]; ok {
+		e.button, e.target = true, int(b)
+	} else if a, ok := padAxisNames[target]; ok {
+		e.target = int(a)
+	} else {
+		return e, false, nil
+	}
+	switch {
+	case strings.HasPrefix(source, "+"):
+		e.srcHalf, source = 1, source[1:]
+	case strings.HasPrefix(source, "-"):
+		e.srcHalf, source = -1, source[1:]
+	}
+	if strings.HasSuffix(source, "~") {
+		e.inverted, source = true, source[:len(source)-1]
+	}
+	if len(source) < 2 {
+		return e, false, InvalidMappingError(target, source)
+	}
+	var err error
+	switch source[0] {
+	case 'b':
+		e.src = srcButton
+		e.index, err = strconv.Atoi(source[1:])
+	case 'a':
+		e.src = srcAxis
+		e.index, err = strconv.Atoi(source[1:])
+	case 'h':
+		e.src = srcHat
+		hm := strings.SplitN(source[1:], ".", 2)
+		if len(hm) != 2 {
+			return e, false, InvalidMappingError(target, source)
+		}
+		if e.index, err = strconv.Atoi(hm[0]); err == nil {
+			e.hatMask, err = strconv.Atoi(hm[1])
+		}
+	default:
+		return e, false, InvalidMappingError(target, source)
+	}
+	return e, true, err
+}
+
+// apply reads a joystick into the gamepad state through the mapping.
+func (m *Mapping) apply(axes []float32, buttons []bool, hats []int, g *padState) {
+	*g = padState{}
+	for _, e := range m.elements {
+		var v float32
+		analog := false
+		switch e.src {
+		case srcButton:
+			if e.index < len(buttons) && buttons[e.index] {
+				v = 1
+			}
+		case srcHat:
+			if e.index < len(hats) && hats[e.index]&e.hatMask != 0 {
+				v = 1
+			}
+		case srcAxis:
+			if e.index < len(axes) {
+				v, analog = axes[e.index], true
+			}
+			if e.inverted {
+				v = -v
+			}
+			switch e.srcHalf {
+			case 1:
+				v = clamp(v, 0, 1)
+			case -1:
+				v = clamp(-v, 0, 1)
+			}
+		}
+		if e.button {
+			if v > padThreshold {
+				g.buttons[e.target] = true
+			}
+			continue
+		}
+		switch {
+		case e.half != 0:
+			g.axes[e.target] += float32(e.half) * clamp(v, 0, 1)
+		case PadAxis(e.target) == PadLeftTrigger || PadAxis(e.target) == PadRightTrigger:
+			// triggers rest at -1 on the joystick, at 0 on the gamepad
+			if analog && e.srcHalf == 0 {
+				v = (v + 1) / 2
+			}
+			g.axes[e.target] = clamp(v, 0, 1)
+		case analog && e.srcHalf != 0:
+			g.axes[e.target] = v*2 - 1
+		default:
+			g.axes[e.target] = v
+		}
+	}
+	for i := range g.axes {
+		g.axes[i] = clamp(g.axes[i], -1, 1)
+	}
+}
+
+// defaultMapping is the Linux xpad layout, used for joysticks with no
+// mapping, with the d-pad hat reported as axes 6 and 7.
+const defaultMapping = "default,default,a:b0,b:b1,x:b2,y:b3,leftshoulder:b4,rightshoulder:b5,back:b6,start:b7,guide:b8,leftstick:b9,rightstick:b10,leftx:a0,lefty:a1,lefttrigger:a2,rightx:a3,righty:a4,righttrigger:a5,dpleft:-a6,dpright:+a6,dpup:-a7,dpdown:+a7,"
+
+// Mappings is a GameControllerDB, mappings found by GUID and, as glfw 3.2
+// does not report GUIDs, by name.
+type Mappings struct {
+	byGUID map[string]*Mapping
+	byName map[string]*Mapping
+	def    *Mapping
+}
+
+func NewMappings() *Mappings {
+	def, _ := ParseMapping(defaultMapping)
+	return &Mappings{
+		byGUID: make(map[string]*Mapping),
+		byName: make(map[string]*Mapping),
+		def:    def,
+	}
+}
+
+// sdlPlatform is the platform name GameControllerDB uses for this one.
+func sdlPlatform() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "Windows"
+	case "darwin":
+		return "Mac OS X"
+	case "android":
+		return "Android"
+	}
+	return "Linux"
+}
+
+// Add adds a mapping line, ignoring mappings for other platforms.
+func (ms *Mappings) Add(line string) error {
+	m, err := ParseMapping(line)
+	if err != nil {
+		return err
+	}
+	if m.Platform != "" && m.Platform != sdlPlatform() {
+		return nil
+	}
+	ms.byGUID[m.GUID] = m
+	ms.byName[m.Name] = m
+	return nil
+}
+
+// Load adds every mapping of a GameControllerDB file.
+func (ms *Mappings) Load(path string) error {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := ms.Add(line); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+// Find is the mapping for a joystick, the default mapping if none matches.
+func (ms *Mappings) Find(guid, name string) *Mapping {
+	if m, ok := ms.byGUID[guid]; ok && guid != "" {
+		return m
+	}
+	if m, ok := ms.byName[name]; ok {
+		return m
+	}
+	return ms.def
+}
@@ -0,0 +1,384 @@
+package input
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// ChecksumFunc sums whatever state a replay should reproduce.
+type ChecksumFunc func() uint64
+
+// recordVersion is written in the header of every recording.
+const recordVersion = 1
+
+// event is one line of a recording: an input event, a joystick state, a
+// checksum or the header, all tagged with the input frame they happened in.
+type event struct {
+	Frame  uint64    `json:"f"`
+	Kind   string    `json:"k"`
+	Ints   []int     `json:"i,omitempty"`
+	Floats []float64 `json:"v,omitempty"`
+	Strs   []string  `json:"s,omitempty"`
+	Bools  []bool    `json:"b,omitempty"`
+}
+
+var (
+	NotRecordingError = xrror.Xrror("%s is not an input recording").Out
+	DivergenceError   = xrror.Xrror("replay diverged at frame %d: checksum %s, recorded %s").Out
+)
+
+// Recorder writes every input event, the joystick state as it changes and
+// a checksum each frame to a file, a JSON object a line.
+type Recorder struct {
+	f     *os.File
+	w     *bufio.Writer
+	enc   *json.Encoder
+	frame uint64
+	sum   ChecksumFunc
+	pads  []*FakeJoystick
+	err   error
+}
+
+func newRecorder(path string, step int64, sum ChecksumFunc) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	r := &Recorder{f: f, w: w, enc: json.NewEncoder(w), sum: sum}
+	r.write(&event{Kind: "shiva", Ints: []int{recordVersion, int(step)}})
+	return r, r.err
+}
+
+func (r *Recorder) write(e *event) {
+	if r == nil || r.err != nil {
+		return
+	}
+	e.Frame = r.frame
+	r.err = r.enc.Encode(e)
+}
+
+// begin starts a frame, summing the state the frames before it left.
+func (r *Recorder) begin(frame uint64) {
+	r.frame = frame
+	if r.sum != nil {
+		r.write(&event{Kind: "sum", Strs: []string{strconv.FormatUint(r.sum(), 16)}})
+	}
+}
+
+// joysticks records the joysticks that changed since the last frame.
+func (r *Recorder) joysticks(js Joysticks) {
+	if len(r.pads) < js.Count() {
+		r.pads = append(r.pads, make([]*FakeJoystick, js.Count()-len(r.pads))...)
+	}
+	for j := 0; j < js.Count(); j++ {
+		var now *FakeJoystick
+		if js.Present(j) {
+			now = &FakeJoystick{
+				Name:    js.Name(j),
+				GUID:    js.GUID(j),
+				Axes:    append([]float32{}, js.Axes(j)...),
+				Buttons: append([]bool{}, js.Buttons(j)...),
+				Hats:    append([]int{}, js.Hats(j)...),
+			}
+		}
+		if reflect.DeepEqual(now, r.pads[j]) {
+			continue
+		}
+		r.pads[j] = now
+		if now == nil {
+			r.write(&event{Kind: "pad", Ints: []int{j}})
+			continue
+		}
+		axes := make([]float64, len(now.Axes))
+		for i, a := range now.Axes {
+			axes[i] = float64(a)
+		}
+		r.write(&event{
+			Kind:   "pad",
+			Ints:   append([]int{j}, now.Hats...),
+			Floats: axes,
+			Strs:   []string{now.Name, now.GUID},
+			Bools:  now.Buttons,
+		})
+	}
+}
+
+func (r *Recorder) KEvent(k glfw.Key, s int, a glfw.Action, m glfw.ModifierKey) {
+	r.write(&event{Kind: "key", Ints: []int{int(k), s, int(a), int(m)}})
+}
+
+func (r *Recorder) CIEvent(c rune) {
+	r.write(&event{Kind: "char", Ints: []int{int(c)}})
+}
+
+func (r *Recorder) CMEvent(c rune, m glfw.ModifierKey) {
+	r.write(&event{Kind: "charmod", Ints: []int{int(c), int(m)}})
+}
+
+func (r *Recorder) MBEvent(b glfw.MouseButton, a glfw.Action, m glfw.ModifierKey) {
+	r.write(&event{Kind: "mouse", Ints: []int{int(b), int(a), int(m)}})
+}
+
+func (r *Recorder) CPEvent(x, y float64) {
+	r.write(&event{Kind: "cursor", Floats: []float64{x, y}})
+}
+
+func (r *Recorder) CEEvent(entered bool) {
+	r.write(&event{Kind: "enter", Bools: []bool{entered}})
+}
+
+func (r *Recorder) SEvent(x, y float64) {
+	r.write(&event{Kind: "scroll", Floats: []float64{x, y}})
+}
+
+func (r *Recorder) DEvent(paths []string) {
+	r.write(&event{Kind: "drop", Strs: paths})
+}
+
+func (r *Recorder) JCEvent(joy, ev int) {
+	r.write(&event{Kind: "joystick", Ints: []int{joy, ev}})
+}
+
+// Close writes the last frame recorded and closes the file.
+func (r *Recorder) Close() error {
+	if r.frame > 0 {
+		r.frame--
+	}
+	r.write(&event{Kind: "end"})
+	if err := r.w.Flush(); err != nil && r.err == nil {
+		r.err = err
+	}
+	if err := r.f.Close(); err != nil && r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
+
+// Replay feeds a recording back through the input subscribers in place of
+// glfw, frame by frame, with joysticks read from the recording.
+type Replay struct {
+	events []*event
+	next   int
+	step   int64
+	sum    ChecksumFunc
+	js     *FakeJoysticks
+	done   bool
+}
+
+func openReplay(path string, sum ChecksumFunc) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(bufio.NewReader(f))
+	events := make([]*event, 0)
+	for {
+		e := &event{}
+		if err := dec.Decode(e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if len(events) == 0 || events[0].Kind != "shiva" || len(events[0].Ints) != 2 || events[0].Ints[0] != recordVersion {
+		return nil, NotRecordingError(path)
+	}
+	return &Replay{
+		events: events[1:],
+		step:   int64(events[0].Ints[1]),
+		sum:    sum,
+		js:     NewFakeJoysticks(int(glfw.JoystickLast) + 1),
+	}, nil
+}
+
+// Step is the fixed frame step, in nanoseconds, the recording was made with.
+func (p *Replay) Step() int64 {
+	return p.step
+}
+
+// Done reports the recording played to its end.
+func (p *Replay) Done() bool {
+	return p.done
+}
+
+// feed dispatches the events of a frame, returning an error on the first
+// checksum not matching the recording.
+func (p *Replay) feed(frame uint64) error {
+	var err error
+	for ; p.next < len(p.events) && p.events[p.next].Frame <= frame; p.next++ {
+		e := p.events[p.next]
+		if e.Frame < frame {
+			continue
+		}
+		if ferr := p.dispatch(e); ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+func (p *Replay) dispatch(e *event) error {
+	i := func(n int) int {
+		if n < len(e.Ints) {
+			return e.Ints[n]
+		}
+		return 0
+	}
+	v := func(n int) float64 {
+		if n < len(e.Floats) {
+			return e.Floats[n]
+		}
+		return 0
+	}
+	switch e.Kind {
+	case "sum":
+		if p.sum != nil && len(e.Strs) == 1 {
+			if got := strconv.FormatUint(p.sum(), 16); got != e.Strs[0] {
+				return DivergenceError(e.Frame, got, e.Strs[0])
+			}
+		}
+	case "key":
+		KeyInput.Callback()(nil, glfw.Key(i(0)), i(1), glfw.Action(i(2)), glfw.ModifierKey(i(3)))
+	case "char":
+		CharInput.Callback()(nil, rune(i(0)))
+	case "charmod":
+		CharModInput.Callback()(nil, rune(i(0)), glfw.ModifierKey(i(1)))
+	case "mouse":
+		MouseButtonInput.Callback()(nil, glfw.MouseButton(i(0)), glfw.Action(i(1)), glfw.ModifierKey(i(2)))
+	case "cursor":
+		CursorPositionInput.Callback()(nil, v(0), v(1))
+	case "enter":
+		CursorEnterInput.Callback()(nil, len(e.Bools) > 0 && e.Bools[0])
+	case "scroll":
+		ScrollInput.Callback()(nil, v(0), v(1))
+	case "drop":
+		DropInput.Callback()(nil, e.Strs)
+	case "joystick":
+		JoystickConnectInput.Callback()(i(0), i(1))
+	case "pad":
+		j := i(0)
+		if j < 0 || j >= p.js.Count() {
+			return nil
+		}
+		if len(e.Strs) < 2 {
+			p.js.Disconnect(j)
+			return nil
+		}
+		fj := p.js.Connect(j, e.Strs[0], e.Strs[1], len(e.Floats), len(e.Bools), len(e.Ints)-1)
+		for n, a := range e.Floats {
+			fj.Axes[n] = float32(a)
+		}
+		copy(fj.Buttons, e.Bools)
+		copy(fj.Hats, e.Ints[1:])
+	case "end":
+		p.done = true
+	}
+	return nil
+}
+
+// Record writes input to a file from the next frame on, with the fixed step
+// in nanoseconds frames are run at and a checksum of the state to reproduce.
+func (is *inputSystem) Record(path string, step int64, sum ChecksumFunc) error {
+	if is.rec != nil {
+		if err := is.rec.Close(); err != nil {
+			return err
+		}
+	}
+	r, err := newRecorder(path, step, sum)
+	if err != nil {
+		return err
+	}
+	if is.rec == nil {
+		rs := &recording{is}
+		JoystickConnectInput.Subscribe(rs)
+		KeyInput.Subscribe(rs)
+		CharInput.Subscribe(rs)
+		CharModInput.Subscribe(rs)
+		MouseButtonInput.Subscribe(rs)
+		CursorPositionInput.Subscribe(rs)
+		CursorEnterInput.Subscribe(rs)
+		ScrollInput.Subscribe(rs)
+		DropInput.Subscribe(rs)
+	}
+	is.rec, is.frame = r, 0
+	return nil
+}
+
+// Replay plays a recording back in place of glfw and the joysticks,
+// returning the fixed step in nanoseconds it was recorded with. A checksum
+// not matching the recorded one fails the frame update, and end is called
+// once the recording is played out.
+func (is *inputSystem) Replay(path string, sum ChecksumFunc, end func()) (int64, error) {
+	p, err := openReplay(path, sum)
+	if err != nil {
+		return 0, err
+	}
+	unregister()
+	if err := is.d.pads.SetJoysticks(p.js); err != nil {
+		return 0, err
+	}
+	is.play, is.end, is.frame = p, end, 0
+	return p.Step(), nil
+}
+
+// Close ends any recording.
+func (is *inputSystem) Close() error {
+	if is.rec == nil {
+		return nil
+	}
+	err := is.rec.Close()
+	is.rec = nil
+	return err
+}
+
+// recording forwards subscribed events to whatever recorder is current, as
+// subscribers cannot be removed.
+type recording struct {
+	is *inputSystem
+}
+
+func (r *recording) KEvent(k glfw.Key, s int, a glfw.Action, m glfw.ModifierKey) {
+	r.is.rec.KEvent(k, s, a, m)
+}
+
+func (r *recording) CIEvent(c rune) {
+	r.is.rec.CIEvent(c)
+}
+
+func (r *recording) CMEvent(c rune, m glfw.ModifierKey) {
+	r.is.rec.CMEvent(c, m)
+}
+
+func (r *recording) MBEvent(b glfw.MouseButton, a glfw.Action, m glfw.ModifierKey) {
+	r.is.rec.MBEvent(b, a, m)
+}
+
+func (r *recording) CPEvent(x, y float64) {
+	r.is.rec.CPEvent(x, y)
+}
+
+func (r *recording) CEEvent(entered bool) {
+	r.is.rec.CEEvent(entered)
+}
+
+func (r *recording) SEvent(x, y float64) {
+	r.is.rec.SEvent(x, y)
+}
+
+func (r *recording) DEvent(paths []string) {
+	r.is.rec.DEvent(paths)
+}
+
+func (r *recording) JCEvent(joy, ev int) {
+	r.is.rec.JCEvent(joy, ev)
+}
@@ -1,10 +1,12 @@
 package lua
 
 import (
+	"bytes"
 	"fmt"
 	"sort"
 	"strings"
 
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
 	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
 	l "github.com/yuin/gopher-lua"
 )
@@ -136,6 +138,7 @@ var builtIns = []Config{
 	config{1004, lTracebackFunc},
 	config{1005, lModules},
 	config{1006, lDetails},
+	config{1007, lVFSLoader},
 }
 
 var registryIndex *l.LTable
@@ -225,6 +228,43 @@ loopbreak:
 	return 1
 }
 
+// vfsModulePath is where vfsLoader looks for a module in the files mounted
+// in vfs.CurrentFS, the directory of the lua file, packs and what is
+// embedded alike.
+var vfsModulePath = []string{"?.lua", "?/init.lua"}
+
+// vfsLoader finds a module in the files mounted in vfs.CurrentFS, before the
+// loader reading package.path from the OS file system.
+func vfsLoader(L *l.LState) int {
+	name := strings.Replace(L.CheckString(1), ".", "/", -1)
+	messages := []string{}
+	for _, pattern := range vfsModulePath {
+		p := strings.Replace(pattern, "?", name, -1)
+		b, err := vfs.ReadFile(p)
+		if err != nil {
+			messages = append(messages, err.Error())
+			continue
+		}
+		fn, err := L.Load(bytes.NewReader(b), "@"+p)
+		if err != nil {
+			L.RaiseError(err.Error())
+		}
+		L.Push(fn)
+		return 1
+	}
+	L.Push(l.LString(strings.Join(messages, "\n\t")))
+	return 1
+}
+
+func lVFSLoader(L *Lua) error {
+	loaders, ok := L.GetField(registryIndex, "_LOADERS").(*l.LTable)
+	if !ok {
+		return NoTableError("_LOADERS")
+	}
+	loaders.Insert(2, L.NewFunction(vfsLoader))
+	return nil
+}
+
 func lRequireFunc(L *Lua) error {
 	requireFn = L.NewClosure(require)
 	L.SetGlobal("require", requireFn)
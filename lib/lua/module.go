@@ -96,3 +96,12 @@ var defaultModules = []GetModule{
 	func(*Lua) Module { return NewModule("debug", l.OpenDebug) },
 	shvGetM,
 }
+
+// SetSub sets a table of functions on a loaded module, as shv.gui for a
+// module tagged shv.
+func SetSub(L *l.LState, M Module, name string, fns map[string]l.LGFunction) {
+	g := L.FindTable(L.Get(l.RegistryIndex).(*l.LTable), "_LOADED", 1)
+	if mod, ok := L.GetField(g, M.Tag()).(*l.LTable); ok {
+		L.SetField(mod, name, L.SetFuncs(L.NewTable(), fns))
+	}
+}
@@ -0,0 +1,454 @@
+package math
+
+// AABB is an axis aligned box from its Min to its Max corner.
+type AABB struct {
+	Min, Max Vector
+}
+
+// NewAABB is the box with two opposite corners.
+func NewAABB(a, b Vector) *AABB {
+	return &AABB{
+		Vec3(Min(a.Get(0), b.Get(0)), Min(a.Get(1), b.Get(1)), Min(a.Get(2), b.Get(2))),
+		Vec3(Max(a.Get(0), b.Get(0)), Max(a.Get(1), b.Get(1)), Max(a.Get(2), b.Get(2))),
+	}
+}
+
+// AABBFromPoints is the smallest box around points, none for no points.
+func AABBFromPoints(points ...Vector) *AABB {
+	if len(points) == 0 {
+		return nil
+	}
+	b := &AABB{vec3Of(points[0]), vec3Of(points[0])}
+	b.Expand(points[1:]...)
+	return b
+}
+
+// Expand grows the box around points.
+func (b *AABB) Expand(points ...Vector) {
+	for _, p := range points {
+		for i := 0; i < 3; i++ {
+			b.Min.Set(i, Min(b.Min.Get(i), p.Get(i)))
+			b.Max.Set(i, Max(b.Max.Get(i), p.Get(i)))
+		}
+	}
+}
+
+// Union is the smallest box around two boxes.
+func (b *AABB) Union(o *AABB) *AABB {
+	return AABBFromPoints(b.Min, b.Max, o.Min, o.Max)
+}
+
+func (b *AABB) Center() Vector {
+	return b.Min.Add(b.Max).Mul(0.5)
+}
+
+func (b *AABB) Size() Vector {
+	return b.Max.Sub(b.Min)
+}
+
+// Extents is half the size of the box.
+func (b *AABB) Extents() Vector {
+	return b.Size().Mul(0.5)
+}
+
+// Corners are the eight corners of the box, the first bit of an index
+// choosing the max on x, the second on y and the third on z.
+func (b *AABB) Corners() []Vector {
+	ret := make([]Vector, 8)
+	for i := range ret {
+		c := Vec3(b.Min.Get(0), b.Min.Get(1), b.Min.Get(2))
+		for a := 0; a < 3; a++ {
+			if i&(1<<uint(a)) != 0 {
+				c.Set(a, b.Max.Get(a))
+			}
+		}
+		ret[i] = c
+	}
+	return ret
+}
+
+func (b *AABB) Contains(p Vector) bool {
+	for i := 0; i < 3; i++ {
+		if p.Get(i) < b.Min.Get(i) || p.Get(i) > b.Max.Get(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *AABB) ContainsAABB(o *AABB) bool {
+	return b.Contains(o.Min) && b.Contains(o.Max)
+}
+
+func (b *AABB) ContainsSphere(s *Sphere) bool {
+	for i := 0; i < 3; i++ {
+		c := s.Center.Get(i)
+		if c-s.Radius < b.Min.Get(i) || c+s.Radius > b.Max.Get(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *AABB) IntersectsAABB(o *AABB) bool {
+	for i := 0; i < 3; i++ {
+		if b.Max.Get(i) < o.Min.Get(i) || o.Max.Get(i) < b.Min.Get(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *AABB) IntersectsSphere(s *Sphere) bool {
+	d := sub3(b.Closest(s.Center), s.Center)
+	return d.Dot(d) <= s.Radius*s.Radius
+}
+
+func (b *AABB) IntersectsOBB(o *OBB) bool {
+	return o.IntersectsOBB(b.OBB())
+}
+
+func (b *AABB) IntersectsTriangle(t *Triangle) bool {
+	return b.OBB().IntersectsTriangle(t)
+}
+
+// Classify is the side of a plane the box is on.
+func (b *AABB) Classify(p *Plane) Side {
+	e := b.Extents()
+	r := e.Get(0)*Abs(p.Normal.Get(0)) + e.Get(1)*Abs(p.Normal.Get(1)) + e.Get(2)*Abs(p.Normal.Get(2))
+	d := p.Distance(b.Center())
+	return classify(d-r, d+r)
+}
+
+// Closest is the point of the box nearest a point, the point itself inside.
+func (b *AABB) Closest(p Vector) Vector {
+	return Vec3(
+		Clamp(p.Get(0), b.Min.Get(0), b.Max.Get(0)),
+		Clamp(p.Get(1), b.Min.Get(1), b.Max.Get(1)),
+		Clamp(p.Get(2), b.Min.Get(2), b.Max.Get(2)),
+	)
+}
+
+// Distance is how far a point is from the box, 0 inside.
+func (b *AABB) Distance(p Vector) float32 {
+	return sub3(b.Closest(p), p).Len()
+}
+
+// OBB is the box as an oriented one.
+func (b *AABB) OBB() *OBB {
+	return &OBB{b.Center(), [3]Vector{Vec3(1, 0, 0), Vec3(0, 1, 0), Vec3(0, 0, 1)}, b.Extents()}
+}
+
+// Transform is the axis aligned box around the box moved by a matrix.
+func (b *AABB) Transform(m Matrice) *AABB {
+	var lo, hi [3]float32
+	for i := 0; i < 3; i++ {
+		lo[i], hi[i] = m.Get(i, 3), m.Get(i, 3)
+		for j := 0; j < 3; j++ {
+			e, f := m.Get(i, j)*b.Min.Get(j), m.Get(i, j)*b.Max.Get(j)
+			if e > f {
+				e, f = f, e
+			}
+			lo[i] += e
+			hi[i] += f
+		}
+	}
+	return &AABB{Vec3(lo[0], lo[1], lo[2]), Vec3(hi[0], hi[1], hi[2])}
+}
+
+// Sphere is the points within Radius of Center.
+type Sphere struct {
+	Center Vector
+	Radius float32
+}
+
+func NewSphere(center Vector, radius float32) *Sphere {
+	return &Sphere{vec3Of(center), Abs(radius)}
+}
+
+// SphereFromPoints is a sphere around points, about the center of their
+// bounds, none for no points.
+func SphereFromPoints(points ...Vector) *Sphere {
+	b := AABBFromPoints(points...)
+	if b == nil {
+		return nil
+	}
+	s := &Sphere{b.Center(), 0}
+	for _, p := range points {
+		s.Radius = Max(s.Radius, sub3(p, s.Center).Len())
+	}
+	return s
+}
+
+func (s *Sphere) Contains(p Vector) bool {
+	d := sub3(p, s.Center)
+	return d.Dot(d) <= s.Radius*s.Radius
+}
+
+func (s *Sphere) ContainsSphere(o *Sphere) bool {
+	return sub3(o.Center, s.Center).Len()+o.Radius <= s.Radius
+}
+
+func (s *Sphere) IntersectsSphere(o *Sphere) bool {
+	d := sub3(o.Center, s.Center)
+	r := s.Radius + o.Radius
+	return d.Dot(d) <= r*r
+}
+
+func (s *Sphere) IntersectsAABB(b *AABB) bool {
+	return b.IntersectsSphere(s)
+}
+
+func (s *Sphere) IntersectsOBB(b *OBB) bool {
+	return b.IntersectsSphere(s)
+}
+
+func (s *Sphere) IntersectsTriangle(t *Triangle) bool {
+	return s.Contains(t.Closest(s.Center))
+}
+
+// Classify is the side of a plane the sphere is on.
+func (s *Sphere) Classify(p *Plane) Side {
+	d := p.Distance(s.Center)
+	return classify(d-s.Radius, d+s.Radius)
+}
+
+// Closest is the point of the sphere nearest a point, the point itself
+// inside.
+func (s *Sphere) Closest(p Vector) Vector {
+	d := sub3(p, s.Center)
+	l := d.Len()
+	if l <= s.Radius {
+		return vec3Of(p)
+	}
+	return s.Center.Add(d.Mul(s.Radius / l))
+}
+
+// Distance is how far a point is from the sphere, 0 inside.
+func (s *Sphere) Distance(p Vector) float32 {
+	return Max(sub3(p, s.Center).Len()-s.Radius, 0)
+}
+
+// Bounds is the axis aligned box around the sphere.
+func (s *Sphere) Bounds() *AABB {
+	r := Vec3(s.Radius, s.Radius, s.Radius)
+	return &AABB{s.Center.Sub(r), s.Center.Add(r)}
+}
+
+// Transform is the sphere moved by a matrix, its radius scaled by the most
+// the matrix scales along any axis.
+func (s *Sphere) Transform(m Matrice) *Sphere {
+	var scale float32
+	for j := 0; j < 3; j++ {
+		scale = Max(scale, Vec3(m.Get(0, j), m.Get(1, j), m.Get(2, j)).Len())
+	}
+	return &Sphere{TransformPoint(m, s.Center), s.Radius * scale}
+}
+
+// OBB is a box oriented along three orthonormal Axes, Half its size along
+// each about Center.
+type OBB struct {
+	Center Vector
+	Axes   [3]Vector
+	Half   Vector
+}
+
+// NewOBB is the box about center of half extents turned by a rotation, none
+// for a box along the axes.
+func NewOBB(center, half Vector, rotation Quaternion) *OBB {
+	o := &OBB{vec3Of(center), [3]Vector{Vec3(1, 0, 0), Vec3(0, 1, 0), Vec3(0, 0, 1)}, vec3Of(half)}
+	if rotation != nil {
+		for i := range o.Axes {
+			o.Axes[i] = o.Axes[i].Rotate(rotation)
+		}
+	}
+	return o
+}
+
+// local is a point along the axes of the box from its center.
+func (o *OBB) local(p Vector) [3]float32 {
+	d := sub3(p, o.Center)
+	return [3]float32{d.Dot(o.Axes[0]), d.Dot(o.Axes[1]), d.Dot(o.Axes[2])}
+}
+
+// Corners are the eight corners of the box, the first bit of an index
+// choosing the positive side of the first axis, and so on.
+func (o *OBB) Corners() []Vector {
+	ret := make([]Vector, 8)
+	for i := range ret {
+		c := vec3Of(o.Center)
+		for a := 0; a < 3; a++ {
+			h := o.Half.Get(a)
+			if i&(1<<uint(a)) == 0 {
+				h = -h
+			}
+			c = c.Add(o.Axes[a].Mul(h))
+		}
+		ret[i] = c
+	}
+	return ret
+}
+
+func (o *OBB) Contains(p Vector) bool {
+	l := o.local(p)
+	for a := 0; a < 3; a++ {
+		if Abs(l[a]) > o.Half.Get(a) {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *OBB) ContainsSphere(s *Sphere) bool {
+	l := o.local(s.Center)
+	for a := 0; a < 3; a++ {
+		if Abs(l[a])+s.Radius > o.Half.Get(a) {
+			return false
+		}
+	}
+	return true
+}
+
+func (o *OBB) IntersectsSphere(s *Sphere) bool {
+	return s.Contains(o.Closest(s.Center))
+}
+
+func (o *OBB) IntersectsAABB(b *AABB) bool {
+	return o.IntersectsOBB(b.OBB())
+}
+
+// IntersectsOBB is whether two boxes overlap, by separating axes.
+func (o *OBB) IntersectsOBB(b *OBB) bool {
+	axes := make([]Vector, 0, 15)
+	axes = append(axes, o.Axes[:]...)
+	axes = append(axes, b.Axes[:]...)
+	for _, a := range o.Axes {
+		for _, c := range b.Axes {
+			axes = append(axes, a.Cross(c))
+		}
+	}
+	return overlap(axes, o.Corners(), b.Corners())
+}
+
+// IntersectsTriangle is whether the box and a triangle overlap, by
+// separating axes.
+func (o *OBB) IntersectsTriangle(t *Triangle) bool {
+	edges := [3]Vector{sub3(t.B, t.A), sub3(t.C, t.B), sub3(t.A, t.C)}
+	axes := make([]Vector, 0, 13)
+	axes = append(axes, o.Axes[:]...)
+	axes = append(axes, edges[0].Cross(edges[1]))
+	for _, a := range o.Axes {
+		for _, e := range edges {
+			axes = append(axes, a.Cross(e))
+		}
+	}
+	return overlap(axes, o.Corners(), t.Points())
+}
+
+// Classify is the side of a plane the box is on.
+func (o *OBB) Classify(p *Plane) Side {
+	var r float32
+	for a := 0; a < 3; a++ {
+		r += o.Half.Get(a) * Abs(p.Normal.Dot(o.Axes[a]))
+	}
+	d := p.Distance(o.Center)
+	return classify(d-r, d+r)
+}
+
+// Closest is the point of the box nearest a point, the point itself inside.
+func (o *OBB) Closest(p Vector) Vector {
+	l := o.local(p)
+	ret := vec3Of(o.Center)
+	for a := 0; a < 3; a++ {
+		h := o.Half.Get(a)
+		ret = ret.Add(o.Axes[a].Mul(Clamp(l[a], -h, h)))
+	}
+	return ret
+}
+
+// Distance is how far a point is from the box, 0 inside.
+func (o *OBB) Distance(p Vector) float32 {
+	return sub3(o.Closest(p), p).Len()
+}
+
+// Bounds is the axis aligned box around the box.
+func (o *OBB) Bounds() *AABB {
+	var e [3]float32
+	for a := 0; a < 3; a++ {
+		for i := 0; i < 3; i++ {
+			e[i] += o.Half.Get(a) * Abs(o.Axes[a].Get(i))
+		}
+	}
+	r := Vec3(e[0], e[1], e[2])
+	return &AABB{o.Center.Sub(r), o.Center.Add(r)}
+}
+
+// Transform is the box moved by a matrix. A matrix scaling unevenly along
+// axes the box is turned from shears it, which a box cannot follow.
+func (o *OBB) Transform(m Matrice) *OBB {
+	ret := &OBB{Center: TransformPoint(m, o.Center)}
+	half := [3]float32{}
+	for a := 0; a < 3; a++ {
+		ax := TransformDirection(m, o.Axes[a].Mul(o.Half.Get(a)))
+		half[a] = ax.Len()
+		if half[a] > 0 {
+			ax = ax.Mul(1 / half[a])
+		}
+		ret.Axes[a] = ax
+	}
+	ret.Half = Vec3(half[0], half[1], half[2])
+	return ret
+}
+
+// overlap is whether two convex sets of points overlap when projected on
+// every axis, the separating axis test, axes near zero skipped.
+func overlap(axes []Vector, a, b []Vector) bool {
+	project := func(ax Vector, pts []Vector) (float32, float32) {
+		lo, hi := Infinity, -Infinity
+		for _, p := range pts {
+			d := dot3(ax, p)
+			lo, hi = Min(lo, d), Max(hi, d)
+		}
+		return lo, hi
+	}
+	for _, ax := range axes {
+		if dot3(ax, ax) < geometryEpsilon {
+			continue
+		}
+		alo, ahi := project(ax, a)
+		blo, bhi := project(ax, b)
+		if ahi < blo || bhi < alo {
+			return false
+		}
+	}
+	return true
+}
+
+// Containment is how much of one shape another holds.
+type Containment int
+
+const (
+	DISJOINT Containment = iota
+	INTERSECTS
+	CONTAINS
+)
+
+func (c Containment) String() string {
+	switch c {
+	case INTERSECTS:
+		return "intersects"
+	case CONTAINS:
+		return "contains"
+	}
+	return "disjoint"
+}
+
+func StringToContainment(s string) Containment {
+	switch s {
+	case "intersects":
+		return INTERSECTS
+	case "contains":
+		return CONTAINS
+	}
+	return DISJOINT
+}
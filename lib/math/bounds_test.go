@@ -0,0 +1,118 @@
+package math
+
+import "testing"
+
+func TestBoundsClosest(t *testing.T) {
+	cases := []struct {
+		name     string
+		shape    closer
+		point    Vector
+		closest  Vector
+		distance float32
+	}{
+		{"aabb face", unitBox, vec(3, 0, 0), vec(1, 0, 0), 2},
+		{"aabb corner", unitBox, vec(3, 3, 3), vec(1, 1, 1), 2 * Sqrt(3)},
+		{"aabb edge", unitBox, vec(-2, 0.5, -5), vec(-1, 0.5, -1), Sqrt(17)},
+		{"aabb inside", unitBox, vec(0.5, 0, 0), vec(0.5, 0, 0), 0},
+		{"sphere outside", ball, vec(0, -4, 3), vec(0, -0.8, 0.6), 4},
+		{"sphere inside", ball, vec(0, 0.5, 0), vec(0, 0.5, 0), 0},
+		{"obb corner", turned, vec(3, 0, 0), vec(Sqrt(2), 0, 0), 3 - Sqrt(2)},
+		{"obb face", turned, vec(2, 2, 0), vec(Sqrt(0.5), Sqrt(0.5), 0), 2*Sqrt(2) - 1},
+		{"obb top", turned, vec(0, 0, 3), vec(0, 0, 1), 2},
+		{"obb inside", turned, vec(0.2, 0.3, 0.4), vec(0.2, 0.3, 0.4), 0},
+	}
+	for _, c := range cases {
+		if got := c.shape.Closest(c.point); !near(got, c.closest) {
+			t.Errorf("%s: closest %v, want %v", c.name, got, c.closest)
+		}
+		if got := c.shape.Distance(c.point); Abs(got-c.distance) > 1e-4 {
+			t.Errorf("%s: distance %v, want %v", c.name, got, c.distance)
+		}
+	}
+}
+
+func TestOBBSeparatingAxes(t *testing.T) {
+	a := NewOBB(vec(0, 0, 0), vec(1, 1, 1), nil)
+	diagonal := 1 + Sqrt(2)
+	// separated on an axis crossing an edge of each, the faces of both
+	// overlapping
+	edge := NewOBB(vec(-2.25, -0.25, 1.25), vec(1, 0.25, 1.5),
+		SetQuatFromAxisAngle(Quat(1, 0, 0, 0), vec(0, 1, 1).Normalize(), 1.25))
+	cases := []struct {
+		name string
+		b    *OBB
+		want bool
+	}{
+		{"faces touching", NewOBB(vec(2, 0, 0), vec(1, 1, 1), nil), true},
+		{"faces apart", NewOBB(vec(2.01, 0, 0), vec(1, 1, 1), nil), false},
+		{"edge into face", NewOBB(vec(diagonal-0.01, 0, 0), vec(1, 1, 1), zRot(45)), true},
+		{"edge short of face", NewOBB(vec(diagonal+0.01, 0, 0), vec(1, 1, 1), zRot(45)), false},
+		{"corner apart", NewOBB(vec(2.1, 2.1, 2.1), vec(1, 1, 1), nil), false},
+		{"inside", NewOBB(vec(0.2, 0, 0), vec(0.5, 0.5, 0.5), zRot(30)), true},
+		{"around", NewOBB(vec(0, 0, 0), vec(3, 3, 3), zRot(30)), true},
+		{"edges apart", edge, false},
+	}
+	for _, c := range cases {
+		if got := a.IntersectsOBB(c.b); got != c.want {
+			t.Errorf("%s: %v, want %v", c.name, got, c.want)
+		}
+		if got := c.b.IntersectsOBB(a); got != c.want {
+			t.Errorf("%s reversed: %v, want %v", c.name, got, c.want)
+		}
+	}
+	faces := append(append([]Vector{}, a.Axes[:]...), edge.Axes[:]...)
+	if !overlap(faces, a.Corners(), edge.Corners()) {
+		t.Error("edges apart is separated by a face")
+	}
+}
+
+func TestAABB(t *testing.T) {
+	b := NewAABB(vec(2, -1, 3), vec(-2, 1, -3))
+	if !near(b.Min, vec(-2, -1, -3)) || !near(b.Max, vec(2, 1, 3)) {
+		t.Fatalf("corners not ordered: %v %v", b.Min, b.Max)
+	}
+	if !near(b.Center(), vec(0, 0, 0)) || !near(b.Size(), vec(4, 2, 6)) || !near(b.Extents(), vec(2, 1, 3)) {
+		t.Error("center, size or extents")
+	}
+	cs := b.Corners()
+	if len(cs) != 8 || !near(cs[0], b.Min) || !near(cs[7], b.Max) || !near(cs[1], vec(2, -1, -3)) {
+		t.Errorf("corners %v", cs)
+	}
+	u := unitBox.Union(NewAABB(vec(3, 3, 3), vec(4, 4, 4)))
+	if !near(u.Min, vec(-1, -1, -1)) || !near(u.Max, vec(4, 4, 4)) {
+		t.Error("union")
+	}
+	if AABBFromPoints() != nil {
+		t.Error("bounds of no points")
+	}
+	p := AABBFromPoints(vec(1, 2, 3), vec(-1, 5, 0), vec(0, 0, 0))
+	if !near(p.Min, vec(-1, 0, 0)) || !near(p.Max, vec(1, 5, 3)) {
+		t.Error("bounds of points")
+	}
+}
+
+func TestBoundsTransform(t *testing.T) {
+	m := translation(1, 2, 3).MulMatrice(IdentityMatrix(MAT4).Rotate(SetQuatFromAxisAngle(Quat(1, 0, 0, 0), vec(0, 1, 0), 0.5)))
+	b := unitBox.Transform(m)
+	o := unitBox.OBB().Transform(m)
+	for _, c := range unitBox.Corners() {
+		p := TransformPoint(m, c)
+		if b.Distance(p) > 1e-4 {
+			t.Errorf("aabb misses corner %v", p)
+		}
+		if o.Distance(p) > 1e-4 {
+			t.Errorf("obb misses corner %v", p)
+		}
+	}
+	if !near(o.Half, vec(1, 1, 1)) || !near(o.Center, vec(1, 2, 3)) {
+		t.Errorf("obb %v %v", o.Center, o.Half)
+	}
+	scale := Mat4(2, 0, 0, 0, 0, 3, 0, 0, 0, 0, 1, 0, 0, 1, 0, 1)
+	s := NewSphere(vec(1, 0, 0), 1).Transform(scale)
+	if !near(s.Center, vec(2, 1, 0)) || s.Radius != 3 {
+		t.Errorf("sphere %v %v", s.Center, s.Radius)
+	}
+	if bb := turned.Bounds(); !near(bb.Max, vec(Sqrt(2), Sqrt(2), 1)) {
+		t.Errorf("obb bounds %v", bb.Max)
+	}
+}
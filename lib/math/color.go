@@ -32,8 +32,8 @@ func (c *color) Raw() []float32 {
 func (c *color) Set(v ...float32) {
 	lv := len(v)
 	if lv > 0 && lv <= 4 {
-		for i := 1; i <= len(v); i++ {
-			c.v.Set(i, v[i-1])
+		for i := 0; i < lv; i++ {
+			c.v.Set(i, v[i])
 		}
 	}
 }
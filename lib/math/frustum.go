@@ -0,0 +1,134 @@
+package math
+
+// The planes of a frustum.
+const (
+	FRUSTUM_LEFT = iota
+	FRUSTUM_RIGHT
+	FRUSTUM_BOTTOM
+	FRUSTUM_TOP
+	FRUSTUM_NEAR
+	FRUSTUM_FAR
+	FRUSTUM_PLANES
+)
+
+// Frustum is the volume a camera sees, between six planes facing in.
+type Frustum struct {
+	Planes [FRUSTUM_PLANES]*Plane
+}
+
+// FrustumFromMatrice is the frustum of a projection * view matrix, in world
+// space, or of a projection * view * model matrix, in model space, for clip
+// space of -w to w on every axis. None for a matrix not 4x4.
+func FrustumFromMatrice(m Matrice) *Frustum {
+	if m == nil || m.Rows() != 4 || m.Cols() != 4 {
+		return nil
+	}
+	row := func(i int) [4]float32 {
+		return [4]float32{m.Get(i, 0), m.Get(i, 1), m.Get(i, 2), m.Get(i, 3)}
+	}
+	w := row(3)
+	plane := func(r [4]float32, sign float32) *Plane {
+		return NewPlane(Vec3(w[0]+sign*r[0], w[1]+sign*r[1], w[2]+sign*r[2]), w[3]+sign*r[3])
+	}
+	f := &Frustum{}
+	for i := 0; i < 3; i++ {
+		r := row(i)
+		f.Planes[i*2] = plane(r, 1)
+		f.Planes[i*2+1] = plane(r, -1)
+	}
+	return f
+}
+
+func (f *Frustum) Contains(p Vector) bool {
+	for _, pl := range f.Planes {
+		if pl.Distance(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ClassifySphere is how much of a sphere the frustum holds.
+func (f *Frustum) ClassifySphere(s *Sphere) Containment {
+	ret := CONTAINS
+	for _, pl := range f.Planes {
+		switch s.Classify(pl) {
+		case SIDE_BEHIND:
+			return DISJOINT
+		case SIDE_CROSSING:
+			ret = INTERSECTS
+		}
+	}
+	return ret
+}
+
+// ClassifyAABB is how much of a box the frustum holds. Boxes near a corner
+// of the frustum, outside it but behind no one plane, intersect it.
+func (f *Frustum) ClassifyAABB(b *AABB) Containment {
+	ret := CONTAINS
+	for _, pl := range f.Planes {
+		switch b.Classify(pl) {
+		case SIDE_BEHIND:
+			return DISJOINT
+		case SIDE_CROSSING:
+			ret = INTERSECTS
+		}
+	}
+	return ret
+}
+
+// ClassifyOBB is how much of a box the frustum holds, as ClassifyAABB.
+func (f *Frustum) ClassifyOBB(o *OBB) Containment {
+	ret := CONTAINS
+	for _, pl := range f.Planes {
+		switch o.Classify(pl) {
+		case SIDE_BEHIND:
+			return DISJOINT
+		case SIDE_CROSSING:
+			ret = INTERSECTS
+		}
+	}
+	return ret
+}
+
+func (f *Frustum) IntersectsSphere(s *Sphere) bool {
+	return f.ClassifySphere(s) != DISJOINT
+}
+
+func (f *Frustum) IntersectsAABB(b *AABB) bool {
+	return f.ClassifyAABB(b) != DISJOINT
+}
+
+func (f *Frustum) IntersectsOBB(o *OBB) bool {
+	return f.ClassifyOBB(o) != DISJOINT
+}
+
+// Corners are the eight corners of the frustum, the first bit of an index
+// choosing right over left, the second top over bottom and the third far
+// over near.
+func (f *Frustum) Corners() []Vector {
+	ret := make([]Vector, 8)
+	for i := range ret {
+		x, y, z := FRUSTUM_LEFT, FRUSTUM_BOTTOM, FRUSTUM_NEAR
+		if i&1 != 0 {
+			x = FRUSTUM_RIGHT
+		}
+		if i&2 != 0 {
+			y = FRUSTUM_TOP
+		}
+		if i&4 != 0 {
+			z = FRUSTUM_FAR
+		}
+		ret[i], _ = IntersectPlanes(f.Planes[x], f.Planes[y], f.Planes[z])
+	}
+	return ret
+}
+
+// Transform is the frustum moved by a matrix.
+func (f *Frustum) Transform(m Matrice) *Frustum {
+	ret := &Frustum{}
+	for i, pl := range f.Planes {
+		ret.Planes[i] = pl.Transform(m)
+	}
+	return ret
+}
@@ -0,0 +1,96 @@
+package math
+
+import "testing"
+
+func TestFrustumFromPerspective(t *testing.T) {
+	f := testFrustum()
+	for i, p := range f.Planes {
+		if Abs(p.Normal.Len()-1) > 1e-5 {
+			t.Errorf("plane %d not normalized", i)
+		}
+		if p.Distance(vec(0, 0, 0)) <= 0 {
+			t.Errorf("plane %d faces out", i)
+		}
+	}
+	want := []Vector{
+		vec(-1, -1, 4), vec(1, -1, 4), vec(-1, 1, 4), vec(1, 1, 4),
+		vec(-10, -10, -5), vec(10, -10, -5), vec(-10, 10, -5), vec(10, 10, -5),
+	}
+	for i, c := range f.Corners() {
+		if sub3(c, want[i]).Len() > 1e-3 {
+			t.Errorf("corner %d %v, want %v", i, c, want[i])
+		}
+	}
+
+	// 60 degrees high, twice as wide, looking down -z from the origin
+	g := FrustumFromMatrice(newMatrix(MAT4, 4, 4).Perspective(60, 2, 1, 100))
+	h := 10 * Tan(DegToRad(30))
+	points := []struct {
+		name string
+		p    Vector
+		want bool
+	}{
+		{"center", vec(0, 0, -10), true},
+		{"below top", vec(0, h*0.99, -10), true},
+		{"above top", vec(0, h*1.01, -10), false},
+		{"inside right", vec(2*h*0.99, 0, -10), true},
+		{"past right", vec(2*h*1.01, 0, -10), false},
+		{"before near", vec(0, 0, -0.99), false},
+		{"past near", vec(0, 0, -1.01), true},
+		{"before far", vec(0, 0, -99), true},
+		{"past far", vec(0, 0, -101), false},
+		{"behind", vec(0, 0, 10), false},
+	}
+	for _, c := range points {
+		if got := g.Contains(c.p); got != c.want {
+			t.Errorf("%s: %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestFrustumClassify(t *testing.T) {
+	f := testFrustum()
+	cases := []struct {
+		name  string
+		shape interface{}
+		want  Containment
+	}{
+		{"sphere inside", NewSphere(vec(0, 0, 0), 1), CONTAINS},
+		{"sphere across near", NewSphere(vec(0, 0, 4.5), 1), INTERSECTS},
+		{"sphere before near", NewSphere(vec(0, 0, 7), 1), DISJOINT},
+		{"sphere past far", NewSphere(vec(0, 0, -6.5), 1), DISJOINT},
+		{"sphere across side", NewSphere(vec(5, 0, 0), 0.5), INTERSECTS},
+		{"sphere beside", NewSphere(vec(6, 0, 0), 0.5), DISJOINT},
+		{"aabb inside", unitBox, CONTAINS},
+		{"aabb across side", NewAABB(vec(4, -1, -1), vec(6, 1, 1)), INTERSECTS},
+		{"aabb beside", NewAABB(vec(8, -1, -1), vec(9, 1, 1)), DISJOINT},
+		{"aabb around", NewAABB(vec(-50, -50, -50), vec(50, 50, 50)), INTERSECTS},
+		{"obb inside", turned, CONTAINS},
+		{"obb across far", NewOBB(vec(0, 0, -5), vec(1, 1, 1), zRot(45)), INTERSECTS},
+		{"obb beside", NewOBB(vec(0, 9, 0), vec(1, 1, 1), zRot(45)), DISJOINT},
+	}
+	for _, c := range cases {
+		var got Containment
+		switch s := c.shape.(type) {
+		case *Sphere:
+			got = f.ClassifySphere(s)
+		case *AABB:
+			got = f.ClassifyAABB(s)
+		case *OBB:
+			got = f.ClassifyOBB(s)
+		}
+		if got != c.want {
+			t.Errorf("%s: %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestFrustumTransform(t *testing.T) {
+	f := testFrustum().Transform(translation(100, 0, 0))
+	if !f.Contains(vec(100, 0, 0)) || f.Contains(vec(0, 0, 0)) {
+		t.Error("moved frustum")
+	}
+	if FrustumFromMatrice(IdentityMatrix(MAT3)) != nil {
+		t.Error("frustum of a 3x3 matrix")
+	}
+}
@@ -172,13 +172,15 @@ func runInner(L *l.LState, ii inner, im innerMathFunc) int {
 }
 
 const (
-	VEC2 = "VEC2"
-	VEC3 = "VEC3"
-	VEC4 = "VEC4"
-	MAT2 = "MAT2"
-	MAT3 = "MAT3"
-	MAT4 = "MAT4"
-	QUAT = "QUAT"
+	VEC2  = "VEC2"
+	VEC3  = "VEC3"
+	VEC4  = "VEC4"
+	MAT2  = "MAT2"
+	MAT3  = "MAT3"
+	MAT4  = "MAT4"
+	QUAT  = "QUAT"
+	RNG   = "RNG"
+	NOISE = "NOISE"
 )
 
 var (
@@ -190,16 +192,36 @@ var (
 		lua.NewTable(MAT3, nil, matMeta, nil, nil),
 		lua.NewTable(MAT4, nil, matMeta, nil, nil),
 		lua.NewTable(QUAT, nil, qutMeta, nil, nil),
+		lua.NewTable(RNG, nil, rngMeta, nil, rngMethods),
+		lua.NewTable(NOISE, nil, noiseMeta, noiseProperties, noiseMethods),
+		lua.NewTable(RAY, shapeParent, shapeMeta, rayProperties, rayMethods),
+		lua.NewTable(PLANE, shapeParent, shapeMeta, planeProperties, nil),
+		lua.NewTable(BOX, shapeParent, shapeMeta, aabbProperties, nil),
+		lua.NewTable(SPHERE, shapeParent, shapeMeta, sphereProperties, nil),
+		lua.NewTable(OBOX, shapeParent, shapeMeta, obbProperties, nil),
+		lua.NewTable(FRUSTUM, shapeParent, shapeMeta, nil, nil),
+		lua.NewTable(TRIANGLE, shapeParent, shapeMeta, triangleProperties, triangleMethods),
+		lua.NewTable(SEGMENT, shapeParent, shapeMeta, segmentProperties, segmentMethods),
 	}
 
 	expandMathFuncs = map[string]l.LGFunction{
-		"vec2": lVec(VEC2),
-		"vec3": lVec(VEC3),
-		"vec4": lVec(VEC4),
-		"mat2": lMat(MAT2),
-		"mat3": lMat(MAT3),
-		"mat4": lMat(MAT4),
-		"quat": lQuat,
+		"vec2":     lVec(VEC2),
+		"vec3":     lVec(VEC3),
+		"vec4":     lVec(VEC4),
+		"mat2":     lMat(MAT2),
+		"mat3":     lMat(MAT3),
+		"mat4":     lMat(MAT4),
+		"quat":     lQuat,
+		"rng":      lRNG,
+		"noise":    lNoise,
+		"ray":      lRay,
+		"plane":    lPlane,
+		"aabb":     lAABB,
+		"sphere":   lSphere,
+		"obb":      lOBB,
+		"frustum":  lFrustum,
+		"triangle": lTriangle,
+		"segment":  lSegment,
 	}
 )
 
@@ -19,6 +19,7 @@ type Matrice interface {
 	Compose([]float32, []float32, []float32) Matrice
 	Decompose(Vector, Vector, Quaternion) Matrice
 	Determinant() float32
+	Inverse() Matrice
 	LookAt(Vector, Vector, Vector) Matrice
 	MulMatrice(Matrice) Matrice
 	MulScalar(float32) Matrice
@@ -297,6 +298,71 @@ func (m *MatRxC) Trace() float32 {
 	return out
 }
 
+// Inverse returns the inverse of a square matrix, or nil when the matrix is
+// not square or is singular.
+func (m *MatRxC) Inverse() Matrice {
+	if m == nil || m.r != m.c {
+		return nil
+	}
+
+	n := m.r
+	a := make([]float32, len(m.v))
+	copy(a, m.v)
+	dst := newMatrix(m.tag, n, n)
+	for i := range dst.v {
+		dst.v[i] = 0
+	}
+	for i := 0; i < n; i++ {
+		dst.v[i*n+i] = 1
+	}
+
+	// gauss-jordan elimination with partial pivoting, column-major storage
+	at := func(v []float32, r, c int) *float32 {
+		return &v[c*n+r]
+	}
+	for c := 0; c < n; c++ {
+		pivot := c
+		for r := c + 1; r < n; r++ {
+			if Abs(*at(a, r, c)) > Abs(*at(a, pivot, c)) {
+				pivot = r
+			}
+		}
+		if Abs(*at(a, pivot, c)) < 1e-12 {
+			return nil
+		}
+		if pivot != c {
+			for k := 0; k < n; k++ {
+				*at(a, c, k), *at(a, pivot, k) = *at(a, pivot, k), *at(a, c, k)
+				*at(dst.v, c, k), *at(dst.v, pivot, k) = *at(dst.v, pivot, k), *at(dst.v, c, k)
+			}
+		}
+		d := *at(a, c, c)
+		for k := 0; k < n; k++ {
+			*at(a, c, k) /= d
+			*at(dst.v, c, k) /= d
+		}
+		for r := 0; r < n; r++ {
+			if r == c {
+				continue
+			}
+			f := *at(a, r, c)
+			if f == 0 {
+				continue
+			}
+			for k := 0; k < n; k++ {
+				*at(a, r, k) -= f * *at(a, c, k)
+				*at(dst.v, r, k) -= f * *at(dst.v, c, k)
+			}
+		}
+	}
+
+	return dst
+}
+
+func InverseMatrix(m Matrice) Matrice {
+	return m.Inverse()
+}
+
 func SetMatriceFromVector(m Matrice, v Vector, ps ...MxPos) Vector {
 	for _, p := range ps {
 		m.Set(p.Row, p.Column, v.Get(p.Correspondence))
@@ -358,11 +424,7 @@ func (m *MatRxC) Rotate(q Quaternion) Matrice {
 		m.Set(3, 0, 0)
 		m.Set(3, 1, 0)
 		m.Set(3, 2, 0)
-
-		m.Set(0, 3, 0)
-		m.Set(1, 3, 0)
-		m.Set(2, 3, 0)
-		m.Set(3, 3, 0)
+		m.Set(3, 3, 1)
 	}
 	return m
 }
@@ -427,11 +489,9 @@ func (m *MatRxC) Decompose(translate, scale Vector, rotate Quaternion) Matrice {
 }
 
 func (m *MatRxC) LookAt(eye, target, up Vector) Matrice {
-	v := Vec3(0, 0, 0)
-	f, s, u := v.Clone(), v.Clone(), v.Clone()
-	//f.SubVectors(target, eye).Normalize()
-	//s.CrossVectors(&f, up).Normalize()
-	//u.CrossVectors(&s, &f)
+	f := target.Sub(eye).Normalize()
+	s := f.Cross(up).Normalize()
+	u := s.Cross(f)
 
 	raw := []float32{
 		s.Get(0), u.Get(0), -(f.Get(0)), 0.0,
@@ -0,0 +1,845 @@
+package math
+
+import (
+	glm "math"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+type Noise2 func(x, y float32) float32
+
+type Noise3 func(x, y, z float32) float32
+
+type Noise4 func(x, y, z, w float32) float32
+
+// Noise is gradient, simplex, value and cellular noise of a seed, the lattice
+// hashed through a permutation shuffled by it. All but cellular noise are
+// about [-1, 1], gradient noise 0 on the lattice.
+type Noise struct {
+	seed uint64
+	perm [512]uint8
+}
+
+func NewNoise(seed uint64) *Noise {
+	n := &Noise{seed: seed}
+	p := Seeded(seed).Perm(256)
+	for i := range n.perm {
+		n.perm[i] = uint8(p[i&255])
+	}
+	return n
+}
+
+func (n *Noise) Seed() uint64 {
+	return n.seed
+}
+
+func (n *Noise) hash2(x, y int) int {
+	return int(n.perm[int(n.perm[x&255])+y&255])
+}
+
+func (n *Noise) hash3(x, y, z int) int {
+	return int(n.perm[n.hash2(x, y)+z&255])
+}
+
+func (n *Noise) hash4(x, y, z, w int) int {
+	return int(n.perm[n.hash3(x, y, z)+w&255])
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+func floor(x float32) (int, float64) {
+	f := glm.Floor(float64(x))
+	return int(f), float64(x) - f
+}
+
+func grad2(h int, x, y float64) float64 {
+	switch h & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	}
+	return -y
+}
+
+func grad3(h int, x, y, z float64) float64 {
+	switch h & 15 {
+	case 0, 12:
+		return x + y
+	case 1, 14:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x + z
+	case 5:
+		return -x + z
+	case 6:
+		return x - z
+	case 7:
+		return -x - z
+	case 8:
+		return y + z
+	case 9, 13:
+		return -y + z
+	case 10:
+		return y - z
+	}
+	return -y - z
+}
+
+// grad4 is the dot of one of the 32 gradients to the middles of the edges of
+// a tesseract, each with a zero and three of 1 or -1.
+func grad4(h int, x, y, z, w float64) float64 {
+	h &= 31
+	a, b, c := y, z, w
+	switch h >> 3 {
+	case 1:
+		a, b, c = x, z, w
+	case 2:
+		a, b, c = x, y, w
+	case 3:
+		a, b, c = x, y, z
+	}
+	if h&4 != 0 {
+		a = -a
+	}
+	if h&2 != 0 {
+		b = -b
+	}
+	if h&1 != 0 {
+		c = -c
+	}
+	return a + b + c
+}
+
+// Perlin2 is improved Perlin gradient noise.
+func (n *Noise) Perlin2(x, y float32) float32 {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	u, v := fade(fx), fade(fy)
+	return float32(lerp(
+		lerp(grad2(n.hash2(ix, iy), fx, fy), grad2(n.hash2(ix+1, iy), fx-1, fy), u),
+		lerp(grad2(n.hash2(ix, iy+1), fx, fy-1), grad2(n.hash2(ix+1, iy+1), fx-1, fy-1), u),
+		v,
+	))
+}
+
+func (n *Noise) Perlin3(x, y, z float32) float32 {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	iz, fz := floor(z)
+	u, v, w := fade(fx), fade(fy), fade(fz)
+	g := func(i, j, k int) float64 {
+		return grad3(n.hash3(ix+i, iy+j, iz+k), fx-float64(i), fy-float64(j), fz-float64(k))
+	}
+	return float32(lerp(
+		lerp(lerp(g(0, 0, 0), g(1, 0, 0), u), lerp(g(0, 1, 0), g(1, 1, 0), u), v),
+		lerp(lerp(g(0, 0, 1), g(1, 0, 1), u), lerp(g(0, 1, 1), g(1, 1, 1), u), v),
+		w,
+	))
+}
+
+func (n *Noise) Perlin4(x, y, z, w float32) float32 {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	iz, fz := floor(z)
+	iw, fw := floor(w)
+	u, v, s, t := fade(fx), fade(fy), fade(fz), fade(fw)
+	g := func(i, j, k, m int) float64 {
+		return grad4(n.hash4(ix+i, iy+j, iz+k, iw+m),
+			fx-float64(i), fy-float64(j), fz-float64(k), fw-float64(m))
+	}
+	cube := func(m int) float64 {
+		return lerp(
+			lerp(lerp(g(0, 0, 0, m), g(1, 0, 0, m), u), lerp(g(0, 1, 0, m), g(1, 1, 0, m), u), v),
+			lerp(lerp(g(0, 0, 1, m), g(1, 0, 1, m), u), lerp(g(0, 1, 1, m), g(1, 1, 1, m), u), v),
+			s,
+		)
+	}
+	return float32(0.85 * lerp(cube(0), cube(1), t))
+}
+
+var (
+	simplexF2 = 0.5 * (glm.Sqrt(3) - 1)
+	simplexG2 = (3 - glm.Sqrt(3)) / 6
+	simplexF4 = (glm.Sqrt(5) - 1) / 4
+	simplexG4 = (5 - glm.Sqrt(5)) / 20
+)
+
+const (
+	simplexF3 = 1.0 / 3
+	simplexG3 = 1.0 / 6
+)
+
+// corner is what a corner of a simplex adds, falling to nothing r2 away.
+func corner(r2, d2, g float64) float64 {
+	t := r2 - d2
+	if t < 0 {
+		return 0
+	}
+	t *= t
+	return t * t * g
+}
+
+// Simplex2 is simplex noise, smoother and with fewer axis aligned artifacts
+// than Perlin noise for less work as dimensions grow.
+func (n *Noise) Simplex2(x, y float32) float32 {
+	xf, yf := float64(x), float64(y)
+	s := (xf + yf) * simplexF2
+	i, j := int(glm.Floor(xf+s)), int(glm.Floor(yf+s))
+	t := float64(i+j) * simplexG2
+	x0, y0 := xf-(float64(i)-t), yf-(float64(j)-t)
+	i1, j1 := 0, 1
+	if x0 > y0 {
+		i1, j1 = 1, 0
+	}
+	x1, y1 := x0-float64(i1)+simplexG2, y0-float64(j1)+simplexG2
+	x2, y2 := x0-1+2*simplexG2, y0-1+2*simplexG2
+	sum := corner(0.5, x0*x0+y0*y0, grad2(n.hash2(i, j), x0, y0)) +
+		corner(0.5, x1*x1+y1*y1, grad2(n.hash2(i+i1, j+j1), x1, y1)) +
+		corner(0.5, x2*x2+y2*y2, grad2(n.hash2(i+1, j+1), x2, y2))
+	return float32(70 * sum)
+}
+
+func (n *Noise) Simplex3(x, y, z float32) float32 {
+	xf, yf, zf := float64(x), float64(y), float64(z)
+	s := (xf + yf + zf) * simplexF3
+	i, j, k := int(glm.Floor(xf+s)), int(glm.Floor(yf+s)), int(glm.Floor(zf+s))
+	t := float64(i+j+k) * simplexG3
+	x0, y0, z0 := xf-(float64(i)-t), yf-(float64(j)-t), zf-(float64(k)-t)
+	var i1, j1, k1, i2, j2, k2 int
+	switch {
+	case x0 >= y0 && y0 >= z0:
+		i1, i2, j2 = 1, 1, 1
+	case x0 >= y0 && x0 >= z0:
+		i1, i2, k2 = 1, 1, 1
+	case x0 >= y0:
+		k1, i2, k2 = 1, 1, 1
+	case y0 < z0:
+		k1, j2, k2 = 1, 1, 1
+	case x0 < z0:
+		j1, j2, k2 = 1, 1, 1
+	default:
+		j1, i2, j2 = 1, 1, 1
+	}
+	sum := 0.0
+	for c, o := range [4][3]int{{0, 0, 0}, {i1, j1, k1}, {i2, j2, k2}, {1, 1, 1}} {
+		dx := x0 - float64(o[0]) + float64(c)*simplexG3
+		dy := y0 - float64(o[1]) + float64(c)*simplexG3
+		dz := z0 - float64(o[2]) + float64(c)*simplexG3
+		sum += corner(0.6, dx*dx+dy*dy+dz*dz, grad3(n.hash3(i+o[0], j+o[1], k+o[2]), dx, dy, dz))
+	}
+	return float32(32 * sum)
+}
+
+func (n *Noise) Simplex4(x, y, z, w float32) float32 {
+	p := [4]float64{float64(x), float64(y), float64(z), float64(w)}
+	s := (p[0] + p[1] + p[2] + p[3]) * simplexF4
+	var c [4]int
+	var d [4]float64
+	for a := range p {
+		c[a] = int(glm.Floor(p[a] + s))
+	}
+	t := float64(c[0]+c[1]+c[2]+c[3]) * simplexG4
+	for a := range p {
+		d[a] = p[a] - (float64(c[a]) - t)
+	}
+	// each axis ranked by how far along it the point is decides the order
+	// the corners of the simplex step along them
+	var rank [4]int
+	for a := 0; a < 4; a++ {
+		for b := a + 1; b < 4; b++ {
+			if d[a] > d[b] {
+				rank[a]++
+			} else {
+				rank[b]++
+			}
+		}
+	}
+	sum := 0.0
+	for step := 0; step <= 4; step++ {
+		var o [4]int
+		var e [4]float64
+		r2 := 0.0
+		for a := range o {
+			if rank[a] >= 4-step {
+				o[a] = 1
+			}
+			e[a] = d[a] - float64(o[a]) + float64(step)*simplexG4
+			r2 += e[a] * e[a]
+		}
+		h := n.hash4(c[0]+o[0], c[1]+o[1], c[2]+o[2], c[3]+o[3])
+		sum += corner(0.6, r2, grad4(h, e[0], e[1], e[2], e[3]))
+	}
+	return float32(27 * sum)
+}
+
+// lattice is the value of noise at a point of the lattice.
+func lattice(h int) float64 {
+	return float64(h)/127.5 - 1
+}
+
+// Value2 is value noise, values at the lattice smoothly blended between.
+func (n *Noise) Value2(x, y float32) float32 {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	u, v := fade(fx), fade(fy)
+	return float32(lerp(
+		lerp(lattice(n.hash2(ix, iy)), lattice(n.hash2(ix+1, iy)), u),
+		lerp(lattice(n.hash2(ix, iy+1)), lattice(n.hash2(ix+1, iy+1)), u),
+		v,
+	))
+}
+
+func (n *Noise) Value3(x, y, z float32) float32 {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	iz, fz := floor(z)
+	u, v, w := fade(fx), fade(fy), fade(fz)
+	g := func(i, j, k int) float64 {
+		return lattice(n.hash3(ix+i, iy+j, iz+k))
+	}
+	return float32(lerp(
+		lerp(lerp(g(0, 0, 0), g(1, 0, 0), u), lerp(g(0, 1, 0), g(1, 1, 0), u), v),
+		lerp(lerp(g(0, 0, 1), g(1, 0, 1), u), lerp(g(0, 1, 1), g(1, 1, 1), u), v),
+		w,
+	))
+}
+
+func (n *Noise) Value4(x, y, z, w float32) float32 {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	iz, fz := floor(z)
+	iw, fw := floor(w)
+	u, v, s, t := fade(fx), fade(fy), fade(fz), fade(fw)
+	g := func(i, j, k, m int) float64 {
+		return lattice(n.hash4(ix+i, iy+j, iz+k, iw+m))
+	}
+	cube := func(m int) float64 {
+		return lerp(
+			lerp(lerp(g(0, 0, 0, m), g(1, 0, 0, m), u), lerp(g(0, 1, 0, m), g(1, 1, 0, m), u), v),
+			lerp(lerp(g(0, 0, 1, m), g(1, 0, 1, m), u), lerp(g(0, 1, 1, m), g(1, 1, 1, m), u), v),
+			s,
+		)
+	}
+	return float32(lerp(cube(0), cube(1), t))
+}
+
+// cell hashes a cell of the lattice to the bits placing its feature point.
+func (n *Noise) cell(x, y, z, w int) uint64 {
+	h := n.seed
+	for _, c := range [4]int{x, y, z, w} {
+		h ^= uint64(int64(c))
+		h = splitmix64(&h)
+	}
+	return h
+}
+
+func jitter(h uint64, axis uint) float64 {
+	return float64(h>>(axis*16)&0xffff) / 0x10000
+}
+
+// worley keeps the nearest and second nearest squared distances.
+func worley(f1, f2, d float64) (float64, float64) {
+	if d < f1 {
+		return d, f1
+	}
+	if d < f2 {
+		return f1, d
+	}
+	return f1, f2
+}
+
+// Worley2 is cellular noise, the distances to the nearest and second
+// nearest of points scattered one a cell of the lattice.
+func (n *Noise) Worley2(x, y float32) (float32, float32) {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	f1, f2 := glm.Inf(1), glm.Inf(1)
+	for j := -1; j <= 1; j++ {
+		for i := -1; i <= 1; i++ {
+			h := n.cell(ix+i, iy+j, 0, 0)
+			dx := float64(i) + jitter(h, 0) - fx
+			dy := float64(j) + jitter(h, 1) - fy
+			f1, f2 = worley(f1, f2, dx*dx+dy*dy)
+		}
+	}
+	return float32(glm.Sqrt(f1)), float32(glm.Sqrt(f2))
+}
+
+func (n *Noise) Worley3(x, y, z float32) (float32, float32) {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	iz, fz := floor(z)
+	f1, f2 := glm.Inf(1), glm.Inf(1)
+	for k := -1; k <= 1; k++ {
+		for j := -1; j <= 1; j++ {
+			for i := -1; i <= 1; i++ {
+				h := n.cell(ix+i, iy+j, iz+k, 0)
+				dx := float64(i) + jitter(h, 0) - fx
+				dy := float64(j) + jitter(h, 1) - fy
+				dz := float64(k) + jitter(h, 2) - fz
+				f1, f2 = worley(f1, f2, dx*dx+dy*dy+dz*dz)
+			}
+		}
+	}
+	return float32(glm.Sqrt(f1)), float32(glm.Sqrt(f2))
+}
+
+func (n *Noise) Worley4(x, y, z, w float32) (float32, float32) {
+	ix, fx := floor(x)
+	iy, fy := floor(y)
+	iz, fz := floor(z)
+	iw, fw := floor(w)
+	f1, f2 := glm.Inf(1), glm.Inf(1)
+	for m := -1; m <= 1; m++ {
+		for k := -1; k <= 1; k++ {
+			for j := -1; j <= 1; j++ {
+				for i := -1; i <= 1; i++ {
+					h := n.cell(ix+i, iy+j, iz+k, iw+m)
+					dx := float64(i) + jitter(h, 0) - fx
+					dy := float64(j) + jitter(h, 1) - fy
+					dz := float64(k) + jitter(h, 2) - fz
+					dw := float64(m) + jitter(h, 3) - fw
+					f1, f2 = worley(f1, f2, dx*dx+dy*dy+dz*dz+dw*dw)
+				}
+			}
+		}
+	}
+	return float32(glm.Sqrt(f1)), float32(glm.Sqrt(f2))
+}
+
+// Fractal sums Octaves of a noise from Frequency, each of Lacunarity times
+// the frequency and Gain times the amplitude of the last, fractal Brownian
+// motion, divided by the sum of the amplitudes to keep the noise's range.
+type Fractal struct {
+	Octaves    int
+	Frequency  float32
+	Lacunarity float32
+	Gain       float32
+}
+
+func DefaultFractal() Fractal {
+	return Fractal{Octaves: 5, Frequency: 1, Lacunarity: 2, Gain: 0.5}
+}
+
+func (f Fractal) sum(at func(freq float32) float32) float32 {
+	var sum, norm float32
+	amp, freq := float32(1), f.Frequency
+	for o := 0; o < f.Octaves; o++ {
+		sum += amp * at(freq)
+		norm += amp
+		amp *= f.Gain
+		freq *= f.Lacunarity
+	}
+	if norm == 0 {
+		return 0
+	}
+	return sum / norm
+}
+
+func (f Fractal) Noise2(n Noise2) Noise2 {
+	return func(x, y float32) float32 {
+		return f.sum(func(q float32) float32 { return n(x*q, y*q) })
+	}
+}
+
+func (f Fractal) Noise3(n Noise3) Noise3 {
+	return func(x, y, z float32) float32 {
+		return f.sum(func(q float32) float32 { return n(x*q, y*q, z*q) })
+	}
+}
+
+func (f Fractal) Noise4(n Noise4) Noise4 {
+	return func(x, y, z, w float32) float32 {
+		return f.sum(func(q float32) float32 { return n(x*q, y*q, z*q, w*q) })
+	}
+}
+
+// Offsets apart enough that the noise warping each axis is unrelated.
+var warpOffsets = [4][4]float32{
+	{0, 0, 0, 0},
+	{5.2, 1.3, 7.1, 3.7},
+	{9.2, 2.8, 4.4, 8.3},
+	{1.7, 9.9, 2.1, 6.6},
+}
+
+// Warp2 is domain warping, a noise sampled where another moved it to,
+// strength times that noise along each axis.
+func Warp2(n, by Noise2, strength float32) Noise2 {
+	return func(x, y float32) float32 {
+		o := warpOffsets
+		return n(
+			x+strength*by(x+o[0][0], y+o[0][1]),
+			y+strength*by(x+o[1][0], y+o[1][1]),
+		)
+	}
+}
+
+func Warp3(n, by Noise3, strength float32) Noise3 {
+	return func(x, y, z float32) float32 {
+		o := warpOffsets
+		return n(
+			x+strength*by(x+o[0][0], y+o[0][1], z+o[0][2]),
+			y+strength*by(x+o[1][0], y+o[1][1], z+o[1][2]),
+			z+strength*by(x+o[2][0], y+o[2][1], z+o[2][2]),
+		)
+	}
+}
+
+func Warp4(n, by Noise4, strength float32) Noise4 {
+	return func(x, y, z, w float32) float32 {
+		o := warpOffsets
+		return n(
+			x+strength*by(x+o[0][0], y+o[0][1], z+o[0][2], w+o[0][3]),
+			y+strength*by(x+o[1][0], y+o[1][1], z+o[1][2], w+o[1][3]),
+			z+strength*by(x+o[2][0], y+o[2][1], z+o[2][2], w+o[2][3]),
+			w+strength*by(x+o[3][0], y+o[3][1], z+o[3][2], w+o[3][3]),
+		)
+	}
+}
+
+type NoiseT int
+
+const (
+	PERLIN NoiseT = iota
+	SIMPLEX
+	VALUE
+	WORLEY
+)
+
+func (n NoiseT) String() string {
+	switch n {
+	case SIMPLEX:
+		return "simplex"
+	case VALUE:
+		return "value"
+	case WORLEY:
+		return "worley"
+	}
+	return "perlin"
+}
+
+func StringToNoiseT(s string) NoiseT {
+	switch s {
+	case "simplex":
+		return SIMPLEX
+	case "value":
+		return VALUE
+	case "worley":
+		return WORLEY
+	}
+	return PERLIN
+}
+
+// Sampler samples a kind of noise as fractal Brownian motion, warped by the
+// same when Warp is more than 0, worley noise as its nearest distance.
+type Sampler struct {
+	*Noise
+	Kind    NoiseT
+	Fractal Fractal
+	Warp    float32
+}
+
+func NewSampler(seed uint64, kind NoiseT) *Sampler {
+	f := DefaultFractal()
+	f.Octaves = 1
+	return &Sampler{Noise: NewNoise(seed), Kind: kind, Fractal: f}
+}
+
+func (s *Sampler) base2() Noise2 {
+	switch s.Kind {
+	case SIMPLEX:
+		return s.Simplex2
+	case VALUE:
+		return s.Value2
+	case WORLEY:
+		return func(x, y float32) float32 {
+			f1, _ := s.Worley2(x, y)
+			return f1
+		}
+	}
+	return s.Perlin2
+}
+
+func (s *Sampler) base3() Noise3 {
+	switch s.Kind {
+	case SIMPLEX:
+		return s.Simplex3
+	case VALUE:
+		return s.Value3
+	case WORLEY:
+		return func(x, y, z float32) float32 {
+			f1, _ := s.Worley3(x, y, z)
+			return f1
+		}
+	}
+	return s.Perlin3
+}
+
+func (s *Sampler) base4() Noise4 {
+	switch s.Kind {
+	case SIMPLEX:
+		return s.Simplex4
+	case VALUE:
+		return s.Value4
+	case WORLEY:
+		return func(x, y, z, w float32) float32 {
+			f1, _ := s.Worley4(x, y, z, w)
+			return f1
+		}
+	}
+	return s.Perlin4
+}
+
+func (s *Sampler) Noise2() Noise2 {
+	f := s.Fractal.Noise2(s.base2())
+	if s.Warp > 0 {
+		return Warp2(f, f, s.Warp)
+	}
+	return f
+}
+
+func (s *Sampler) Noise3() Noise3 {
+	f := s.Fractal.Noise3(s.base3())
+	if s.Warp > 0 {
+		return Warp3(f, f, s.Warp)
+	}
+	return f
+}
+
+func (s *Sampler) Noise4() Noise4 {
+	f := s.Fractal.Noise4(s.base4())
+	if s.Warp > 0 {
+		return Warp4(f, f, s.Warp)
+	}
+	return f
+}
+
+func (s *Sampler) At2(x, y float32) float32 {
+	return s.Noise2()(x, y)
+}
+
+func (s *Sampler) At3(x, y, z float32) float32 {
+	return s.Noise3()(x, y, z)
+}
+
+func (s *Sampler) At4(x, y, z, w float32) float32 {
+	return s.Noise4()(x, y, z, w)
+}
+
+// SamplerFrom sets a sampler from {seed = 0, kind = "perlin", octaves = 1,
+// frequency = 1, lacunarity = 2, gain = 0.5, warp = 0}, what it leaves out
+// as it was, a new noise for a new seed.
+func SamplerFrom(s *Sampler, t *l.LTable) {
+	if n, ok := t.RawGetString("seed").(l.LNumber); ok && uint64(n) != s.seed {
+		s.Noise = NewNoise(uint64(n))
+	}
+	if k, ok := t.RawGetString("kind").(l.LString); ok {
+		s.Kind = StringToNoiseT(string(k))
+	}
+	if n, ok := t.RawGetString("octaves").(l.LNumber); ok {
+		s.Fractal.Octaves = int(n)
+	}
+	for k, to := range map[string]*float32{
+		"frequency":  &s.Fractal.Frequency,
+		"lacunarity": &s.Fractal.Lacunarity,
+		"gain":       &s.Fractal.Gain,
+		"warp":       &s.Warp,
+	} {
+		if n, ok := t.RawGetString(k).(l.LNumber); ok {
+			*to = float32(n)
+		}
+	}
+}
+
+// math.noise([seed | {seed, kind, octaves, frequency, lacunarity, gain,
+// warp}]), sampled as n(x, y[, z[, w]]) or n(vec)
+func lNoise(L *l.LState) int {
+	s := NewSampler(0, PERLIN)
+	switch v := L.Get(1).(type) {
+	case l.LNumber:
+		s.Noise = NewNoise(uint64(v))
+	case *l.LTable:
+		SamplerFrom(s, v)
+	}
+	fn := func(u *l.LUserData) {
+		u.Value = s
+	}
+	lua.PushNewUserData(L, fn, NOISE)
+	return 1
+}
+
+func checkSampler(L *l.LState, pos int) *Sampler {
+	ud := L.CheckUserData(pos)
+	if s, ok := ud.Value.(*Sampler); ok {
+		return s
+	}
+	L.ArgError(pos, "noise expected")
+	return nil
+}
+
+// noiseArgs are the coordinates from a position on, numbers or a vector.
+func noiseArgs(L *l.LState, from int) []float32 {
+	if ud, ok := L.Get(from).(*l.LUserData); ok {
+		if v, ok := ud.Value.(Vector); ok {
+			return v.Raw()
+		}
+	}
+	var ret []float32
+	for i := from; i <= L.GetTop() && len(ret) < 4; i++ {
+		ret = append(ret, float32(L.CheckNumber(i)))
+	}
+	if len(ret) < 2 {
+		L.RaiseError("noise takes 2 to 4 coordinates, not %d", len(ret))
+	}
+	return ret
+}
+
+type noiseKindFunc func(*Noise, []float32) (float32, float32)
+
+func noiseMember(fn noiseKindFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		s := checkSampler(L, 1)
+		if s == nil {
+			return 0
+		}
+		a, b := fn(s.Noise, noiseArgs(L, 2))
+		L.Push(l.LNumber(a))
+		if b >= 0 {
+			L.Push(l.LNumber(b))
+			return 2
+		}
+		return 1
+	}
+}
+
+func one(v float32) (float32, float32) {
+	return v, -1
+}
+
+func noisePerlin(n *Noise, c []float32) (float32, float32) {
+	switch len(c) {
+	case 2:
+		return one(n.Perlin2(c[0], c[1]))
+	case 3:
+		return one(n.Perlin3(c[0], c[1], c[2]))
+	}
+	return one(n.Perlin4(c[0], c[1], c[2], c[3]))
+}
+
+func noiseSimplex(n *Noise, c []float32) (float32, float32) {
+	switch len(c) {
+	case 2:
+		return one(n.Simplex2(c[0], c[1]))
+	case 3:
+		return one(n.Simplex3(c[0], c[1], c[2]))
+	}
+	return one(n.Simplex4(c[0], c[1], c[2], c[3]))
+}
+
+func noiseValue(n *Noise, c []float32) (float32, float32) {
+	switch len(c) {
+	case 2:
+		return one(n.Value2(c[0], c[1]))
+	case 3:
+		return one(n.Value3(c[0], c[1], c[2]))
+	}
+	return one(n.Value4(c[0], c[1], c[2], c[3]))
+}
+
+// n:worley(...) is the nearest and second nearest distances
+func noiseWorley(n *Noise, c []float32) (float32, float32) {
+	switch len(c) {
+	case 2:
+		return n.Worley2(c[0], c[1])
+	case 3:
+		return n.Worley3(c[0], c[1], c[2])
+	}
+	return n.Worley4(c[0], c[1], c[2], c[3])
+}
+
+func noiseCall(t *lua.Table, _ string) l.LGFunction {
+	return func(L *l.LState) int {
+		s := checkSampler(L, 1)
+		if s == nil {
+			return 0
+		}
+		c := noiseArgs(L, 2)
+		var v float32
+		switch len(c) {
+		case 2:
+			v = s.At2(c[0], c[1])
+		case 3:
+			v = s.At3(c[0], c[1], c[2])
+		default:
+			v = s.At4(c[0], c[1], c[2], c[3])
+		}
+		L.Push(l.LNumber(v))
+		return 1
+	}
+}
+
+// n:set{kind = "simplex", octaves = 4, ...}, as math.noise
+func noiseSet(L *l.LState) int {
+	if s := checkSampler(L, 1); s != nil {
+		SamplerFrom(s, L.CheckTable(2))
+	}
+	return 0
+}
+
+func getNoiseSeed(L *l.LState) int {
+	if s := checkSampler(L, 1); s != nil {
+		L.Push(l.LNumber(s.seed))
+		return 1
+	}
+	return 0
+}
+
+func getNoiseKind(L *l.LState) int {
+	if s := checkSampler(L, 1); s != nil {
+		L.Push(l.LString(s.Kind.String()))
+		return 1
+	}
+	return 0
+}
+
+var noiseMeta = []*lua.LMetaFunc{
+	lua.DefaultIdx("__index"),
+	lua.ImmutableNewIdx(),
+	{"__call", noiseCall},
+}
+
+var noiseProperties = map[string]l.LGFunction{
+	"seed": lua.NewProperty(getNoiseSeed, nil),
+	"kind": lua.NewProperty(getNoiseKind, nil),
+}
+
+var noiseMethods = map[string]l.LGFunction{
+	"perlin":  noiseMember(noisePerlin),
+	"simplex": noiseMember(noiseSimplex),
+	"value":   noiseMember(noiseValue),
+	"worley":  noiseMember(noiseWorley),
+	"set":     noiseSet,
+}
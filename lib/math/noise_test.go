@@ -0,0 +1,61 @@
+package math
+
+import "testing"
+
+func TestNoiseSeeded(t *testing.T) {
+	for k := PERLIN; k <= WORLEY; k++ {
+		a, b, c := NewSampler(9, k), NewSampler(9, k), NewSampler(10, k)
+		for _, s := range []*Sampler{a, b, c} {
+			s.Fractal.Octaves = 4
+			s.Warp = 0.5
+		}
+		var differ bool
+		for i := 0; i < 200; i++ {
+			x, y, z, w := float32(i)*0.37, float32(i)*0.21-3, float32(i)*0.13, float32(i)*-0.29
+			for d, pair := range [][2]float32{
+				{a.At2(x, y), b.At2(x, y)},
+				{a.At3(x, y, z), b.At3(x, y, z)},
+				{a.At4(x, y, z, w), b.At4(x, y, z, w)},
+			} {
+				if pair[0] != pair[1] {
+					t.Fatalf("%v %dd at %d: %v then %v of the same seed", k, d+2, i, pair[0], pair[1])
+				}
+			}
+			differ = differ || a.At3(x, y, z) != c.At3(x, y, z)
+		}
+		if !differ {
+			t.Errorf("%v: seeds 9 and 10 give the same noise", k)
+		}
+	}
+}
+
+func TestNoiseLattice(t *testing.T) {
+	n := NewNoise(3)
+	for i := -4; i <= 4; i++ {
+		x, y, z, w := float32(i), float32(i*3), float32(-i), float32(i*7)
+		for _, v := range []float32{n.Perlin2(x, y), n.Perlin3(x, y, z), n.Perlin4(x, y, z, w)} {
+			if v != 0 {
+				t.Fatalf("%v on the lattice at %d", v, i)
+			}
+		}
+	}
+	for i := 0; i < 500; i++ {
+		x, y, z := float32(i)*0.173, float32(i)*0.311, float32(i)*0.057
+		for _, v := range []float32{n.Perlin3(x, y, z), n.Simplex3(x, y, z), n.Value3(x, y, z)} {
+			if v < -1.01 || v > 1.01 {
+				t.Fatalf("%v out of range at %v %v %v", v, x, y, z)
+			}
+		}
+		if f1, f2 := n.Worley3(x, y, z); f1 < 0 || f2 < f1 {
+			t.Fatalf("worley %v %v", f1, f2)
+		}
+	}
+}
+
+func TestNoiseTString(t *testing.T) {
+	for k := PERLIN; k <= WORLEY; k++ {
+		if StringToNoiseT(k.String()) != k {
+			t.Errorf("%v round trip", k)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package math
+
+// Plane is the points p where Normal·p + D is 0, those where it is more in
+// front of it, Normal of unit length.
+type Plane struct {
+	Normal Vector
+	D      float32
+}
+
+// NewPlane is the plane of a normal and distance, normalized.
+func NewPlane(normal Vector, d float32) *Plane {
+	p := &Plane{vec3Of(normal), d}
+	if l := p.Normal.Len(); l > 0 {
+		p.Normal = p.Normal.Mul(1 / l)
+		p.D /= l
+	}
+	return p
+}
+
+// PlaneFromPoint is the plane through a point facing along normal.
+func PlaneFromPoint(point, normal Vector) *Plane {
+	n := vec3Of(normal).Normalize()
+	return &Plane{n, -dot3(n, point)}
+}
+
+// PlaneFromPoints is the plane through three points, facing the side they
+// wind counter clockwise seen from.
+func PlaneFromPoints(a, b, c Vector) *Plane {
+	return PlaneFromPoint(a, sub3(b, a).Cross(sub3(c, a)))
+}
+
+// Distance is how far a point is in front of the plane, less than 0 behind.
+func (p *Plane) Distance(point Vector) float32 {
+	return dot3(p.Normal, point) + p.D
+}
+
+// Closest is the point of the plane nearest a point.
+func (p *Plane) Closest(point Vector) Vector {
+	return vec3Of(point).Sub(p.Normal.Mul(p.Distance(point)))
+}
+
+// Contains is whether a point is on the plane.
+func (p *Plane) Contains(point Vector) bool {
+	return Abs(p.Distance(point)) <= geometryEpsilon
+}
+
+// classify is the side of the plane of the span from lo to hi of distances.
+func classify(lo, hi float32) Side {
+	switch {
+	case lo > geometryEpsilon:
+		return SIDE_FRONT
+	case hi < -geometryEpsilon:
+		return SIDE_BEHIND
+	}
+	return SIDE_CROSSING
+}
+
+// ClassifyPoints is the side of the plane the points are on, crossing when
+// on both or on it.
+func (p *Plane) ClassifyPoints(points ...Vector) Side {
+	lo, hi := Infinity, -Infinity
+	for _, pt := range points {
+		d := p.Distance(pt)
+		lo, hi = Min(lo, d), Max(hi, d)
+	}
+	return classify(lo, hi)
+}
+
+// IntersectPlane is the line where two planes meet, as a ray along it from
+// its point nearest the origin, none for parallel planes.
+func (p *Plane) IntersectPlane(o *Plane) (*Ray, bool) {
+	dir := p.Normal.Cross(o.Normal)
+	l2 := dir.Dot(dir)
+	if l2 < geometryEpsilon {
+		return nil, false
+	}
+	pt := o.Normal.Cross(dir).Mul(-p.D).Add(dir.Cross(p.Normal).Mul(-o.D)).Mul(1 / l2)
+	return NewRay(pt, dir), true
+}
+
+// IntersectsPlane is whether two planes meet, along a line or as one.
+func (p *Plane) IntersectsPlane(o *Plane) bool {
+	if _, ok := p.IntersectPlane(o); ok {
+		return true
+	}
+	return Abs(o.Distance(p.Closest(Vec3(0, 0, 0)))) <= geometryEpsilon
+}
+
+// IntersectPlanes is the point where three planes meet, none when any two
+// are parallel.
+func IntersectPlanes(a, b, c *Plane) (Vector, bool) {
+	bc := b.Normal.Cross(c.Normal)
+	denom := a.Normal.Dot(bc)
+	if Abs(denom) < geometryEpsilon {
+		return nil, false
+	}
+	ca := c.Normal.Cross(a.Normal)
+	ab := a.Normal.Cross(b.Normal)
+	return bc.Mul(-a.D).Add(ca.Mul(-b.D)).Add(ab.Mul(-c.D)).Mul(1 / denom), true
+}
+
+// Transform is the plane moved by a matrix, its normal by the inverse
+// transpose, unchanged for a matrix with no inverse.
+func (p *Plane) Transform(m Matrice) *Plane {
+	inv := m.Inverse()
+	if inv == nil {
+		return &Plane{vec3Of(p.Normal), p.D}
+	}
+	var n [3]float32
+	for j := 0; j < 3; j++ {
+		for i := 0; i < 3; i++ {
+			n[j] += inv.Get(i, j) * p.Normal.Get(i)
+		}
+	}
+	return PlaneFromPoint(TransformPoint(m, p.Normal.Mul(-p.D)), Vec3(n[0], n[1], n[2]))
+}
+
+type Side int
+
+const (
+	SIDE_CROSSING Side = iota
+	SIDE_FRONT
+	SIDE_BEHIND
+)
+
+func (s Side) String() string {
+	switch s {
+	case SIDE_FRONT:
+		return "front"
+	case SIDE_BEHIND:
+		return "behind"
+	}
+	return "crossing"
+}
+
+func StringToSide(s string) Side {
+	switch s {
+	case "front":
+		return SIDE_FRONT
+	case "behind":
+		return SIDE_BEHIND
+	}
+	return SIDE_CROSSING
+}
@@ -0,0 +1,75 @@
+package math
+
+import "testing"
+
+func TestPlane(t *testing.T) {
+	p := NewPlane(vec(0, 0, 2), -2)
+	cases := []struct {
+		point    Vector
+		distance float32
+		closest  Vector
+	}{
+		{vec(3, 4, 5), 4, vec(3, 4, 1)},
+		{vec(0, 0, -1), -2, vec(0, 0, 1)},
+		{vec(1, 1, 1), 0, vec(1, 1, 1)},
+	}
+	for _, c := range cases {
+		if d := p.Distance(c.point); Abs(d-c.distance) > 1e-5 {
+			t.Errorf("distance %v: %v, want %v", c.point, d, c.distance)
+		}
+		if got := p.Closest(c.point); !near(got, c.closest) {
+			t.Errorf("closest %v: %v, want %v", c.point, got, c.closest)
+		}
+	}
+	if q := PlaneFromPoints(vec(0, 0, 1), vec(1, 0, 1), vec(0, 1, 1)); !near(q.Normal, vec(0, 0, 1)) || q.D != -1 {
+		t.Errorf("from points %v %v", q.Normal, q.D)
+	}
+	sides := []struct {
+		points []Vector
+		want   Side
+	}{
+		{[]Vector{vec(0, 0, 2), vec(5, 5, 3)}, SIDE_FRONT},
+		{[]Vector{vec(0, 0, 0), vec(5, 5, -3)}, SIDE_BEHIND},
+		{[]Vector{vec(0, 0, 0), vec(0, 0, 2)}, SIDE_CROSSING},
+		{[]Vector{vec(0, 0, 1)}, SIDE_CROSSING},
+	}
+	for _, c := range sides {
+		if got := p.ClassifyPoints(c.points...); got != c.want {
+			t.Errorf("classify %v: %v, want %v", c.points, got, c.want)
+		}
+		if StringToSide(c.want.String()) != c.want {
+			t.Errorf("side %v round trip", c.want)
+		}
+	}
+}
+
+func TestIntersectPlanes(t *testing.T) {
+	x, y, z := NewPlane(vec(1, 0, 0), -1), NewPlane(vec(0, 1, 0), -2), NewPlane(vec(0, 0, 1), -3)
+	if pt, ok := IntersectPlanes(x, y, z); !ok || !near(pt, vec(1, 2, 3)) {
+		t.Errorf("three planes %v %v", pt, ok)
+	}
+	if _, ok := IntersectPlanes(x, NewPlane(vec(2, 0, 0), 1), z); ok {
+		t.Error("parallel planes meet")
+	}
+	line, ok := x.IntersectPlane(y)
+	if !ok || !near(line.Origin, vec(1, 2, 0)) || !near(line.Direction, vec(0, 0, 1)) {
+		t.Errorf("line %v", line)
+	}
+	if _, ok := x.IntersectPlane(NewPlane(vec(-1, 0, 0), 4)); ok {
+		t.Error("parallel planes cross")
+	}
+}
+
+func TestPlaneTransform(t *testing.T) {
+	m := translation(1, 2, 3).MulMatrice(IdentityMatrix(MAT4).Rotate(SetQuatFromAxisAngle(Quat(1, 0, 0, 0), vec(0, 1, 0), 0.5)))
+	p := NewPlane(vec(0, 1, 0), -1)
+	q := p.Transform(m)
+	for _, pt := range []Vector{vec(3, 1, -2), vec(0, 1, 0), vec(-4, 1, 7)} {
+		if d := q.Distance(TransformPoint(m, pt)); Abs(d) > 1e-4 {
+			t.Errorf("%v off the moved plane by %v", pt, d)
+		}
+	}
+	if d := q.Distance(TransformPoint(m, vec(0, 3, 0))); Abs(d-2) > 1e-4 {
+		t.Errorf("distance not kept: %v", d)
+	}
+}
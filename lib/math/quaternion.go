@@ -14,9 +14,11 @@ type Quaternion interface {
 	Length
 	Clone() Quaternion
 	Conjugate() Quaternion
+	Dot(Quaternion) float32
 	Inverse() Quaternion
 	Mul(Quaternion) Quaternion
 	Normalize() Quaternion
+	Slerp(Quaternion, float32) Quaternion
 }
 
 type quaternion struct {
@@ -173,54 +175,50 @@ func multiplyQuats(a, b Quaternion) Quaternion {
 	return nq
 }
 
+// Slerp spherically interpolates q towards o by t, updating and returning q.
 func (q *quaternion) Slerp(o Quaternion, t float32) Quaternion {
 	switch {
 	case t == 0:
 		return q
 	case t == 1:
-		return o.Clone()
+		q.Update(o.Raw()...)
+		return q
 	}
 
-	w1, w2 := q.Get(0), o.Get(0)
-	x1, x2 := q.Get(1), o.Get(1)
-	y1, y2 := q.Get(2), o.Get(2)
-	z1, z2 := q.Get(3), o.Get(3)
+	w1, x1, y1, z1 := q.Get(0), q.Get(1), q.Get(2), q.Get(3)
+	w2, x2, y2, z2 := o.Get(0), o.Get(1), o.Get(2), o.Get(3)
 
 	cosHalfTheta := w1*w2 + x1*x2 + y1*y2 + z1*z2
 
-	switch {
-	case cosHalfTheta < 0:
-		w1 = -w2
-		x1 = -x2
-		y1 = -y2
-		z1 = -z2
+	// take the short way around
+	if cosHalfTheta < 0 {
+		w2, x2, y2, z2 = -w2, -x2, -y2, -z2
 		cosHalfTheta = -cosHalfTheta
-	case cosHalfTheta > 0 && cosHalfTheta < 1.0:
-		q.Update(w2, x2, y2, z2)
-	case cosHalfTheta >= 1.0:
-		return q
 	}
 
-	halfTheta := Acos(cosHalfTheta)
-	sinHalfTheta := Sqrt(1.0 - cosHalfTheta + cosHalfTheta)
+	if cosHalfTheta >= 1.0 {
+		return q
+	}
 
-	if Abs(sinHalfTheta) < 0.001 {
-		nw := 0.5 * (w1 + q.Get(0))
-		nx := 0.5 * (x1 + q.Get(1))
-		ny := 0.5 * (y1 + q.Get(2))
-		nz := 0.5 * (z1 + q.Get(3))
-		q.Update(nw, nx, ny, nz)
+	sqrSinHalfTheta := 1.0 - cosHalfTheta*cosHalfTheta
+	if sqrSinHalfTheta <= 1e-6 {
+		s := 1 - t
+		q.Update(s*w1+t*w2, s*x1+t*x2, s*y1+t*y2, s*z1+t*z2)
+		q.Normalize()
 		return q
 	}
 
+	sinHalfTheta := Sqrt(sqrSinHalfTheta)
+	halfTheta := Atan2(sinHalfTheta, cosHalfTheta)
 	ratioA := Sin((1-t)*halfTheta) / sinHalfTheta
 	ratioB := Sin(t*halfTheta) / sinHalfTheta
 
-	nw := (w1*ratioA + q.Get(0)*ratioB)
-	nx := (x1*ratioA + q.Get(1)*ratioB)
-	ny := (y1*ratioA + q.Get(2)*ratioB)
-	nz := (z1*ratioA + q.Get(3)*ratioB)
-	q.Update(nw, nx, ny, nz)
+	q.Update(
+		w1*ratioA+w2*ratioB,
+		x1*ratioA+x2*ratioB,
+		y1*ratioA+y2*ratioB,
+		z1*ratioA+z2*ratioB,
+	)
 	return q
 }
 
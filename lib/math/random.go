@@ -0,0 +1,466 @@
+package math
+
+import (
+	glm "math"
+	"math/bits"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+// Source is a seeded stream of uniformly distributed 64 bit values, the same
+// stream for the same seed.
+type Source interface {
+	Seed(uint64)
+	Uint64() uint64
+}
+
+// splitmix64 steps a seed, expanding it into the state of a generator.
+func splitmix64(s *uint64) uint64 {
+	*s += 0x9E3779B97F4A7C15
+	z := *s
+	z = (z ^ z>>30) * 0xBF58476D1CE4E5B9
+	z = (z ^ z>>27) * 0x94D049BB133111EB
+	return z ^ z>>31
+}
+
+// PCG is a PCG32 generator, 64 bits of state giving 32 of output a step,
+// its stream chosen by its increment.
+type PCG struct {
+	state, inc uint64
+}
+
+const pcgDefaultStream = 0xda3e39cb94b95bdb
+
+func NewPCG(seed, stream uint64) *PCG {
+	p := &PCG{}
+	p.SeedStream(seed, stream)
+	return p
+}
+
+func (p *PCG) Seed(seed uint64) {
+	p.SeedStream(seed, pcgDefaultStream)
+}
+
+// SeedStream seeds the generator on one of its 2^63 streams, those of
+// different streams not overlapping for the same seed.
+func (p *PCG) SeedStream(seed, stream uint64) {
+	p.state = 0
+	p.inc = stream<<1 | 1
+	p.Uint32()
+	p.state += seed
+	p.Uint32()
+}
+
+func (p *PCG) Uint32() uint32 {
+	old := p.state
+	p.state = old*6364136223846793005 + p.inc
+	x := uint32((old>>18 ^ old) >> 27)
+	return bits.RotateLeft32(x, -int(old>>59))
+}
+
+func (p *PCG) Uint64() uint64 {
+	return uint64(p.Uint32())<<32 | uint64(p.Uint32())
+}
+
+// Xoshiro is a xoshiro256** generator, 256 bits of state seeded through
+// splitmix64.
+type Xoshiro struct {
+	s [4]uint64
+}
+
+func NewXoshiro(seed uint64) *Xoshiro {
+	x := &Xoshiro{}
+	x.Seed(seed)
+	return x
+}
+
+func (x *Xoshiro) Seed(seed uint64) {
+	for i := range x.s {
+		x.s[i] = splitmix64(&seed)
+	}
+}
+
+func (x *Xoshiro) Uint64() uint64 {
+	s := &x.s
+	r := bits.RotateLeft64(s[1]*5, 7) * 9
+	t := s[1] << 17
+	s[2] ^= s[0]
+	s[3] ^= s[1]
+	s[1] ^= s[2]
+	s[0] ^= s[3]
+	s[2] ^= t
+	s[3] = bits.RotateLeft64(s[3], 45)
+	return r
+}
+
+var xoshiroJump = [4]uint64{0x180ec6d33cfd0aba, 0xd5a61266f0c9392c, 0xa9582618e03fc9aa, 0x39abdc4529b1661c}
+
+// Jump advances the generator 2^128 steps, as many streams apart as it is
+// jumped that will not overlap.
+func (x *Xoshiro) Jump() {
+	var s [4]uint64
+	for _, j := range xoshiroJump {
+		for b := uint(0); b < 64; b++ {
+			if j&(1<<b) != 0 {
+				for i := range s {
+					s[i] ^= x.s[i]
+				}
+			}
+			x.Uint64()
+		}
+	}
+	x.s = s
+}
+
+// Rand draws the distributions procedural content is made from out of a
+// Source, apart from the global rand and the same for the same seed.
+type Rand struct {
+	src      Source
+	spare    float64
+	hasSpare bool
+}
+
+func NewRand(src Source) *Rand {
+	return &Rand{src: src}
+}
+
+// Seeded is a Rand of a PCG source of a seed.
+func Seeded(seed uint64) *Rand {
+	return NewRand(NewPCG(seed, pcgDefaultStream))
+}
+
+func (r *Rand) Source() Source {
+	return r.src
+}
+
+func (r *Rand) Seed(seed uint64) {
+	r.src.Seed(seed)
+	r.hasSpare = false
+}
+
+func (r *Rand) Uint64() uint64 {
+	return r.src.Uint64()
+}
+
+// Uint64n is in [0, n), without the bias of a modulo.
+func (r *Rand) Uint64n(n uint64) uint64 {
+	if n == 0 {
+		return 0
+	}
+	hi, lo := bits.Mul64(r.src.Uint64(), n)
+	if lo < n {
+		floor := -n % n
+		for lo < floor {
+			hi, lo = bits.Mul64(r.src.Uint64(), n)
+		}
+	}
+	return hi
+}
+
+// Intn is in [0, n), 0 for n of 0 or less.
+func (r *Rand) Intn(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return int(r.Uint64n(uint64(n)))
+}
+
+// IntRange is in [lo, hi], inclusive.
+func (r *Rand) IntRange(lo, hi int) int {
+	if hi < lo {
+		lo, hi = hi, lo
+	}
+	return lo + int(r.Uint64n(uint64(hi-lo)+1))
+}
+
+// Float64 is in [0, 1).
+func (r *Rand) Float64() float64 {
+	return float64(r.src.Uint64()>>11) / (1 << 53)
+}
+
+// Float32 is in [0, 1).
+func (r *Rand) Float32() float32 {
+	return float32(r.src.Uint64()>>40) / (1 << 24)
+}
+
+// Range is in [lo, hi).
+func (r *Rand) Range(lo, hi float32) float32 {
+	return lo + (hi-lo)*r.Float32()
+}
+
+// Normal is normally distributed about mean, of standard deviation sd.
+func (r *Rand) Normal(mean, sd float32) float32 {
+	if r.hasSpare {
+		r.hasSpare = false
+		return mean + sd*float32(r.spare)
+	}
+	var u, v, s float64
+	for s == 0 || s >= 1 {
+		u, v = 2*r.Float64()-1, 2*r.Float64()-1
+		s = u*u + v*v
+	}
+	f := glm.Sqrt(-2 * glm.Log(s) / s)
+	r.spare, r.hasSpare = v*f, true
+	return mean + sd*float32(u*f)
+}
+
+// Perm is a permutation of [0, n).
+func (r *Rand) Perm(n int) []int {
+	p := make([]int, n)
+	for i := range p {
+		p[i] = i
+	}
+	r.Shuffle(n, func(i, j int) {
+		p[i], p[j] = p[j], p[i]
+	})
+	return p
+}
+
+// Shuffle puts n elements in random order by swapping them.
+func (r *Rand) Shuffle(n int, swap func(i, j int)) {
+	for i := n - 1; i > 0; i-- {
+		swap(i, r.Intn(i+1))
+	}
+}
+
+// OnSphere is a point on the sphere of radius about the origin, uniformly
+// spread over its surface.
+func (r *Rand) OnSphere(radius float32) Vector {
+	z := 2*r.Float64() - 1
+	phi := 2 * glm.Pi * r.Float64()
+	s := glm.Sqrt(1 - z*z)
+	return Vec3(
+		radius*float32(s*glm.Cos(phi)),
+		radius*float32(s*glm.Sin(phi)),
+		radius*float32(z),
+	)
+}
+
+// InSphere is a point in the sphere of radius about the origin, uniformly
+// spread through its volume.
+func (r *Rand) InSphere(radius float32) Vector {
+	return r.OnSphere(radius * float32(glm.Cbrt(r.Float64())))
+}
+
+// PoissonDiskCells is the most grid cells PoissonDisk works over, each
+// holding a point at most, so the most points it returns.
+const PoissonDiskCells = 1 << 21
+
+var PoissonDiskError = xrror.Xrror("a radius of %v over %v by %v needs more than %d cells").Out
+
+// PoissonDisk is points over width by height from the origin, none nearer
+// another than radius, tried k times around each before no more fit there,
+// k of 30 being usual, Bridson's algorithm. A radius too small for the area
+// to fit PoissonDiskCells is an error.
+func (r *Rand) PoissonDisk(width, height, radius float32, k int) ([]Vector, error) {
+	if width <= 0 || height <= 0 || radius <= 0 {
+		return nil, nil
+	}
+	if k <= 0 {
+		k = 30
+	}
+	cell := float64(radius) / glm.Sqrt2
+	fw := glm.Ceil(float64(width)/cell) + 1
+	fh := glm.Ceil(float64(height)/cell) + 1
+	if fw*fh > PoissonDiskCells {
+		return nil, PoissonDiskError(radius, width, height, PoissonDiskCells)
+	}
+	gw, gh := int(fw), int(fh)
+	grid := make([]int, gw*gh)
+	for i := range grid {
+		grid[i] = -1
+	}
+	var pts [][2]float32
+	var active []int
+	add := func(x, y float32) {
+		grid[int(float64(y)/cell)*gw+int(float64(x)/cell)] = len(pts)
+		active = append(active, len(pts))
+		pts = append(pts, [2]float32{x, y})
+	}
+	fits := func(x, y float32) bool {
+		if x < 0 || y < 0 || x >= width || y >= height {
+			return false
+		}
+		cx, cy := int(float64(x)/cell), int(float64(y)/cell)
+		for j := cy - 2; j <= cy+2; j++ {
+			for i := cx - 2; i <= cx+2; i++ {
+				if i < 0 || j < 0 || i >= gw || j >= gh {
+					continue
+				}
+				if p := grid[j*gw+i]; p >= 0 {
+					dx, dy := pts[p][0]-x, pts[p][1]-y
+					if dx*dx+dy*dy < radius*radius {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+	add(r.Range(0, width), r.Range(0, height))
+	for len(active) > 0 {
+		ai := r.Intn(len(active))
+		p := pts[active[ai]]
+		found := false
+		for t := 0; t < k; t++ {
+			a := 2 * glm.Pi * r.Float64()
+			d := float64(radius) * glm.Sqrt(1+3*r.Float64())
+			x, y := p[0]+float32(d*glm.Cos(a)), p[1]+float32(d*glm.Sin(a))
+			if fits(x, y) {
+				add(x, y)
+				found = true
+				break
+			}
+		}
+		if !found {
+			active[ai] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+	ret := make([]Vector, len(pts))
+	for i, p := range pts {
+		ret[i] = Vec2(p[0], p[1])
+	}
+	return ret, nil
+}
+
+func pushVector(L *l.LState, v Vector) {
+	fn := func(u *l.LUserData) {
+		u.Value = v
+	}
+	lua.PushNewUserData(L, fn, v.Tag())
+}
+
+// math.rng([seed[, "pcg" | "xoshiro"]])
+func lRNG(L *l.LState) int {
+	seed := uint64(L.OptInt64(1, 0))
+	var src Source
+	switch L.OptString(2, "pcg") {
+	case "xoshiro":
+		src = NewXoshiro(seed)
+	case "pcg":
+		src = NewPCG(seed, pcgDefaultStream)
+	default:
+		L.ArgError(2, "pcg or xoshiro expected")
+		return 0
+	}
+	fn := func(u *l.LUserData) {
+		u.Value = NewRand(src)
+	}
+	lua.PushNewUserData(L, fn, RNG)
+	return 1
+}
+
+type randMemberFunc func(*l.LState, *Rand) int
+
+func checkRand(L *l.LState, pos int) *Rand {
+	ud := L.CheckUserData(pos)
+	if r, ok := ud.Value.(*Rand); ok {
+		return r
+	}
+	L.ArgError(pos, "rng expected")
+	return nil
+}
+
+func randMember(fn randMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if r := checkRand(L, 1); r != nil {
+			return fn(L, r)
+		}
+		return 0
+	}
+}
+
+func randSeed(L *l.LState, r *Rand) int {
+	r.Seed(uint64(L.CheckInt64(2)))
+	return 0
+}
+
+// r:float([lo, hi]), in [0, 1) by default
+func randFloat(L *l.LState, r *Rand) int {
+	if L.GetTop() < 3 {
+		L.Push(l.LNumber(r.Float64()))
+		return 1
+	}
+	L.Push(l.LNumber(r.Range(float32(L.CheckNumber(2)), float32(L.CheckNumber(3)))))
+	return 1
+}
+
+// r:int(lo, hi), inclusive, or r:int(n) in [1, n] as math.random
+func randInt(L *l.LState, r *Rand) int {
+	if L.GetTop() < 3 {
+		L.Push(l.LNumber(r.IntRange(1, L.CheckInt(2))))
+		return 1
+	}
+	L.Push(l.LNumber(r.IntRange(L.CheckInt(2), L.CheckInt(3))))
+	return 1
+}
+
+// r:normal([mean, sd]), 0 and 1 by default
+func randNormal(L *l.LState, r *Rand) int {
+	L.Push(l.LNumber(r.Normal(float32(L.OptNumber(2, 0)), float32(L.OptNumber(3, 1)))))
+	return 1
+}
+
+func randOnSphere(L *l.LState, r *Rand) int {
+	pushVector(L, r.OnSphere(float32(L.OptNumber(2, 1))))
+	return 1
+}
+
+func randInSphere(L *l.LState, r *Rand) int {
+	pushVector(L, r.InSphere(float32(L.OptNumber(2, 1))))
+	return 1
+}
+
+// r:shuffle(t), in place, returning t
+func randShuffle(L *l.LState, r *Rand) int {
+	t := L.CheckTable(2)
+	r.Shuffle(t.Len(), func(i, j int) {
+		a, b := t.RawGetInt(i+1), t.RawGetInt(j+1)
+		t.RawSetInt(i+1, b)
+		t.RawSetInt(j+1, a)
+	})
+	L.Push(t)
+	return 1
+}
+
+// r:poisson(width, height, radius[, k]), a table of vec2
+func randPoisson(L *l.LState, r *Rand) int {
+	pts, err := r.PoissonDisk(
+		float32(L.CheckNumber(2)),
+		float32(L.CheckNumber(3)),
+		float32(L.CheckNumber(4)),
+		L.OptInt(5, 30),
+	)
+	if err != nil {
+		L.RaiseError("%s", err)
+		return 0
+	}
+	t := L.CreateTable(len(pts), 0)
+	for _, p := range pts {
+		pushVector(L, p)
+		t.Append(L.Get(-1))
+		L.Pop(1)
+	}
+	L.Push(t)
+	return 1
+}
+
+var rngMeta = []*lua.LMetaFunc{
+	lua.DefaultIdx("__index"),
+	lua.ImmutableNewIdx(),
+}
+
+var rngMethods = map[string]l.LGFunction{
+	"seed":     randMember(randSeed),
+	"float":    randMember(randFloat),
+	"int":      randMember(randInt),
+	"normal":   randMember(randNormal),
+	"onsphere": randMember(randOnSphere),
+	"insphere": randMember(randInSphere),
+	"shuffle":  randMember(randShuffle),
+	"poisson":  randMember(randPoisson),
+}
@@ -0,0 +1,124 @@
+package math
+
+import "testing"
+
+func TestSourcesSeeded(t *testing.T) {
+	sources := []struct {
+		name string
+		new  func(seed uint64) Source
+	}{
+		{"pcg", func(seed uint64) Source { return NewPCG(seed, pcgDefaultStream) }},
+		{"xoshiro", func(seed uint64) Source { return NewXoshiro(seed) }},
+	}
+	for _, s := range sources {
+		a, b, c := s.new(42), s.new(42), s.new(43)
+		var differ bool
+		for i := 0; i < 1000; i++ {
+			x, y := a.Uint64(), b.Uint64()
+			if x != y {
+				t.Fatalf("%s: step %d of the same seed, %x then %x", s.name, i, x, y)
+			}
+			differ = differ || x != c.Uint64()
+		}
+		if !differ {
+			t.Errorf("%s: seeds 42 and 43 give the same stream", s.name)
+		}
+		a.Seed(42)
+		if x, y := a.Uint64(), s.new(42).Uint64(); x != y {
+			t.Errorf("%s: reseeded %x, want %x", s.name, x, y)
+		}
+	}
+
+	p, q := NewPCG(42, 1), NewPCG(42, 2)
+	if p.Uint64() == q.Uint64() && p.Uint64() == q.Uint64() {
+		t.Error("pcg streams 1 and 2 overlap")
+	}
+	x, y := NewXoshiro(42), NewXoshiro(42)
+	x.Jump()
+	y.Jump()
+	if x.Uint64() != y.Uint64() || x.Uint64() == NewXoshiro(42).Uint64() {
+		t.Error("xoshiro jump")
+	}
+}
+
+// draws is one of each distribution of a Rand.
+func draws(r *Rand) []float32 {
+	out := []float32{
+		float32(r.Intn(100)),
+		float32(r.IntRange(-5, 5)),
+		float32(r.Float64()),
+		r.Float32(),
+		r.Range(-1, 1),
+		r.Normal(0, 1),
+		r.Normal(0, 1),
+	}
+	for _, p := range r.Perm(8) {
+		out = append(out, float32(p))
+	}
+	for _, v := range []Vector{r.OnSphere(2), r.InSphere(2)} {
+		out = append(out, v.Get(0), v.Get(1), v.Get(2))
+	}
+	return out
+}
+
+func TestRandSeeded(t *testing.T) {
+	a, b := Seeded(7), Seeded(7)
+	for i := 0; i < 100; i++ {
+		x, y := draws(a), draws(b)
+		for j := range x {
+			if x[j] != y[j] {
+				t.Fatalf("draw %d.%d of the same seed, %v then %v", i, j, x[j], y[j])
+			}
+		}
+	}
+	a.Seed(7)
+	x, y := draws(a), draws(Seeded(7))
+	for j := range x {
+		if x[j] != y[j] {
+			t.Fatalf("reseeded draw %d, %v want %v", j, x[j], y[j])
+		}
+	}
+}
+
+func TestPoissonDisk(t *testing.T) {
+	pts, err := Seeded(7).PoissonDisk(20, 10, 1, 30)
+	if err != nil || len(pts) < 50 {
+		t.Fatalf("%d points, %v", len(pts), err)
+	}
+	for i, a := range pts {
+		if x, y := a.Get(0), a.Get(1); x < 0 || y < 0 || x >= 20 || y >= 10 {
+			t.Fatalf("point %v out of bounds", a)
+		}
+		for _, b := range pts[i+1:] {
+			if d := a.Sub(b).Len(); d < 1 {
+				t.Fatalf("points %v and %v %v apart", a, b, d)
+			}
+		}
+	}
+	again, err := Seeded(7).PoissonDisk(20, 10, 1, 30)
+	if err != nil || len(again) != len(pts) {
+		t.Fatalf("same seed, %d then %d points", len(pts), len(again))
+	}
+	for i := range pts {
+		if again[i].Get(0) != pts[i].Get(0) || again[i].Get(1) != pts[i].Get(1) {
+			t.Fatalf("same seed, point %d %v then %v", i, pts[i], again[i])
+		}
+	}
+	if pts, err := Seeded(7).PoissonDisk(10, 10, 0, 30); pts != nil || err != nil {
+		t.Error("zero radius")
+	}
+}
+
+func TestPoissonDiskTooSmall(t *testing.T) {
+	pts, err := Seeded(7).PoissonDisk(1000, 1000, 1e-4, 30)
+	if err == nil || pts != nil {
+		t.Fatalf("%d points of an uncapped grid, %v", len(pts), err)
+	}
+	if want := PoissonDiskError(float32(1e-4), float32(1000), float32(1000), PoissonDiskCells); err.Error() != want.Error() {
+		t.Errorf("error %q, want %q", err, want)
+	}
+	// the smallest radius a 1000 by 1000 area fits in the cells
+	if _, err := Seeded(7).PoissonDisk(1000, 1000, 1000*1.4143/1447, 1); err != nil {
+		t.Errorf("radius within the cap, %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package math
+
+// Ray is a half line from an origin along a normalized direction.
+type Ray struct {
+	Origin    Vector
+	Direction Vector
+}
+
+// NewRay returns a ray with its direction normalized, a zero direction kept
+// as is and hitting nothing.
+func NewRay(origin, direction Vector) *Ray {
+	var d Vector = Vec3(direction.Get(0), direction.Get(1), direction.Get(2))
+	if d.Len() > 0 {
+		d = d.Normalize()
+	}
+	return &Ray{
+		Origin:    Vec3(origin.Get(0), origin.Get(1), origin.Get(2)),
+		Direction: d,
+	}
+}
+
+// Unproject returns the ray through normalized device coordinates x and y,
+// using the inverse of a projection * view matrix.
+func Unproject(x, y float32, inverseViewProjection Matrice) *Ray {
+	near := unprojectPoint(inverseViewProjection, x, y, -1)
+	far := unprojectPoint(inverseViewProjection, x, y, 1)
+	if near == nil || far == nil {
+		return nil
+	}
+	return NewRay(near, far.Sub(near))
+}
+
+func unprojectPoint(m Matrice, x, y, z float32) Vector {
+	p := m.MulVec(Vec4(x, y, z, 1))
+	if p == nil {
+		return nil
+	}
+	w := p.Get(3)
+	if w == 0 {
+		return nil
+	}
+	return Vec3(p.Get(0)/w, p.Get(1)/w, p.Get(2)/w)
+}
+
+// At returns the point at distance t along the ray.
+func (r *Ray) At(t float32) Vector {
+	return r.Origin.Add(r.Direction.Mul(t))
+}
+
+// Transform returns a new ray transformed by the provided 4x4 matrix. The
+// direction is not renormalized, so that distances along the returned ray
+// correspond to distances along the original one.
+func (r *Ray) Transform(m Matrice) *Ray {
+	o := m.MulVec(Vec4(r.Origin.Get(0), r.Origin.Get(1), r.Origin.Get(2), 1))
+	d := m.MulVec(Vec4(r.Direction.Get(0), r.Direction.Get(1), r.Direction.Get(2), 0))
+	return &Ray{
+		Origin:    Vec3(o.Get(0), o.Get(1), o.Get(2)),
+		Direction: Vec3(d.Get(0), d.Get(1), d.Get(2)),
+	}
+}
+
+// IntersectSphere returns the nearest non negative distance along the ray to
+// the sphere surface.
+func (r *Ray) IntersectSphere(center Vector, radius float32) (float32, bool) {
+	oc := r.Origin.Sub(center)
+	a := r.Direction.Dot(r.Direction)
+	b := oc.Dot(r.Direction)
+	c := oc.Dot(oc) - radius*radius
+	disc := b*b - a*c
+	if disc < 0 || a == 0 {
+		return 0, false
+	}
+	sq := Sqrt(disc)
+	t := (-b - sq) / a
+	if t < 0 {
+		t = (-b + sq) / a
+	}
+	if t < 0 {
+		return 0, false
+	}
+	return t, true
+}
+
+// IntersectBox returns the nearest non negative distance along the ray to an
+// axis aligned box, zero if the origin is inside the box.
+func (r *Ray) IntersectBox(min, max Vector) (float32, bool) {
+	tmin, tmax := -Infinity, Infinity
+	for i := 0; i < 3; i++ {
+		o, d := r.Origin.Get(i), r.Direction.Get(i)
+		lo, hi := min.Get(i), max.Get(i)
+		if d == 0 {
+			if o < lo || o > hi {
+				return 0, false
+			}
+			continue
+		}
+		t1, t2 := (lo-o)/d, (hi-o)/d
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		tmin = Max(tmin, t1)
+		tmax = Min(tmax, t2)
+		if tmin > tmax {
+			return 0, false
+		}
+	}
+	if tmax < 0 {
+		return 0, false
+	}
+	return Max(tmin, 0), true
+}
+
+// IntersectTriangle returns the distance along the ray and the barycentric u,
+// v coordinates of the intersection with triangle a, b, c (Möller–Trumbore).
+// Back facing triangles are ignored when cull is true.
+func (r *Ray) IntersectTriangle(a, b, c Vector, cull bool) (t, u, v float32, ok bool) {
+	const epsilon = 1e-7
+	e1 := b.Sub(a)
+	e2 := c.Sub(a)
+	p := r.Direction.Cross(e2)
+	det := e1.Dot(p)
+	if cull && det < epsilon {
+		return 0, 0, 0, false
+	}
+	if Abs(det) < epsilon {
+		return 0, 0, 0, false
+	}
+	inv := 1 / det
+	s := r.Origin.Sub(a)
+	u = s.Dot(p) * inv
+	if u < 0 || u > 1 {
+		return 0, 0, 0, false
+	}
+	q := s.Cross(e1)
+	v = r.Direction.Dot(q) * inv
+	if v < 0 || u+v > 1 {
+		return 0, 0, 0, false
+	}
+	t = e2.Dot(q) * inv
+	if t < 0 {
+		return 0, 0, 0, false
+	}
+	return t, u, v, true
+}
+
+// IntersectPlane returns the non negative distance along the ray to a plane.
+func (r *Ray) IntersectPlane(p *Plane) (float32, bool) {
+	denom := dot3(p.Normal, r.Direction)
+	if Abs(denom) < geometryEpsilon {
+		return 0, false
+	}
+	t := -p.Distance(r.Origin) / denom
+	if t < 0 {
+		return 0, false
+	}
+	return t, true
+}
+
+// IntersectOBB returns the nearest non negative distance along the ray to an
+// oriented box, zero if the origin is inside the box.
+func (r *Ray) IntersectOBB(o *OBB) (float32, bool) {
+	l := o.local(r.Origin)
+	local := &Ray{
+		Origin:    Vec3(l[0], l[1], l[2]),
+		Direction: Vec3(dot3(o.Axes[0], r.Direction), dot3(o.Axes[1], r.Direction), dot3(o.Axes[2], r.Direction)),
+	}
+	return local.IntersectBox(o.Half.Mul(-1), o.Half)
+}
+
+// Closest returns the point of the ray nearest a point.
+func (r *Ray) Closest(p Vector) Vector {
+	l2 := dot3(r.Direction, r.Direction)
+	if l2 == 0 {
+		return vec3Of(r.Origin)
+	}
+	return r.At(Max(dot3(sub3(p, r.Origin), r.Direction)/l2, 0))
+}
+
+// Distance returns how far a point is from the ray.
+func (r *Ray) Distance(p Vector) float32 {
+	return sub3(r.Closest(p), p).Len()
+}
+
+// Cast returns the nearest non negative distance along the ray to a plane,
+// sphere, box, oriented box or triangle, either face of the triangle, none
+// for a ray of zero direction.
+func (r *Ray) Cast(shape interface{}) (float32, bool) {
+	if dot3(r.Direction, r.Direction) == 0 {
+		return 0, false
+	}
+	switch s := shape.(type) {
+	case *Plane:
+		return r.IntersectPlane(s)
+	case *Sphere:
+		return r.IntersectSphere(s.Center, s.Radius)
+	case *AABB:
+		return r.IntersectBox(s.Min, s.Max)
+	case *OBB:
+		return r.IntersectOBB(s)
+	case *Triangle:
+		t, _, _, ok := r.IntersectTriangle(s.A, s.B, s.C, false)
+		return t, ok
+	}
+	return 0, false
+}
@@ -0,0 +1,66 @@
+package math
+
+import "testing"
+
+func TestRayCast(t *testing.T) {
+	cases := []struct {
+		name  string
+		ray   *Ray
+		shape interface{}
+		t     float32
+		hit   bool
+	}{
+		{"plane", NewRay(vec(0, 0, 5), vec(0, 0, -2)), ground, 5, true},
+		{"plane behind", NewRay(vec(0, 0, 5), vec(0, 0, 1)), ground, 0, false},
+		{"plane parallel", NewRay(vec(0, 0, 5), vec(1, 0, 0)), ground, 0, false},
+		{"sphere", NewRay(vec(-5, 0, 0), vec(1, 0, 0)), ball, 4, true},
+		{"sphere from inside", NewRay(vec(0, 0, 0), vec(1, 0, 0)), ball, 1, true},
+		{"sphere miss", NewRay(vec(-5, 2, 0), vec(1, 0, 0)), ball, 0, false},
+		{"aabb", NewRay(vec(-5, 0, 0), vec(1, 0, 0)), unitBox, 4, true},
+		{"aabb from inside", NewRay(vec(0, 0, 0), vec(1, 0, 0)), unitBox, 0, true},
+		{"aabb behind", NewRay(vec(5, 0, 0), vec(1, 0, 0)), unitBox, 0, false},
+		{"obb", NewRay(vec(-5, 0, 0), vec(1, 0, 0)), turned, 5 - Sqrt(2), true},
+		{"obb miss", NewRay(vec(-5, 1.5, 0), vec(1, 0, 0)), turned, 0, false},
+		{"triangle", NewRay(vec(0, 0.5, 3), vec(0, 0, -1)), flatTri, 3, true},
+		{"triangle back", NewRay(vec(0, 0.5, -3), vec(0, 0, 1)), flatTri, 3, true},
+		{"triangle miss", NewRay(vec(0, 1.5, 3), vec(0, 0, -1)), flatTri, 0, false},
+		{"segment", NewRay(vec(0, 0, 0), vec(1, 0, 0)), xSegment, 0, false},
+	}
+	for _, c := range cases {
+		d, hit := c.ray.Cast(c.shape)
+		if hit != c.hit || (hit && Abs(d-c.t) > 1e-4) {
+			t.Errorf("%s: %v %v, want %v %v", c.name, d, hit, c.t, c.hit)
+		}
+	}
+}
+
+func TestRayZeroDirection(t *testing.T) {
+	r := NewRay(vec(0, 0, 0), vec(0, 0, 0))
+	if d := r.Direction; d.Get(0) != 0 || d.Get(1) != 0 || d.Get(2) != 0 {
+		t.Fatalf("direction %v", d)
+	}
+	for _, s := range []interface{}{ground, ball, unitBox, turned, flatTri} {
+		if d, hit := r.Cast(s); hit {
+			t.Errorf("zero ray hit %T at %v", s, d)
+		}
+		if _, ok := Intersects(r, s); !ok {
+			t.Errorf("zero ray against %T untested", s)
+		}
+	}
+}
+
+func TestRayClosest(t *testing.T) {
+	r := NewRay(vec(0, 0, 0), vec(2, 0, 0))
+	cases := []struct {
+		point, want Vector
+	}{
+		{vec(-1, 1, 0), vec(0, 0, 0)},
+		{vec(2, 1, 0), vec(2, 0, 0)},
+		{vec(5, 0, 0), vec(5, 0, 0)},
+	}
+	for _, c := range cases {
+		if got := r.Closest(c.point); !near(got, c.want) {
+			t.Errorf("closest %v: %v, want %v", c.point, got, c.want)
+		}
+	}
+}
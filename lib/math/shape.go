@@ -0,0 +1,659 @@
+package math
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+const geometryEpsilon = 1e-5
+
+func vec3Of(v Vector) Vector {
+	return Vec3(v.Get(0), v.Get(1), v.Get(2))
+}
+
+func sub3(a, b Vector) Vector {
+	return Vec3(a.Get(0)-b.Get(0), a.Get(1)-b.Get(1), a.Get(2)-b.Get(2))
+}
+
+func dot3(a, b Vector) float32 {
+	return a.Get(0)*b.Get(0) + a.Get(1)*b.Get(1) + a.Get(2)*b.Get(2)
+}
+
+// TransformPoint is a point moved by a 3x3 or 4x4 matrix, divided by w for
+// a projection.
+func TransformPoint(m Matrice, p Vector) Vector {
+	if m.Cols() == 3 {
+		return m.MulVec(vec3Of(p))
+	}
+	v := m.MulVec(Vec4(p.Get(0), p.Get(1), p.Get(2), 1))
+	if w := v.Get(3); w != 0 && w != 1 {
+		return Vec3(v.Get(0)/w, v.Get(1)/w, v.Get(2)/w)
+	}
+	return vec3Of(v)
+}
+
+// TransformDirection is a direction moved by a 3x3 or 4x4 matrix, without
+// its translation.
+func TransformDirection(m Matrice, d Vector) Vector {
+	if m.Cols() == 3 {
+		return m.MulVec(vec3Of(d))
+	}
+	return vec3Of(m.MulVec(Vec4(d.Get(0), d.Get(1), d.Get(2), 0)))
+}
+
+type pointContainer interface {
+	Contains(Vector) bool
+}
+
+// pointsOf are the points a convex shape is the hull of.
+func pointsOf(s interface{}) ([]Vector, bool) {
+	switch v := s.(type) {
+	case Vector:
+		return []Vector{v}, true
+	case *AABB:
+		return v.Corners(), true
+	case *OBB:
+		return v.Corners(), true
+	case *Triangle:
+		return v.Points(), true
+	case *Segment:
+		return []Vector{v.A, v.B}, true
+	}
+	return nil, false
+}
+
+// Intersects is whether two shapes, or a shape and a point, meet, ok false
+// for a pair there is no test for.
+func Intersects(a, b interface{}) (hit, ok bool) {
+	if hit, ok = intersects(a, b); ok {
+		return
+	}
+	return intersects(b, a)
+}
+
+func intersects(a, b interface{}) (bool, bool) {
+	switch x := a.(type) {
+	case Vector:
+		return Contains(b, x)
+	case *Plane:
+		switch y := b.(type) {
+		case *Plane:
+			return x.IntersectsPlane(y), true
+		case *Sphere:
+			return y.Classify(x) == SIDE_CROSSING, true
+		case *AABB:
+			return y.Classify(x) == SIDE_CROSSING, true
+		case *OBB:
+			return y.Classify(x) == SIDE_CROSSING, true
+		case *Triangle:
+			return y.Classify(x) == SIDE_CROSSING, true
+		case *Segment:
+			return y.Classify(x) == SIDE_CROSSING, true
+		}
+	case *Sphere:
+		switch y := b.(type) {
+		case *Sphere:
+			return x.IntersectsSphere(y), true
+		case *AABB:
+			return x.IntersectsAABB(y), true
+		case *OBB:
+			return x.IntersectsOBB(y), true
+		case *Triangle:
+			return x.IntersectsTriangle(y), true
+		case *Segment:
+			return y.IntersectsSphere(x), true
+		}
+	case *AABB:
+		switch y := b.(type) {
+		case *AABB:
+			return x.IntersectsAABB(y), true
+		case *OBB:
+			return x.IntersectsOBB(y), true
+		case *Triangle:
+			return x.IntersectsTriangle(y), true
+		case *Segment:
+			return y.IntersectsAABB(x), true
+		}
+	case *OBB:
+		switch y := b.(type) {
+		case *OBB:
+			return x.IntersectsOBB(y), true
+		case *Triangle:
+			return x.IntersectsTriangle(y), true
+		case *Segment:
+			return y.IntersectsOBB(x), true
+		}
+	case *Triangle:
+		switch y := b.(type) {
+		case *Triangle:
+			return x.IntersectsTriangle(y), true
+		case *Segment:
+			return x.IntersectsSegment(y), true
+		}
+	case *Segment:
+		if y, is := b.(*Segment); is {
+			p, q := x.ClosestSegment(y)
+			return sub3(p, q).Len() <= geometryEpsilon, true
+		}
+	case *Ray:
+		switch b.(type) {
+		case *Plane, *Sphere, *AABB, *OBB, *Triangle:
+			_, hit := x.Cast(b)
+			return hit, true
+		}
+	case *Frustum:
+		switch y := b.(type) {
+		case *Sphere:
+			return x.IntersectsSphere(y), true
+		case *AABB:
+			return x.IntersectsAABB(y), true
+		case *OBB:
+			return x.IntersectsOBB(y), true
+		}
+	}
+	return false, false
+}
+
+// Contains is whether a shape holds all of another shape or a point, ok
+// false for a pair there is no test for.
+func Contains(a, b interface{}) (in, ok bool) {
+	if s, is := b.(*Sphere); is {
+		switch x := a.(type) {
+		case *AABB:
+			return x.ContainsSphere(s), true
+		case *OBB:
+			return x.ContainsSphere(s), true
+		case *Sphere:
+			return x.ContainsSphere(s), true
+		case *Frustum:
+			return x.ClassifySphere(s) == CONTAINS, true
+		}
+		return false, false
+	}
+	c, is := a.(pointContainer)
+	if !is {
+		return false, false
+	}
+	pts, is := pointsOf(b)
+	if !is {
+		return false, false
+	}
+	for _, p := range pts {
+		if !c.Contains(p) {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+const (
+	RAY      = "RAY"
+	PLANE    = "PLANE"
+	BOX      = "AABB"
+	SPHERE   = "SPHERE"
+	OBOX     = "OBB"
+	FRUSTUM  = "FRUSTUM"
+	TRIANGLE = "TRIANGLE"
+	SEGMENT  = "SEGMENT"
+)
+
+func shapeClass(s interface{}) string {
+	switch s.(type) {
+	case *Ray:
+		return RAY
+	case *Plane:
+		return PLANE
+	case *AABB:
+		return BOX
+	case *Sphere:
+		return SPHERE
+	case *OBB:
+		return OBOX
+	case *Frustum:
+		return FRUSTUM
+	case *Triangle:
+		return TRIANGLE
+	case *Segment:
+		return SEGMENT
+	}
+	return ""
+}
+
+func shapeName(s interface{}) string {
+	if v, ok := s.(Vector); ok {
+		return v.Tag()
+	}
+	return shapeClass(s)
+}
+
+func pushShape(L *l.LState, s interface{}) {
+	fn := func(u *l.LUserData) {
+		u.Value = s
+	}
+	lua.PushNewUserData(L, fn, shapeClass(s))
+}
+
+func checkShape(L *l.LState, pos int) interface{} {
+	ud := L.CheckUserData(pos)
+	switch v := ud.Value.(type) {
+	case Vector:
+		if v.RawLen() < 3 {
+			L.ArgError(pos, "vec3 expected")
+		}
+		return v
+	default:
+		if shapeClass(v) != "" {
+			return v
+		}
+	}
+	L.ArgError(pos, "shape expected")
+	return nil
+}
+
+func checkVec3(L *l.LState, pos int) Vector {
+	v := ToVector(L, L.Get(pos))
+	if v.RawLen() < 3 {
+		L.ArgError(pos, "vec3 expected")
+	}
+	return vec3Of(v)
+}
+
+func optQuat(L *l.LState, pos int) Quaternion {
+	if L.Get(pos) == l.LNil {
+		return nil
+	}
+	if q, ok := L.CheckUserData(pos).Value.(Quaternion); ok {
+		return q
+	}
+	L.ArgError(pos, "quat expected")
+	return nil
+}
+
+func pushVectors(L *l.LState, vs []Vector) {
+	t := L.CreateTable(len(vs), 0)
+	for _, v := range vs {
+		pushVector(L, v)
+		t.Append(L.Get(-1))
+		L.Pop(1)
+	}
+	L.Push(t)
+}
+
+// math.ray(origin, direction)
+func lRay(L *l.LState) int {
+	d := checkVec3(L, 2)
+	if d.Len() == 0 {
+		L.ArgError(2, "non zero direction expected")
+		return 0
+	}
+	pushShape(L, NewRay(checkVec3(L, 1), d))
+	return 1
+}
+
+// math.plane(normal, d), math.plane(normal, point) or math.plane(a, b, c)
+func lPlane(L *l.LState) int {
+	switch {
+	case L.GetTop() >= 3:
+		pushShape(L, PlaneFromPoints(checkVec3(L, 1), checkVec3(L, 2), checkVec3(L, 3)))
+	case L.Get(2).Type() == l.LTNumber:
+		pushShape(L, NewPlane(checkVec3(L, 1), float32(L.CheckNumber(2))))
+	default:
+		pushShape(L, PlaneFromPoint(checkVec3(L, 2), checkVec3(L, 1)))
+	}
+	return 1
+}
+
+// math.aabb(min, max)
+func lAABB(L *l.LState) int {
+	pushShape(L, NewAABB(checkVec3(L, 1), checkVec3(L, 2)))
+	return 1
+}
+
+// math.sphere(center, radius)
+func lSphere(L *l.LState) int {
+	pushShape(L, NewSphere(checkVec3(L, 1), float32(L.CheckNumber(2))))
+	return 1
+}
+
+// math.obb(center, half[, rotation])
+func lOBB(L *l.LState) int {
+	pushShape(L, NewOBB(checkVec3(L, 1), checkVec3(L, 2), optQuat(L, 3)))
+	return 1
+}
+
+// math.frustum(projection * view)
+func lFrustum(L *l.LState) int {
+	f := FrustumFromMatrice(ToMatrice(L, L.Get(1)))
+	if f == nil {
+		L.ArgError(1, "mat4 expected")
+		return 0
+	}
+	pushShape(L, f)
+	return 1
+}
+
+// math.triangle(a, b, c)
+func lTriangle(L *l.LState) int {
+	pushShape(L, NewTriangle(checkVec3(L, 1), checkVec3(L, 2), checkVec3(L, 3)))
+	return 1
+}
+
+// math.segment(a, b)
+func lSegment(L *l.LState) int {
+	pushShape(L, NewSegment(checkVec3(L, 1), checkVec3(L, 2)))
+	return 1
+}
+
+func shapeIntersects(L *l.LState) int {
+	a, b := checkShape(L, 1), checkShape(L, 2)
+	hit, ok := Intersects(a, b)
+	if !ok {
+		L.RaiseError("no intersection test for %s and %s", shapeName(a), shapeName(b))
+		return 0
+	}
+	L.Push(l.LBool(hit))
+	return 1
+}
+
+func shapeContains(L *l.LState) int {
+	a, b := checkShape(L, 1), checkShape(L, 2)
+	in, ok := Contains(a, b)
+	if !ok {
+		L.RaiseError("no containment test for %s and %s", shapeName(a), shapeName(b))
+		return 0
+	}
+	L.Push(l.LBool(in))
+	return 1
+}
+
+type closer interface {
+	Closest(Vector) Vector
+	Distance(Vector) float32
+}
+
+func checkCloser(L *l.LState) closer {
+	if c, ok := checkShape(L, 1).(closer); ok {
+		return c
+	}
+	L.RaiseError("no closest point for %s", shapeName(checkShape(L, 1)))
+	return nil
+}
+
+// s:closest(point)
+func shapeClosest(L *l.LState) int {
+	if c := checkCloser(L); c != nil {
+		pushVector(L, c.Closest(checkVec3(L, 2)))
+		return 1
+	}
+	return 0
+}
+
+// s:distance(point), signed for a plane
+func shapeDistance(L *l.LState) int {
+	if c := checkCloser(L); c != nil {
+		L.Push(l.LNumber(c.Distance(checkVec3(L, 2))))
+		return 1
+	}
+	return 0
+}
+
+// s:transform(matrix), a new shape
+func shapeTransform(L *l.LState) int {
+	m := ToMatrice(L, L.Get(2))
+	if m.Cols() != 3 && m.Cols() != 4 {
+		L.ArgError(2, "mat3 or mat4 expected")
+		return 0
+	}
+	switch s := checkShape(L, 1).(type) {
+	case *Ray:
+		pushShape(L, NewRay(TransformPoint(m, s.Origin), TransformDirection(m, s.Direction)))
+	case *Plane:
+		pushShape(L, s.Transform(m))
+	case *AABB:
+		pushShape(L, s.Transform(m))
+	case *Sphere:
+		pushShape(L, s.Transform(m))
+	case *OBB:
+		pushShape(L, s.Transform(m))
+	case *Frustum:
+		pushShape(L, s.Transform(m))
+	case *Triangle:
+		pushShape(L, s.Transform(m))
+	case *Segment:
+		pushShape(L, s.Transform(m))
+	default:
+		pushVector(L, TransformPoint(m, s.(Vector)))
+	}
+	return 1
+}
+
+type planeClassifier interface {
+	Classify(*Plane) Side
+}
+
+// f:classify(shape) is how much of a sphere or box a frustum holds, any
+// other s:classify(plane) the side of a plane it is on.
+func shapeClassify(L *l.LState) int {
+	s := checkShape(L, 1)
+	if f, ok := s.(*Frustum); ok {
+		var c Containment
+		switch o := checkShape(L, 2).(type) {
+		case *Sphere:
+			c = f.ClassifySphere(o)
+		case *AABB:
+			c = f.ClassifyAABB(o)
+		case *OBB:
+			c = f.ClassifyOBB(o)
+		default:
+			L.ArgError(2, "sphere, aabb or obb expected")
+			return 0
+		}
+		L.Push(l.LString(c.String()))
+		return 1
+	}
+	p, ok := checkShape(L, 2).(*Plane)
+	if !ok {
+		L.ArgError(2, "plane expected")
+		return 0
+	}
+	switch o := s.(type) {
+	case planeClassifier:
+		L.Push(l.LString(o.Classify(p).String()))
+	case *Plane:
+		side := SIDE_CROSSING
+		if !o.IntersectsPlane(p) {
+			side = p.ClassifyPoints(o.Closest(Vec3(0, 0, 0)))
+		}
+		L.Push(l.LString(side.String()))
+	default:
+		L.RaiseError("cannot classify %s", shapeName(s))
+		return 0
+	}
+	return 1
+}
+
+// s:corners(), a table of vec3
+func shapeCorners(L *l.LState) int {
+	switch s := checkShape(L, 1).(type) {
+	case *AABB:
+		pushVectors(L, s.Corners())
+	case *OBB:
+		pushVectors(L, s.Corners())
+	case *Frustum:
+		pushVectors(L, s.Corners())
+	case *Triangle:
+		pushVectors(L, s.Points())
+	case *Segment:
+		pushVectors(L, []Vector{s.A, s.B})
+	default:
+		L.RaiseError("no corners for %s", shapeName(checkShape(L, 1)))
+		return 0
+	}
+	return 1
+}
+
+var shapeMeta = []*lua.LMetaFunc{
+	lua.DefaultIdx("__index"),
+	lua.DefaultIdx("__newindex"),
+}
+
+var shapeTable = lua.NewTable("SHAPE", nil, shapeMeta, nil, map[string]l.LGFunction{
+	"intersects": shapeIntersects,
+	"contains":   shapeContains,
+	"closest":    shapeClosest,
+	"distance":   shapeDistance,
+	"transform":  shapeTransform,
+	"classify":   shapeClassify,
+	"corners":    shapeCorners,
+})
+
+var shapeParent = []*lua.Table{shapeTable}
+
+// vectorField is a property of a vec3 a shape holds.
+func vectorField(field func(interface{}) *Vector) l.LGFunction {
+	return lua.NewProperty(
+		func(L *l.LState) int {
+			pushVector(L, vec3Of(*field(checkShape(L, 1))))
+			return 1
+		},
+		func(L *l.LState) int {
+			*field(checkShape(L, 1)) = checkVec3(L, 3)
+			return 0
+		},
+	)
+}
+
+// numberField is a property of a number a shape holds.
+func numberField(field func(interface{}) *float32) l.LGFunction {
+	return lua.NewProperty(
+		func(L *l.LState) int {
+			L.Push(l.LNumber(*field(checkShape(L, 1))))
+			return 1
+		},
+		func(L *l.LState) int {
+			*field(checkShape(L, 1)) = float32(L.CheckNumber(3))
+			return 0
+		},
+	)
+}
+
+func boundsGetter(L *l.LState) int {
+	switch s := checkShape(L, 1).(type) {
+	case *AABB:
+		pushShape(L, &AABB{vec3Of(s.Min), vec3Of(s.Max)})
+	case *Sphere:
+		pushShape(L, s.Bounds())
+	case *OBB:
+		pushShape(L, s.Bounds())
+	case *Triangle:
+		pushShape(L, s.Bounds())
+	case *Segment:
+		pushShape(L, s.Bounds())
+	}
+	return 1
+}
+
+var rayProperties = map[string]l.LGFunction{
+	"origin":    vectorField(func(s interface{}) *Vector { return &s.(*Ray).Origin }),
+	"direction": vectorField(func(s interface{}) *Vector { return &s.(*Ray).Direction }),
+}
+
+// r:cast(shape), the distance along the ray to it or nil
+func rayCast(L *l.LState) int {
+	r := checkShape(L, 1).(*Ray)
+	if t, ok := r.Cast(checkShape(L, 2)); ok {
+		L.Push(l.LNumber(t))
+		return 1
+	}
+	L.Push(l.LNil)
+	return 1
+}
+
+func rayAt(L *l.LState) int {
+	pushVector(L, checkShape(L, 1).(*Ray).At(float32(L.CheckNumber(2))))
+	return 1
+}
+
+var rayMethods = map[string]l.LGFunction{
+	"cast": rayCast,
+	"at":   rayAt,
+}
+
+var planeProperties = map[string]l.LGFunction{
+	"normal": vectorField(func(s interface{}) *Vector { return &s.(*Plane).Normal }),
+	"d":      numberField(func(s interface{}) *float32 { return &s.(*Plane).D }),
+}
+
+var aabbProperties = map[string]l.LGFunction{
+	"min": vectorField(func(s interface{}) *Vector { return &s.(*AABB).Min }),
+	"max": vectorField(func(s interface{}) *Vector { return &s.(*AABB).Max }),
+	"center": lua.NewProperty(func(L *l.LState) int {
+		pushVector(L, checkShape(L, 1).(*AABB).Center())
+		return 1
+	}, nil),
+	"size": lua.NewProperty(func(L *l.LState) int {
+		pushVector(L, checkShape(L, 1).(*AABB).Size())
+		return 1
+	}, nil),
+	"bounds": lua.NewProperty(boundsGetter, nil),
+}
+
+var sphereProperties = map[string]l.LGFunction{
+	"center": vectorField(func(s interface{}) *Vector { return &s.(*Sphere).Center }),
+	"radius": numberField(func(s interface{}) *float32 { return &s.(*Sphere).Radius }),
+	"bounds": lua.NewProperty(boundsGetter, nil),
+}
+
+var obbProperties = map[string]l.LGFunction{
+	"center": vectorField(func(s interface{}) *Vector { return &s.(*OBB).Center }),
+	"half":   vectorField(func(s interface{}) *Vector { return &s.(*OBB).Half }),
+	"bounds": lua.NewProperty(boundsGetter, nil),
+}
+
+var triangleProperties = map[string]l.LGFunction{
+	"a": vectorField(func(s interface{}) *Vector { return &s.(*Triangle).A }),
+	"b": vectorField(func(s interface{}) *Vector { return &s.(*Triangle).B }),
+	"c": vectorField(func(s interface{}) *Vector { return &s.(*Triangle).C }),
+	"normal": lua.NewProperty(func(L *l.LState) int {
+		pushVector(L, checkShape(L, 1).(*Triangle).Normal())
+		return 1
+	}, nil),
+	"area": lua.NewProperty(func(L *l.LState) int {
+		L.Push(l.LNumber(checkShape(L, 1).(*Triangle).Area()))
+		return 1
+	}, nil),
+	"bounds": lua.NewProperty(boundsGetter, nil),
+}
+
+// t:barycentric(point), the weights of a, b and c
+func triangleBarycentric(L *l.LState) int {
+	u, v, w := checkShape(L, 1).(*Triangle).Barycentric(checkVec3(L, 2))
+	L.Push(l.LNumber(u))
+	L.Push(l.LNumber(v))
+	L.Push(l.LNumber(w))
+	return 3
+}
+
+var triangleMethods = map[string]l.LGFunction{
+	"barycentric": triangleBarycentric,
+}
+
+var segmentProperties = map[string]l.LGFunction{
+	"a": vectorField(func(s interface{}) *Vector { return &s.(*Segment).A }),
+	"b": vectorField(func(s interface{}) *Vector { return &s.(*Segment).B }),
+	"length": lua.NewProperty(func(L *l.LState) int {
+		L.Push(l.LNumber(checkShape(L, 1).(*Segment).Length()))
+		return 1
+	}, nil),
+	"bounds": lua.NewProperty(boundsGetter, nil),
+}
+
+func segmentAt(L *l.LState) int {
+	pushVector(L, checkShape(L, 1).(*Segment).At(float32(L.CheckNumber(2))))
+	return 1
+}
+
+var segmentMethods = map[string]l.LGFunction{
+	"at": segmentAt,
+}
@@ -0,0 +1,335 @@
+package math
+
+import (
+	"testing"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+func vec(x, y, z float32) Vector {
+	return Vec3(x, y, z)
+}
+
+func near(a, b Vector) bool {
+	return sub3(a, b).Len() <= 1e-4
+}
+
+func zRot(degrees float32) Quaternion {
+	return SetQuatFromAxisAngle(Quat(1, 0, 0, 0), Vec3(0, 0, 1), DegToRad(degrees))
+}
+
+func translation(x, y, z float32) Matrice {
+	return Mat4(1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, x, y, z, 1)
+}
+
+// testFrustum looks down -z from z 5 with a 90 degree field of view, its
+// near plane at z 4 and far at z -5.
+func testFrustum() *Frustum {
+	proj := newMatrix(MAT4, 4, 4).Perspective(90, 1, 1, 10)
+	view := newMatrix(MAT4, 4, 4).LookAt(vec(0, 0, 5), vec(0, 0, 0), vec(0, 1, 0))
+	return FrustumFromMatrice(proj.MulMatrice(view))
+}
+
+var (
+	unitBox  = NewAABB(vec(-1, -1, -1), vec(1, 1, 1))
+	turned   = NewOBB(vec(0, 0, 0), vec(1, 1, 1), zRot(45))
+	ground   = NewPlane(vec(0, 0, 1), 0)
+	ball     = NewSphere(vec(0, 0, 0), 1)
+	flatTri  = NewTriangle(vec(-1, 0, 0), vec(1, 0, 0), vec(0, 1, 0))
+	xSegment = NewSegment(vec(0, 0, 0), vec(2, 0, 0))
+)
+
+func TestIntersects(t *testing.T) {
+	f := testFrustum()
+	cases := []struct {
+		name string
+		a, b interface{}
+		want bool
+	}{
+		{"point plane", vec(3, 0, 0), ground, true},
+		{"point plane off", vec(0, 0, 1), ground, false},
+		{"point sphere", vec(0.5, 0, 0), ball, true},
+		{"point sphere out", vec(1.1, 0, 0), ball, false},
+		{"point aabb corner", vec(1, 1, 1), unitBox, true},
+		{"point aabb out", vec(1.01, 0, 0), unitBox, false},
+		{"point obb", vec(1.4, 0, 0), turned, true},
+		{"point obb out", vec(1.1, 1.1, 0), turned, false},
+		{"point triangle", vec(0, 0.25, 0), flatTri, true},
+		{"point triangle off", vec(0, 0.25, 0.1), flatTri, false},
+		{"point segment", vec(1, 0, 0), xSegment, true},
+		{"point segment off", vec(1, 0.1, 0), xSegment, false},
+		{"point frustum", vec(0, 0, 0), f, true},
+		{"point frustum out", vec(0, 0, 6), f, false},
+
+		{"plane plane", ground, NewPlane(vec(1, 0, 0), 3), true},
+		{"plane plane parallel", ground, NewPlane(vec(0, 0, 2), -4), false},
+		{"plane plane same", ground, NewPlane(vec(0, 0, -3), 0), true},
+		{"plane sphere", ground, NewSphere(vec(0, 0, 0.5), 1), true},
+		{"plane sphere apart", ground, NewSphere(vec(0, 0, 2), 1), false},
+		{"plane aabb", ground, unitBox, true},
+		{"plane aabb touching", ground, NewAABB(vec(0, 0, 0), vec(1, 1, 1)), true},
+		{"plane aabb apart", ground, NewAABB(vec(0, 0, 1), vec(1, 1, 2)), false},
+		{"plane obb", ground, turned, true},
+		{"plane obb apart", ground, NewOBB(vec(0, 0, 3), vec(1, 1, 1), zRot(45)), false},
+		{"plane triangle", ground, NewTriangle(vec(0, 0, -1), vec(1, 0, 1), vec(0, 1, 1)), true},
+		{"plane triangle apart", ground, NewTriangle(vec(0, 0, 1), vec(1, 0, 2), vec(0, 1, 2)), false},
+		{"plane segment", ground, NewSegment(vec(0, 0, -1), vec(0, 0, 1)), true},
+		{"plane segment apart", ground, NewSegment(vec(0, 0, 1), vec(0, 0, 2)), false},
+		{"plane ray", ground, NewRay(vec(0, 0, 5), vec(0, 0, -1)), true},
+		{"plane ray away", ground, NewRay(vec(0, 0, 5), vec(0, 0, 1)), false},
+
+		{"sphere sphere", ball, NewSphere(vec(1.9, 0, 0), 1), true},
+		{"sphere sphere apart", ball, NewSphere(vec(2.1, 0, 0), 1), false},
+		{"sphere aabb", ball, NewAABB(vec(0.5, 0.5, 0.5), vec(2, 2, 2)), true},
+		{"sphere aabb corner apart", ball, NewAABB(vec(0.8, 0.8, 0.8), vec(2, 2, 2)), false},
+		{"sphere obb", ball, NewOBB(vec(1.9, 0, 0), vec(1, 1, 1), nil), true},
+		{"sphere obb apart", ball, NewOBB(vec(2.5, 0, 0), vec(1, 1, 1), zRot(45)), false},
+		{"sphere triangle", ball, NewTriangle(vec(0.5, -5, -5), vec(0.5, 5, -5), vec(0.5, 0, 5)), true},
+		{"sphere triangle apart", ball, NewTriangle(vec(1.5, -5, -5), vec(1.5, 5, -5), vec(1.5, 0, 5)), false},
+		{"sphere segment", ball, NewSegment(vec(-2, 0.5, 0), vec(2, 0.5, 0)), true},
+		{"sphere segment apart", ball, NewSegment(vec(-2, 1.5, 0), vec(2, 1.5, 0)), false},
+		{"sphere ray", ball, NewRay(vec(-5, 0, 0), vec(1, 0, 0)), true},
+		{"sphere ray apart", ball, NewRay(vec(-5, 1.5, 0), vec(1, 0, 0)), false},
+		{"sphere frustum", ball, f, true},
+		{"sphere frustum apart", NewSphere(vec(0, 0, 8), 1), f, false},
+
+		{"aabb aabb touching", unitBox, NewAABB(vec(1, 0, 0), vec(2, 1, 1)), true},
+		{"aabb aabb apart", unitBox, NewAABB(vec(1.01, 0, 0), vec(2, 1, 1)), false},
+		{"aabb obb", unitBox, NewOBB(vec(2.3, 0, 0), vec(1, 1, 1), zRot(45)), true},
+		{"aabb obb apart", unitBox, NewOBB(vec(2.5, 0, 0), vec(1, 1, 1), zRot(45)), false},
+		{"aabb triangle", unitBox, NewTriangle(vec(-3, 0, -3), vec(3, 0, -3), vec(0, 0, 3)), true},
+		{"aabb triangle apart", unitBox, NewTriangle(vec(-3, 1.5, -3), vec(3, 1.5, -3), vec(0, 1.5, 3)), false},
+		{"aabb segment", unitBox, NewSegment(vec(-3, 0, 0), vec(-0.5, 0, 0)), true},
+		{"aabb segment short", unitBox, NewSegment(vec(-3, 0, 0), vec(-1.5, 0, 0)), false},
+		{"aabb ray", unitBox, NewRay(vec(-5, 0, 0), vec(1, 0, 0)), true},
+		{"aabb ray away", unitBox, NewRay(vec(-5, 0, 0), vec(-1, 0, 0)), false},
+		{"aabb frustum", unitBox, f, true},
+		{"aabb frustum apart", NewAABB(vec(8, -1, -1), vec(9, 1, 1)), f, false},
+
+		{"obb obb", turned, NewOBB(vec(2.3, 0, 0), vec(1, 1, 1), nil), true},
+		{"obb obb apart", turned, NewOBB(vec(2.5, 0, 0), vec(1, 1, 1), nil), false},
+		{"obb triangle", turned, NewTriangle(vec(1.3, -5, -5), vec(1.3, 5, -5), vec(1.3, 0, 5)), true},
+		{"obb triangle apart", turned, NewTriangle(vec(1.5, -5, -5), vec(1.5, 5, -5), vec(1.5, 0, 5)), false},
+		{"obb segment", turned, NewSegment(vec(-3, 0, 0), vec(-1.3, 0, 0)), true},
+		{"obb segment short", turned, NewSegment(vec(-3, 0, 0), vec(-1.5, 0, 0)), false},
+		{"obb ray", turned, NewRay(vec(-5, 1.3, 0), vec(1, 0, 0)), true},
+		{"obb ray apart", turned, NewRay(vec(-5, 1.5, 0), vec(1, 0, 0)), false},
+		{"obb frustum", turned, f, true},
+		{"obb frustum apart", NewOBB(vec(0, 0, 8), vec(1, 1, 1), zRot(45)), f, false},
+
+		{"triangle triangle", flatTri, NewTriangle(vec(0, 0.5, -1), vec(0, 0.5, 1), vec(0, -1, 0)), true},
+		{"triangle triangle apart", flatTri, NewTriangle(vec(0, 0.5, 1), vec(0, 0.5, 2), vec(0, -1, 1)), false},
+		{"triangle triangle coplanar", flatTri, NewTriangle(vec(0, 0.5, 0), vec(2, 0.5, 0), vec(1, 2, 0)), true},
+		{"triangle triangle coplanar inside", flatTri, NewTriangle(vec(0, 0.1, 0), vec(0.1, 0.1, 0), vec(0, 0.2, 0)), true},
+		{"triangle triangle coplanar apart", flatTri, NewTriangle(vec(2, 2, 0), vec(3, 2, 0), vec(2, 3, 0)), false},
+		{"triangle segment", flatTri, NewSegment(vec(0, 0.3, -1), vec(0, 0.3, 1)), true},
+		{"triangle segment short", flatTri, NewSegment(vec(0, 0.3, 0.1), vec(0, 0.3, 1)), false},
+		{"triangle ray", flatTri, NewRay(vec(0, 0.3, 5), vec(0, 0, -1)), true},
+		{"triangle ray away", flatTri, NewRay(vec(0, 0.3, 5), vec(0, 0, 1)), false},
+
+		{"segment segment", NewSegment(vec(-1, 0, 0), vec(1, 0, 0)), NewSegment(vec(0, -1, 0), vec(0, 1, 0)), true},
+		{"segment segment skew", NewSegment(vec(-1, 0, 0), vec(1, 0, 0)), NewSegment(vec(0, -1, 0.1), vec(0, 1, 0.1)), false},
+	}
+	for _, c := range cases {
+		if hit, ok := Intersects(c.a, c.b); !ok || hit != c.want {
+			t.Errorf("%s: Intersects(a, b) = %v, %v, want %v", c.name, hit, ok, c.want)
+		}
+		if hit, ok := Intersects(c.b, c.a); !ok || hit != c.want {
+			t.Errorf("%s: Intersects(b, a) = %v, %v, want %v", c.name, hit, ok, c.want)
+		}
+	}
+}
+
+func TestIntersectsUntested(t *testing.T) {
+	f := testFrustum()
+	r := NewRay(vec(0, 0, 0), vec(1, 0, 0))
+	cases := []struct {
+		name string
+		a, b interface{}
+	}{
+		{"point point", vec(0, 0, 0), vec(0, 0, 0)},
+		{"point ray", vec(0, 0, 0), r},
+		{"ray ray", r, r},
+		{"ray segment", r, xSegment},
+		{"ray frustum", r, f},
+		{"frustum frustum", f, f},
+		{"frustum plane", f, ground},
+		{"frustum triangle", f, flatTri},
+		{"frustum segment", f, xSegment},
+	}
+	for _, c := range cases {
+		if _, ok := Intersects(c.a, c.b); ok {
+			t.Errorf("%s: Intersects(a, b) tested", c.name)
+		}
+		if _, ok := Intersects(c.b, c.a); ok {
+			t.Errorf("%s: Intersects(b, a) tested", c.name)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	f := testFrustum()
+	big := NewSphere(vec(0, 0, 0), 2)
+	seg := NewSegment(vec(-2, 0, 0), vec(2, 0, 0))
+	cases := []struct {
+		name string
+		a, b interface{}
+		want bool
+	}{
+		{"aabb point", unitBox, vec(0, 0, 0), true},
+		{"aabb point out", unitBox, vec(2, 0, 0), false},
+		{"aabb aabb", unitBox, NewAABB(vec(-0.5, -0.5, -0.5), vec(0.5, 0.5, 0.5)), true},
+		{"aabb aabb out", unitBox, NewAABB(vec(0.5, 0.5, 0.5), vec(1.5, 1.5, 1.5)), false},
+		{"aabb obb", unitBox, NewOBB(vec(0, 0, 0), vec(0.5, 0.5, 0.5), zRot(45)), true},
+		{"aabb obb out", unitBox, turned, false},
+		{"aabb triangle", unitBox, flatTri, true},
+		{"aabb triangle out", unitBox, NewTriangle(vec(0, 0, 0), vec(2, 0, 0), vec(0, 1, 0)), false},
+		{"aabb segment", unitBox, NewSegment(vec(-1, -1, -1), vec(1, 1, 1)), true},
+		{"aabb segment out", unitBox, NewSegment(vec(0, 0, 0), vec(0, 0, 1.5)), false},
+		{"aabb sphere", unitBox, ball, true},
+		{"aabb sphere out", unitBox, NewSphere(vec(0.5, 0, 0), 0.6), false},
+
+		{"sphere point", big, vec(0, 2, 0), true},
+		{"sphere point out", big, vec(0, 2.1, 0), false},
+		{"sphere aabb", big, unitBox, true},
+		{"sphere aabb out", big, NewAABB(vec(-1, -1, -1), vec(1.2, 1.2, 1.2)), false},
+		{"sphere obb", big, turned, true},
+		{"sphere obb out", big, NewOBB(vec(0, 0, 0), vec(1.2, 1.2, 1.2), zRot(45)), false},
+		{"sphere triangle", big, flatTri, true},
+		{"sphere triangle out", big, NewTriangle(vec(-3, 0, 0), vec(1, 0, 0), vec(0, 1, 0)), false},
+		{"sphere segment", big, seg, true},
+		{"sphere segment out", big, NewSegment(vec(-2.5, 0, 0), vec(0, 0, 0)), false},
+		{"sphere sphere", big, NewSphere(vec(1, 0, 0), 1), true},
+		{"sphere sphere out", big, NewSphere(vec(1, 0, 0), 1.1), false},
+
+		{"obb point", turned, vec(1.4, 0, 0), true},
+		{"obb point out", turned, vec(1.1, 1.1, 0), false},
+		{"obb aabb", turned, NewAABB(vec(-0.7, -0.7, -0.7), vec(0.7, 0.7, 0.7)), true},
+		{"obb aabb out", turned, unitBox, false},
+		{"obb obb", turned, NewOBB(vec(0, 0, 0), vec(0.5, 0.5, 0.5), nil), true},
+		{"obb obb out", turned, NewOBB(vec(0, 0, 0), vec(1, 1, 1), nil), false},
+		{"obb triangle", turned, flatTri, true},
+		{"obb triangle out", turned, NewTriangle(vec(-1.5, 0, 0), vec(1, 0, 0), vec(0, 1, 0)), false},
+		{"obb segment", turned, NewSegment(vec(-1.4, 0, 0), vec(1.4, 0, 0)), true},
+		{"obb segment out", turned, NewSegment(vec(-1.5, 0, 0), vec(0, 0, 0)), false},
+		{"obb sphere", turned, ball, true},
+		{"obb sphere out", turned, NewSphere(vec(0, 0, 0), 1.1), false},
+
+		{"frustum point", f, vec(0, 0, 0), true},
+		{"frustum point out", f, vec(0, 0, 6), false},
+		{"frustum aabb", f, unitBox, true},
+		{"frustum aabb across near", f, NewAABB(vec(-1, -1, 3), vec(1, 1, 4.5)), false},
+		{"frustum obb", f, turned, true},
+		{"frustum obb across far", f, NewOBB(vec(0, 0, -5), vec(1, 1, 1), zRot(45)), false},
+		{"frustum triangle", f, flatTri, true},
+		{"frustum triangle out", f, NewTriangle(vec(-1, 0, 0), vec(1, 0, 0), vec(0, 0, 7)), false},
+		{"frustum segment", f, NewSegment(vec(0, 0, 3), vec(0, 0, -4)), true},
+		{"frustum segment out", f, NewSegment(vec(0, 0, 3), vec(0, 0, -6)), false},
+		{"frustum sphere", f, ball, true},
+		{"frustum sphere across near", f, NewSphere(vec(0, 0, 4), 0.5), false},
+
+		{"plane point", ground, vec(5, 5, 0), true},
+		{"plane point off", ground, vec(5, 5, 1), false},
+		{"plane flat aabb", ground, NewAABB(vec(-1, -1, 0), vec(1, 1, 0)), true},
+		{"plane aabb", ground, unitBox, false},
+		{"plane flat obb", ground, NewOBB(vec(0, 0, 0), vec(1, 1, 0), zRot(45)), true},
+		{"plane obb", ground, turned, false},
+		{"plane triangle", ground, flatTri, true},
+		{"plane triangle off", ground, NewTriangle(vec(0, 0, 0), vec(1, 0, 0), vec(0, 1, 1)), false},
+		{"plane segment", ground, xSegment, true},
+		{"plane segment off", ground, NewSegment(vec(0, 0, 0), vec(0, 0, 1)), false},
+
+		{"triangle point", flatTri, vec(0, 0.5, 0), true},
+		{"triangle point out", flatTri, vec(0, 1.5, 0), false},
+		{"triangle point aabb", flatTri, NewAABB(vec(0, 0.2, 0), vec(0, 0.2, 0)), true},
+		{"triangle aabb", flatTri, unitBox, false},
+		{"triangle flat obb", flatTri, NewOBB(vec(0, 0.3, 0), vec(0.1, 0.1, 0), nil), true},
+		{"triangle obb", flatTri, turned, false},
+		{"triangle triangle", flatTri, NewTriangle(vec(0, 0.1, 0), vec(0.1, 0.1, 0), vec(0, 0.2, 0)), true},
+		{"triangle triangle out", flatTri, NewTriangle(vec(0, 0.1, 0), vec(2, 0.1, 0), vec(0, 0.2, 0)), false},
+		{"triangle segment", flatTri, NewSegment(vec(-0.5, 0.1, 0), vec(0.5, 0.1, 0)), true},
+		{"triangle segment out", flatTri, NewSegment(vec(-2, 0, 0), vec(0, 0, 0)), false},
+
+		{"segment point", seg, vec(1, 0, 0), true},
+		{"segment point off", seg, vec(1, 0.1, 0), false},
+		{"segment line aabb", seg, NewAABB(vec(-1, 0, 0), vec(1, 0, 0)), true},
+		{"segment aabb", seg, unitBox, false},
+		{"segment line obb", seg, NewOBB(vec(0, 0, 0), vec(1, 0, 0), nil), true},
+		{"segment obb", seg, turned, false},
+		{"segment line triangle", seg, NewTriangle(vec(-1, 0, 0), vec(0, 0, 0), vec(1, 0, 0)), true},
+		{"segment triangle", seg, flatTri, false},
+		{"segment segment", seg, NewSegment(vec(-1, 0, 0), vec(1, 0, 0)), true},
+		{"segment segment out", seg, NewSegment(vec(1, 0, 0), vec(3, 0, 0)), false},
+	}
+	for _, c := range cases {
+		if in, ok := Contains(c.a, c.b); !ok || in != c.want {
+			t.Errorf("%s: Contains = %v, %v, want %v", c.name, in, ok, c.want)
+		}
+	}
+}
+
+func TestContainsUntested(t *testing.T) {
+	r := NewRay(vec(0, 0, 0), vec(1, 0, 0))
+	cases := []struct {
+		name string
+		a, b interface{}
+	}{
+		{"plane sphere", ground, ball},
+		{"triangle sphere", flatTri, ball},
+		{"segment sphere", xSegment, ball},
+		{"ray point", r, vec(0, 0, 0)},
+		{"aabb ray", unitBox, r},
+		{"aabb plane", unitBox, ground},
+		{"aabb frustum", unitBox, testFrustum()},
+		{"sphere frustum", ball, testFrustum()},
+	}
+	for _, c := range cases {
+		if _, ok := Contains(c.a, c.b); ok {
+			t.Errorf("%s: tested", c.name)
+		}
+	}
+}
+
+func TestShapeLua(t *testing.T) {
+	L := l.NewState()
+	defer L.Close()
+	for _, tb := range mathMTs {
+		mt := L.NewTypeMetatable(tb.Name)
+		for _, v := range tb.Meta {
+			L.SetField(mt, v.Key, L.NewClosure(v.Value(tb, v.Key)))
+		}
+	}
+	L.SetFuncs(L.G.Global, expandMathFuncs)
+	err := L.DoString(`
+local b = aabb(vec3(1, 1, 1), vec3(-1, -1, -1))
+local s = sphere(vec3(0, 0, 0), 0.5)
+assert(b:contains(s))
+assert(b:intersects(sphere(vec3(1.5, 0, 0), 0.6)))
+s.radius = 3
+assert(s.radius == 3)
+assert(not b:contains(s))
+assert(b.size.x == 2)
+local r = ray(vec3(-5, 0, 0), vec3(2, 0, 0))
+assert(r:cast(b) == 4)
+assert(r:cast(sphere(vec3(0, 9, 0), 1)) == nil)
+local p = plane(vec3(0, 1, 0), 0)
+assert(b:classify(p) == "crossing")
+assert(sphere(vec3(0, 5, 0), 1):classify(p) == "front")
+assert(p:distance(vec3(0, 3, 0)) == 3)
+assert(#obb(vec3(0, 0, 0), vec3(1, 1, 1), quat(0.9689, 0, 0, 0.2474)):corners() == 8)
+local t = triangle(vec3(0, 0, 0), vec3(1, 0, 0), vec3(0, 1, 0))
+local u = t:barycentric(vec3(0, 0, 0))
+assert(u == 1)
+local c = t:closest(vec3(2, 2, 5))
+assert(c.x == 0.5 and c.y == 0.5 and c.z == 0)
+local g = segment(vec3(0.2, 0.2, -1), vec3(0.2, 0.2, 1))
+assert(g:intersects(t))
+assert(g.length == 2)
+local m = b:transform(mat4(1,0,0,0, 0,1,0,0, 0,0,1,0, 3,0,0,1))
+assert(m.min.x == 2 and m.max.x == 4)
+assert(not pcall(function() return ray(vec3(0, 0, 0), vec3(0, 0, 0)) end))
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,279 @@
+package math
+
+// Triangle is the triangle of three points, facing the side they wind
+// counter clockwise seen from.
+type Triangle struct {
+	A, B, C Vector
+}
+
+func NewTriangle(a, b, c Vector) *Triangle {
+	return &Triangle{vec3Of(a), vec3Of(b), vec3Of(c)}
+}
+
+func (t *Triangle) Points() []Vector {
+	return []Vector{t.A, t.B, t.C}
+}
+
+func (t *Triangle) Edges() [3]*Segment {
+	return [3]*Segment{{t.A, t.B}, {t.B, t.C}, {t.C, t.A}}
+}
+
+// Normal is the unit normal of the side the triangle faces.
+func (t *Triangle) Normal() Vector {
+	return sub3(t.B, t.A).Cross(sub3(t.C, t.A)).Normalize()
+}
+
+func (t *Triangle) Area() float32 {
+	return sub3(t.B, t.A).Cross(sub3(t.C, t.A)).Len() / 2
+}
+
+func (t *Triangle) Centroid() Vector {
+	return t.A.Add(t.B).Add(t.C).Mul(1.0 / 3)
+}
+
+func (t *Triangle) Plane() *Plane {
+	return PlaneFromPoints(t.A, t.B, t.C)
+}
+
+func (t *Triangle) Bounds() *AABB {
+	return AABBFromPoints(t.A, t.B, t.C)
+}
+
+// Barycentric are the weights of A, B and C giving a point, projected on
+// the plane of the triangle, all in [0, 1] for points over it.
+func (t *Triangle) Barycentric(p Vector) (u, v, w float32) {
+	v0, v1, v2 := sub3(t.B, t.A), sub3(t.C, t.A), sub3(p, t.A)
+	d00, d01, d11 := v0.Dot(v0), v0.Dot(v1), v1.Dot(v1)
+	d20, d21 := v2.Dot(v0), v2.Dot(v1)
+	denom := d00*d11 - d01*d01
+	if denom == 0 {
+		return 1, 0, 0
+	}
+	v = (d11*d20 - d01*d21) / denom
+	w = (d00*d21 - d01*d20) / denom
+	return 1 - v - w, v, w
+}
+
+// Closest is the point of the triangle nearest a point.
+func (t *Triangle) Closest(p Vector) Vector {
+	ab, ac, ap := sub3(t.B, t.A), sub3(t.C, t.A), sub3(p, t.A)
+	d1, d2 := ab.Dot(ap), ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return vec3Of(t.A)
+	}
+	bp := sub3(p, t.B)
+	d3, d4 := ab.Dot(bp), ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return vec3Of(t.B)
+	}
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		return t.A.Add(ab.Mul(d1 / (d1 - d3)))
+	}
+	cp := sub3(p, t.C)
+	d5, d6 := ab.Dot(cp), ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return vec3Of(t.C)
+	}
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		return t.A.Add(ac.Mul(d2 / (d2 - d6)))
+	}
+	va := d3*d6 - d5*d4
+	if va <= 0 && d4-d3 >= 0 && d5-d6 >= 0 {
+		return t.B.Add(sub3(t.C, t.B).Mul((d4 - d3) / ((d4 - d3) + (d5 - d6))))
+	}
+	denom := 1 / (va + vb + vc)
+	return t.A.Add(ab.Mul(vb * denom)).Add(ac.Mul(vc * denom))
+}
+
+func (t *Triangle) Distance(p Vector) float32 {
+	return sub3(t.Closest(p), p).Len()
+}
+
+// Contains is whether a point is on the triangle.
+func (t *Triangle) Contains(p Vector) bool {
+	return t.Distance(p) <= geometryEpsilon
+}
+
+// Classify is the side of a plane the triangle is on.
+func (t *Triangle) Classify(p *Plane) Side {
+	return p.ClassifyPoints(t.A, t.B, t.C)
+}
+
+func (t *Triangle) IntersectsAABB(b *AABB) bool {
+	return b.IntersectsTriangle(t)
+}
+
+func (t *Triangle) IntersectsOBB(o *OBB) bool {
+	return o.IntersectsTriangle(t)
+}
+
+func (t *Triangle) IntersectsSphere(s *Sphere) bool {
+	return s.IntersectsTriangle(t)
+}
+
+// IntersectsTriangle is whether two triangles meet: an edge of one through
+// the other, or lying in one plane, one over the other or their edges
+// crossing.
+func (t *Triangle) IntersectsTriangle(o *Triangle) bool {
+	for _, e := range t.Edges() {
+		if _, _, _, ok := e.IntersectTriangle(o); ok {
+			return true
+		}
+	}
+	for _, e := range o.Edges() {
+		if _, _, _, ok := e.IntersectTriangle(t); ok {
+			return true
+		}
+	}
+	p := t.Plane()
+	for _, v := range o.Points() {
+		if !p.Contains(v) {
+			return false
+		}
+	}
+	if t.Contains(o.A) || o.Contains(t.A) {
+		return true
+	}
+	for _, e := range t.Edges() {
+		for _, f := range o.Edges() {
+			a, b := e.ClosestSegment(f)
+			if sub3(a, b).Len() <= geometryEpsilon {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t *Triangle) IntersectsSegment(s *Segment) bool {
+	_, _, _, ok := s.IntersectTriangle(t)
+	return ok
+}
+
+func (t *Triangle) Transform(m Matrice) *Triangle {
+	return &Triangle{TransformPoint(m, t.A), TransformPoint(m, t.B), TransformPoint(m, t.C)}
+}
+
+// Segment is the line segment from A to B.
+type Segment struct {
+	A, B Vector
+}
+
+func NewSegment(a, b Vector) *Segment {
+	return &Segment{vec3Of(a), vec3Of(b)}
+}
+
+// Vector is B from A.
+func (s *Segment) Vector() Vector {
+	return sub3(s.B, s.A)
+}
+
+func (s *Segment) Length() float32 {
+	return s.Vector().Len()
+}
+
+// At is the point t of the way from A to B.
+func (s *Segment) At(t float32) Vector {
+	return s.A.Add(s.Vector().Mul(t))
+}
+
+func (s *Segment) Bounds() *AABB {
+	return AABBFromPoints(s.A, s.B)
+}
+
+// ray is a ray along the segment, distances along it in lengths of the
+// segment.
+func (s *Segment) ray() *Ray {
+	return &Ray{vec3Of(s.A), s.Vector()}
+}
+
+// ClosestT is how far along the segment, 0 at A to 1 at B, its point
+// nearest a point is.
+func (s *Segment) ClosestT(p Vector) float32 {
+	d := s.Vector()
+	l2 := d.Dot(d)
+	if l2 == 0 {
+		return 0
+	}
+	return Clamp(sub3(p, s.A).Dot(d)/l2, 0, 1)
+}
+
+// Closest is the point of the segment nearest a point.
+func (s *Segment) Closest(p Vector) Vector {
+	return s.At(s.ClosestT(p))
+}
+
+func (s *Segment) Distance(p Vector) float32 {
+	return sub3(s.Closest(p), p).Len()
+}
+
+func (s *Segment) Contains(p Vector) bool {
+	return s.Distance(p) <= geometryEpsilon
+}
+
+// ClosestSegment are the points of two segments nearest each other.
+func (s *Segment) ClosestSegment(o *Segment) (Vector, Vector) {
+	d1, d2, r := s.Vector(), o.Vector(), sub3(s.A, o.A)
+	a, e, f := d1.Dot(d1), d2.Dot(d2), d2.Dot(r)
+	var sc, tc float32
+	switch {
+	case a <= geometryEpsilon && e <= geometryEpsilon:
+	case a <= geometryEpsilon:
+		tc = Clamp(f/e, 0, 1)
+	default:
+		c := d1.Dot(r)
+		if e <= geometryEpsilon {
+			sc = Clamp(-c/a, 0, 1)
+			break
+		}
+		b := d1.Dot(d2)
+		if denom := a*e - b*b; denom != 0 {
+			sc = Clamp((b*f-c*e)/denom, 0, 1)
+		}
+		tc = (b*sc + f) / e
+		if tc < 0 {
+			tc, sc = 0, Clamp(-c/a, 0, 1)
+		} else if tc > 1 {
+			tc, sc = 1, Clamp((b-c)/a, 0, 1)
+		}
+	}
+	return s.At(sc), o.At(tc)
+}
+
+// IntersectPlane is how far along the segment, 0 at A to 1 at B, it
+// crosses a plane.
+func (s *Segment) IntersectPlane(p *Plane) (float32, bool) {
+	t, ok := s.ray().IntersectPlane(p)
+	return t, ok && t <= 1
+}
+
+// IntersectTriangle is how far along the segment it crosses a triangle and
+// the barycentric v and w of where, as Ray.IntersectTriangle.
+func (s *Segment) IntersectTriangle(t *Triangle) (float32, float32, float32, bool) {
+	d, u, v, ok := s.ray().IntersectTriangle(t.A, t.B, t.C, false)
+	return d, u, v, ok && d <= 1
+}
+
+func (s *Segment) IntersectsSphere(sp *Sphere) bool {
+	return sp.Contains(s.Closest(sp.Center))
+}
+
+func (s *Segment) IntersectsAABB(b *AABB) bool {
+	t, ok := s.ray().IntersectBox(b.Min, b.Max)
+	return ok && t <= 1
+}
+
+func (s *Segment) IntersectsOBB(o *OBB) bool {
+	t, ok := s.ray().IntersectOBB(o)
+	return ok && t <= 1
+}
+
+func (s *Segment) Classify(p *Plane) Side {
+	return p.ClassifyPoints(s.A, s.B)
+}
+
+func (s *Segment) Transform(m Matrice) *Segment {
+	return &Segment{TransformPoint(m, s.A), TransformPoint(m, s.B)}
+}
@@ -0,0 +1,128 @@
+package math
+
+import "testing"
+
+func TestTriangleClosest(t *testing.T) {
+	tri := NewTriangle(vec(0, 0, 0), vec(2, 0, 0), vec(0, 2, 0))
+	cases := []struct {
+		name  string
+		point Vector
+		want  Vector
+	}{
+		{"vertex a", vec(-1, -1, 0), vec(0, 0, 0)},
+		{"vertex b", vec(3, -1, 0), vec(2, 0, 0)},
+		{"vertex c", vec(-1, 3, 1), vec(0, 2, 0)},
+		{"edge ab", vec(1, -1, 0), vec(1, 0, 0)},
+		{"edge ac", vec(-1, 1, -2), vec(0, 1, 0)},
+		{"edge bc", vec(2, 2, 0), vec(1, 1, 0)},
+		{"face", vec(0.5, 0.5, 3), vec(0.5, 0.5, 0)},
+		{"on", vec(0.5, 0.5, 0), vec(0.5, 0.5, 0)},
+	}
+	for _, c := range cases {
+		if got := tri.Closest(c.point); !near(got, c.want) {
+			t.Errorf("%s: %v, want %v", c.name, got, c.want)
+		}
+		if got, want := tri.Distance(c.point), sub3(c.point, c.want).Len(); Abs(got-want) > 1e-4 {
+			t.Errorf("%s: distance %v, want %v", c.name, got, want)
+		}
+	}
+}
+
+func TestTriangle(t *testing.T) {
+	tri := NewTriangle(vec(0, 0, 0), vec(2, 0, 0), vec(0, 2, 0))
+	if !near(tri.Normal(), vec(0, 0, 1)) || tri.Area() != 2 {
+		t.Error("normal or area")
+	}
+	if p := tri.Plane(); !near(p.Normal, vec(0, 0, 1)) || p.D != 0 {
+		t.Error("plane")
+	}
+	cases := []struct {
+		point   Vector
+		u, v, w float32
+	}{
+		{vec(0, 0, 0), 1, 0, 0},
+		{vec(2, 0, 0), 0, 1, 0},
+		{vec(0, 2, 0), 0, 0, 1},
+		{tri.Centroid(), 1.0 / 3, 1.0 / 3, 1.0 / 3},
+		{vec(1, 1, 5), 0, 0.5, 0.5},
+		{vec(-2, 0, 0), 2, -1, 0},
+	}
+	for _, c := range cases {
+		u, v, w := tri.Barycentric(c.point)
+		if Abs(u-c.u) > 1e-5 || Abs(v-c.v) > 1e-5 || Abs(w-c.w) > 1e-5 {
+			t.Errorf("barycentric %v: %v %v %v, want %v %v %v", c.point, u, v, w, c.u, c.v, c.w)
+		}
+	}
+}
+
+func TestTriangleSeparatingAxes(t *testing.T) {
+	box := NewOBB(vec(0, 0, 0), vec(1, 1, 1), nil)
+	// separated on an axis crossing a box axis and an edge, the box faces
+	// and the triangle normal overlapping
+	edge := NewTriangle(vec(-1.5, 0, 2.25), vec(-0.25, 1.75, 2.25), vec(0.5, 2, 0.75))
+	cases := []struct {
+		name string
+		box  *OBB
+		tri  *Triangle
+		want bool
+	}{
+		{"corner touching", box, NewTriangle(vec(1, 1, 1), vec(3, 2, 2), vec(2, 3, 2)), true},
+		{"face touching", box, NewTriangle(vec(1, -5, -5), vec(1, 5, -5), vec(1, 0, 5)), true},
+		{"face apart", box, NewTriangle(vec(1.01, -5, -5), vec(1.01, 5, -5), vec(1.01, 0, 5)), false},
+		{"normal apart", box, NewTriangle(vec(3.1, 0, 0), vec(0, 3.1, 0), vec(0, 0, 3.1)), false},
+		{"normal through", box, NewTriangle(vec(2.9, 0, 0), vec(0, 2.9, 0), vec(0, 0, 2.9)), true},
+		{"inside", box, NewTriangle(vec(-0.5, 0, 0), vec(0.5, 0, 0), vec(0, 0.5, 0.5)), true},
+		{"around", box, NewTriangle(vec(-9, -9, 0), vec(9, -9, 0), vec(0, 9, 0)), true},
+		{"edge apart", box, edge, false},
+		{"turned face", turned, NewTriangle(vec(1.4, -5, -5), vec(1.4, 5, -5), vec(1.4, 0, 5)), true},
+		{"turned apart", turned, NewTriangle(vec(1.42, -5, -5), vec(1.42, 5, -5), vec(1.42, 0, 5)), false},
+	}
+	for _, c := range cases {
+		if got := c.box.IntersectsTriangle(c.tri); got != c.want {
+			t.Errorf("%s: %v, want %v", c.name, got, c.want)
+		}
+	}
+	faces := append(append([]Vector{}, box.Axes[:]...), edge.Normal())
+	if !overlap(faces, box.Corners(), edge.Points()) {
+		t.Error("edge apart is separated by a face")
+	}
+}
+
+func TestSegment(t *testing.T) {
+	closest := []struct {
+		point, want Vector
+	}{
+		{vec(-1, 1, 0), vec(0, 0, 0)},
+		{vec(1, 1, 0), vec(1, 0, 0)},
+		{vec(3, 1, 0), vec(2, 0, 0)},
+	}
+	for _, c := range closest {
+		if got := xSegment.Closest(c.point); !near(got, c.want) {
+			t.Errorf("closest %v: %v, want %v", c.point, got, c.want)
+		}
+	}
+	pairs := []struct {
+		name     string
+		a, b     *Segment
+		distance float32
+		p, q     Vector
+	}{
+		{"skew", NewSegment(vec(-1, 0, 0), vec(1, 0, 0)), NewSegment(vec(0, -1, 1), vec(0, 1, 1)), 1, vec(0, 0, 0), vec(0, 0, 1)},
+		{"ends", NewSegment(vec(0, 0, 0), vec(1, 0, 0)), NewSegment(vec(2, 1, 0), vec(3, 1, 0)), Sqrt(2), vec(1, 0, 0), vec(2, 1, 0)},
+		{"parallel", NewSegment(vec(0, 0, 0), vec(1, 0, 0)), NewSegment(vec(0, 1, 0), vec(1, 1, 0)), 1, nil, nil},
+		{"points", NewSegment(vec(0, 0, 0), vec(0, 0, 0)), NewSegment(vec(0, 3, 4), vec(0, 3, 4)), 5, vec(0, 0, 0), vec(0, 3, 4)},
+		{"point and segment", NewSegment(vec(1, 1, 0), vec(1, 1, 0)), NewSegment(vec(0, 0, 0), vec(2, 0, 0)), 1, vec(1, 1, 0), vec(1, 0, 0)},
+	}
+	for _, c := range pairs {
+		p, q := c.a.ClosestSegment(c.b)
+		if d := sub3(p, q).Len(); Abs(d-c.distance) > 1e-5 {
+			t.Errorf("%s: distance %v, want %v", c.name, d, c.distance)
+		}
+		if c.p != nil && (!near(p, c.p) || !near(q, c.q)) {
+			t.Errorf("%s: %v %v, want %v %v", c.name, p, q, c.p, c.q)
+		}
+	}
+	if d, ok := NewSegment(vec(0, 0, -1), vec(0, 0, 3)).IntersectPlane(ground); !ok || d != 0.25 {
+		t.Errorf("plane crossing %v %v", d, ok)
+	}
+}
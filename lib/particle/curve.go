@@ -0,0 +1,66 @@
+package particle
+
+import "sort"
+
+// Curve is a piecewise linear value over the normalized lifetime of a
+// particle, each key holding Width values.
+type Curve struct {
+	Width  int
+	Times  []float32
+	Values []float32
+}
+
+func NewCurve(width int) *Curve {
+	return &Curve{
+		Width:  width,
+		Times:  make([]float32, 0),
+		Values: make([]float32, 0),
+	}
+}
+
+// Constant is a curve holding v over the whole lifetime.
+func Constant(v ...float32) *Curve {
+	return NewCurve(len(v)).Key(0, v...)
+}
+
+// Key adds a key at t in [0, 1], keeping keys ordered by time.
+func (c *Curve) Key(t float32, v ...float32) *Curve {
+	if len(v) < c.Width {
+		return c
+	}
+	i := sort.Search(len(c.Times), func(i int) bool { return c.Times[i] > t })
+	c.Times = append(c.Times, 0)
+	copy(c.Times[i+1:], c.Times[i:])
+	c.Times[i] = t
+	at := i * c.Width
+	c.Values = append(c.Values, v[:c.Width]...)
+	copy(c.Values[at+c.Width:], c.Values[at:len(c.Values)-c.Width])
+	copy(c.Values[at:], v[:c.Width])
+	return c
+}
+
+func (c *Curve) key(i int) []float32 {
+	return c.Values[i*c.Width : i*c.Width+c.Width]
+}
+
+// Sample writes the curve value at t into out.
+func (c *Curve) Sample(t float32, out []float32) {
+	n := len(c.Times)
+	switch {
+	case n == 0:
+		return
+	case t <= c.Times[0]:
+		copy(out, c.key(0))
+		return
+	case t >= c.Times[n-1]:
+		copy(out, c.key(n-1))
+		return
+	}
+	b := sort.Search(n, func(i int) bool { return c.Times[i] > t })
+	a := b - 1
+	f := (t - c.Times[a]) / (c.Times[b] - c.Times[a])
+	ka, kb := c.key(a), c.key(b)
+	for i := range ka {
+		out[i] = ka[i] + (kb[i]-ka[i])*f
+	}
+}
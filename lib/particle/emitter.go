@@ -0,0 +1,288 @@
+package particle
+
+import (
+	glm "math"
+	"math/rand"
+	"time"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/ecs"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+)
+
+// Burst emits Count particles at Time seconds into each emitter cycle.
+type Burst struct {
+	Time  float32
+	Count int
+}
+
+// Atlas animates particles through the frames of a texture atlas of Cols by
+// Rows cells, at FPS frames per second or once over the lifetime when FPS is
+// 0, optionally starting on a random frame. Texture is the path of the atlas
+// image, none drawing soft round points.
+type Atlas struct {
+	Cols, Rows int
+	Frames     int
+	FPS        float32
+	Random     bool
+	Texture    string
+}
+
+func (a Atlas) frames() int {
+	if a.Frames > 0 {
+		return a.Frames
+	}
+	if n := a.Cols * a.Rows; n > 0 {
+		return n
+	}
+	return 1
+}
+
+// Config describes how an emitter spawns and simulates particles. Ranges are
+// [min, max] sampled uniformly per particle.
+type Config struct {
+	Max      int
+	Shape    Shape
+	Rate     float32
+	Bursts   []Burst
+	Duration float32
+	Loop     bool
+	Life     [2]float32
+	Speed    [2]float32
+	Spin     [2]float32
+	Size     *Curve
+	Color    *Curve
+	Velocity *Curve
+	Gravity  [3]float32
+	Drag     float32
+	Atlas    Atlas
+	Additive bool
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Max:      1000,
+		Shape:    Point(),
+		Rate:     10,
+		Bursts:   make([]Burst, 0),
+		Loop:     true,
+		Life:     [2]float32{1, 1},
+		Speed:    [2]float32{1, 1},
+		Size:     Constant(0.1),
+		Color:    Constant(1, 1, 1, 1),
+		Velocity: Constant(1),
+	}
+}
+
+type particle struct {
+	pos, vel  [3]float32
+	age, life float32
+	rot, spin float32
+	frame     float32
+}
+
+// Stride is the number of floats of instance data per particle: position and
+// size, rgba color, atlas frame and rotation.
+const Stride = 10
+
+// Emitter spawns and simulates a bounded pool of particles in world space.
+type Emitter struct {
+	ecs.Entity
+	*Config
+	ps       []particle
+	live     int
+	rng      *rand.Rand
+	time     float32
+	acc      float32
+	started  bool
+	emitting bool
+	world    math.Matrice
+	data     []float32
+	scratch  [4]float32
+}
+
+func NewEmitter(c *Config) *Emitter {
+	if c == nil {
+		c = DefaultConfig()
+	}
+	if c.Max <= 0 {
+		c.Max = 1
+	}
+	return &Emitter{
+		Entity:   ecs.NewEntity(),
+		Config:   c,
+		ps:       make([]particle, c.Max),
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		emitting: true,
+		world:    math.IdentityMatrix(math.MAT4),
+		data:     make([]float32, 0, c.Max*Stride),
+	}
+}
+
+// Start restarts the emitter cycle.
+func (e *Emitter) Start() {
+	e.time = 0
+	e.acc = 0
+	e.started = false
+	e.emitting = true
+}
+
+// Stop ends emission, live particles living out their lifetime.
+func (e *Emitter) Stop() {
+	e.emitting = false
+}
+
+func (e *Emitter) Emitting() bool {
+	return e.emitting
+}
+
+// Clear removes every live particle.
+func (e *Emitter) Clear() {
+	e.live = 0
+	e.data = e.data[:0]
+}
+
+func (e *Emitter) Live() int {
+	return e.live
+}
+
+// SetWorld sets the world matrix new particles are spawned relative to.
+func (e *Emitter) SetWorld(m math.Matrice) {
+	if m != nil {
+		e.world = m
+	}
+}
+
+// Instances returns the instance data of the live particles, Stride floats
+// each.
+func (e *Emitter) Instances() []float32 {
+	return e.data
+}
+
+func (e *Emitter) between(r [2]float32) float32 {
+	return r[0] + (r[1]-r[0])*e.rng.Float32()
+}
+
+// Burst spawns n particles at once, as far as the pool allows.
+func (e *Emitter) Burst(n int) {
+	w := e.world.Raw()
+	for i := 0; i < n && e.live < len(e.ps); i++ {
+		pos, dir := e.Shape.Sample(e.rng)
+		speed := e.between(e.Speed)
+		p := &e.ps[e.live]
+		for c := 0; c < 3; c++ {
+			p.pos[c] = w[c]*pos[0] + w[4+c]*pos[1] + w[8+c]*pos[2] + w[12+c]
+			p.vel[c] = (w[c]*dir[0] + w[4+c]*dir[1] + w[8+c]*dir[2]) * speed
+		}
+		p.age = 0
+		p.life = e.between(e.Life)
+		if p.life <= 0 {
+			p.life = 1
+		}
+		p.rot = e.rng.Float32() * 2 * glm.Pi
+		p.spin = e.between(e.Spin)
+		p.frame = 0
+		if e.Atlas.Random {
+			p.frame = float32(e.rng.Intn(e.Atlas.frames()))
+		}
+		e.live++
+	}
+}
+
+// bursts fires the bursts in (from, to] of the cycle.
+func (e *Emitter) bursts(from, to float32) {
+	for _, b := range e.Bursts {
+		if b.Time > from && b.Time <= to {
+			e.Burst(b.Count)
+		}
+	}
+}
+
+func (e *Emitter) emit(dt float32) {
+	if !e.emitting {
+		return
+	}
+	from := e.time
+	if !e.started {
+		from = -1
+		e.started = true
+	}
+	e.time += dt
+	if d := e.Duration; d > 0 && e.time >= d {
+		e.bursts(from, d)
+		if !e.Loop {
+			e.emitting = false
+			return
+		}
+		for e.time >= d {
+			e.time -= d
+		}
+		from = -1
+	}
+	e.bursts(from, e.time)
+
+	e.acc += e.Rate * dt
+	if n := int(e.acc); n > 0 {
+		e.acc -= float32(n)
+		e.Burst(n)
+	}
+}
+
+// Update emits and simulates the particles over dt seconds, then rebuilds
+// the instance data.
+func (e *Emitter) Update(dt float32) {
+	e.emit(dt)
+
+	drag := float32(1)
+	if e.Drag > 0 {
+		drag = 1 / (1 + e.Drag*dt)
+	}
+	e.data = e.data[:0]
+	for i := 0; i < e.live; {
+		p := &e.ps[i]
+		p.age += dt
+		if p.age >= p.life {
+			e.live--
+			e.ps[i] = e.ps[e.live]
+			continue
+		}
+		t := p.age / p.life
+
+		vm := float32(1)
+		if e.Velocity != nil {
+			e.Velocity.Sample(t, e.scratch[:1])
+			vm = e.scratch[0]
+		}
+		for c := 0; c < 3; c++ {
+			p.vel[c] = (p.vel[c] + e.Gravity[c]*dt) * drag
+			p.pos[c] += p.vel[c] * vm * dt
+		}
+		p.rot += p.spin * dt
+
+		frame := p.frame
+		if n := e.Atlas.frames(); n > 1 {
+			if e.Atlas.FPS > 0 {
+				frame += p.age * e.Atlas.FPS
+			} else {
+				frame += t * float32(n)
+			}
+			frame = float32(int(frame) % n)
+		}
+
+		size := float32(1)
+		if e.Size != nil {
+			e.Size.Sample(t, e.scratch[:1])
+			size = e.scratch[0]
+		}
+		e.scratch = [4]float32{1, 1, 1, 1}
+		if e.Color != nil {
+			e.Color.Sample(t, e.scratch[:])
+		}
+
+		e.data = append(e.data,
+			p.pos[0], p.pos[1], p.pos[2], size,
+			e.scratch[0], e.scratch[1], e.scratch[2], e.scratch[3],
+			frame, p.rot,
+		)
+		i++
+	}
+}
@@ -0,0 +1,251 @@
+package particle
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/render"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+var ConfigError = xrror.Xrror("particle %s: %v is not valid").Out
+
+func numbers(t *l.LTable) []float32 {
+	ret := make([]float32, 0, t.Len())
+	for i := 1; i <= t.Len(); i++ {
+		ret = append(ret, float32(l.LVAsNumber(t.RawGetInt(i))))
+	}
+	return ret
+}
+
+// between reads a number or a {min, max} table.
+func between(key string, v l.LValue) ([2]float32, error) {
+	switch vv := v.(type) {
+	case l.LNumber:
+		return [2]float32{float32(vv), float32(vv)}, nil
+	case *l.LTable:
+		n := numbers(vv)
+		switch len(n) {
+		case 1:
+			return [2]float32{n[0], n[0]}, nil
+		case 2:
+			return [2]float32{n[0], n[1]}, nil
+		}
+	}
+	return [2]float32{}, ConfigError(key, v)
+}
+
+// curve reads a constant number, a flat table of width numbers, or a list of
+// {t, values...} keys.
+func curve(key string, width int, v l.LValue) (*Curve, error) {
+	switch vv := v.(type) {
+	case l.LNumber:
+		c := make([]float32, width)
+		for i := range c {
+			c[i] = float32(vv)
+		}
+		return Constant(c...), nil
+	case *l.LTable:
+		if _, ok := vv.RawGetInt(1).(*l.LTable); !ok {
+			n := numbers(vv)
+			if len(n) < width {
+				return nil, ConfigError(key, v)
+			}
+			return Constant(n[:width]...), nil
+		}
+		c := NewCurve(width)
+		for i := 1; i <= vv.Len(); i++ {
+			kt, ok := vv.RawGetInt(i).(*l.LTable)
+			if !ok {
+				return nil, ConfigError(key, v)
+			}
+			n := numbers(kt)
+			if len(n) < width+1 {
+				return nil, ConfigError(key, v)
+			}
+			c.Key(n[0], n[1:]...)
+		}
+		return c, nil
+	}
+	return nil, ConfigError(key, v)
+}
+
+func vec3(key string, v l.LValue) ([3]float32, error) {
+	var ret [3]float32
+	switch vv := v.(type) {
+	case *l.LTable:
+		copy(ret[:], numbers(vv))
+		return ret, nil
+	case *l.LUserData:
+		if vec, ok := vv.Value.(math.Vector); ok {
+			copy(ret[:], vec.Raw())
+			return ret, nil
+		}
+	}
+	return ret, ConfigError(key, v)
+}
+
+type mesher interface {
+	Mesh() render.Mesh
+}
+
+func meshShape(v l.LValue) (Shape, error) {
+	ud, ok := v.(*l.LUserData)
+	if !ok {
+		return nil, ConfigError("shape node", v)
+	}
+	ms, ok := ud.Value.(mesher)
+	if !ok {
+		return nil, ConfigError("shape node", v)
+	}
+	g := ms.Mesh().Geometry()
+	pos, size := g.Attribute("VertexPosition")
+	if size < 3 {
+		return nil, ConfigError("shape node", v)
+	}
+	if size > 3 {
+		packed := math.NewAF32(0, len(pos)/size*3)
+		for i := 0; i+2 < len(pos); i += size {
+			packed.Append(pos[i : i+3]...)
+		}
+		pos = packed
+	}
+	return Mesh(pos, g.Indices()), nil
+}
+
+// shape reads a shape name or {kind = "cone", angle = 0.3, radius = 0.1}
+// table, "mesh" shapes taking a node with a mesh.
+func shape(v l.LValue) (Shape, error) {
+	kind := v.String()
+	t, isTable := v.(*l.LTable)
+	if isTable {
+		kind = t.RawGetString("kind").String()
+	}
+	num := func(k string, def float32) float32 {
+		if !isTable {
+			return def
+		}
+		if n, ok := t.RawGetString(k).(l.LNumber); ok {
+			return float32(n)
+		}
+		return def
+	}
+	switch StringToShapeT(kind) {
+	case POINT:
+		return Point(), nil
+	case SPHERE:
+		return Sphere(num("radius", 1), isTable && l.LVAsBool(t.RawGetString("surface"))), nil
+	case BOX:
+		size := [3]float32{1, 1, 1}
+		if isTable {
+			if sv := t.RawGetString("size"); sv != l.LNil {
+				var err error
+				if size, err = vec3("box size", sv); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return Box(size[0], size[1], size[2]), nil
+	case CONE:
+		return Cone(num("angle", 0.5), num("radius", 0)), nil
+	case MESH:
+		if !isTable {
+			return nil, ConfigError("shape", v)
+		}
+		return meshShape(t.RawGetString("node"))
+	}
+	return nil, ConfigError("shape", v)
+}
+
+// ConfigFrom reads an emitter configuration from a lua table, starting from
+// the defaults.
+//
+// {max = 500, shape = {kind = "sphere", radius = 1}, rate = 20,
+// bursts = {{time = 0, count = 50}}, duration = 2, loop = true, life = {1, 2},
+// speed = 1, spin = {-1, 1}, size = {{0, 0.1}, {1, 0.5}},
+// color = {{0, 1, 1, 1, 1}, {1, 1, 0, 0, 0}}, velocity = 1,
+// gravity = {0, -9.8, 0}, drag = 0.1, blend = "additive",
+// atlas = {texture = "smoke.png", cols = 4, rows = 4, frames = 16, fps = 12,
+// random = true}}
+func ConfigFrom(t *l.LTable) (*Config, error) {
+	c := DefaultConfig()
+	var err error
+	t.ForEach(func(k, v l.LValue) {
+		if err != nil {
+			return
+		}
+		key := k.String()
+		switch key {
+		case "max":
+			c.Max = int(l.LVAsNumber(v))
+		case "shape":
+			c.Shape, err = shape(v)
+		case "rate":
+			c.Rate = float32(l.LVAsNumber(v))
+		case "bursts":
+			bt, ok := v.(*l.LTable)
+			if !ok {
+				err = ConfigError(key, v)
+				return
+			}
+			for i := 1; i <= bt.Len(); i++ {
+				b, ok := bt.RawGetInt(i).(*l.LTable)
+				if !ok {
+					err = ConfigError(key, v)
+					return
+				}
+				c.Bursts = append(c.Bursts, Burst{
+					float32(l.LVAsNumber(b.RawGetString("time"))),
+					int(l.LVAsNumber(b.RawGetString("count"))),
+				})
+			}
+		case "duration":
+			c.Duration = float32(l.LVAsNumber(v))
+		case "loop":
+			c.Loop = l.LVAsBool(v)
+		case "life":
+			c.Life, err = between(key, v)
+		case "speed":
+			c.Speed, err = between(key, v)
+		case "spin":
+			c.Spin, err = between(key, v)
+		case "size":
+			c.Size, err = curve(key, 1, v)
+		case "color":
+			c.Color, err = curve(key, 4, v)
+		case "velocity":
+			c.Velocity, err = curve(key, 1, v)
+		case "gravity":
+			c.Gravity, err = vec3(key, v)
+		case "drag":
+			c.Drag = float32(l.LVAsNumber(v))
+		case "blend":
+			c.Additive = v.String() == "additive"
+		case "atlas":
+			at, ok := v.(*l.LTable)
+			if !ok {
+				err = ConfigError(key, v)
+				return
+			}
+			c.Atlas = Atlas{
+				Cols:   int(l.LVAsNumber(at.RawGetString("cols"))),
+				Rows:   int(l.LVAsNumber(at.RawGetString("rows"))),
+				Frames: int(l.LVAsNumber(at.RawGetString("frames"))),
+				FPS:    float32(l.LVAsNumber(at.RawGetString("fps"))),
+				Random: l.LVAsBool(at.RawGetString("random")),
+			}
+			if tv := at.RawGetString("texture"); tv != l.LNil {
+				tex, ok := tv.(l.LString)
+				if !ok {
+					err = ConfigError("atlas texture", tv)
+					return
+				}
+				c.Atlas.Texture = string(tex)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
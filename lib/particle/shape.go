@@ -0,0 +1,201 @@
+package particle
+
+import (
+	glm "math"
+	"math/rand"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+)
+
+// Shape samples the local spawn position and direction of a particle.
+type Shape interface {
+	Sample(*rand.Rand) (pos, dir [3]float32)
+}
+
+type ShapeT int
+
+const (
+	UNKNOWN_SHAPE ShapeT = iota
+	POINT
+	SPHERE
+	BOX
+	CONE
+	MESH
+)
+
+func (s ShapeT) String() string {
+	switch s {
+	case POINT:
+		return "point"
+	case SPHERE:
+		return "sphere"
+	case BOX:
+		return "box"
+	case CONE:
+		return "cone"
+	case MESH:
+		return "mesh"
+	}
+	return "unknown"
+}
+
+func StringToShapeT(s string) ShapeT {
+	switch strings.ToLower(s) {
+	case "point":
+		return POINT
+	case "sphere":
+		return SPHERE
+	case "box":
+		return BOX
+	case "cone":
+		return CONE
+	case "mesh":
+		return MESH
+	}
+	return UNKNOWN_SHAPE
+}
+
+func unit(r *rand.Rand) [3]float32 {
+	z := r.Float64()*2 - 1
+	a := r.Float64() * 2 * glm.Pi
+	s := glm.Sqrt(1 - z*z)
+	return [3]float32{float32(s * glm.Cos(a)), float32(s * glm.Sin(a)), float32(z)}
+}
+
+type point struct{}
+
+// Point emits from the origin in every direction.
+func Point() Shape {
+	return point{}
+}
+
+func (point) Sample(r *rand.Rand) ([3]float32, [3]float32) {
+	return [3]float32{}, unit(r)
+}
+
+type sphere struct {
+	radius  float32
+	surface bool
+}
+
+// Sphere emits outwards from within a sphere, or only its surface.
+func Sphere(radius float32, surface bool) Shape {
+	return &sphere{radius, surface}
+}
+
+func (s *sphere) Sample(r *rand.Rand) ([3]float32, [3]float32) {
+	d := unit(r)
+	l := s.radius
+	if !s.surface {
+		l *= float32(glm.Cbrt(r.Float64()))
+	}
+	return [3]float32{d[0] * l, d[1] * l, d[2] * l}, d
+}
+
+type box struct {
+	half [3]float32
+}
+
+// Box emits in every direction from within a box of the given size.
+func Box(x, y, z float32) Shape {
+	return &box{[3]float32{x / 2, y / 2, z / 2}}
+}
+
+func (b *box) Sample(r *rand.Rand) ([3]float32, [3]float32) {
+	var p [3]float32
+	for i := range p {
+		p[i] = (r.Float32()*2 - 1) * b.half[i]
+	}
+	return p, unit(r)
+}
+
+type cone struct {
+	angle, radius float32
+}
+
+// Cone emits along +y from a disc of radius, spreading up to angle radians.
+func Cone(angle, radius float32) Shape {
+	return &cone{angle, radius}
+}
+
+func (c *cone) Sample(r *rand.Rand) ([3]float32, [3]float32) {
+	a := r.Float64() * 2 * glm.Pi
+	d := float64(c.radius) * glm.Sqrt(r.Float64())
+	pos := [3]float32{float32(d * glm.Cos(a)), 0, float32(d * glm.Sin(a))}
+	// uniform direction within the cone solid angle
+	cosMax := glm.Cos(float64(c.angle))
+	z := 1 - r.Float64()*(1-cosMax)
+	s := glm.Sqrt(1 - z*z)
+	b := r.Float64() * 2 * glm.Pi
+	return pos, [3]float32{float32(s * glm.Cos(b)), float32(z), float32(s * glm.Sin(b))}
+}
+
+type mesh struct {
+	tris  [][3][3]float32
+	areas []float32
+	total float32
+}
+
+// Mesh emits from the surface of triangles along their normals, positions
+// holding 3 floats per vertex, indices 3 per triangle or none for a plain
+// triangle list. Larger triangles emit proportionally more.
+func Mesh(positions math.AF32, indices math.AU32) Shape {
+	vert := func(i uint32) [3]float32 {
+		return [3]float32{positions[i*3], positions[i*3+1], positions[i*3+2]}
+	}
+	if len(indices) == 0 {
+		for i := uint32(0); i < uint32(len(positions)/3); i++ {
+			indices = append(indices, i)
+		}
+	}
+	s := &mesh{}
+	for i := 0; i+2 < len(indices); i += 3 {
+		t := [3][3]float32{vert(indices[i]), vert(indices[i+1]), vert(indices[i+2])}
+		n := normal(t)
+		area := float32(glm.Sqrt(float64(n[0]*n[0]+n[1]*n[1]+n[2]*n[2]))) / 2
+		s.total += area
+		s.tris = append(s.tris, t)
+		s.areas = append(s.areas, s.total)
+	}
+	return s
+}
+
+func normal(t [3][3]float32) [3]float32 {
+	var u, v [3]float32
+	for i := 0; i < 3; i++ {
+		u[i] = t[1][i] - t[0][i]
+		v[i] = t[2][i] - t[0][i]
+	}
+	return [3]float32{
+		u[1]*v[2] - u[2]*v[1],
+		u[2]*v[0] - u[0]*v[2],
+		u[0]*v[1] - u[1]*v[0],
+	}
+}
+
+func (s *mesh) Sample(r *rand.Rand) ([3]float32, [3]float32) {
+	if len(s.tris) == 0 {
+		return point{}.Sample(r)
+	}
+	at := r.Float32() * s.total
+	i := 0
+	for i < len(s.areas)-1 && s.areas[i] < at {
+		i++
+	}
+	t := s.tris[i]
+	a, b := r.Float32(), r.Float32()
+	if a+b > 1 {
+		a, b = 1-a, 1-b
+	}
+	var p [3]float32
+	for c := 0; c < 3; c++ {
+		p[c] = t[0][c] + (t[1][c]-t[0][c])*a + (t[2][c]-t[0][c])*b
+	}
+	n := normal(t)
+	l := float32(glm.Sqrt(float64(n[0]*n[0] + n[1]*n[1] + n[2]*n[2])))
+	if l > 0 {
+		n = [3]float32{n[0] / l, n[1] / l, n[2] / l}
+	}
+	return p, n
+}
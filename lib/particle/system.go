@@ -0,0 +1,57 @@
+package particle
+
+var CurrentParticleSystem *particleSystem
+
+type particleSystem struct {
+	emitters []*Emitter
+}
+
+func (p *particleSystem) Priority() int {
+	return 5
+}
+
+// Update simulates every emitter by the frame delta, in nanoseconds.
+func (p *particleSystem) Update(d int64) error {
+	dt := float32(d) / 1e9
+	for _, e := range p.emitters {
+		e.Update(dt)
+	}
+	return nil
+}
+
+// Count is the number of emitters simulated.
+func (p *particleSystem) Count() int {
+	return len(p.emitters)
+}
+
+func (p *particleSystem) Add(es ...*Emitter) {
+	for _, e := range es {
+		if !p.has(e) {
+			p.emitters = append(p.emitters, e)
+		}
+	}
+}
+
+func (p *particleSystem) has(e *Emitter) bool {
+	for _, h := range p.emitters {
+		if h == e {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *particleSystem) Remove(id uint64) {
+	for idx, e := range p.emitters {
+		if e.ID() == id {
+			p.emitters = append(p.emitters[:idx], p.emitters[idx+1:]...)
+			return
+		}
+	}
+}
+
+func init() {
+	CurrentParticleSystem = &particleSystem{
+		make([]*Emitter, 0),
+	}
+}
@@ -1,6 +1,8 @@
 package render
 
 import (
+	"time"
+
 	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
 	"github.com/Laughs-In-Flowers/shiva/lib/graphics/shader"
 	"github.com/Laughs-In-Flowers/shiva/lib/math"
@@ -9,9 +11,15 @@ import (
 type fRenderer struct {
 	graphics.Provider
 	shader.Shaderer
-	view math.Matrice
-	proj math.Matrice
-	last math.Matrice
+	view   math.Matrice
+	proj   math.Matrice
+	last   math.Matrice
+	mask   uint32
+	stats  Stats
+	frame  *graphics.UniformBlock
+	lights *graphics.UniformBlock
+	env    *graphics.UniformBlock
+	start  time.Time
 }
 
 func newForwardRenderer(gp graphics.Provider) Renderer {
@@ -19,6 +27,12 @@ func newForwardRenderer(gp graphics.Provider) Renderer {
 		gp,
 		shader.DefaultShaderer(),
 		math.Mat4(), math.Mat4(), math.Mat4(),
+		AllLayers,
+		Stats{},
+		graphics.NewBoundBlock("Frame"),
+		graphics.NewBoundBlock("Lights"),
+		graphics.NewBoundBlock("Environment"),
+		time.Now(),
 	}
 	r.Initialize()
 	return r
@@ -30,6 +44,11 @@ func (r *fRenderer) ViewMatrice() math.Matrice {
 
 func (r *fRenderer) SetViewMatrice(m math.Matrice) {
 	r.view = m
+	r.frame.Set("ViewMatrix", 0, m.Raw()...)
+	if inv := m.Inverse(); inv != nil {
+		c := inv.Raw()
+		r.frame.Set("CameraPosition", 0, c[12], c[13], c[14], 1)
+	}
 }
 
 func (r *fRenderer) ProjectionMatrice() math.Matrice {
@@ -38,6 +57,31 @@ func (r *fRenderer) ProjectionMatrice() math.Matrice {
 
 func (r *fRenderer) SetProjectionMatrice(m math.Matrice) {
 	r.proj = m
+	r.frame.Set("ProjectionMatrix", 0, m.Raw()...)
+}
+
+func (r *fRenderer) Frame() *graphics.UniformBlock {
+	return r.frame
+}
+
+func (r *fRenderer) Lights() *graphics.UniformBlock {
+	return r.lights
+}
+
+func (r *fRenderer) Environment() *graphics.UniformBlock {
+	return r.env
+}
+
+// SetProgram sets the program of a profile, uploading the blocks programs
+// share when they changed since.
+func (r *fRenderer) SetProgram(p graphics.Provider, pr *shader.Profile) error {
+	err := r.Shaderer.SetProgram(p, pr)
+	for _, b := range []*graphics.UniformBlock{r.frame, r.lights, r.env} {
+		if b.Changed() {
+			b.Provide(p)
+		}
+	}
+	return err
 }
 
 func (r *fRenderer) Last() math.Matrice {
@@ -48,6 +92,27 @@ func (r *fRenderer) SetLast(m math.Matrice) {
 	r.last = m
 }
 
+func (r *fRenderer) Mask() uint32 {
+	return r.mask
+}
+
+func (r *fRenderer) SetMask(m uint32) {
+	r.mask = m
+}
+
+func (r *fRenderer) Count(draws, vertices int) {
+	r.stats.Draws += draws
+	r.stats.Vertices += vertices
+}
+
+func (r *fRenderer) Stats() Stats {
+	return r.stats
+}
+
+func (r *fRenderer) ResetStats() {
+	r.stats = Stats{}
+}
+
 func (r *fRenderer) Type() RendererT {
 	return FORWARD
 }
@@ -80,8 +145,49 @@ func (r *fRenderer) Rend(d ...Renderable) {
 	r.post()
 }
 
+// RendPass renders into the pass target, or the default framebuffer when the
+// pass has no target, clipped to the pass viewport and filtered by pass mask.
+func (r *fRenderer) RendPass(p *Pass, d ...Renderable) {
+	width, height := p.Width, p.Height
+	if t := p.Target; t != nil {
+		t.Provide(r)
+		width, height = t.Size()
+	} else {
+		r.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+	}
+
+	vp := p.Viewport
+	if vp == nil {
+		vp = FullViewport()
+	}
+	x, y, w, h := vp.Pixels(width, height)
+	r.Viewport(x, y, w, h)
+	r.Enable(graphics.SCISSOR_TEST)
+	r.Scissor(x, y, w, h)
+
+	if v := p.View; v != nil {
+		r.SetViewMatrice(v.ViewMatrix())
+		r.SetProjectionMatrice(v.ProjectionMatrix())
+	}
+	r.SetLast(math.IdentityMatrix(math.MAT4))
+
+	mask := r.mask
+	r.mask = p.Mask
+	r.Rend(d...)
+	r.mask = mask
+
+	r.Disable(graphics.SCISSOR_TEST)
+	if p.Target != nil {
+		r.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+	}
+}
+
 func (r *fRenderer) pre() {
 	r.Clear(graphics.COLOR_BUFFER_BIT | graphics.DEPTH_BUFFER_BIT | graphics.STENCIL_BUFFER_BIT)
+	r.frame.Set("Time", 0, float32(time.Since(r.start).Seconds()))
+	r.frame.Provide(r)
+	r.lights.Provide(r)
+	r.env.Provide(r)
 }
 
 func (r *fRenderer) post() {
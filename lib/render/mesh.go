@@ -13,6 +13,7 @@ type Mesh interface {
 	graphics.Moder
 	Geometer
 	Materializer
+	Instancer
 	Renderable
 }
 
@@ -25,6 +26,8 @@ type mesh struct {
 	mode       graphics.Enum
 	renderable bool
 	rfn        innerRenderFunc
+	instanced  bool
+	instances  int
 }
 
 func NewMesh(tag string, e geometry.Geometry, rfn innerRenderFunc, mode graphics.Enum) *mesh {
@@ -72,6 +75,28 @@ func (m *mesh) Render(r Renderer) {
 	m.rfn(r)
 }
 
+// Instancer draws a mesh as many instances in one call, per instance
+// attributes coming from geometry buffers with a divisor set.
+type Instancer interface {
+	Instanced() bool
+	Instances() int
+	SetInstances(int)
+}
+
+func (m *mesh) Instanced() bool {
+	return m.instanced
+}
+
+func (m *mesh) Instances() int {
+	return m.instances
+}
+
+// SetInstances makes the mesh instanced, drawing n instances, none for n 0.
+func (m *mesh) SetInstances(n int) {
+	m.instanced = true
+	m.instances = n
+}
+
 type Geometer interface {
 	Geometry() geometry.Geometry
 }
@@ -85,6 +110,7 @@ type Materializer interface {
 	GetMaterial(int) material.Material
 	AddMaterial(material.Material, int, int)
 	AddGroupMaterial(material.Material, int) error
+	SetMaterial(material.Material)
 }
 
 func (m *mesh) Materials() []Material {
@@ -108,6 +134,18 @@ func (m *mesh) AddMaterial(a material.Material, start, count int) {
 	m.materials = append(m.materials, gm)
 }
 
+// SetMaterial draws every group of the mesh with a material, the whole of
+// it when it has none.
+func (m *mesh) SetMaterial(a material.Material) {
+	if len(m.materials) == 0 {
+		m.AddMaterial(a, 0, 0)
+		return
+	}
+	for i := range m.materials {
+		m.materials[i].m = a
+	}
+}
+
 var InvalidGroupIdxError = xrror.Xrror("%d is an invalid group index for graphic geometry %v").Out
 
 func (m *mesh) AddGroupMaterial(a material.Material, gidx int) error {
@@ -128,6 +166,10 @@ type Material struct {
 	count  int
 }
 
+func (m *Material) Material() material.Material {
+	return m.m
+}
+
 func (m *Material) Shader(r Renderer) {
 	pr := r.GenerateProfile(m.m)
 	r.SetProgram(r, pr)
@@ -153,16 +195,45 @@ func (m *Material) Render(r Renderer) {
 
 	indices := gg.Indices()
 	mode := parent.Mode()
+	if parent.Instanced() {
+		m.renderInstanced(r, mode, count)
+		return
+	}
 	if indices.Size() > 0 {
 		if count == 0 {
 			count = indices.Size()
 		}
 		val := 4 * uint32(m.start)
 		r.DrawElements(mode, int32(count), graphics.UNSIGNED_INT, r.Ptr(&val))
+		r.Count(1, count)
 	} else {
 		if count == 0 {
 			count = gg.VBOItems()
 		}
 		r.DrawArrays(mode, int32(m.start), int32(count))
+		r.Count(1, count)
+	}
+}
+
+func (m *Material) renderInstanced(r Renderer, mode graphics.Enum, count int) {
+	n := int32(m.parent.Instances())
+	if n <= 0 {
+		return
+	}
+	gg := m.g
+	indices := gg.Indices()
+	if indices.Size() > 0 {
+		if count == 0 {
+			count = indices.Size()
+		}
+		val := 4 * uint32(m.start)
+		r.DrawElementsInstanced(mode, int32(count), graphics.UNSIGNED_INT, r.Ptr(&val), n)
+		r.Count(1, count*int(n))
+		return
+	}
+	if count == 0 {
+		count = gg.VBOItems()
 	}
+	r.DrawArraysInstanced(mode, int32(m.start), int32(count), n)
+	r.Count(1, count*int(n))
 }
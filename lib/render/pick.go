@@ -0,0 +1,87 @@
+package render
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/shader"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+)
+
+// IDBuffer is an offscreen target meshes are drawn into with a flat color
+// encoding an id, read back to find what is under a pixel.
+type IDBuffer struct {
+	*target
+	profile *shader.Profile
+	id      graphics.Uniform
+	mvp     graphics.Uniform
+}
+
+func NewIDBuffer(width, height int) *IDBuffer {
+	return &IDBuffer{
+		NewTarget(width, height),
+		&shader.Profile{Prog: shader.PickProg, Independent: true},
+		graphics.Uniform4f("PickID"),
+		graphics.UniformMatrix4fv("MVP"),
+	}
+}
+
+// EncodeID packs an id into an rgba color, 0 being nothing.
+func EncodeID(id uint32) []float32 {
+	return []float32{
+		float32(id&0xFF) / 255,
+		float32((id>>8)&0xFF) / 255,
+		float32((id>>16)&0xFF) / 255,
+		float32((id>>24)&0xFF) / 255,
+	}
+}
+
+func DecodeID(px [4]uint8) uint32 {
+	return uint32(px[0]) | uint32(px[1])<<8 | uint32(px[2])<<16 | uint32(px[3])<<24
+}
+
+// Begin binds and clears the buffer for drawing ids.
+func (b *IDBuffer) Begin(r Renderer) error {
+	b.Provide(r)
+	if err := b.Err(); err != nil {
+		r.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+		return err
+	}
+	w, h := b.Size()
+	r.Viewport(0, 0, int32(w), int32(h))
+	r.Disable(graphics.BLEND)
+	r.ClearColor(0, 0, 0, 0)
+	r.Clear(graphics.COLOR_BUFFER_BIT | graphics.DEPTH_BUFFER_BIT)
+	return r.SetProgram(r, b.profile)
+}
+
+// Draw draws the mesh geometry with the provided id and model view
+// projection matrix.
+func (b *IDBuffer) Draw(r Renderer, id uint32, m Mesh, mvp math.Matrice) {
+	b.id.Update(EncodeID(id)...)
+	b.id.Transfer(r)
+	b.mvp.Update(mvp.Raw()...)
+	b.mvp.Transfer(r)
+
+	g := m.Geometry()
+	g.Provide(r)
+	indices := g.Indices()
+	if count := indices.Size(); count > 0 {
+		var start uint32
+		r.DrawElements(m.Mode(), int32(count), graphics.UNSIGNED_INT, r.Ptr(&start))
+		return
+	}
+	r.DrawArrays(m.Mode(), 0, int32(g.VBOItems()))
+}
+
+// Read returns the id drawn at pixel x, y, origin at bottom left.
+func (b *IDBuffer) Read(r Renderer, x, y int) uint32 {
+	var px [4]uint8
+	r.ReadPixels(int32(x), int32(y), 1, 1, graphics.RGBA, graphics.UNSIGNED_BYTE, r.Ptr(&px[0]))
+	return DecodeID(px)
+}
+
+// End restores default framebuffer state after drawing ids.
+func (b *IDBuffer) End(r Renderer) {
+	r.UseProgram(0)
+	r.Enable(graphics.BLEND)
+	r.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+}
@@ -80,13 +80,37 @@ type Space interface {
 	SetLast(math.Matrice)
 }
 
+// Stats count what has been drawn since they were last reset.
+type Stats struct {
+	Draws, Vertices int
+}
+
+type Counter interface {
+	Count(draws, vertices int)
+	Stats() Stats
+	ResetStats()
+}
+
+// Blocker has the uniform blocks every program of a renderer shares: the
+// Frame block, of the camera and time, the Lights block and the Environment
+// block.
+type Blocker interface {
+	Frame() *graphics.UniformBlock
+	Lights() *graphics.UniformBlock
+	Environment() *graphics.UniformBlock
+}
+
 type Renderer interface {
 	graphics.Provider
 	shader.Shaderer
 	Space
+	Masker
+	Counter
+	Blocker
 	Type() RendererT
 	Initialize()
 	Rend(...Renderable)
+	RendPass(*Pass, ...Renderable)
 }
 
 func init() {
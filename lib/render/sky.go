@@ -0,0 +1,144 @@
+package render
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/shader"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/texture"
+)
+
+type SkyT int
+
+const (
+	SKY_NONE SkyT = iota
+	SKY_GRADIENT
+	SKY_CUBE
+	SKY_EQUIRECT
+)
+
+func (s SkyT) String() string {
+	switch s {
+	case SKY_GRADIENT:
+		return "gradient"
+	case SKY_CUBE:
+		return "cube"
+	case SKY_EQUIRECT:
+		return "equirect"
+	}
+	return "none"
+}
+
+func StringToSkyT(s string) SkyT {
+	switch s {
+	case "gradient":
+		return SKY_GRADIENT
+	case "cube":
+		return SKY_CUBE
+	case "equirect":
+		return SKY_EQUIRECT
+	}
+	return SKY_NONE
+}
+
+// Sky draws behind whatever a pass draws, before it and without writing
+// depth: a gradient of the colors of the Environment block, a cube map or
+// an equirectangular image.
+type Sky struct {
+	profile   *shader.Profile
+	kind      SkyT
+	cube      *texture.Cube
+	equirect  *texture.Float
+	uCube     graphics.Uniform
+	uEquirect graphics.Uniform
+	vao       uint32
+	p         graphics.Provider
+}
+
+func NewSky() *Sky {
+	s := &Sky{
+		profile:   &shader.Profile{Prog: shader.SkyProg, Independent: true},
+		uCube:     graphics.Uniform1i("SkyCube"),
+		uEquirect: graphics.Uniform1i("SkyEquirect"),
+	}
+	s.uCube.Update(0)
+	s.uEquirect.Update(1)
+	return s
+}
+
+func (s *Sky) Kind() SkyT {
+	return s.kind
+}
+
+// SetGradient draws the sky as a gradient, top to horizon to bottom.
+func (s *Sky) SetGradient() {
+	s.set(SKY_GRADIENT, nil, nil)
+}
+
+// SetCube draws the sky from a cube map.
+func (s *Sky) SetCube(c *texture.Cube) {
+	s.set(SKY_CUBE, c, nil)
+}
+
+// SetEquirect draws the sky from an equirectangular image.
+func (s *Sky) SetEquirect(f *texture.Float) {
+	s.set(SKY_EQUIRECT, nil, f)
+}
+
+// Clear draws no sky, leaving what a pass clears to.
+func (s *Sky) Clear() {
+	s.set(SKY_NONE, nil, nil)
+}
+
+func (s *Sky) set(k SkyT, c *texture.Cube, f *texture.Float) {
+	if s.cube != nil && s.cube != c {
+		s.cube.Close()
+	}
+	if s.equirect != nil && s.equirect != f {
+		s.equirect.Close()
+	}
+	s.kind, s.cube, s.equirect = k, c, f
+}
+
+func (s *Sky) Renderable() bool {
+	return s.kind != SKY_NONE
+}
+
+func (s *Sky) SetRenderable(bool) {}
+
+func (s *Sky) Render(r Renderer) {
+	if s.kind == SKY_NONE {
+		return
+	}
+	if s.p == nil {
+		s.vao = r.GenVertexArray()
+		s.p = r
+	}
+	r.Environment().Set("SkyParams", 0, float32(s.kind))
+	if err := r.SetProgram(r, s.profile); err != nil {
+		return
+	}
+	switch s.kind {
+	case SKY_CUBE:
+		if s.cube != nil {
+			s.cube.Render(r, 0)
+		}
+	case SKY_EQUIRECT:
+		if s.equirect != nil {
+			s.equirect.Render(r, 1)
+		}
+	}
+	s.uCube.Transfer(r)
+	s.uEquirect.Transfer(r)
+	r.BindVertexArray(s.vao)
+	r.DepthMask(false)
+	r.DrawArrays(graphics.TRIANGLES, 0, 3)
+	r.DepthMask(true)
+	r.Count(1, 3)
+}
+
+func (s *Sky) Close() {
+	if s.p != nil {
+		s.p.DeleteVertexArray(s.vao)
+	}
+	s.p, s.vao = nil, 0
+	s.set(SKY_NONE, nil, nil)
+}
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// Target is an offscreen destination for a render pass.
+type Target interface {
+	graphics.Closer
+	graphics.Providable
+	Size() (int, int)
+	Resize(int, int)
+	Texture() graphics.Texture
+	Err() error
+}
+
+type target struct {
+	p             graphics.Provider
+	width, height int
+	fbo           graphics.Buffer
+	depth         graphics.Buffer
+	color         graphics.Texture
+	update        bool
+	err           error
+}
+
+// NewTarget returns a framebuffer backed Target with a color texture and a
+// depth renderbuffer of the provided size.
+func NewTarget(width, height int) *target {
+	return &target{
+		width:  width,
+		height: height,
+		update: true,
+	}
+}
+
+func (t *target) Size() (int, int) {
+	return t.width, t.height
+}
+
+func (t *target) Resize(width, height int) {
+	if width != t.width || height != t.height {
+		t.width = width
+		t.height = height
+		t.update = true
+	}
+}
+
+func (t *target) Texture() graphics.Texture {
+	return t.color
+}
+
+func (t *target) Err() error {
+	return t.err
+}
+
+var IncompleteTargetError = xrror.Xrror("offscreen target %dx%d incomplete, status %d").Out
+
+// Provide binds the target framebuffer, (re)allocating storage as needed.
+func (t *target) Provide(p graphics.Provider) {
+	if t.p == nil {
+		t.fbo = p.GenFramebuffer()
+		t.depth = p.GenRenderbuffer()
+		t.color = p.GenTexture()
+		t.p = p
+	}
+
+	p.BindFramebuffer(graphics.FRAMEBUFFER, t.fbo)
+
+	if !t.update {
+		return
+	}
+
+	w, h := int32(t.width), int32(t.height)
+
+	p.BindTexture(graphics.TEXTURE_2D, t.color)
+	p.TexImage2D(graphics.TEXTURE_2D, 0, graphics.RGBA8, w, h, 0, graphics.RGBA, graphics.UNSIGNED_BYTE, nil, 0)
+	p.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MIN_FILTER, graphics.LINEAR)
+	p.TexParameteri(graphics.TEXTURE_2D, graphics.TEXTURE_MAG_FILTER, graphics.LINEAR)
+	p.FramebufferTexture2D(graphics.FRAMEBUFFER, graphics.COLOR_ATTACHMENT0, graphics.TEXTURE_2D, t.color, 0)
+
+	p.BindRenderbuffer(graphics.RENDERBUFFER, t.depth)
+	p.RenderbufferStorage(graphics.RENDERBUFFER, graphics.DEPTH24_STENCIL8, w, h)
+	p.FramebufferRenderbuffer(graphics.FRAMEBUFFER, graphics.DEPTH_STENCIL_ATTACHMENT, graphics.RENDERBUFFER, t.depth)
+
+	if status := p.CheckFramebufferStatus(graphics.FRAMEBUFFER); status != graphics.FRAMEBUFFER_COMPLETE {
+		t.err = IncompleteTargetError(t.width, t.height, status)
+	} else {
+		t.err = nil
+	}
+
+	t.update = false
+}
+
+func (t *target) Close() {
+	if p := t.p; p != nil {
+		p.DeleteFramebuffer(t.fbo)
+		p.DeleteRenderbuffer(t.depth)
+		p.DeleteTexture(t.color)
+	}
+	t.p = nil
+	t.update = true
+}
@@ -0,0 +1,76 @@
+package render
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+)
+
+// Layer bits, a renderable is drawn by a pass when its layers and the pass
+// mask share at least one bit.
+const (
+	DefaultLayer uint32 = 1
+	AllLayers    uint32 = 0xFFFFFFFF
+	NoLayers     uint32 = 0
+)
+
+type Masker interface {
+	Mask() uint32
+	SetMask(uint32)
+}
+
+// Visible reports whether layers are visible to the provided mask.
+func Visible(layers, mask uint32) bool {
+	return layers&mask != 0
+}
+
+// Viewport is a rectangle expressed as fractions(0 to 1) of the size of
+// whatever is being rendered into, origin at bottom left.
+type Viewport struct {
+	X, Y, W, H float32
+}
+
+func FullViewport() *Viewport {
+	return &Viewport{0, 0, 1, 1}
+}
+
+func (v *Viewport) Set(x, y, w, h float32) {
+	v.X = x
+	v.Y = y
+	v.W = w
+	v.H = h
+}
+
+func (v *Viewport) Raw() []float32 {
+	return []float32{v.X, v.Y, v.W, v.H}
+}
+
+// Pixels resolves the viewport against a width and height in pixels.
+func (v *Viewport) Pixels(width, height int) (int32, int32, int32, int32) {
+	fw, fh := float32(width), float32(height)
+	return int32(v.X * fw), int32(v.Y * fh), int32(v.W * fw), int32(v.H * fh)
+}
+
+// Aspect returns the aspect ratio of the viewport resolved against a width
+// and height in pixels.
+func (v *Viewport) Aspect(width, height int) float32 {
+	_, _, w, h := v.Pixels(width, height)
+	if h == 0 {
+		return 1
+	}
+	return float32(w) / float32(h)
+}
+
+// Pass describes one rendering of a set of renderables, from one point of view
+// into one viewport of a target.
+type Pass struct {
+	Mask          uint32
+	Viewport      *Viewport
+	Target        Target
+	View          Viewer
+	Width, Height int
+}
+
+// Viewer provides the matrices a Pass renders with.
+type Viewer interface {
+	ViewMatrix() math.Matrice
+	ProjectionMatrix() math.Matrice
+}
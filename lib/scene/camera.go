@@ -225,9 +225,74 @@ func orthographic(left, right, top, bottom, near, far float32) *cam {
 	return c
 }
 
+func (c *cam) Kind() CamT {
+	return c.camt
+}
+
+// view holds what a camera renders and where, when the camera is attached to
+// a scene.
+type view struct {
+	mask     uint32
+	order    int
+	viewport *render.Viewport
+	target   render.Target
+	attached bool
+}
+
+func newView() *view {
+	return &view{
+		mask:     render.AllLayers,
+		viewport: render.FullViewport(),
+	}
+}
+
+func (v *view) Mask() uint32 {
+	return v.mask
+}
+
+func (v *view) SetMask(m uint32) {
+	v.mask = m
+}
+
+func (v *view) Order() int {
+	return v.order
+}
+
+func (v *view) SetOrder(o int) {
+	v.order = o
+}
+
+func (v *view) Viewport() *render.Viewport {
+	return v.viewport
+}
+
+func (v *view) SetViewport(x, y, w, h float32) {
+	v.viewport.Set(x, y, w, h)
+}
+
+func (v *view) Target() render.Target {
+	return v.target
+}
+
+func (v *view) SetTarget(t render.Target) {
+	if v.target != nil && v.target != t {
+		v.target.Close()
+	}
+	v.target = t
+}
+
+func (v *view) Attached() bool {
+	return v.attached
+}
+
+func (v *view) setAttached(as bool) {
+	v.attached = as
+}
+
 type camera struct {
 	*cam
 	*position
+	*view
 	target     math.Vector
 	up         math.Vector
 	viewMatrix math.Matrice
@@ -237,6 +302,7 @@ func newCamera(ck *cam) *camera {
 	c := &camera{
 		ck,
 		newPosition(),
+		newView(),
 		math.Vec3(0, 0, 0),
 		math.Vec3(0, 1, 0),
 		math.Mat4(),
@@ -270,6 +336,25 @@ func (c *camera) ViewMatrix() math.Matrice {
 	return c.viewMatrix
 }
 
+// Viewer is a camera node a Scene can render from.
+type Viewer interface {
+	Node
+	Cam
+	render.Masker
+	render.Viewer
+	Kind() CamT
+	Order() int
+	SetOrder(int)
+	Viewport() *render.Viewport
+	SetViewport(float32, float32, float32, float32)
+	Target() render.Target
+	SetTarget(render.Target)
+	Attached() bool
+	setAttached(bool)
+	localMatrix() math.Matrice
+	setMatrixWorld(math.Matrice)
+}
+
 type cameraNode struct {
 	*camera
 	*node
@@ -280,10 +365,17 @@ const lCameraNodeClass = "NCAMERA"
 func Camera(tag string, c *cam) Node {
 	cc := newCamera(c)
 	nn := newNode(tag, func(r render.Renderer, n Node) {
+		// cameras attached to a scene are placed before the passes and
+		// provide matrices per pass
+		if cc.Attached() {
+			r.SetLast(math.MultiplyMatrices(r.Last(), cc.localMatrix()))
+			return
+		}
 		cc.updateMatrixWorld(r)
 		r.SetViewMatrice(cc.ViewMatrix())
 		r.SetProjectionMatrice(cc.ProjectionMatrix())
 	}, defaultRemovalFn, defaultReplaceFn, lCameraNodeClass, lNodeClass)
+	nn.layers = render.AllLayers
 
 	return &cameraNode{
 		cc,
@@ -364,6 +456,63 @@ func cameraMember(fn cameraMemberFunc) l.LGFunction {
 	}
 }
 
+func cameraProperty(get, set cameraMemberFunc) l.LGFunction {
+	return lua.NewProperty(cameraMember(get), cameraMember(set))
+}
+
+func getCameraMask(L *l.LState, u *l.LUserData, n *cameraNode) int {
+	L.Push(l.LNumber(n.Mask()))
+	return 1
+}
+
+func setCameraMask(L *l.LState, u *l.LUserData, n *cameraNode) int {
+	mask := L.CheckInt64(3)
+	n.SetMask(uint32(mask))
+	return 0
+}
+
+func getCameraOrder(L *l.LState, u *l.LUserData, n *cameraNode) int {
+	L.Push(l.LNumber(n.Order()))
+	return 1
+}
+
+func setCameraOrder(L *l.LState, u *l.LUserData, n *cameraNode) int {
+	order := L.CheckInt(3)
+	n.SetOrder(order)
+	return 0
+}
+
+func getCameraViewport(L *l.LState, u *l.LUserData, n *cameraNode) int {
+	vp := math.VecUnp(n.Viewport().Raw()...)
+	fn := func(u *l.LUserData) {
+		u.Value = vp
+	}
+	lua.PushNewUserData(L, fn, math.VEC4)
+	return 1
+}
+
+func setCameraViewport(L *l.LState, u *l.LUserData, n *cameraNode) int {
+	vp := math.UnpackToVec(L, 3, math.VEC4, true)
+	n.SetViewport(vp.Get(0), vp.Get(1), vp.Get(2), vp.Get(3))
+	return 0
+}
+
+func cameraOffscreen(L *l.LState, u *l.LUserData, n *cameraNode) int {
+	width := L.CheckInt(2)
+	height := L.CheckInt(3)
+	if t := n.Target(); t != nil {
+		t.Resize(width, height)
+		return 0
+	}
+	n.SetTarget(render.NewTarget(width, height))
+	return 0
+}
+
+func cameraOnscreen(L *l.LState, u *l.LUserData, n *cameraNode) int {
+	n.SetTarget(nil)
+	return 0
+}
+
 func cameraFov(L *l.LState, u *l.LUserData, n *cameraNode) int {
 	return 0
 }
@@ -388,13 +537,19 @@ var lCameraNodeTable = &lua.Table{
 	lCameraNodeClass,
 	[]*lua.Table{nodeTable},
 	defaultIdxMetaFuncs(),
-	map[string]l.LGFunction{},
 	map[string]l.LGFunction{
-		"fov":    cameraMember(cameraFov),
-		"aspect": cameraMember(cameraAspect),
-		"near":   cameraMember(cameraNear),
-		"far":    cameraMember(cameraFar),
-		"zoom":   cameraMember(cameraZoom),
+		"mask":     cameraProperty(getCameraMask, setCameraMask),
+		"order":    cameraProperty(getCameraOrder, setCameraOrder),
+		"viewport": cameraProperty(getCameraViewport, setCameraViewport),
+	},
+	map[string]l.LGFunction{
+		"fov":       cameraMember(cameraFov),
+		"aspect":    cameraMember(cameraAspect),
+		"near":      cameraMember(cameraNear),
+		"far":       cameraMember(cameraFar),
+		"zoom":      cameraMember(cameraZoom),
+		"offscreen": cameraMember(cameraOffscreen),
+		"onscreen":  cameraMember(cameraOnscreen),
 		//view matrix
 		//projection matrix
 	},
@@ -0,0 +1,49 @@
+package scene
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	glm "math"
+)
+
+type rawer interface {
+	Raw() []float32
+}
+
+// Checksum sums the current scene graph, each node's tag, class, visibility
+// and any transform values, for detecting replays diverging. It is 0 with no
+// scene.
+func Checksum() uint64 {
+	if currentScene == nil {
+		return 0
+	}
+	return currentScene.Checksum()
+}
+
+func (s *Scene) Checksum() uint64 {
+	h := fnv.New64a()
+	var b [4]byte
+	var visit func(Node)
+	visit = func(n Node) {
+		h.Write([]byte(n.Tag()))
+		h.Write([]byte(n.LClass()))
+		if n.Hidden() {
+			h.Write([]byte{1})
+		} else {
+			h.Write([]byte{0})
+		}
+		if r, ok := n.(rawer); ok {
+			for _, v := range r.Raw() {
+				binary.LittleEndian.PutUint32(b[:], glm.Float32bits(v))
+				h.Write(b[:])
+			}
+		}
+		for _, o := range n.Out() {
+			visit(o)
+		}
+	}
+	for _, n := range s.n.List() {
+		visit(n)
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,349 @@
+package scene
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/texture"
+	"github.com/Laughs-In-Flowers/shiva/lib/render"
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+type FogT int
+
+const (
+	FOG_NONE FogT = iota
+	FOG_LINEAR
+	FOG_EXP
+	FOG_EXP2
+	FOG_HEIGHT
+)
+
+func (f FogT) String() string {
+	switch f {
+	case FOG_LINEAR:
+		return "linear"
+	case FOG_EXP:
+		return "exp"
+	case FOG_EXP2:
+		return "exp2"
+	case FOG_HEIGHT:
+		return "height"
+	}
+	return "none"
+}
+
+func StringToFogT(s string) FogT {
+	switch s {
+	case "linear":
+		return FOG_LINEAR
+	case "exp":
+		return FOG_EXP
+	case "exp2":
+		return FOG_EXP2
+	case "height":
+		return FOG_HEIGHT
+	}
+	return FOG_NONE
+}
+
+// Fog is linear from Start to End, exponential of Density, or exponential
+// of Density thinning with height above Base by Falloff.
+type Fog struct {
+	Mode                FogT
+	Color               [4]float32
+	Density, Start, End float32
+	Base, Falloff       float32
+}
+
+// SkyColors are those of a gradient sky, with how sharp the horizon is,
+// how an image sky is exposed, none for as it is, and how far about the up
+// axis either is turned.
+type SkyColors struct {
+	Top, Horizon, Bottom [4]float32
+	Sharpness            float32
+	Exposure             float32
+	Rotation             float32
+}
+
+// Environment is what a scene has around the nodes it draws: an ambient
+// light lighting every lit material, a fog and a sky.
+type Environment struct {
+	Ambient [4]float32
+	Fog     Fog
+	Colors  SkyColors
+	sky     *render.Sky
+	source  []string
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{
+		Ambient: [4]float32{0, 0, 0, 1},
+		Fog: Fog{
+			Color:   [4]float32{0.5, 0.5, 0.5, 1},
+			Density: 0.02,
+			Start:   10,
+			End:     100,
+			Falloff: 0.1,
+		},
+		Colors: SkyColors{
+			Top:       [4]float32{0.2, 0.4, 0.8, 1},
+			Horizon:   [4]float32{0.7, 0.8, 0.9, 1},
+			Bottom:    [4]float32{0.3, 0.3, 0.3, 1},
+			Sharpness: 4,
+		},
+		sky: render.NewSky(),
+	}
+}
+
+func (e *Environment) Sky() *render.Sky {
+	return e.sky
+}
+
+// SkySource is the files the sky was loaded from, six faces of a cube or
+// one equirectangular image, none for a gradient.
+func (e *Environment) SkySource() []string {
+	return e.source
+}
+
+var (
+	CubeFacesError = xrror.Xrror("a cube sky takes 6 faces, not %d").Out
+	CubeSizeError  = xrror.Xrror("cube face %s is %dx%d, not square as the first %dx%d").Out
+)
+
+// LoadCube draws the sky from six images, the +x, -x, +y, -y, +z and -z
+// faces of a cube.
+func (e *Environment) LoadCube(paths ...string) error {
+	if len(paths) != texture.CUBE_FACES {
+		return CubeFacesError(len(paths))
+	}
+	c := texture.NewCube()
+	var fw, fh int
+	for i, p := range paths {
+		pix, w, h, err := decodeImage(p)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			fw, fh = w, h
+		}
+		if w != h || w != fw {
+			return CubeSizeError(p, w, h, fw, fh)
+		}
+		c.SetFace(i, w, h, pix)
+	}
+	e.sky.SetCube(c)
+	e.source = append([]string(nil), paths...)
+	return nil
+}
+
+// LoadEquirect draws the sky from an equirectangular image, a radiance hdr
+// or a png or jpeg.
+func (e *Environment) LoadEquirect(path string) error {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var pix []float32
+	var w, h int
+	if strings.EqualFold(filepath.Ext(path), ".hdr") {
+		pix, w, h, err = texture.DecodeHDR(f)
+	} else {
+		var rgba []byte
+		rgba, w, h, err = texture.DecodeRGBA(f)
+		pix = make([]float32, w*h*3)
+		for i := 0; i < w*h; i++ {
+			for c := 0; c < 3; c++ {
+				pix[i*3+c] = float32(rgba[i*4+c]) / 255
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	t := texture.NewFloat()
+	t.Set(w, h, pix)
+	e.sky.SetEquirect(t)
+	e.source = []string{path}
+	return nil
+}
+
+// SetGradient draws the sky as a gradient of its colors.
+func (e *Environment) SetGradient() {
+	e.sky.SetGradient()
+	e.source = nil
+}
+
+// ClearSky draws no sky.
+func (e *Environment) ClearSky() {
+	e.sky.Clear()
+	e.source = nil
+}
+
+// Provide writes the environment into an Environment block.
+func (e *Environment) Provide(b *graphics.UniformBlock) {
+	c := e.Colors
+	b.Set("EnvAmbient", 0, e.Ambient[:3]...)
+	b.Set("FogColor", 0, e.Fog.Color[0], e.Fog.Color[1], e.Fog.Color[2], float32(e.Fog.Mode))
+	b.Set("FogParams", 0, e.Fog.Density, e.Fog.Start, e.Fog.End)
+	b.Set("FogHeight", 0, e.Fog.Base, e.Fog.Falloff)
+	b.Set("SkyTop", 0, c.Top[:]...)
+	b.Set("SkyHorizon", 0, c.Horizon[:]...)
+	b.Set("SkyBottom", 0, c.Bottom[:]...)
+	b.Set("SkyParams", 0, float32(e.sky.Kind()), c.Exposure, c.Rotation, c.Sharpness)
+}
+
+func (e *Environment) Close() {
+	e.sky.Close()
+	e.source = nil
+}
+
+func pushColor(L *l.LState, c [4]float32) {
+	t := L.CreateTable(4, 0)
+	for _, v := range c {
+		t.Append(l.LNumber(v))
+	}
+	L.Push(t)
+}
+
+func setNumber(t *l.LTable, key string, to *float32) {
+	if n, ok := t.RawGetString(key).(l.LNumber); ok {
+		*to = float32(n)
+	}
+}
+
+func setColor(t *l.LTable, key string, to *[4]float32) {
+	if c, ok := checkColor(t.RawGetString(key)); ok {
+		*to = c
+	}
+}
+
+// environmentFrom applies {ambient = {r, g, b}, fog = {mode = "exp", color =
+// {r, g, b}, density = 0.02, start = 10, ["end"] = 100, base = 0, falloff =
+// 0.1}, sky = {top = {...}, horizon = {...}, bottom = {...}, sharpness = 4,
+// exposure = 1, rotation = 0, cube = {px, nx, py, ny, pz, nz} | equirect =
+// "sky.hdr" | gradient = true | kind = "none"}, false for no sky} to an
+// environment, what it leaves out as it was.
+func environmentFrom(L *l.LState, e *Environment, t *l.LTable) error {
+	setColor(t, "ambient", &e.Ambient)
+	if f, ok := t.RawGetString("fog").(*l.LTable); ok {
+		if m, ok := f.RawGetString("mode").(l.LString); ok {
+			e.Fog.Mode = StringToFogT(string(m))
+		}
+		setColor(f, "color", &e.Fog.Color)
+		setNumber(f, "density", &e.Fog.Density)
+		setNumber(f, "start", &e.Fog.Start)
+		setNumber(f, "end", &e.Fog.End)
+		setNumber(f, "base", &e.Fog.Base)
+		setNumber(f, "falloff", &e.Fog.Falloff)
+	} else if t.RawGetString("fog") == l.LFalse {
+		e.Fog.Mode = FOG_NONE
+	}
+	switch s := t.RawGetString("sky").(type) {
+	case *l.LTable:
+		setColor(s, "top", &e.Colors.Top)
+		setColor(s, "horizon", &e.Colors.Horizon)
+		setColor(s, "bottom", &e.Colors.Bottom)
+		setNumber(s, "sharpness", &e.Colors.Sharpness)
+		setNumber(s, "exposure", &e.Colors.Exposure)
+		setNumber(s, "rotation", &e.Colors.Rotation)
+		switch {
+		case l.LVAsString(s.RawGetString("kind")) == render.SKY_NONE.String():
+			e.ClearSky()
+		case s.RawGetString("cube") != l.LNil:
+			ft, ok := s.RawGetString("cube").(*l.LTable)
+			if !ok {
+				return CubeFacesError(0)
+			}
+			var paths []string
+			for i := 1; i <= ft.Len(); i++ {
+				paths = append(paths, l.LVAsString(ft.RawGetInt(i)))
+			}
+			if e.sky.Kind() == render.SKY_CUBE && sameSource(e.source, paths) {
+				return nil
+			}
+			return e.LoadCube(paths...)
+		case s.RawGetString("equirect") != l.LNil:
+			path := l.LVAsString(s.RawGetString("equirect"))
+			if e.sky.Kind() == render.SKY_EQUIRECT && sameSource(e.source, []string{path}) {
+				return nil
+			}
+			return e.LoadEquirect(path)
+		case l.LVAsBool(s.RawGetString("gradient")), e.sky.Kind() == render.SKY_NONE:
+			e.SetGradient()
+		}
+	case l.LBool:
+		if !s {
+			e.ClearSky()
+		}
+	}
+	return nil
+}
+
+func sameSource(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// pushEnvironment pushes an environment as a table environmentFrom takes.
+func pushEnvironment(L *l.LState, e *Environment) {
+	t := L.NewTable()
+	pushColor(L, e.Ambient)
+	t.RawSetString("ambient", L.Get(-1))
+	L.Pop(1)
+
+	f := L.NewTable()
+	f.RawSetString("mode", l.LString(e.Fog.Mode.String()))
+	pushColor(L, e.Fog.Color)
+	f.RawSetString("color", L.Get(-1))
+	L.Pop(1)
+	f.RawSetString("density", l.LNumber(e.Fog.Density))
+	f.RawSetString("start", l.LNumber(e.Fog.Start))
+	f.RawSetString("end", l.LNumber(e.Fog.End))
+	f.RawSetString("base", l.LNumber(e.Fog.Base))
+	f.RawSetString("falloff", l.LNumber(e.Fog.Falloff))
+	t.RawSetString("fog", f)
+
+	s := L.NewTable()
+	s.RawSetString("kind", l.LString(e.sky.Kind().String()))
+	for k, c := range map[string][4]float32{
+		"top":     e.Colors.Top,
+		"horizon": e.Colors.Horizon,
+		"bottom":  e.Colors.Bottom,
+	} {
+		pushColor(L, c)
+		s.RawSetString(k, L.Get(-1))
+		L.Pop(1)
+	}
+	s.RawSetString("sharpness", l.LNumber(e.Colors.Sharpness))
+	s.RawSetString("exposure", l.LNumber(e.Colors.Exposure))
+	s.RawSetString("rotation", l.LNumber(e.Colors.Rotation))
+	switch e.sky.Kind() {
+	case render.SKY_GRADIENT:
+		s.RawSetString("gradient", l.LTrue)
+	case render.SKY_CUBE:
+		faces := L.CreateTable(len(e.source), 0)
+		for _, p := range e.source {
+			faces.Append(l.LString(p))
+		}
+		s.RawSetString("cube", faces)
+	case render.SKY_EQUIRECT:
+		if len(e.source) > 0 {
+			s.RawSetString("equirect", l.LString(e.source[0]))
+		}
+	}
+	t.RawSetString("sky", s)
+	L.Push(t)
+}
@@ -0,0 +1,257 @@
+package scene
+
+import (
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	glm "math"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// Heightfield is heights sampled on a grid of width by depth samples,
+// spacing apart on x and z and centered on the origin. Quads of the grid
+// are split from their -x-z corner to their +x+z corner, as terrain draws
+// them, which heights between samples follow.
+type Heightfield struct {
+	width, depth int
+	spacing      float32
+	heights      []float32
+}
+
+var HeightfieldSizeError = xrror.Xrror("a heightfield is at least 2x2 samples, not %dx%d").Out
+
+func NewHeightfield(width, depth int, spacing float32) (*Heightfield, error) {
+	if width < 2 || depth < 2 {
+		return nil, HeightfieldSizeError(width, depth)
+	}
+	if spacing <= 0 {
+		spacing = 1
+	}
+	return &Heightfield{width, depth, spacing, make([]float32, width*depth)}, nil
+}
+
+// DecodeHeightmap reads a grayscale image, 16 bits a sample or 8, as a
+// heightfield from 0 for black to scale for white.
+func DecodeHeightmap(r io.Reader, spacing, scale float32) (*Heightfield, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	h, err := NewHeightfield(b.Dx(), b.Dy(), spacing)
+	if err != nil {
+		return nil, err
+	}
+	for z := 0; z < h.depth; z++ {
+		for x := 0; x < h.width; x++ {
+			g := color.Gray16Model.Convert(img.At(b.Min.X+x, b.Min.Y+z)).(color.Gray16)
+			h.heights[z*h.width+x] = float32(g.Y) / 0xffff * scale
+		}
+	}
+	return h, nil
+}
+
+// LoadHeightmap decodes a heightmap file, as DecodeHeightmap.
+func LoadHeightmap(path string, spacing, scale float32) (*Heightfield, error) {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return DecodeHeightmap(f, spacing, scale)
+}
+
+// Size is the samples of the heightfield along x and z.
+func (h *Heightfield) Size() (int, int) {
+	return h.width, h.depth
+}
+
+func (h *Heightfield) Spacing() float32 {
+	return h.spacing
+}
+
+// Extent is how far the heightfield reaches along x and z.
+func (h *Heightfield) Extent() (float32, float32) {
+	return float32(h.width-1) * h.spacing, float32(h.depth-1) * h.spacing
+}
+
+// At is the height of a sample, the nearest on the edge for samples beyond
+// it.
+func (h *Heightfield) At(x, z int) float32 {
+	x = math.ClampInt(x, 0, h.width-1)
+	z = math.ClampInt(z, 0, h.depth-1)
+	return h.heights[z*h.width+x]
+}
+
+func (h *Heightfield) Set(x, z int, v float32) {
+	if x >= 0 && x < h.width && z >= 0 && z < h.depth {
+		h.heights[z*h.width+x] = v
+	}
+}
+
+// Position is where a sample is, centered on the origin.
+func (h *Heightfield) Position(x, z int) (float32, float32) {
+	w, d := h.Extent()
+	return float32(x)*h.spacing - w/2, float32(z)*h.spacing - d/2
+}
+
+// Generate sets every height from a function of where the sample is.
+func (h *Heightfield) Generate(fn func(x, z float32) float32) {
+	for z := 0; z < h.depth; z++ {
+		for x := 0; x < h.width; x++ {
+			px, pz := h.Position(x, z)
+			h.heights[z*h.width+x] = fn(px, pz)
+		}
+	}
+}
+
+// grid is where x and z fall on the grid, in samples.
+func (h *Heightfield) grid(x, z float32) (float32, float32) {
+	w, d := h.Extent()
+	return (x + w/2) / h.spacing, (z + d/2) / h.spacing
+}
+
+// Contains is whether x and z are over the heightfield.
+func (h *Heightfield) Contains(x, z float32) bool {
+	gx, gz := h.grid(x, z)
+	return gx >= 0 && gz >= 0 && gx <= float32(h.width-1) && gz <= float32(h.depth-1)
+}
+
+// Height is the height of the surface at x and z, on the triangle of the
+// quad they fall in, that of the nearest edge beyond the heightfield.
+func (h *Heightfield) Height(x, z float32) float32 {
+	gx, gz := h.grid(x, z)
+	gx = math.Clamp(gx, 0, float32(h.width-1))
+	gz = math.Clamp(gz, 0, float32(h.depth-1))
+	ix, iz := int(gx), int(gz)
+	if ix == h.width-1 {
+		ix--
+	}
+	if iz == h.depth-1 {
+		iz--
+	}
+	u, v := gx-float32(ix), gz-float32(iz)
+	h00, h11 := h.At(ix, iz), h.At(ix+1, iz+1)
+	if u >= v {
+		h10 := h.At(ix+1, iz)
+		return h00 + u*(h10-h00) + v*(h11-h10)
+	}
+	h01 := h.At(ix, iz+1)
+	return h00 + v*(h01-h00) + u*(h11-h01)
+}
+
+// SampleNormal is the normal at a sample, from the heights around it.
+func (h *Heightfield) SampleNormal(x, z int) (float32, float32, float32) {
+	dx := (h.At(x+1, z) - h.At(x-1, z)) / (2 * h.spacing)
+	dz := (h.At(x, z+1) - h.At(x, z-1)) / (2 * h.spacing)
+	l := float32(glm.Sqrt(float64(dx*dx + 1 + dz*dz)))
+	return -dx / l, 1 / l, -dz / l
+}
+
+// Normal is the normal of the surface at x and z, blended between those of
+// the samples around.
+func (h *Heightfield) Normal(x, z float32) math.Vector {
+	gx, gz := h.grid(x, z)
+	gx = math.Clamp(gx, 0, float32(h.width-1))
+	gz = math.Clamp(gz, 0, float32(h.depth-1))
+	ix, iz := int(gx), int(gz)
+	u, v := gx-float32(ix), gz-float32(iz)
+	var n [3]float32
+	for _, c := range []struct {
+		x, z int
+		w    float32
+	}{
+		{ix, iz, (1 - u) * (1 - v)},
+		{ix + 1, iz, u * (1 - v)},
+		{ix, iz + 1, (1 - u) * v},
+		{ix + 1, iz + 1, u * v},
+	} {
+		nx, ny, nz := h.SampleNormal(c.x, c.z)
+		n[0] += nx * c.w
+		n[1] += ny * c.w
+		n[2] += nz * c.w
+	}
+	return math.Vec3(n[0], n[1], n[2]).Normalize()
+}
+
+// Region is the samples from X0, Z0 to X1, Z1, inclusive, a brush changed.
+type Region struct {
+	X0, Z0, X1, Z1 int
+}
+
+func (r Region) Empty() bool {
+	return r.X1 < r.X0 || r.Z1 < r.Z0
+}
+
+func (r Region) Overlaps(o Region) bool {
+	return !r.Empty() && !o.Empty() &&
+		r.X0 <= o.X1 && o.X0 <= r.X1 && r.Z0 <= o.Z1 && o.Z0 <= r.Z1
+}
+
+// Grow is the region reaching n samples further every way.
+func (r Region) Grow(n int) Region {
+	return Region{r.X0 - n, r.Z0 - n, r.X1 + n, r.Z1 + n}
+}
+
+// brush calls fn with every sample within radius of x and z and how much
+// the brush weighs there, smoothly falling from 1 at the center to 0 at
+// the radius, returning the region of them.
+func (h *Heightfield) brush(x, z, radius float32, fn func(idx int, w float32)) Region {
+	gx, gz := h.grid(x, z)
+	gr := radius / h.spacing
+	r := Region{
+		math.ClampInt(int(glm.Floor(float64(gx-gr))), 0, h.width-1),
+		math.ClampInt(int(glm.Floor(float64(gz-gr))), 0, h.depth-1),
+		math.ClampInt(int(glm.Ceil(float64(gx+gr))), 0, h.width-1),
+		math.ClampInt(int(glm.Ceil(float64(gz+gr))), 0, h.depth-1),
+	}
+	if gr <= 0 {
+		return Region{0, 0, -1, -1}
+	}
+	for iz := r.Z0; iz <= r.Z1; iz++ {
+		for ix := r.X0; ix <= r.X1; ix++ {
+			dx, dz := float32(ix)-gx, float32(iz)-gz
+			d := float32(glm.Sqrt(float64(dx*dx+dz*dz))) / gr
+			if d >= 1 {
+				continue
+			}
+			t := 1 - d
+			fn(iz*h.width+ix, t*t*(3-2*t))
+		}
+	}
+	return r
+}
+
+// Raise lifts the surface within radius of x and z by amount at the center,
+// less toward the radius, lowering it for amounts below zero.
+func (h *Heightfield) Raise(x, z, radius, amount float32) Region {
+	return h.brush(x, z, radius, func(i int, w float32) {
+		h.heights[i] += amount * w
+	})
+}
+
+// Flatten brings the surface within radius of x and z toward height, all
+// the way at the center for strength 1.
+func (h *Heightfield) Flatten(x, z, radius, height, strength float32) Region {
+	strength = math.Clamp(strength, 0, 1)
+	return h.brush(x, z, radius, func(i int, w float32) {
+		h.heights[i] += (height - h.heights[i]) * w * strength
+	})
+}
+
+// Bounds are the lowest and highest samples of a region.
+func (h *Heightfield) Bounds(r Region) (float32, float32) {
+	lo, hi := float32(glm.Inf(1)), float32(glm.Inf(-1))
+	for z := math.ClampInt(r.Z0, 0, h.depth-1); z <= math.ClampInt(r.Z1, 0, h.depth-1); z++ {
+		for x := math.ClampInt(r.X0, 0, h.width-1); x <= math.ClampInt(r.X1, 0, h.width-1); x++ {
+			v := h.heights[z*h.width+x]
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	return lo, hi
+}
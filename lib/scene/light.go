@@ -1,7 +1,6 @@
 package scene
 
 import (
-	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
 	"github.com/Laughs-In-Flowers/shiva/lib/lua"
 	"github.com/Laughs-In-Flowers/shiva/lib/math"
 	"github.com/Laughs-In-Flowers/shiva/lib/render"
@@ -50,7 +49,8 @@ type light struct {
 	idx       int
 	intensity float32
 	color     math.Color
-	u         graphics.Uniform
+	member    string
+	value     []float32
 }
 
 func (t *light) Initialize(k LightKind) {
@@ -86,12 +86,15 @@ func (t *light) SetIntensity(v float32) {
 }
 
 func (t *light) postChange() {
-	t.color.MulScalar(t.intensity)
-	t.u.Update(t.color.Raw()...)
+	c := t.color.Clone()
+	c.MulScalar(t.intensity)
+	t.value = c.Raw()
 }
 
-func (t *light) Provide(p graphics.Provider) {
-	t.u.TransferIdx(p, t.idx)
+// Provide sets the light in the Lights block of a renderer, a light past
+// the most the block holds left out.
+func (t *light) Provide(r render.Renderer) {
+	r.Lights().Set(t.member, t.idx, t.value...)
 }
 
 const lLightNodeClass = "NLIGHT"
@@ -105,8 +108,7 @@ var lLightNodeTable = &lua.Table{
 const lAmbientLightNodeClass = "NLAMBIENT"
 
 func Ambient(tag string, intensity float32, color math.Color) *light {
-	u := graphics.Uniform3f("AmbientLightColor")
-	lg := &light{nil, 0, intensity, color, u}
+	lg := &light{nil, 0, intensity, color, "AmbientLight", nil}
 	n := newNode(tag, func(r render.Renderer, n Node) {
 		lg.Provide(r)
 	}, defaultRemovalFn, defaultReplaceFn, lAmbientLightNodeClass, lLightNodeClass, lNodeClass)
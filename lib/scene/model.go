@@ -0,0 +1,75 @@
+package scene
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/asset"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/geometry"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/render"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+type model struct {
+	*node
+	m render.Mesh
+}
+
+const lModelNodeClass = "NMODEL"
+
+// Model returns a node drawing geometry, loaded from a model asset, with a
+// material, basic when nil. Reloading the asset changes what it draws.
+func Model(tag string, g geometry.Geometry, mat material.Material) *model {
+	if mat == nil {
+		mat = material.Basic()
+	}
+
+	mm := render.NewMesh(
+		tag,
+		g,
+		func(r render.Renderer) {},
+		graphics.TRIANGLES,
+	)
+	mm.AddMaterial(mat, 0, 0)
+
+	return &model{
+		newNode(tag, func(r render.Renderer, n Node) {
+			for _, m := range mm.Materials() {
+				m.Render(r)
+			}
+		}, defaultRemovalFn, defaultReplaceFn, lModelNodeClass, lNodeClass),
+		mm,
+	}
+}
+
+func (m *model) Mesh() render.Mesh {
+	return m.m
+}
+
+var modelTag TagFunc = tagFnFor("model", 1)
+
+// shv.model(tag, shv.assets.load("models/crate.obj"), material) draws a
+// ready model asset, the material optional. The asset is drawn while it is
+// held.
+func lmodel(L *l.LState) int {
+	tag := modelTag(L)
+	a := asset.CheckAsset(L, 2)
+	g, ok := a.Value().(geometry.Geometry)
+	if !ok {
+		L.ArgError(2, "ready model asset expected")
+		return 0
+	}
+	var mat material.Material
+	if L.Get(3) != l.LNil {
+		mat = material.CheckMaterial(L, 3)
+	}
+	return pushNode(L, Model(tag, g, mat))
+}
+
+var lModelNodeTable = &lua.Table{
+	lModelNodeClass,
+	[]*lua.Table{nodeTable},
+	defaultIdxMetaFuncs(),
+	nil, nil,
+}
@@ -4,6 +4,7 @@ import (
 	"reflect"
 
 	"github.com/Laughs-In-Flowers/shiva/lib/ecs"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
 	"github.com/Laughs-In-Flowers/shiva/lib/lua"
 	"github.com/Laughs-In-Flowers/shiva/lib/render"
 	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
@@ -22,6 +23,7 @@ type Node interface {
 	Comparer
 	Counter
 	Terminater
+	Layerer
 	//Removal
 }
 
@@ -136,6 +138,11 @@ type Terminater interface {
 	SetTerminal(bool)
 }
 
+type Layerer interface {
+	Layers() uint32
+	SetLayers(uint32)
+}
+
 type Lighter interface {
 	Light() LightKind
 }
@@ -157,6 +164,7 @@ type node struct {
 	out       []Node
 	lightKind LightKind
 	terminal  bool
+	layers    uint32
 }
 
 func newNode(
@@ -177,6 +185,7 @@ func newNode(
 		rpfn:     rpfn,
 		in:       make([]Node, 0),
 		out:      make([]Node, 0),
+		layers:   render.DefaultLayer,
 	}
 }
 
@@ -196,9 +205,11 @@ func (n *node) LClass() string {
 }
 
 func (n *node) Render(r render.Renderer) {
-	n.rExecute(func() {
-		n.rfn(r, n)
-	})
+	if render.Visible(n.layers, r.Mask()) {
+		n.rExecute(func() {
+			n.rfn(r, n)
+		})
+	}
 	if !n.terminal {
 		for _, nd := range n.Out() {
 			nd.Render(r)
@@ -354,6 +365,14 @@ func (n *node) SetTerminal(as bool) {
 	n.terminal = as
 }
 
+func (n *node) Layers() uint32 {
+	return n.layers
+}
+
+func (n *node) SetLayers(l uint32) {
+	n.layers = l
+}
+
 func pushNode(L *l.LState, n Node) int {
 	fn := func(u *l.LUserData) {
 		u.Value = n
@@ -477,6 +496,39 @@ func setRecursionLimit(L *l.LState, u *l.LUserData, n Node) int {
 	return 0
 }
 
+func getLayers(L *l.LState, u *l.LUserData, n Node) int {
+	L.Push(l.LNumber(n.Layers()))
+	return 1
+}
+
+func setLayers(L *l.LState, u *l.LUserData, n Node) int {
+	layers := L.CheckInt64(3)
+	n.SetLayers(uint32(layers))
+	return 0
+}
+
+// node.material is the material of the mesh of a node, nil for a node
+// without one; set, it draws the whole mesh.
+func getMaterial(L *l.LState, u *l.LUserData, n Node) int {
+	if p, ok := n.(Pickable); ok {
+		if mm := p.Mesh().Materials(); len(mm) > 0 {
+			return material.PushMaterial(L, mm[0].Material())
+		}
+	}
+	L.Push(l.LNil)
+	return 1
+}
+
+func setMaterial(L *l.LState, u *l.LUserData, n Node) int {
+	p, ok := n.(Pickable)
+	if !ok {
+		L.RaiseError("%s has no mesh", n.Tag())
+		return 0
+	}
+	p.Mesh().SetMaterial(material.CheckMaterial(L, 3))
+	return 0
+}
+
 func nodeAdd(L *l.LState, afn func(RelationDir, ...Node) error, dir RelationDir) int {
 	var add []Node
 	ta := L.GetTop()
@@ -606,6 +658,8 @@ var nodeTable = &lua.Table{
 		"paused":          nodeProperty(getPaused, setPaused),
 		"tag":             nodeProperty(getTag, setTag),
 		"recursion_limit": nodeProperty(getRecursionLimit, setRecursionLimit),
+		"layers":          nodeProperty(getLayers, setLayers),
+		"material":        nodeProperty(getMaterial, setMaterial),
 	},
 	map[string]l.LGFunction{
 		"prepend": nodeMember(nodePrependOut),
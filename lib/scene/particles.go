@@ -0,0 +1,307 @@
+package scene
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/geometry"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/texture"
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/particle"
+	"github.com/Laughs-In-Flowers/shiva/lib/render"
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+type particleEmitter struct {
+	*node
+	*particle.Emitter
+	m         render.Mesh
+	mat       material.Material
+	instances *graphics.Buff
+	atlas     graphics.Uniform
+	tex       texture.Texture
+}
+
+const lParticleEmitterNodeClass = "NPARTICLES"
+
+// billboard is a unit quad centered on the origin, drawn once per particle.
+func billboard() geometry.Geometry {
+	g := geometry.New()
+	g.AddVBO(graphics.NewBuff().
+		AddAttrib("VertexPosition", 3).
+		AddAttrib("VertexTexcoord", 2).
+		SetBuffer(math.AF32{
+			-0.5, -0.5, 0, 0, 0,
+			0.5, -0.5, 0, 1, 0,
+			0.5, 0.5, 0, 1, 1,
+			-0.5, 0.5, 0, 0, 1,
+		}))
+	g.SetIndices(math.AU32{0, 1, 2, 0, 2, 3})
+	return g
+}
+
+// ParticleEmitter returns a node emitting particles from its world position,
+// simulated by the particle system and drawn as instanced billboards.
+func ParticleEmitter(tag string, c *particle.Config) *particleEmitter {
+	p := &particleEmitter{
+		Emitter: particle.NewEmitter(c),
+		atlas:   graphics.Uniform2f("ParticleAtlas"),
+	}
+
+	g := billboard()
+	p.instances = graphics.NewBuff().
+		AddAttrib("ParticlePosition", 4).
+		AddAttrib("ParticleColor", 4).
+		AddAttrib("ParticleFrame", 2).
+		SetDivisor(1)
+	p.instances.SetUsage(graphics.STREAM_DRAW)
+	g.AddVBO(p.instances)
+
+	p.m = render.NewMesh(tag, g, p.provide, graphics.TRIANGLES)
+	p.m.SetInstances(0)
+
+	p.mat = material.New()
+	p.mat.SetShader("particle")
+	p.mat.SetIndependent(true)
+	p.mat.SetUseLights(material.ULNone)
+	p.mat.SetSide(material.SIDouble)
+	p.mat.SetDepthMask(false)
+	p.SetAdditive(p.Additive)
+	p.m.AddMaterial(p.mat, 0, 0)
+
+	p.node = newNode(tag, func(r render.Renderer, n Node) {
+		p.SetWorld(r.Last())
+		if p.Live() == 0 {
+			return
+		}
+		p.instances.SetBuffer(p.Instances())
+		p.m.SetInstances(p.Live())
+		for _, m := range p.m.Materials() {
+			m.Render(r)
+		}
+	}, func(n *node) error {
+		particle.CurrentParticleSystem.Remove(p.Emitter.ID())
+		return defaultRemovalFn(n)
+	}, defaultReplaceFn, lParticleEmitterNodeClass, lNodeClass)
+
+	particle.CurrentParticleSystem.Add(p.Emitter)
+	return p
+}
+
+// provide sets the atlas of the emitter, the camera coming from the Frame
+// block of the renderer.
+func (p *particleEmitter) provide(r render.Renderer) {
+	p.atlas.Update(float32(p.Atlas.Cols), float32(p.Atlas.Rows))
+	p.atlas.Transfer(r)
+}
+
+// ID is the node id, the emitter keeping its own for the particle system.
+func (p *particleEmitter) ID() uint64 {
+	return p.node.ID()
+}
+
+// SetAdditive switches between additive and alpha blending.
+func (p *particleEmitter) SetAdditive(b bool) {
+	p.Additive = b
+	if b {
+		p.mat.SetBlending(material.BLAdditive)
+		return
+	}
+	p.mat.SetBlending(material.BLNormal)
+}
+
+// Material is the particle material, its first texture being sampled as the
+// configured atlas.
+func (p *particleEmitter) Material() material.Material {
+	return p.mat
+}
+
+// SetAtlasTexture draws the particles with the atlas image at a path, ""
+// for soft round points.
+func (p *particleEmitter) SetAtlasTexture(path string) error {
+	var tex texture.Texture
+	if path != "" {
+		var err error
+		if tex, err = atlasTexture(path); err != nil {
+			return err
+		}
+	}
+	p.setTexture(path, tex)
+	return nil
+}
+
+func (p *particleEmitter) setTexture(path string, tex texture.Texture) {
+	if p.tex != nil {
+		p.mat.RemoveTexture(p.tex)
+	}
+	p.tex = tex
+	if tex != nil {
+		p.mat.AddTexture(tex)
+	}
+	p.Atlas.Texture = path
+}
+
+func atlasTexture(path string) (texture.Texture, error) {
+	pix, w, h, err := decodeImage(path)
+	if err != nil {
+		return nil, err
+	}
+	tex := texture.NewData(graphics.RGBA)
+	tex.Set(w, h, pix)
+	return tex, nil
+}
+
+func decodeImage(path string) ([]byte, int, int, error) {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer f.Close()
+	return texture.DecodeRGBA(f)
+}
+
+func (p *particleEmitter) Mesh() render.Mesh {
+	return p.m
+}
+
+var particleEmitterTag TagFunc = tagFnFor("particles", 1)
+
+// shv.particles(tag, {rate = 20, shape = "sphere", ...})
+func lparticles(L *l.LState) int {
+	tag := particleEmitterTag(L)
+	c := particle.DefaultConfig()
+	var tex texture.Texture
+	if t, ok := L.Get(2).(*l.LTable); ok {
+		var err error
+		if c, err = particle.ConfigFrom(t); err != nil {
+			L.RaiseError("error building particles: %s", err)
+			return 0
+		}
+		if c.Atlas.Texture != "" {
+			if tex, err = atlasTexture(c.Atlas.Texture); err != nil {
+				L.RaiseError("error building particles: %s", err)
+				return 0
+			}
+		}
+	}
+	p := ParticleEmitter(tag, c)
+	if tex != nil {
+		p.setTexture(c.Atlas.Texture, tex)
+	}
+	return pushNode(L, p)
+}
+
+type particleEmitterMemberFunc func(*l.LState, *particleEmitter) int
+
+func checkParticleEmitter(L *l.LState, pos int) *particleEmitter {
+	ud := L.CheckUserData(pos)
+	if p, ok := ud.Value.(*particleEmitter); ok {
+		return p
+	}
+	L.ArgError(pos, "particle emitter expected")
+	return nil
+}
+
+func particleEmitterMember(fn particleEmitterMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if p := checkParticleEmitter(L, 1); p != nil {
+			return fn(L, p)
+		}
+		return 0
+	}
+}
+
+func particleEmitterProperty(get, set particleEmitterMemberFunc) l.LGFunction {
+	var lset l.LGFunction
+	if set != nil {
+		lset = particleEmitterMember(set)
+	}
+	return lua.NewProperty(particleEmitterMember(get), lset)
+}
+
+func particlesStart(L *l.LState, p *particleEmitter) int {
+	p.Start()
+	return 0
+}
+
+func particlesStop(L *l.LState, p *particleEmitter) int {
+	p.Stop()
+	return 0
+}
+
+func particlesBurst(L *l.LState, p *particleEmitter) int {
+	p.Burst(L.CheckInt(2))
+	return 0
+}
+
+func particlesClear(L *l.LState, p *particleEmitter) int {
+	p.Clear()
+	return 0
+}
+
+func getParticlesLive(L *l.LState, p *particleEmitter) int {
+	L.Push(l.LNumber(p.Live()))
+	return 1
+}
+
+func getParticlesEmitting(L *l.LState, p *particleEmitter) int {
+	L.Push(l.LBool(p.Emitting()))
+	return 1
+}
+
+func getParticlesRate(L *l.LState, p *particleEmitter) int {
+	L.Push(l.LNumber(p.Rate))
+	return 1
+}
+
+func setParticlesRate(L *l.LState, p *particleEmitter) int {
+	p.Rate = float32(L.CheckNumber(3))
+	return 0
+}
+
+func getParticlesBlend(L *l.LState, p *particleEmitter) int {
+	if p.Additive {
+		L.Push(l.LString("additive"))
+		return 1
+	}
+	L.Push(l.LString("alpha"))
+	return 1
+}
+
+func setParticlesBlend(L *l.LState, p *particleEmitter) int {
+	p.SetAdditive(L.CheckString(3) == "additive")
+	return 0
+}
+
+func getParticlesTexture(L *l.LState, p *particleEmitter) int {
+	L.Push(l.LString(p.Atlas.Texture))
+	return 1
+}
+
+func setParticlesTexture(L *l.LState, p *particleEmitter) int {
+	if err := p.SetAtlasTexture(L.OptString(3, "")); err != nil {
+		L.RaiseError("error loading particle atlas: %s", err)
+	}
+	return 0
+}
+
+var lParticleEmitterNodeTable = &lua.Table{
+	lParticleEmitterNodeClass,
+	[]*lua.Table{nodeTable},
+	defaultIdxMetaFuncs(),
+	map[string]l.LGFunction{
+		"live":     particleEmitterProperty(getParticlesLive, nil),
+		"emitting": particleEmitterProperty(getParticlesEmitting, nil),
+		"rate":     particleEmitterProperty(getParticlesRate, setParticlesRate),
+		"blend":    particleEmitterProperty(getParticlesBlend, setParticlesBlend),
+		"texture":  particleEmitterProperty(getParticlesTexture, setParticlesTexture),
+	},
+	map[string]l.LGFunction{
+		"start": particleEmitterMember(particlesStart),
+		"stop":  particleEmitterMember(particlesStop),
+		"burst": particleEmitterMember(particlesBurst),
+		"clear": particleEmitterMember(particlesClear),
+	},
+}
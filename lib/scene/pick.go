@@ -0,0 +1,375 @@
+package scene
+
+import (
+	"sort"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/render"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+// Pickable is a node with a mesh that can be hit by rays.
+type Pickable interface {
+	Node
+	Mesh() render.Mesh
+}
+
+type localMatrixer interface {
+	localMatrix() math.Matrice
+}
+
+// Hit is the intersection of a ray with a node mesh, in world space. UV is
+// nil when the mesh has no texture coordinates.
+type Hit struct {
+	Node     Node
+	Distance float32
+	Point    math.Vector
+	Normal   math.Vector
+	UV       math.Vector
+}
+
+type Hits []*Hit
+
+func (h Hits) Len() int           { return len(h) }
+func (h Hits) Less(i, j int) bool { return h[i].Distance < h[j].Distance }
+func (h Hits) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+type walkFunc func(Node, math.Matrice)
+
+// walk visits every node in the scene graph with its world matrix.
+func (s *Scene) walk(fn walkFunc) {
+	var inner func(Node, math.Matrice, int)
+	inner = func(n Node, parent math.Matrice, depth int) {
+		if depth > maxWalkDepth {
+			return
+		}
+		world := parent
+		if lm, ok := n.(localMatrixer); ok {
+			world = math.MultiplyMatrices(parent, lm.localMatrix())
+		}
+		fn(n, world)
+		if !n.Terminal() {
+			for _, o := range n.Out() {
+				inner(o, world, depth+1)
+			}
+		}
+	}
+	for _, n := range s.n.List() {
+		inner(n, math.IdentityMatrix(math.MAT4), 0)
+	}
+}
+
+const maxWalkDepth = 256
+
+// pixels converts window coordinates, as provided by cursor callbacks with
+// origin top left, to framebuffer pixels with origin bottom left.
+func (s *Scene) pixels(x, y float64) (float32, float32) {
+	fx, fy := float32(x), float32(y)
+	if nativeWindow != nil {
+		ww, wh := nativeWindow.GetSize()
+		if ww > 0 && wh > 0 {
+			fx = fx * float32(s.width) / float32(ww)
+			fy = fy * float32(s.height) / float32(wh)
+		}
+	}
+	return fx, float32(s.height) - fy
+}
+
+// ViewerAt returns the camera rendering to the window at window coordinates
+// x, y, the last in render order when viewports overlap. Without attached
+// cameras the first camera found in the scene graph is returned.
+func (s *Scene) ViewerAt(x, y float64) Viewer {
+	px, py := s.pixels(x, y)
+	vs := s.Cameras()
+	for i := len(vs) - 1; i >= 0; i-- {
+		v := vs[i]
+		if v.Hidden() || v.Target() != nil {
+			continue
+		}
+		vx, vy, vw, vh := v.Viewport().Pixels(s.width, s.height)
+		if px >= float32(vx) && px <= float32(vx+vw) && py >= float32(vy) && py <= float32(vy+vh) {
+			return v
+		}
+	}
+	if len(vs) > 0 {
+		return nil
+	}
+	var found Viewer
+	s.walk(func(n Node, _ math.Matrice) {
+		if v, ok := n.(Viewer); ok && found == nil {
+			found = v
+		}
+	})
+	return found
+}
+
+// Ray returns the world space ray through window coordinates x, y and the
+// camera it was cast from.
+func (s *Scene) Ray(x, y float64) (*math.Ray, Viewer) {
+	v := s.ViewerAt(x, y)
+	if v == nil {
+		return nil, nil
+	}
+	px, py := s.pixels(x, y)
+	vx, vy, vw, vh := v.Viewport().Pixels(s.width, s.height)
+	if vw == 0 || vh == 0 {
+		return nil, v
+	}
+	nx := 2*(px-float32(vx))/float32(vw) - 1
+	ny := 2*(py-float32(vy))/float32(vh) - 1
+	return ViewerRay(v, nx, ny), v
+}
+
+// ViewerRay returns the world space ray through normalized device
+// coordinates x, y of the provided camera.
+func ViewerRay(v render.Viewer, x, y float32) *math.Ray {
+	vp := math.MultiplyMatrices(v.ProjectionMatrix(), v.ViewMatrix())
+	inv := vp.Inverse()
+	if inv == nil {
+		return nil
+	}
+	return math.Unproject(x, y, inv)
+}
+
+// Cast returns every hit of the ray against visible meshes on the layers in
+// mask, nearest first.
+func (s *Scene) Cast(ray *math.Ray, mask uint32) Hits {
+	ret := make(Hits, 0)
+	if ray == nil {
+		return ret
+	}
+	s.walk(func(n Node, world math.Matrice) {
+		if p, ok := n.(Pickable); ok && !n.Hidden() && render.Visible(n.Layers(), mask) {
+			if h := castMesh(ray, p, world); h != nil {
+				ret = append(ret, h)
+			}
+		}
+	})
+	sort.Sort(ret)
+	return ret
+}
+
+// Pick casts a ray through window coordinates x, y from the camera under
+// them.
+func (s *Scene) Pick(x, y float64) Hits {
+	ray, v := s.Ray(x, y)
+	if v == nil {
+		return make(Hits, 0)
+	}
+	return s.Cast(ray, v.Mask())
+}
+
+func castMesh(ray *math.Ray, p Pickable, world math.Matrice) *Hit {
+	inv := world.Inverse()
+	if inv == nil {
+		return nil
+	}
+	lr := ray.Transform(inv)
+	g := p.Mesh().Geometry()
+
+	if center, radius := g.BoundingSphere(); radius > 0 {
+		if _, ok := lr.IntersectSphere(center, radius); !ok {
+			return nil
+		}
+	}
+	min, max := g.BoundingBox()
+	bt, ok := lr.IntersectBox(min, max)
+	if !ok {
+		return nil
+	}
+
+	pos, psz := g.Attribute("VertexPosition")
+	if p.Mesh().Mode() != graphics.TRIANGLES || psz < 3 {
+		// no triangles to test, the bounding box is as close as it gets
+		return newHit(p, ray, world, inv, lr.At(bt), nil, nil)
+	}
+
+	vertex := func(i uint32) math.Vector {
+		o := int(i) * psz
+		return math.Vec3(pos[o], pos[o+1], pos[o+2])
+	}
+	indices := g.Indices()
+	count := len(indices)
+	if count == 0 {
+		count = len(pos) / psz
+	}
+	index := func(i int) uint32 {
+		if len(indices) > 0 {
+			return indices[i]
+		}
+		return uint32(i)
+	}
+
+	var best float32 = math.Infinity
+	var tri [3]uint32
+	var bu, bv float32
+	hit := false
+	for i := 0; i+2 < count; i += 3 {
+		a, b, c := index(i), index(i+1), index(i+2)
+		if int(a)*psz+2 >= len(pos) || int(b)*psz+2 >= len(pos) || int(c)*psz+2 >= len(pos) {
+			continue
+		}
+		if t, u, v, ok := lr.IntersectTriangle(vertex(a), vertex(b), vertex(c), false); ok && t < best {
+			best, tri, bu, bv, hit = t, [3]uint32{a, b, c}, u, v, true
+		}
+	}
+	if !hit {
+		return nil
+	}
+
+	w := 1 - bu - bv
+	interpolate := func(name string) math.Vector {
+		vals, sz := g.Attribute(name)
+		if sz == 0 || int(tri[0]+1)*sz > len(vals) || int(tri[1]+1)*sz > len(vals) || int(tri[2]+1)*sz > len(vals) {
+			return nil
+		}
+		out := make([]float32, sz)
+		for c := 0; c < sz; c++ {
+			out[c] = w*vals[int(tri[0])*sz+c] + bu*vals[int(tri[1])*sz+c] + bv*vals[int(tri[2])*sz+c]
+		}
+		return math.VecUnp(out...)
+	}
+
+	normal := interpolate("VertexNormal")
+	if normal == nil {
+		a, b, c := vertex(tri[0]), vertex(tri[1]), vertex(tri[2])
+		normal = b.Sub(a).Cross(c.Sub(a))
+	}
+
+	return newHit(p, ray, world, inv, lr.At(best), normal, interpolate("VertexTexcoord"))
+}
+
+func newHit(n Node, ray *math.Ray, world, inv math.Matrice, local, normal, uv math.Vector) *Hit {
+	wp := world.MulVec(math.Vec4(local.Get(0), local.Get(1), local.Get(2), 1))
+	point := math.Vec3(wp.Get(0), wp.Get(1), wp.Get(2))
+	h := &Hit{
+		Node:     n,
+		Distance: point.Sub(ray.Origin).Len(),
+		Point:    point,
+		UV:       uv,
+	}
+	if normal != nil {
+		// normals transform by the inverse transpose of the world matrix
+		wn := inv.Transpose().MulVec(math.Vec4(normal.Get(0), normal.Get(1), normal.Get(2), 0))
+		h.Normal = math.Vec3(wn.Get(0), wn.Get(1), wn.Get(2)).Normalize()
+	}
+	return h
+}
+
+// PickID renders pickable meshes into an id buffer from the camera under
+// window coordinates x, y and returns the node drawn at that pixel, for
+// scenes too dense to ray cast.
+func (s *Scene) PickID(x, y float64) Node {
+	v := s.ViewerAt(x, y)
+	if v == nil || s.width == 0 || s.height == 0 {
+		return nil
+	}
+	r := s.Renderer
+	if s.ids == nil {
+		s.ids = render.NewIDBuffer(s.width, s.height)
+	}
+	s.ids.Resize(s.width, s.height)
+	if err := s.ids.Begin(r); err != nil {
+		return nil
+	}
+
+	vx, vy, vw, vh := v.Viewport().Pixels(s.width, s.height)
+	r.Viewport(vx, vy, vw, vh)
+	vp := math.MultiplyMatrices(v.ProjectionMatrix(), v.ViewMatrix())
+	nodes := make(map[uint32]Node)
+	var id uint32
+	s.walk(func(n Node, world math.Matrice) {
+		if p, ok := n.(Pickable); ok && !n.Hidden() && render.Visible(n.Layers(), v.Mask()) {
+			id++
+			nodes[id] = n
+			s.ids.Draw(r, id, p.Mesh(), math.MultiplyMatrices(vp, world))
+		}
+	})
+
+	px, py := s.pixels(x, y)
+	got := s.ids.Read(r, int(px), int(py))
+	s.ids.End(r)
+	return nodes[got]
+}
+
+func cursorOr(L *l.LState, from int) (float64, float64) {
+	if L.GetTop() >= from+1 {
+		return float64(L.CheckNumber(from)), float64(L.CheckNumber(from + 1))
+	}
+	if nativeWindow != nil {
+		return nativeWindow.GetCursorPos()
+	}
+	return 0, 0
+}
+
+func vecValue(L *l.LState, v math.Vector, k string) l.LValue {
+	if v == nil {
+		return l.LNil
+	}
+	ud := L.NewUserData()
+	ud.Value = v
+	L.SetMetatable(ud, L.GetTypeMetatable(k))
+	return ud
+}
+
+func pushHits(L *l.LState, hs Hits) int {
+	t := L.NewTable()
+	for _, h := range hs {
+		ht := L.NewTable()
+		pushNode(L, h.Node)
+		ht.RawSetString("node", L.Get(-1))
+		L.Pop(1)
+		ht.RawSetString("distance", l.LNumber(h.Distance))
+		ht.RawSetString("point", vecValue(L, h.Point, math.VEC3))
+		ht.RawSetString("normal", vecValue(L, h.Normal, math.VEC3))
+		ht.RawSetString("uv", vecValue(L, h.UV, math.VEC2))
+		t.Append(ht)
+	}
+	L.Push(t)
+	return 1
+}
+
+// scene:pick([x, y][, "gpu"]), cursor position when x, y are omitted
+func scenePick(L *l.LState, u *l.LUserData, s *Scene) int {
+	x, y := cursorOr(L, 2)
+	mode := "ray"
+	if top := L.GetTop(); top == 2 || top == 4 {
+		mode = L.CheckString(top)
+	}
+	switch mode {
+	case "gpu":
+		hs := make(Hits, 0)
+		if n := s.PickID(x, y); n != nil {
+			hs = append(hs, &Hit{Node: n})
+		}
+		return pushHits(L, hs)
+	case "ray":
+		return pushHits(L, s.Pick(x, y))
+	}
+	L.ArgError(L.GetTop(), "pick mode must be ray or gpu")
+	return 0
+}
+
+func sceneRay(L *l.LState, u *l.LUserData, s *Scene) int {
+	x, y := cursorOr(L, 2)
+	ray, _ := s.Ray(x, y)
+	if ray == nil {
+		return 0
+	}
+	L.Push(vecValue(L, ray.Origin, math.VEC3))
+	L.Push(vecValue(L, ray.Direction, math.VEC3))
+	return 2
+}
+
+// scene:cast(origin, direction[, mask])
+func sceneCast(L *l.LState, u *l.LUserData, s *Scene) int {
+	origin := math.UnpackToVec(L, 2, math.VEC3, true)
+	direction := math.UnpackToVec(L, 3, math.VEC3, true)
+	mask := uint32(render.AllLayers)
+	if L.GetTop() >= 4 {
+		mask = uint32(L.CheckInt64(4))
+	}
+	return pushHits(L, s.Cast(math.NewRay(origin, direction), mask))
+}
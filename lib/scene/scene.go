@@ -1,9 +1,13 @@
 package scene
 
 import (
+	"sort"
 	"sync"
 
+	"github.com/Laughs-In-Flowers/shiva/lib/audio"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
 	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
 	"github.com/Laughs-In-Flowers/shiva/lib/render"
 	"github.com/go-gl/glfw/v3.2/glfw"
 
@@ -72,10 +76,82 @@ func (n *nenderable) List() []Node {
 	return n.nodes
 }
 
+type viewers struct {
+	sync.RWMutex
+	has []Viewer
+}
+
+func newViewers() *viewers {
+	return &viewers{
+		sync.RWMutex{},
+		make([]Viewer, 0),
+	}
+}
+
+func (v *viewers) add(vs ...Viewer) {
+	v.Lock()
+	for _, nv := range vs {
+		if v.index(nv) < 0 {
+			nv.setAttached(true)
+			v.has = append(v.has, nv)
+		}
+	}
+	v.Unlock()
+}
+
+func (v *viewers) remove(vs ...Viewer) {
+	v.Lock()
+	for _, rv := range vs {
+		if idx := v.index(rv); idx >= 0 {
+			rv.setAttached(false)
+			v.has = v.has[:idx+copy(v.has[idx:], v.has[idx+1:])]
+		}
+	}
+	v.Unlock()
+}
+
+func (v *viewers) index(vv Viewer) int {
+	for idx, h := range v.has {
+		if h.Equal(vv) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// list returns the viewers sorted by order, lowest first, ties kept in the
+// order they were added.
+func (v *viewers) list() []Viewer {
+	v.RLock()
+	ret := make([]Viewer, len(v.has))
+	copy(ret, v.has)
+	v.RUnlock()
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Order() < ret[j].Order()
+	})
+	return ret
+}
+
 type Scene struct {
 	render.Renderer
-	n      *nenderable
-	update bool
+	n             *nenderable
+	v             *viewers
+	ids           *render.IDBuffer
+	width, height int
+	update        bool
+	overlays      []Overlay
+	env           *Environment
+}
+
+// Overlay draws over the scene once it is rendered, in window coordinates
+// from the top left, as a GUI.
+type Overlay interface {
+	Overlay(r render.Renderer, width, height int)
+}
+
+// Current is the scene last created, nil if there is none.
+func Current() *Scene {
+	return currentScene
 }
 
 var nativeWindow *glfw.Window
@@ -91,18 +167,154 @@ func Aspect(w *glfw.Window) float32 {
 
 func NewScene(r render.Renderer, nw *glfw.Window) *Scene {
 	s := &Scene{
-		r,
-		newNenderable(),
-		true,
+		Renderer: r,
+		n:        newNenderable(),
+		v:        newViewers(),
+		update:   true,
+		env:      NewEnvironment(),
+	}
+	if nw != nil {
+		s.width, s.height = nw.GetFramebufferSize()
 	}
 	nativeWindow = nw
 	currentScene = s
 	return s
 }
 
+// Render renders the scene once per attached camera, in camera order, or
+// once with whatever camera is found in the graph if no cameras are attached.
 func (s *Scene) Render() {
 	r := s.Renderer
-	r.Rend(s.n)
+	r.ResetStats()
+	s.env.Provide(r.Environment())
+	sky := s.env.Sky()
+	vs := s.v.list()
+	if len(vs) == 0 {
+		r.Rend(sky, s.n)
+		audio.CurrentAudioSystem.Listener().SetView(r.ViewMatrice().Raw())
+		return
+	}
+	s.placeViewers(vs)
+	listening := false
+	for _, v := range vs {
+		if v.Hidden() {
+			continue
+		}
+		p := &render.Pass{
+			Mask:     v.Mask(),
+			Viewport: v.Viewport(),
+			Target:   v.Target(),
+			View:     v,
+			Width:    s.width,
+			Height:   s.height,
+		}
+		if p.Target == nil && v.Kind() == PERSPECTIVE {
+			aspect := p.Viewport.Aspect(s.width, s.height)
+			if aspect != v.GetPlane(ASPECT) {
+				v.SetPlane(ASPECT, aspect)
+			}
+		}
+		r.RendPass(p, sky, s.n)
+		// the first camera rendering to screen hears for the scene
+		if !listening && p.Target == nil {
+			audio.CurrentAudioSystem.Listener().SetView(v.ViewMatrix().Raw())
+			listening = true
+		}
+	}
+}
+
+// placeViewers sets the world matrix of each viewer from where it is in the
+// graph before any pass is rendered from it, as the graph is only walked
+// during a pass; a viewer outside the graph is placed by its own transform.
+func (s *Scene) placeViewers(vs []Viewer) {
+	placed := make(map[Node]bool, len(vs))
+	for _, v := range vs {
+		placed[v] = false
+	}
+	s.walk(func(n Node, world math.Matrice) {
+		if v, ok := n.(Viewer); ok {
+			if done, has := placed[n]; has && !done {
+				v.setMatrixWorld(world)
+				placed[n] = true
+			}
+		}
+	})
+	for _, v := range vs {
+		if !placed[v] {
+			v.setMatrixWorld(math.MultiplyMatrices(math.IdentityMatrix(math.MAT4), v.localMatrix()))
+		}
+	}
+}
+
+// Environment is the ambient light, fog and sky around the scene.
+func (s *Scene) Environment() *Environment {
+	return s.env
+}
+
+// SetEnvironment replaces the environment of the scene, closing the one it
+// had.
+func (s *Scene) SetEnvironment(e *Environment) {
+	if s.env != nil && s.env != e {
+		s.env.Close()
+	}
+	s.env = e
+}
+
+// AddOverlay adds overlays drawn, in the order added, after the scene.
+func (s *Scene) AddOverlay(ovs ...Overlay) {
+	s.overlays = append(s.overlays, ovs...)
+}
+
+// Overlay draws the overlays over the whole framebuffer.
+func (s *Scene) Overlay() {
+	if len(s.overlays) == 0 {
+		return
+	}
+	r := s.Renderer
+	r.BindFramebuffer(graphics.FRAMEBUFFER, 0)
+	r.Viewport(0, 0, int32(s.width), int32(s.height))
+	w, h := s.WindowSize()
+	for _, o := range s.overlays {
+		o.Overlay(r, w, h)
+	}
+}
+
+// WindowSize is the size of the window in screen coordinates, which may
+// differ from the framebuffer's pixels.
+func (s *Scene) WindowSize() (int, int) {
+	if nativeWindow != nil {
+		return nativeWindow.GetSize()
+	}
+	return s.width, s.height
+}
+
+// Nodes are the nodes attached to the scene.
+func (s *Scene) Nodes() []Node {
+	return s.n.List()
+}
+
+// Resize sets the size in pixels of the framebuffer the scene renders to.
+func (s *Scene) Resize(width, height int) {
+	s.width = width
+	s.height = height
+}
+
+func (s *Scene) Size() (int, int) {
+	return s.width, s.height
+}
+
+// AddCamera attaches cameras to the scene, each rendering a pass every frame.
+func (s *Scene) AddCamera(vs ...Viewer) {
+	s.v.add(vs...)
+}
+
+func (s *Scene) RemoveCamera(vs ...Viewer) {
+	s.v.remove(vs...)
+}
+
+// Cameras returns the attached cameras in render order.
+func (s *Scene) Cameras() []Viewer {
+	return s.v.list()
 }
 
 func (s *Scene) Attach(ns ...Node) {
@@ -188,6 +400,58 @@ func detachNode(L *l.LState, u *l.LUserData, s *Scene) int {
 	return 0
 }
 
+func pullViewer(L *l.LState, pos int) Viewer {
+	if n := pullNode(L, pos); n != nil {
+		if v, ok := n.(Viewer); ok {
+			return v
+		}
+	}
+	L.ArgError(pos, "camera expected")
+	return nil
+}
+
+func addCamera(L *l.LState, u *l.LUserData, s *Scene) int {
+	if v := pullViewer(L, 2); v != nil {
+		if L.GetTop() > 2 {
+			v.SetOrder(L.CheckInt(3))
+		}
+		s.AddCamera(v)
+	}
+	return 0
+}
+
+func removeCamera(L *l.LState, u *l.LUserData, s *Scene) int {
+	if v := pullViewer(L, 2); v != nil {
+		s.RemoveCamera(v)
+	}
+	return 0
+}
+
+func getCameras(L *l.LState, u *l.LUserData, s *Scene) int {
+	t := L.NewTable()
+	for _, v := range s.Cameras() {
+		pushNode(L, v)
+		t.Append(L.Get(-1))
+		L.Pop(1)
+	}
+	L.Push(t)
+	return 1
+}
+
+func getEnvironment(L *l.LState, u *l.LUserData, s *Scene) int {
+	pushEnvironment(L, s.Environment())
+	return 1
+}
+
+// scene.environment = {ambient = ..., fog = ..., sky = ...} sets what of
+// the environment the table has.
+func setEnvironment(L *l.LState, u *l.LUserData, s *Scene) int {
+	if err := environmentFrom(L, s.Environment(), L.CheckTable(3)); err != nil {
+		L.RaiseError("%s", err)
+	}
+	return 0
+}
+
 func clearScene(L *l.LState, u *l.LUserData, s *Scene) int {
 	s.Clear()
 	return 0
@@ -201,12 +465,19 @@ var sceneTable = &lua.Table{
 		lua.DefaultIdx("__newindex"),
 	},
 	map[string]l.LGFunction{
-		"count": sceneProperty(getNodeCount, nil),
+		"count":       sceneProperty(getNodeCount, nil),
+		"cameras":     sceneProperty(getCameras, nil),
+		"environment": sceneProperty(getEnvironment, setEnvironment),
 	},
 	map[string]l.LGFunction{
-		"attach": sceneMember(attachNode),
-		"detach": sceneMember(detachNode),
-		"clear":  sceneMember(clearScene),
+		"attach":        sceneMember(attachNode),
+		"detach":        sceneMember(detachNode),
+		"clear":         sceneMember(clearScene),
+		"add_camera":    sceneMember(addCamera),
+		"remove_camera": sceneMember(removeCamera),
+		"pick":          sceneMember(scenePick),
+		"ray":           sceneMember(sceneRay),
+		"cast":          sceneMember(sceneCast),
 	},
 }
 
@@ -267,6 +538,12 @@ func RegisterWith() lua.RegisterWith {
 		sr.add(registerWith("axis", laxis, lAxisNodeTable))
 		sr.add(registerWith("sphere", lsphere, lSphereNodeTable))
 		sr.add(registerWith("camera", lcamera, lCameraNodeTable))
+		sr.add(registerWith("skinned", lskinned, lSkinnedNodeTable))
+		sr.add(registerWith("model", lmodel, lModelNodeTable))
+		sr.add(registerWith("particles", lparticles, lParticleEmitterNodeTable))
+		sr.add(registerWith("speaker", lspeaker, lSpeakerNodeTable))
+		sr.add(registerWith("text", ltext, lTextNodeTable))
+		sr.add(registerWith("terrain", lterrain, lTerrainNodeTable))
 		// default orthographic camera
 		// default perspective camera
 		return sr.run(m)
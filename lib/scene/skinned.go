@@ -0,0 +1,164 @@
+package scene
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/animation"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/geometry"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/shader"
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/render"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+type skinned struct {
+	*node
+	m      render.Mesh
+	mixer  *animation.Mixer
+	joints graphics.Uniform
+}
+
+const lSkinnedNodeClass = "NSKINNED"
+
+// Skinned returns a node drawing skinned geometry, deformed on the gpu by the
+// joint matrices of the mixer. The geometry needs VertexJoints and
+// VertexWeights attributes, see geometry.Skin.
+func Skinned(tag string, g geometry.Geometry, mat material.Material, mx *animation.Mixer) *skinned {
+	s := &skinned{
+		mixer:  mx,
+		joints: graphics.UniformMatrix4fvArray("JointMatrix", shader.MaxJoints),
+	}
+
+	if mat == nil {
+		mat = material.Skinned()
+	}
+
+	s.m = render.NewMesh(
+		tag,
+		g,
+		func(r render.Renderer) {
+			ms := s.mixer.Matrices()
+			if max := 16 * shader.MaxJoints; len(ms) > max {
+				ms = ms[:max]
+			}
+			s.joints.Update(ms...)
+			s.joints.Transfer(r)
+		},
+		graphics.TRIANGLES,
+	)
+	s.m.AddMaterial(mat, 0, 0)
+
+	s.node = newNode(tag, func(r render.Renderer, n Node) {
+		for _, m := range s.m.Materials() {
+			m.Render(r)
+		}
+	}, defaultRemovalFn, defaultReplaceFn, lSkinnedNodeClass, lNodeClass)
+
+	return s
+}
+
+func (s *skinned) Mesh() render.Mesh {
+	return s.m
+}
+
+func (s *skinned) Mixer() *animation.Mixer {
+	return s.mixer
+}
+
+var (
+	SkinSizeError  = xrror.Xrror("a skin of %d joint indices and %d weights does not fit %d vertices, 4 each").Out
+	SkinJointError = xrror.Xrror("skin joint %v is not one of the %d skeleton joints").Out
+)
+
+// Skin adds joints and weights, 4 per vertex, to the geometry with
+// geometry.Skin, checking they fit its vertices and a skeleton of count
+// joints.
+func Skin(g geometry.Geometry, joints, weights []float32, count int) error {
+	pos, size := g.Attribute("VertexPosition")
+	var vertices int
+	if size > 0 {
+		vertices = len(pos) / size
+	}
+	if len(joints) != vertices*4 || len(weights) != vertices*4 {
+		return SkinSizeError(len(joints), len(weights), vertices)
+	}
+	for _, j := range joints {
+		if j < 0 || int(j) >= count {
+			return SkinJointError(j, count)
+		}
+	}
+	geometry.Skin(g, math.AF32(joints), math.AF32(weights))
+	return nil
+}
+
+var skinnedTag TagFunc = tagFnFor("skinned", 1)
+
+func skinNumbers(t *l.LTable, key string) []float32 {
+	ret := make([]float32, 0)
+	if nt, ok := t.RawGetString(key).(*l.LTable); ok {
+		for i := 1; i <= nt.Len(); i++ {
+			ret = append(ret, float32(l.LVAsNumber(nt.RawGetInt(i))))
+		}
+	}
+	return ret
+}
+
+// shv.skinned(tag, node, {joints = {0, 1, 0, 0, ...}, weights = {0.75, 0.25, 0, 0, ...}}, mixer, material)
+// skins the geometry of a node with a mesh and draws it deformed by the
+// mixer, the skin nil for geometry already skinned and the material optional.
+func lskinned(L *l.LState) int {
+	tag := skinnedTag(L)
+	ms, ok := L.CheckUserData(2).Value.(interface{ Mesh() render.Mesh })
+	if !ok {
+		L.ArgError(2, "node with a mesh expected")
+		return 0
+	}
+	mx := animation.CheckMixer(L, 4)
+	g := ms.Mesh().Geometry()
+	switch st := L.Get(3).(type) {
+	case *l.LTable:
+		if err := Skin(g, skinNumbers(st, "joints"), skinNumbers(st, "weights"), mx.Skeleton().Len()); err != nil {
+			L.RaiseError("error building skinned node: %s", err)
+			return 0
+		}
+	default:
+		if _, size := g.Attribute("VertexJoints"); size == 0 {
+			L.ArgError(3, "skin table expected")
+			return 0
+		}
+	}
+	var mat material.Material
+	if L.Get(5) != l.LNil {
+		mat = material.CheckMaterial(L, 5)
+	}
+	return pushNode(L, Skinned(tag, g, mat, mx))
+}
+
+func checkSkinned(L *l.LState, pos int) *skinned {
+	ud := L.CheckUserData(pos)
+	if s, ok := ud.Value.(*skinned); ok {
+		return s
+	}
+	L.ArgError(pos, "skinned node expected")
+	return nil
+}
+
+func getSkinnedMixer(L *l.LState) int {
+	if s := checkSkinned(L, 1); s != nil {
+		return animation.PushMixer(L, s.mixer)
+	}
+	return 0
+}
+
+var lSkinnedNodeTable = &lua.Table{
+	lSkinnedNodeClass,
+	[]*lua.Table{nodeTable},
+	defaultIdxMetaFuncs(),
+	map[string]l.LGFunction{
+		"mixer": lua.NewProperty(getSkinnedMixer, nil),
+	},
+	nil,
+}
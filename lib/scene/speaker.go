@@ -0,0 +1,192 @@
+package scene
+
+import (
+	glm "math"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/audio"
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/render"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+type speaker struct {
+	*node
+	v *audio.Voice
+	s *audio.Spatial
+}
+
+const lSpeakerNodeClass = "NSPEAKER"
+
+// Speaker returns a node playing a voice from its world position, facing down
+// its local -Z axis for sound cones.
+func Speaker(tag string, v *audio.Voice) *speaker {
+	sp := &speaker{v: v, s: audio.NewSpatial()}
+	v.SetSpatial(sp.s)
+	sp.node = newNode(tag, func(r render.Renderer, n Node) {
+		w := r.Last().Raw()
+		sp.s.SetPosition([3]float32{w[12], w[13], w[14]})
+		sp.s.Direction = [3]float32{-w[8], -w[9], -w[10]}
+	}, func(n *node) error {
+		sp.v.Stop()
+		return defaultRemovalFn(n)
+	}, defaultReplaceFn, lSpeakerNodeClass, lNodeClass)
+	// placed whatever the camera renders
+	sp.node.layers = render.AllLayers
+	return sp
+}
+
+func (sp *speaker) Voice() *audio.Voice {
+	return sp.v
+}
+
+func (sp *speaker) Spatial() *audio.Spatial {
+	return sp.s
+}
+
+var speakerTag TagFunc = tagFnFor("speaker", 1)
+
+// shv.speaker(tag, sound | voice, {rolloff = "linear", max = 20, loop = true, ...})
+func lspeaker(L *l.LState) int {
+	tag := speakerTag(L)
+	var v *audio.Voice
+	ud := L.CheckUserData(2)
+	switch src := ud.Value.(type) {
+	case *audio.Buffer:
+		v = audio.CurrentAudioSystem.Voice(src.Source())
+	case *audio.Voice:
+		v = src
+	default:
+		L.ArgError(2, "sound or voice expected")
+		return 0
+	}
+	sp := Speaker(tag, v)
+	if t, ok := L.Get(3).(*l.LTable); ok {
+		if err := audio.SpatialFrom(sp.s, t); err != nil {
+			L.RaiseError("error building speaker: %s", err)
+			return 0
+		}
+		audio.VoiceOptions(L, v, t)
+	}
+	return pushNode(L, sp)
+}
+
+type speakerMemberFunc func(*l.LState, *speaker) int
+
+func checkSpeaker(L *l.LState, pos int) *speaker {
+	ud := L.CheckUserData(pos)
+	if sp, ok := ud.Value.(*speaker); ok {
+		return sp
+	}
+	L.ArgError(pos, "speaker expected")
+	return nil
+}
+
+func speakerMember(fn speakerMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if sp := checkSpeaker(L, 1); sp != nil {
+			return fn(L, sp)
+		}
+		return 0
+	}
+}
+
+func speakerProperty(get, set speakerMemberFunc) l.LGFunction {
+	var lset l.LGFunction
+	if set != nil {
+		lset = speakerMember(set)
+	}
+	return lua.NewProperty(speakerMember(get), lset)
+}
+
+// speakerNumber is a number property of the speaker spatial settings.
+func speakerNumber(field func(*audio.Spatial) *float32) l.LGFunction {
+	return speakerProperty(
+		func(L *l.LState, sp *speaker) int {
+			L.Push(l.LNumber(*field(sp.s)))
+			return 1
+		},
+		func(L *l.LState, sp *speaker) int {
+			*field(sp.s) = float32(L.CheckNumber(3))
+			return 0
+		},
+	)
+}
+
+func speakerPlay(L *l.LState, sp *speaker) int {
+	sp.v.Play()
+	return 0
+}
+
+func speakerPause(L *l.LState, sp *speaker) int {
+	sp.v.Pause()
+	return 0
+}
+
+func speakerStop(L *l.LState, sp *speaker) int {
+	sp.v.Stop()
+	return 0
+}
+
+func getSpeakerVoice(L *l.LState, sp *speaker) int {
+	return audio.PushVoice(L, sp.v)
+}
+
+func getSpeakerRolloff(L *l.LState, sp *speaker) int {
+	L.Push(l.LString(sp.s.Rolloff.String()))
+	return 1
+}
+
+func setSpeakerRolloff(L *l.LState, sp *speaker) int {
+	r := audio.StringToRolloffT(L.CheckString(3))
+	if r == audio.UNKNOWN_ROLLOFF {
+		L.ArgError(3, "linear, inverse or exponential expected")
+		return 0
+	}
+	sp.s.Rolloff = r
+	return 0
+}
+
+func degrees(r float32) l.LNumber {
+	return l.LNumber(r * 180 / glm.Pi)
+}
+
+func getSpeakerCone(L *l.LState, sp *speaker) int {
+	t := L.NewTable()
+	t.RawSetString("inner", degrees(sp.s.ConeInner))
+	t.RawSetString("outer", degrees(sp.s.ConeOuter))
+	t.RawSetString("gain", l.LNumber(sp.s.ConeOuterGain))
+	L.Push(t)
+	return 1
+}
+
+func setSpeakerCone(L *l.LState, sp *speaker) int {
+	t := L.NewTable()
+	t.RawSetString("cone", L.CheckTable(3))
+	if err := audio.SpatialFrom(sp.s, t); err != nil {
+		L.RaiseError(err.Error())
+	}
+	return 0
+}
+
+var lSpeakerNodeTable = &lua.Table{
+	lSpeakerNodeClass,
+	[]*lua.Table{nodeTable},
+	defaultIdxMetaFuncs(),
+	map[string]l.LGFunction{
+		"voice":   speakerProperty(getSpeakerVoice, nil),
+		"rolloff": speakerProperty(getSpeakerRolloff, setSpeakerRolloff),
+		"cone":    speakerProperty(getSpeakerCone, setSpeakerCone),
+		"ref":     speakerNumber(func(s *audio.Spatial) *float32 { return &s.RefDistance }),
+		"max":     speakerNumber(func(s *audio.Spatial) *float32 { return &s.MaxDistance }),
+		"factor":  speakerNumber(func(s *audio.Spatial) *float32 { return &s.RolloffFactor }),
+		"doppler": speakerNumber(func(s *audio.Spatial) *float32 { return &s.Doppler }),
+		"reverb":  speakerNumber(func(s *audio.Spatial) *float32 { return &s.Reverb }),
+		"lowpass": speakerNumber(func(s *audio.Spatial) *float32 { return &s.LowPass }),
+	},
+	map[string]l.LGFunction{
+		"play":  speakerMember(speakerPlay),
+		"pause": speakerMember(speakerPause),
+		"stop":  speakerMember(speakerStop),
+	},
+}
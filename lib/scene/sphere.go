@@ -47,6 +47,10 @@ func Sphere(tag string) *sphere {
 	}
 }
 
+func (s *sphere) Mesh() render.Mesh {
+	return s.m
+}
+
 func lsphere(L *l.LState) int {
 	s := Sphere("test-sphere")
 	return pushNode(L, s)
@@ -0,0 +1,720 @@
+package scene
+
+import (
+	"sort"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/geometry"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/texture"
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/render"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+// TerrainLayersMax is how many layers a splat map blends, one a channel.
+const TerrainLayersMax = 4
+
+// chunkKey is a node of the quadtree of a terrain, level 0 the root
+// covering all of it, x and z counting nodes of the level.
+type chunkKey struct {
+	level, x, z int
+}
+
+type terrainChunk struct {
+	mesh  render.Mesh
+	geo   geometry.Geometry
+	vbo   *graphics.Buff
+	mask  int
+	stale bool
+	used  int
+}
+
+// Terrain draws a heightfield as chunks of a quadtree, each a grid of the
+// same quads spaced wider the nearer the root, split the nearer the eye.
+// Chunks next to a coarser one leave out every other vertex of that edge,
+// meeting it without cracks, the tree kept so neighbours differ a level at
+// most. Layers of texture are blended by the channels of a splat map.
+type Terrain struct {
+	*node
+	field    *Heightfield
+	quads    int
+	levels   int
+	lod      float32
+	mat      material.Material
+	splat    *texture.Data
+	splatPix []byte
+	layers   [TerrainLayersMax]*texture.Data
+	tiling   [TerrainLayersMax]float32
+	chunks   map[chunkKey]*terrainChunk
+	bounds   map[chunkKey][2]float32
+	indices  [16]math.AU32
+	drawn    int
+	frame    int
+	mv, nm   graphics.Uniform
+	mvp      graphics.Uniform
+	uSplat   graphics.Uniform
+	uLayers  [TerrainLayersMax]graphics.Uniform
+	uTiling  graphics.Uniform
+	uCount   graphics.Uniform
+}
+
+const lTerrainNodeClass = "NTERRAIN"
+
+var (
+	TerrainChunkError = xrror.Xrror("terrain chunks of %d quads are not a power of two of at least 2").Out
+	TerrainLayerError = xrror.Xrror("%d is not a terrain layer, 0 to %d").Out
+)
+
+// terrainEvictAfter is how many frames a chunk not drawn is kept before its
+// geometry is freed.
+const terrainEvictAfter = 300
+
+// NewTerrain is a terrain of a heightfield drawn in chunks of quads by
+// quads, a power of two.
+func NewTerrain(tag string, h *Heightfield, quads int) (*Terrain, error) {
+	if quads < 2 || quads&(quads-1) != 0 {
+		return nil, TerrainChunkError(quads)
+	}
+	t := &Terrain{
+		field:   h,
+		quads:   quads,
+		lod:     2,
+		chunks:  make(map[chunkKey]*terrainChunk),
+		bounds:  make(map[chunkKey][2]float32),
+		mv:      graphics.UniformMatrix4fv("ModelViewMatrix"),
+		nm:      graphics.UniformMatrix3fv("NormalMatrix"),
+		mvp:     graphics.UniformMatrix4fv("MVP"),
+		uSplat:  graphics.Uniform1i("SplatMap"),
+		uTiling: graphics.Uniform4f("TerrainTiling"),
+		uCount:  graphics.Uniform1i("TerrainLayers"),
+	}
+	w, d := h.Size()
+	side := w - 1
+	if d > w {
+		side = d - 1
+	}
+	for quads<<uint(t.levels) < side {
+		t.levels++
+	}
+	for i := range t.uLayers {
+		t.uLayers[i] = graphics.Uniform1i("TerrainLayer")
+		t.uLayers[i].Update(float32(i + 1))
+		t.tiling[i] = 1
+	}
+	t.uSplat.Update(0)
+
+	t.splat = texture.NewData(graphics.RGBA)
+	t.splatPix = make([]byte, w*d*4)
+	for i := 0; i < w*d; i++ {
+		t.splatPix[i*4] = 255
+	}
+	t.splat.Set(w, d, t.splatPix)
+
+	t.mat = material.New()
+	t.mat.SetShader("terrain")
+	t.mat.AddTexture(t.splat)
+
+	t.node = newNode(tag, t.render, func(n *node) error {
+		t.Close()
+		return defaultRemovalFn(n)
+	}, defaultReplaceFn, lTerrainNodeClass, lNodeClass)
+	return t, nil
+}
+
+func (t *Terrain) Heightfield() *Heightfield {
+	return t.field
+}
+
+func (t *Terrain) Material() material.Material {
+	return t.mat
+}
+
+// LOD is how many times its own size away a chunk is split.
+func (t *Terrain) LOD() float32 {
+	return t.lod
+}
+
+func (t *Terrain) SetLOD(f float32) {
+	if f > 0 {
+		t.lod = f
+	}
+}
+
+// Drawn is how many chunks were drawn last.
+func (t *Terrain) Drawn() int {
+	return t.drawn
+}
+
+// Height is the height of the terrain at x and z, in its own coordinates.
+func (t *Terrain) Height(x, z float32) float32 {
+	return t.field.Height(x, z)
+}
+
+// Normal is the normal of the terrain at x and z, in its own coordinates.
+func (t *Terrain) Normal(x, z float32) math.Vector {
+	return t.field.Normal(x, z)
+}
+
+// Raise lifts the terrain within radius of x and z, as Heightfield.Raise.
+func (t *Terrain) Raise(x, z, radius, amount float32) {
+	t.invalidate(t.field.Raise(x, z, radius, amount))
+}
+
+// Flatten brings the terrain within radius of x and z toward a height, as
+// Heightfield.Flatten.
+func (t *Terrain) Flatten(x, z, radius, height, strength float32) {
+	t.invalidate(t.field.Flatten(x, z, radius, height, strength))
+}
+
+// Paint weighs a layer of the splat map more within radius of x and z, all
+// of it at the center for strength 1, the other layers less.
+func (t *Terrain) Paint(x, z, radius float32, layer int, strength float32) error {
+	if layer < 0 || layer >= TerrainLayersMax {
+		return TerrainLayerError(layer, TerrainLayersMax-1)
+	}
+	strength = math.Clamp(strength, 0, 1)
+	r := t.field.brush(x, z, radius, func(i int, w float32) {
+		a := w * strength
+		px := t.splatPix[i*4 : i*4+4]
+		for c := range px {
+			v := float32(px[c])
+			if c == layer {
+				v += (255 - v) * a
+			} else {
+				v -= v * a
+			}
+			px[c] = uint8(math.Clamp(v+0.5, 0, 255))
+		}
+	})
+	if !r.Empty() {
+		w, d := t.field.Size()
+		t.splat.Set(w, d, t.splatPix)
+	}
+	return nil
+}
+
+// SetLayer sets a layer of texture, repeating tiling times across the
+// terrain. Layers are blended from the first up to the first not set.
+func (t *Terrain) SetLayer(i int, tex *texture.Data, tiling float32) error {
+	if i < 0 || i >= TerrainLayersMax {
+		return TerrainLayerError(i, TerrainLayersMax-1)
+	}
+	if tex != nil {
+		tex.SetWrap(graphics.REPEAT)
+	}
+	for _, l := range t.layers {
+		if l != nil {
+			t.mat.RemoveTexture(l)
+		}
+	}
+	t.layers[i] = tex
+	if tiling > 0 {
+		t.tiling[i] = tiling
+	}
+	for _, l := range t.layers {
+		if l == nil {
+			break
+		}
+		t.mat.AddTexture(l)
+	}
+	return nil
+}
+
+func (t *Terrain) layerCount() int {
+	n := 0
+	for n < TerrainLayersMax && t.layers[n] != nil {
+		n++
+	}
+	return n
+}
+
+// size is how many samples a chunk of a level spans.
+func (t *Terrain) size(level int) int {
+	return t.quads << uint(t.levels-level)
+}
+
+func (t *Terrain) region(k chunkKey) Region {
+	s := t.size(k.level)
+	return Region{k.x * s, k.z * s, (k.x + 1) * s, (k.z + 1) * s}
+}
+
+// inside is whether a chunk covers any of the heightfield.
+func (t *Terrain) inside(k chunkKey) bool {
+	w, d := t.field.Size()
+	s := t.size(k.level)
+	return k.x >= 0 && k.z >= 0 && k.x*s < w-1 && k.z*s < d-1
+}
+
+func (t *Terrain) heights(k chunkKey) (float32, float32) {
+	if b, ok := t.bounds[k]; ok {
+		return b[0], b[1]
+	}
+	lo, hi := t.field.Bounds(t.region(k))
+	t.bounds[k] = [2]float32{lo, hi}
+	return lo, hi
+}
+
+// near is whether the eye is close enough to a chunk to split it.
+func (t *Terrain) near(k chunkKey, eye [3]float32) bool {
+	r := t.region(k)
+	x0, z0 := t.field.Position(r.X0, r.Z0)
+	x1, z1 := t.field.Position(r.X1, r.Z1)
+	lo, hi := t.heights(k)
+	dx := eye[0] - math.Clamp(eye[0], x0, x1)
+	dy := eye[1] - math.Clamp(eye[1], lo, hi)
+	dz := eye[2] - math.Clamp(eye[2], z0, z1)
+	reach := t.lod * float32(t.size(k.level)) * t.field.spacing
+	return dx*dx+dy*dy+dz*dz < reach*reach
+}
+
+func (t *Terrain) children(k chunkKey) []chunkKey {
+	ret := make([]chunkKey, 0, 4)
+	for _, c := range [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		ck := chunkKey{k.level + 1, k.x*2 + c[0], k.z*2 + c[1]}
+		if t.inside(ck) {
+			ret = append(ret, ck)
+		}
+	}
+	return ret
+}
+
+// The edges of a chunk, as bits of its mask, and the way to the chunk
+// across each.
+var chunkEdges = [4][2]int{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+
+// Select is the chunks to draw for an eye, in the terrain's coordinates,
+// with the edges of each meeting a coarser chunk.
+func (t *Terrain) Select(eye [3]float32) map[chunkKey]int {
+	leaves := make(map[chunkKey]int)
+	var walk func(chunkKey)
+	walk = func(k chunkKey) {
+		if !t.inside(k) {
+			return
+		}
+		if k.level < t.levels && t.near(k, eye) {
+			for _, c := range t.children(k) {
+				walk(c)
+			}
+			return
+		}
+		leaves[k] = 0
+	}
+	walk(chunkKey{})
+	t.balance(leaves)
+	for k := range leaves {
+		mask := 0
+		for i, e := range chunkEdges {
+			nx, nz := k.x+e[0], k.z+e[1]
+			if nx < 0 || nz < 0 || k.level == 0 {
+				continue
+			}
+			if _, ok := leaves[chunkKey{k.level - 1, nx >> 1, nz >> 1}]; ok {
+				mask |= 1 << uint(i)
+			}
+		}
+		leaves[k] = mask
+	}
+	return leaves
+}
+
+// balance splits chunks until none meets one more than a level finer.
+func (t *Terrain) balance(leaves map[chunkKey]int) {
+	for changed := true; changed; {
+		changed = false
+		for k := range leaves {
+			for _, e := range chunkEdges {
+				nx, nz := k.x+e[0], k.z+e[1]
+				if nx < 0 || nz < 0 {
+					continue
+				}
+				for lv := k.level - 2; lv >= 0; lv-- {
+					sh := uint(k.level - lv)
+					c := chunkKey{lv, nx >> sh, nz >> sh}
+					if _, ok := leaves[c]; ok {
+						delete(leaves, c)
+						for _, cc := range t.children(c) {
+							leaves[cc] = 0
+						}
+						changed = true
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// stitched is the indices of a chunk with the edges of a mask meeting
+// coarser chunks, their odd vertices moved onto the even before them.
+func (t *Terrain) stitched(mask int) math.AU32 {
+	if t.indices[mask] != nil {
+		return t.indices[mask]
+	}
+	n := t.quads
+	idx := func(i, j int) uint32 {
+		switch {
+		case j == 0 && mask&1 != 0 && i%2 == 1:
+			i--
+		case i == n && mask&2 != 0 && j%2 == 1:
+			j--
+		case j == n && mask&4 != 0 && i%2 == 1:
+			i--
+		case i == 0 && mask&8 != 0 && j%2 == 1:
+			j--
+		}
+		return uint32(j*(n+1) + i)
+	}
+	ret := math.NewAU32(0, n*n*6)
+	tri := func(a, b, c uint32) {
+		if a != b && b != c && a != c {
+			ret.Append(a, b, c)
+		}
+	}
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			a, b, c, d := idx(i, j), idx(i, j+1), idx(i+1, j+1), idx(i+1, j)
+			tri(a, b, c)
+			tri(a, c, d)
+		}
+	}
+	t.indices[mask] = ret
+	return ret
+}
+
+// build fills the vertices of a chunk from the heightfield.
+func (t *Terrain) build(k chunkKey, c *terrainChunk) {
+	n := t.quads
+	step := 1 << uint(t.levels-k.level)
+	r := t.region(k)
+	w, d := t.field.Size()
+	data := make([]float32, 0, (n+1)*(n+1)*8)
+	for j := 0; j <= n; j++ {
+		sz := math.ClampInt(r.Z0+j*step, 0, d-1)
+		for i := 0; i <= n; i++ {
+			sx := math.ClampInt(r.X0+i*step, 0, w-1)
+			px, pz := t.field.Position(sx, sz)
+			nx, ny, nz := t.field.SampleNormal(sx, sz)
+			data = append(data,
+				px, t.field.At(sx, sz), pz,
+				nx, ny, nz,
+				float32(sx)/float32(w-1), float32(sz)/float32(d-1),
+			)
+		}
+	}
+	c.vbo.SetBuffer(data)
+	c.stale = false
+}
+
+func (t *Terrain) chunk(k chunkKey) *terrainChunk {
+	c, ok := t.chunks[k]
+	if !ok {
+		c = &terrainChunk{mask: -1, stale: true}
+		c.vbo = graphics.NewBuff().
+			AddAttrib("VertexPosition", 3).
+			AddAttrib("VertexNormal", 3).
+			AddAttrib("VertexTexcoord", 2)
+		g := geometry.New()
+		g.AddVBO(c.vbo)
+		c.geo = g
+		c.mesh = render.NewMesh(t.Tag(), g, t.provide, graphics.TRIANGLES)
+		c.mesh.AddMaterial(t.mat, 0, 0)
+		t.chunks[k] = c
+	}
+	if c.stale {
+		t.build(k, c)
+	}
+	return c
+}
+
+// invalidate rebuilds the chunks over a region of changed samples, and
+// those whose normals they change.
+func (t *Terrain) invalidate(r Region) {
+	if r.Empty() {
+		return
+	}
+	r = r.Grow(1)
+	for k, c := range t.chunks {
+		if t.region(k).Overlaps(r) {
+			c.stale = true
+		}
+	}
+	for k := range t.bounds {
+		if t.region(k).Overlaps(r) {
+			delete(t.bounds, k)
+		}
+	}
+}
+
+// eye is where the camera is in the terrain's coordinates.
+func (t *Terrain) eye(r render.Renderer) [3]float32 {
+	inv := math.MultiplyMatrices(r.ViewMatrice(), r.Last()).Inverse()
+	if inv == nil {
+		return [3]float32{}
+	}
+	m := inv.Raw()
+	return [3]float32{m[12], m[13], m[14]}
+}
+
+func (t *Terrain) render(r render.Renderer, n Node) {
+	t.frame++
+	sel := t.Select(t.eye(r))
+	keys := make([]chunkKey, 0, len(sel))
+	for k := range sel {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.level != b.level {
+			return a.level > b.level
+		}
+		if a.z != b.z {
+			return a.z < b.z
+		}
+		return a.x < b.x
+	})
+	for _, k := range keys {
+		c := t.chunk(k)
+		if mask := sel[k]; mask != c.mask {
+			c.geo.SetIndices(t.stitched(mask))
+			c.mask = mask
+		}
+		c.used = t.frame
+		for _, m := range c.mesh.Materials() {
+			m.Render(r)
+		}
+	}
+	t.drawn = len(keys)
+	for k, c := range t.chunks {
+		if t.frame-c.used > terrainEvictAfter {
+			c.geo.Close()
+			delete(t.chunks, k)
+		}
+	}
+}
+
+// provide transfers the matrices of the terrain and the units of its
+// textures, the splat map first and the layers after.
+func (t *Terrain) provide(r render.Renderer) {
+	mv := math.MultiplyMatrices(r.ViewMatrice(), r.Last())
+	m := mv.Raw()
+	t.mv.Update(m...)
+	t.nm.Update(m[0], m[1], m[2], m[4], m[5], m[6], m[8], m[9], m[10])
+	t.mvp.Update(math.MultiplyMatrices(r.ProjectionMatrice(), mv).Raw()...)
+	t.uTiling.Update(t.tiling[:]...)
+	t.uCount.Update(float32(t.layerCount()))
+	for _, u := range []graphics.Uniform{t.mv, t.nm, t.mvp, t.uSplat, t.uTiling, t.uCount} {
+		u.Transfer(r)
+	}
+	for i, u := range t.uLayers {
+		u.TransferIdx(r, i)
+	}
+}
+
+func (t *Terrain) Close() {
+	for k, c := range t.chunks {
+		c.geo.Close()
+		delete(t.chunks, k)
+	}
+	t.mat.Close()
+}
+
+var terrainTag TagFunc = tagFnFor("terrain", 1)
+
+// shv.terrain(tag, {heightmap = "h.png", scale = 20, spacing = 1, chunk =
+// 32, lod = 2}), or {width = 257, depth = 257, height = function(x, z)
+// ... end} or noise = {seed = 1, kind = "simplex", octaves = 5, frequency =
+// 0.01, amplitude = 20}, as math.noise, in place of a heightmap
+func lterrain(L *l.LState) int {
+	tag := terrainTag(L)
+	o := L.OptTable(2, L.NewTable())
+	num := func(k string, d float32) float32 {
+		setNumber(o, k, &d)
+		return d
+	}
+	spacing := num("spacing", 1)
+	var h *Heightfield
+	var err error
+	if path := l.LVAsString(o.RawGetString("heightmap")); path != "" {
+		h, err = LoadHeightmap(path, spacing, num("scale", 1))
+	} else {
+		h, err = NewHeightfield(int(num("width", 129)), int(num("depth", 129)), spacing)
+	}
+	if err != nil {
+		L.RaiseError("error building terrain: %s", err)
+		return 0
+	}
+	if fn, ok := o.RawGetString("height").(*l.LFunction); ok {
+		h.Generate(func(x, z float32) float32 {
+			if err == nil {
+				err = L.CallByParam(l.P{Fn: fn, NRet: 1, Protect: true}, l.LNumber(x), l.LNumber(z))
+			}
+			if err != nil {
+				return 0
+			}
+			v := float32(L.ToNumber(-1))
+			L.Pop(1)
+			return v
+		})
+		if err != nil {
+			L.RaiseError("error building terrain: %s", err)
+			return 0
+		}
+	} else if n, ok := o.RawGetString("noise").(*l.LTable); ok {
+		s := math.NewSampler(0, math.SIMPLEX)
+		s.Fractal = math.Fractal{Octaves: 5, Frequency: 0.01, Lacunarity: 2, Gain: 0.5}
+		math.SamplerFrom(s, n)
+		amplitude := float32(10)
+		setNumber(n, "amplitude", &amplitude)
+		at := s.Noise2()
+		h.Generate(func(x, z float32) float32 {
+			return amplitude * at(x, z)
+		})
+	}
+	t, err := NewTerrain(tag, h, int(num("chunk", 32)))
+	if err != nil {
+		L.RaiseError("error building terrain: %s", err)
+		return 0
+	}
+	t.SetLOD(num("lod", 2))
+	return pushNode(L, t)
+}
+
+type terrainMemberFunc func(*l.LState, *Terrain) int
+
+func checkTerrain(L *l.LState, pos int) *Terrain {
+	ud := L.CheckUserData(pos)
+	if t, ok := ud.Value.(*Terrain); ok {
+		return t
+	}
+	L.ArgError(pos, "terrain expected")
+	return nil
+}
+
+func terrainMember(fn terrainMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if t := checkTerrain(L, 1); t != nil {
+			return fn(L, t)
+		}
+		return 0
+	}
+}
+
+func terrainProperty(get, set terrainMemberFunc) l.LGFunction {
+	var lset l.LGFunction
+	if set != nil {
+		lset = terrainMember(set)
+	}
+	return lua.NewProperty(terrainMember(get), lset)
+}
+
+func checkXZ(L *l.LState) (float32, float32) {
+	return float32(L.CheckNumber(2)), float32(L.CheckNumber(3))
+}
+
+func terrainHeight(L *l.LState, t *Terrain) int {
+	L.Push(l.LNumber(t.Height(checkXZ(L))))
+	return 1
+}
+
+func terrainNormal(L *l.LState, t *Terrain) int {
+	L.Push(vecValue(L, t.Normal(checkXZ(L)), math.VEC3))
+	return 1
+}
+
+// t:raise(x, z, radius, amount)
+func terrainRaise(L *l.LState, t *Terrain) int {
+	x, z := checkXZ(L)
+	t.Raise(x, z, float32(L.CheckNumber(4)), float32(L.CheckNumber(5)))
+	return 0
+}
+
+// t:lower(x, z, radius, amount)
+func terrainLower(L *l.LState, t *Terrain) int {
+	x, z := checkXZ(L)
+	t.Raise(x, z, float32(L.CheckNumber(4)), -float32(L.CheckNumber(5)))
+	return 0
+}
+
+// t:flatten(x, z, radius[, height[, strength]]), to the height at x and z
+// by default
+func terrainFlatten(L *l.LState, t *Terrain) int {
+	x, z := checkXZ(L)
+	height := float32(L.OptNumber(5, l.LNumber(t.Height(x, z))))
+	t.Flatten(x, z, float32(L.CheckNumber(4)), height, float32(L.OptNumber(6, 1)))
+	return 0
+}
+
+// t:paint(x, z, radius, layer[, strength]), layers from 1
+func terrainPaint(L *l.LState, t *Terrain) int {
+	x, z := checkXZ(L)
+	err := t.Paint(x, z, float32(L.CheckNumber(4)), L.CheckInt(5)-1, float32(L.OptNumber(6, 1)))
+	if err != nil {
+		L.RaiseError("%s", err)
+	}
+	return 0
+}
+
+// t:layer(i, "grass.png"[, tiling]), layers from 1
+func terrainLayer(L *l.LState, t *Terrain) int {
+	i := L.CheckInt(2) - 1
+	pix, w, h, err := decodeImage(L.CheckString(3))
+	if err != nil {
+		L.RaiseError("error loading terrain layer: %s", err)
+		return 0
+	}
+	tex := texture.NewData(graphics.RGBA)
+	tex.Set(w, h, pix)
+	if err := t.SetLayer(i, tex, float32(L.OptNumber(4, 1))); err != nil {
+		L.RaiseError("%s", err)
+	}
+	return 0
+}
+
+func getTerrainLOD(L *l.LState, t *Terrain) int {
+	L.Push(l.LNumber(t.LOD()))
+	return 1
+}
+
+func setTerrainLOD(L *l.LState, t *Terrain) int {
+	t.SetLOD(float32(L.CheckNumber(3)))
+	return 0
+}
+
+func getTerrainChunks(L *l.LState, t *Terrain) int {
+	L.Push(l.LNumber(t.Drawn()))
+	return 1
+}
+
+// size is the extent of the terrain, {x, z}
+func getTerrainSize(L *l.LState, t *Terrain) int {
+	w, d := t.field.Extent()
+	s := L.CreateTable(2, 0)
+	s.Append(l.LNumber(w))
+	s.Append(l.LNumber(d))
+	L.Push(s)
+	return 1
+}
+
+var lTerrainNodeTable = &lua.Table{
+	lTerrainNodeClass,
+	[]*lua.Table{nodeTable},
+	defaultIdxMetaFuncs(),
+	map[string]l.LGFunction{
+		"lod":    terrainProperty(getTerrainLOD, setTerrainLOD),
+		"chunks": terrainProperty(getTerrainChunks, nil),
+		"size":   terrainProperty(getTerrainSize, nil),
+	},
+	map[string]l.LGFunction{
+		"height":  terrainMember(terrainHeight),
+		"normal":  terrainMember(terrainNormal),
+		"raise":   terrainMember(terrainRaise),
+		"lower":   terrainMember(terrainLower),
+		"flatten": terrainMember(terrainFlatten),
+		"paint":   terrainMember(terrainPaint),
+		"layer":   terrainMember(terrainLayer),
+	},
+}
@@ -0,0 +1,552 @@
+package scene
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/geometry"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/render"
+	"github.com/Laughs-In-Flowers/shiva/lib/text"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+// textBatch draws the glyphs of one font, each font having its own atlas.
+type textBatch struct {
+	m      render.Mesh
+	mat    material.Material
+	vbo    *graphics.Buff
+	glyphs int
+	height int
+}
+
+// Text is a node drawing laid out text, in its local XY plane at Scale world
+// units a pixel, or in screen pixels from the top left of the window when
+// Screen, placed by its world translation.
+type Text struct {
+	*node
+	str      string
+	spans    []text.Span
+	font     *text.Font
+	size     float32
+	color    [4]float32
+	opts     text.Options
+	screen   bool
+	scale    float32
+	layout   *text.Layout
+	batches  map[*text.Font]*textBatch
+	dirty    bool
+	mv, proj graphics.Uniform
+}
+
+const lTextNodeClass = "NTEXT"
+
+// NewText returns a text node drawing s in a font at a pixel size.
+func NewText(tag string, s string, f *text.Font, size float32) *Text {
+	t := &Text{
+		str:     s,
+		font:    f,
+		size:    size,
+		color:   [4]float32{1, 1, 1, 1},
+		scale:   0.01,
+		batches: make(map[*text.Font]*textBatch),
+		dirty:   true,
+		mv:      graphics.UniformMatrix4fv("ModelViewMatrix"),
+		proj:    graphics.UniformMatrix4fv("ProjectionMatrix"),
+	}
+	t.node = newNode(tag, func(r render.Renderer, n Node) {
+		if err := t.relay(); err != nil {
+			return
+		}
+		for _, b := range t.batches {
+			if b.glyphs == 0 {
+				continue
+			}
+			for _, m := range b.m.Materials() {
+				m.Render(r)
+			}
+		}
+	}, func(n *node) error {
+		for _, b := range t.batches {
+			b.m.Close()
+		}
+		return defaultRemovalFn(n)
+	}, defaultReplaceFn, lTextNodeClass, lNodeClass)
+	return t
+}
+
+func (t *Text) provide(r render.Renderer) {
+	if t.screen {
+		w, h := 1, 1
+		if currentScene != nil {
+			w, h = currentScene.Size()
+		}
+		t.mv.Update(r.Last().Raw()...)
+		t.proj.Update(math.Mat4().Orthographic(0, float32(w), 0, float32(h), -1, 1).Raw()...)
+	} else {
+		t.mv.Update(math.MultiplyMatrices(r.ViewMatrice(), r.Last()).Raw()...)
+		t.proj.Update(r.ProjectionMatrice().Raw()...)
+	}
+	t.mv.Transfer(r)
+	t.proj.Transfer(r)
+}
+
+func (t *Text) batch(f *text.Font) *textBatch {
+	if b, ok := t.batches[f]; ok {
+		return b
+	}
+	b := &textBatch{}
+	b.vbo = graphics.NewBuff().
+		AddAttrib("VertexPosition", 3).
+		AddAttrib("VertexTexcoord", 2).
+		AddAttrib("GlyphColor", 4)
+	b.vbo.SetUsage(graphics.DYNAMIC_DRAW)
+	g := geometry.New()
+	g.AddVBO(b.vbo)
+	b.m = render.NewMesh(t.Tag(), g, t.provide, graphics.TRIANGLES)
+	b.mat = material.New()
+	if f.Mode() == text.SDF {
+		b.mat.SetShader("textsdf")
+	} else {
+		b.mat.SetShader("text")
+	}
+	b.mat.SetIndependent(true)
+	b.mat.SetUseLights(material.ULNone)
+	b.mat.SetSide(material.SIDouble)
+	b.mat.SetDepthMask(false)
+	b.mat.SetBlending(material.BLNormal)
+	b.mat.AddTexture(f.Atlas().Texture())
+	t.setDepthTest(b)
+	b.m.AddMaterial(b.mat, 0, 0)
+	t.batches[f] = b
+	return b
+}
+
+func (t *Text) setDepthTest(b *textBatch) {
+	b.mat.SetDepthTest(!t.screen)
+}
+
+// stale reports the text or any atlas it draws from changed since it was
+// last laid out, atlases growing moving every texture coordinate.
+func (t *Text) stale() bool {
+	if t.dirty {
+		return true
+	}
+	for f, b := range t.batches {
+		if b.glyphs > 0 && b.height != f.Atlas().Height {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Text) relay() error {
+	if !t.stale() {
+		return nil
+	}
+	lo, err := text.Lay(t.Spans(), t.opts)
+	if err != nil {
+		return err
+	}
+	t.layout, t.dirty = lo, false
+	data := make(map[*text.Font]math.AF32)
+	for _, q := range lo.Quads {
+		x0, y0, x1, y1 := q.X0, q.Y0, q.X1, q.Y1
+		if !t.screen {
+			x0, y0, x1, y1 = x0*t.scale, -y0*t.scale, x1*t.scale, -y1*t.scale
+		}
+		c := q.Color
+		data[q.Font] = append(data[q.Font],
+			x0, y0, 0, q.U0, q.V0, c[0], c[1], c[2], c[3],
+			x0, y1, 0, q.U0, q.V1, c[0], c[1], c[2], c[3],
+			x1, y1, 0, q.U1, q.V1, c[0], c[1], c[2], c[3],
+			x0, y0, 0, q.U0, q.V0, c[0], c[1], c[2], c[3],
+			x1, y1, 0, q.U1, q.V1, c[0], c[1], c[2], c[3],
+			x1, y0, 0, q.U1, q.V0, c[0], c[1], c[2], c[3],
+		)
+	}
+	for _, b := range t.batches {
+		b.glyphs = 0
+	}
+	for f, d := range data {
+		b := t.batch(f)
+		b.vbo.SetBuffer(d)
+		b.glyphs = len(d) / (9 * 6)
+		b.height = f.Atlas().Height
+	}
+	return nil
+}
+
+// Spans are the runs of text laid out: those set, or the string in the
+// node's font, size and color.
+func (t *Text) Spans() []text.Span {
+	if t.spans != nil {
+		ret := make([]text.Span, len(t.spans))
+		for i, s := range t.spans {
+			if s.Font == nil {
+				s.Font = t.font
+			}
+			if s.Size <= 0 {
+				s.Size = t.size
+			}
+			ret[i] = s
+		}
+		return ret
+	}
+	return []text.Span{{Text: t.str, Font: t.font, Size: t.size, Color: t.color}}
+}
+
+func (t *Text) String() string {
+	if t.spans != nil {
+		var s string
+		for _, sp := range t.spans {
+			s += sp.Text
+		}
+		return s
+	}
+	return t.str
+}
+
+func (t *Text) SetString(s string) {
+	t.str, t.spans, t.dirty = s, nil, true
+}
+
+// SetSpans sets runs of text in their own fonts, sizes and colors, those
+// with no font or size taking the node's.
+func (t *Text) SetSpans(s []text.Span) {
+	t.spans, t.dirty = s, true
+}
+
+func (t *Text) Font() *text.Font {
+	return t.font
+}
+
+func (t *Text) SetFont(f *text.Font) {
+	t.font, t.dirty = f, true
+}
+
+func (t *Text) Size() float32 {
+	return t.size
+}
+
+func (t *Text) SetSize(s float32) {
+	t.size, t.dirty = s, true
+}
+
+func (t *Text) Color() [4]float32 {
+	return t.color
+}
+
+func (t *Text) SetColor(c [4]float32) {
+	t.color, t.dirty = c, true
+}
+
+func (t *Text) Options() text.Options {
+	return t.opts
+}
+
+func (t *Text) SetOptions(o text.Options) {
+	t.opts, t.dirty = o, true
+}
+
+func (t *Text) Screen() bool {
+	return t.screen
+}
+
+// SetScreen switches between drawing in screen pixels and in the scene.
+func (t *Text) SetScreen(b bool) {
+	t.screen, t.dirty = b, true
+	for _, bt := range t.batches {
+		t.setDepthTest(bt)
+	}
+}
+
+func (t *Text) Scale() float32 {
+	return t.scale
+}
+
+// SetScale sets the world units a pixel of text spans outside screen space.
+func (t *Text) SetScale(s float32) {
+	t.scale, t.dirty = s, true
+}
+
+// Bounds is the width and height of the laid out text in pixels.
+func (t *Text) Bounds() (float32, float32) {
+	if err := t.relay(); err != nil || t.layout == nil {
+		return 0, 0
+	}
+	return t.layout.Width, t.layout.Height
+}
+
+var textTag TagFunc = tagFnFor("text", 1)
+
+func checkColor(v l.LValue) ([4]float32, bool) {
+	c := [4]float32{1, 1, 1, 1}
+	switch cv := v.(type) {
+	case *l.LTable:
+		for i := 0; i < 4; i++ {
+			if n, ok := cv.RawGetInt(i + 1).(l.LNumber); ok {
+				c[i] = float32(n)
+			}
+		}
+		return c, true
+	case *l.LUserData:
+		if vec, ok := cv.Value.(math.Vector); ok {
+			for i := 0; i < 4 && i < vec.RawLen(); i++ {
+				c[i] = vec.Get(i)
+			}
+			return c, true
+		}
+	}
+	return c, false
+}
+
+// spansFrom reads {{"plain "}, {"bold", font = bold, size = 24, color =
+// {1, 0, 0, 1}}} into spans.
+func spansFrom(L *l.LState, t *l.LTable, color [4]float32) []text.Span {
+	ret := make([]text.Span, 0)
+	t.ForEach(func(_, v l.LValue) {
+		switch sv := v.(type) {
+		case l.LString:
+			ret = append(ret, text.Span{Text: string(sv), Color: color})
+		case *l.LTable:
+			s := text.Span{Text: l.LVAsString(sv.RawGetInt(1)), Color: color}
+			if ud, ok := sv.RawGetString("font").(*l.LUserData); ok {
+				s.Font, _ = ud.Value.(*text.Font)
+			}
+			if n, ok := sv.RawGetString("size").(l.LNumber); ok {
+				s.Size = float32(n)
+			}
+			if c, ok := checkColor(sv.RawGetString("color")); ok {
+				s.Color = c
+			}
+			ret = append(ret, s)
+		}
+	})
+	return ret
+}
+
+// textOptions applies {font = f, size = 16, color = {1, 1, 1, 1}, width =
+// 300, align = "center", line_height = 1.2, screen = true, scale = 0.01}.
+func textOptions(L *l.LState, t *Text, o *l.LTable) {
+	if ud, ok := o.RawGetString("font").(*l.LUserData); ok {
+		if f, ok := ud.Value.(*text.Font); ok {
+			t.SetFont(f)
+		}
+	}
+	if n, ok := o.RawGetString("size").(l.LNumber); ok {
+		t.SetSize(float32(n))
+	}
+	if c, ok := checkColor(o.RawGetString("color")); ok {
+		t.SetColor(c)
+	}
+	opts := t.Options()
+	if n, ok := o.RawGetString("width").(l.LNumber); ok {
+		opts.Width = float32(n)
+	}
+	if s, ok := o.RawGetString("align").(l.LString); ok {
+		if a, ok := text.StringToAlign(string(s)); ok {
+			opts.Align = a
+		}
+	}
+	if n, ok := o.RawGetString("line_height").(l.LNumber); ok {
+		opts.LineHeight = float32(n)
+	}
+	t.SetOptions(opts)
+	if b := o.RawGetString("screen"); b != l.LNil {
+		t.SetScreen(l.LVAsBool(b))
+	}
+	if n, ok := o.RawGetString("scale").(l.LNumber); ok {
+		t.SetScale(float32(n))
+	}
+}
+
+// shv.text(tag, "hello" | {{"plain "}, {"red", color = {1, 0, 0, 1}}}, {font = f, size = 16, ...})
+func ltext(L *l.LState) int {
+	tag := textTag(L)
+	t := NewText(tag, "", nil, 16)
+	if o, ok := L.Get(3).(*l.LTable); ok {
+		textOptions(L, t, o)
+	}
+	switch v := L.Get(2).(type) {
+	case l.LString:
+		t.SetString(string(v))
+	case *l.LTable:
+		t.SetSpans(spansFrom(L, v, t.color))
+	}
+	if t.font == nil {
+		L.RaiseError("error building text %s: no font", tag)
+		return 0
+	}
+	return pushNode(L, t)
+}
+
+type textMemberFunc func(*l.LState, *Text) int
+
+func checkText(L *l.LState, pos int) *Text {
+	ud := L.CheckUserData(pos)
+	if t, ok := ud.Value.(*Text); ok {
+		return t
+	}
+	L.ArgError(pos, "text expected")
+	return nil
+}
+
+func textMember(fn textMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if t := checkText(L, 1); t != nil {
+			return fn(L, t)
+		}
+		return 0
+	}
+}
+
+func textProperty(get, set textMemberFunc) l.LGFunction {
+	var lset l.LGFunction
+	if set != nil {
+		lset = textMember(set)
+	}
+	return lua.NewProperty(textMember(get), lset)
+}
+
+func getTextText(L *l.LState, t *Text) int {
+	L.Push(l.LString(t.String()))
+	return 1
+}
+
+func setTextText(L *l.LState, t *Text) int {
+	switch v := L.Get(3).(type) {
+	case *l.LTable:
+		t.SetSpans(spansFrom(L, v, t.color))
+	default:
+		t.SetString(L.CheckString(3))
+	}
+	return 0
+}
+
+func getTextFont(L *l.LState, t *Text) int {
+	return text.PushFont(L, t.font)
+}
+
+func setTextFont(L *l.LState, t *Text) int {
+	t.SetFont(text.CheckFont(L, 3))
+	return 0
+}
+
+func getTextSize(L *l.LState, t *Text) int {
+	L.Push(l.LNumber(t.size))
+	return 1
+}
+
+func setTextSize(L *l.LState, t *Text) int {
+	t.SetSize(float32(L.CheckNumber(3)))
+	return 0
+}
+
+func getTextColor(L *l.LState, t *Text) int {
+	c := t.color
+	lua.PushNewUserData(L, func(u *l.LUserData) {
+		u.Value = math.Vec4(c[0], c[1], c[2], c[3])
+	}, math.VEC4)
+	return 1
+}
+
+func setTextColor(L *l.LState, t *Text) int {
+	c, ok := checkColor(L.Get(3))
+	if !ok {
+		L.ArgError(3, "color expected")
+		return 0
+	}
+	t.SetColor(c)
+	return 0
+}
+
+func getTextWidth(L *l.LState, t *Text) int {
+	L.Push(l.LNumber(t.opts.Width))
+	return 1
+}
+
+func setTextWidth(L *l.LState, t *Text) int {
+	o := t.Options()
+	o.Width = float32(L.CheckNumber(3))
+	t.SetOptions(o)
+	return 0
+}
+
+func getTextAlign(L *l.LState, t *Text) int {
+	L.Push(l.LString(t.opts.Align.String()))
+	return 1
+}
+
+func setTextAlign(L *l.LState, t *Text) int {
+	a, ok := text.StringToAlign(L.CheckString(3))
+	if !ok {
+		L.ArgError(3, "align must be left, center or right")
+		return 0
+	}
+	o := t.Options()
+	o.Align = a
+	t.SetOptions(o)
+	return 0
+}
+
+func getTextLineHeight(L *l.LState, t *Text) int {
+	L.Push(l.LNumber(t.opts.LineHeight))
+	return 1
+}
+
+func setTextLineHeight(L *l.LState, t *Text) int {
+	o := t.Options()
+	o.LineHeight = float32(L.CheckNumber(3))
+	t.SetOptions(o)
+	return 0
+}
+
+func getTextScreen(L *l.LState, t *Text) int {
+	L.Push(l.LBool(t.screen))
+	return 1
+}
+
+func setTextScreen(L *l.LState, t *Text) int {
+	t.SetScreen(L.CheckBool(3))
+	return 0
+}
+
+func getTextScale(L *l.LState, t *Text) int {
+	L.Push(l.LNumber(t.scale))
+	return 1
+}
+
+func setTextScale(L *l.LState, t *Text) int {
+	t.SetScale(float32(L.CheckNumber(3)))
+	return 0
+}
+
+// text:bounds() is the width and height of the text in pixels
+func textBounds(L *l.LState, t *Text) int {
+	w, h := t.Bounds()
+	L.Push(l.LNumber(w))
+	L.Push(l.LNumber(h))
+	return 2
+}
+
+var lTextNodeTable = &lua.Table{
+	lTextNodeClass,
+	[]*lua.Table{nodeTable},
+	defaultIdxMetaFuncs(),
+	map[string]l.LGFunction{
+		"text":        textProperty(getTextText, setTextText),
+		"font":        textProperty(getTextFont, setTextFont),
+		"size":        textProperty(getTextSize, setTextSize),
+		"color":       textProperty(getTextColor, setTextColor),
+		"width":       textProperty(getTextWidth, setTextWidth),
+		"align":       textProperty(getTextAlign, setTextAlign),
+		"line_height": textProperty(getTextLineHeight, setTextLineHeight),
+		"screen":      textProperty(getTextScreen, setTextScreen),
+		"scale":       textProperty(getTextScale, setTextScale),
+	},
+	map[string]l.LGFunction{
+		"bounds": textMember(textBounds),
+	},
+}
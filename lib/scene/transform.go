@@ -277,6 +277,11 @@ func (o *position) updateMatrix() {
 	o.matrix.Compose(o.t.Raw(), o.r.Raw(), o.s.Raw())
 }
 
+func (o *position) localMatrix() math.Matrice {
+	o.updateMatrix()
+	return o.matrix
+}
+
 func (o *position) updateMatrixWorld(r render.Renderer) {
 	o.updateMatrix()
 	last := r.Last()
@@ -284,6 +289,11 @@ func (o *position) updateMatrixWorld(r render.Renderer) {
 	r.SetLast(o.matrixWorld)
 }
 
+// setMatrixWorld sets the world matrix as found outside a render.
+func (o *position) setMatrixWorld(m math.Matrice) {
+	o.matrixWorld = m
+}
+
 func (o *position) translate(world math.Vector) math.Vector {
 	return math.SetVectorFromMatrice(world, o.matrixWorld, math.TranslateMxPos...)
 }
@@ -0,0 +1,113 @@
+package scene
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/animation"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
+	"github.com/Laughs-In-Flowers/shiva/lib/render"
+)
+
+func transformProperty(t Transform, rotation bool) animation.Property {
+	return animation.NewProperty(t.Raw, t.Update, rotation)
+}
+
+func (t *translateNode) TweenProperty(name string) animation.Property {
+	if stringToTransform(name) == TRANSLATE {
+		return transformProperty(t.translate, false)
+	}
+	return nil
+}
+
+func (s *scaleNode) TweenProperty(name string) animation.Property {
+	if stringToTransform(name) == SCALE {
+		return transformProperty(s.scale, false)
+	}
+	return nil
+}
+
+func (r *rotateNode) TweenProperty(name string) animation.Property {
+	if stringToTransform(name) == ROTATE {
+		return transformProperty(r.rotate, true)
+	}
+	return nil
+}
+
+func (p *positionNode) TweenProperty(name string) animation.Property {
+	k := stringToTransform(name)
+	if t := p.getTransform(k); t != nil {
+		return transformProperty(t, k == ROTATE)
+	}
+	return nil
+}
+
+func (t *light) TweenProperty(name string) animation.Property {
+	switch name {
+	case "intensity":
+		return animation.NewProperty(
+			func() []float32 { return []float32{t.intensity} },
+			func(v ...float32) { t.SetIntensity(v[0]) },
+			false,
+		)
+	case "color":
+		return animation.NewProperty(
+			func() []float32 { return t.color.Raw() },
+			func(v ...float32) {
+				t.color.Set(v...)
+				t.postChange()
+			},
+			false,
+		)
+	}
+	return nil
+}
+
+// materialProperty tweens a color, "opacity" or "shininess" of every
+// material of a mesh, reading from the first.
+func materialProperty(m render.Mesh, name string) animation.Property {
+	ms := m.Materials()
+	if len(ms) == 0 {
+		return nil
+	}
+	first := ms[0].Material()
+	each := func(fn func(material.Material)) {
+		for _, gm := range m.Materials() {
+			fn(gm.Material())
+		}
+	}
+	switch name {
+	case "opacity":
+		return animation.NewProperty(
+			func() []float32 { return []float32{first.Opacity()} },
+			func(v ...float32) { each(func(a material.Material) { a.SetOpacity(v[0]) }) },
+			false,
+		)
+	case "shininess":
+		return animation.NewProperty(
+			func() []float32 { return []float32{first.Shininess()} },
+			func(v ...float32) { each(func(a material.Material) { a.SetShininess(v[0]) }) },
+			false,
+		)
+	}
+	c := material.StringToColorT(name)
+	if c == material.UNKNOWN_COLOR {
+		return nil
+	}
+	return animation.NewProperty(
+		func() []float32 { return first.Color(c).Raw() },
+		func(v ...float32) {
+			each(func(a material.Material) {
+				if col := a.Color(c); col != nil {
+					col.Set(v...)
+				}
+			})
+		},
+		false,
+	)
+}
+
+func (s *sphere) TweenProperty(name string) animation.Property {
+	return materialProperty(s.m, name)
+}
+
+func (s *skinned) TweenProperty(name string) animation.Property {
+	return materialProperty(s.m, name)
+}
@@ -0,0 +1,90 @@
+package text
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/texture"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+var AtlasFullError = xrror.Xrror("glyph atlas full: no room for %dx%d at %dx%d").Out
+
+const (
+	atlasWidth     = 512
+	atlasMaxHeight = 4096
+	atlasPadding   = 1
+)
+
+type shelf struct {
+	y, h, x int
+}
+
+// Atlas packs glyph bitmaps onto shelves of a single channel texture,
+// doubling its height as it fills.
+type Atlas struct {
+	Width, Height int
+	pix           []byte
+	shelves       []*shelf
+	tex           *texture.Data
+}
+
+func NewAtlas() *Atlas {
+	a := &Atlas{
+		Width:   atlasWidth,
+		Height:  atlasWidth / 2,
+		shelves: make([]*shelf, 0),
+		tex:     texture.NewData(graphics.RED),
+	}
+	a.pix = make([]byte, a.Width*a.Height)
+	return a
+}
+
+// Texture is the atlas texture, updated as glyphs are added.
+func (a *Atlas) Texture() *texture.Data {
+	return a.tex
+}
+
+// Add copies a w by h bitmap into the atlas, returning where it went.
+func (a *Atlas) Add(w, h int, pix []byte) (int, int, error) {
+	x, y, err := a.place(w+atlasPadding, h+atlasPadding)
+	if err != nil {
+		return 0, 0, err
+	}
+	for row := 0; row < h; row++ {
+		copy(a.pix[(y+row)*a.Width+x:], pix[row*w:(row+1)*w])
+	}
+	a.tex.Set(a.Width, a.Height, a.pix)
+	return x, y, nil
+}
+
+// place finds the shortest shelf the rectangle fits on, opening a new shelf
+// and growing the atlas as needed.
+func (a *Atlas) place(w, h int) (int, int, error) {
+	if w > a.Width {
+		return 0, 0, AtlasFullError(w, h, a.Width, a.Height)
+	}
+	var best *shelf
+	for _, s := range a.shelves {
+		if s.h >= h && a.Width-s.x >= w && (best == nil || s.h < best.h) {
+			best = s
+		}
+	}
+	if best == nil {
+		y := 0
+		if n := len(a.shelves); n > 0 {
+			last := a.shelves[n-1]
+			y = last.y + last.h
+		}
+		for y+h > a.Height {
+			if a.Height*2 > atlasMaxHeight {
+				return 0, 0, AtlasFullError(w, h, a.Width, a.Height)
+			}
+			a.pix = append(a.pix, make([]byte, a.Width*a.Height)...)
+			a.Height *= 2
+		}
+		best = &shelf{y: y, h: h}
+		a.shelves = append(a.shelves, best)
+	}
+	x := best.x
+	best.x += w
+	return x, best.y, nil
+}
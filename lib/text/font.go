@@ -0,0 +1,253 @@
+package text
+
+import (
+	"image"
+	"image/draw"
+	glm "math"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+type Mode int
+
+const (
+	BITMAP Mode = iota
+	SDF
+)
+
+func (m Mode) String() string {
+	switch m {
+	case BITMAP:
+		return "bitmap"
+	case SDF:
+		return "sdf"
+	}
+	return "UNKNOWN_MODE"
+}
+
+func StringToMode(s string) (Mode, bool) {
+	switch strings.ToLower(s) {
+	case "bitmap":
+		return BITMAP, true
+	case "sdf":
+		return SDF, true
+	}
+	return BITMAP, false
+}
+
+const (
+	// SDFSize is the pixel size SDF glyphs are rasterized at, every other
+	// size scaled from it.
+	SDFSize = 48
+	// SDFSpread is the distance in pixels an SDF reaches past the outline.
+	SDFSpread = 6
+)
+
+var FontError = xrror.Xrror("error loading font %s: %s").Out
+
+// Glyph is a glyph in the atlas: where its bitmap is, the offset of the
+// bitmap's top left from the pen on the baseline, y down, and the pixel
+// size it was drawn at.
+type Glyph struct {
+	X, Y, W, H int
+	Left, Top  float32
+	Size       float32
+}
+
+type glyphKey struct {
+	idx  sfnt.GlyphIndex
+	size int
+}
+
+// Font is a TrueType or OpenType font, rasterizing glyphs into its atlas as
+// they are first drawn, one per pixel size in bitmap mode and one for every
+// size in SDF mode.
+type Font struct {
+	Name   string
+	f      *sfnt.Font
+	buf    sfnt.Buffer
+	mode   Mode
+	atlas  *Atlas
+	glyphs map[glyphKey]*Glyph
+}
+
+// Load reads a font from a .ttf or .otf file.
+func Load(path string, mode Mode) (*Font, error) {
+	b, err := vfs.ReadFile(path)
+	if err != nil {
+		return nil, FontError(path, err)
+	}
+	f, err := Parse(b, mode)
+	if err != nil {
+		return nil, FontError(path, err)
+	}
+	return f, nil
+}
+
+type fontKey struct {
+	path string
+	mode Mode
+}
+
+// fonts are loaded once a path and mode, sharing their atlas.
+var fonts = make(map[fontKey]*Font)
+
+// Cached is the font loaded from a path in a mode, loading it the first
+// time.
+func Cached(path string, mode Mode) (*Font, error) {
+	k := fontKey{path, mode}
+	if f, ok := fonts[k]; ok {
+		return f, nil
+	}
+	f, err := Load(path, mode)
+	if err != nil {
+		return nil, err
+	}
+	fonts[k] = f
+	return f, nil
+}
+
+func Parse(b []byte, mode Mode) (*Font, error) {
+	sf, err := sfnt.Parse(b)
+	if err != nil {
+		return nil, err
+	}
+	f := &Font{
+		f:      sf,
+		mode:   mode,
+		atlas:  NewAtlas(),
+		glyphs: make(map[glyphKey]*Glyph),
+	}
+	f.Name, _ = sf.Name(&f.buf, sfnt.NameIDFamily)
+	return f, nil
+}
+
+func (f *Font) Mode() Mode {
+	return f.mode
+}
+
+func (f *Font) Atlas() *Atlas {
+	return f.atlas
+}
+
+func ppem(size float32) fixed.Int26_6 {
+	return fixed.Int26_6(glm.Round(float64(size) * 64))
+}
+
+func float(x fixed.Int26_6) float32 {
+	return float32(x) / 64
+}
+
+func (f *Font) index(r rune) sfnt.GlyphIndex {
+	idx, err := f.f.GlyphIndex(&f.buf, r)
+	if err != nil {
+		return 0
+	}
+	return idx
+}
+
+// Metrics are the ascent, descent and line height at a pixel size.
+func (f *Font) Metrics(size float32) (float32, float32, float32) {
+	m, err := f.f.Metrics(&f.buf, ppem(size), font.HintingNone)
+	if err != nil {
+		return size, 0, size
+	}
+	return float(m.Ascent), float(m.Descent), float(m.Height)
+}
+
+// Advance is how far the pen moves past a rune at a pixel size.
+func (f *Font) Advance(r rune, size float32) float32 {
+	a, err := f.f.GlyphAdvance(&f.buf, f.index(r), ppem(size), font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	return float(a)
+}
+
+// Kern is the adjustment between two runes at a pixel size, 0 where the
+// font has none.
+func (f *Font) Kern(a, b rune, size float32) float32 {
+	k, err := f.f.Kern(&f.buf, f.index(a), f.index(b), ppem(size), font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	return float(k)
+}
+
+// Glyph is the atlas glyph for a rune at a pixel size, rasterizing it the
+// first time. Glyphs with no outline, as spaces, have no width or height.
+func (f *Font) Glyph(r rune, size float32) (*Glyph, error) {
+	px := int(glm.Round(float64(size)))
+	if f.mode == SDF {
+		px = SDFSize
+	}
+	if px < 1 {
+		px = 1
+	}
+	k := glyphKey{f.index(r), px}
+	if g, ok := f.glyphs[k]; ok {
+		return g, nil
+	}
+	g, err := f.rasterize(k)
+	if err != nil {
+		return nil, err
+	}
+	f.glyphs[k] = g
+	return g, nil
+}
+
+func (f *Font) rasterize(k glyphKey) (*Glyph, error) {
+	g := &Glyph{Size: float32(k.size)}
+	segs, err := f.f.LoadGlyph(&f.buf, k.idx, fixed.I(k.size), nil)
+	if err != nil {
+		return nil, err
+	}
+	b := segs.Bounds()
+	x0, y0 := b.Min.X.Floor(), b.Min.Y.Floor()
+	w, h := b.Max.X.Ceil()-x0, b.Max.Y.Ceil()-y0
+	if len(segs) == 0 || w <= 0 || h <= 0 {
+		return g, nil
+	}
+	ox, oy := float32(x0), float32(y0)
+	pt := func(p fixed.Point26_6) (float32, float32) {
+		return float(p.X) - ox, float(p.Y) - oy
+	}
+	z := vector.NewRasterizer(w, h)
+	z.DrawOp = draw.Src
+	for _, s := range segs {
+		ax, ay := pt(s.Args[0])
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			z.MoveTo(ax, ay)
+		case sfnt.SegmentOpLineTo:
+			z.LineTo(ax, ay)
+		case sfnt.SegmentOpQuadTo:
+			bx, by := pt(s.Args[1])
+			z.QuadTo(ax, ay, bx, by)
+		case sfnt.SegmentOpCubeTo:
+			bx, by := pt(s.Args[1])
+			cx, cy := pt(s.Args[2])
+			z.CubeTo(ax, ay, bx, by, cx, cy)
+		}
+	}
+	dst := image.NewAlpha(image.Rect(0, 0, w, h))
+	z.Draw(dst, dst.Bounds(), image.Opaque, image.Point{})
+	pix := dst.Pix
+	if f.mode == SDF {
+		w, h, pix = sdf(w, h, pix, SDFSpread)
+		ox, oy = ox-SDFSpread, oy-SDFSpread
+	}
+	x, y, err := f.atlas.Add(w, h, pix)
+	if err != nil {
+		return nil, err
+	}
+	g.X, g.Y, g.W, g.H = x, y, w, h
+	g.Left, g.Top = ox, oy
+	return g, nil
+}
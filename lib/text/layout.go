@@ -0,0 +1,282 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Align int
+
+const (
+	LEFT Align = iota
+	CENTER
+	RIGHT
+)
+
+func (a Align) String() string {
+	switch a {
+	case LEFT:
+		return "left"
+	case CENTER:
+		return "center"
+	case RIGHT:
+		return "right"
+	}
+	return "UNKNOWN_ALIGN"
+}
+
+func StringToAlign(s string) (Align, bool) {
+	switch strings.ToLower(s) {
+	case "left":
+		return LEFT, true
+	case "center":
+		return CENTER, true
+	case "right":
+		return RIGHT, true
+	}
+	return LEFT, false
+}
+
+// Span is a run of text in one font, size and color.
+type Span struct {
+	Text  string
+	Font  *Font
+	Size  float32
+	Color [4]float32
+}
+
+// Options shape a layout: Width wraps lines at word breaks, 0 for no
+// wrapping, and LineHeight multiplies each line's height.
+type Options struct {
+	Width      float32
+	Align      Align
+	LineHeight float32
+}
+
+// Quad is a laid out glyph, positioned in pixels from the top left of the
+// text y down, with texture coordinates into its font's atlas.
+type Quad struct {
+	Font           *Font
+	X0, Y0, X1, Y1 float32
+	U0, V0, U1, V1 float32
+	Color          [4]float32
+}
+
+// Layout is laid out text, its quads and the size of the block they fill.
+type Layout struct {
+	Quads         []Quad
+	Width, Height float32
+	Lines         int
+}
+
+type placed struct {
+	r    rune
+	s    *Span
+	x    float32
+	g    *Glyph
+	word int
+}
+
+type line struct {
+	glyphs          []placed
+	width           float32
+	ascent, descent float32
+	height          float32
+}
+
+// Lay lays out spans, rasterizing any glyphs not yet in their fonts' atlas.
+func Lay(spans []Span, o Options) (*Layout, error) {
+	if o.LineHeight <= 0 {
+		o.LineHeight = 1
+	}
+	lines := make([]*line, 0)
+	cur := &line{}
+	var x float32
+	var prev rune
+	var prevSpan *Span
+	word := 0
+	wrapped := false
+
+	newLine := func(s *Span) {
+		if len(cur.glyphs) == 0 && s != nil {
+			cur.ascent, cur.descent, cur.height = s.Font.Metrics(s.Size)
+		}
+		lines = append(lines, cur)
+		cur, x, prev, prevSpan = &line{}, 0, 0, nil
+		wrapped = false
+	}
+
+	for i := range spans {
+		s := &spans[i]
+		if s.Font == nil || s.Size <= 0 {
+			continue
+		}
+		asc, desc, h := s.Font.Metrics(s.Size)
+		for str := s.Text; len(str) > 0; {
+			r, n := utf8.DecodeRuneInString(str)
+			str = str[n:]
+			if r == '\n' {
+				newLine(s)
+				word++
+				continue
+			}
+			if prevSpan != nil && prevSpan.Font == s.Font && prevSpan.Size == s.Size {
+				x += s.Font.Kern(prev, r, s.Size)
+			}
+			g, err := s.Font.Glyph(r, s.Size)
+			if err != nil {
+				return nil, err
+			}
+			adv := s.Font.Advance(r, s.Size)
+			space := unicode.IsSpace(r)
+			if space {
+				word++
+			}
+			// wrap, carrying the word being broken to the next line
+			if o.Width > 0 && !space && x+adv > o.Width && len(cur.glyphs) > 0 {
+				carry := wordStart(cur.glyphs, word)
+				if carry == 0 {
+					carry = len(cur.glyphs)
+				}
+				moved := append([]placed{}, cur.glyphs[carry:]...)
+				cur.glyphs = trimSpace(cur.glyphs[:carry])
+				cur.width = lineWidth(cur.glyphs)
+				cur.measure()
+				lines = append(lines, cur)
+				cur, x, wrapped = &line{}, 0, true
+				if len(moved) > 0 {
+					shift := moved[0].x
+					for _, p := range moved {
+						p.x -= shift
+						cur.glyphs = append(cur.glyphs, p)
+					}
+					last := moved[len(moved)-1]
+					x = last.x + last.s.Font.Advance(last.r, last.s.Size)
+					cur.measure()
+				}
+			}
+			if space && len(cur.glyphs) == 0 && wrapped {
+				// no leading space on wrapped lines
+				prev, prevSpan = r, s
+				continue
+			}
+			cur.glyphs = append(cur.glyphs, placed{r, s, x, g, word})
+			x += adv
+			cur.width = x
+			if asc > cur.ascent {
+				cur.ascent = asc
+			}
+			if desc > cur.descent {
+				cur.descent = desc
+			}
+			if h > cur.height {
+				cur.height = h
+			}
+			prev, prevSpan = r, s
+		}
+	}
+	if len(cur.glyphs) > 0 || len(lines) == 0 {
+		if len(cur.glyphs) == 0 && len(spans) > 0 && spans[len(spans)-1].Font != nil {
+			s := &spans[len(spans)-1]
+			cur.ascent, cur.descent, cur.height = s.Font.Metrics(s.Size)
+		}
+		lines = append(lines, cur)
+	}
+
+	ret := &Layout{Quads: make([]Quad, 0), Lines: len(lines)}
+	for _, ln := range lines {
+		ln.glyphs = trimSpace(ln.glyphs)
+		ln.width = lineWidth(ln.glyphs)
+		if ln.width > ret.Width {
+			ret.Width = ln.width
+		}
+	}
+	block := ret.Width
+	if o.Width > 0 {
+		block = o.Width
+	}
+	var y float32
+	for i, ln := range lines {
+		var ox float32
+		switch o.Align {
+		case CENTER:
+			ox = (block - ln.width) / 2
+		case RIGHT:
+			ox = block - ln.width
+		}
+		base := y + ln.ascent
+		for _, p := range ln.glyphs {
+			if p.g.W == 0 || p.g.H == 0 {
+				continue
+			}
+			a := p.s.Font.Atlas()
+			scale := p.s.Size / p.g.Size
+			x0 := ox + p.x + p.g.Left*scale
+			y0 := base + p.g.Top*scale
+			ret.Quads = append(ret.Quads, Quad{
+				Font:  p.s.Font,
+				X0:    x0,
+				Y0:    y0,
+				X1:    x0 + float32(p.g.W)*scale,
+				Y1:    y0 + float32(p.g.H)*scale,
+				U0:    float32(p.g.X) / float32(a.Width),
+				V0:    float32(p.g.Y) / float32(a.Height),
+				U1:    float32(p.g.X+p.g.W) / float32(a.Width),
+				V1:    float32(p.g.Y+p.g.H) / float32(a.Height),
+				Color: p.s.Color,
+			})
+		}
+		if i < len(lines)-1 {
+			y += ln.height * o.LineHeight
+		} else {
+			y += ln.ascent + ln.descent
+		}
+	}
+	ret.Height = y
+	if o.Width > 0 {
+		ret.Width = o.Width
+	}
+	return ret, nil
+}
+
+// measure sets a line's metrics from the spans of its glyphs.
+func (ln *line) measure() {
+	ln.ascent, ln.descent, ln.height = 0, 0, 0
+	for _, p := range ln.glyphs {
+		a, d, h := p.s.Font.Metrics(p.s.Size)
+		if a > ln.ascent {
+			ln.ascent = a
+		}
+		if d > ln.descent {
+			ln.descent = d
+		}
+		if h > ln.height {
+			ln.height = h
+		}
+	}
+}
+
+// wordStart is the index of the first glyph of the word being laid out.
+func wordStart(gs []placed, word int) int {
+	i := len(gs)
+	for i > 0 && gs[i-1].word == word && !unicode.IsSpace(gs[i-1].r) {
+		i--
+	}
+	return i
+}
+
+func trimSpace(gs []placed) []placed {
+	for len(gs) > 0 && unicode.IsSpace(gs[len(gs)-1].r) {
+		gs = gs[:len(gs)-1]
+	}
+	return gs
+}
+
+func lineWidth(gs []placed) float32 {
+	if len(gs) == 0 {
+		return 0
+	}
+	last := gs[len(gs)-1]
+	return last.x + last.s.Font.Advance(last.r, last.s.Size)
+}
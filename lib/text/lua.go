@@ -0,0 +1,123 @@
+package text
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+const lFontClass = "FONT"
+
+func PushFont(L *l.LState, f *Font) int {
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = f }, lFontClass)
+	return 1
+}
+
+// shv.font("fonts/sans.ttf", {mode = "sdf"})
+func lFont(L *l.LState) int {
+	path := L.CheckString(1)
+	mode := SDF
+	if t, ok := L.Get(2).(*l.LTable); ok {
+		if s, ok := t.RawGetString("mode").(l.LString); ok {
+			m, ok := StringToMode(string(s))
+			if !ok {
+				L.ArgError(2, "mode must be sdf or bitmap")
+				return 0
+			}
+			mode = m
+		}
+	}
+	f, err := Cached(path, mode)
+	if err != nil {
+		L.RaiseError(err.Error())
+		return 0
+	}
+	return PushFont(L, f)
+}
+
+func CheckFont(L *l.LState, pos int) *Font {
+	ud := L.CheckUserData(pos)
+	if f, ok := ud.Value.(*Font); ok {
+		return f
+	}
+	L.ArgError(pos, "font expected")
+	return nil
+}
+
+type fontMemberFunc func(*l.LState, *Font) int
+
+func fontMember(fn fontMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if f := CheckFont(L, 1); f != nil {
+			return fn(L, f)
+		}
+		return 0
+	}
+}
+
+func fontProperty(get fontMemberFunc) l.LGFunction {
+	return lua.NewProperty(fontMember(get), nil)
+}
+
+func getFontName(L *l.LState, f *Font) int {
+	L.Push(l.LString(f.Name))
+	return 1
+}
+
+func getFontMode(L *l.LState, f *Font) int {
+	L.Push(l.LString(f.Mode().String()))
+	return 1
+}
+
+// font:measure("text", size, width) is the width, height and line count the
+// text lays out to.
+func fontMeasure(L *l.LState, f *Font) int {
+	lo, err := Lay(
+		[]Span{{Text: L.CheckString(2), Font: f, Size: float32(L.CheckNumber(3))}},
+		Options{Width: float32(L.OptNumber(4, 0))},
+	)
+	if err != nil {
+		L.RaiseError(err.Error())
+		return 0
+	}
+	L.Push(l.LNumber(lo.Width))
+	L.Push(l.LNumber(lo.Height))
+	L.Push(l.LNumber(lo.Lines))
+	return 3
+}
+
+// font:metrics(size) is the ascent, descent and line height at a size.
+func fontMetrics(L *l.LState, f *Font) int {
+	a, d, h := f.Metrics(float32(L.CheckNumber(2)))
+	L.Push(l.LNumber(a))
+	L.Push(l.LNumber(d))
+	L.Push(l.LNumber(h))
+	return 3
+}
+
+var fontTable = &lua.Table{
+	lFontClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+	},
+	map[string]l.LGFunction{
+		"name": fontProperty(getFontName),
+		"mode": fontProperty(getFontMode),
+	},
+	map[string]l.LGFunction{
+		"measure": fontMember(fontMeasure),
+		"metrics": fontMember(fontMetrics),
+	},
+}
+
+func RegisterWith() lua.RegisterWith {
+	return func(m lua.Module) error {
+		m.AddLGFunc("font", lFont)
+		rmtfn := func(L *l.LState, M lua.Module) {
+			M.Register(L, fontTable)
+		}
+		m.AddMT(rmtfn)
+		return nil
+	}
+}
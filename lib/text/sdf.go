@@ -0,0 +1,44 @@
+package text
+
+import (
+	glm "math"
+)
+
+// sdf turns a w by h coverage bitmap into a signed distance field padded by
+// spread on each side, 128 on the outline and rising inside, distances past
+// spread clamped.
+func sdf(w, h int, cov []byte, spread int) (int, int, []byte) {
+	sw, sh := w+2*spread, h+2*spread
+	inside := func(x, y int) bool {
+		x, y = x-spread, y-spread
+		if x < 0 || y < 0 || x >= w || y >= h {
+			return false
+		}
+		return cov[y*w+x] >= 128
+	}
+	out := make([]byte, sw*sh)
+	max := float64(spread)
+	for y := 0; y < sh; y++ {
+		for x := 0; x < sw; x++ {
+			in := inside(x, y)
+			// the nearest texel on the other side of the outline
+			d := max
+			for dy := -spread; dy <= spread; dy++ {
+				for dx := -spread; dx <= spread; dx++ {
+					if inside(x+dx, y+dy) == in {
+						continue
+					}
+					if dd := glm.Sqrt(float64(dx*dx+dy*dy)) - 0.5; dd < d {
+						d = dd
+					}
+				}
+			}
+			if !in {
+				d = -d
+			}
+			v := 128 + d/max*127
+			out[y*sw+x] = byte(glm.Max(0, glm.Min(255, glm.Round(v))))
+		}
+	}
+	return sw, sh, out
+}
@@ -3,6 +3,7 @@ package tread
 import (
 	"errors"
 	"runtime"
+	"sync"
 )
 
 var CallQueueCap = 16
@@ -64,6 +65,31 @@ func CallErr(f func() error) error {
 	return nil
 }
 
+var (
+	postMu sync.Mutex
+	posted []func()
+)
+
+// Post queues f to run on the main thread at its next Pump, from any
+// goroutine, without waiting for it.
+func Post(f func()) {
+	postMu.Lock()
+	posted = append(posted, f)
+	postMu.Unlock()
+}
+
+// Pump runs, in order, everything posted before it was called. The engine
+// pumps once a frame on the main thread.
+func Pump() {
+	postMu.Lock()
+	fns := posted
+	posted = nil
+	postMu.Unlock()
+	for _, f := range fns {
+		f()
+	}
+}
+
 func init() {
 	runtime.LockOSThread()
 }
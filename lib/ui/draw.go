@@ -0,0 +1,303 @@
+package ui
+
+import (
+	glm "math"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/texture"
+	"github.com/Laughs-In-Flowers/shiva/lib/gui"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/text"
+)
+
+// run is triangles drawn with one shader and texture, runs drawn in order
+// so widgets overlap as the tree does.
+type run struct {
+	shader string
+	tex    texture.Texture
+	verts  math.AF32
+}
+
+type drawList struct {
+	runs []*run
+	n    int
+	clip Rect
+}
+
+func (d *drawList) reset() {
+	for _, r := range d.runs {
+		r.verts = r.verts[:0]
+	}
+	d.n = 0
+}
+
+// to is the run to add to for a shader and texture, the last if the same.
+func (d *drawList) to(shader string, tex texture.Texture) *run {
+	if d.n > 0 {
+		if r := d.runs[d.n-1]; r.shader == shader && r.tex == tex {
+			return r
+		}
+	}
+	if d.n == len(d.runs) {
+		d.runs = append(d.runs, &run{verts: make(math.AF32, 0)})
+	}
+	r := d.runs[d.n]
+	r.shader, r.tex = shader, tex
+	d.n++
+	return r
+}
+
+// quad adds a textured rectangle, clipped with its texture coordinates.
+func (d *drawList) quad(shader string, tex texture.Texture, r Rect, u0, v0, u1, v1 float32, c Color) {
+	cr := r.Intersect(d.clip)
+	if cr.Empty() {
+		return
+	}
+	if cr != r {
+		du, dv := (u1-u0)/r.W, (v1-v0)/r.H
+		u0, u1 = u0+(cr.X-r.X)*du, u0+(cr.X+cr.W-r.X)*du
+		v0, v1 = v0+(cr.Y-r.Y)*dv, v0+(cr.Y+cr.H-r.Y)*dv
+	}
+	x0, y0, x1, y1 := cr.X, cr.Y, cr.X+cr.W, cr.Y+cr.H
+	rn := d.to(shader, tex)
+	rn.verts = append(rn.verts,
+		x0, y0, 0, u0, v0, c[0], c[1], c[2], c[3],
+		x0, y1, 0, u0, v1, c[0], c[1], c[2], c[3],
+		x1, y1, 0, u1, v1, c[0], c[1], c[2], c[3],
+		x0, y0, 0, u0, v0, c[0], c[1], c[2], c[3],
+		x1, y1, 0, u1, v1, c[0], c[1], c[2], c[3],
+		x1, y0, 0, u1, v0, c[0], c[1], c[2], c[3],
+	)
+}
+
+func (d *drawList) rect(r Rect, c Color) {
+	if c[3] <= 0 {
+		return
+	}
+	u, v := gui.WhiteUV()
+	d.quad("text", gui.Atlas(), r, u, v, u, v, c)
+}
+
+// border draws the outline of a rectangle, w wide inside it.
+func (d *drawList) border(r Rect, w float32, c Color) {
+	if w <= 0 || c[3] <= 0 {
+		return
+	}
+	d.rect(Rect{r.X, r.Y, r.W, w}, c)
+	d.rect(Rect{r.X, r.Y + r.H - w, r.W, w}, c)
+	d.rect(Rect{r.X, r.Y + w, w, r.H - 2*w}, c)
+	d.rect(Rect{r.X + r.W - w, r.Y + w, w, r.H - 2*w}, c)
+}
+
+// bitmapScale is how many pixels a texel of the built in font takes at a
+// text size.
+func bitmapScale(size float32) float32 {
+	return max32(1, float32(glm.Floor(float64(size/gui.GlyphSize)+0.5)))
+}
+
+func (u *UI) lineHeight(s *Style) float32 {
+	if s.Font == nil {
+		return gui.GlyphSize * bitmapScale(s.Size)
+	}
+	_, _, h := s.Font.Metrics(s.Size)
+	return h
+}
+
+// textSize is the width and height a line of text draws at.
+func (u *UI) textSize(s *Style, str string) (float32, float32) {
+	if str == "" {
+		return 0, u.lineHeight(s)
+	}
+	if s.Font == nil {
+		n := 0
+		for range str {
+			n++
+		}
+		g := gui.GlyphSize * bitmapScale(s.Size)
+		return float32(n) * g, g
+	}
+	lo, err := text.Lay([]text.Span{{Text: str, Font: s.Font, Size: s.Size}}, text.Options{})
+	if err != nil {
+		return 0, u.lineHeight(s)
+	}
+	return lo.Width, max32(lo.Height, u.lineHeight(s))
+}
+
+// text draws a line of text from its top left.
+func (d *drawList) text(s *Style, x, y float32, str string, c Color) {
+	if str == "" {
+		return
+	}
+	if s.Font == nil {
+		g := gui.GlyphSize * bitmapScale(s.Size)
+		for _, r := range str {
+			if r != ' ' {
+				u0, v0, u1, v1 := gui.GlyphUV(r)
+				d.quad("text", gui.Atlas(), Rect{x, y, g, g}, u0, v0, u1, v1, c)
+			}
+			x += g
+		}
+		return
+	}
+	lo, err := text.Lay([]text.Span{{Text: str, Font: s.Font, Size: s.Size, Color: c}}, text.Options{})
+	if err != nil {
+		return
+	}
+	shader := "text"
+	if s.Font.Mode() == text.SDF {
+		shader = "textsdf"
+	}
+	tex := s.Font.Atlas().Texture()
+	for _, q := range lo.Quads {
+		d.quad(shader, tex, Rect{x + q.X0, y + q.Y0, q.X1 - q.X0, q.Y1 - q.Y0}, q.U0, q.V0, q.U1, q.V1, c)
+	}
+}
+
+// draw adds a widget and those under it, popups of open dropdowns put off
+// until last to draw over everything.
+func (u *UI) draw(w *Widget, popups *[]*Widget) {
+	if w.hidden || w.clip.Empty() {
+		return
+	}
+	d := u.d
+	d.clip = w.clip
+	s := u.style(w)
+	p := u.padding(w, &s)
+	r := w.rect
+	lh := u.lineHeight(&s)
+	d.rect(r, s.Background)
+	switch w.kind {
+	case LABEL:
+		_, th := u.textSize(&s, w.text)
+		d.text(&s, r.X+p, r.Y+(r.H-th)/2, w.text, s.Color)
+	case BUTTON:
+		tw, th := u.textSize(&s, w.text)
+		d.text(&s, r.X+(r.W-tw)/2, r.Y+(r.H-th)/2, w.text, s.Color)
+	case CHECKBOX:
+		box := Rect{r.X, r.Y + (r.H-lh)/2, lh, lh}
+		d.rect(box, Color{0, 0, 0, 0.5})
+		d.border(box, max32(1, s.BorderWidth), s.Border)
+		if w.checked {
+			in := lh / 4
+			d.rect(Rect{box.X + in, box.Y + in, lh - 2*in, lh - 2*in}, s.Accent)
+		}
+		_, th := u.textSize(&s, w.text)
+		d.text(&s, r.X+lh+p, r.Y+(r.H-th)/2, w.text, s.Color)
+	case SLIDER:
+		track := Rect{r.X + p, r.Y + p, r.W - 2*p, r.H - 2*p}
+		t := float32(0)
+		if w.max != w.min {
+			t = clamp32((w.value-w.min)/(w.max-w.min), 0, 1)
+		}
+		d.rect(Rect{track.X, track.Y, track.W * t, track.H}, s.Accent)
+		knob := lh / 2
+		d.rect(Rect{track.X + track.W*t - knob/2, r.Y, knob, r.H}, s.Color)
+	case FIELD:
+		str, col := w.text, s.Color
+		if str == "" && !w.Focused() {
+			str, col = w.hint, Color{s.Color[0], s.Color[1], s.Color[2], s.Color[3] * 0.5}
+		}
+		d.clip = Rect{r.X + p, r.Y, r.W - 2*p, r.H}.Intersect(w.clip)
+		_, th := u.textSize(&s, str)
+		runes := []rune(w.text)
+		cx, _ := u.textSize(&s, string(runes[:minInt(w.caret, len(runes))]))
+		off := max32(0, cx-(r.W-2*p)+2)
+		d.text(&s, r.X+p-off, r.Y+(r.H-th)/2, str, col)
+		if w.Focused() && u.blink() {
+			d.rect(Rect{r.X + p + cx - off, r.Y + p, max32(1, s.Size/12), r.H - 2*p}, s.Color)
+		}
+		d.clip = w.clip
+	case DROPDOWN:
+		if w.selected >= 0 && w.selected < len(w.items) {
+			_, th := u.textSize(&s, w.items[w.selected])
+			d.text(&s, r.X+p, r.Y+(r.H-th)/2, w.items[w.selected], s.Color)
+		}
+		aw, th := u.textSize(&s, "v")
+		d.text(&s, r.X+r.W-p-aw, r.Y+(r.H-th)/2, "v", s.Color)
+		if w.open {
+			*popups = append(*popups, w)
+		}
+	case LIST:
+		d.clip = Rect{r.X, r.Y + p, r.W, r.H - 2*p}.Intersect(w.clip)
+		for i, it := range w.items {
+			row := Rect{r.X, r.Y + p + float32(i)*lh - w.scroll, r.W, lh}
+			switch {
+			case i == w.selected:
+				d.rect(row, s.Accent)
+			case i == w.hover:
+				d.rect(row, Color{1, 1, 1, 0.08})
+			}
+			_, th := u.textSize(&s, it)
+			d.text(&s, row.X+p, row.Y+(lh-th)/2, it, s.Color)
+		}
+		d.clip = w.clip
+		u.scrollbar(w, Rect{r.X, r.Y + p, r.W, r.H - 2*p}, w.content, &s)
+	case IMAGE:
+		if w.image != nil {
+			d.quad("image", w.image, r, 0, 0, 1, 1, Color{1, 1, 1, 1})
+		}
+	case PANEL, SCROLL:
+		for _, c := range w.children {
+			u.draw(c, popups)
+		}
+		d.clip = w.clip
+		if w.kind == SCROLL {
+			inner := Rect{r.X + p, r.Y + p, r.W - 2*p, r.H - 2*p}
+			u.scrollbar(w, inner, w.content, &s)
+		}
+	}
+	d.clip = w.clip
+	d.border(r, s.BorderWidth, s.Border)
+}
+
+// scrollbar draws where a view is in content taller than it.
+func (u *UI) scrollbar(w *Widget, view Rect, content float32, s *Style) {
+	if content <= view.H || view.H <= 0 {
+		return
+	}
+	bw := max32(2, s.Padding/2)
+	h := view.H * view.H / content
+	y := view.Y + (view.H-h)*w.scroll/(content-view.H)
+	u.d.rect(Rect{view.X + view.W - bw, y, bw, h}, Color{s.Color[0], s.Color[1], s.Color[2], 0.4})
+}
+
+// popup is the rectangle the items of an open dropdown drop into.
+func (u *UI) popup(w *Widget) Rect {
+	s := u.style(w)
+	lh := u.lineHeight(&s)
+	p := u.padding(w, &s)
+	h := float32(len(w.items))*lh + 2*p
+	y := w.rect.Y + w.rect.H
+	if y+h > u.height && w.rect.Y-h >= 0 {
+		y = w.rect.Y - h
+	}
+	return Rect{w.rect.X, y, w.rect.W, h}
+}
+
+func (u *UI) drawPopup(w *Widget) {
+	d := u.d
+	s := u.style(w)
+	lh := u.lineHeight(&s)
+	p := u.padding(w, &s)
+	r := u.popup(w)
+	d.clip = Rect{0, 0, u.width, u.height}
+	d.rect(r, s.Background)
+	for i, it := range w.items {
+		row := Rect{r.X, r.Y + p + float32(i)*lh, r.W, lh}
+		switch {
+		case i == w.hover:
+			d.rect(row, s.Accent)
+		case i == w.selected:
+			d.rect(row, Color{1, 1, 1, 0.08})
+		}
+		_, th := u.textSize(&s, it)
+		d.text(&s, row.X+p, row.Y+(lh-th)/2, it, s.Color)
+	}
+	d.border(r, max32(1, s.BorderWidth), s.Border)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
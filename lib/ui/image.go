@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/texture"
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
+)
+
+// LoadImage decodes a png or jpeg file into a texture, with its size.
+func LoadImage(path string) (*texture.Data, int, int, error) {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer f.Close()
+	pix, w, h, err := texture.DecodeRGBA(f)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	t := texture.NewData(graphics.RGBA)
+	t.Set(w, h, pix)
+	return t, w, h, nil
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// edit is a character typed or a key pressed, in the order they came.
+type edit struct {
+	r    rune
+	k    glfw.Key
+	mods glfw.ModifierKey
+}
+
+// io collects the input events arriving between frames.
+type io struct {
+	mouse             [2]float32
+	down              bool
+	tap, lift         bool
+	pressed, released bool
+	scroll, wheel     float32
+	edits, typed      []edit
+}
+
+func newIO() *io {
+	return &io{
+		edits: make([]edit, 0),
+		typed: make([]edit, 0),
+	}
+}
+
+func (o *io) CPEvent(x, y float64) {
+	o.mouse = [2]float32{float32(x), float32(y)}
+}
+
+func (o *io) MBEvent(b glfw.MouseButton, a glfw.Action, m glfw.ModifierKey) {
+	if b != glfw.MouseButtonLeft {
+		return
+	}
+	switch a {
+	case glfw.Press:
+		o.down, o.tap = true, true
+	case glfw.Release:
+		o.down, o.lift = false, true
+	}
+}
+
+func (o *io) SEvent(x, y float64) {
+	o.scroll += float32(y)
+}
+
+func (o *io) CIEvent(r rune) {
+	o.edits = append(o.edits, edit{r: r})
+}
+
+func (o *io) KEvent(k glfw.Key, s int, a glfw.Action, m glfw.ModifierKey) {
+	if a == glfw.Press || a == glfw.Repeat {
+		o.edits = append(o.edits, edit{k: k, mods: m})
+	}
+}
+
+// frame moves what arrived since the last frame into this one.
+func (o *io) frame() {
+	o.pressed, o.released = o.tap, o.lift
+	o.tap, o.lift = false, false
+	o.wheel, o.scroll = o.scroll, 0
+	o.typed = append(o.typed[:0], o.edits...)
+	o.edits = o.edits[:0]
+}
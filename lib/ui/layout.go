@@ -0,0 +1,323 @@
+package ui
+
+import (
+	"strings"
+)
+
+// Direction is the axis children are laid out along.
+type Direction int
+
+const (
+	COLUMN Direction = iota
+	ROW
+)
+
+func (d Direction) String() string {
+	switch d {
+	case COLUMN:
+		return "column"
+	case ROW:
+		return "row"
+	}
+	return "UNKNOWN_DIRECTION"
+}
+
+func StringToDirection(s string) (Direction, bool) {
+	switch strings.ToLower(s) {
+	case "column":
+		return COLUMN, true
+	case "row":
+		return ROW, true
+	}
+	return COLUMN, false
+}
+
+// Align places children along an axis: justified along the direction, any
+// space left before, around or between them, and aligned across it, or
+// stretched to fill it.
+type Align int
+
+const (
+	START Align = iota
+	CENTER
+	END
+	BETWEEN
+	STRETCH
+)
+
+var alignNames = []string{"start", "center", "end", "between", "stretch"}
+
+func (a Align) String() string {
+	if a >= 0 && int(a) < len(alignNames) {
+		return alignNames[a]
+	}
+	return "UNKNOWN_ALIGN"
+}
+
+func StringToAlign(s string) (Align, bool) {
+	s = strings.ToLower(s)
+	for i, n := range alignNames {
+		if n == s {
+			return Align(i), true
+		}
+	}
+	return START, false
+}
+
+// Anchor is where a widget at the top of the tree sits in the display.
+type Anchor int
+
+const (
+	TOP_LEFT Anchor = iota
+	TOP
+	TOP_RIGHT
+	LEFT
+	MIDDLE
+	RIGHT
+	BOTTOM_LEFT
+	BOTTOM
+	BOTTOM_RIGHT
+	FILL
+)
+
+var anchorNames = []string{
+	"top_left",
+	"top",
+	"top_right",
+	"left",
+	"center",
+	"right",
+	"bottom_left",
+	"bottom",
+	"bottom_right",
+	"fill",
+}
+
+func (a Anchor) String() string {
+	if a >= 0 && int(a) < len(anchorNames) {
+		return anchorNames[a]
+	}
+	return "UNKNOWN_ANCHOR"
+}
+
+func StringToAnchor(s string) (Anchor, bool) {
+	s = strings.ToLower(s)
+	for i, n := range anchorNames {
+		if n == s {
+			return Anchor(i), true
+		}
+	}
+	return TOP_LEFT, false
+}
+
+// Layout is how a widget sizes and places itself and its children, after
+// flexbox: Width and Height fix a size, 0 sizing to content; Grow shares
+// space left along the parent's direction; Padding, when not negative,
+// overrides that of the style; Anchor, X and Y place widgets at the top of
+// the tree in the display.
+type Layout struct {
+	Direction     Direction
+	Justify       Align
+	Align         Align
+	Gap           float32
+	Padding       float32
+	Width, Height float32
+	Grow          float32
+	Anchor        Anchor
+	X, Y          float32
+}
+
+func DefaultLayout() Layout {
+	return Layout{
+		Direction: COLUMN,
+		Justify:   START,
+		Align:     STRETCH,
+		Gap:       6,
+		Padding:   -1,
+	}
+}
+
+// axes splits a width and height into main and cross sizes for a
+// direction, or back.
+func axes(d Direction, a, b float32) (float32, float32) {
+	if d == ROW {
+		return a, b
+	}
+	return b, a
+}
+
+func (u *UI) padding(w *Widget, s *Style) float32 {
+	if w.Layout.Padding >= 0 {
+		return w.Layout.Padding
+	}
+	return s.Padding
+}
+
+// measure is the size a widget wants, its fixed size or that of its content,
+// kept for arrange.
+func (u *UI) measure(w *Widget) (float32, float32) {
+	s := u.style(w)
+	p := u.padding(w, &s)
+	lh := u.lineHeight(&s)
+	var pw, ph float32
+	switch w.kind {
+	case LABEL, BUTTON:
+		tw, th := u.textSize(&s, w.text)
+		pw, ph = tw+2*p, max32(th, lh)+2*p
+	case CHECKBOX:
+		tw, _ := u.textSize(&s, w.text)
+		pw, ph = lh+p+tw, lh
+		if w.text == "" {
+			pw = lh
+		}
+	case SLIDER:
+		pw, ph = 10*lh+2*p, lh+2*p
+	case FIELD:
+		pw, ph = 12*lh+2*p, lh+2*p
+	case DROPDOWN:
+		var iw float32
+		for _, it := range w.items {
+			tw, _ := u.textSize(&s, it)
+			iw = max32(iw, tw)
+		}
+		pw, ph = iw+lh+3*p, lh+2*p
+	case IMAGE:
+		pw, ph = float32(w.imageW), float32(w.imageH)
+	case LIST:
+		var iw float32
+		for _, it := range w.items {
+			tw, _ := u.textSize(&s, it)
+			iw = max32(iw, tw)
+		}
+		pw, ph = iw+2*p, float32(len(w.items))*lh+2*p
+	default:
+		var main, cross float32
+		n := 0
+		for _, c := range w.children {
+			if c.hidden {
+				continue
+			}
+			cw, ch := u.measure(c)
+			m, x := axes(w.Layout.Direction, cw, ch)
+			main += m
+			cross = max32(cross, x)
+			n++
+		}
+		if n > 1 {
+			main += w.Layout.Gap * float32(n-1)
+		}
+		pw, ph = axes(w.Layout.Direction, main, cross)
+		pw, ph = pw+2*p, ph+2*p
+	}
+	if w.Layout.Width > 0 {
+		pw = w.Layout.Width
+	}
+	if w.Layout.Height > 0 {
+		ph = w.Layout.Height
+	}
+	w.pref = [2]float32{pw, ph}
+	return pw, ph
+}
+
+// arrange places a measured widget in r and its children within it, clipped
+// to clip.
+func (u *UI) arrange(w *Widget, r Rect, clip Rect) {
+	w.rect, w.clip = r, r.Intersect(clip)
+	s := u.style(w)
+	p := u.padding(w, &s)
+	if w.kind == LIST {
+		w.content = float32(len(w.items)) * u.lineHeight(&s)
+		w.scroll = clamp32(w.scroll, 0, max32(0, w.content-r.H+2*p))
+	}
+	if w.kind != PANEL && w.kind != SCROLL {
+		return
+	}
+	inner := Rect{r.X + p, r.Y + p, max32(r.W-2*p, 0), max32(r.H-2*p, 0)}
+	cclip := inner.Intersect(w.clip)
+	d := w.Layout.Direction
+	shown := make([]*Widget, 0, len(w.children))
+	var total, grow float32
+	for _, c := range w.children {
+		if c.hidden {
+			continue
+		}
+		m, _ := axes(d, c.pref[0], c.pref[1])
+		total += m
+		grow += c.Layout.Grow
+		shown = append(shown, c)
+	}
+	if len(shown) > 1 {
+		total += w.Layout.Gap * float32(len(shown)-1)
+	}
+	innerMain, innerCross := axes(d, inner.W, inner.H)
+	free := innerMain - total
+	if w.kind == SCROLL {
+		w.content = total
+		w.scroll = clamp32(w.scroll, 0, max32(0, total-innerMain))
+		free = 0
+	}
+	pos, gap := float32(0), w.Layout.Gap
+	switch {
+	case free > 0 && grow > 0:
+	case w.Layout.Justify == CENTER:
+		pos = free / 2
+	case w.Layout.Justify == END:
+		pos = free
+	case w.Layout.Justify == BETWEEN && len(shown) > 1 && free > 0:
+		gap += free / float32(len(shown)-1)
+	}
+	pos -= w.scroll
+	for _, c := range shown {
+		m, x := axes(d, c.pref[0], c.pref[1])
+		if free > 0 && grow > 0 {
+			m += free * c.Layout.Grow / grow
+		}
+		fixedCross := c.Layout.Height
+		if d == COLUMN {
+			fixedCross = c.Layout.Width
+		}
+		var off float32
+		switch w.Layout.Align {
+		case STRETCH:
+			if fixedCross <= 0 {
+				x = innerCross
+			}
+		case CENTER:
+			off = (innerCross - x) / 2
+		case END:
+			off = innerCross - x
+		}
+		var cr Rect
+		if d == ROW {
+			cr = Rect{inner.X + pos, inner.Y + off, m, x}
+		} else {
+			cr = Rect{inner.X + off, inner.Y + pos, x, m}
+		}
+		u.arrange(c, cr, cclip)
+		pos += m + gap
+	}
+}
+
+// place is where a widget at the top of the tree sits in a display sized
+// width by height, X and Y moving it in from the edges it is anchored to.
+func place(w *Widget, width, height float32) Rect {
+	l := w.Layout
+	pw, ph := w.pref[0], w.pref[1]
+	if l.Anchor == FILL {
+		return Rect{l.X, l.Y, width - 2*l.X, height - 2*l.Y}
+	}
+	x, y := l.X, l.Y
+	switch l.Anchor {
+	case TOP, MIDDLE, BOTTOM:
+		x = (width-pw)/2 + l.X
+	case TOP_RIGHT, RIGHT, BOTTOM_RIGHT:
+		x = width - pw - l.X
+	}
+	switch l.Anchor {
+	case LEFT, MIDDLE, RIGHT:
+		y = (height-ph)/2 + l.Y
+	case BOTTOM_LEFT, BOTTOM, BOTTOM_RIGHT:
+		y = height - ph - l.Y
+	}
+	return Rect{x, y, pw, ph}
+}
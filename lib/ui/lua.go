@@ -0,0 +1,660 @@
+package ui
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/lua"
+	"github.com/Laughs-In-Flowers/shiva/lib/text"
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+
+	l "github.com/yuin/gopher-lua"
+)
+
+const lWidgetClass = "WIDGET"
+
+func PushWidget(L *l.LState, w *Widget) int {
+	if w == nil {
+		L.Push(l.LNil)
+		return 1
+	}
+	lua.PushNewUserData(L, func(u *l.LUserData) { u.Value = w }, lWidgetClass)
+	return 1
+}
+
+func CheckWidget(L *l.LState, pos int) *Widget {
+	ud := L.CheckUserData(pos)
+	if w, ok := ud.Value.(*Widget); ok {
+		return w
+	}
+	L.ArgError(pos, "widget expected")
+	return nil
+}
+
+var (
+	UnknownKindError  = xrror.Xrror("unknown widget type %s").Out
+	UnknownValueError = xrror.Xrror("unknown %s %s").Out
+	BadColorError     = xrror.Xrror("bad color %s").Out
+	StyleFileError    = xrror.Xrror("style file %s did not return a table").Out
+	BadStyleError     = xrror.Xrror("style for %s is not a table").Out
+)
+
+// handler calls a Lua function with the widget and the value of the event.
+func handler(L *l.LState, fn *l.LFunction) Handler {
+	return func(w *Widget, e Event) error {
+		PushWidget(L, w)
+		ud := L.Get(-1)
+		L.Pop(1)
+		return L.CallByParam(l.P{
+			Fn:      fn,
+			NRet:    0,
+			Protect: true,
+		}, ud, toLValue(e.Value))
+	}
+}
+
+func toLValue(v interface{}) l.LValue {
+	switch t := v.(type) {
+	case bool:
+		return l.LBool(t)
+	case int:
+		if t < 0 {
+			return l.LNil
+		}
+		return l.LNumber(t + 1)
+	case float32:
+		return l.LNumber(t)
+	case string:
+		return l.LString(t)
+	}
+	return l.LNil
+}
+
+var eventKeys = map[string]string{
+	"on_click":  "click",
+	"on_change": "change",
+	"on_submit": "submit",
+	"on_select": "select",
+	"on_focus":  "focus",
+	"on_blur":   "blur",
+	"on_cancel": "cancel",
+}
+
+func tNumber(t *l.LTable, k string) (float32, bool) {
+	if n, ok := t.RawGetString(k).(l.LNumber); ok {
+		return float32(n), true
+	}
+	return 0, false
+}
+
+func tString(t *l.LTable, k string) (string, bool) {
+	if s, ok := t.RawGetString(k).(l.LString); ok {
+		return string(s), true
+	}
+	return "", false
+}
+
+func tStrings(t *l.LTable) []string {
+	ret := make([]string, 0, t.Len())
+	for i := 1; i <= t.Len(); i++ {
+		ret = append(ret, t.RawGetInt(i).String())
+	}
+	return ret
+}
+
+// build makes a widget and its children from a table:
+//
+//	{type = "panel", id = "menu", class = "dark", anchor = "center",
+//	 direction = "column", gap = 8, children = {
+//	   {type = "label", text = "Volume"},
+//	   {type = "slider", min = 0, max = 100, value = 50,
+//	    on_change = function(w, v) ... end},
+//	 }}
+func build(L *l.LState, t *l.LTable) (*Widget, error) {
+	k := PANEL
+	if s, ok := tString(t, "type"); ok {
+		var known bool
+		if k, known = StringToKind(s); !known {
+			return nil, UnknownKindError(s)
+		}
+	}
+	w := New(k)
+	w.ID, _ = tString(t, "id")
+	w.Class, _ = tString(t, "class")
+	if s, ok := tString(t, "text"); ok {
+		w.text = s
+	}
+	if s, ok := tString(t, "hint"); ok {
+		w.hint = s
+	}
+	if n, ok := tNumber(t, "min"); ok {
+		w.min = n
+	}
+	if n, ok := tNumber(t, "max"); ok {
+		w.max = n
+	}
+	if n, ok := tNumber(t, "step"); ok {
+		w.step = n
+	}
+	if n, ok := tNumber(t, "value"); ok {
+		w.SetValue(n)
+	}
+	if b, ok := t.RawGetString("checked").(l.LBool); ok {
+		w.checked = bool(b)
+	}
+	if it, ok := t.RawGetString("items").(*l.LTable); ok {
+		w.items = tStrings(it)
+	}
+	if n, ok := tNumber(t, "selected"); ok {
+		w.SetSelected(int(n) - 1)
+	}
+	if s, ok := tString(t, "image"); ok {
+		img, iw, ih, err := LoadImage(s)
+		if err != nil {
+			return nil, err
+		}
+		w.SetImage(img, iw, ih)
+	}
+	if b, ok := t.RawGetString("visible").(l.LBool); ok {
+		w.hidden = !bool(b)
+	}
+	if b, ok := t.RawGetString("enabled").(l.LBool); ok {
+		w.disabled = !bool(b)
+	}
+	if err := buildLayout(t, &w.Layout); err != nil {
+		return nil, err
+	}
+	for key, ev := range eventKeys {
+		if fn, ok := t.RawGetString(key).(*l.LFunction); ok {
+			w.On(ev, handler(L, fn))
+		}
+	}
+	if cs, ok := t.RawGetString("children").(*l.LTable); ok {
+		for i := 1; i <= cs.Len(); i++ {
+			ct, ok := cs.RawGetInt(i).(*l.LTable)
+			if !ok {
+				continue
+			}
+			c, err := build(L, ct)
+			if err != nil {
+				return nil, err
+			}
+			w.Add(c)
+		}
+	}
+	return w, nil
+}
+
+func buildLayout(t *l.LTable, ly *Layout) error {
+	enum := func(key string, fn func(string) bool) error {
+		if s, ok := tString(t, key); ok && !fn(s) {
+			return UnknownValueError(key, s)
+		}
+		return nil
+	}
+	for _, e := range []struct {
+		key string
+		fn  func(string) bool
+	}{
+		{"direction", func(s string) (ok bool) { ly.Direction, ok = StringToDirection(s); return }},
+		{"justify", func(s string) (ok bool) { ly.Justify, ok = StringToAlign(s); return }},
+		{"align", func(s string) (ok bool) { ly.Align, ok = StringToAlign(s); return }},
+		{"anchor", func(s string) (ok bool) { ly.Anchor, ok = StringToAnchor(s); return }},
+	} {
+		if err := enum(e.key, e.fn); err != nil {
+			return err
+		}
+	}
+	for _, f := range []struct {
+		key string
+		v   *float32
+	}{
+		{"gap", &ly.Gap},
+		{"padding", &ly.Padding},
+		{"width", &ly.Width},
+		{"height", &ly.Height},
+		{"grow", &ly.Grow},
+		{"x", &ly.X},
+		{"y", &ly.Y},
+	} {
+		if n, ok := tNumber(t, f.key); ok {
+			*f.v = n
+		}
+	}
+	return nil
+}
+
+// toColor reads a color as {r, g, b, a} or "#rrggbb" or "#rrggbbaa".
+func toColor(v l.LValue) (Color, error) {
+	switch t := v.(type) {
+	case *l.LTable:
+		c := Color{0, 0, 0, 1}
+		for i := 0; i < 4; i++ {
+			if n, ok := t.RawGetInt(i + 1).(l.LNumber); ok {
+				c[i] = float32(n)
+			}
+		}
+		return c, nil
+	case l.LString:
+		s := strings.TrimPrefix(string(t), "#")
+		if len(s) == 6 {
+			s += "ff"
+		}
+		n, err := strconv.ParseUint(s, 16, 32)
+		if len(s) != 8 || err != nil {
+			return Color{}, BadColorError(string(t))
+		}
+		return Color{
+			float32(n>>24&0xff) / 255,
+			float32(n>>16&0xff) / 255,
+			float32(n>>8&0xff) / 255,
+			float32(n&0xff) / 255,
+		}, nil
+	}
+	return Color{}, BadColorError(v.String())
+}
+
+// toStyle reads a style from a table of background, color, border,
+// border_width, padding, accent, font, font_mode and size.
+func toStyle(t *l.LTable) (*Style, error) {
+	s := &Style{}
+	for _, c := range []struct {
+		key string
+		set func(Color)
+	}{
+		{"background", s.SetBackground},
+		{"color", s.SetColor},
+		{"border", s.SetBorder},
+		{"accent", s.SetAccent},
+	} {
+		if v := t.RawGetString(c.key); v != l.LNil {
+			col, err := toColor(v)
+			if err != nil {
+				return nil, err
+			}
+			c.set(col)
+		}
+	}
+	if n, ok := tNumber(t, "border_width"); ok {
+		s.SetBorderWidth(n)
+	}
+	if n, ok := tNumber(t, "padding"); ok {
+		s.SetPadding(n)
+	}
+	if n, ok := tNumber(t, "size"); ok {
+		s.SetSize(n)
+	}
+	if path, ok := tString(t, "font"); ok {
+		mode := text.SDF
+		if m, ok := tString(t, "font_mode"); ok {
+			var known bool
+			if mode, known = text.StringToMode(m); !known {
+				return nil, UnknownValueError("font_mode", m)
+			}
+		}
+		f, err := text.Cached(path, mode)
+		if err != nil {
+			return nil, err
+		}
+		s.SetFont(f)
+	}
+	return s, nil
+}
+
+// toTheme reads a theme over the default one from a table of selector to
+// style.
+func toTheme(t *l.LTable) (*Theme, error) {
+	th := DefaultTheme()
+	var err error
+	t.ForEach(func(k, v l.LValue) {
+		if err != nil {
+			return
+		}
+		st, ok := v.(*l.LTable)
+		if !ok {
+			err = BadStyleError(k.String())
+			return
+		}
+		var s *Style
+		if s, err = toStyle(st); err == nil {
+			th.Set(k.String(), s)
+		}
+	})
+	return th, err
+}
+
+// shv.ui.build({type = "panel", ...}) builds widgets from a table, put at
+// the top of the tree and returned.
+func lBuild(L *l.LState) int {
+	w, err := build(L, L.CheckTable(1))
+	if err != nil {
+		L.RaiseError(err.Error())
+		return 0
+	}
+	CurrentUI.Add(w)
+	return PushWidget(L, w)
+}
+
+// shv.ui.style("ui/style.lua") or shv.ui.style({button = {...}}) themes
+// widgets from a file returning a table of selector to style, or the table.
+func lStyle(L *l.LState) int {
+	var t *l.LTable
+	switch v := L.Get(1).(type) {
+	case *l.LTable:
+		t = v
+	case l.LString:
+		b, err := vfs.ReadFile(string(v))
+		if err != nil {
+			L.RaiseError(err.Error())
+			return 0
+		}
+		fn, err := L.Load(bytes.NewReader(b), "@"+string(v))
+		if err != nil {
+			L.RaiseError(err.Error())
+			return 0
+		}
+		if err := L.CallByParam(l.P{Fn: fn, NRet: 1, Protect: true}); err != nil {
+			L.RaiseError(err.Error())
+			return 0
+		}
+		r, ok := L.Get(-1).(*l.LTable)
+		L.Pop(1)
+		if !ok {
+			L.RaiseError(StyleFileError(string(v)).Error())
+			return 0
+		}
+		t = r
+	default:
+		L.ArgError(1, "style file or table expected")
+		return 0
+	}
+	th, err := toTheme(t)
+	if err != nil {
+		L.RaiseError(err.Error())
+		return 0
+	}
+	CurrentUI.SetTheme(th)
+	return 0
+}
+
+func lFind(L *l.LState) int {
+	return PushWidget(L, CurrentUI.Find(L.CheckString(1)))
+}
+
+// shv.ui.focus(w) focuses a widget, nil for none.
+func lFocus(L *l.LState) int {
+	if L.Get(1) == l.LNil {
+		CurrentUI.SetFocus(nil)
+		return 0
+	}
+	CurrentUI.SetFocus(CheckWidget(L, 1))
+	return 0
+}
+
+func lFocused(L *l.LState) int {
+	return PushWidget(L, CurrentUI.Focus())
+}
+
+var uiFuncs = map[string]l.LGFunction{
+	"build":   lBuild,
+	"style":   lStyle,
+	"find":    lFind,
+	"focus":   lFocus,
+	"focused": lFocused,
+}
+
+type widgetMemberFunc func(*l.LState, *Widget) int
+
+func widgetMember(fn widgetMemberFunc) l.LGFunction {
+	return func(L *l.LState) int {
+		if w := CheckWidget(L, 1); w != nil {
+			return fn(L, w)
+		}
+		return 0
+	}
+}
+
+func widgetProperty(get, set widgetMemberFunc) l.LGFunction {
+	if set == nil {
+		return lua.NewProperty(widgetMember(get), nil)
+	}
+	return lua.NewProperty(widgetMember(get), widgetMember(set))
+}
+
+func getWidgetID(L *l.LState, w *Widget) int {
+	L.Push(l.LString(w.ID))
+	return 1
+}
+
+func setWidgetID(L *l.LState, w *Widget) int {
+	w.ID = L.CheckString(3)
+	return 0
+}
+
+func getWidgetKind(L *l.LState, w *Widget) int {
+	L.Push(l.LString(w.Kind().String()))
+	return 1
+}
+
+func getWidgetClass(L *l.LState, w *Widget) int {
+	L.Push(l.LString(w.Class))
+	return 1
+}
+
+func setWidgetClass(L *l.LState, w *Widget) int {
+	w.Class = L.CheckString(3)
+	w.changed()
+	return 0
+}
+
+func getWidgetText(L *l.LState, w *Widget) int {
+	L.Push(l.LString(w.Text()))
+	return 1
+}
+
+func setWidgetText(L *l.LState, w *Widget) int {
+	w.SetText(L.CheckString(3))
+	return 0
+}
+
+func getWidgetHint(L *l.LState, w *Widget) int {
+	L.Push(l.LString(w.Hint()))
+	return 1
+}
+
+func setWidgetHint(L *l.LState, w *Widget) int {
+	w.SetHint(L.CheckString(3))
+	return 0
+}
+
+func getWidgetValue(L *l.LState, w *Widget) int {
+	L.Push(l.LNumber(w.Value()))
+	return 1
+}
+
+func setWidgetValue(L *l.LState, w *Widget) int {
+	w.SetValue(float32(L.CheckNumber(3)))
+	return 0
+}
+
+func getWidgetMin(L *l.LState, w *Widget) int {
+	lo, _ := w.Range()
+	L.Push(l.LNumber(lo))
+	return 1
+}
+
+func setWidgetMin(L *l.LState, w *Widget) int {
+	_, hi := w.Range()
+	w.SetRange(float32(L.CheckNumber(3)), hi)
+	return 0
+}
+
+func getWidgetMax(L *l.LState, w *Widget) int {
+	_, hi := w.Range()
+	L.Push(l.LNumber(hi))
+	return 1
+}
+
+func setWidgetMax(L *l.LState, w *Widget) int {
+	lo, _ := w.Range()
+	w.SetRange(lo, float32(L.CheckNumber(3)))
+	return 0
+}
+
+func getWidgetChecked(L *l.LState, w *Widget) int {
+	L.Push(l.LBool(w.Checked()))
+	return 1
+}
+
+func setWidgetChecked(L *l.LState, w *Widget) int {
+	w.SetChecked(L.ToBool(3))
+	return 0
+}
+
+func getWidgetItems(L *l.LState, w *Widget) int {
+	t := L.NewTable()
+	for _, it := range w.Items() {
+		t.Append(l.LString(it))
+	}
+	L.Push(t)
+	return 1
+}
+
+func setWidgetItems(L *l.LState, w *Widget) int {
+	w.SetItems(tStrings(L.CheckTable(3)))
+	return 0
+}
+
+// selected is 1 based in Lua, nil for none.
+func getWidgetSelected(L *l.LState, w *Widget) int {
+	L.Push(toLValue(w.Selected()))
+	return 1
+}
+
+func setWidgetSelected(L *l.LState, w *Widget) int {
+	w.SetSelected(L.OptInt(3, 0) - 1)
+	return 0
+}
+
+func getWidgetVisible(L *l.LState, w *Widget) int {
+	L.Push(l.LBool(!w.Hidden()))
+	return 1
+}
+
+func setWidgetVisible(L *l.LState, w *Widget) int {
+	w.SetHidden(!L.ToBool(3))
+	return 0
+}
+
+func getWidgetEnabled(L *l.LState, w *Widget) int {
+	L.Push(l.LBool(!w.Disabled()))
+	return 1
+}
+
+func setWidgetEnabled(L *l.LState, w *Widget) int {
+	w.SetDisabled(!L.ToBool(3))
+	return 0
+}
+
+// widget:add({type = "button", ...}) builds and adds a child, or adds a
+// widget, returning it.
+func widgetAdd(L *l.LState, w *Widget) int {
+	var c *Widget
+	if t, ok := L.Get(2).(*l.LTable); ok {
+		var err error
+		if c, err = build(L, t); err != nil {
+			L.RaiseError(err.Error())
+			return 0
+		}
+	} else {
+		c = CheckWidget(L, 2)
+	}
+	w.Add(c)
+	return PushWidget(L, c)
+}
+
+func widgetRemove(L *l.LState, w *Widget) int {
+	w.Remove()
+	return 0
+}
+
+func widgetFind(L *l.LState, w *Widget) int {
+	return PushWidget(L, w.Find(L.CheckString(2)))
+}
+
+// widget:on("click", function(w, v) ... end)
+func widgetOn(L *l.LState, w *Widget) int {
+	w.On(L.CheckString(2), handler(L, L.CheckFunction(3)))
+	return 0
+}
+
+func widgetFocus(L *l.LState, w *Widget) int {
+	w.Focus()
+	return 0
+}
+
+// widget:rect() is the x, y, width and height it was last laid out to.
+func widgetRect(L *l.LState, w *Widget) int {
+	r := w.Rect()
+	L.Push(l.LNumber(r.X))
+	L.Push(l.LNumber(r.Y))
+	L.Push(l.LNumber(r.W))
+	L.Push(l.LNumber(r.H))
+	return 4
+}
+
+func widgetChildren(L *l.LState, w *Widget) int {
+	t := L.NewTable()
+	for _, c := range w.Children() {
+		PushWidget(L, c)
+		t.Append(L.Get(-1))
+		L.Pop(1)
+	}
+	L.Push(t)
+	return 1
+}
+
+var widgetTable = &lua.Table{
+	lWidgetClass,
+	nil,
+	[]*lua.LMetaFunc{
+		lua.DefaultIdx("__index"),
+	},
+	map[string]l.LGFunction{
+		"id":       widgetProperty(getWidgetID, setWidgetID),
+		"kind":     widgetProperty(getWidgetKind, nil),
+		"class":    widgetProperty(getWidgetClass, setWidgetClass),
+		"text":     widgetProperty(getWidgetText, setWidgetText),
+		"hint":     widgetProperty(getWidgetHint, setWidgetHint),
+		"value":    widgetProperty(getWidgetValue, setWidgetValue),
+		"min":      widgetProperty(getWidgetMin, setWidgetMin),
+		"max":      widgetProperty(getWidgetMax, setWidgetMax),
+		"checked":  widgetProperty(getWidgetChecked, setWidgetChecked),
+		"items":    widgetProperty(getWidgetItems, setWidgetItems),
+		"selected": widgetProperty(getWidgetSelected, setWidgetSelected),
+		"visible":  widgetProperty(getWidgetVisible, setWidgetVisible),
+		"enabled":  widgetProperty(getWidgetEnabled, setWidgetEnabled),
+	},
+	map[string]l.LGFunction{
+		"add":      widgetMember(widgetAdd),
+		"remove":   widgetMember(widgetRemove),
+		"find":     widgetMember(widgetFind),
+		"on":       widgetMember(widgetOn),
+		"focus":    widgetMember(widgetFocus),
+		"rect":     widgetMember(widgetRect),
+		"children": widgetMember(widgetChildren),
+	},
+}
+
+func RegisterWith() lua.RegisterWith {
+	return func(m lua.Module) error {
+		rmtfn := func(L *l.LState, M lua.Module) {
+			M.Register(L, widgetTable)
+			lua.SetSub(L, M, "ui", uiFuncs)
+		}
+		m.AddMT(rmtfn)
+		return nil
+	}
+}
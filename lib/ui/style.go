@@ -0,0 +1,211 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/text"
+)
+
+// Color is an rgba color, 0 to 1.
+type Color [4]float32
+
+type styleField uint
+
+const (
+	sBackground styleField = 1 << iota
+	sColor
+	sBorder
+	sBorderWidth
+	sPadding
+	sAccent
+	sFont
+	sSize
+)
+
+// Style is how a widget draws. Only fields set by their setters override
+// those of the styles a widget's style is built from.
+type Style struct {
+	Background  Color
+	Color       Color
+	Border      Color
+	BorderWidth float32
+	Padding     float32
+	Accent      Color
+	Font        *text.Font
+	Size        float32
+	set         styleField
+}
+
+func (s *Style) SetBackground(c Color) {
+	s.Background, s.set = c, s.set|sBackground
+}
+
+// SetColor sets the color of text.
+func (s *Style) SetColor(c Color) {
+	s.Color, s.set = c, s.set|sColor
+}
+
+func (s *Style) SetBorder(c Color) {
+	s.Border, s.set = c, s.set|sBorder
+}
+
+func (s *Style) SetBorderWidth(w float32) {
+	s.BorderWidth, s.set = w, s.set|sBorderWidth
+}
+
+func (s *Style) SetPadding(p float32) {
+	s.Padding, s.set = p, s.set|sPadding
+}
+
+// SetAccent sets the color of what shows a value: a tick, the filled part of
+// a slider, a chosen item.
+func (s *Style) SetAccent(c Color) {
+	s.Accent, s.set = c, s.set|sAccent
+}
+
+// SetFont sets the font of text, nil for the built in bitmap font.
+func (s *Style) SetFont(f *text.Font) {
+	s.Font, s.set = f, s.set|sFont
+}
+
+// SetSize sets the pixel size of text.
+func (s *Style) SetSize(px float32) {
+	s.Size, s.set = px, s.set|sSize
+}
+
+// merge overrides the fields o sets.
+func (s *Style) merge(o *Style) {
+	if o == nil {
+		return
+	}
+	if o.set&sBackground != 0 {
+		s.Background = o.Background
+	}
+	if o.set&sColor != 0 {
+		s.Color = o.Color
+	}
+	if o.set&sBorder != 0 {
+		s.Border = o.Border
+	}
+	if o.set&sBorderWidth != 0 {
+		s.BorderWidth = o.BorderWidth
+	}
+	if o.set&sPadding != 0 {
+		s.Padding = o.Padding
+	}
+	if o.set&sAccent != 0 {
+		s.Accent = o.Accent
+	}
+	if o.set&sFont != 0 {
+		s.Font = o.Font
+	}
+	if o.set&sSize != 0 {
+		s.Size = o.Size
+	}
+	s.set |= o.set
+}
+
+// Theme is styles by selector: "default", a kind as "button", a class as
+// ".title", either followed by a state as "button:hover". States are
+// "focus", "hover", "pressed" and "disabled".
+type Theme struct {
+	styles map[string]*Style
+}
+
+func NewTheme() *Theme {
+	return &Theme{styles: make(map[string]*Style)}
+}
+
+// DefaultTheme is the theme widgets draw with until another is set.
+func DefaultTheme() *Theme {
+	t := NewTheme()
+	d := &Style{}
+	d.SetColor(Color{0.92, 0.92, 0.92, 1})
+	d.SetBorder(Color{0.45, 0.45, 0.55, 1})
+	d.SetPadding(6)
+	d.SetAccent(Color{0.3, 0.5, 0.9, 1})
+	d.SetSize(16)
+	t.Set("default", d)
+	frame := func(sel string, bg Color, bw float32) {
+		s := &Style{}
+		s.SetBackground(bg)
+		s.SetBorderWidth(bw)
+		t.Set(sel, s)
+	}
+	frame("panel", Color{0.1, 0.1, 0.13, 0.92}, 1)
+	frame("button", Color{0.22, 0.22, 0.28, 1}, 1)
+	frame("field", Color{0.05, 0.05, 0.07, 1}, 1)
+	frame("slider", Color{0.05, 0.05, 0.07, 1}, 1)
+	frame("checkbox", Color{0.05, 0.05, 0.07, 1}, 0)
+	frame("dropdown", Color{0.22, 0.22, 0.28, 1}, 1)
+	frame("list", Color{0.05, 0.05, 0.07, 1}, 1)
+	frame("scroll", Color{0, 0, 0, 0}, 0)
+	hover := &Style{}
+	hover.SetBackground(Color{0.3, 0.3, 0.4, 1})
+	t.Set("button:hover", hover)
+	t.Set("dropdown:hover", hover)
+	pressed := &Style{}
+	pressed.SetBackground(Color{0.35, 0.45, 0.7, 1})
+	t.Set("button:pressed", pressed)
+	focus := &Style{}
+	focus.SetBorder(Color{0.95, 0.75, 0.3, 1})
+	focus.SetBorderWidth(2)
+	for _, k := range []Kind{BUTTON, FIELD, SLIDER, CHECKBOX, DROPDOWN, LIST} {
+		t.Set(k.String()+":focus", focus)
+	}
+	disabled := &Style{}
+	disabled.SetColor(Color{0.5, 0.5, 0.5, 1})
+	t.Set("default:disabled", disabled)
+	return t
+}
+
+// Set merges a style into that of a selector.
+func (t *Theme) Set(selector string, s *Style) {
+	selector = strings.ToLower(strings.TrimSpace(selector))
+	if h, ok := t.styles[selector]; ok {
+		h.merge(s)
+		return
+	}
+	n := &Style{}
+	n.merge(s)
+	t.styles[selector] = n
+}
+
+// Get is the style set for a selector, nil if none.
+func (t *Theme) Get(selector string) *Style {
+	return t.styles[strings.ToLower(selector)]
+}
+
+// state is what a widget is doing, as style selectors.
+type state struct {
+	focus, hover, pressed, disabled bool
+}
+
+// resolve builds the style of a widget from the default style, its kind
+// and its class, then the same for each state it is in.
+func (t *Theme) resolve(k Kind, class string, st state) Style {
+	var s Style
+	sels := []string{"default", k.String()}
+	if class != "" {
+		sels = append(sels, "."+strings.ToLower(class))
+	}
+	for _, sel := range sels {
+		s.merge(t.styles[sel])
+	}
+	for _, on := range []struct {
+		is   bool
+		name string
+	}{
+		{st.focus, ":focus"},
+		{st.hover, ":hover"},
+		{st.pressed, ":pressed"},
+		{st.disabled, ":disabled"},
+	} {
+		if on.is {
+			for _, sel := range sels {
+				s.merge(t.styles[sel+on.name])
+			}
+		}
+	}
+	return s
+}
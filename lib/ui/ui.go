@@ -0,0 +1,785 @@
+package ui
+
+import (
+	glm "math"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/display"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/geometry"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/material"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/texture"
+	"github.com/Laughs-In-Flowers/shiva/lib/input"
+	"github.com/Laughs-In-Flowers/shiva/lib/math"
+	"github.com/Laughs-In-Flowers/shiva/lib/render"
+	"github.com/Laughs-In-Flowers/shiva/lib/scene"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+var CurrentUI *UI
+
+// nav is a move of focus or what is done to the focused widget, from the
+// keyboard or a gamepad.
+type nav int
+
+const (
+	navUp nav = iota
+	navDown
+	navLeft
+	navRight
+	navNext
+	navPrev
+	navActivate
+	navCancel
+)
+
+// batch draws a run, kept between frames while its shader and texture stay
+// the same.
+type batch struct {
+	shader string
+	tex    texture.Texture
+	m      render.Mesh
+	vbo    *graphics.Buff
+}
+
+// UI is a retained widget tree over the display: widgets at the top of the
+// tree anchored to the display, laid out again when anything changes, drawn
+// after the scene. It is a system updating after input, focus moved and
+// widgets worked from the mouse, keyboard or a gamepad, events handed to
+// their handlers.
+type UI struct {
+	roots   []*Widget
+	theme   *Theme
+	io      *io
+	focus   *Widget
+	hot     *Widget
+	pressed *Widget
+	dirty   bool
+	width   float32
+	height  float32
+	time    float64
+	d       *drawList
+	batches []*batch
+	mv      graphics.Uniform
+	proj    graphics.Uniform
+	err     error
+}
+
+// NewUI returns a UI reading input from the input subscriptions.
+func NewUI() *UI {
+	u := &UI{
+		roots: make([]*Widget, 0),
+		theme: DefaultTheme(),
+		io:    newIO(),
+		d:     &drawList{runs: make([]*run, 0)},
+		mv:    graphics.UniformMatrix4fv("ModelViewMatrix"),
+		proj:  graphics.UniformMatrix4fv("ProjectionMatrix"),
+		dirty: true,
+	}
+	input.KeyInput.Subscribe(u.io)
+	input.CharInput.Subscribe(u.io)
+	input.MouseButtonInput.Subscribe(u.io)
+	input.CursorPositionInput.Subscribe(u.io)
+	input.ScrollInput.Subscribe(u.io)
+	return u
+}
+
+// Attach claims input for the UI and draws it over the current scene.
+func (u *UI) Attach() {
+	input.CurrentInputSystem.Claim(u.Claim)
+	if s := scene.Current(); s != nil {
+		s.AddOverlay(u)
+	}
+}
+
+func (u *UI) Priority() int {
+	return 7
+}
+
+func (u *UI) Remove(uint64) {}
+
+// Add puts widgets at the top of the tree, anchored to the display.
+func (u *UI) Add(ws ...*Widget) {
+	for _, w := range ws {
+		if w.parent != nil {
+			w.parent.remove(w)
+		}
+		u.detach(w)
+		w.attach(u)
+		u.roots = append(u.roots, w)
+	}
+	u.dirty = true
+}
+
+func (u *UI) detach(w *Widget) {
+	for i, r := range u.roots {
+		if r == w {
+			u.roots = append(u.roots[:i], u.roots[i+1:]...)
+			break
+		}
+	}
+	if u.focus != nil && u.within(u.focus, w) {
+		u.SetFocus(nil)
+	}
+	if u.pressed != nil && u.within(u.pressed, w) {
+		u.pressed = nil
+	}
+	if u.hot != nil && u.within(u.hot, w) {
+		u.hot = nil
+	}
+}
+
+// within is whether w is at or under p.
+func (u *UI) within(w, p *Widget) bool {
+	for ; w != nil; w = w.parent {
+		if w == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *UI) Roots() []*Widget {
+	return u.roots
+}
+
+// Find is the widget with an id anywhere in the tree.
+func (u *UI) Find(id string) *Widget {
+	for _, r := range u.roots {
+		if f := r.Find(id); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func (u *UI) Theme() *Theme {
+	return u.theme
+}
+
+func (u *UI) SetTheme(t *Theme) {
+	u.theme = t
+	u.dirty = true
+}
+
+// SetSize sets the size of the display widgets are anchored to, followed
+// each frame when there is a display.
+func (u *UI) SetSize(width, height float32) {
+	if width != u.width || height != u.height {
+		u.width, u.height, u.dirty = width, height, true
+	}
+}
+
+func (u *UI) Focus() *Widget {
+	return u.focus
+}
+
+// SetFocus moves focus to a widget, nil for none, with blur and focus
+// events.
+func (u *UI) SetFocus(w *Widget) {
+	if w == u.focus {
+		return
+	}
+	if o := u.focus; o != nil {
+		o.open = false
+		u.fail(o.Emit(Event{Name: "blur"}))
+	}
+	u.focus = w
+	if w != nil {
+		w.caret = len([]rune(w.text))
+		u.reveal(w)
+		u.fail(w.Emit(Event{Name: "focus"}))
+	}
+}
+
+// fail keeps the first error of a frame.
+func (u *UI) fail(err error) {
+	if err != nil && u.err == nil {
+		u.err = err
+	}
+}
+
+// Claim reports whether the mouse is over a widget that is drawn or held by
+// one, and whether a widget has focus, from the layout of the last frame.
+func (u *UI) Claim() (bool, bool) {
+	return u.hit(u.io.mouse[0], u.io.mouse[1]) != nil || u.pressed != nil, u.focus != nil
+}
+
+// style is how a widget draws as it is now.
+func (u *UI) style(w *Widget) Style {
+	return u.theme.resolve(w.kind, w.Class, state{
+		focus:    w == u.focus,
+		hover:    w == u.hot,
+		pressed:  w == u.pressed && u.io.down,
+		disabled: !w.Enabled(),
+	})
+}
+
+func (u *UI) blink() bool {
+	return int(u.time*2)%2 == 0
+}
+
+// Update works input into the tree and lays it out and draws it again.
+func (u *UI) Update(dt int64) error {
+	u.err = nil
+	u.time += float64(dt) / 1e9
+	u.io.frame()
+	if d, err := display.Current(); err == nil {
+		w, h := d.GetSize()
+		u.SetSize(float32(w), float32(h))
+	}
+	u.layout()
+	u.mouse()
+	u.keys()
+	u.layout()
+	u.d.reset()
+	popups := make([]*Widget, 0)
+	for _, r := range u.roots {
+		u.draw(r, &popups)
+	}
+	for _, p := range popups {
+		u.drawPopup(p)
+	}
+	return u.err
+}
+
+func (u *UI) layout() {
+	if !u.dirty {
+		return
+	}
+	screen := Rect{0, 0, u.width, u.height}
+	for _, r := range u.roots {
+		if r.hidden {
+			continue
+		}
+		u.measure(r)
+		u.arrange(r, place(r, u.width, u.height), screen)
+	}
+	u.dirty = false
+}
+
+// hit is the deepest widget drawn at a point, the front most where they
+// overlap, skipping panels drawing nothing there.
+func (u *UI) hit(x, y float32) *Widget {
+	if f := u.focus; f != nil && f.kind == DROPDOWN && f.open && u.popup(f).Contains(x, y) {
+		return f
+	}
+	for i := len(u.roots) - 1; i >= 0; i-- {
+		if w := u.hitIn(u.roots[i], x, y); w != nil {
+			return w
+		}
+	}
+	return nil
+}
+
+func (u *UI) hitIn(w *Widget, x, y float32) *Widget {
+	if w.hidden || !w.clip.Contains(x, y) {
+		return nil
+	}
+	for i := len(w.children) - 1; i >= 0; i-- {
+		if h := u.hitIn(w.children[i], x, y); h != nil {
+			return h
+		}
+	}
+	if w.kind == PANEL && u.style(w).Background[3] <= 0 {
+		return nil
+	}
+	return w
+}
+
+// interactive is the widget at or over w that takes the mouse.
+func (u *UI) interactive(w *Widget) *Widget {
+	for ; w != nil; w = w.parent {
+		if w.kind.Focusable() && w.Enabled() {
+			return w
+		}
+	}
+	return nil
+}
+
+// scrollable is the list or scroll view at or over w.
+func (u *UI) scrollable(w *Widget) *Widget {
+	for ; w != nil; w = w.parent {
+		if w.kind == LIST || w.kind == SCROLL {
+			return w
+		}
+	}
+	return nil
+}
+
+func (u *UI) mouse() {
+	o := u.io
+	mx, my := o.mouse[0], o.mouse[1]
+	h := u.hit(mx, my)
+	u.hot = u.interactive(h)
+	for _, r := range u.roots {
+		r.Walk(func(w *Widget) bool {
+			w.hover = -1
+			return true
+		})
+	}
+	if f := u.focus; f != nil && f.kind == DROPDOWN && f.open {
+		if i := u.row(f, u.popup(f), my); i >= 0 && u.popup(f).Contains(mx, my) {
+			f.hover = i
+		}
+	}
+	if l := u.hot; l != nil && l.kind == LIST {
+		l.hover = u.row(l, l.rect, my+l.scroll)
+	}
+	if o.wheel != 0 {
+		if s := u.scrollable(h); s != nil {
+			st := u.style(s)
+			s.scroll -= o.wheel * u.lineHeight(&st) * 2
+			u.dirty = true
+		}
+	}
+	if o.pressed {
+		u.press(h, mx, my)
+	}
+	if p := u.pressed; p != nil && o.down && p.kind == SLIDER {
+		u.drag(p, mx)
+	}
+	if o.released {
+		if p := u.pressed; p != nil && p == u.hot {
+			u.click(p)
+		}
+		u.pressed = nil
+	}
+}
+
+// row is the index of the item of a list or popup at y, -1 for none.
+func (u *UI) row(w *Widget, r Rect, y float32) int {
+	s := u.style(w)
+	p := u.padding(w, &s)
+	i := int(glm.Floor(float64((y - r.Y - p) / u.lineHeight(&s))))
+	if i < 0 || i >= len(w.items) {
+		return -1
+	}
+	return i
+}
+
+func (u *UI) press(h *Widget, mx, my float32) {
+	if f := u.focus; f != nil && f.kind == DROPDOWN && f.open {
+		if pr := u.popup(f); pr.Contains(mx, my) {
+			if i := u.row(f, pr, my); i >= 0 {
+				u.choose(f, i)
+			}
+			f.open = false
+			return
+		}
+		if h != f {
+			f.open = false
+		}
+	}
+	w := u.interactive(h)
+	u.pressed = w
+	u.SetFocus(w)
+	if w == nil {
+		return
+	}
+	switch w.kind {
+	case SLIDER:
+		u.drag(w, mx)
+	case LIST:
+		if i := u.row(w, w.rect, my+w.scroll); i >= 0 {
+			u.choose(w, i)
+		}
+	}
+}
+
+func (u *UI) drag(w *Widget, mx float32) {
+	s := u.style(w)
+	p := u.padding(w, &s)
+	track := w.rect.W - 2*p
+	if track <= 0 {
+		return
+	}
+	t := clamp32((mx-w.rect.X-p)/track, 0, 1)
+	u.setValue(w, w.min+t*(w.max-w.min))
+}
+
+func (u *UI) setValue(w *Widget, v float32) {
+	old := w.value
+	w.SetValue(v)
+	if w.value != old {
+		u.fail(w.Emit(Event{Name: "change", Value: w.value}))
+	}
+}
+
+// choose selects an item of a list or dropdown.
+func (u *UI) choose(w *Widget, i int) {
+	if i == w.selected {
+		return
+	}
+	w.SetSelected(i)
+	u.fail(w.Emit(Event{Name: "change", Value: i}))
+}
+
+// click is the mouse released over the widget it was pressed on, or the
+// widget activated from the keyboard or a gamepad.
+func (u *UI) click(w *Widget) {
+	switch w.kind {
+	case BUTTON:
+		u.fail(w.Emit(Event{Name: "click"}))
+	case CHECKBOX:
+		w.checked = !w.checked
+		u.fail(w.Emit(Event{Name: "change", Value: w.checked}))
+	case DROPDOWN:
+		w.open = !w.open
+		w.hover = w.selected
+	case LIST:
+		if w.selected >= 0 {
+			u.fail(w.Emit(Event{Name: "select", Value: w.selected}))
+		}
+	}
+}
+
+func (u *UI) keys() {
+	navs := make([]nav, 0)
+	for _, e := range u.io.typed {
+		if f := u.focus; f != nil && f.kind == FIELD && u.edit(f, e) {
+			continue
+		}
+		if e.r != 0 {
+			continue
+		}
+		switch e.k {
+		case glfw.KeyUp:
+			navs = append(navs, navUp)
+		case glfw.KeyDown:
+			navs = append(navs, navDown)
+		case glfw.KeyLeft:
+			navs = append(navs, navLeft)
+		case glfw.KeyRight:
+			navs = append(navs, navRight)
+		case glfw.KeyTab:
+			if e.mods&glfw.ModShift != 0 {
+				navs = append(navs, navPrev)
+			} else {
+				navs = append(navs, navNext)
+			}
+		case glfw.KeyEnter, glfw.KeyKPEnter, glfw.KeySpace:
+			navs = append(navs, navActivate)
+		case glfw.KeyEscape:
+			navs = append(navs, navCancel)
+		}
+	}
+	if p := input.CurrentGamepads.Active(); p != nil {
+		for _, b := range []struct {
+			b input.PadButton
+			n nav
+		}{
+			{input.PadDpadUp, navUp},
+			{input.PadDpadDown, navDown},
+			{input.PadDpadLeft, navLeft},
+			{input.PadDpadRight, navRight},
+			{input.PadA, navActivate},
+			{input.PadB, navCancel},
+		} {
+			if p.Pressed(b.b) {
+				navs = append(navs, b.n)
+			}
+		}
+	}
+	for _, n := range navs {
+		u.navigate(n)
+	}
+}
+
+// edit applies a character or key to a focused field, reporting whether it
+// was used.
+func (u *UI) edit(w *Widget, e edit) bool {
+	t := []rune(w.text)
+	if w.caret > len(t) {
+		w.caret = len(t)
+	}
+	set := func(nt []rune) {
+		w.text = string(nt)
+		u.dirty = true
+		u.fail(w.Emit(Event{Name: "change", Value: w.text}))
+	}
+	if e.r != 0 {
+		nt := append(append(append([]rune(nil), t[:w.caret]...), e.r), t[w.caret:]...)
+		w.caret++
+		set(nt)
+		return true
+	}
+	switch e.k {
+	case glfw.KeyBackspace:
+		if w.caret > 0 {
+			nt := append(append([]rune(nil), t[:w.caret-1]...), t[w.caret:]...)
+			w.caret--
+			set(nt)
+		}
+	case glfw.KeyDelete:
+		if w.caret < len(t) {
+			set(append(append([]rune(nil), t[:w.caret]...), t[w.caret+1:]...))
+		}
+	case glfw.KeyLeft:
+		if w.caret > 0 {
+			w.caret--
+		}
+	case glfw.KeyRight:
+		if w.caret < len(t) {
+			w.caret++
+		}
+	case glfw.KeyHome:
+		w.caret = 0
+	case glfw.KeyEnd:
+		w.caret = len(t)
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		u.fail(w.Emit(Event{Name: "submit", Value: w.text}))
+	case glfw.KeySpace:
+		// typed as a character
+	default:
+		return false
+	}
+	return true
+}
+
+func (u *UI) navigate(n nav) {
+	f := u.focus
+	if f == nil {
+		switch n {
+		case navCancel:
+			for _, r := range u.roots {
+				if !r.hidden {
+					u.fail(r.Emit(Event{Name: "cancel"}))
+				}
+			}
+		case navActivate:
+		default:
+			if c := u.focusables(); len(c) > 0 {
+				u.SetFocus(c[0])
+			}
+		}
+		return
+	}
+	if f.kind == DROPDOWN && f.open {
+		switch n {
+		case navUp:
+			f.hover = maxInt(0, f.hover-1)
+		case navDown:
+			f.hover = minInt(len(f.items)-1, f.hover+1)
+		case navActivate:
+			if f.hover >= 0 {
+				u.choose(f, f.hover)
+			}
+			f.open = false
+		case navCancel:
+			f.open = false
+		}
+		return
+	}
+	switch {
+	case f.kind == SLIDER && (n == navLeft || n == navRight):
+		step := f.step * (f.max - f.min)
+		if n == navLeft {
+			step = -step
+		}
+		u.setValue(f, f.value+step)
+		return
+	case f.kind == LIST && (n == navUp || n == navDown) && len(f.items) > 0:
+		i := f.selected
+		if n == navUp && i > 0 {
+			u.choose(f, i-1)
+			u.revealRow(f)
+			return
+		}
+		if n == navDown && i < len(f.items)-1 {
+			u.choose(f, i+1)
+			u.revealRow(f)
+			return
+		}
+	}
+	switch n {
+	case navActivate:
+		if f.Enabled() {
+			u.click(f)
+		}
+	case navCancel:
+		root := f
+		for root.parent != nil {
+			root = root.parent
+		}
+		u.fail(root.Emit(Event{Name: "cancel"}))
+	case navNext, navPrev:
+		c := u.focusables()
+		for i, w := range c {
+			if w == f {
+				d := 1
+				if n == navPrev {
+					d = len(c) - 1
+				}
+				u.SetFocus(c[(i+d)%len(c)])
+				return
+			}
+		}
+		if len(c) > 0 {
+			u.SetFocus(c[0])
+		}
+	default:
+		if w := u.nearest(f, n); w != nil {
+			u.SetFocus(w)
+		}
+	}
+}
+
+// focusables are the widgets taking focus that are shown and enabled, in
+// tree order.
+func (u *UI) focusables() []*Widget {
+	ret := make([]*Widget, 0)
+	for _, r := range u.roots {
+		r.Walk(func(w *Widget) bool {
+			if w.hidden {
+				return false
+			}
+			if w.kind.Focusable() && w.Enabled() {
+				ret = append(ret, w)
+			}
+			return true
+		})
+	}
+	return ret
+}
+
+// nearest is the widget taking focus closest from w in a direction, those
+// straight ahead preferred over those to the side.
+func (u *UI) nearest(w *Widget, n nav) *Widget {
+	fx, fy := w.rect.center()
+	var best *Widget
+	bestScore := float32(glm.MaxFloat32)
+	for _, c := range u.focusables() {
+		if c == w {
+			continue
+		}
+		cx, cy := c.rect.center()
+		dx, dy := cx-fx, cy-fy
+		var ahead, side float32
+		switch n {
+		case navUp:
+			ahead, side = -dy, dx
+		case navDown:
+			ahead, side = dy, dx
+		case navLeft:
+			ahead, side = -dx, dy
+		case navRight:
+			ahead, side = dx, dy
+		}
+		if ahead <= 0 {
+			continue
+		}
+		if side < 0 {
+			side = -side
+		}
+		if score := ahead + 2*side; score < bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
+
+// reveal scrolls any scroll views a widget is in to show it.
+func (u *UI) reveal(w *Widget) {
+	for p := w.parent; p != nil; p = p.parent {
+		if p.kind != SCROLL {
+			continue
+		}
+		s := u.style(p)
+		pad := u.padding(p, &s)
+		top, bottom := p.rect.Y+pad, p.rect.Y+p.rect.H-pad
+		switch {
+		case w.rect.Y < top:
+			p.scroll -= top - w.rect.Y
+			u.dirty = true
+		case w.rect.Y+w.rect.H > bottom:
+			p.scroll += w.rect.Y + w.rect.H - bottom
+			u.dirty = true
+		}
+	}
+}
+
+// revealRow scrolls a list to show its selected item.
+func (u *UI) revealRow(w *Widget) {
+	s := u.style(w)
+	p := u.padding(w, &s)
+	lh := u.lineHeight(&s)
+	view := w.rect.H - 2*p
+	y := float32(w.selected) * lh
+	switch {
+	case y < w.scroll:
+		w.scroll = y
+	case y+lh > w.scroll+view:
+		w.scroll = y + lh - view
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Overlay draws the widgets over a display sized width by height in screen
+// coordinates.
+func (u *UI) Overlay(r render.Renderer, width, height int) {
+	if u.d.n == 0 {
+		return
+	}
+	u.mv.Update(math.IdentityMatrix(math.MAT4).Raw()...)
+	u.proj.Update(math.Mat4().Orthographic(0, float32(width), 0, float32(height), -1, 1).Raw()...)
+	for i := 0; i < u.d.n; i++ {
+		rn := u.d.runs[i]
+		b := u.batch(i, rn)
+		b.vbo.SetBuffer(rn.verts)
+		for _, m := range b.m.Materials() {
+			m.Render(r)
+		}
+	}
+}
+
+// batch is the batch drawing the run at i, made again when the run's shader
+// or texture changed.
+func (u *UI) batch(i int, rn *run) *batch {
+	for len(u.batches) <= i {
+		u.batches = append(u.batches, nil)
+	}
+	if b := u.batches[i]; b != nil && b.shader == rn.shader && b.tex == rn.tex {
+		return b
+	}
+	if b := u.batches[i]; b != nil {
+		// textures are shared, so only the geometry goes
+		b.m.Geometry().Close()
+	}
+	b := &batch{shader: rn.shader, tex: rn.tex}
+	b.vbo = graphics.NewBuff().
+		AddAttrib("VertexPosition", 3).
+		AddAttrib("VertexTexcoord", 2).
+		AddAttrib("GlyphColor", 4)
+	b.vbo.SetUsage(graphics.DYNAMIC_DRAW)
+	g := geometry.New()
+	g.AddVBO(b.vbo)
+	b.m = render.NewMesh("ui", g, u.provide, graphics.TRIANGLES)
+	mat := material.New()
+	mat.SetShader(rn.shader)
+	mat.SetIndependent(true)
+	mat.SetUseLights(material.ULNone)
+	mat.SetSide(material.SIDouble)
+	mat.SetDepthTest(false)
+	mat.SetDepthMask(false)
+	mat.SetBlending(material.BLNormal)
+	mat.AddTexture(rn.tex)
+	b.m.AddMaterial(mat, 0, 0)
+	u.batches[i] = b
+	return b
+}
+
+func (u *UI) provide(r render.Renderer) {
+	u.mv.Transfer(r)
+	u.proj.Transfer(r)
+}
+
+func init() {
+	CurrentUI = NewUI()
+}
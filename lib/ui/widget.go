@@ -0,0 +1,430 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/texture"
+)
+
+// Kind is what a widget is, deciding how it measures, draws and takes input.
+type Kind int
+
+const (
+	PANEL Kind = iota
+	LABEL
+	BUTTON
+	IMAGE
+	LIST
+	SCROLL
+	FIELD
+	SLIDER
+	CHECKBOX
+	DROPDOWN
+)
+
+var kindNames = []string{
+	"panel",
+	"label",
+	"button",
+	"image",
+	"list",
+	"scroll",
+	"field",
+	"slider",
+	"checkbox",
+	"dropdown",
+}
+
+func (k Kind) String() string {
+	if k >= 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "UNKNOWN_KIND"
+}
+
+func StringToKind(s string) (Kind, bool) {
+	s = strings.ToLower(s)
+	for i, n := range kindNames {
+		if n == s {
+			return Kind(i), true
+		}
+	}
+	return PANEL, false
+}
+
+// Focusable is whether widgets of a kind take focus, to be navigated to and
+// activated from the keyboard or a gamepad.
+func (k Kind) Focusable() bool {
+	switch k {
+	case BUTTON, LIST, FIELD, SLIDER, CHECKBOX, DROPDOWN:
+		return true
+	}
+	return false
+}
+
+// Rect is a rectangle in window coordinates, y down.
+type Rect struct {
+	X, Y, W, H float32
+}
+
+func (r Rect) Contains(x, y float32) bool {
+	return x >= r.X && y >= r.Y && x < r.X+r.W && y < r.Y+r.H
+}
+
+// Intersect is the part of r inside o, empty if none.
+func (r Rect) Intersect(o Rect) Rect {
+	x0, y0 := max32(r.X, o.X), max32(r.Y, o.Y)
+	x1, y1 := min32(r.X+r.W, o.X+o.W), min32(r.Y+r.H, o.Y+o.H)
+	if x1 <= x0 || y1 <= y0 {
+		return Rect{x0, y0, 0, 0}
+	}
+	return Rect{x0, y0, x1 - x0, y1 - y0}
+}
+
+func (r Rect) Empty() bool {
+	return r.W <= 0 || r.H <= 0
+}
+
+func (r Rect) center() (float32, float32) {
+	return r.X + r.W/2, r.Y + r.H/2
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func clamp32(v, lo, hi float32) float32 {
+	return max32(lo, min32(hi, v))
+}
+
+// Event is something happening to a widget: "click", "change", "submit",
+// "select", "focus", "blur" or "cancel", with the value it happened with.
+type Event struct {
+	Name  string
+	Value interface{}
+}
+
+// Handler is called with the widget an event happened to.
+type Handler func(*Widget, Event) error
+
+// Widget is a node of the widget tree, kept between frames and laid out
+// again whenever it or the display changes.
+type Widget struct {
+	ID       string
+	Class    string
+	kind     Kind
+	Layout   Layout
+	text     string
+	hint     string
+	value    float32
+	min, max float32
+	step     float32
+	checked  bool
+	items    []string
+	selected int
+	image    *texture.Data
+	imageW   int
+	imageH   int
+	parent   *Widget
+	children []*Widget
+	hidden   bool
+	disabled bool
+	handlers map[string][]Handler
+	ui       *UI
+
+	pref    [2]float32
+	rect    Rect
+	clip    Rect
+	scroll  float32
+	content float32
+	open    bool
+	caret   int
+	hover   int
+}
+
+// New returns a widget of a kind, laid out by defaults until set.
+func New(k Kind) *Widget {
+	w := &Widget{
+		kind:     k,
+		Layout:   DefaultLayout(),
+		max:      1,
+		selected: -1,
+		children: make([]*Widget, 0),
+		handlers: make(map[string][]Handler),
+		hover:    -1,
+	}
+	switch k {
+	case PANEL, LIST, SCROLL:
+		w.Layout.Direction = COLUMN
+	case SLIDER:
+		w.step = 0.05
+	}
+	return w
+}
+
+func (w *Widget) Kind() Kind {
+	return w.kind
+}
+
+func (w *Widget) changed() {
+	if w.ui != nil {
+		w.ui.dirty = true
+	}
+}
+
+func (w *Widget) Text() string {
+	return w.text
+}
+
+func (w *Widget) SetText(s string) {
+	w.text = s
+	if w.caret > len([]rune(s)) {
+		w.caret = len([]rune(s))
+	}
+	w.changed()
+}
+
+// Hint is the text a field shows while empty.
+func (w *Widget) Hint() string {
+	return w.hint
+}
+
+func (w *Widget) SetHint(s string) {
+	w.hint = s
+	w.changed()
+}
+
+func (w *Widget) Value() float32 {
+	return w.value
+}
+
+// SetValue sets the value of a slider, kept within its range.
+func (w *Widget) SetValue(v float32) {
+	w.value = clamp32(v, min32(w.min, w.max), max32(w.min, w.max))
+}
+
+func (w *Widget) Range() (float32, float32) {
+	return w.min, w.max
+}
+
+func (w *Widget) SetRange(lo, hi float32) {
+	w.min, w.max = lo, hi
+	w.SetValue(w.value)
+}
+
+// Step is how far a slider moves a key or button press, as a fraction of
+// its range.
+func (w *Widget) Step() float32 {
+	return w.step
+}
+
+func (w *Widget) SetStep(s float32) {
+	w.step = s
+}
+
+func (w *Widget) Checked() bool {
+	return w.checked
+}
+
+func (w *Widget) SetChecked(c bool) {
+	w.checked = c
+}
+
+// Items are the choices of a list or dropdown.
+func (w *Widget) Items() []string {
+	return w.items
+}
+
+func (w *Widget) SetItems(items []string) {
+	w.items = items
+	if w.selected >= len(items) {
+		w.selected = len(items) - 1
+	}
+	w.changed()
+}
+
+// Selected is the index of the chosen item, -1 for none.
+func (w *Widget) Selected() int {
+	return w.selected
+}
+
+func (w *Widget) SetSelected(i int) {
+	if i < -1 || i >= len(w.items) {
+		i = -1
+	}
+	w.selected = i
+	w.changed()
+}
+
+// SetImage sets the texture an image draws, sized w by h texels.
+func (w *Widget) SetImage(t *texture.Data, width, height int) {
+	w.image, w.imageW, w.imageH = t, width, height
+	w.changed()
+}
+
+func (w *Widget) Hidden() bool {
+	return w.hidden
+}
+
+func (w *Widget) SetHidden(h bool) {
+	w.hidden = h
+	w.changed()
+}
+
+func (w *Widget) Disabled() bool {
+	return w.disabled
+}
+
+func (w *Widget) SetDisabled(d bool) {
+	w.disabled = d
+}
+
+// SetLayout replaces how the widget is laid out.
+func (w *Widget) SetLayout(l Layout) {
+	w.Layout = l
+	w.changed()
+}
+
+// Rect is where the widget was last laid out.
+func (w *Widget) Rect() Rect {
+	return w.rect
+}
+
+func (w *Widget) Parent() *Widget {
+	return w.parent
+}
+
+func (w *Widget) Children() []*Widget {
+	return w.children
+}
+
+// Add appends children, taking them from any parent they had.
+func (w *Widget) Add(cs ...*Widget) {
+	for _, c := range cs {
+		if c.parent != nil {
+			c.parent.remove(c)
+		}
+		c.parent = w
+		c.attach(w.ui)
+		w.children = append(w.children, c)
+	}
+	w.changed()
+}
+
+func (w *Widget) remove(c *Widget) {
+	for i, h := range w.children {
+		if h == c {
+			w.children = append(w.children[:i], w.children[i+1:]...)
+			break
+		}
+	}
+	c.parent = nil
+}
+
+// Remove takes the widget out of the tree.
+func (w *Widget) Remove() {
+	u := w.ui
+	if w.parent != nil {
+		w.parent.remove(w)
+	}
+	if u != nil {
+		u.detach(w)
+	}
+	w.attach(nil)
+	if u != nil {
+		u.dirty = true
+	}
+}
+
+func (w *Widget) attach(u *UI) {
+	w.ui = u
+	for _, c := range w.children {
+		c.attach(u)
+	}
+}
+
+// Find is the widget with an id at or under this one.
+func (w *Widget) Find(id string) *Widget {
+	if w.ID == id {
+		return w
+	}
+	for _, c := range w.children {
+		if f := c.Find(id); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// Walk visits the widget and those under it, depth first, until fn returns
+// false.
+func (w *Widget) Walk(fn func(*Widget) bool) bool {
+	if !fn(w) {
+		return false
+	}
+	for _, c := range w.children {
+		if !c.Walk(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Visible is whether neither the widget nor any parent is hidden.
+func (w *Widget) Visible() bool {
+	for p := w; p != nil; p = p.parent {
+		if p.hidden {
+			return false
+		}
+	}
+	return true
+}
+
+// Enabled is whether neither the widget nor any parent is disabled.
+func (w *Widget) Enabled() bool {
+	for p := w; p != nil; p = p.parent {
+		if p.disabled {
+			return false
+		}
+	}
+	return true
+}
+
+// On adds a handler for an event.
+func (w *Widget) On(event string, h Handler) {
+	w.handlers[event] = append(w.handlers[event], h)
+}
+
+// Emit calls the handlers of an event, the first error returned after all
+// have run.
+func (w *Widget) Emit(e Event) error {
+	var err error
+	for _, h := range w.handlers[e.Name] {
+		if herr := h(w, e); herr != nil && err == nil {
+			err = herr
+		}
+	}
+	return err
+}
+
+// Focused is whether the widget has focus.
+func (w *Widget) Focused() bool {
+	return w.ui != nil && w.ui.focus == w
+}
+
+// Focus gives the widget focus.
+func (w *Widget) Focus() {
+	if w.ui != nil {
+		w.ui.SetFocus(w)
+	}
+}
@@ -0,0 +1,86 @@
+package vfs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type dir struct {
+	root string
+}
+
+// Dir is a directory of the OS file system as a source, names leaving it
+// not found.
+func Dir(root string) Source {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &dir{root}
+}
+
+func (d *dir) String() string {
+	return d.root
+}
+
+// path is the OS path of a name, false for one outside the directory.
+func (d *dir) path(name string) (string, bool) {
+	name = Clean(name)
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return "", false
+	}
+	return filepath.Join(d.root, filepath.FromSlash(name)), true
+}
+
+func (d *dir) Open(name string) (File, error) {
+	p, ok := d.path(name)
+	if !ok {
+		return nil, NotFoundError(name)
+	}
+	return os.Open(p)
+}
+
+func (d *dir) Has(name string) bool {
+	p, ok := d.path(name)
+	if !ok {
+		return false
+	}
+	fi, err := os.Stat(p)
+	return err == nil && !fi.IsDir()
+}
+
+func (d *dir) ModTime(name string) (time.Time, bool) {
+	p, ok := d.path(name)
+	if !ok {
+		return time.Time{}, false
+	}
+	fi, err := os.Stat(p)
+	if err != nil || fi.IsDir() {
+		return time.Time{}, false
+	}
+	return fi.ModTime(), true
+}
+
+func (d *dir) Names() ([]string, error) {
+	ret := make([]string, 0)
+	err := filepath.Walk(d.root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		r, err := filepath.Rel(d.root, p)
+		if err != nil {
+			return err
+		}
+		ret = append(ret, filepath.ToSlash(r))
+		return nil
+	})
+	return ret, err
+}
+
+func (d *dir) Close() error {
+	return nil
+}
@@ -0,0 +1,56 @@
+package vfs
+
+// Assets are files embedded in the binary by go-bindata, as the bindataFS
+// of generated code.
+type Assets interface {
+	Asset(name string) ([]byte, error)
+	AssetNames() []string
+}
+
+type embedded struct {
+	name  string
+	a     Assets
+	names map[string]string
+}
+
+// Embedded is assets embedded in the binary as a source.
+func Embedded(name string, a Assets) Source {
+	e := &embedded{name: name, a: a, names: make(map[string]string)}
+	for _, n := range a.AssetNames() {
+		e.names[Clean(n)] = n
+	}
+	return e
+}
+
+func (e *embedded) String() string {
+	return e.name
+}
+
+func (e *embedded) Open(name string) (File, error) {
+	n, ok := e.names[name]
+	if !ok {
+		return nil, NotFoundError(name)
+	}
+	b, err := e.a.Asset(n)
+	if err != nil {
+		return nil, err
+	}
+	return newMemFile(b), nil
+}
+
+func (e *embedded) Has(name string) bool {
+	_, ok := e.names[name]
+	return ok
+}
+
+func (e *embedded) Names() ([]string, error) {
+	ret := make([]string, 0, len(e.names))
+	for n := range e.names {
+		ret = append(ret, n)
+	}
+	return ret, nil
+}
+
+func (e *embedded) Close() error {
+	return nil
+}
@@ -0,0 +1,288 @@
+package vfs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// A pack is a single file of assets: a header, each file deflated, then an
+// index naming where each is, its sizes and the CRC-32 of its bytes. The
+// header holds where the index is and its own CRC-32.
+//
+//	magic    [8]byte "SHVPACK" and the version
+//	count    uint32
+//	index    uint64
+//	indexCRC uint32
+//
+// and for each file, in the index,
+//
+//	name   uint16 length, then the name
+//	offset uint64
+//	packed uint64
+//	size   uint64
+//	crc    uint32
+//
+// all little endian.
+const packVersion = 1
+
+var packMagic = [8]byte{'S', 'H', 'V', 'P', 'A', 'C', 'K', packVersion}
+
+const packHeaderSize = 8 + 4 + 8 + 4
+
+var (
+	NotPackError      = xrror.Xrror("%s is not a version %d pack").Out
+	CorruptPackError  = xrror.Xrror("pack %s is corrupt: %s").Out
+	ChecksumError     = xrror.Xrror("%s in pack %s fails its checksum").Out
+	PackNameSizeError = xrror.Xrror("%s is too long a name for a pack").Out
+)
+
+type packEntry struct {
+	name   string
+	offset uint64
+	packed uint64
+	size   uint64
+	crc    uint32
+}
+
+// PackFilter decides whether a name goes into a pack.
+type PackFilter func(name string) bool
+
+// WritePack writes the files of a source a filter passes, every file if
+// nil, to a pack, returning the names written.
+func WritePack(path string, s Source, filter PackFilter) ([]string, error) {
+	names, err := s.Names()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Write(make([]byte, packHeaderSize)); err != nil {
+		return nil, err
+	}
+	w := &countWriter{w: bufio.NewWriter(f), n: packHeaderSize}
+	entries := make([]*packEntry, 0, len(names))
+	for _, n := range names {
+		if filter != nil && !filter(n) {
+			continue
+		}
+		if len(n) > 0xffff {
+			return nil, PackNameSizeError(n)
+		}
+		e, err := packFile(w, s, n)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	index := new(bytes.Buffer)
+	for _, e := range entries {
+		binary.Write(index, binary.LittleEndian, uint16(len(e.name)))
+		index.WriteString(e.name)
+		binary.Write(index, binary.LittleEndian, []uint64{e.offset, e.packed, e.size})
+		binary.Write(index, binary.LittleEndian, e.crc)
+	}
+	at := w.n
+	if _, err := w.Write(index.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := w.w.Flush(); err != nil {
+		return nil, err
+	}
+	header := new(bytes.Buffer)
+	header.Write(packMagic[:])
+	binary.Write(header, binary.LittleEndian, uint32(len(entries)))
+	binary.Write(header, binary.LittleEndian, at)
+	binary.Write(header, binary.LittleEndian, crc32.ChecksumIEEE(index.Bytes()))
+	if _, err := f.WriteAt(header.Bytes(), 0); err != nil {
+		return nil, err
+	}
+	written := make([]string, len(entries))
+	for i, e := range entries {
+		written[i] = e.name
+	}
+	return written, f.Close()
+}
+
+func packFile(w *countWriter, s Source, name string) (*packEntry, error) {
+	in, err := s.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	e := &packEntry{name: name, offset: w.n}
+	sum := crc32.NewIEEE()
+	fw, err := flate.NewWriter(w, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	size, err := io.Copy(io.MultiWriter(fw, sum), in)
+	if err != nil {
+		return nil, err
+	}
+	if err := fw.Close(); err != nil {
+		return nil, err
+	}
+	e.packed, e.size, e.crc = w.n-e.offset, uint64(size), sum.Sum32()
+	return e, nil
+}
+
+// countWriter counts the bytes written through it.
+type countWriter struct {
+	w *bufio.Writer
+	n uint64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += uint64(n)
+	return n, err
+}
+
+type pack struct {
+	path    string
+	f       *os.File
+	entries map[string]*packEntry
+}
+
+// OpenPack is a pack as a source, each file checked against its checksum
+// as it is read.
+func OpenPack(path string) (Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := readPack(path, f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func readPack(path string, f *os.File) (*pack, error) {
+	header := make([]byte, packHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return nil, NotPackError(path, packVersion)
+	}
+	var magic [8]byte
+	copy(magic[:], header)
+	if magic != packMagic {
+		return nil, NotPackError(path, packVersion)
+	}
+	le := binary.LittleEndian
+	count := le.Uint32(header[8:])
+	at := le.Uint64(header[12:])
+	crc := le.Uint32(header[20:])
+	if _, err := f.Seek(int64(at), io.SeekStart); err != nil {
+		return nil, CorruptPackError(path, err)
+	}
+	index, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, CorruptPackError(path, err)
+	}
+	if crc32.ChecksumIEEE(index) != crc {
+		return nil, CorruptPackError(path, "index checksum")
+	}
+	p := &pack{path: path, f: f, entries: make(map[string]*packEntry, count)}
+	for i := uint32(0); i < count; i++ {
+		if len(index) < 2 {
+			return nil, CorruptPackError(path, "short index")
+		}
+		n := int(le.Uint16(index))
+		if len(index) < 2+n+28 {
+			return nil, CorruptPackError(path, "short index")
+		}
+		r := index[2+n:]
+		e := &packEntry{
+			name:   string(index[2 : 2+n]),
+			offset: le.Uint64(r),
+			packed: le.Uint64(r[8:]),
+			size:   le.Uint64(r[16:]),
+			crc:    le.Uint32(r[24:]),
+		}
+		if e.offset+e.packed > at {
+			return nil, CorruptPackError(path, e.name)
+		}
+		p.entries[e.name] = e
+		index = r[28:]
+	}
+	return p, nil
+}
+
+func (p *pack) String() string {
+	return p.path
+}
+
+func (p *pack) Open(name string) (File, error) {
+	e, ok := p.entries[name]
+	if !ok {
+		return nil, NotFoundError(name)
+	}
+	b, err := p.read(e)
+	if err != nil {
+		return nil, err
+	}
+	return newMemFile(b), nil
+}
+
+func (p *pack) read(e *packEntry) ([]byte, error) {
+	fr := flate.NewReader(io.NewSectionReader(p.f, int64(e.offset), int64(e.packed)))
+	defer fr.Close()
+	b := make([]byte, e.size)
+	if _, err := io.ReadFull(fr, b); err != nil {
+		return nil, CorruptPackError(p.path, err)
+	}
+	if crc32.ChecksumIEEE(b) != e.crc {
+		return nil, ChecksumError(e.name, p.path)
+	}
+	return b, nil
+}
+
+func (p *pack) Has(name string) bool {
+	_, ok := p.entries[name]
+	return ok
+}
+
+func (p *pack) Names() ([]string, error) {
+	ret := make([]string, 0, len(p.entries))
+	for n := range p.entries {
+		ret = append(ret, n)
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+func (p *pack) Close() error {
+	return p.f.Close()
+}
+
+// VerifyPack reads every file of a pack, returning the first failing its
+// checksum.
+func VerifyPack(path string) error {
+	s, err := OpenPack(path)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	p := s.(*pack)
+	names, _ := p.Names()
+	for _, n := range names {
+		if _, err := p.read(p.entries[n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
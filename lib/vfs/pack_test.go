@@ -0,0 +1,113 @@
+package vfs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPackRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"main.lua":          "print('main')",
+		"lib/util.lua":      strings.Repeat("local x = 1\n", 500),
+		"textures/a.png":    "\x89PNG\r\n\x1a\n\x00\x01\x02\xff",
+		"empty.txt":         "",
+		"skipped/notes.txt": "left out",
+	}
+	writeFiles(t, src, files)
+	out := filepath.Join(t.TempDir(), "game.pack")
+	written, err := WritePack(out, Dir(src), func(name string) bool {
+		return !strings.HasPrefix(name, "skipped/")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != len(files)-1 {
+		t.Fatalf("wrote %v", written)
+	}
+	if err := VerifyPack(out); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := OpenPack(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	names, _ := p.Names()
+	if strings.Join(names, ",") != "empty.txt,lib/util.lua,main.lua,textures/a.png" {
+		t.Errorf("names %v", names)
+	}
+	for n, c := range files {
+		if strings.HasPrefix(n, "skipped/") {
+			if p.Has(n) {
+				t.Errorf("filtered %s packed", n)
+			}
+			continue
+		}
+		f, err := p.Open(n)
+		if err != nil {
+			t.Fatalf("%s: %v", n, err)
+		}
+		b, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil || string(b) != c {
+			t.Errorf("%s: read %q, %v", n, b, err)
+		}
+	}
+
+	fs := New()
+	fs.Mount("", p, 10)
+	if b, err := fs.ReadFile("/lib/../main.lua"); err != nil || string(b) != files["main.lua"] {
+		t.Errorf("main.lua through the fs: %q, %v", b, err)
+	}
+}
+
+func TestPackCorrupt(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{"a.txt": strings.Repeat("abc", 100)})
+	out := filepath.Join(t.TempDir(), "a.pack")
+	if _, err := WritePack(out, Dir(src), nil); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bad := append([]byte(nil), b...)
+	bad[7]++
+	if err := ioutil.WriteFile(out, bad, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenPack(out); err == nil || !strings.Contains(err.Error(), "is not a version") {
+		t.Errorf("other version opened: %v", err)
+	}
+
+	// the last byte is of the index, the first after the header of a.txt
+	for _, at := range []int{len(b) - 1, packHeaderSize} {
+		bad := append([]byte(nil), b...)
+		bad[at] ^= 0xff
+		if err := ioutil.WriteFile(out, bad, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifyPack(out); err == nil {
+			t.Errorf("byte %d corrupted unnoticed", at)
+		}
+	}
+
+	if err := ioutil.WriteFile(out, bytes.Repeat([]byte{0}, 4), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenPack(out); err == nil {
+		t.Error("short pack opened")
+	}
+	os.Remove(out)
+	if _, err := OpenPack(out); err == nil {
+		t.Error("missing pack opened")
+	}
+}
@@ -0,0 +1,275 @@
+package vfs
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Laughs-In-Flowers/shiva/lib/xrror"
+)
+
+// File is an open file of a source, seekable for decoders reading around
+// in it.
+type File interface {
+	io.Reader
+	io.Seeker
+	io.Closer
+}
+
+// Source is somewhere files are read from: a directory, a zip archive, a
+// pack or assets embedded in the binary. Names are slash separated and
+// relative to the source.
+type Source interface {
+	String() string
+	Open(name string) (File, error)
+	Has(name string) bool
+	Names() ([]string, error)
+	Close() error
+}
+
+// Modified is a source telling when its files last changed, a directory of
+// the OS file system; those that do not never change.
+type Modified interface {
+	ModTime(name string) (time.Time, bool)
+}
+
+var (
+	NotFoundError   = xrror.Xrror("%s not found in any mounted source").Out
+	NotMountedError = xrror.Xrror("nothing mounted at %s").Out
+)
+
+type mount struct {
+	at       string
+	src      Source
+	priority int
+	order    int
+}
+
+// FS layers mounted sources, a name read from the source of highest
+// priority having it, those of the same priority in the order mounted. It
+// is safe to read from while mounts change on another goroutine.
+type FS struct {
+	mu     sync.RWMutex
+	mounts []*mount
+	n      int
+}
+
+func New() *FS {
+	return &FS{mounts: make([]*mount, 0)}
+}
+
+// Clean is the form names are looked up in: slash separated, without dot
+// elements and relative to the top of the file system, "/a" being "a".
+func Clean(name string) string {
+	name = path.Clean(filepath.ToSlash(name))
+	if name == "." {
+		return ""
+	}
+	return strings.TrimPrefix(name, "/")
+}
+
+// Mount adds a source at a directory of the file system, "" for the top,
+// read before those of lower priority.
+func (fs *FS) Mount(at string, s Source, priority int) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.n++
+	fs.mounts = append(fs.mounts, &mount{Clean(at), s, priority, fs.n})
+	sort.SliceStable(fs.mounts, func(i, j int) bool {
+		a, b := fs.mounts[i], fs.mounts[j]
+		if a.priority != b.priority {
+			return a.priority > b.priority
+		}
+		return a.order < b.order
+	})
+}
+
+// Unmount removes and closes the sources mounted at a directory.
+func (fs *FS) Unmount(at string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	at = Clean(at)
+	kept := fs.mounts[:0]
+	var err error
+	found := false
+	for _, m := range fs.mounts {
+		if m.at != at {
+			kept = append(kept, m)
+			continue
+		}
+		found = true
+		if cerr := m.src.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	fs.mounts = kept
+	if !found {
+		return NotMountedError(at)
+	}
+	return err
+}
+
+// Sources are the mounted sources, in the order names are looked up.
+func (fs *FS) Sources() []Source {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	ret := make([]Source, len(fs.mounts))
+	for i, m := range fs.mounts {
+		ret[i] = m.src
+	}
+	return ret
+}
+
+// rel is a name relative to where a source is mounted, false if it is not
+// under it.
+func (m *mount) rel(name string) (string, bool) {
+	if m.at == "" {
+		return name, true
+	}
+	if name == m.at {
+		return "", true
+	}
+	if strings.HasPrefix(name, m.at+"/") {
+		return name[len(m.at)+1:], true
+	}
+	return "", false
+}
+
+// find is the mount a name is read from and the name in its source, for
+// callers holding the read lock.
+func (fs *FS) find(name string) (*mount, string) {
+	name = Clean(name)
+	for _, m := range fs.mounts {
+		if r, ok := m.rel(name); ok && m.src.Has(r) {
+			return m, r
+		}
+	}
+	return nil, ""
+}
+
+// Open opens a file from the first source having it.
+func (fs *FS) Open(name string) (File, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	m, r := fs.find(name)
+	if m == nil {
+		return nil, NotFoundError(name)
+	}
+	return m.src.Open(r)
+}
+
+func (fs *FS) ReadFile(name string) ([]byte, error) {
+	f, err := fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+func (fs *FS) Exists(name string) bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	m, _ := fs.find(name)
+	return m != nil
+}
+
+// Where is the source a name is read from.
+func (fs *FS) Where(name string) (Source, bool) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if m, _ := fs.find(name); m != nil {
+		return m.src, true
+	}
+	return nil, false
+}
+
+// ModTime is when a name, as read, last changed, false if it is not found
+// or its source cannot tell.
+func (fs *FS) ModTime(name string) (time.Time, bool) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	m, r := fs.find(name)
+	if m == nil {
+		return time.Time{}, false
+	}
+	if md, ok := m.src.(Modified); ok {
+		return md.ModTime(r)
+	}
+	return time.Time{}, false
+}
+
+// Names are every name of every source, as mounted, once each, sorted.
+func (fs *FS) Names() ([]string, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	seen := make(map[string]bool)
+	ret := make([]string, 0)
+	for _, m := range fs.mounts {
+		ns, err := m.src.Names()
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range ns {
+			n = path.Join(m.at, n)
+			if !seen[n] {
+				seen[n] = true
+				ret = append(ret, n)
+			}
+		}
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+// Close closes and removes every source.
+func (fs *FS) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	var err error
+	for _, m := range fs.mounts {
+		if cerr := m.src.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	fs.mounts = fs.mounts[:0]
+	return err
+}
+
+// memFile is a file read from memory.
+type memFile struct {
+	*bytes.Reader
+}
+
+func (memFile) Close() error {
+	return nil
+}
+
+func newMemFile(b []byte) File {
+	return memFile{bytes.NewReader(b)}
+}
+
+// CurrentFS is what every loader of the engine reads through.
+var CurrentFS *FS = New()
+
+func Mount(at string, s Source, priority int) {
+	CurrentFS.Mount(at, s, priority)
+}
+
+func Open(name string) (File, error) {
+	return CurrentFS.Open(name)
+}
+
+func ReadFile(name string) ([]byte, error) {
+	return CurrentFS.ReadFile(name)
+}
+
+func Exists(name string) bool {
+	return CurrentFS.Exists(name)
+}
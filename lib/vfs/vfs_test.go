@@ -0,0 +1,139 @@
+package vfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles writes files of names to contents under a directory.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for n, c := range files {
+		p := filepath.Join(dir, filepath.FromSlash(n))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(c), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestClean(t *testing.T) {
+	cases := []struct {
+		name, want string
+	}{
+		{"", ""},
+		{".", ""},
+		{"/", ""},
+		{"a.lua", "a.lua"},
+		{"./a.lua", "a.lua"},
+		{"/a.lua", "a.lua"},
+		{"//a//b.lua", "a/b.lua"},
+		{"/assets/a/../y.lua", "assets/y.lua"},
+		{"a/./b/", "a/b"},
+		{"../a.lua", "../a.lua"},
+		{"a/../../b", "../b"},
+		{"/../a.lua", "a.lua"},
+	}
+	for _, c := range cases {
+		if got := Clean(c.name); got != c.want {
+			t.Errorf("Clean(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestDirOutside(t *testing.T) {
+	top := t.TempDir()
+	writeFiles(t, top, map[string]string{
+		"game/a.lua": "a",
+		"secret.txt": "secret",
+	})
+	d := Dir(filepath.Join(top, "game"))
+	for _, n := range []string{"a.lua", "/a.lua", "./a.lua", "b/../a.lua"} {
+		if !d.Has(n) {
+			t.Errorf("%q not found", n)
+		}
+	}
+	fs := New()
+	fs.Mount("", d, 0)
+	for _, n := range []string{
+		"../secret.txt",
+		"a/../../secret.txt",
+		"../../../../../../../../" + filepath.ToSlash(filepath.Join(top, "secret.txt")),
+		filepath.Join(top, "secret.txt"),
+	} {
+		if d.Has(n) {
+			t.Errorf("%q found outside the directory", n)
+		}
+		if f, err := d.Open(n); err == nil {
+			f.Close()
+			t.Errorf("%q opened outside the directory", n)
+		}
+		if b, err := fs.ReadFile(n); err == nil {
+			t.Errorf("%q read %q outside the directory", n, b)
+		}
+	}
+}
+
+func TestMounts(t *testing.T) {
+	low, high, assets := t.TempDir(), t.TempDir(), t.TempDir()
+	writeFiles(t, low, map[string]string{"x.lua": "low x", "only.lua": "low only"})
+	writeFiles(t, high, map[string]string{"x.lua": "high x"})
+	writeFiles(t, assets, map[string]string{"y.lua": "assets y", "a/z.lua": "assets z"})
+	fs := New()
+	lowSrc, highSrc := Dir(low), Dir(high)
+	fs.Mount("", lowSrc, 0)
+	fs.Mount("", highSrc, 5)
+	fs.Mount("/assets/", Dir(assets), 0)
+
+	cases := []struct {
+		name, want string
+	}{
+		{"x.lua", "high x"},
+		{"only.lua", "low only"},
+		{"assets/y.lua", "assets y"},
+		{"/assets/y.lua", "assets y"},
+		{"/assets/a/../y.lua", "assets y"},
+		{"./assets/a/z.lua", "assets z"},
+	}
+	for _, c := range cases {
+		b, err := fs.ReadFile(c.name)
+		if err != nil || string(b) != c.want {
+			t.Errorf("%q read %q, %v, want %q", c.name, b, err, c.want)
+		}
+	}
+	if fs.Exists("y.lua") || fs.Exists("assets") || fs.Exists("assetsy.lua") {
+		t.Error("name outside its mount found")
+	}
+	if s, ok := fs.Where("x.lua"); !ok || s != highSrc {
+		t.Errorf("x.lua from %v", s)
+	}
+	names, err := fs.Names()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"assets/a/z.lua", "assets/y.lua", "only.lua", "x.lua"}
+	if len(names) != len(want) {
+		t.Fatalf("names %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("names %v, want %v", names, want)
+		}
+	}
+
+	if err := fs.Unmount("/assets"); err != nil {
+		t.Fatal(err)
+	}
+	if fs.Exists("assets/y.lua") {
+		t.Error("unmounted source read")
+	}
+	if err := fs.Unmount("assets"); err == nil {
+		t.Error("unmounted twice")
+	}
+	if _, err := fs.Open("missing.lua"); err == nil {
+		t.Error("missing file opened")
+	}
+}
@@ -0,0 +1,158 @@
+package vfs
+
+import (
+	"sort"
+	"time"
+)
+
+type watcher struct {
+	fn func(string)
+}
+
+type watched struct {
+	mod   time.Time
+	found bool
+	ws    []*watcher
+}
+
+// Watcher polls names of a file system for changes, calling what watches
+// a name when the file it is read from changes. Only files of sources
+// telling when they changed, directories, are ever seen to. Every method is
+// for the main thread.
+type Watcher struct {
+	fs       *FS
+	names    map[string]*watched
+	interval time.Duration
+	last     time.Time
+	on       bool
+}
+
+// NewWatcher returns a watcher of a file system polling, once enabled, at
+// most every interval.
+func NewWatcher(fs *FS, interval time.Duration) *Watcher {
+	return &Watcher{
+		fs:       fs,
+		names:    make(map[string]*watched),
+		interval: interval,
+	}
+}
+
+// Watch calls fn with a name whenever its file changes, until the function
+// returned is called.
+func (w *Watcher) Watch(name string, fn func(string)) func() {
+	name = Clean(name)
+	n, ok := w.names[name]
+	if !ok {
+		n = &watched{}
+		n.mod, n.found = w.fs.ModTime(name)
+		w.names[name] = n
+	}
+	wr := &watcher{fn}
+	n.ws = append(n.ws, wr)
+	return func() { w.stop(name, wr) }
+}
+
+func (w *Watcher) stop(name string, wr *watcher) {
+	n, ok := w.names[name]
+	if !ok {
+		return
+	}
+	for i, o := range n.ws {
+		if o == wr {
+			n.ws = append(n.ws[:i], n.ws[i+1:]...)
+			break
+		}
+	}
+	if len(n.ws) == 0 {
+		delete(w.names, name)
+	}
+}
+
+// Unwatch stops everything watching a name.
+func (w *Watcher) Unwatch(name string) {
+	delete(w.names, Clean(name))
+}
+
+func (w *Watcher) Watching(name string) bool {
+	_, ok := w.names[Clean(name)]
+	return ok
+}
+
+// Names are the names watched, sorted.
+func (w *Watcher) Names() []string {
+	ret := make([]string, 0, len(w.names))
+	for n := range w.names {
+		ret = append(ret, n)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (w *Watcher) Enabled() bool {
+	return w.on
+}
+
+// SetEnabled starts or stops polling on update.
+func (w *Watcher) SetEnabled(on bool) {
+	w.on = on
+}
+
+func (w *Watcher) SetInterval(d time.Duration) {
+	w.interval = d
+}
+
+// Poll checks every name watched now, calling what watches those changed,
+// returning how many. A file removed is a change once it is back.
+func (w *Watcher) Poll() int {
+	changed := make([]string, 0)
+	for name, n := range w.names {
+		mod, found := w.fs.ModTime(name)
+		if found == n.found && mod.Equal(n.mod) {
+			continue
+		}
+		n.mod, n.found = mod, found
+		if found {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	for _, name := range changed {
+		// unwatched by an earlier callback
+		n, ok := w.names[name]
+		if !ok {
+			continue
+		}
+		for _, wr := range append([]*watcher(nil), n.ws...) {
+			wr.fn(name)
+		}
+	}
+	return len(changed)
+}
+
+// Priority, Update and Remove make the watcher a system, polling once its
+// interval has passed while enabled.
+func (w *Watcher) Priority() int {
+	return 2
+}
+
+func (w *Watcher) Update(int64) error {
+	if !w.on {
+		return nil
+	}
+	now := time.Now()
+	if now.Sub(w.last) < w.interval {
+		return nil
+	}
+	w.last = now
+	w.Poll()
+	return nil
+}
+
+func (w *Watcher) Remove(uint64) {}
+
+// CurrentWatcher watches CurrentFS for everything reloading what changes.
+var CurrentWatcher *Watcher = NewWatcher(CurrentFS, 500*time.Millisecond)
+
+func Watch(name string, fn func(string)) func() {
+	return CurrentWatcher.Watch(name, fn)
+}
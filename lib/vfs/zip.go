@@ -0,0 +1,66 @@
+package vfs
+
+import (
+	"archive/zip"
+	"io/ioutil"
+)
+
+type zipSource struct {
+	path  string
+	r     *zip.ReadCloser
+	files map[string]*zip.File
+}
+
+// Zip is a zip archive as a source.
+func Zip(path string) (Source, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	z := &zipSource{path: path, r: r, files: make(map[string]*zip.File)}
+	for _, f := range r.File {
+		if !f.FileInfo().IsDir() {
+			z.files[Clean(f.Name)] = f
+		}
+	}
+	return z, nil
+}
+
+func (z *zipSource) String() string {
+	return z.path
+}
+
+// Open reads the whole file, zip entries not being seekable.
+func (z *zipSource) Open(name string) (File, error) {
+	f, ok := z.files[name]
+	if !ok {
+		return nil, NotFoundError(name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	return newMemFile(b), nil
+}
+
+func (z *zipSource) Has(name string) bool {
+	_, ok := z.files[name]
+	return ok
+}
+
+func (z *zipSource) Names() ([]string, error) {
+	ret := make([]string, 0, len(z.files))
+	for n := range z.files {
+		ret = append(ret, n)
+	}
+	return ret, nil
+}
+
+func (z *zipSource) Close() error {
+	return z.r.Close()
+}
@@ -6,28 +6,50 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/Laughs-In-Flowers/flip"
 	"github.com/Laughs-In-Flowers/log"
+	"github.com/Laughs-In-Flowers/shiva/lib/audio"
 	"github.com/Laughs-In-Flowers/shiva/lib/engine"
 	"github.com/Laughs-In-Flowers/shiva/lib/graphics"
+	"github.com/Laughs-In-Flowers/shiva/lib/graphics/shader"
+	"github.com/Laughs-In-Flowers/shiva/lib/vfs"
 
 	// initialize & register providers with graphics package
 	_ "github.com/Laughs-In-Flowers/shiva/lib/graphics/providers"
+	// initialize & register sinks with audio package
+	_ "github.com/Laughs-In-Flowers/shiva/lib/audio/sinks"
 )
 
 type Options struct {
 	debug     bool
 	formatter string
 	provider  string
+	audio     string
 	file      string
+	record    string
+	replay    string
+	packs     string
+	watch     string
+	source    string
+	out       string
+	exclude   string
+	verify    bool
+	validator string
+	cache     string
 }
 
 func defaultOptions() *Options {
 	wd, _ := os.Getwd()
 	defaultProvider := graphics.DefaultProvider.String()
+	cache, err := os.UserCacheDir()
+	if err == nil {
+		cache = filepath.Join(cache, "shiva", "shaders")
+	}
 	return &Options{
-		false, "null", defaultProvider, filepath.Join(wd, "main.lua"),
+		false, "null", defaultProvider, "", filepath.Join(wd, "main.lua"), "", "", "", "", wd, "assets.pack", "", false, "glslangValidator", cache,
 	}
 }
 
@@ -36,6 +58,7 @@ func tFlags(fs *flip.FlagSet, o *Options) *flip.FlagSet {
 	fs.StringVar(&o.file, "file", o.file, "The main lua file argument to pass to the engine.")
 	fs.StringVar(&o.formatter, "formatter", o.formatter, "Specify the log formatter.")
 	fs.StringVar(&o.provider, "provider", o.provider, "String tag to specify the graphics provder.")
+	fs.StringVar(&o.audio, "audio", o.audio, "Specify the audio sink: device, null or wav:<file>. Defaults to "+audio.DefaultSink.String()+", or null without one.")
 	return fs
 }
 
@@ -94,12 +117,42 @@ func basicErr(e error) {
 	}
 }
 
+func audioSink(s string) (string, string) {
+	spl := strings.SplitN(s, ":", 2)
+	if len(spl) == 2 {
+		return spl[0], spl[1]
+	}
+	return s, ""
+}
+
 func newEngine(o *Options) *engine.Engine {
 	configuration := []engine.Config{
 		engine.SetLogger(o.formatter),
 		engine.SetGraphics(o.provider),
 		engine.SetLua(o.file),
 	}
+	if o.audio != "" {
+		configuration = append(configuration, engine.SetAudio(audioSink(o.audio)))
+	}
+	if o.record != "" {
+		configuration = append(configuration, engine.SetRecord(o.record))
+	}
+	if o.replay != "" {
+		configuration = append(configuration, engine.SetReplay(o.replay))
+	}
+	if o.packs != "" {
+		configuration = append(configuration, engine.SetPacks(strings.Split(o.packs, ",")...))
+	}
+	if o.cache != "" {
+		configuration = append(configuration, engine.SetShaderCache(o.cache))
+	}
+	if o.watch != "" {
+		d, err := time.ParseDuration(o.watch)
+		if err != nil {
+			basicErr(err)
+		}
+		configuration = append(configuration, engine.SetWatch(d))
+	}
 	v, err := engine.New(o.debug, configuration...)
 	if err != nil {
 		basicErr(err)
@@ -107,7 +160,18 @@ func newEngine(o *Options) *engine.Engine {
 	return v
 }
 
+func pFlags(fs *flip.FlagSet, o *Options) *flip.FlagSet {
+	fs.StringVar(&o.record, "record", o.record, "Record input to a file for replaying.")
+	fs.StringVar(&o.replay, "replay", o.replay, "Replay input recorded to a file.")
+	fs.StringVar(&o.packs, "packs", o.packs, "Comma separated asset packs to read before loose files, the last read first.")
+	fs.StringVar(&o.cache, "shadercache", o.cache, "The directory to keep compiled shader programs in, none if empty.")
+	fs.StringVar(&o.watch, "watch", o.watch, "Reload changed textures, models and shader templates, polling at an interval such as 500ms.")
+	return fs
+}
+
 func playCommand(o *Options) flip.Command {
+	fs := flip.NewFlagSet("play", flip.ContinueOnError)
+	fs = pFlags(fs, o)
 	return flip.NewCommand(
 		"",
 		"play",
@@ -119,7 +183,116 @@ func playCommand(o *Options) flip.Command {
 			e.Run()
 			return c, flip.ExitSuccess
 		},
-		flip.NewFlagSet("play", flip.ContinueOnError),
+		fs,
+	)
+}
+
+func kFlags(fs *flip.FlagSet, o *Options) *flip.FlagSet {
+	fs.StringVar(&o.source, "source", o.source, "The directory to pack.")
+	fs.StringVar(&o.out, "out", o.out, "The pack file to write, or to verify with -verify.")
+	fs.StringVar(&o.exclude, "exclude", o.exclude, "Comma separated patterns of names to leave out, as 'saves/*' or '*.psd'.")
+	fs.BoolVar(&o.verify, "verify", o.verify, "Verify the checksums of the pack file instead of writing it.")
+	return fs
+}
+
+// excluded is whether a name matches a pattern, or its base name does.
+func excluded(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if p == "" {
+			continue
+		}
+		if m, _ := path.Match(p, name); m {
+			return true
+		}
+		if m, _ := path.Match(p, path.Base(name)); m {
+			return true
+		}
+	}
+	return false
+}
+
+func pack(o *Options) error {
+	if o.verify {
+		return vfs.VerifyPack(o.out)
+	}
+	out, err := filepath.Abs(o.out)
+	if err != nil {
+		return err
+	}
+	src := vfs.Dir(o.source)
+	patterns := strings.Split(o.exclude, ",")
+	names, err := vfs.WritePack(out, src, func(name string) bool {
+		return filepath.Join(src.String(), filepath.FromSlash(name)) != out && !excluded(patterns, name)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("packed %d files from %s into %s\n", len(names), src, out)
+	return nil
+}
+
+func packCommand(o *Options) flip.Command {
+	fs := flip.NewFlagSet("pack", flip.ContinueOnError)
+	fs = kFlags(fs, o)
+	return flip.NewCommand(
+		"",
+		"pack",
+		"shiva pack: write a directory of assets to a single compressed pack with checksums",
+		1,
+		false,
+		func(c context.Context, a []string) (context.Context, flip.ExitStatus) {
+			if err := pack(o); err != nil {
+				basicErr(err)
+			}
+			return c, flip.ExitSuccess
+		},
+		fs,
+	)
+}
+
+func sFlags(fs *flip.FlagSet, o *Options) *flip.FlagSet {
+	fs.StringVar(&o.source, "source", o.source, "The directory whose shaders directory holds shader templates and libraries.")
+	fs.StringVar(&o.validator, "validator", o.validator, "The glslangValidator to check shaders with, the built in syntax check when not found.")
+	return fs
+}
+
+// checkShaders renders every profile of every built in program, validating
+// each shader, returning whether all are valid.
+func checkShaders(o *Options) bool {
+	vfs.Mount("", vfs.Dir(o.source), 0)
+	v, err := shader.Glslang(o.validator)
+	if err != nil {
+		fmt.Printf("%s not found, checking syntax only\n", o.validator)
+		v = shader.Syntax
+	}
+	problems, n := shader.DefaultShaderer().Check(v)
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	fmt.Printf("checked %d shaders, %d problems\n", n, len(problems))
+	return len(problems) == 0
+}
+
+func shadersCommand(o *Options) flip.Command {
+	fs := flip.NewFlagSet("shaders", flip.ContinueOnError)
+	fs = sFlags(fs, o)
+	return flip.NewCommand(
+		"",
+		"shaders",
+		"shiva shaders check: render every shader permutation and validate it without a GPU",
+		1,
+		false,
+		func(c context.Context, a []string) (context.Context, flip.ExitStatus) {
+			if len(a) < 1 || a[len(a)-1] != "check" {
+				fmt.Println("usage: shiva shaders check")
+				return c, flip.ExitUsageError
+			}
+			if !checkShaders(o) {
+				return c, flip.ExitFailure
+			}
+			return c, flip.ExitSuccess
+		},
+		fs,
 	)
 }
 
@@ -143,7 +316,9 @@ func init() {
 	F.AddCommand("version", versionPackage, versionTag, versionHash, versionDate).
 		AddCommand("help").
 		SetGroup("top", -1, topCommand(options)).
-		SetGroup("play", 1, playCommand(options))
+		SetGroup("play", 1, playCommand(options)).
+		SetGroup("pack", 1, packCommand(options)).
+		SetGroup("shaders", 1, shadersCommand(options))
 }
 
 func main() {